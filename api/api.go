@@ -0,0 +1,317 @@
+package api
+
+import (
+	"io"
+	"unicode"
+
+	"github.com/bantling/goparse/internal/parser"
+)
+
+// Grammar model
+type (
+	// Grammar is a list of rules
+	Grammar = parser.Grammar
+	// Rule is a named expression
+	Rule = parser.Rule
+	// Expression is the alternatives of a rule
+	Expression = parser.Expression
+	// ExpressionItem is an alternative of an expression, which repeats a list of items
+	ExpressionItem = parser.ExpressionItem
+	// ListItem is a rule name or terminal of an alternative
+	ListItem = parser.ListItem
+	// Terminal is a string or character range
+	Terminal = parser.Terminal
+	// RuneSet is the set of runes of a character range
+	RuneSet = parser.RuneSet
+	// RuneInterval is the runes from Lo through Hi inclusive
+	RuneInterval = parser.RuneInterval
+	// Option is an option of a rule or list item, such as :MEMO
+	Option = parser.Option
+	// SourceNode is the source that a part of a grammar was read from
+	SourceNode = parser.SourceNode
+	// SourcePosition is a position in grammar source
+	SourcePosition = parser.SourcePosition
+	// RuleLimits are the resource limits of a rule
+	RuleLimits = parser.RuleLimits
+)
+
+// Option constants
+const (
+	OptionAST         = parser.OptionAST
+	OptionEOL         = parser.OptionEOL
+	OptionIndent      = parser.OptionIndent
+	OptionOutdent     = parser.OptionOutdent
+	OptionPreEOL      = parser.OptionPreEOL
+	OptionPreIndent   = parser.OptionPreIndent
+	OptionPreOutdent  = parser.OptionPreOutdent
+	OptionMemo        = parser.OptionMemo
+	OptionNoMemo      = parser.OptionNoMemo
+	OptionIndependent = parser.OptionIndependent
+	OptionConstTime   = parser.OptionConstTime
+	OptionKeyword     = parser.OptionKeyword
+)
+
+// Compiling grammars
+type (
+	// Dialect is a notation of grammar source
+	Dialect = parser.Dialect
+	// CompileOptions are the options of Compile
+	CompileOptions = parser.CompileOptions
+	// KeywordPolicy chooses how keywords are disambiguated from the other token rules that match them
+	KeywordPolicy = parser.KeywordPolicy
+	// Diagnostic is a problem with a grammar
+	Diagnostic = parser.Diagnostic
+	// Diagnostics are several errors, such as every diagnostic of a grammar
+	Diagnostics = parser.Diagnostics
+	// XRef is the cross-reference index of a grammar returned by Grammar.XRef
+	XRef = parser.XRef
+	// RuleXRef is the sites that refer to a rule
+	RuleXRef = parser.RuleXRef
+	// TerminalXRef is the sites that use a terminal
+	TerminalXRef = parser.TerminalXRef
+	// XRefSite is where a rule refers to a rule or uses a terminal
+	XRefSite = parser.XRefSite
+)
+
+// Dialect constants
+const (
+	DialectGoparse = parser.DialectGoparse
+	DialectISO     = parser.DialectISO
+	DialectW3C     = parser.DialectW3C
+	DialectABNF    = parser.DialectABNF
+	DialectANTLR   = parser.DialectANTLR
+)
+
+// KeywordPolicy constants
+const (
+	KeywordsNone  = parser.KeywordsNone
+	KeywordsExact = parser.KeywordsExact
+)
+
+// Parsing input
+type (
+	// Engine parses input according to a grammar
+	Engine = parser.Engine
+	// ParseOptions are the options of an Engine
+	ParseOptions = parser.ParseOptions
+	// ParseContext is passed to actions and predicates
+	ParseContext = parser.ParseContext
+	// Action is called for each node of a rule after a successful parse
+	Action = parser.Action
+	// Predicate can reject a match of a rule while parsing
+	Predicate = parser.Predicate
+	// Node is a node of a parse tree
+	Node = parser.Node
+	// ParseError is an error where the input does not match the grammar
+	ParseError = parser.ParseError
+	// LimitError is an error where a rule exceeds one of its limits
+	LimitError = parser.LimitError
+	// LineIndex finds the lines and columns of offsets in parsed input
+	LineIndex = parser.LineIndex
+	// Position is a position in parsed input
+	Position = parser.Position
+	// PositionOptions are the conventions of positions in parsed input
+	PositionOptions = parser.PositionOptions
+	// IndentOptions are the options of indentation sensitive parsing
+	IndentOptions = parser.IndentOptions
+	// EOLOptions are the options of matching the end of a line
+	EOLOptions = parser.EOLOptions
+	// Locale is how numbers and dates are written in a region
+	Locale = parser.Locale
+	// InputEncoding is the encoding of parsed input
+	InputEncoding = parser.InputEncoding
+	// Visitor is called by Walk for each node of a tree
+	Visitor = parser.Visitor
+	// VisitorFuncs is a Visitor of functions
+	VisitorFuncs = parser.VisitorFuncs
+	// RuleVisitor is a Visitor of functions for each rule
+	RuleVisitor = parser.RuleVisitor
+)
+
+// InputEncoding constants
+const (
+	EncodingUTF8    = parser.EncodingUTF8
+	EncodingDetect  = parser.EncodingDetect
+	EncodingUTF16LE = parser.EncodingUTF16LE
+	EncodingUTF16BE = parser.EncodingUTF16BE
+)
+
+// ErrParseFailed is the beginning of the message of a ParseError
+const ErrParseFailed = parser.ErrParseFailed
+
+// OfTerminalString constructs a Terminal from a string
+func OfTerminalString(sourceString, terminalString string) Terminal {
+	return parser.OfTerminalString(sourceString, terminalString)
+}
+
+// OfTerminalRange constructs a Terminal from a range of the runes that map to true
+func OfTerminalRange(sourceString string, theRange map[rune]bool) Terminal {
+	return parser.OfTerminalRange(sourceString, theRange)
+}
+
+// OfTerminalRuneSet constructs a Terminal from a range of a set of runes
+func OfTerminalRuneSet(sourceString string, theRange RuneSet) Terminal {
+	return parser.OfTerminalRuneSet(sourceString, theRange)
+}
+
+// OfRuneSet constructs a RuneSet of the runes of intervals, which can be in any order and overlap
+func OfRuneSet(intervals ...RuneInterval) RuneSet {
+	return parser.OfRuneSet(intervals...)
+}
+
+// OfRuneSetRunes constructs a RuneSet of runes
+func OfRuneSetRunes(runes ...rune) RuneSet {
+	return parser.OfRuneSetRunes(runes...)
+}
+
+// OfRuneSetRangeTable constructs a RuneSet of the runes of unicode range tables, such as unicode.Letter
+func OfRuneSetRangeTable(tables ...*unicode.RangeTable) RuneSet {
+	return parser.OfRuneSetRangeTable(tables...)
+}
+
+// OfListItemRuleName constructs a ListItem from a rule name and options
+func OfListItemRuleName(sourceString string, ruleName string, options []Option) ListItem {
+	return parser.OfListItemRuleName(sourceString, ruleName, options)
+}
+
+// OfListItemTerminal constructs a ListItem from a terminal and options
+func OfListItemTerminal(sourceString string, terminal Terminal, options []Option) ListItem {
+	return parser.OfListItemTerminal(sourceString, terminal, options)
+}
+
+// OfExpressionItem constructs an ExpressionItem from a list of ListItem and n, m repetitions
+func OfExpressionItem(sourceString string, list []ListItem, n, m int) ExpressionItem {
+	return parser.OfExpressionItem(sourceString, list, n, m)
+}
+
+// OfExpression constructs a Expression from a list of expression items
+func OfExpression(sourceString string, items []ExpressionItem) Expression {
+	return parser.OfExpression(sourceString, items)
+}
+
+// OfRule constructs a rule from a name and expression
+func OfRule(sourceString string, name string, expr Expression) Rule {
+	return parser.OfRule(sourceString, name, expr)
+}
+
+// OfRuleOptions constructs a rule from a name, options, and expression
+func OfRuleOptions(sourceString string, name string, options []Option, expr Expression) Rule {
+	return parser.OfRuleOptions(sourceString, name, options, expr)
+}
+
+// OfGrammar constructs a Grammar from a list of rules
+func OfGrammar(sourceString string, rules []Rule) Grammar {
+	return parser.OfGrammar(sourceString, rules)
+}
+
+// Compile reads grammar source in a dialect, as described by parser.Compile
+func Compile(source io.Reader, options CompileOptions) (Grammar, error) {
+	return parser.Compile(source, options)
+}
+
+// CompileString is the same as Compile, except that it compiles a string
+func CompileString(source string, options CompileOptions) (Grammar, error) {
+	return parser.CompileString(source, options)
+}
+
+// CompileBytes is the same as Compile, except that it compiles bytes
+func CompileBytes(source []byte, options CompileOptions) (Grammar, error) {
+	return parser.CompileBytes(source, options)
+}
+
+// DefaultReservedWords returns the words that rule names cannot be by default, as described by
+// parser.DefaultReservedWords
+func DefaultReservedWords() []string {
+	return parser.DefaultReservedWords()
+}
+
+// GoKeywords returns the keywords of Go, which can be reserved along with DefaultReservedWords, as described by
+// parser.GoKeywords
+func GoKeywords() []string {
+	return parser.GoKeywords()
+}
+
+// CompileEngine compiles grammar source supplied by untrusted users into an Engine, as described by parser.CompileEngine
+func CompileEngine(source io.Reader, options CompileOptions, parse ParseOptions) (*Engine, error) {
+	return parser.CompileEngine(source, options, parse)
+}
+
+// NewEngine constructs an Engine for a grammar
+func NewEngine(g Grammar, options ParseOptions) (*Engine, error) {
+	return parser.NewEngine(g, options)
+}
+
+// OfParseContext constructs an empty ParseContext
+func OfParseContext() *ParseContext {
+	return parser.OfParseContext()
+}
+
+// OfDiagnostics constructs Diagnostics of errors
+func OfDiagnostics(errs ...error) Diagnostics {
+	return parser.OfDiagnostics(errs...)
+}
+
+// OfLineIndex constructs a LineIndex of an input
+func OfLineIndex(input string) *LineIndex {
+	return parser.OfLineIndex(input)
+}
+
+// OfLineIndexOptions constructs a LineIndex of an input, with columns of the conventions of positions
+func OfLineIndexOptions(input string, positions PositionOptions) *LineIndex {
+	return parser.OfLineIndexOptions(input, positions)
+}
+
+// OfLocale returns the locale of a language tag
+func OfLocale(tag string) (Locale, error) {
+	return parser.OfLocale(tag)
+}
+
+// Walk calls the visitor for each node of a tree in depth first order
+func Walk(node Node, visitor Visitor) {
+	parser.Walk(node, visitor)
+}
+
+// WalkPreOrder calls visit for each node of a tree before its children, skipping the children if it returns false
+func WalkPreOrder(node Node, visit func(Node) bool) {
+	parser.WalkPreOrder(node, visit)
+}
+
+// WalkPostOrder calls leave for each node of a tree after its children
+func WalkPostOrder(node Node, leave func(Node)) {
+	parser.WalkPostOrder(node, leave)
+}
+
+// SortDiagnostics sorts diagnostics by file, line, position, then code.
+//
+// Deprecated: Use Diagnostics.Sort, which also sorts errors that are not diagnostics.
+func SortDiagnostics(diags []Diagnostic) {
+	parser.SortDiagnostics(diags)
+}
+
+// ImportISOEBNF reads a grammar in the EBNF of ISO/IEC 14977.
+//
+// Deprecated: Use Compile with DialectISO, which can also validate the grammar and check its budget.
+func ImportISOEBNF(source io.Reader) (Grammar, error) {
+	return parser.ImportISOEBNF(source)
+}
+
+// ImportW3CEBNF reads a grammar in the EBNF notation of the W3C XML specification.
+//
+// Deprecated: Use Compile with DialectW3C, which can also validate the grammar and check its budget.
+func ImportW3CEBNF(source io.Reader) (Grammar, error) {
+	return parser.ImportW3CEBNF(source)
+}
+
+// ImportABNF reads a grammar in the ABNF of RFC 5234.
+//
+// Deprecated: Use Compile with DialectABNF, which can also validate the grammar and check its budget.
+func ImportABNF(source io.Reader) (Grammar, error) {
+	return parser.ImportABNF(source)
+}
+
+// ImportANTLR reads a grammar in ANTLR 4 notation.
+//
+// Deprecated: Use Compile with DialectANTLR, which can also validate the grammar and check its budget.
+func ImportANTLR(source io.Reader) (Grammar, error) {
+	return parser.ImportANTLR(source)
+}
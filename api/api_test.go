@@ -0,0 +1,84 @@
+package api
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompileParse(t *testing.T) {
+	g, err := Compile(strings.NewReader(`list : item (',' item)* ; item : 'a' | 'b' ;`), CompileOptions{Dialect: DialectANTLR, Validate: true})
+	assert.Nil(t, err)
+
+	e, err := NewEngine(g, ParseOptions{Encoding: EncodingDetect})
+	assert.Nil(t, err)
+
+	tree, err := e.Parse(strings.NewReader("a,b"))
+	assert.Nil(t, err)
+	assert.Equal(t, "list", tree.Rule())
+	assert.Equal(t, "a,b", tree.Text())
+
+	one, err := e.ParseString("b")
+	assert.Nil(t, err)
+	assert.Equal(t, "b", one.Text())
+
+	var items []string
+	WalkPreOrder(tree, func(n Node) bool {
+		if n.Rule() == "item" {
+			items = append(items, n.Text())
+		}
+		return true
+	})
+	assert.Equal(t, []string{"a", "b"}, items)
+
+	_, err = e.Parse(strings.NewReader("a,"))
+	assert.True(t, strings.HasPrefix(err.Error(), ErrParseFailed))
+	_, isParseError := err.(ParseError)
+	assert.True(t, isParseError)
+}
+
+func TestOfGrammar(t *testing.T) {
+	var (
+		a     = OfListItemTerminal("'a'", OfTerminalString("'a'", "a"), nil)
+		bc    = OfListItemTerminal("[bc]", OfTerminalRuneSet("[bc]", OfRuneSet(RuneInterval{Lo: 'b', Hi: 'c'})), nil)
+		upper = OfListItemTerminal("\\p{Lu}", OfTerminalRuneSet("\\p{Lu}", OfRuneSetRangeTable(unicode.Lu)), nil)
+		item  = OfListItemRuleName("item", "item", []Option{OptionAST})
+		g     = OfGrammar("", []Rule{
+			OfRule("", "list", OfExpression("", []ExpressionItem{OfExpressionItem("", []ListItem{item}, 1, -1)})),
+			OfRuleOptions("", "item", []Option{OptionMemo}, OfExpression("", []ExpressionItem{
+				OfExpressionItem("", []ListItem{a}, 1, 1),
+				OfExpressionItem("", []ListItem{bc}, 1, 1),
+				OfExpressionItem("", []ListItem{upper}, 1, 1),
+			})),
+		})
+	)
+
+	e, err := NewEngine(g, ParseOptions{})
+	assert.Nil(t, err)
+
+	tree, err := e.Parse(strings.NewReader("acaÉ"))
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(tree.Children()))
+}
+
+func TestDeprecated(t *testing.T) {
+	//lint:ignore SA1019 the deprecated function must still work until it is removed
+	g, err := ImportANTLR(strings.NewReader(`a : 'x' ;`))
+	assert.Nil(t, err)
+
+	c, err := CompileString(`a : 'x' ;`, CompileOptions{Dialect: DialectANTLR})
+	assert.Nil(t, err)
+	assert.Equal(t, c.String(), g.String())
+}
+
+func TestLocale(t *testing.T) {
+	l, err := OfLocale("de_de")
+	assert.Nil(t, err)
+	assert.Equal(t, ',', l.DecimalSeparator)
+
+	p := OfLineIndexOptions("a\n\tb", PositionOptions{TabWidth: 4, ZeroBased: true}).Position(3)
+	assert.Equal(t, 2, p.Line)
+	assert.Equal(t, 4, p.Column)
+}
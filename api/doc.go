@@ -0,0 +1,13 @@
+// Package api is the stable API of goparse, which follows semantic versioning: a declaration of this package is only
+// removed or changed incompatibly in a new major version, after being marked deprecated in at least one minor version.
+//
+// A deprecated declaration has a doc comment paragraph that begins with "Deprecated:" and names its replacement,
+// which staticcheck, gopls, and other vet tools report wherever it is used, so that uses can be found before upgrading.
+//
+// The types of this package are aliases of the implementation types, so values can be passed between this package and
+// package experimental, which has the features that are still incubating, such as matching backends and code
+// generation. An alias cannot hide anything, so every method and field of an implementation type can be used through
+// this package, including those that belong to incubating features, such as Grammar.GenerateGo and
+// ParseOptions.Backend. Nothing stops them from being used, and they are not covered by semantic versioning: only the
+// methods and fields that package experimental does not list are stable.
+package api
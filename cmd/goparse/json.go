@@ -0,0 +1,63 @@
+//go:build !tinygo
+// +build !tinygo
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/bantling/goparse/internal/parser"
+)
+
+func init() {
+	dialects["json"] = loadJSON
+	readOptions, writeOptions = readJSONOptions, writeJSONOptions
+	diagnosticFormats["json"] = writeDiagnosticsJSON
+	diagnosticFormats["sarif"] = writeDiagnosticsSARIF
+}
+
+// loadJSON reads a grammar in the JSON form of Grammar.MarshalJSON
+func loadJSON(source io.Reader) (parser.Grammar, error) {
+	var g parser.Grammar
+	err := json.NewDecoder(source).Decode(&g)
+	return g, err
+}
+
+// readJSONOptions reads an options file written by writeJSONOptions
+func readJSONOptions(source io.Reader) (parser.TuneRecommendation, error) {
+	var recommendation parser.TuneRecommendation
+	err := json.NewDecoder(source).Decode(&recommendation)
+	return recommendation, err
+}
+
+// writeJSONOptions writes an options file of a recommendation as indented JSON
+func writeJSONOptions(w io.Writer, recommendation parser.TuneRecommendation) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(recommendation)
+}
+
+// writeDiagnosticsJSON writes diagnostics as a JSON array on one line
+func writeDiagnosticsJSON(w io.Writer, diags []parser.Diagnostic, _ string) error {
+	src, err := parser.DiagnosticsJSON(diags)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "%s\n", src)
+	return err
+}
+
+// writeDiagnosticsSARIF writes diagnostics as a SARIF log of the grammar file
+func writeDiagnosticsSARIF(w io.Writer, diags []parser.Diagnostic, path string) error {
+	src, err := parser.DiagnosticsSARIF(diags, filepath.ToSlash(path))
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "%s\n", src)
+	return err
+}
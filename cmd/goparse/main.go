@@ -0,0 +1,327 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Command goparse checks, formats, and parses with grammars, without writing Go code.
+//
+// Usage:
+//
+//	goparse check [-dialect d] [-format f] grammar
+//	goparse fmt [-dialect d] grammar
+//	goparse parse [-dialect d] [-start rule] [-options file] [-positions] grammar input
+//	goparse tree [-dialect d] [-start rule] [-options file] grammar input
+//	goparse generate [-dialect d] [-start rule] [-trivia rule] [-peg] [-ast] [-visitor] [-c-shared] [-max-depth n] [-max-steps n] -package name [-o file] grammar
+//	goparse tune [-dialect d] [-start rule] [-trivia rule] [-threshold r] [-o file] [-grammar file] grammar input...
+//	goparse xref [-dialect d] grammar
+//	goparse playground [-dialect d] [-start rule] [-trivia rule] [-peg] -o dir grammar
+//
+// check prints the diagnostics of the grammar, and exits with status 1 if there are any. The format of the diagnostics is
+// text, one per line, json, an array of objects, or sarif, a SARIF log for code review tools.
+// fmt prints the grammar in goparse notation.
+// parse prints the parse tree of the input as an S-expression, and tree prints it as an indented outline.
+// generate writes a standalone Go package that parses with the grammar to a file or stdout, so it can be used with go:generate.
+// With -c-shared, the package is main, and builds with go build -buildmode=c-shared into a C library that parses to JSON.
+// tune parses a corpus of inputs, prints which rules to memoize, which alternatives to try first, and which rules are DFA
+// candidates, and writes the recommendation to an options file that parse and tree read with -options, and the tuned
+// grammar in goparse notation.
+// xref prints where each rule is defined and referred to, and which rules use each terminal, one site per line.
+// playground writes a static page to a directory, with a wasm module the go command builds from a generated parser, where
+// input pasted into the page is parsed in the browser and its tree shown, for sharing grammars such as DSL proposals.
+//
+// The dialect of a grammar is goparse, iso, w3c, abnf, antlr, or json, and defaults to the one for the file extension:
+// .gp, .iso, .ebnf, .abnf, .g4, or .json. The goparse dialect is the notation fmt and tune write, so their output can be
+// read back. An input or grammar of - is read from stdin.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/bantling/goparse/internal/parser"
+)
+
+// Exit status constants
+const (
+	exitOK = iota
+	exitFailed
+	exitUsage
+)
+
+const usage = `usage:
+  goparse check [-dialect d] [-format f] grammar
+  goparse fmt [-dialect d] grammar
+  goparse parse [-dialect d] [-start rule] [-options file] [-positions] grammar input
+  goparse tree [-dialect d] [-start rule] [-options file] grammar input
+  goparse generate [-dialect d] [-start rule] [-trivia rule] [-peg] [-ast] [-visitor] [-c-shared] [-max-depth n] [-max-steps n] -package name [-o file] grammar
+  goparse tune [-dialect d] [-start rule] [-trivia rule] [-threshold r] [-o file] [-grammar file] grammar input...
+  goparse xref [-dialect d] grammar
+  goparse playground [-dialect d] [-start rule] [-trivia rule] [-peg] -o dir grammar
+`
+
+// loader reads grammar source of a dialect
+type loader func(io.Reader) (parser.Grammar, error)
+
+// dialects are the loaders of each dialect name
+var dialects = map[string]loader{
+	"goparse": compiler(parser.DialectGoparse),
+	"iso":     compiler(parser.DialectISO),
+	"w3c":     compiler(parser.DialectW3C),
+	"abnf":    compiler(parser.DialectABNF),
+	"antlr":   compiler(parser.DialectANTLR),
+}
+
+// extensions are the dialect names of each grammar file extension
+var extensions = map[string]string{
+	".gp":   "goparse",
+	".iso":  "iso",
+	".ebnf": "w3c",
+	".abnf": "abnf",
+	".g4":   "antlr",
+	".json": "json",
+}
+
+// diagnosticFormats are the writers of the diagnostics of check for each format other than text, where JSON is supported,
+// given the path of the grammar
+var diagnosticFormats = map[string]func(io.Writer, []parser.Diagnostic, string) error{}
+
+// Options files are read and written as JSON, where it is supported
+var (
+	readOptions  func(io.Reader) (parser.TuneRecommendation, error)
+	writeOptions func(io.Writer, parser.TuneRecommendation) error
+)
+
+// compiler returns a loader that compiles a dialect
+func compiler(dialect parser.Dialect) loader {
+	return func(source io.Reader) (parser.Grammar, error) {
+		return parser.Compile(source, parser.CompileOptions{Dialect: dialect})
+	}
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs a command line, and returns the exit status
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+
+	var (
+		command   = args[0]
+		flags     = flag.NewFlagSet(command, flag.ContinueOnError)
+		dialect   = flags.String("dialect", "", "grammar dialect: goparse, iso, w3c, abnf, antlr, or json")
+		start     = flags.String("start", "", "start rule, the first rule of the grammar by default")
+		positions = flags.Bool("positions", false, "print the rune offsets of each node")
+		trivia    = flags.String("trivia", "", "rule skipped before each terminal")
+		peg       = flags.Bool("peg", false, "alternatives are ordered choices")
+		pkg       = flags.String("package", "", "name of the generated package")
+		output    = flags.String("o", "", "file the generated package, or the options of tune, or directory the playground, are written to")
+		options   = flags.String("options", "", "options file written by tune")
+		threshold = flags.Float64("threshold", 0, "repeat ratio at which tune memoizes a rule, 0.25 by default")
+		tuned     = flags.String("grammar", "", "file tune writes the tuned grammar to")
+		ast       = flags.Bool("ast", false, "generate a typed node struct of each rule")
+		visitor   = flags.Bool("visitor", false, "generate Listener and Visitor interfaces")
+		cshared   = flags.Bool("c-shared", false, "generate a main package with C entry points, main by default")
+		maxDepth  = flags.Int("max-depth", 0, "maximum nesting of rules of the generated parser, 10000 by default, none if < 0")
+		maxSteps  = flags.Int("max-steps", 0, "maximum number of steps of the generated parser, none by default")
+		format    = flags.String("format", "text", "format of the diagnostics of check: text, json, or sarif")
+		operands  = 1
+	)
+	flags.SetOutput(stderr)
+
+	switch command {
+	case "check", "fmt", "generate", "xref", "playground":
+	case "parse", "tree":
+		operands = 2
+	case "tune":
+		operands = -1
+	default:
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+
+	if err := flags.Parse(args[1:]); err != nil {
+		return exitUsage
+	}
+	if (flags.NArg() != operands) && ((operands != -1) || (flags.NArg() < 2)) {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+
+	g, err := loadGrammar(flags.Arg(0), *dialect, stdin)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitFailed
+	}
+
+	switch command {
+	case "check":
+		diagnostics := g.Validate()
+		if *format == "text" {
+			for _, diagnostic := range diagnostics {
+				fmt.Fprintln(stdout, diagnostic)
+			}
+		} else if write, known := diagnosticFormats[*format]; !known {
+			fmt.Fprintf(stderr, "unknown format %s\n", *format)
+			return exitUsage
+		} else if err := write(stdout, diagnostics, flags.Arg(0)); err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitFailed
+		}
+		if len(diagnostics) > 0 {
+			return exitFailed
+		}
+
+	case "fmt":
+		fmt.Fprint(stdout, g.Format())
+
+	case "xref":
+		writeXRef(stdout, g.XRef())
+
+	case "generate":
+		if *cshared && (*pkg == "") {
+			*pkg = "main"
+		}
+
+		src, err := g.GenerateGo(parser.GoOptions{
+			Package:  *pkg,
+			Start:    *start,
+			Trivia:   *trivia,
+			PEG:      *peg,
+			AST:      *ast,
+			Visitor:  *visitor,
+			CShared:  *cshared,
+			MaxDepth: *maxDepth,
+			MaxSteps: *maxSteps,
+		})
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitFailed
+		}
+
+		if *output == "" {
+			stdout.Write(src)
+		} else if err := ioutil.WriteFile(*output, src, 0644); err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitFailed
+		}
+
+	case "playground":
+		if *output == "" {
+			fmt.Fprintln(stderr, "the directory of the playground must be given with -o")
+			return exitUsage
+		}
+
+		if err := playground(g, parser.GoOptions{Start: *start, Trivia: *trivia, PEG: *peg}, *output); err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitFailed
+		}
+
+	case "tune":
+		return tune(g, flags.Args()[1:], parser.TuneOptions{
+			ParseOptions:  parser.ParseOptions{Start: *start, Trivia: *trivia},
+			MemoThreshold: *threshold,
+		}, *output, *tuned, stdin, stdout, stderr)
+
+	default:
+		if *options != "" {
+			if g, err = loadOptions(g, *options, stdin); err != nil {
+				fmt.Fprintln(stderr, err)
+				return exitFailed
+			}
+		}
+
+		engine, err := parser.NewEngine(g, parser.ParseOptions{Start: *start})
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitFailed
+		}
+
+		source, closer, err := open(flags.Arg(1), stdin)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitFailed
+		}
+		defer closer()
+
+		tree, err := engine.Parse(source)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitFailed
+		}
+
+		if command == "parse" {
+			fmt.Fprintln(stdout, tree.SExpr(*positions))
+		} else {
+			writeTree(stdout, tree, 0)
+		}
+	}
+
+	return exitOK
+}
+
+// loadGrammar reads a grammar file in a dialect, or the dialect of its extension if the dialect is empty
+func loadGrammar(path, dialect string, stdin io.Reader) (parser.Grammar, error) {
+	if dialect == "" {
+		dialect = extensions[strings.ToLower(filepath.Ext(path))]
+		if dialect == "" {
+			return parser.Grammar{}, fmt.Errorf("%s: the dialect of the grammar must be given with -dialect", path)
+		}
+	}
+
+	load, known := dialects[dialect]
+	if !known {
+		return parser.Grammar{}, fmt.Errorf("%s: unknown dialect %s", path, dialect)
+	}
+
+	source, closer, err := open(path, stdin)
+	if err != nil {
+		return parser.Grammar{}, err
+	}
+	defer closer()
+
+	g, err := load(source)
+	if err != nil {
+		return parser.Grammar{}, fmt.Errorf("%s: %s", path, err)
+	}
+
+	return g, nil
+}
+
+// open opens a file, or returns stdin if the path is -
+func open(path string, stdin io.Reader) (io.Reader, func(), error) {
+	if path == "-" {
+		return stdin, func() {}, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return file, func() { file.Close() }, nil
+}
+
+// writeTree writes a node as an outline, with a line for each node indented by its depth,
+// where a rule is its name and a terminal is its quoted text
+func writeTree(w io.Writer, node parser.Node, depth int) {
+	indent := strings.Repeat("  ", depth)
+
+	switch {
+	case node.IsError():
+		fmt.Fprintf(w, "%s! %s\n", indent, strconv.Quote(node.Text()))
+	case node.IsTerminal():
+		fmt.Fprintf(w, "%s%s\n", indent, strconv.Quote(node.Text()))
+	default:
+		fmt.Fprintf(w, "%s%s\n", indent, node.Rule())
+		for _, child := range node.Children() {
+			writeTree(w, child, depth+1)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// runCommand runs a command line with stdin, and returns the exit status, stdout, and stderr
+func runCommand(stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	status := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return status, stdout.String(), stderr.String()
+}
+
+func TestRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goparse")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	var (
+		grammar = filepath.Join(dir, "list.ebnf")
+		bad     = filepath.Join(dir, "bad.ebnf")
+		input   = filepath.Join(dir, "input.txt")
+	)
+	assert.Nil(t, ioutil.WriteFile(grammar, []byte("list ::= item (',' item)*\nitem ::= [a-c]\n"), 0644))
+	assert.Nil(t, ioutil.WriteFile(bad, []byte("list ::= item other\nitem ::= 'a'\n"), 0644))
+	assert.Nil(t, ioutil.WriteFile(input, []byte("a,b"), 0644))
+
+	// check
+	status, stdout, _ := runCommand("", "check", grammar)
+	assert.Equal(t, exitOK, status)
+	assert.Equal(t, "", stdout)
+
+	status, stdout, _ = runCommand("", "check", bad)
+	assert.Equal(t, exitFailed, status)
+	assert.Contains(t, stdout, "other")
+
+	// fmt
+	status, stdout, _ = runCommand("", "fmt", grammar)
+	assert.Equal(t, exitOK, status)
+	assert.Equal(t, "list = item list-part;\n\nlist-part = (',' item)*;\n\nitem = [a-c];\n", stdout)
+
+	// xref
+	status, stdout, _ = runCommand("", "xref", grammar)
+	assert.Equal(t, exitOK, status)
+	assert.Equal(
+		t,
+		"rule list defined at line 1 position 1\n"+
+			"rule list-part defined at line 1 position 15\n"+
+			"rule list-part referenced by list at line 1 position 15\n"+
+			"rule item defined at line 2 position 1\n"+
+			"rule item referenced by list at line 1 position 10\n"+
+			"rule item referenced by list-part at line 1 position 20\n"+
+			"terminal ',' used by list-part at line 1 position 16\n"+
+			"terminal [a-c] used by item at line 2 position 10\n",
+		stdout,
+	)
+
+	status, stdout, _ = runCommand("x = y ;\ny = 'y' ;\n", "xref", "-dialect", "iso", "-")
+	assert.Equal(t, exitOK, status)
+	assert.Equal(
+		t,
+		"rule x defined at line 1 position 1\n"+
+			"rule y defined at line 2 position 1\n"+
+			"rule y referenced by x at line 1 position 5\n"+
+			"terminal 'y' used by y at line 2 position 5\n",
+		stdout,
+	)
+
+	// parse
+	status, stdout, _ = runCommand("", "parse", grammar, input)
+	assert.Equal(t, exitOK, status)
+	assert.Equal(t, `(list (item "a") (list-part "," (item "b")))`+"\n", stdout)
+
+	status, stdout, _ = runCommand("", "parse", "-positions", grammar, input)
+	assert.Equal(t, exitOK, status)
+	assert.True(t, strings.HasPrefix(stdout, "(list@0-3 "))
+
+	status, stdout, _ = runCommand("c", "parse", "-start", "item", grammar, "-")
+	assert.Equal(t, exitOK, status)
+	assert.Equal(t, `(item "c")`+"\n", stdout)
+
+	status, _, stderr := runCommand("a,", "parse", grammar, "-")
+	assert.Equal(t, exitFailed, status)
+	assert.NotEqual(t, "", stderr)
+
+	// tree
+	status, stdout, _ = runCommand("", "tree", grammar, input)
+	assert.Equal(t, exitOK, status)
+	assert.Equal(t, "list\n  item\n    \"a\"\n  list-part\n    \",\"\n    item\n      \"b\"\n", stdout)
+
+	// generate
+	status, stdout, _ = runCommand("", "generate", "-package", "list", grammar)
+	assert.Equal(t, exitOK, status)
+	assert.Contains(t, stdout, "package list\n")
+
+	output := filepath.Join(dir, "list.go")
+	status, _, _ = runCommand("", "generate", "-package", "list", "-o", output, grammar)
+	assert.Equal(t, exitOK, status)
+	src, err := ioutil.ReadFile(output)
+	assert.Nil(t, err)
+	assert.Contains(t, string(src), "func ParseString(input string) (node Node, err error) {")
+
+	status, stdout, _ = runCommand("", "generate", "-package", "list", "-max-depth", "-1", "-max-steps", "100", grammar)
+	assert.Equal(t, exitOK, status)
+	assert.Contains(t, stdout, "\tmaxDepth = -1\n\tmaxSteps = 100\n")
+
+	status, stdout, _ = runCommand("", "generate", "-c-shared", grammar)
+	assert.Equal(t, exitOK, status)
+	assert.Contains(t, stdout, "package main\n")
+	assert.Contains(t, stdout, "//export GoparseParse\n")
+
+	status, _, stderr = runCommand("", "generate", grammar)
+	assert.Equal(t, exitFailed, status)
+	assert.Contains(t, stderr, "package name")
+
+	// The dialect can be given for a grammar read from stdin
+	status, stdout, _ = runCommand("x = 'y' ;", "fmt", "-dialect", "iso", "-")
+	assert.Equal(t, exitOK, status)
+	assert.Equal(t, "x = 'y';\n", stdout)
+
+	// The output of fmt is a grammar in goparse notation, the dialect of .gp files
+	status, stdout, _ = runCommand("", "fmt", grammar)
+	assert.Equal(t, exitOK, status)
+	formatted := filepath.Join(dir, "list.gp")
+	assert.Nil(t, ioutil.WriteFile(formatted, []byte(stdout), 0644))
+
+	status, _, _ = runCommand("", "check", formatted)
+	assert.Equal(t, exitOK, status)
+
+	status, stdout, _ = runCommand("", "fmt", formatted)
+	assert.Equal(t, exitOK, status)
+	assert.Equal(t, "list = item list-part;\n\nlist-part = (',' item)*;\n\nitem = [a-c];\n", stdout)
+
+	status, stdout, _ = runCommand("", "parse", formatted, input)
+	assert.Equal(t, exitOK, status)
+	assert.Equal(t, `(list (item "a") (list-part "," (item "b")))`+"\n", stdout)
+
+	status, stdout, _ = runCommand("x = 'y';", "fmt", "-dialect", "goparse", "-")
+	assert.Equal(t, exitOK, status)
+	assert.Equal(t, "x = 'y';\n", stdout)
+
+	// Usage errors
+	status, _, stderr = runCommand("")
+	assert.Equal(t, exitUsage, status)
+	assert.Equal(t, usage, stderr)
+
+	status, _, _ = runCommand("", "unknown")
+	assert.Equal(t, exitUsage, status)
+
+	status, _, _ = runCommand("", "parse", grammar)
+	assert.Equal(t, exitUsage, status)
+
+	status, _, stderr = runCommand("", "check", filepath.Join(dir, "list.txt"))
+	assert.Equal(t, exitFailed, status)
+	assert.Contains(t, stderr, "-dialect")
+
+	status, _, stderr = runCommand("", "check", "-dialect", "cobol", grammar)
+	assert.Equal(t, exitFailed, status)
+	assert.Contains(t, stderr, "unknown dialect")
+
+	status, _, _ = runCommand("", "check", filepath.Join(dir, "missing.ebnf"))
+	assert.Equal(t, exitFailed, status)
+}
+
+func TestCheckFormat(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goparse")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	var (
+		grammar = filepath.Join(dir, "list.ebnf")
+		bad     = filepath.Join(dir, "bad.ebnf")
+	)
+	assert.Nil(t, ioutil.WriteFile(grammar, []byte("list ::= item (',' item)*\nitem ::= [a-c]\n"), 0644))
+	assert.Nil(t, ioutil.WriteFile(bad, []byte("list ::= item other\nitem ::= 'a'\n"), 0644))
+
+	// json
+	status, stdout, _ := runCommand("", "check", "-format", "json", grammar)
+	assert.Equal(t, exitOK, status)
+	assert.Equal(t, "[]\n", stdout)
+
+	status, stdout, _ = runCommand("", "check", "-format", "json", bad)
+	assert.Equal(t, exitFailed, status)
+	assert.Equal(t, `[{"code":"undefrule","message":"Undefined rule other","line":1,"position":15}]`+"\n", stdout)
+
+	// sarif
+	status, stdout, _ = runCommand("", "check", "-format", "sarif", grammar)
+	assert.Equal(t, exitOK, status)
+	assert.Contains(t, stdout, `"results":[]`)
+
+	status, stdout, _ = runCommand("", "check", "-format", "sarif", bad)
+	assert.Equal(t, exitFailed, status)
+	assert.Contains(t, stdout, `"version":"2.1.0"`)
+	assert.Contains(t, stdout, `{"ruleId":"undefrule","level":"error","message":{"text":"Undefined rule other"},`)
+	assert.Contains(t, stdout, `"artifactLocation":{"uri":"`+filepath.ToSlash(bad)+`"},"region":{"startLine":1,"startColumn":15}`)
+
+	status, _, stderr := runCommand("", "check", "-format", "xml", bad)
+	assert.Equal(t, exitUsage, status)
+	assert.Contains(t, stderr, "unknown format xml")
+}
+
+func TestPlayground(t *testing.T) {
+	if _, err := exec.LookPath("go"); (err != nil) || testing.Short() {
+		t.Skip("the playground is built with the go command")
+	}
+
+	dir, err := ioutil.TempDir("", "goparse")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	var (
+		grammar = filepath.Join(dir, "list.ebnf")
+		output  = filepath.Join(dir, "playground")
+	)
+	assert.Nil(t, ioutil.WriteFile(grammar, []byte("list ::= item (',' item)*\nitem ::= [a-c] | '<'\n"), 0644))
+
+	status, _, stderr := runCommand("", "playground", "-o", output, grammar)
+	assert.Equal(t, exitOK, status, stderr)
+	for _, name := range []string{"main.wasm", "wasm_exec.js"} {
+		info, err := os.Stat(filepath.Join(output, name))
+		assert.Nil(t, err)
+		assert.True(t, info.Size() > 0)
+	}
+
+	// The page shows the grammar, escaped
+	page, err := ioutil.ReadFile(filepath.Join(output, "index.html"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(page), "<pre>list = item list-part;")
+	assert.Contains(t, string(page), "item = [a-c] | &#39;&lt;&#39;;")
+	assert.Contains(t, string(page), `fetch("main.wasm")`)
+
+	status, _, stderr = runCommand("", "playground", grammar)
+	assert.Equal(t, exitUsage, status)
+	assert.Contains(t, stderr, "-o")
+}
+
+func TestTune(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goparse")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	var (
+		grammar = filepath.Join(dir, "sum.ebnf")
+		options = filepath.Join(dir, "sum.json")
+		tuned   = filepath.Join(dir, "tuned.ebnf")
+		inputs  []string
+	)
+	assert.Nil(t, ioutil.WriteFile(grammar, []byte("sum ::= term '+' sum | term\nterm ::= number | name\nnumber ::= [0-9]+\nname ::= [a-z]+\n"), 0644))
+	for i, input := range []string{"a+b+1", "c", "d+e", "1+"} {
+		inputs = append(inputs, filepath.Join(dir, fmt.Sprintf("input%d.txt", i)))
+		assert.Nil(t, ioutil.WriteFile(inputs[i], []byte(input), 0644))
+	}
+
+	status, stdout, _ := runCommand("", append([]string{"tune", "-o", options, "-grammar", tuned, grammar}, inputs...)...)
+	assert.Equal(t, exitOK, status)
+	assert.Equal(
+		t,
+		"inputs: 3 parsed, 1 failed\n"+
+			"failed "+inputs[3]+": The input does not match the grammar at line 1 position 3: found EOF, expected [0-9] or [a-z] in rule number\n"+
+			"memo term:MEMO, repeat ratio 0.50\n"+
+			"order term: alternatives 2 1\n"+
+			"dfa term\n",
+		stdout,
+	)
+
+	src, err := ioutil.ReadFile(options)
+	assert.Nil(t, err)
+	assert.Contains(t, string(src), `"term": [`)
+
+	src, err = ioutil.ReadFile(tuned)
+	assert.Nil(t, err)
+	assert.Contains(t, string(src), "term:MEMO = name | number;")
+
+	// The options file tunes the grammar of a parse
+	status, stdout, _ = runCommand("a+1", "tree", "-options", options, grammar, "-")
+	assert.Equal(t, exitOK, status)
+	assert.Contains(t, stdout, "term\n")
+
+	status, _, stderr := runCommand("a", "parse", "-options", grammar, grammar, "-")
+	assert.Equal(t, exitFailed, status)
+	assert.Contains(t, stderr, grammar)
+
+	status, _, _ = runCommand("", "tune", grammar)
+	assert.Equal(t, exitUsage, status)
+}
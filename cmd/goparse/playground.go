@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/bantling/goparse/internal/parser"
+)
+
+// wasmExecPaths are where wasm_exec.js is in the Go distribution, relative to GOROOT, newest first
+var wasmExecPaths = []string{
+	filepath.Join("lib", "wasm", "wasm_exec.js"),
+	filepath.Join("misc", "wasm", "wasm_exec.js"),
+}
+
+// playground writes a static page to a directory, which parses input pasted into it with the grammar and shows the tree.
+// The directory has index.html, the wasm_exec.js of the Go distribution, and main.wasm, which the go command builds from
+// the parser GenerateGo generates for GOOS=js and GOARCH=wasm, so that it can be served by any static file server.
+func playground(g parser.Grammar, options parser.GoOptions, dir string) error {
+	options.Package, options.WASM = "main", true
+	src, err := g.GenerateGo(options)
+	if err != nil {
+		return err
+	}
+
+	if dir, err = filepath.Abs(dir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	build, err := ioutil.TempDir("", "goparse-playground")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(build)
+
+	if err := ioutil.WriteFile(filepath.Join(build, "main.go"), src, 0644); err != nil {
+		return err
+	}
+	if _, err := goCommand(build, []string{"GOOS=js", "GOARCH=wasm"}, "build", "-o", filepath.Join(dir, "main.wasm"), "main.go"); err != nil {
+		return err
+	}
+
+	goroot, err := goCommand(build, nil, "env", "GOROOT")
+	if err != nil {
+		return err
+	}
+
+	var wasmExec []byte
+	for _, path := range wasmExecPaths {
+		if wasmExec, err = ioutil.ReadFile(filepath.Join(strings.TrimSpace(goroot), path)); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("wasm_exec.js is not in the Go distribution: %s", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "wasm_exec.js"), wasmExec, 0644); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(dir, "index.html"), []byte(playgroundHTML(g, options.Start)), 0644)
+}
+
+// goCommand runs the go command in a directory with additional environment variables, and returns its output.
+// Returns an error with the output if it fails.
+func goCommand(dir string, env []string, args ...string) (string, error) {
+	var output bytes.Buffer
+
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdout, cmd.Stderr = &output, &output
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("go %s: %s\n%s", strings.Join(args, " "), err, output.String())
+	}
+
+	return output.String(), nil
+}
+
+// playgroundHTML returns the page of a playground, which shows the grammar, and the tree or error of the input as it is edited
+func playgroundHTML(g parser.Grammar, start string) string {
+	title := "goparse playground"
+	if start != "" {
+		title += ": " + start
+	}
+
+	return fmt.Sprintf(playgroundPage, html.EscapeString(title), html.EscapeString(g.Format()))
+}
+
+// playgroundPage is the HTML of a playground, given its title and grammar
+const playgroundPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>%[1]s</title>
+<style>
+body { font-family: sans-serif; margin: 1em; }
+.panes { display: flex; gap: 1em; }
+.panes > div { flex: 1; min-width: 0; }
+textarea, pre { box-sizing: border-box; width: 100%%; min-height: 20em; font-family: monospace; font-size: 14px; }
+pre { border: 1px solid #ccc; padding: 0.5em; margin: 0; overflow: auto; }
+.error { color: #b00; }
+</style>
+</head>
+<body>
+<h1>%[1]s</h1>
+<details>
+<summary>Grammar</summary>
+<pre>%[2]s</pre>
+</details>
+<div class="panes">
+<div>
+<h2>Input</h2>
+<textarea id="input" spellcheck="false" disabled>loading...</textarea>
+</div>
+<div>
+<h2>Tree</h2>
+<pre id="tree"></pre>
+</div>
+</div>
+<script src="wasm_exec.js"></script>
+<script>
+// outline returns a node as lines indented by depth, where a rule is its name and a terminal is its quoted text
+function outline(node, depth) {
+  var indent = "  ".repeat(depth);
+  if (!node.rule) {
+    return indent + JSON.stringify(node.text) + "\n";
+  }
+
+  var str = indent + node.rule + "\n";
+  (node.children || []).forEach(function(child) {
+    str += outline(child, depth + 1);
+  });
+  return str;
+}
+
+// parse shows the tree or error of the input
+function parse() {
+  var result = JSON.parse(goparseParse(input.value)), tree = document.getElementById("tree");
+  tree.className = result.error ? "error" : "";
+  tree.textContent = result.error ? result.error : outline(result.tree, 0);
+}
+
+var input = document.getElementById("input"), go = new Go();
+WebAssembly.instantiateStreaming(fetch("main.wasm"), go.importObject).then(function(module) {
+  go.run(module.instance);
+  input.value = "";
+  input.disabled = false;
+  input.addEventListener("input", parse);
+  parse();
+});
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/bantling/goparse/internal/parser"
+)
+
+// errNoOptionsFiles is the error of reading or writing an options file where JSON is not supported
+const errNoOptionsFiles = "options files are not supported by this build"
+
+// tune parses each input file with the grammar, prints the measurements, and writes the recommendation to an options
+// file and the tuned grammar to a grammar file, if they are given. Returns the exit status.
+func tune(g parser.Grammar, inputs []string, options parser.TuneOptions, output, grammar string, stdin io.Reader, stdout, stderr io.Writer) int {
+	corpus := make([]string, len(inputs))
+	for i, path := range inputs {
+		source, closer, err := open(path, stdin)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitFailed
+		}
+
+		data, err := ioutil.ReadAll(source)
+		closer()
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitFailed
+		}
+		corpus[i] = string(data)
+	}
+
+	report, err := g.Measure(corpus, options)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitFailed
+	}
+
+	writeReport(stdout, report, inputs)
+
+	if output != "" {
+		if writeOptions == nil {
+			fmt.Fprintln(stderr, errNoOptionsFiles)
+			return exitFailed
+		}
+
+		file, err := os.Create(output)
+		if err == nil {
+			err = writeOptions(file, report.Recommendation)
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitFailed
+		}
+	}
+
+	if grammar != "" {
+		tunedGrammar, err := g.Tune(report.Recommendation)
+		if err == nil {
+			err = ioutil.WriteFile(grammar, []byte(tunedGrammar.Format()), 0644)
+		}
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitFailed
+		}
+	}
+
+	return exitOK
+}
+
+// writeReport writes the measurements of tune, with a line for each input that failed, rule whose memoization should
+// change, rule whose alternatives should be reordered with the alternatives numbered from 1, and DFA candidate
+func writeReport(w io.Writer, report parser.TuneReport, inputs []string) {
+	fmt.Fprintf(w, "inputs: %d parsed, %d failed\n", report.Inputs-len(report.Failed), len(report.Failed))
+	for i, path := range inputs {
+		if err, failed := report.Failed[i]; failed {
+			fmt.Fprintf(w, "failed %s: %s\n", path, err)
+		}
+	}
+
+	for _, suggestion := range report.Memo {
+		if _, recommended := report.Recommendation.Memo[suggestion.Rule]; recommended {
+			fmt.Fprintf(w, "memo %s%s, repeat ratio %.2f\n", suggestion.Rule, suggestion.Option, suggestion.RepeatRatio)
+		}
+	}
+
+	rules := make([]string, 0, len(report.Recommendation.Order))
+	for rule := range report.Recommendation.Order {
+		rules = append(rules, rule)
+	}
+	sort.Strings(rules)
+
+	for _, rule := range rules {
+		alts := make([]string, len(report.Recommendation.Order[rule]))
+		for i, index := range report.Recommendation.Order[rule] {
+			alts[i] = fmt.Sprint(index + 1)
+		}
+		fmt.Fprintf(w, "order %s: alternatives %s\n", rule, strings.Join(alts, " "))
+	}
+
+	for _, rule := range report.Recommendation.DFA {
+		fmt.Fprintf(w, "dfa %s\n", rule)
+	}
+}
+
+// loadOptions reads an options file written by tune, and returns the grammar tuned by it
+func loadOptions(g parser.Grammar, path string, stdin io.Reader) (parser.Grammar, error) {
+	if readOptions == nil {
+		return parser.Grammar{}, fmt.Errorf("%s", errNoOptionsFiles)
+	}
+
+	source, closer, err := open(path, stdin)
+	if err != nil {
+		return parser.Grammar{}, err
+	}
+	defer closer()
+
+	recommendation, err := readOptions(source)
+	if err == nil {
+		g, err = g.Tune(recommendation)
+	}
+	if err != nil {
+		return parser.Grammar{}, fmt.Errorf("%s: %s", path, err)
+	}
+
+	return g, nil
+}
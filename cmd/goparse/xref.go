@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/bantling/goparse/internal/parser"
+)
+
+// writeXRef writes a cross-reference index, with a line for where each rule is defined or that it is not, each site
+// that refers to a rule, and each site that uses a terminal, so that the lines of a rule or terminal can be found with grep
+func writeXRef(w io.Writer, xref parser.XRef) {
+	for _, rule := range xref.Rules {
+		if rule.Defined {
+			fmt.Fprintf(w, "rule %s defined%s\n", rule.Name, at(rule.Line, rule.Position))
+		} else {
+			fmt.Fprintf(w, "rule %s undefined\n", rule.Name)
+		}
+
+		for _, site := range rule.References {
+			fmt.Fprintf(w, "rule %s referenced by %s%s\n", rule.Name, site.Rule, at(site.Line, site.Position))
+		}
+	}
+
+	for _, terminal := range xref.Terminals {
+		for _, site := range terminal.Sites {
+			fmt.Fprintf(w, "terminal %s used by %s%s\n", terminal.Terminal, site.Rule, at(site.Line, site.Position))
+		}
+	}
+}
+
+// at returns where a site is in grammar source, or "" if it was not read from source
+func at(line, position int) string {
+	if line == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(" at line %d position %d", line, position)
+}
@@ -1,2 +1,5 @@
-// Package goparse provides cli access to an Extended Backus-Naur Form parser
+// Package goparse provides cli access to an Extended Backus-Naur Form parser, and the Lexer of its grammar notation.
+//
+// The module requires Go 1.13, except for Lexer.Tokens, which is only defined when building with Go 1.23 or later,
+// as it returns an iter.Seq2.
 package goparse
@@ -0,0 +1,16 @@
+//go:build !tinygo
+// +build !tinygo
+
+package experimental
+
+import (
+	"github.com/bantling/goparse/internal/parser"
+)
+
+// GoOptions are the options of GenerateGo
+type GoOptions = parser.GoOptions
+
+// GenerateGo generates Go source of a standalone parser for the grammar, as described by parser.Grammar.GenerateGo
+func GenerateGo(g parser.Grammar, options GoOptions) ([]byte, error) {
+	return g.GenerateGo(options)
+}
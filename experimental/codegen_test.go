@@ -0,0 +1,21 @@
+//go:build !tinygo
+// +build !tinygo
+
+package experimental
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bantling/goparse/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateGo(t *testing.T) {
+	g, err := api.Compile(strings.NewReader(`a : 'x' ;`), api.CompileOptions{Dialect: api.DialectANTLR})
+	assert.Nil(t, err)
+
+	src, err := GenerateGo(g, GoOptions{Package: "x"})
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(string(src), "// Code generated"))
+}
@@ -0,0 +1,13 @@
+// Package experimental has the features of goparse that are still incubating, such as matching backends and code
+// generation, which can change incompatibly or be removed in any minor version, without being deprecated first.
+// A feature graduates by being added to package api, after which its declaration here is deprecated in favour of it.
+//
+// The types of this package are aliases of the implementation types, so values can be passed between this package and
+// package api. As aliases cannot hide methods or fields, the following methods and fields of stable types can be used
+// through package api, but belong to incubating features, and are experimental all the same:
+//
+//	api.ParseOptions.Backend, which is a Backend
+//	api.Grammar.GenerateGo, which GenerateGo calls
+//	api.Engine.ParseForest, which ParseForest calls
+//	api.Engine.Supports, which takes a Feature
+package experimental
@@ -0,0 +1,52 @@
+package experimental
+
+import (
+	"io"
+
+	"github.com/bantling/goparse/internal/parser"
+)
+
+// Backend is an algorithm an Engine uses to match input
+type Backend = parser.Backend
+
+// Backend constants
+const (
+	// BackendBacktrack tries each alternative and repetition in turn, backtracking when the rest of the input does not match
+	BackendBacktrack = parser.BackendBacktrack
+	// BackendEarley uses the Earley algorithm, which matches any grammar including left recursive and ambiguous grammars
+	BackendEarley = parser.BackendEarley
+)
+
+// Feature is an optional capability of an Engine
+type Feature = parser.Feature
+
+// Feature constants
+const (
+	FeatureBacktrack     = parser.FeatureBacktrack
+	FeatureEarley        = parser.FeatureEarley
+	FeatureLeftRecursion = parser.FeatureLeftRecursion
+	FeaturePEG           = parser.FeaturePEG
+	FeatureTrivia        = parser.FeatureTrivia
+	FeatureRecovery      = parser.FeatureRecovery
+	FeatureLimits        = parser.FeatureLimits
+	FeatureParseOne      = parser.FeatureParseOne
+	FeatureReplay        = parser.FeatureReplay
+	FeatureForest        = parser.FeatureForest
+	FeatureJSON          = parser.FeatureJSON
+	FeatureKeywords      = parser.FeatureKeywords
+)
+
+// NewEngineFallback constructs an Engine with the first backend of the fallback chain that supports every required feature
+// and accepts the grammar and options, as described by parser.NewEngineFallback
+func NewEngineFallback(g parser.Grammar, options parser.ParseOptions, chain []Backend, required ...Feature) (*parser.Engine, error) {
+	return parser.NewEngineFallback(g, options, chain, required...)
+}
+
+// Forest is every derivation of a parse of an ambiguous grammar
+type Forest = parser.Forest
+
+// ParseForest reads all of the source, and returns every derivation of the start rule of the engine that matches all of
+// it, as described by parser.Engine.ParseForest
+func ParseForest(e *parser.Engine, ctx *parser.ParseContext, source io.Reader) (Forest, error) {
+	return e.ParseForest(ctx, source)
+}
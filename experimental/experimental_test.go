@@ -0,0 +1,35 @@
+package experimental
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bantling/goparse/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackend(t *testing.T) {
+	g, err := api.Compile(strings.NewReader(`e : e '+' e | 'x' ;`), api.CompileOptions{Dialect: api.DialectANTLR})
+	assert.Nil(t, err)
+
+	e, err := api.NewEngine(g, api.ParseOptions{Backend: BackendEarley})
+	assert.Nil(t, err)
+
+	tree, err := e.Parse(strings.NewReader("x+x"))
+	assert.Nil(t, err)
+	assert.Equal(t, "x+x", tree.Text())
+
+	forest, err := ParseForest(e, api.OfParseContext(), strings.NewReader("x+x+x"))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, forest.Count(0))
+}
+
+func TestNewEngineFallback(t *testing.T) {
+	g, err := api.Compile(strings.NewReader(`e : e '+' e | 'x' ;`), api.CompileOptions{Dialect: api.DialectANTLR})
+	assert.Nil(t, err)
+
+	e, err := NewEngineFallback(g, api.ParseOptions{}, []Backend{BackendBacktrack, BackendEarley})
+	assert.Nil(t, err)
+	assert.True(t, e.Supports(FeatureLeftRecursion))
+	assert.False(t, e.Supports(FeaturePEG))
+}
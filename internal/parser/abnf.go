@@ -0,0 +1,724 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ABNF import error message constants
+const (
+	ErrABNFSyntax      = "Invalid ABNF grammar"
+	ErrABNFUnsupported = "Unsupported ABNF construct"
+)
+
+// abnfCoreRules are the core rules of RFC 5234 appendix B.1, which an imported grammar can use without defining them
+const abnfCoreRules = `
+ALPHA  = %x41-5A / %x61-7A
+BIT    = "0" / "1"
+CHAR   = %x01-7F
+CR     = %x0D
+CRLF   = CR LF
+CTL    = %x00-1F / %x7F
+DIGIT  = %x30-39
+DQUOTE = %x22
+HEXDIG = DIGIT / "A" / "B" / "C" / "D" / "E" / "F"
+HTAB   = %x09
+LF     = %x0A
+LWSP   = *(WSP / CRLF WSP)
+OCTET  = %x00-FF
+SP     = %x20
+VCHAR  = %x21-7E
+WSP    = SP / HTAB
+`
+
+// abnfTokenType is the type of a token of an ABNF grammar
+type abnfTokenType uint
+
+const (
+	abnfEOF abnfTokenType = iota
+	abnfName
+	abnfNumber
+	// a quoted string, case insensitive unless it has the %s prefix
+	abnfString
+	// a numeric value, which is a string of one or more characters or a range
+	abnfValue
+	abnfPunct
+)
+
+// abnfToken is a token of an ABNF grammar
+type abnfToken struct {
+	tokenType abnfTokenType
+	// name, number, string, or punctuation
+	text string
+	// true for a case sensitive string
+	sensitive bool
+	// characters of a range value
+	charSet  RuneSet
+	line     int
+	position int
+	// span of source of the token
+	start, end SourcePosition
+}
+
+// abnfRule is an ABNF rule, where alternatives added by =/ are appended
+type abnfRule struct {
+	name       string
+	alts       [][]antlrElement
+	line       int
+	position   int
+	start, end SourcePosition
+}
+
+// abnfParser parses ABNF tokens into rules
+type abnfParser struct {
+	tokens []abnfToken
+	index  int
+	// end of the last token consumed
+	end SourcePosition
+}
+
+// ImportABNF reads a grammar in the Augmented BNF of RFC 5234, as used by protocol specifications such as HTTP, SIP, and URI,
+// and converts its rules to a Grammar, in the order they are defined.
+// Alternatives added to a rule by =/ are added to the first definition of the rule.
+//
+// Rule names are case insensitive, and are converted to the spelling of their definition.
+// Core rules such as ALPHA, DIGIT, and CRLF that are used but not defined are added to the end of the grammar.
+//
+// A quoted string is case insensitive, unless it has the %s prefix of RFC 7405, so its letters are converted to ranges,
+// such as "ab" to [Aa] [Bb]. Numeric values such as %x41, %d13.10, and %x30-39 are converted to strings and ranges.
+// Repetitions and optional [ ] groups are converted the same way as ImportANTLR, into new rules named after the enclosing rule,
+// such as name-part and name-part-2.
+//
+// Returns an error if the grammar is invalid, or uses prose values such as <any character>.
+func ImportABNF(source io.Reader) (Grammar, error) {
+	return importABNF(source, PositionOptions{})
+}
+
+// importABNF is ImportABNF, where the positions of the grammar follow the options
+func importABNF(source io.Reader, positions PositionOptions) (grammar Grammar, err error) {
+	// The conversion shares the ANTLR importer's error handling
+	defer func() {
+		if r := recover(); r != nil {
+			if e, isa := r.(antlrError); isa {
+				err = e.err
+				return
+			}
+			panic(r)
+		}
+	}()
+
+	data, err := ioutil.ReadAll(source)
+	if err != nil {
+		return Grammar{}, err
+	}
+
+	rules, end := abnfParseRules(string(data), positions)
+
+	// Add the core rules that are used and not defined, including those used by other core rules
+	var (
+		core, _    = abnfParseRules(abnfCoreRules, PositionOptions{})
+		coreByName = map[string]abnfRule{}
+		defined    = map[string]bool{}
+	)
+	for _, rule := range core {
+		coreByName[strings.ToLower(rule.name)] = rule
+	}
+	for _, rule := range rules {
+		defined[strings.ToLower(rule.name)] = true
+	}
+	for i := 0; i < len(rules); i++ {
+		for _, name := range abnfReferences(rules[i].alts) {
+			if rule, isCore := coreByName[strings.ToLower(name)]; isCore && !defined[strings.ToLower(name)] {
+				// Core rules are not in the source
+				rule.line, rule.position, rule.start, rule.end = 0, 0, SourcePosition{}, SourcePosition{}
+				rule.alts = abnfWithoutSpans(rule.alts)
+				defined[strings.ToLower(name)] = true
+				rules = append(rules, rule)
+			}
+		}
+	}
+
+	// Convert references to the spelling of the definition
+	spelling := map[string]string{}
+	for _, rule := range rules {
+		spelling[strings.ToLower(rule.name)] = rule.name
+	}
+
+	var (
+		result []Rule
+		names  = map[string]bool{}
+	)
+	for _, rule := range rules {
+		names[rule.name] = true
+	}
+	for _, rule := range rules {
+		conv := &antlrConverter{ruleName: rule.name, names: names}
+		items := conv.alternatives(abnfRename(rule.alts, spelling), rule.line, rule.position)
+
+		converted := newRule(rule.name, nil, conv.expression(items))
+		converted.SourceNode = OfSourceNodeSpan(converted.sourceString, rule.start, rule.end)
+		result = append(append(result, converted), conv.parts...)
+	}
+
+	return newSpannedGrammar(result, end), nil
+}
+
+// abnfParseRules parses the rules of an ABNF grammar, merging alternatives added by =/, and returns them with the end
+// of the source, where the positions follow the options
+func abnfParseRules(source string, opts PositionOptions) ([]abnfRule, SourcePosition) {
+	var (
+		p       = &abnfParser{tokens: abnfTokens([]rune(source), opts)}
+		rules   []abnfRule
+		indexes = map[string]int{}
+	)
+
+	for rule, incremental, ok := p.parseRule(); ok; rule, incremental, ok = p.parseRule() {
+		key := strings.ToLower(rule.name)
+		if index, haveIt := indexes[key]; haveIt && incremental {
+			rules[index].alts = append(rules[index].alts, rule.alts...)
+			continue
+		}
+
+		indexes[key] = len(rules)
+		rules = append(rules, rule)
+	}
+
+	return rules, p.tokens[len(p.tokens)-1].end
+}
+
+// abnfReferences returns the names of rules referred to by alternatives, in order
+func abnfReferences(alts [][]antlrElement) []string {
+	var names []string
+
+	for _, alt := range alts {
+		for _, elem := range alt {
+			if elem.isGroup {
+				names = append(names, abnfReferences(elem.group)...)
+			} else if elem.ruleName != "" {
+				names = append(names, elem.ruleName)
+			}
+		}
+	}
+
+	return names
+}
+
+// abnfRename returns a copy of alternatives whose rule references are changed to the spelling of their definitions
+func abnfRename(alts [][]antlrElement, spelling map[string]string) [][]antlrElement {
+	result := make([][]antlrElement, len(alts))
+
+	for i, alt := range alts {
+		for _, elem := range alt {
+			if elem.isGroup {
+				elem.group = abnfRename(elem.group, spelling)
+			} else if name, haveIt := spelling[strings.ToLower(elem.ruleName)]; haveIt && (elem.ruleName != "") {
+				elem.ruleName = name
+			}
+			result[i] = append(result[i], elem)
+		}
+	}
+
+	return result
+}
+
+// abnfWithoutSpans returns a copy of alternatives without the spans of source they were read from, for the core rules
+// that are not in the source
+func abnfWithoutSpans(alts [][]antlrElement) [][]antlrElement {
+	result := make([][]antlrElement, len(alts))
+
+	for i, alt := range alts {
+		for _, elem := range alt {
+			elem.start, elem.end = SourcePosition{}, SourcePosition{}
+			if elem.isGroup {
+				elem.group = abnfWithoutSpans(elem.group)
+			}
+			result[i] = append(result[i], elem)
+		}
+	}
+
+	return result
+}
+
+// ==== Lexer
+
+// isABNFNameStart returns true if a rune can begin a rule name
+func isABNFNameStart(char rune) bool {
+	return ((char >= 'a') && (char <= 'z')) || ((char >= 'A') && (char <= 'Z'))
+}
+
+// isABNFNameChar returns true if a rune can be part of a rule name after the first letter
+func isABNFNameChar(char rune) bool {
+	return ((char >= 'a') && (char <= 'z')) || ((char >= 'A') && (char <= 'Z')) || ((char >= '0') && (char <= '9')) || (char == '-')
+}
+
+// abnfTokens splits an ABNF grammar into tokens, ending with EOF, skipping whitespace and comments
+func abnfTokens(input []rune, opts PositionOptions) []abnfToken {
+	var (
+		tokens    []abnfToken
+		positions = sourcePositions(input, opts)
+		i         int
+		advance   = func(count int) {
+			if i += count; i > len(input) {
+				i = len(input)
+			}
+		}
+		// digits reads the digits of a base at the current rune
+		digits = func(base int) string {
+			start := i
+			for (i < len(input)) && isABNFDigit(input[i], base) {
+				advance(1)
+			}
+
+			return string(input[start:i])
+		}
+	)
+
+	for {
+		token := abnfToken{line: positions[i].Line, position: positions[i].Position, start: positions[i], end: positions[i]}
+
+		switch {
+		case i >= len(input):
+			token.tokenType = abnfEOF
+			return append(tokens, token)
+
+		case (input[i] == ' ') || (input[i] == '\t') || (input[i] == '\r') || (input[i] == '\n'):
+			advance(1)
+			continue
+
+		case input[i] == ';':
+			for (i < len(input)) && (input[i] != '\n') {
+				advance(1)
+			}
+			continue
+
+		case isABNFNameStart(input[i]):
+			start := i
+			for (i < len(input)) && isABNFNameChar(input[i]) {
+				advance(1)
+			}
+			if (string(input[start:i]) == "super") && isSuperDot(input, i, isABNFNameStart) {
+				for advance(1); (i < len(input)) && isABNFNameChar(input[i]); {
+					advance(1)
+				}
+			}
+			token.tokenType, token.text = abnfName, string(input[start:i])
+
+		case (input[i] >= '0') && (input[i] <= '9'):
+			token.tokenType, token.text = abnfNumber, digits(10)
+
+		case (input[i] == '"') || strings.HasPrefix(string(input[i:]), `%s"`) || strings.HasPrefix(string(input[i:]), `%i"`):
+			token.sensitive = input[i] == '%' && input[i+1] == 's'
+			if input[i] == '%' {
+				advance(2)
+			}
+			advance(1)
+
+			start := i
+			for (i < len(input)) && (input[i] != '"') && (input[i] != '\n') {
+				advance(1)
+			}
+			if (i >= len(input)) || (input[i] != '"') {
+				antlrFail(ErrABNFSyntax, "unterminated string", token.line, token.position)
+			}
+			token.tokenType, token.text = abnfString, string(input[start:i])
+			advance(1)
+
+			if token.text == "" {
+				antlrFail(ErrABNFUnsupported, "empty string", token.line, token.position)
+			}
+
+		case input[i] == '%':
+			advance(1)
+			var base int
+			if i < len(input) {
+				switch unicode.ToLower(input[i]) {
+				case 'b':
+					base = 2
+				case 'd':
+					base = 10
+				case 'x':
+					base = 16
+				}
+			}
+			if base == 0 {
+				antlrFail(ErrABNFSyntax, "invalid numeric value", token.line, token.position)
+			}
+			advance(1)
+
+			var (
+				value = func() rune {
+					str := digits(base)
+					value, err := strconv.ParseUint(str, base, 32)
+					if err != nil {
+						antlrFail(ErrABNFSyntax, "invalid numeric value", token.line, token.position)
+					}
+					return rune(value)
+				}
+				first = value()
+			)
+			token.tokenType = abnfValue
+			switch {
+			case (i < len(input)) && (input[i] == '-'):
+				advance(1)
+				last := value()
+				if last < first {
+					antlrFail(ErrABNFSyntax, "invalid range", token.line, token.position)
+				}
+
+				token.charSet = OfRuneSet(RuneInterval{first, last})
+			default:
+				chars := []rune{first}
+				for (i < len(input)) && (input[i] == '.') {
+					advance(1)
+					chars = append(chars, value())
+				}
+				token.text = string(chars)
+			}
+
+		case input[i] == '<':
+			antlrFail(ErrABNFUnsupported, "prose value", positions[i].Line, positions[i].Position)
+
+		case strings.HasPrefix(string(input[i:]), "=/"):
+			token.tokenType, token.text = abnfPunct, "=/"
+			advance(2)
+
+		case strings.ContainsRune("=/()[]*", input[i]):
+			token.tokenType, token.text = abnfPunct, string(input[i])
+			advance(1)
+
+		default:
+			antlrFail(ErrABNFSyntax, strconv.Quote(string(input[i])), positions[i].Line, positions[i].Position)
+		}
+
+		token.end = positions[i]
+		tokens = append(tokens, token)
+	}
+}
+
+// isABNFDigit returns true if a rune is a digit of a base of at most 16
+func isABNFDigit(char rune, base int) bool {
+	value := strings.IndexRune("0123456789abcdef", unicode.ToLower(char))
+	return (value >= 0) && (value < base)
+}
+
+// ==== Parser
+
+// peek returns the token at an offset from the next token, EOF if there are no more
+func (p *abnfParser) peek(offset int) abnfToken {
+	if p.index+offset >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+
+	return p.tokens[p.index+offset]
+}
+
+// next consumes and returns the next token
+func (p *abnfParser) next() abnfToken {
+	token := p.peek(0)
+	if token.tokenType != abnfEOF {
+		p.index++
+	}
+
+	p.end = token.end
+	return token
+}
+
+// isPunct returns true if a token is the given punctuation
+func (t abnfToken) isPunct(punct string) bool {
+	return (t.tokenType == abnfPunct) && (t.text == punct)
+}
+
+// describe returns a description of a token for error messages
+func (t abnfToken) describe() string {
+	switch t.tokenType {
+	case abnfEOF:
+		return "EOF"
+	case abnfName, abnfNumber:
+		return t.text
+	case abnfString:
+		return strconv.Quote(t.text)
+	case abnfValue:
+		return "numeric value"
+	}
+
+	return "'" + t.text + "'"
+}
+
+// atRule returns true if the next tokens begin a rule, which is a name followed by = or =/
+func (p *abnfParser) atRule() bool {
+	return (p.peek(0).tokenType == abnfName) && (p.peek(1).isPunct("=") || p.peek(1).isPunct("=/"))
+}
+
+// parseRule parses the next rule, returning true if it adds alternatives with =/, and false at EOF
+func (p *abnfParser) parseRule() (abnfRule, bool, bool) {
+	if p.peek(0).tokenType == abnfEOF {
+		return abnfRule{}, false, false
+	}
+
+	if !p.atRule() {
+		token := p.peek(0)
+		antlrFail(ErrABNFSyntax, "expected a rule, found "+token.describe(), token.line, token.position)
+	}
+
+	name := p.next()
+	incremental := p.next().isPunct("=/")
+
+	rule := abnfRule{name: name.text, alts: p.parseAlternatives(), line: name.line, position: name.position, start: name.start}
+	rule.end = p.end
+
+	return rule, incremental, true
+}
+
+// parseAlternatives parses concatenations separated by /
+func (p *abnfParser) parseAlternatives() [][]antlrElement {
+	alts := [][]antlrElement{p.parseConcatenation()}
+	for p.peek(0).isPunct("/") {
+		p.next()
+		alts = append(alts, p.parseConcatenation())
+	}
+
+	return alts
+}
+
+// parseConcatenation parses the repetitions of a concatenation, up to /, ), ], EOF, or the next rule
+func (p *abnfParser) parseConcatenation() []antlrElement {
+	var elems []antlrElement
+
+	for token := p.peek(0); !token.isPunct("/") && !token.isPunct(")") && !token.isPunct("]") && (token.tokenType != abnfEOF) && !p.atRule(); token = p.peek(0) {
+		elems = append(elems, p.parseRepetition())
+	}
+
+	if len(elems) == 0 {
+		token := p.peek(0)
+		antlrFail(ErrABNFSyntax, "expected an element, found "+token.describe(), token.line, token.position)
+	}
+
+	return elems
+}
+
+// parseRepetition parses an element with an optional repeat of n, n*, *m, or n*m
+func (p *abnfParser) parseRepetition() antlrElement {
+	var (
+		start = p.peek(0)
+		n, m  = 1, 1
+		count = func() int {
+			value, _ := strconv.Atoi(p.next().text)
+			return value
+		}
+	)
+
+	if p.peek(0).tokenType == abnfNumber {
+		n = count()
+		m = n
+	}
+	if p.peek(0).isPunct("*") {
+		p.next()
+		if start.tokenType != abnfNumber {
+			n = 0
+		}
+		m = -1
+		if p.peek(0).tokenType == abnfNumber {
+			m = count()
+		}
+	}
+	if ((m >= 0) && (m < n)) || (m == 0) {
+		antlrFail(ErrABNFSyntax, "invalid repetition", start.line, start.position)
+	}
+
+	elem := p.parseElement()
+	if (n == 1) && (m == 1) {
+		return elem
+	}
+
+	// A repeated element that already has a repetition, such as an optional group, is grouped so both apply
+	if (elem.n != 1) || (elem.m != 1) {
+		elem = antlrElement{isGroup: true, group: [][]antlrElement{{elem}}, end: elem.end}
+	}
+	elem.n, elem.m, elem.start = n, m, start.start
+
+	return elem
+}
+
+// parseElement parses a rule name, group, optional group, string, or numeric value
+func (p *abnfParser) parseElement() antlrElement {
+	token := p.next()
+	elem := antlrElement{n: 1, m: 1, start: token.start}
+
+	switch {
+	case token.tokenType == abnfName:
+		elem.ruleName = token.text
+
+	case token.isPunct("("), token.isPunct("["):
+		closing := ")"
+		if token.isPunct("[") {
+			closing = "]"
+			elem.n, elem.m = 0, 1
+		}
+
+		elem.isGroup, elem.group = true, p.parseAlternatives()
+		if next := p.next(); !next.isPunct(closing) {
+			antlrFail(ErrABNFSyntax, "expected '"+closing+"', found "+next.describe(), next.line, next.position)
+		}
+
+	case (token.tokenType == abnfString) && !token.sensitive:
+		// The letters of a case insensitive string are ranges of their upper and lower case, and other characters are strings
+		var (
+			group []antlrElement
+			chars []rune
+			flush = func() {
+				if len(chars) > 0 {
+					group = append(group, antlrElement{terminal: OfTerminalString(stringSource(string(chars)), string(chars)), n: 1, m: 1, start: token.start, end: token.end})
+					chars = nil
+				}
+			}
+		)
+		for _, char := range token.text {
+			if upper, lower := unicode.ToUpper(char), unicode.ToLower(char); upper != lower {
+				flush()
+				theRange := OfRuneSetRunes(upper, lower)
+				group = append(group, antlrElement{terminal: OfTerminalRuneSet(rangeSource(theRange), theRange), n: 1, m: 1, start: token.start, end: token.end})
+			} else {
+				chars = append(chars, char)
+			}
+		}
+		flush()
+
+		if len(group) == 1 {
+			return group[0]
+		}
+		elem.isGroup, elem.group = true, [][]antlrElement{group}
+
+	case (token.tokenType == abnfString) || ((token.tokenType == abnfValue) && token.charSet.IsEmpty()):
+		elem.terminal = OfTerminalString(stringSource(token.text), token.text)
+
+	case token.tokenType == abnfValue:
+		elem.terminal = OfTerminalRuneSet(rangeSource(token.charSet), token.charSet)
+
+	default:
+		antlrFail(ErrABNFSyntax, "unexpected "+token.describe(), token.line, token.position)
+	}
+
+	elem.end = p.end
+	return elem
+}
+
+// ==== Exporter
+
+// ABNF returns the grammar in the Augmented BNF of RFC 5234, one rule per line, where doc comments are ; comments.
+// Strings with letters use the case sensitive %s prefix of RFC 7405, and strings that cannot be quoted are %x values.
+// Ranges are %x values, or alternatives of them. Options are left out, as ABNF has no equivalent.
+func (g Grammar) ABNF() string {
+	var str strings.Builder
+
+	for _, rule := range g.rules {
+		for _, line := range strings.Split(rule.doc, "\n") {
+			if rule.doc != "" {
+				str.WriteString("; ")
+				str.WriteString(line)
+				str.WriteRune('\n')
+			}
+		}
+
+		str.WriteString(rule.name)
+		str.WriteString(" = ")
+		for i, alt := range rule.expr.items {
+			if i > 0 {
+				str.WriteString(" / ")
+			}
+			str.WriteString(abnfAlternative(alt))
+		}
+		str.WriteRune('\n')
+	}
+
+	return str.String()
+}
+
+// abnfAlternative returns an alternative in ABNF
+func abnfAlternative(alt ExpressionItem) string {
+	items := make([]string, len(alt.list))
+	for i, item := range alt.list {
+		items[i] = abnfListItem(item)
+	}
+
+	str := strings.Join(items, " ")
+	switch {
+	case (alt.n == 1) && (alt.m == 1):
+		return str
+	case (alt.n == 0) && (alt.m == 1):
+		return "[" + str + "]"
+	}
+
+	if len(items) > 1 {
+		str = "(" + str + ")"
+	}
+
+	switch {
+	case alt.n == alt.m:
+		return fmt.Sprintf("%d%s", alt.n, str)
+	case alt.m == -1:
+		return fmt.Sprintf("%s*%s", abnfCount(alt.n), str)
+	default:
+		return fmt.Sprintf("%s*%d%s", abnfCount(alt.n), alt.m, str)
+	}
+}
+
+// abnfCount returns the lower bound of a repetition, which is left out if it is 0
+func abnfCount(n int) string {
+	if n == 0 {
+		return ""
+	}
+
+	return strconv.Itoa(n)
+}
+
+// abnfListItem returns a rule name, string, or range in ABNF
+func abnfListItem(item ListItem) string {
+	if item.IsRuleName() {
+		return item.ruleName
+	}
+
+	if item.terminal.IsString() {
+		var (
+			str      = item.terminal.theString
+			letters  bool
+			quotable = true
+		)
+		for _, char := range str {
+			letters = letters || (unicode.ToUpper(char) != unicode.ToLower(char))
+			quotable = quotable && (char >= 0x20) && (char <= 0x7E) && (char != '"')
+		}
+
+		switch {
+		case quotable && letters:
+			return `%s"` + str + `"`
+		case quotable:
+			return `"` + str + `"`
+		}
+
+		values := make([]string, 0, len(str))
+		for _, char := range str {
+			values = append(values, fmt.Sprintf("%X", char))
+		}
+		return "%x" + strings.Join(values, ".")
+	}
+
+	// Ranges are intervals of consecutive characters
+	var intervals []string
+	for _, interval := range item.terminal.theRange.intervals {
+		if interval.Lo == interval.Hi {
+			intervals = append(intervals, fmt.Sprintf("%%x%X", interval.Lo))
+		} else {
+			intervals = append(intervals, fmt.Sprintf("%%x%X-%X", interval.Lo, interval.Hi))
+		}
+	}
+
+	if len(intervals) == 1 {
+		return intervals[0]
+	}
+
+	return "(" + strings.Join(intervals, " / ") + ")"
+}
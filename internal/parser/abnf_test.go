@@ -0,0 +1,98 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportABNF(t *testing.T) {
+	g, err := ImportABNF(strings.NewReader(`
+; URI like
+uri      = scheme ":" *segment [ "?" query ]
+scheme   = alpha *( ALPHA / "+" / "-" )
+segment  = "/" 1*pchar
+segment  =/ %x2A
+pchar    = %x61-7A / %d48-57 / %s"Ab" / %x0D.0A
+query    = 2*3pchar *5"x" 2*[pchar]
+line     = Text CRLF
+text     = "http"
+`))
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		`uri = scheme ':' uri-part uri-part-2
+uri-part = (segment)*
+uri-part-2 = ('?' query)?
+scheme = ALPHA scheme-part-2
+scheme-part = ALPHA | '+' | '-'
+scheme-part-2 = (scheme-part)*
+segment = '/' segment-part | '*'
+segment-part = (pchar)+
+pchar = [a-z] | [0-9] | 'Ab' | '\r\n'
+query = query-part query-part-2 query-part-4
+query-part = (pchar){2,3}
+query-part-2 = ([Xx]){,5}
+query-part-3 = (pchar)?
+query-part-4 = (query-part-3){2,}
+line = text CRLF
+text = [Hh] [Tt] [Tt] [Pp]
+ALPHA = [A-Z] | [a-z]
+CRLF = CR LF
+CR = '\r'
+LF = '\n'`,
+		g.String(),
+	)
+
+	// Positions of rules are kept for diagnostics, and core rules have none
+	assert.Equal(t, 3, g.rules[0].Line())
+	assert.Equal(t, 1, g.rules[0].Position())
+	assert.Equal(t, 0, g.rules[len(g.rules)-1].Line())
+}
+
+func TestImportABNFErrors(t *testing.T) {
+	for source, msg := range map[string]string{
+		`a = "x`:      fmt.Sprintf("%s unterminated string at line 1 position 5", ErrABNFSyntax),
+		`a = ""`:      fmt.Sprintf("%s empty string at line 1 position 5", ErrABNFUnsupported),
+		`a = <x>`:     fmt.Sprintf("%s prose value at line 1 position 5", ErrABNFUnsupported),
+		`a = %q41`:    fmt.Sprintf("%s invalid numeric value at line 1 position 5", ErrABNFSyntax),
+		`a = %x5A-41`: fmt.Sprintf("%s invalid range at line 1 position 5", ErrABNFSyntax),
+		`a = 3*2"x"`:  fmt.Sprintf("%s invalid repetition at line 1 position 5", ErrABNFSyntax),
+		`a = ( "x"`:   fmt.Sprintf("%s expected ')', found EOF at line 1 position 10", ErrABNFSyntax),
+		`a = [ "x" )`: fmt.Sprintf("%s expected ']', found ')' at line 1 position 11", ErrABNFSyntax),
+		`a = `:        fmt.Sprintf("%s expected an element, found EOF at line 1 position 5", ErrABNFSyntax),
+		`"x"`:         fmt.Sprintf("%s expected a rule, found \"x\" at line 1 position 1", ErrABNFSyntax),
+		`a = "x" !`:   fmt.Sprintf("%s \"!\" at line 1 position 9", ErrABNFSyntax),
+	} {
+		_, err := ImportABNF(strings.NewReader(source))
+		assert.Equal(t, msg, err.Error(), source)
+	}
+}
+
+func TestGrammarABNF(t *testing.T) {
+	g := testGrammar(
+		"a = b 'x' | ('Ab')? | (c)* | ('y' c)+ | (b){,3} | (b){2,2}",
+		"b = [abcx] | ('\"')+",
+		"c = 'é' | [z]",
+	)
+	g.rules[1] = g.rules[1].WithDoc("A b\nAnother line")
+
+	abnf := g.ABNF()
+	assert.Equal(
+		t,
+		`a = b %s"x" / [%s"Ab"] / *c / 1*(%s"y" c) / *3b / 2b
+; A b
+; Another line
+b = (%x61-63 / %x78) / 1*%x22
+c = %xE9 / %x7A
+`,
+		abnf,
+	)
+
+	// Importing the output matches the same input, where a range of one character is a string
+	imported, err := ImportABNF(strings.NewReader(abnf))
+	assert.Nil(t, err)
+	assert.Equal(t, "c = 'é' | 'z'", imported.rules[len(imported.rules)-1].String())
+}
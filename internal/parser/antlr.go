@@ -0,0 +1,831 @@
+package parser
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ANTLR import error message constants
+const (
+	ErrANTLRSyntax      = "Invalid ANTLR grammar"
+	ErrANTLRUnsupported = "Unsupported ANTLR construct"
+	ErrANTLREmptyRule   = "An ANTLR rule must be able to match something"
+)
+
+// antlrTokenType is the type of a token of an ANTLR grammar
+type antlrTokenType uint
+
+const (
+	antlrEOF antlrTokenType = iota
+	antlrIdent
+	antlrString
+	antlrCharSet
+	antlrAction
+	antlrOptions
+	antlrPunct
+)
+
+// antlrToken is a token of an ANTLR grammar
+type antlrToken struct {
+	tokenType antlrTokenType
+	// identifier, decoded string, or punctuation
+	text     string
+	charSet  RuneSet
+	line     int
+	position int
+	// span of source of the token
+	start, end SourcePosition
+}
+
+// antlrLexer splits an ANTLR grammar into tokens
+type antlrLexer struct {
+	source   *bufio.Reader
+	next     rune
+	line     int
+	position int
+	// byte offset and size of the next rune
+	offset, size int
+	// conventions of the positions
+	positions PositionOptions
+}
+
+// antlrElement is an element of an ANTLR alternative: a rule reference, terminal, or group, with a repetition,
+// and the span of source it was read from
+type antlrElement struct {
+	ruleName   string
+	terminal   Terminal
+	group      [][]antlrElement
+	isGroup    bool
+	n, m       int
+	lazy       bool
+	start, end SourcePosition
+}
+
+// antlrRule is an ANTLR rule
+type antlrRule struct {
+	name       string
+	alts       [][]antlrElement
+	line       int
+	position   int
+	start, end SourcePosition
+}
+
+// antlrParser parses ANTLR tokens into rules
+type antlrParser struct {
+	lexer  *antlrLexer
+	token  antlrToken
+	peeked bool
+	// end of the last token consumed
+	end SourcePosition
+	// names of imported grammars
+	imports []string
+}
+
+// ImportANTLR reads an ANTLR v4 grammar (.g4 file) and converts its rules to a Grammar, in the order they are defined.
+// Both parser and lexer rules are converted, as both are matched at the character level.
+//
+// Supported constructs are rule references, string literals, character sets, 'a'..'z' ranges, parenthesized alternatives,
+// and the ?, *, and + suffixes, where the non-greedy suffixes ??, *?, and +? are lazy.
+// The following are ignored: grammar headers, options, tokens, channels, imports, named actions, actions, predicates,
+// element options such as <assoc=right>, labels, alternative labels, lexer commands, fragment, and EOF.
+// Negated sets, wildcards, rule arguments and return values are not supported.
+//
+// As a Grammar has one repetition per alternative over a flat list of items, a repeated element that is not a whole
+// alternative, and a group of alternatives inside an alternative, become new rules named after the enclosing rule,
+// such as expr-part and expr-part-2.
+// An empty alternative makes the other alternatives of its rule optional, and a group that matches nothing, such as (),
+// is left out.
+//
+// Returns an error if the grammar is invalid or uses unsupported constructs.
+func ImportANTLR(source io.Reader) (Grammar, error) {
+	grammar, _, err := importANTLR(source, PositionOptions{})
+	return grammar, err
+}
+
+// importANTLR is ImportANTLR, that also returns the names of the grammars imported by import statements, where the
+// positions of the grammar follow the options
+func importANTLR(source io.Reader, positions PositionOptions) (grammar Grammar, imports []string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if e, isa := r.(antlrError); isa {
+				err = e.err
+				return
+			}
+			panic(r)
+		}
+	}()
+
+	p := &antlrParser{
+		lexer: &antlrLexer{
+			source:    bufio.NewReader(source),
+			line:      1,
+			position:  positions.first(),
+			positions: positions,
+		},
+	}
+	p.lexer.load()
+
+	var (
+		rules []antlrRule
+		names = map[string]bool{}
+	)
+	for rule, ok := p.parseRule(); ok; rule, ok = p.parseRule() {
+		rules = append(rules, rule)
+		names[rule.name] = true
+	}
+
+	var result []Rule
+	for _, rule := range rules {
+		conv := &antlrConverter{ruleName: rule.name, names: names}
+		items := conv.alternatives(rule.alts, rule.line, rule.position)
+
+		converted := newRule(rule.name, nil, conv.expression(items))
+		converted.SourceNode = OfSourceNodeSpan(converted.sourceString, rule.start, rule.end)
+		result = append(append(result, converted), conv.parts...)
+	}
+
+	return newSpannedGrammar(result, p.end), p.imports, nil
+}
+
+// antlrError wraps an error panicked while importing, so that other panics are not recovered
+type antlrError struct {
+	err error
+}
+
+// fail panics with an error at a line and position
+func antlrFail(msg string, details string, line, position int) {
+	panic(antlrError{fmt.Errorf("%s %s at line %d position %d", msg, details, line, position)})
+}
+
+// ==== Lexer
+
+// read reads the rune after the next rune, -1 at EOF, where EOF is at the position after the last rune
+func (l *antlrLexer) read() {
+	previous := l.next
+	if previous < 0 {
+		return
+	}
+
+	l.load()
+	if endsLine(previous, l.next) {
+		l.line++
+		l.position = l.positions.first()
+	} else {
+		l.position = l.positions.next(l.position, previous)
+	}
+}
+
+// load reads the next rune at the position, -1 at EOF
+func (l *antlrLexer) load() {
+	l.offset += l.size
+	char, size, err := l.source.ReadRune()
+	if err != nil {
+		char, size = -1, 0
+	}
+
+	l.next, l.size = char, size
+}
+
+// here returns the position of the next rune
+func (l *antlrLexer) here() SourcePosition {
+	return SourcePosition{Offset: l.offset, Line: l.line, Position: l.position}
+}
+
+// skipTrivia skips whitespace and comments
+func (l *antlrLexer) skipTrivia() {
+	for {
+		switch {
+		case (l.next == ' ') || (l.next == '\t') || (l.next == '\r') || (l.next == '\n'):
+			l.read()
+
+		case l.next == '/':
+			line, position := l.line, l.position
+			l.read()
+			switch l.next {
+			case '/':
+				for (l.next != '\n') && (l.next >= 0) {
+					l.read()
+				}
+			case '*':
+				l.read()
+				for prev := rune(0); !((prev == '*') && (l.next == '/')); l.read() {
+					if l.next < 0 {
+						antlrFail(ErrANTLRSyntax, "unterminated comment", line, position)
+					}
+					prev = l.next
+				}
+				l.read()
+			default:
+				antlrFail(ErrANTLRSyntax, "'/'", line, position)
+			}
+
+		default:
+			return
+		}
+	}
+}
+
+// escape reads an escape sequence after a backslash, returning the rune it represents
+func (l *antlrLexer) escape() rune {
+	line, position := l.line, l.position
+	char := l.next
+	l.read()
+
+	switch char {
+	case 'n':
+		return '\n'
+	case 'r':
+		return '\r'
+	case 't':
+		return '\t'
+	case 'b':
+		return '\b'
+	case 'f':
+		return '\f'
+	case 'u':
+		var hex strings.Builder
+		if l.next == '{' {
+			for l.read(); (l.next != '}') && (l.next >= 0); l.read() {
+				hex.WriteRune(l.next)
+			}
+			l.read()
+		} else {
+			for i := 0; i < 4; i++ {
+				hex.WriteRune(l.next)
+				l.read()
+			}
+		}
+
+		value, err := strconv.ParseUint(hex.String(), 16, 32)
+		if err != nil {
+			antlrFail(ErrANTLRSyntax, "unicode escape", line, position)
+		}
+		return rune(value)
+	case -1:
+		antlrFail(ErrANTLRSyntax, "EOF in escape", line, position)
+	}
+
+	// \\, \', \", \], \-, and any other character represent themselves
+	return char
+}
+
+// nextToken reads the next token
+func (l *antlrLexer) nextToken() antlrToken {
+	l.skipTrivia()
+
+	token := antlrToken{line: l.line, position: l.position, start: l.here()}
+	switch {
+	case l.next < 0:
+		token.tokenType = antlrEOF
+
+	case ((l.next >= 'a') && (l.next <= 'z')) || ((l.next >= 'A') && (l.next <= 'Z')) || (l.next == '_'):
+		var ident strings.Builder
+		for ((l.next >= 'a') && (l.next <= 'z')) || ((l.next >= 'A') && (l.next <= 'Z')) || (l.next == '_') || ((l.next >= '0') && (l.next <= '9')) {
+			ident.WriteRune(l.next)
+			l.read()
+
+			// super.name refers to a rule of the grammar this one extends
+			if (ident.String() == "super") && (l.next == '.') {
+				if next, err := l.source.Peek(1); (err == nil) && isW3CNameStart(rune(next[0])) {
+					ident.WriteRune(l.next)
+					l.read()
+				}
+			}
+		}
+		token.tokenType, token.text = antlrIdent, ident.String()
+
+	case l.next == '\'':
+		var str strings.Builder
+		for l.read(); l.next != '\''; {
+			switch l.next {
+			case -1, '\n':
+				antlrFail(ErrANTLRSyntax, "unterminated string", token.line, token.position)
+			case '\\':
+				l.read()
+				str.WriteRune(l.escape())
+			default:
+				str.WriteRune(l.next)
+				l.read()
+			}
+		}
+		l.read()
+		token.tokenType, token.text = antlrString, str.String()
+
+	case l.next == '[':
+		var (
+			chars []rune
+			dash  []bool
+		)
+		for l.read(); l.next != ']'; {
+			switch l.next {
+			case -1:
+				antlrFail(ErrANTLRSyntax, "unterminated character set", token.line, token.position)
+			case '\\':
+				l.read()
+				chars, dash = append(chars, l.escape()), append(dash, false)
+			default:
+				chars, dash = append(chars, l.next), append(dash, l.next == '-')
+				l.read()
+			}
+		}
+		l.read()
+
+		// An unescaped - between two characters is a range
+		var intervals []RuneInterval
+		for i := 0; i < len(chars); i++ {
+			if (i+2 < len(chars)) && dash[i+1] {
+				intervals = append(intervals, RuneInterval{chars[i], chars[i+2]})
+				i += 2
+				continue
+			}
+			intervals = append(intervals, RuneInterval{chars[i], chars[i]})
+		}
+		token.tokenType, token.charSet = antlrCharSet, OfRuneSet(intervals...)
+
+	case l.next == '{':
+		// Actions are skipped, including nested braces
+		for depth := 0; ; {
+			switch l.next {
+			case -1:
+				antlrFail(ErrANTLRSyntax, "unterminated action", token.line, token.position)
+			case '{':
+				depth++
+			case '}':
+				depth--
+			}
+			l.read()
+			if depth == 0 {
+				break
+			}
+		}
+		token.tokenType = antlrAction
+
+	case l.next == '<':
+		for l.next != '>' {
+			if l.next < 0 {
+				antlrFail(ErrANTLRSyntax, "unterminated element options", token.line, token.position)
+			}
+			l.read()
+		}
+		l.read()
+		token.tokenType = antlrOptions
+
+	default:
+		char := l.next
+		l.read()
+		token.tokenType, token.text = antlrPunct, string(char)
+
+		// Two character punctuation
+		if pair := token.text + string(l.next); (pair == "..") || (pair == "+=") || (pair == "->") || (pair == "::") {
+			l.read()
+			token.text = pair
+		}
+	}
+
+	token.end = l.here()
+	return token
+}
+
+// ==== Parser
+
+// next returns the next token
+func (p *antlrParser) next() antlrToken {
+	if !p.peeked {
+		p.token = p.lexer.nextToken()
+	}
+
+	p.peeked = false
+	p.end = p.token.end
+	return p.token
+}
+
+// peek returns the next token without consuming it
+func (p *antlrParser) peek() antlrToken {
+	if !p.peeked {
+		p.token = p.lexer.nextToken()
+		p.peeked = true
+	}
+
+	return p.token
+}
+
+// isPunct returns true if a token is the given punctuation
+func (t antlrToken) isPunct(punct string) bool {
+	return (t.tokenType == antlrPunct) && (t.text == punct)
+}
+
+// isIdent returns true if a token is the given identifier
+func (t antlrToken) isIdent(ident string) bool {
+	return (t.tokenType == antlrIdent) && (t.text == ident)
+}
+
+// describe returns a description of a token for error messages
+func (t antlrToken) describe() string {
+	switch t.tokenType {
+	case antlrEOF:
+		return "EOF"
+	case antlrString:
+		return stringSource(t.text)
+	case antlrCharSet:
+		return "character set"
+	case antlrAction:
+		return "action"
+	case antlrOptions:
+		return "element options"
+	}
+
+	return "'" + t.text + "'"
+}
+
+// expect consumes a punctuation token, failing if the next token is different
+func (p *antlrParser) expect(punct string) {
+	if token := p.next(); !token.isPunct(punct) {
+		antlrFail(ErrANTLRSyntax, "expected '"+punct+"', found "+token.describe(), token.line, token.position)
+	}
+}
+
+// skipToSemicolon skips tokens through the next semicolon
+func (p *antlrParser) skipToSemicolon() {
+	for token := p.next(); !token.isPunct(";"); token = p.next() {
+		if token.tokenType == antlrEOF {
+			antlrFail(ErrANTLRSyntax, "expected ';', found EOF", token.line, token.position)
+		}
+	}
+}
+
+// parseImports parses the names of the grammars of an import statement through the semicolon.
+// In import A, B = C; the grammars are A and C, where B is a local name of C.
+func (p *antlrParser) parseImports() {
+	for token := p.next(); !token.isPunct(";"); token = p.next() {
+		switch {
+		case token.tokenType == antlrEOF:
+			antlrFail(ErrANTLRSyntax, "expected ';', found EOF", token.line, token.position)
+		case token.isPunct("=") && (len(p.imports) > 0):
+			p.imports = p.imports[:len(p.imports)-1]
+		case token.tokenType == antlrIdent:
+			p.imports = append(p.imports, token.text)
+		}
+	}
+}
+
+// parseRule skips any declarations, and parses the next rule, returning false at EOF
+func (p *antlrParser) parseRule() (antlrRule, bool) {
+	for {
+		token := p.next()
+
+		switch {
+		case token.tokenType == antlrEOF:
+			return antlrRule{}, false
+
+		case token.isIdent("lexer") || token.isIdent("parser") || token.isIdent("grammar") || token.isIdent("mode"):
+			p.skipToSemicolon()
+
+		case token.isIdent("import"):
+			p.parseImports()
+
+		case token.isIdent("options") || token.isIdent("tokens") || token.isIdent("channels"):
+			if next := p.next(); next.tokenType != antlrAction {
+				antlrFail(ErrANTLRSyntax, "expected '{', found "+next.describe(), next.line, next.position)
+			}
+
+		case token.isPunct("@"):
+			// Named action such as @header or @parser::members
+			for next := p.next(); next.tokenType != antlrAction; next = p.next() {
+				if next.tokenType == antlrEOF {
+					antlrFail(ErrANTLRSyntax, "expected '{', found EOF", next.line, next.position)
+				}
+			}
+
+		case token.isIdent("fragment"):
+			continue
+
+		case token.tokenType == antlrIdent:
+			rule := antlrRule{name: token.text, line: token.line, position: token.position, start: token.start}
+
+			// Skip rule options and actions before the colon
+			for next := p.peek(); !next.isPunct(":"); next = p.peek() {
+				switch {
+				case next.tokenType == antlrCharSet, next.isIdent("returns"), next.isIdent("locals"), next.isIdent("throws"):
+					antlrFail(ErrANTLRUnsupported, "rule arguments and return values", next.line, next.position)
+				case next.isIdent("options"), next.tokenType == antlrAction:
+					p.next()
+				default:
+					antlrFail(ErrANTLRSyntax, "expected ':', found "+next.describe(), next.line, next.position)
+				}
+			}
+			p.next()
+
+			rule.alts = p.parseAlternatives()
+			p.expect(";")
+			rule.end = p.end
+
+			// Skip exception handlers
+			for next := p.peek(); next.isIdent("catch") || next.isIdent("finally"); next = p.peek() {
+				for next = p.next(); next.tokenType != antlrAction; next = p.next() {
+					if next.tokenType == antlrEOF {
+						antlrFail(ErrANTLRSyntax, "expected '{', found EOF", next.line, next.position)
+					}
+				}
+			}
+
+			return rule, true
+
+		default:
+			antlrFail(ErrANTLRSyntax, "expected a rule, found "+token.describe(), token.line, token.position)
+		}
+	}
+}
+
+// parseAlternatives parses alternatives separated by |
+func (p *antlrParser) parseAlternatives() [][]antlrElement {
+	alts := [][]antlrElement{p.parseAlternative()}
+	for p.peek().isPunct("|") {
+		p.next()
+		alts = append(alts, p.parseAlternative())
+	}
+
+	return alts
+}
+
+// parseAlternative parses the elements of an alternative, up to |, ), or ;
+func (p *antlrParser) parseAlternative() []antlrElement {
+	var elems []antlrElement
+
+	for {
+		token := p.peek()
+
+		switch {
+		case token.isPunct("|"), token.isPunct(")"), token.isPunct(";"), token.tokenType == antlrEOF:
+			return elems
+
+		case token.isPunct("#"):
+			// Alternative label
+			p.next()
+			p.next()
+
+		case token.isPunct("->"):
+			// Lexer commands continue to the end of the alternative
+			for next := p.peek(); !next.isPunct("|") && !next.isPunct(")") && !next.isPunct(";") && (next.tokenType != antlrEOF); next = p.peek() {
+				p.next()
+			}
+
+		case (token.tokenType == antlrAction) || (token.tokenType == antlrOptions):
+			// Actions, predicates, and element options
+			p.next()
+			if p.peek().isPunct("?") {
+				p.next()
+			}
+
+		default:
+			if elem, ok := p.parseElement(); ok {
+				elems = append(elems, elem)
+			}
+		}
+	}
+}
+
+// parseElement parses an element and its suffix, returning false for an element that is ignored
+func (p *antlrParser) parseElement() (antlrElement, bool) {
+	token := p.next()
+	elem := antlrElement{n: 1, m: 1}
+	keep := true
+
+	// Labels
+	if token.tokenType == antlrIdent {
+		if next := p.peek(); next.isPunct("=") || next.isPunct("+=") {
+			p.next()
+			token = p.next()
+		}
+	}
+
+	elem.start = token.start
+	switch {
+	case token.isIdent("EOF"):
+		keep = false
+
+	case token.tokenType == antlrIdent:
+		elem.ruleName = token.text
+
+	case token.tokenType == antlrString:
+		if token.text == "" {
+			antlrFail(ErrANTLRUnsupported, "empty string", token.line, token.position)
+		}
+
+		if p.peek().isPunct("..") {
+			p.next()
+			to := p.next()
+			if (to.tokenType != antlrString) || ([]rune(token.text)[0] > []rune(to.text)[0]) {
+				antlrFail(ErrANTLRSyntax, "invalid range", to.line, to.position)
+			}
+
+			theRange := OfRuneSet(RuneInterval{[]rune(token.text)[0], []rune(to.text)[0]})
+			elem.terminal = OfTerminalRuneSet(rangeSource(theRange), theRange)
+		} else {
+			elem.terminal = OfTerminalString(stringSource(token.text), token.text)
+		}
+
+	case token.tokenType == antlrCharSet:
+		if token.charSet.IsEmpty() {
+			antlrFail(ErrANTLRUnsupported, "empty character set", token.line, token.position)
+		}
+		elem.terminal = OfTerminalRuneSet(rangeSource(token.charSet), token.charSet)
+
+	case token.isPunct("("):
+		elem.isGroup = true
+		elem.group = p.parseAlternatives()
+		p.expect(")")
+
+	case token.isPunct("~"), token.isPunct("."):
+		antlrFail(ErrANTLRUnsupported, token.describe(), token.line, token.position)
+
+	default:
+		antlrFail(ErrANTLRSyntax, "unexpected "+token.describe(), token.line, token.position)
+	}
+
+	// Suffix, where a following ? makes it lazy
+	switch next := p.peek(); {
+	case next.isPunct("?"):
+		elem.n, elem.m = 0, 1
+	case next.isPunct("*"):
+		elem.n, elem.m = 0, -1
+	case next.isPunct("+"):
+		elem.n, elem.m = 1, -1
+	default:
+		elem.end = p.end
+		return elem, keep
+	}
+	p.next()
+	if p.peek().isPunct("?") {
+		p.next()
+		elem.lazy = true
+	}
+
+	elem.end = p.end
+	return elem, keep
+}
+
+// ==== Converter
+
+// antlrConverter converts the alternatives of one ANTLR rule, adding part rules as needed
+type antlrConverter struct {
+	ruleName string
+	names    map[string]bool
+	parts    []Rule
+	// error message for a rule with only empty alternatives, ErrANTLREmptyRule if empty
+	emptyRuleErr string
+}
+
+// matchesNothing returns true if the element is a group whose alternatives are all empty, once the groups in them that
+// match nothing are removed, such as () or ( | ()* ), however it is repeated
+func (e antlrElement) matchesNothing() bool {
+	if !e.isGroup {
+		return false
+	}
+
+	for _, alt := range e.group {
+		if len(withoutEmptyGroups(alt)) > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// withoutEmptyGroups returns the elements without the groups that match nothing, which an alternative can leave out
+func withoutEmptyGroups(elems []antlrElement) []antlrElement {
+	var result []antlrElement
+	for _, elem := range elems {
+		if !elem.matchesNothing() {
+			result = append(result, elem)
+		}
+	}
+
+	return result
+}
+
+// alternatives converts alternatives, making them optional if any are empty, where an alternative of only groups that
+// match nothing is empty
+func (c *antlrConverter) alternatives(alts [][]antlrElement, line, position int) []ExpressionItem {
+	var (
+		nonEmpty [][]antlrElement
+		items    []ExpressionItem
+	)
+	for _, alt := range alts {
+		if alt = withoutEmptyGroups(alt); len(alt) > 0 {
+			nonEmpty = append(nonEmpty, alt)
+		}
+	}
+
+	if len(nonEmpty) == 0 {
+		msg := c.emptyRuleErr
+		if msg == "" {
+			msg = ErrANTLREmptyRule
+		}
+		antlrFail(msg, c.ruleName, line, position)
+	}
+
+	for _, alt := range nonEmpty {
+		items = append(items, c.alternative(alt))
+	}
+
+	if len(nonEmpty) < len(alts) {
+		var optional ExpressionItem
+		if (len(items) == 1) && (items[0].n == 1) && (items[0].m == 1) {
+			optional = newExpressionItem(items[0].list, 0, 1)
+		} else {
+			optional = newExpressionItem([]ListItem{c.part(items)}, 0, 1)
+		}
+		optional.SourceNode = optional.withSpan(spanOf(items[0].SourceNode, items[len(items)-1].SourceNode))
+
+		return []ExpressionItem{optional}
+	}
+
+	return items
+}
+
+// expression constructs an expression of alternatives, spanning the source of the alternatives
+func (c *antlrConverter) expression(items []ExpressionItem) Expression {
+	expr := newExpression(items)
+	expr.SourceNode = expr.withSpan(spanOf(items[0].SourceNode, items[len(items)-1].SourceNode))
+
+	return expr
+}
+
+// spanOf returns the span of source from the beginning of one node to the end of another
+func spanOf(first, last SourceNode) (start, end SourcePosition) {
+	start, _ = first.Span()
+	_, end = last.Span()
+
+	return start, end
+}
+
+// newSpannedGrammar constructs a Grammar of the rules, spanning the source from the beginning to end
+func newSpannedGrammar(rules []Rule, end SourcePosition) Grammar {
+	g := newGrammar(rules)
+	g.SourceNode = g.withSpan(SourcePosition{Line: 1, Position: 1}, end)
+
+	return g
+}
+
+// alternative converts an alternative, where a single repeated element is the repetition of the alternative
+func (c *antlrConverter) alternative(elems []antlrElement) ExpressionItem {
+	var item ExpressionItem
+	if elem := elems[0]; (len(elems) == 1) && ((elem.n != 1) || (elem.m != 1)) {
+		if elem.isGroup && (len(elem.group) == 1) {
+			item = newLazyExpressionItem(c.list(elem.group[0]), elem.n, elem.m, elem.lazy)
+		} else {
+			elem.n, elem.m, elem.lazy = 1, 1, false
+			item = newLazyExpressionItem(c.list([]antlrElement{elem}), elems[0].n, elems[0].m, elems[0].lazy)
+		}
+	} else {
+		item = newExpressionItem(c.list(elems), 1, 1)
+	}
+	item.SourceNode = item.withSpan(elems[0].start, elems[len(elems)-1].end)
+
+	return item
+}
+
+// list converts elements to list items, where a group of one alternative is inlined, and a group that matches nothing
+// is left out
+func (c *antlrConverter) list(elems []antlrElement) []ListItem {
+	var result []ListItem
+
+	for _, elem := range withoutEmptyGroups(elems) {
+		var item ListItem
+		switch {
+		case (elem.n != 1) || (elem.m != 1):
+			item = c.part([]ExpressionItem{c.alternative([]antlrElement{elem})})
+		case elem.isGroup && (len(elem.group) == 1):
+			result = append(result, c.list(elem.group[0])...)
+			continue
+		case elem.isGroup:
+			item = c.part(c.alternatives(elem.group, 0, 0))
+		case elem.ruleName != "":
+			item = newListItemRuleName(elem.ruleName)
+		default:
+			terminal := elem.terminal
+			terminal.SourceNode = terminal.withSpan(elem.start, elem.end)
+			item = OfListItemTerminal(terminal.String(), terminal, nil)
+		}
+		item.SourceNode = item.withSpan(elem.start, elem.end)
+
+		result = append(result, item)
+	}
+
+	return result
+}
+
+// part adds a part rule with the given alternatives, and returns a reference to it
+func (c *antlrConverter) part(items []ExpressionItem) ListItem {
+	var (
+		name = uniqueRuleName(c.ruleName+"-part", c.names)
+		rule = newRule(name, nil, c.expression(items))
+	)
+	rule.SourceNode = rule.withSpan(spanOf(items[0].SourceNode, items[len(items)-1].SourceNode))
+	c.parts = append(c.parts, rule)
+
+	return newListItemRuleName(name)
+}
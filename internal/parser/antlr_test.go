@@ -0,0 +1,94 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportANTLR(t *testing.T) {
+	g, err := ImportANTLR(strings.NewReader(`
+grammar Expr;
+
+options { language = Go; }
+@header { import "fmt" }
+
+/* Expressions */
+expr
+    : expr ('*' | '/') expr  # Mul
+    | left=expr op+='+' right=expr {fmt.Println("add")}
+    | INT
+    | '(' expr ')'
+    ;
+
+list : item (',' item)* ','? EOF ;
+item : {true}? INT | <assoc=right> ID | ;
+args : (INT ID)+ ;
+
+// Lexer rules
+INT : [0-9]+ ;
+ID  : ('a'..'c' | [xyz_\-])+? ;
+fragment ESC : '\\' ['\\A] ;
+WS  : [ \t\r\n]+ -> skip ;
+`))
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		`expr = expr expr-part expr | expr '+' expr | INT | '(' expr ')'
+expr-part = '*' | '/'
+list = item list-part list-part-2
+list-part = (',' item)*
+list-part-2 = (',')?
+item = (item-part)?
+item-part = INT | ID
+args = (INT ID)+
+INT = ([0-9])+
+ID = (ID-part)+?
+ID-part = [a-c] | [_x-z-]
+ESC = '\\' ['A\\]
+WS = ([\t\n\r ])+`,
+		g.String(),
+	)
+
+	// Positions of rules are kept for diagnostics
+	assert.Equal(t, 8, g.rules[0].Line())
+	assert.Equal(t, 1, g.rules[0].Position())
+	assert.Equal(t, 15, g.rules[2].Line())
+}
+
+func TestImportANTLREmptyGroups(t *testing.T) {
+	for source, expected := range map[string]string{
+		"a : () 'x' ;":          "a = 'x'",
+		"a : ()+ 'y' | 'z' ;":   "a = 'y' | 'z'",
+		"a : ('x' ()) ()* | ;":  "a = ('x')?",
+		"a : 'x' (() | ()?) ; ": "a = 'x'",
+	} {
+		g, err := ImportANTLR(strings.NewReader(source))
+		assert.Nil(t, err, source)
+		assert.Equal(t, expected, g.String(), source)
+	}
+}
+
+func TestImportANTLRErrors(t *testing.T) {
+	for source, msg := range map[string]string{
+		"a : ~'x' ;":        fmt.Sprintf("%s '~' at line 1 position 5", ErrANTLRUnsupported),
+		"a : . ;":           fmt.Sprintf("%s '.' at line 1 position 5", ErrANTLRUnsupported),
+		"a[int x] : 'x' ;":  fmt.Sprintf("%s rule arguments and return values at line 1 position 2", ErrANTLRUnsupported),
+		"a : 'x'":           fmt.Sprintf("%s expected ';', found EOF at line 1 position 8", ErrANTLRSyntax),
+		"a : ( 'x' ;":       fmt.Sprintf("%s expected ')', found ';' at line 1 position 11", ErrANTLRSyntax),
+		"a : 'x ;":          fmt.Sprintf("%s unterminated string at line 1 position 5", ErrANTLRSyntax),
+		"a : | ;":           fmt.Sprintf("%s a at line 1 position 1", ErrANTLREmptyRule),
+		"a : () ;":          fmt.Sprintf("%s a at line 1 position 1", ErrANTLREmptyRule),
+		"a : ( | ()* ) ;":   fmt.Sprintf("%s a at line 1 position 1", ErrANTLREmptyRule),
+		"a : 'z'..'a' ;":    fmt.Sprintf("%s invalid range at line 1 position 10", ErrANTLRSyntax),
+		"a : 'x' ;\n/* x":   fmt.Sprintf("%s unterminated comment at line 2 position 1", ErrANTLRSyntax),
+		"a : 'x' ; ;":       fmt.Sprintf("%s expected a rule, found ';' at line 1 position 11", ErrANTLRSyntax),
+		"a : '' ;":          fmt.Sprintf("%s empty string at line 1 position 5", ErrANTLRUnsupported),
+		"a : 'x' ; catch [": fmt.Sprintf("%s unterminated character set at line 1 position 17", ErrANTLRSyntax),
+	} {
+		_, err := ImportANTLR(strings.NewReader(source))
+		assert.Equal(t, msg, err.Error(), source)
+	}
+}
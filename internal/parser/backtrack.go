@@ -0,0 +1,457 @@
+package parser
+
+// matchResult is one way a list of items can match, ending at an offset
+type matchResult struct {
+	end      int
+	children []Node
+}
+
+// matchResults are the ways items can match in order, where only the first way that ends at each offset is kept,
+// unless all is true. A later way that ends at the same offset lets the rest of the input match in the same ways,
+// so it can only change the tree when a predicate of the rule sees its children.
+type matchResults struct {
+	all     bool
+	results []matchResult
+	// ends of the results, once there are too many to search
+	ends map[int]bool
+}
+
+// The number of results matchResults searches for an end offset before it indexes them
+const matchResultsSearch = 8
+
+// newMatchResults constructs the matchResults of the named rule, which keeps every way it can match if it has a predicate
+func (b *backtracker) newMatchResults(name string) *matchResults {
+	return &matchResults{all: b.engine.options.Predicates[name] != nil}
+}
+
+// has returns true if a result ends at end
+func (r *matchResults) has(end int) bool {
+	if r.ends != nil {
+		return r.ends[end]
+	}
+
+	for _, result := range r.results {
+		if result.end == end {
+			return true
+		}
+	}
+
+	return false
+}
+
+// keep adds each match that is kept
+func (r *matchResults) keep(matches ...matchResult) {
+	for _, match := range matches {
+		if r.all || !r.has(match.end) {
+			r.put(match)
+		}
+	}
+}
+
+// add adds the way match follows the children of a previous match if it is kept, returning true if it is.
+// The children of the first way added after a previous match, which extended is false for, are appended to the
+// children of the previous match in place, so that a chain of repetitions shares one slice of children instead of
+// copying it at each repetition. Other ways copy the children of the previous match.
+func (r *matchResults) add(prev []Node, extended bool, match matchResult) bool {
+	if !r.all && r.has(match.end) {
+		return false
+	}
+
+	children := match.children[:len(match.children):len(match.children)]
+	if len(prev) > 0 {
+		if extended {
+			prev = prev[:len(prev):len(prev)]
+		}
+		children = append(prev, match.children...)
+	}
+	r.put(matchResult{end: match.end, children: children})
+
+	return true
+}
+
+// put adds a result
+func (r *matchResults) put(result matchResult) {
+	r.results = append(r.results, result)
+
+	switch {
+	case r.ends != nil:
+		r.ends[result.end] = true
+	case !r.all && (len(r.results) == matchResultsSearch):
+		r.ends = map[int]bool{}
+		for _, result := range r.results {
+			r.ends[result.end] = true
+		}
+	}
+}
+
+// backtracker matches input by trying each alternative and repetition of each rule in turn.
+// Every way a rule can match up to each offset is returned, so that later list items can backtrack into earlier ones,
+// except in PEG mode, where only the first match of each rule and alternative is returned.
+// Only the first way a rule can match up to an offset is returned unless the rule has a predicate,
+// so that the number of matches kept at each offset is at most the length of the input.
+type backtracker struct {
+	engine *Engine
+	ctx    *ParseContext
+	input  []rune
+	memo   MemoStore
+	// matches of the alternatives shared between rules at each offset
+	shared map[sharedKey][]matchResult
+	// the input as a string, and the offset in it of each rune of the input and of the end, so that the text of each
+	// match is a substring of it instead of a copy
+	source  string
+	offsets []int
+	// all of the input, of which input is the region starting at regionStart, for the positions of limit errors
+	whole       []rune
+	regionStart int
+	// furthest rune that a terminal failed to match, and what was expected there
+	failure parseFailure
+	// rules being matched, innermost last
+	active []activeRule
+	// true while matching the trivia rule, which does not skip trivia
+	inTrivia bool
+	// rules with a maximum length that are being matched
+	lengths []activeLimit
+	// number of rules tried, and the nesting of the rules being matched
+	steps int
+	depth int
+	// regions of input skipped to recover from errors
+	regions []recoveryRegion
+	// decisions of the parse, if its replay log is being recorded
+	replay *replayRecord
+	// quick check sets of the alternatives that cannot match the next rune, or nil if no alternatives are skipped
+	quickCheck *QuickCheck
+}
+
+// newBacktracker constructs a backtracker
+func newBacktracker(engine *Engine, ctx *ParseContext, input []rune) *backtracker {
+	b := &backtracker{
+		engine: engine,
+		ctx:    ctx,
+		input:  input,
+		whole:  input,
+		replay: ctx.replay,
+		shared: map[sharedKey][]matchResult{},
+	}
+	b.source = string(input)
+	b.offsets = make([]int, 0, len(input)+1)
+	for offset := range b.source {
+		b.offsets = append(b.offsets, offset)
+	}
+	b.offsets = append(b.offsets, len(b.source))
+
+	if engine.options.MemoStore != nil {
+		b.memo = engine.options.MemoStore()
+	} else {
+		b.memo = newMemoTable(engine.options.MemoSize, engine.options.MemoEviction)
+	}
+
+	return b
+}
+
+// parse returns the first match of the start rule that matches all of the input, including trailing trivia, and true.
+// Otherwise, it returns the longest match of only part of the input if there is one, false,
+// and the furthest rune that failed to match.
+func (b *backtracker) parse() (Node, bool, parseFailure) {
+	var longest Node
+
+	for _, node := range b.matchRule(b.engine.options.Start, 0) {
+		trivia, end := b.skipTrivia(node.end)
+		if end == len(b.input) {
+			if b.engine.options.FullFidelity {
+				node.children = append(append([]Node(nil), node.children...), trivia...)
+			}
+			node.text = b.source
+			node.end = end
+
+			return node, true, parseFailure{}
+		}
+
+		// The rune after a match of only part of the input failed to match
+		b.fail(end, nil)
+		if (longest.rule == "") || (node.end > longest.end) {
+			longest = node
+		}
+	}
+
+	return longest, false, b.failure
+}
+
+// matchRule returns each way the named rule can match at offset that is accepted by its predicate, in order of alternatives.
+// The matches of a memoized rule are only computed once per offset, unless they are evicted from the memo table.
+// An alternative that the quick check sets rule out for the rune at offset is not matched.
+// An alternative shared with other rules is only matched once per offset for all of them.
+func (b *backtracker) matchRule(name string, offset int) []Node {
+	// The matches of rules used by the trivia rule would be different outside of it
+	memoized := b.engine.memoized[name] && !b.inTrivia
+	if memoized {
+		if nodes, haveIt := b.memo.Get(MemoKey{name, offset}); haveIt {
+			b.record(name, offset, 0, len(nodes))
+			return nodes
+		}
+	}
+	defer b.enter(name, offset)()
+
+	var (
+		predicate = b.engine.options.Predicates[name]
+		limits    = b.engine.limits[name]
+		result    []Node
+	)
+	if limits.MaxLength > 0 {
+		b.lengths = append(b.lengths, activeLimit{rule: name, start: offset, max: limits.MaxLength})
+		defer func() { b.lengths = b.lengths[:len(b.lengths)-1] }()
+	}
+
+	for a, alt := range b.engine.rules[name].expr.items {
+		b.active[len(b.active)-1].alt = a + 1
+		if !b.candidate(name, a, offset) {
+			continue
+		}
+
+		matches := len(result)
+		for _, match := range b.matchShared(name, a, alt, offset) {
+			node := Node{
+				rule:     name,
+				text:     b.text(offset, match.end),
+				start:    offset,
+				end:      match.end,
+				children: match.children[:len(match.children):len(match.children)],
+			}
+
+			if b.keywordAllows(name, node) && ((predicate == nil) || predicate(b.ctx, node)) {
+				result = append(result, node)
+			}
+		}
+		b.record(name, offset, a+1, len(result)-matches)
+
+		if b.engine.options.PEG && (len(result) > 0) {
+			break
+		}
+	}
+
+	// An error node is the last match, so that it is only used if the real matches do not let the input match
+	if (len(b.regions) > 0) && !(b.engine.options.PEG && (len(result) > 0)) {
+		result = append(result, b.recover(name, offset)...)
+	}
+
+	// In PEG mode the first match is the only match, so a memoized rule stores one match per offset
+	if b.engine.options.PEG && (len(result) > 1) {
+		result = result[:1]
+	}
+
+	if memoized {
+		b.memo.Put(MemoKey{name, offset}, result)
+	}
+
+	return result
+}
+
+// matchShared returns matchAlternative of the numbered alternative of the named rule, which is only computed once per
+// offset if the alternative is shared with other rules, except while matching the trivia rule
+func (b *backtracker) matchShared(name string, a int, alt ExpressionItem, offset int) []matchResult {
+	id, shared := b.engine.shared[name][a]
+	if !shared || b.inTrivia {
+		return b.matchAlternative(name, alt, offset)
+	}
+
+	key := sharedKey{alt: id, offset: offset}
+	results, haveIt := b.shared[key]
+	if !haveIt {
+		results = b.matchAlternative(name, alt, offset)
+		b.shared[key] = results
+	}
+
+	return results
+}
+
+// text returns the input from start to end
+func (b *backtracker) text(start, end int) string {
+	return b.source[b.offsets[start]:b.offsets[end]]
+}
+
+// candidate returns true if the numbered alternative of the named rule may match at offset, which is always true if
+// there are no quick check sets. The terminals an alternative that is skipped begins with fail at offset.
+func (b *backtracker) candidate(name string, alt int, offset int) bool {
+	if b.quickCheck == nil {
+		return true
+	}
+
+	next := rune(-1)
+	if offset < len(b.input) {
+		next = b.input[offset]
+	}
+
+	if !b.quickCheck.candidate(name, alt, next) {
+		b.skip(name, alt, offset)
+		return false
+	}
+
+	return true
+}
+
+// matchAlternative returns each way an alternative can match at offset, with the most repetitions first, or the fewest
+// first if it is lazy, keeping only the first way it can match up to each offset as described by matchResults.
+// A repetition beyond the lower bound that matches nothing is not tried, as it could be repeated forever.
+// The parse is aborted if the alternative repeats more than the maximum repetitions of the named rule it belongs to,
+// or of any alternative.
+func (b *backtracker) matchAlternative(name string, alt ExpressionItem, offset int) []matchResult {
+	errRepetitions, maxRepetitions := ErrMaxRepetitions, b.engine.options.MaxRepetitions
+	if limit := b.engine.limits[name].MaxRepetitions; (limit > 0) && ((maxRepetitions <= 0) || (limit <= maxRepetitions)) {
+		errRepetitions, maxRepetitions = ErrRuleMaxRepetitions, limit
+	}
+
+	levels := [][]matchResult{{{end: offset}}}
+
+	for count := 1; (alt.m == -1) || (count <= alt.m); count++ {
+		next := b.newMatchResults(name)
+		for _, prev := range levels[count-1] {
+			extended := false
+			for _, match := range b.matchList(name, alt.list, prev.end) {
+				if (match.end == prev.end) && (count > alt.n) {
+					continue
+				}
+
+				b.step(name, match.end)
+				if next.add(prev.children, extended, match) {
+					extended = true
+				}
+			}
+		}
+
+		if len(next.results) == 0 {
+			break
+		}
+		if (maxRepetitions > 0) && (count > maxRepetitions) {
+			b.exceeded(errRepetitions, name, maxRepetitions, levels[count-1][0].end)
+		}
+		levels = append(levels, next.results)
+	}
+
+	result := b.newMatchResults(name)
+	if alt.lazy {
+		for count := alt.n; count < len(levels); count++ {
+			result.keep(levels[count]...)
+		}
+	} else {
+		for count := len(levels) - 1; count >= alt.n; count-- {
+			result.keep(levels[count]...)
+		}
+	}
+
+	if b.engine.options.PEG && (len(result.results) > 1) {
+		return result.results[:1]
+	}
+
+	return result.results
+}
+
+// matchList returns each way a list of items of the named rule can match in sequence at offset, keeping only the first
+// way it can match up to each offset as described by matchResults
+func (b *backtracker) matchList(name string, list []ListItem, offset int) []matchResult {
+	results := []matchResult{{end: offset}}
+
+	for _, item := range list {
+		next := b.newMatchResults(name)
+		for _, prev := range results {
+			extended := false
+			for _, match := range b.matchItem(item, prev.end) {
+				b.step(name, match.end)
+				if next.add(prev.children, extended, match) {
+					extended = true
+				}
+			}
+		}
+
+		if len(next.results) == 0 {
+			return nil
+		}
+		results = next.results
+	}
+
+	return results
+}
+
+// matchItem returns each way a list item can match at offset.
+// A terminal is preceded by any trivia, which are only children of the match in a full fidelity parse.
+func (b *backtracker) matchItem(item ListItem, offset int) []matchResult {
+	if item.IsRuleName() {
+		var results []matchResult
+		for _, node := range b.matchRule(item.ruleName, offset) {
+			results = append(results, matchResult{end: node.end, children: []Node{node}})
+		}
+
+		return results
+	}
+
+	trivia, offset := b.skipTrivia(offset)
+	if !b.engine.options.FullFidelity {
+		trivia = nil
+	}
+
+	end := offset
+	if item.terminal.IsString() && item.ConstantTime() {
+		var matched bool
+		if end, matched = matchConstantTime(b.input, offset, item.terminal.theString); !matched {
+			b.fail(offset, &item.terminal)
+			return nil
+		}
+	} else if item.terminal.IsString() {
+		for _, char := range item.terminal.theString {
+			if (end >= len(b.input)) || (b.input[end] != char) {
+				b.fail(end, &item.terminal)
+				return nil
+			}
+			end++
+		}
+	} else {
+		if (end >= len(b.input)) || !item.terminal.theRange.Contains(b.input[end]) {
+			b.fail(end, &item.terminal)
+			return nil
+		}
+		end++
+	}
+	b.checkLength(end)
+
+	return []matchResult{{
+		end: end,
+		children: append(trivia, Node{
+			text:  b.text(offset, end),
+			start: offset,
+			end:   end,
+		}),
+	}}
+}
+
+// skipTrivia returns the matches of the trivia rule at offset, repeated as many times as it matches some input,
+// and the offset after them. Nothing is skipped if there is no trivia rule, or it is being matched.
+func (b *backtracker) skipTrivia(offset int) ([]Node, int) {
+	if (b.engine.options.Trivia == "") || b.inTrivia {
+		return nil, offset
+	}
+
+	b.inTrivia = true
+	defer func() { b.inTrivia = false }()
+
+	var trivia []Node
+	for {
+		// The first match is the first alternative with the most repetitions, as the trivia rule is not backtracked into
+		matches := b.matchRule(b.engine.options.Trivia, offset)
+		if (len(matches) == 0) || (matches[0].end == offset) {
+			return trivia, offset
+		}
+
+		trivia = append(trivia, matches[0])
+		offset = matches[0].end
+	}
+}
+
+// fail records that a terminal failed to match the rune at offset, or the end of the input if it is nil, unless the
+// offset is in a skipped region. Only the offset is recorded for terminals of the trivia rule.
+func (b *backtracker) fail(offset int, terminal *Terminal) {
+	switch {
+	case b.skipped(offset):
+	case b.inTrivia:
+		b.failure.reach(offset)
+	default:
+		b.failure.fail(offset, terminal, b.active)
+	}
+}
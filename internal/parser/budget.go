@@ -0,0 +1,118 @@
+package parser
+
+import (
+	"fmt"
+)
+
+// Budget error message constants
+const (
+	ErrBudgetRules  = "The grammar has more rules than its budget"
+	ErrBudgetDepth  = "The grammar nests rules deeper than its budget"
+	ErrBudgetStates = "The grammar has more estimated NFA states than its budget"
+)
+
+// GrammarBudget is the maximum complexity of a grammar, so that a service that compiles user supplied grammars can
+// reject those that would be too expensive to use. A maximum of 0 is no maximum.
+type GrammarBudget struct {
+	// MaxRules is the maximum number of rules
+	MaxRules int
+	// MaxDepth is the maximum nesting depth
+	MaxDepth int
+	// MaxStates is the maximum estimated number of NFA states
+	MaxStates int
+}
+
+// GrammarComplexity is the complexity of a grammar, as measured by Complexity
+type GrammarComplexity struct {
+	// Rules is the number of rules, including those generated for nested expressions
+	Rules int
+	// Depth is the length of the longest chain of rules that refer to each other, where a cycle of rules counts as one
+	Depth int
+	// States is an estimate of the states of an NFA that matches the grammar: one for each rule and alternative,
+	// and one for each rune of a string, range, and rule name in an alternative, for each copy of the alternative
+	// a repetition {N,M} needs, which is M, or N+1 if there is no upper bound
+	States int
+}
+
+// Complexity returns the complexity of the grammar
+func (g Grammar) Complexity() GrammarComplexity {
+	result := GrammarComplexity{Rules: len(g.rules)}
+
+	for _, rule := range g.rules {
+		result.States++
+
+		for _, alt := range rule.expr.items {
+			items := 0
+			for _, item := range alt.list {
+				if item.IsTerminal() && item.terminal.IsString() {
+					items += len([]rune(item.terminal.theString))
+				} else {
+					items++
+				}
+			}
+
+			copies := alt.m
+			if copies == -1 {
+				copies = alt.n + 1
+			}
+			if copies < 1 {
+				copies = 1
+			}
+
+			result.States += 1 + copies*items
+		}
+	}
+
+	// Tarjan numbers each component after every component it refers to, so they are in order of depth
+	var (
+		byName    = rulesByName(g.rules)
+		component = ruleComponents(g)
+		depths    = map[int]int{}
+		order     = make([][]string, len(g.rules))
+	)
+	for name, c := range component {
+		order[c] = append(order[c], name)
+	}
+
+	for c, names := range order {
+		depth := 1
+		for _, name := range names {
+			for _, alt := range byName[name].expr.items {
+				for _, item := range alt.list {
+					if d, defined := component[item.ruleName]; item.IsRuleName() && defined && (d != c) && (depths[d]+1 > depth) {
+						depth = depths[d] + 1
+					}
+				}
+			}
+		}
+
+		if len(names) > 0 {
+			depths[c] = depth
+			if depth > result.Depth {
+				result.Depth = depth
+			}
+		}
+	}
+
+	return result
+}
+
+// CheckBudget returns an error if the complexity of the grammar exceeds the budget
+func (g Grammar) CheckBudget(budget GrammarBudget) error {
+	complexity := g.Complexity()
+
+	for _, check := range []struct {
+		err        string
+		value, max int
+	}{
+		{ErrBudgetRules, complexity.Rules, budget.MaxRules},
+		{ErrBudgetDepth, complexity.Depth, budget.MaxDepth},
+		{ErrBudgetStates, complexity.States, budget.MaxStates},
+	} {
+		if (check.max > 0) && (check.value > check.max) {
+			return fmt.Errorf("%s: %d > %d", check.err, check.value, check.max)
+		}
+	}
+
+	return nil
+}
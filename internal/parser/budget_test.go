@@ -0,0 +1,35 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComplexity(t *testing.T) {
+	g := testGrammar("a = 'xy' b | c", "b = 'z' a", "c = ([_])+")
+	assert.Equal(t, GrammarComplexity{Rules: 3, Depth: 2, States: 15}, g.Complexity())
+
+	g = testGrammar("a = b", "b = c", "c = 'x'")
+	assert.Equal(t, 3, g.Complexity().Depth)
+
+	assert.Equal(t, GrammarComplexity{}, Grammar{}.Complexity())
+}
+
+func TestCheckBudget(t *testing.T) {
+	g := testGrammar("a = 'xy' b | c", "b = 'z' a", "c = ([_])+")
+
+	assert.Nil(t, g.CheckBudget(GrammarBudget{}))
+	assert.Nil(t, g.CheckBudget(GrammarBudget{MaxRules: 3, MaxDepth: 2, MaxStates: 15}))
+	assert.Equal(t, ErrBudgetRules+": 3 > 2", g.CheckBudget(GrammarBudget{MaxRules: 2}).Error())
+	assert.Equal(t, ErrBudgetDepth+": 2 > 1", g.CheckBudget(GrammarBudget{MaxDepth: 1}).Error())
+	assert.Equal(t, ErrBudgetStates+": 15 > 14", g.CheckBudget(GrammarBudget{MaxStates: 14}).Error())
+
+	// Compile rejects a grammar over budget
+	_, err := Compile(strings.NewReader(`a = "x", { b } ; b = 'y' ;`), CompileOptions{Dialect: DialectISO, Budget: GrammarBudget{MaxRules: 2}})
+	assert.Equal(t, ErrBudgetRules+": 3 > 2", err.Error())
+
+	_, err = Compile(strings.NewReader(`a = "x", { b } ; b = 'y' ;`), CompileOptions{Dialect: DialectISO, Budget: GrammarBudget{MaxRules: 3}})
+	assert.Nil(t, err)
+}
@@ -0,0 +1,155 @@
+package parser
+
+import (
+	"fmt"
+)
+
+// Capability error message constants
+const (
+	ErrUnsupportedFeature = "No backend of the fallback chain supports the feature"
+)
+
+// Feature is an optional capability of an Engine, which depends on its backend, and on the build for features such as
+// JSON that are not compiled in with tinygo
+type Feature uint
+
+// Feature constants
+const (
+	// FeatureBacktrack is the backtracking backend
+	FeatureBacktrack Feature = iota
+	// FeatureEarley is the Earley backend
+	FeatureEarley
+	// FeatureLeftRecursion is matching left recursive rules
+	FeatureLeftRecursion
+	// FeaturePEG is ParseOptions.PEG
+	FeaturePEG
+	// FeatureTrivia is ParseOptions.Trivia
+	FeatureTrivia
+	// FeatureRecovery is recovering from errors with ParseOptions.Sync
+	FeatureRecovery
+	// FeatureLimits is rule limits and ParseOptions.MaxDepth, MaxSteps, and MaxRepetitions
+	FeatureLimits
+	// FeatureParseOne is Engine.ParseOne
+	FeatureParseOne
+	// FeatureReplay is ParseOptions.Replay and Engine.Replay
+	FeatureReplay
+	// FeatureForest is Engine.ParseForest
+	FeatureForest
+	// FeatureJSON is the JSON of grammars, trees, and diagnostics
+	FeatureJSON
+	// FeatureKeywords is keyword rules, such as those of CompileOptions.Keywords
+	FeatureKeywords
+)
+
+var (
+	// featureStrings are the names of the features
+	featureStrings = map[Feature]string{
+		FeatureBacktrack:     "backtrack",
+		FeatureEarley:        "earley",
+		FeatureLeftRecursion: "left recursion",
+		FeaturePEG:           "peg",
+		FeatureTrivia:        "trivia",
+		FeatureRecovery:      "recovery",
+		FeatureLimits:        "limits",
+		FeatureParseOne:      "parse one",
+		FeatureReplay:        "replay",
+		FeatureForest:        "forest",
+		FeatureJSON:          "json",
+		FeatureKeywords:      "keywords",
+	}
+
+	// backendFeatures are the features of each backend that do not depend on the build
+	backendFeatures = map[Backend][]Feature{
+		BackendBacktrack: {
+			FeatureBacktrack,
+			FeaturePEG,
+			FeatureTrivia,
+			FeatureRecovery,
+			FeatureLimits,
+			FeatureParseOne,
+			FeatureReplay,
+			FeatureForest,
+			FeatureKeywords,
+		},
+		BackendEarley: {
+			FeatureEarley,
+			FeatureLeftRecursion,
+			FeatureForest,
+		},
+	}
+
+	// buildFeatures are the features that are compiled in, which files with build constraints add to
+	buildFeatures = map[Feature]bool{}
+
+	// DefaultFallback is the fallback chain of NewEngineFallback if it is given none: the backtracking backend, then
+	// the Earley backend for grammars and options the backtracking backend cannot handle
+	DefaultFallback = []Backend{BackendBacktrack, BackendEarley}
+)
+
+// String is the name of the feature
+func (f Feature) String() string {
+	if str, haveIt := featureStrings[f]; haveIt {
+		return str
+	}
+
+	return fmt.Sprintf("Feature(%d)", uint(f))
+}
+
+// Supports returns true if engines of the backend support the feature
+func (b Backend) Supports(f Feature) bool {
+	if buildFeatures[f] {
+		return true
+	}
+
+	for _, feature := range backendFeatures[b] {
+		if feature == f {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Supports returns true if the engine supports the feature, so that an application can check for an optional feature
+// before using it, instead of handling the error of an unsupported feature.
+// An engine that eliminates left recursion supports FeatureLeftRecursion with any backend.
+func (e *Engine) Supports(f Feature) bool {
+	return e.options.Backend.Supports(f) || ((f == FeatureLeftRecursion) && e.options.EliminateLeftRecursion)
+}
+
+// NewEngineFallback constructs an Engine with the first backend of the fallback chain that supports every required
+// feature and that NewEngine accepts the grammar and options for, ignoring the backend of the options, so that an
+// application gets the best available backend and degrades in a predictable order. DefaultFallback is used if the chain is
+// empty, which uses the Earley backend for a left recursive grammar.
+// Returns the error of NewEngine for the last backend that supports the required features, or if no backend supports them,
+// ErrUnsupportedFeature with the first required feature that the first backend of the chain does not support.
+func NewEngineFallback(g Grammar, options ParseOptions, chain []Backend, required ...Feature) (*Engine, error) {
+	if len(chain) == 0 {
+		chain = DefaultFallback
+	}
+
+	var err error
+	for _, backend := range chain {
+		missing := false
+		for _, feature := range required {
+			if !backend.Supports(feature) {
+				if err == nil {
+					err = fmt.Errorf("%s: %s", ErrUnsupportedFeature, feature)
+				}
+				missing = true
+				break
+			}
+		}
+		if missing {
+			continue
+		}
+
+		options.Backend = backend
+		var e *Engine
+		if e, err = NewEngine(g, options); err == nil {
+			return e, nil
+		}
+	}
+
+	return nil, err
+}
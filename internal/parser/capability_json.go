@@ -0,0 +1,9 @@
+//go:build !tinygo
+// +build !tinygo
+
+package parser
+
+// JSON is a feature except with tinygo, which does not support encoding/json
+func init() {
+	buildFeatures[FeatureJSON] = true
+}
@@ -0,0 +1,75 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSupports(t *testing.T) {
+	g := testGrammar("list = 'a' ','")
+
+	backtrack, err := NewEngine(g, ParseOptions{})
+	assert.Nil(t, err)
+	earley, err := NewEngine(g, ParseOptions{Backend: BackendEarley})
+	assert.Nil(t, err)
+
+	for _, test := range []struct {
+		feature   Feature
+		backtrack bool
+		earley    bool
+	}{
+		{FeatureBacktrack, true, false},
+		{FeatureEarley, false, true},
+		{FeatureLeftRecursion, false, true},
+		{FeaturePEG, true, false},
+		{FeatureTrivia, true, false},
+		{FeatureRecovery, true, false},
+		{FeatureLimits, true, false},
+		{FeatureParseOne, true, false},
+		{FeatureReplay, true, false},
+		{FeatureForest, true, true},
+		{FeatureJSON, buildFeatures[FeatureJSON], buildFeatures[FeatureJSON]},
+		{FeatureKeywords, true, false},
+	} {
+		assert.Equal(t, test.backtrack, backtrack.Supports(test.feature), test.feature.String())
+		assert.Equal(t, test.earley, earley.Supports(test.feature), test.feature.String())
+	}
+
+	assert.Equal(t, "left recursion", FeatureLeftRecursion.String())
+	assert.Equal(t, "Feature(99)", Feature(99).String())
+}
+
+func TestNewEngineFallback(t *testing.T) {
+	// The backtracking backend is preferred
+	g := testGrammar("list = 'a' ','")
+	engine, err := NewEngineFallback(g, ParseOptions{Backend: BackendEarley}, nil)
+	assert.Nil(t, err)
+	assert.True(t, engine.Supports(FeatureBacktrack))
+
+	// A left recursive grammar falls back to the Earley backend
+	g = testGrammar("list = list ',' 'a' | 'a'")
+	engine, err = NewEngineFallback(g, ParseOptions{}, nil)
+	assert.Nil(t, err)
+	assert.True(t, engine.Supports(FeatureEarley))
+	tree, err := engine.Parse(strings.NewReader("a,a"))
+	assert.Nil(t, err)
+	assert.Equal(t, `(list (list "a") "," "a")`, tree.SExpr(false))
+
+	// Backends without a required feature are skipped
+	g = testGrammar("list = 'a' ','")
+	engine, err = NewEngineFallback(g, ParseOptions{}, nil, FeatureLeftRecursion)
+	assert.Nil(t, err)
+	assert.True(t, engine.Supports(FeatureEarley))
+
+	_, err = NewEngineFallback(g, ParseOptions{}, []Backend{BackendEarley}, FeaturePEG)
+	assert.Equal(t, ErrUnsupportedFeature+": peg", err.Error())
+
+	_, err = NewEngineFallback(g, ParseOptions{}, []Backend{BackendBacktrack, BackendEarley}, FeatureForest, FeatureEarley, FeaturePEG)
+	assert.Equal(t, ErrUnsupportedFeature+": earley", err.Error())
+
+	// The error is that of the last backend that supports the required features
+	_, err = NewEngineFallback(testGrammar("list = list ',' 'a' | 'a'"), ParseOptions{PEG: true}, nil)
+	assert.Equal(t, ErrPEGBackend, err.Error())
+}
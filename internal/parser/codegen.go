@@ -0,0 +1,795 @@
+//go:build !tinygo
+// +build !tinygo
+
+package parser
+
+import (
+	"fmt"
+	"go/format"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Code generation error message constants
+const (
+	ErrGoPackage = "The package name is not a Go identifier"
+	ErrGoCShared = "The package of a c-shared library must be main"
+	ErrGoWASM    = "The package of a wasm module must be main, and cannot also be a c-shared library"
+)
+
+// GoOptions are the options of GenerateGo
+type GoOptions struct {
+	// Package is the name of the generated package
+	Package string
+	// Start is the name of the rule that must match all of the input, the first rule of the grammar by default
+	Start string
+	// Trivia is the name of a rule that is skipped before each terminal and at the end of the input, as in ParseOptions
+	Trivia string
+	// PEG is true if alternatives are ordered choices, as in ParseOptions
+	PEG bool
+	// MaxDepth is the maximum nesting of rules the parser matches, DefaultMaxDepth if 0, no maximum if < 0, as in ParseOptions
+	MaxDepth int
+	// MaxSteps is the maximum number of steps the parser takes, no maximum if <= 0, as in ParseOptions
+	MaxSteps int
+	// AST is true if a typed node struct is generated for each rule, as described by GenerateGo
+	AST bool
+	// Visitor is true if Listener and Visitor interfaces are generated, as described by GenerateGo
+	Visitor bool
+	// CShared is true if the package is a main package with C entry points, as described by GenerateGo
+	CShared bool
+	// WASM is true if the package is a main package for GOOS=js and GOARCH=wasm that exports a JavaScript function,
+	// as described by GenerateGo
+	WASM bool
+}
+
+// goGenerator writes the Go source of a parser for a grammar
+type goGenerator struct {
+	str     strings.Builder
+	grammar Grammar
+	options GoOptions
+	// identifier of each rule, used in its constant and method names
+	idents map[string]string
+	// names used so far
+	used map[string]bool
+	// exported names of the typed nodes used so far
+	types map[string]bool
+	// tables of the range terminals, in order
+	classes [][]runeInterval
+}
+
+// runeInterval is a run of consecutive runes of a range
+type runeInterval struct {
+	lo, hi rune
+}
+
+// GenerateGo returns the gofmt formatted source of a standalone Go package that parses input with the grammar,
+// so that a grammar can be compiled into a program instead of being interpreted by an Engine, such as with
+//
+//	//go:generate goparse generate -package calc -o calc.go calc.ebnf
+//
+// The package imports only the standard library, and has:
+// - A Rule constant of each rule name, such as RuleNodesSection for nodes-section
+// - A Node type, which is the same as the parse tree of an Engine, with exported fields instead of methods
+// - Parse and ParseString functions, which return the same tree or error as Engine.Parse would with the same options
+// - A LimitError type, which ParseString returns when the parse exceeds MaxDepth or MaxSteps
+// - If the AST option is true, a typed node struct of each rule, and a New function that converts a Node into it
+// - If the Visitor option is true, Listener and Visitor interfaces, as in ANTLR, with BaseListener and BaseVisitor
+// - If the CShared option is true, a ParseToJSON function, and GoparseParse and GoparseFree functions exported to C
+// - If the WASM option is true, a ParseToJSON function, and a main function that exports it to JavaScript
+//
+// A Listener has EnterX and ExitX methods for each rule X, and a VisitTerminal method, which Walk calls in depth first
+// order. A Visitor has a VisitX method for each rule X, and a VisitTerminal method, which return a result. Visit calls
+// the method for a node, and VisitChildren calls Visit for each child. BaseListener and BaseVisitor do nothing, so
+// that they can be embedded in a struct that only implements the methods it needs.
+//
+// A typed node has the Node, and a field for each rule it refers to, named after that rule with the name of the
+// referring rule and a dash removed from the beginning, which is a slice if the rule can match more than once.
+// A rule with several alternatives also has a Type enum of its alternatives, and an Is method for each,
+// named after the first rule each alternative refers to. EG, comment = comment-one-line | comment-multi-line has
+// fields OneLine and MultiLine, constants CommentOneLine and CommentMultiLine, and methods IsOneLine and IsMultiLine.
+//
+// Each rule and alternative is a function, and terminals are matched directly against the input, as a lexer would.
+// The generated parser backtracks like BackendBacktrack, keeping only the first way each list of items or repetition
+// can match up to each offset, and memoizes every rule. It does not support actions, predicates, rule limits,
+// MaxRepetitions, or full fidelity.
+//
+// A CShared package must be named main, and is built with go build -buildmode=c-shared into a library and header that
+// non-Go applications can parse with, where GoparseParse(input, length) parses length bytes of input, and returns the
+// JSON of ParseToJSON as a C string that the caller frees with GoparseFree. ParseToJSON returns the same JSON as the
+// ParseToJSON of this package, except that there are no error nodes.
+//
+// A WASM package must be named main, and only builds with GOOS=js and GOARCH=wasm into a module that sets a global
+// goparseParse(input) JavaScript function to ParseToJSON, which a page can call once the module runs with wasm_exec.js
+// from the Go distribution, as goparse playground does.
+//
+// Returns an error if the package name is not an identifier, or not main for the CShared or WASM option, or both options
+// are true, or NewEngine would return an error for the grammar and options.
+func (g Grammar) GenerateGo(options GoOptions) ([]byte, error) {
+	if !token.IsIdentifier(options.Package) {
+		return nil, fmt.Errorf("%s: %s", ErrGoPackage, options.Package)
+	}
+	if options.CShared && (options.Package != "main") {
+		return nil, fmt.Errorf("%s: %s", ErrGoCShared, options.Package)
+	}
+	if options.WASM && ((options.Package != "main") || options.CShared) {
+		return nil, fmt.Errorf("%s: %s", ErrGoWASM, options.Package)
+	}
+
+	engine, err := NewEngine(g, ParseOptions{Start: options.Start, Trivia: options.Trivia, PEG: options.PEG})
+	if err != nil {
+		return nil, err
+	}
+	options.Start = engine.options.Start
+	withDefault(&options.MaxDepth, DefaultMaxDepth)
+
+	gen := &goGenerator{
+		grammar: g,
+		options: options,
+		idents:  map[string]string{},
+		used:    map[string]bool{},
+		types:   map[string]bool{},
+	}
+	for _, rule := range g.rules {
+		gen.idents[rule.name] = gen.unique(goIdentifier(rule.name))
+	}
+
+	gen.write()
+
+	return format.Source([]byte(gen.str.String()))
+}
+
+// goIdentifier returns a rule name in upper camel case, where each run of characters that cannot be in an identifier
+// separates words. As the first letter is upper case, the result is never a Go keyword, and a result that would begin
+// with a digit or be empty begins with Rule instead.
+func goIdentifier(name string) string {
+	var (
+		str   strings.Builder
+		upper = true
+	)
+
+	for _, char := range name {
+		switch {
+		case unicode.IsLetter(char) || unicode.IsDigit(char):
+			if upper {
+				char = unicode.ToUpper(char)
+				upper = false
+			}
+			str.WriteRune(char)
+		default:
+			upper = true
+		}
+	}
+
+	if result := str.String(); token.IsIdentifier(result) {
+		return result
+	}
+
+	return "Rule" + str.String()
+}
+
+// unique returns the name, followed by the lowest number from 2 that makes it unique if it has been used
+func (gen *goGenerator) unique(name string) string {
+	return uniqueName(gen.used, name)
+}
+
+// uniqueName returns the name, followed by the lowest number from 2 that makes it unique if it is in used, and adds
+// the result to used
+func uniqueName(used map[string]bool, name string) string {
+	result := name
+	for i := 2; used[result]; i++ {
+		result = name + strconv.Itoa(i)
+	}
+	used[result] = true
+
+	return result
+}
+
+// write writes the source of the package
+func (gen *goGenerator) write() {
+	fmt.Fprintf(&gen.str, "// Code generated by goparse; DO NOT EDIT.\n\n")
+	if gen.options.WASM {
+		gen.str.WriteString("//go:build js && wasm\n\n")
+	}
+	fmt.Fprintf(&gen.str, "// Package %s parses input with a grammar of %d rules, starting with %s.\n", gen.options.Package, len(gen.grammar.rules), gen.options.Start)
+	fmt.Fprintf(&gen.str, "package %s\n\n", gen.options.Package)
+	imports := []string{"fmt", "io", "io/ioutil", "sort"}
+	switch {
+	case gen.options.CShared:
+		gen.str.WriteString("// #include <stdlib.h>\nimport \"C\"\n\n")
+		imports = append(imports, "encoding/json", "unsafe")
+	case gen.options.WASM:
+		imports = append(imports, "encoding/json", "syscall/js")
+	}
+	sort.Strings(imports)
+	gen.str.WriteString("import (\n")
+	for _, path := range imports {
+		fmt.Fprintf(&gen.str, "\t%s\n", strconv.Quote(path))
+	}
+	gen.str.WriteString(")\n\n")
+
+	gen.str.WriteString("// Rule name constants\nconst (\n")
+	for _, rule := range gen.grammar.rules {
+		fmt.Fprintf(&gen.str, "\tRule%s = %s\n", gen.idents[rule.name], strconv.Quote(rule.name))
+	}
+	gen.str.WriteString(")\n\n")
+
+	fmt.Fprintf(&gen.str, "// peg is true if alternatives are ordered choices\nconst peg = %t\n\n", gen.options.PEG)
+	fmt.Fprintf(
+		&gen.str,
+		"// The maximum nesting of rules and number of steps of a parse, no maximum if <= 0\nconst (\n\tmaxDepth = %d\n\tmaxSteps = %d\n)\n\n",
+		gen.options.MaxDepth,
+		gen.options.MaxSteps,
+	)
+	gen.str.WriteString(goRuntime)
+
+	fmt.Fprintf(&gen.str, `
+// ParseString returns the parse tree of the %[1]s rule matching all of the input.
+// Returns an error if the input does not match, or a LimitError if the parse exceeds a limit.
+func ParseString(input string) (node Node, err error) {
+	p := newParser(input)
+	defer p.recoverLimit(&node, &err)
+
+	for _, node := range p.rule%[2]s(0) {
+		end := p.skipTrivia(node.End)
+		if end == len(p.input) {
+			node.Text = input
+			node.End = end
+			return node, nil
+		}
+
+		// The rune after a match of only part of the input failed to match
+		p.fail(end)
+	}
+
+	line, position := p.linePosition(p.furthest)
+	return Node{}, fmt.Errorf("%%s at line %%d position %%d", ErrParseFailed, line, position)
+}
+`, gen.options.Start, gen.idents[gen.options.Start])
+
+	if gen.options.Trivia == "" {
+		gen.str.WriteString(`
+// skipTrivia returns the offset, as there is no trivia rule
+func (p *parser) skipTrivia(offset int) int {
+	return offset
+}
+`)
+	} else {
+		fmt.Fprintf(&gen.str, `
+// skipTrivia returns the offset after the matches of the %[1]s rule at offset, repeated as many times as it matches
+// some input. Nothing is skipped while matching the %[1]s rule.
+func (p *parser) skipTrivia(offset int) int {
+	if p.inTrivia {
+		return offset
+	}
+
+	p.inTrivia = true
+	defer func() { p.inTrivia = false }()
+
+	for {
+		matches := p.rule%[2]s(offset)
+		if (len(matches) == 0) || (matches[0].End == offset) {
+			return offset
+		}
+
+		offset = matches[0].End
+	}
+}
+`, gen.options.Trivia, gen.idents[gen.options.Trivia])
+	}
+
+	for _, rule := range gen.grammar.rules {
+		gen.writeRule(rule)
+	}
+
+	if len(gen.classes) > 0 {
+		gen.str.WriteString("\n// Runes of the range terminals\nvar (\n")
+		for i, class := range gen.classes {
+			fmt.Fprintf(&gen.str, "\tclass%d = []runeInterval{", i+1)
+			for j, interval := range class {
+				if j > 0 {
+					gen.str.WriteString(", ")
+				}
+				fmt.Fprintf(&gen.str, "{%s, %s}", goRune(interval.lo), goRune(interval.hi))
+			}
+			gen.str.WriteString("}\n")
+		}
+		gen.str.WriteString(")\n")
+	}
+
+	if gen.options.Visitor {
+		gen.writeVisitors()
+	}
+
+	if gen.options.AST {
+		gen.writeAST()
+	}
+
+	if gen.options.CShared || gen.options.WASM {
+		gen.str.WriteString(goParseJSON)
+	}
+
+	if gen.options.CShared {
+		gen.str.WriteString(goCShared)
+	}
+
+	if gen.options.WASM {
+		gen.str.WriteString(goWASM)
+	}
+}
+
+// writeRule writes the method of a rule, and a method of each of its alternatives
+func (gen *goGenerator) writeRule(rule Rule) {
+	var (
+		ident = gen.idents[rule.name]
+		alts  = make([]string, len(rule.expr.items))
+	)
+	for i := range rule.expr.items {
+		alts[i] = gen.unique(fmt.Sprintf("alt%s%d", ident, i+1))
+	}
+
+	src := make([]string, len(rule.expr.items))
+	for i, alt := range rule.expr.items {
+		src[i] = formatAlternative(alt)
+	}
+	fmt.Fprintf(&gen.str, "\n// rule%s matches %s = %s\n", ident, rule.name, goComment(strings.Join(src, " | ")))
+	fmt.Fprintf(&gen.str, "func (p *parser) rule%s(offset int) []Node {\n", ident)
+	fmt.Fprintf(&gen.str, "\treturn p.match(Rule%s, offset", ident)
+	for _, alt := range alts {
+		fmt.Fprintf(&gen.str, ", p.%s", alt)
+	}
+	gen.str.WriteString(")\n}\n")
+
+	for i, alt := range rule.expr.items {
+		fmt.Fprintf(&gen.str, "\n// %s matches %s\n", alts[i], goComment(formatAlternative(alt)))
+		fmt.Fprintf(&gen.str, "func (p *parser) %s(offset int) []result {\n", alts[i])
+		repeat := "repeat"
+		if alt.lazy {
+			repeat = "repeatLazy"
+		}
+		fmt.Fprintf(&gen.str, "\treturn p.%s(%d, %d, offset, func(offset int) []result {\n", repeat, alt.n, alt.m)
+		gen.str.WriteString("\t\tresults := []result{{end: offset}}\n")
+		for _, item := range alt.list {
+			fmt.Fprintf(&gen.str, "\t\tresults = p.then(results, func(offset int) []result { return %s })\n", gen.item(item))
+		}
+		gen.str.WriteString("\t\treturn results\n\t})\n}\n")
+	}
+}
+
+// item returns the expression that matches a list item at offset
+func (gen *goGenerator) item(item ListItem) string {
+	switch {
+	case item.IsRuleName():
+		return fmt.Sprintf("p.rule(p.rule%s(offset))", gen.idents[item.ruleName])
+	case item.terminal.IsString() && item.ConstantTime():
+		return fmt.Sprintf("p.strConstantTime(%s, offset)", strconv.Quote(item.terminal.theString))
+	case item.terminal.IsString():
+		return fmt.Sprintf("p.str(%s, offset)", strconv.Quote(item.terminal.theString))
+	}
+
+	var class []runeInterval
+	for _, interval := range item.terminal.theRange.intervals {
+		class = append(class, runeInterval{interval.Lo, interval.Hi})
+	}
+	gen.classes = append(gen.classes, class)
+
+	return fmt.Sprintf("p.class(class%d, offset)", len(gen.classes))
+}
+
+// goRune returns a Go literal of a rune, which is a character literal if the rune is valid
+func goRune(char rune) string {
+	if utf8.ValidRune(char) {
+		return strconv.QuoteRune(char)
+	}
+
+	return fmt.Sprintf("0x%X", char)
+}
+
+// goComment returns source on one line, so that it can be written in a // comment
+func goComment(src string) string {
+	return strings.Join(strings.Fields(src), " ")
+}
+
+// goRuntime is the source of the generated package that does not depend on the grammar
+const goRuntime = `// Error message constants
+const (
+	ErrParseFailed = "The input does not match the grammar"
+	ErrMaxDepth    = "The rules nested deeper than the maximum depth"
+	ErrMaxSteps    = "The parse took more than the maximum number of steps"
+)
+
+// LimitError is the error when a parse exceeds a limit, in the innermost rule being matched
+type LimitError struct {
+	// Err is ErrMaxDepth or ErrMaxSteps
+	Err string
+	// Rule is the name of the rule
+	Rule string
+	// Max is the limit that was exceeded
+	Max int
+	// Line and Position are where the limit was exceeded, both starting at 1
+	Line     int
+	Position int
+}
+
+// Error is the error interface
+func (e LimitError) Error() string {
+	return fmt.Sprintf("%s: %s (%d) at line %d position %d", e.Err, e.Rule, e.Max, e.Line, e.Position)
+}
+
+// Node is a node of a parse tree
+type Node struct {
+	// Rule is the name of the rule that matched, or "" for a terminal
+	Rule string
+	// Text is the input that matched
+	Text string
+	// Start is the offset of the first rune of input that matched, and End is the offset of the rune after the last
+	Start int
+	End   int
+	// Alt is the number of the alternative of the rule that matched, starting at 1, or 0 for a terminal
+	Alt int
+	// Children are the nodes that matched the list items of the rule, in order
+	Children []Node
+}
+
+// Parse reads all of the source, and returns the parse tree of the start rule matching all of it.
+// Returns an error if the source cannot be read, or does not match.
+func Parse(source io.Reader) (Node, error) {
+	data, err := ioutil.ReadAll(source)
+	if err != nil {
+		return Node{}, err
+	}
+
+	return ParseString(string(data))
+}
+
+// result is one way a list of items can match, ending at an offset
+type result struct {
+	end      int
+	children []Node
+}
+
+// memoKey is a rule matched at an offset
+type memoKey struct {
+	rule   string
+	offset int
+}
+
+// runeInterval is a run of consecutive runes of a range terminal
+type runeInterval struct {
+	lo, hi rune
+}
+
+// parser matches input by trying each alternative and repetition of each rule in turn
+type parser struct {
+	input []rune
+	// the input as a string, and the offset in it of each rune of the input and of the end, so that the text of each
+	// match is a substring of it instead of a copy
+	source  string
+	offsets []int
+	memo    map[memoKey][]Node
+	// offset of the furthest rune that a terminal failed to match
+	furthest int
+	// true while matching the trivia rule, which does not skip trivia
+	inTrivia bool
+	// innermost rule being matched, the nesting of the rules being matched, and the number of steps taken
+	active string
+	depth  int
+	steps  int
+}
+
+// newParser constructs a parser of the input
+func newParser(input string) *parser {
+	p := &parser{input: []rune(input), source: input, memo: map[memoKey][]Node{}}
+	p.offsets = make([]int, 0, len(p.input)+1)
+	for offset := range input {
+		p.offsets = append(p.offsets, offset)
+	}
+	p.offsets = append(p.offsets, len(input))
+
+	return p
+}
+
+// text returns the input from start to end
+func (p *parser) text(start, end int) string {
+	return p.source[p.offsets[start]:p.offsets[end]]
+}
+
+// resultSet are the ways items can match in order, where only the first way that ends at each offset is kept.
+// A later way that ends at the same offset lets the rest of the input match in the same ways, so the number of ways
+// kept is at most the length of the input.
+type resultSet struct {
+	list []result
+	ends map[int]bool
+}
+
+// keep adds each match that ends at an offset no kept match ends at
+func (r *resultSet) keep(matches ...result) {
+	for _, match := range matches {
+		if !r.ends[match.end] {
+			r.put(match)
+		}
+	}
+}
+
+// add adds the way match follows the children of a previous match unless a kept way ends at the same offset,
+// returning true if it is added. The children of the first way added after a previous match, which extended is false
+// for, are appended to the children of the previous match in place, so that a chain of repetitions shares one slice
+// of children instead of copying it at each repetition. Other ways copy the children of the previous match.
+func (r *resultSet) add(prev []Node, extended bool, match result) bool {
+	if r.ends[match.end] {
+		return false
+	}
+
+	children := match.children[:len(match.children):len(match.children)]
+	if len(prev) > 0 {
+		if extended {
+			prev = prev[:len(prev):len(prev)]
+		}
+		children = append(prev, match.children...)
+	}
+	r.put(result{end: match.end, children: children})
+
+	return true
+}
+
+// put adds a result
+func (r *resultSet) put(match result) {
+	if r.ends == nil {
+		r.ends = map[int]bool{}
+	}
+	r.list = append(r.list, match)
+	r.ends[match.end] = true
+}
+
+// enter counts the named rule tried at offset, panicking with a LimitError if there are too many steps or the rules
+// nest too deeply. The rule is the innermost rule until the returned function leaves it.
+func (p *parser) enter(name string, offset int) func() {
+	outer := p.active
+	p.active = name
+	p.step(offset)
+
+	p.depth++
+	if (maxDepth > 0) && (p.depth > maxDepth) {
+		p.exceeded(ErrMaxDepth, maxDepth, offset)
+	}
+
+	return func() {
+		p.active = outer
+		p.depth--
+	}
+}
+
+// step counts a step at offset, which is trying a rule or finding a way a list of items or a repetition matches,
+// panicking with a LimitError if there are too many
+func (p *parser) step(offset int) {
+	p.steps++
+	if (maxSteps > 0) && (p.steps > maxSteps) {
+		p.exceeded(ErrMaxSteps, maxSteps, offset)
+	}
+}
+
+// exceeded panics with a LimitError of the innermost rule, which recoverLimit recovers
+func (p *parser) exceeded(err string, max, offset int) {
+	line, position := p.linePosition(offset)
+	panic(LimitError{Err: err, Rule: p.active, Max: max, Line: line, Position: position})
+}
+
+// recoverLimit recovers a LimitError into err, with an empty node, and repanics anything else
+func (p *parser) recoverLimit(node *Node, err *error) {
+	if r := recover(); r != nil {
+		e, isa := r.(LimitError)
+		if !isa {
+			panic(r)
+		}
+
+		*node, *err = Node{}, e
+	}
+}
+
+// match returns each way the named rule can match at offset, in order of alternatives
+func (p *parser) match(name string, offset int, alts ...func(int) []result) []Node {
+	// The matches of rules used by the trivia rule would be different outside of it
+	if nodes, haveIt := p.memo[memoKey{name, offset}]; haveIt && !p.inTrivia {
+		return nodes
+	}
+	defer p.enter(name, offset)()
+
+	var nodes []Node
+	for i, alt := range alts {
+		for _, match := range alt(offset) {
+			nodes = append(nodes, Node{
+				Rule:     name,
+				Text:     p.text(offset, match.end),
+				Start:    offset,
+				End:      match.end,
+				Alt:      i + 1,
+				Children: match.children[:len(match.children):len(match.children)],
+			})
+		}
+
+		if peg && (len(nodes) > 0) {
+			nodes = nodes[:1]
+			break
+		}
+	}
+
+	if !p.inTrivia {
+		p.memo[memoKey{name, offset}] = nodes
+	}
+
+	return nodes
+}
+
+// repeat returns each way a list can match n to m times at offset, where m = -1 is no maximum, with the most repetitions first.
+func (p *parser) repeat(n, m, offset int, list func(int) []result) []result {
+	levels := p.levels(n, m, offset, list)
+
+	var results resultSet
+	for count := len(levels) - 1; count >= n; count-- {
+		results.keep(levels[count]...)
+	}
+
+	if peg && (len(results.list) > 1) {
+		return results.list[:1]
+	}
+
+	return results.list
+}
+
+// repeatLazy is the same as repeat, except that the fewest repetitions are first
+func (p *parser) repeatLazy(n, m, offset int, list func(int) []result) []result {
+	levels := p.levels(n, m, offset, list)
+
+	var results resultSet
+	for count := n; count < len(levels); count++ {
+		results.keep(levels[count]...)
+	}
+
+	if peg && (len(results.list) > 1) {
+		return results.list[:1]
+	}
+
+	return results.list
+}
+
+// levels returns the ways a list can match at offset each number of times, from 0 up to the most repetitions that match,
+// where m = -1 is no maximum, keeping only the first way each number of times ends at each offset.
+// A repetition beyond n that matches nothing is not tried, as it could be repeated forever.
+func (p *parser) levels(n, m, offset int, list func(int) []result) [][]result {
+	levels := [][]result{{{end: offset}}}
+
+	for count := 1; (m == -1) || (count <= m); count++ {
+		var next resultSet
+		for _, prev := range levels[count-1] {
+			extended := false
+			for _, match := range list(prev.end) {
+				if (match.end == prev.end) && (count > n) {
+					continue
+				}
+
+				p.step(match.end)
+				if next.add(prev.children, extended, match) {
+					extended = true
+				}
+			}
+		}
+
+		if len(next.list) == 0 {
+			break
+		}
+		levels = append(levels, next.list)
+	}
+
+	return levels
+}
+
+// then returns each way an item can match after each of the results, keeping only the first way that ends at each offset
+func (p *parser) then(prevs []result, item func(int) []result) []result {
+	var next resultSet
+	for _, prev := range prevs {
+		extended := false
+		for _, match := range item(prev.end) {
+			p.step(match.end)
+			if next.add(prev.children, extended, match) {
+				extended = true
+			}
+		}
+	}
+
+	return next.list
+}
+
+// rule returns the matches of a rule as results
+func (p *parser) rule(nodes []Node) []result {
+	var results []result
+	for _, node := range nodes {
+		results = append(results, result{end: node.End, children: []Node{node}})
+	}
+
+	return results
+}
+
+// str returns the match of a string terminal after any trivia at offset
+func (p *parser) str(s string, offset int) []result {
+	offset = p.skipTrivia(offset)
+
+	end := offset
+	for _, char := range s {
+		if (end >= len(p.input)) || (p.input[end] != char) {
+			p.fail(end)
+			return nil
+		}
+		end++
+	}
+
+	return []result{{end: end, children: []Node{{Text: p.text(offset, end), Start: offset, End: end}}}}
+}
+
+// strConstantTime returns the match of a string terminal after any trivia at offset, comparing every rune of the string
+// whether or not an earlier rune differs, so that the time taken does not reveal how many leading runes match
+func (p *parser) strConstantTime(s string, offset int) []result {
+	offset = p.skipTrivia(offset)
+
+	var (
+		end  = offset
+		diff rune
+	)
+	for _, char := range s {
+		other := ^char
+		if end < len(p.input) {
+			other = p.input[end]
+		}
+
+		diff |= other ^ char
+		end++
+	}
+
+	if (diff != 0) || (end > len(p.input)) {
+		p.fail(offset)
+		return nil
+	}
+
+	return []result{{end: end, children: []Node{{Text: p.text(offset, end), Start: offset, End: end}}}}
+}
+
+// class returns the match of a range terminal after any trivia at offset
+func (p *parser) class(class []runeInterval, offset int) []result {
+	offset = p.skipTrivia(offset)
+
+	if offset < len(p.input) {
+		char := p.input[offset]
+		i := sort.Search(len(class), func(i int) bool { return class[i].hi >= char })
+		if (i < len(class)) && (class[i].lo <= char) {
+			return []result{{end: offset + 1, children: []Node{{Text: p.text(offset, offset+1), Start: offset, End: offset + 1}}}}
+		}
+	}
+
+	p.fail(offset)
+	return nil
+}
+
+// fail records that a terminal failed to match the rune at offset
+func (p *parser) fail(offset int) {
+	if offset > p.furthest {
+		p.furthest = offset
+	}
+}
+
+// linePosition returns the line and position of an offset in the input, both starting at 1
+func (p *parser) linePosition(offset int) (line, position int) {
+	line, position = 1, 1
+	for i := 0; (i < offset) && (i < len(p.input)); i++ {
+		if p.input[i] == '\n' {
+			line++
+			position = 1
+		} else {
+			position++
+		}
+	}
+
+	return line, position
+}
+`
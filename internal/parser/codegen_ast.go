@@ -0,0 +1,170 @@
+//go:build !tinygo
+// +build !tinygo
+
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// astField is a field of a typed node, which holds the matches of a rule the node refers to
+type astField struct {
+	name string
+	rule string
+	// true if the rule can match more than once in a node, so the field is a slice
+	many bool
+}
+
+// astFieldName returns the field name of a rule referred to by another rule, which has the name of the referring rule
+// and an optional dash removed from the beginning, unless that leaves nothing
+func astFieldName(rule, ref string) string {
+	if name := strings.TrimPrefix(strings.TrimPrefix(ref, rule), "-"); (name != ref) && (name != "") {
+		return goIdentifier(name)
+	}
+
+	return goIdentifier(ref)
+}
+
+// astFields returns the fields of the typed node of a rule, in order of first reference
+func astFields(rule Rule) []astField {
+	var (
+		result []astField
+		index  = map[string]int{}
+	)
+
+	for _, alt := range rule.expr.items {
+		counts := map[string]int{}
+		for _, item := range alt.list {
+			if !item.IsRuleName() {
+				continue
+			}
+
+			i, haveIt := index[item.ruleName]
+			if !haveIt {
+				i = len(result)
+				index[item.ruleName] = i
+				result = append(result, astField{rule: item.ruleName})
+			}
+
+			counts[item.ruleName]++
+			if (alt.m != 1) || (counts[item.ruleName] > 1) {
+				result[i].many = true
+			}
+		}
+	}
+
+	return result
+}
+
+// astAltName returns the name of an alternative of a rule, which is the field name of its first rule name item,
+// or Alt followed by its number if it has none
+func astAltName(rule Rule, a int) string {
+	for _, item := range rule.expr.items[a].list {
+		if item.IsRuleName() {
+			return astFieldName(rule.name, item.ruleName)
+		}
+	}
+
+	return fmt.Sprintf("Alt%d", a+1)
+}
+
+// writeAST writes a typed node struct of each rule, with a constructor that converts a Node of the rule into it
+func (gen *goGenerator) writeAST() {
+	// Exported names of the generated package that are not typed nodes, including those of the options that are false
+	for _, name := range []string{
+		"Node", "Parse", "ParseString", "ErrParseFailed", "ErrMaxDepth", "ErrMaxSteps", "LimitError",
+		"Listener", "BaseListener", "Walk", "Visitor", "BaseVisitor", "Visit", "VisitChildren",
+		"ParseToJSON", "GoparseParse", "GoparseFree",
+	} {
+		gen.types[name] = true
+	}
+	for _, rule := range gen.grammar.rules {
+		gen.types["Rule"+gen.idents[rule.name]] = true
+	}
+
+	structs, constructors := map[string]string{}, map[string]string{}
+	for _, rule := range gen.grammar.rules {
+		structs[rule.name] = uniqueName(gen.types, gen.idents[rule.name])
+	}
+	for _, rule := range gen.grammar.rules {
+		constructors[rule.name] = uniqueName(gen.types, "New"+structs[rule.name])
+	}
+
+	for _, rule := range gen.grammar.rules {
+		var (
+			name     = structs[rule.name]
+			newName  = constructors[rule.name]
+			typeName string
+			members  = map[string]bool{"Node": true, "Type": true}
+			fields   = astFields(rule)
+			alts     = len(rule.expr.items)
+		)
+		for i := range fields {
+			fields[i].name = uniqueName(members, astFieldName(rule.name, fields[i].rule))
+		}
+
+		fmt.Fprintf(&gen.str, "\n// %s is the typed node of rule %s\ntype %s struct {\n", name, rule.name, name)
+		gen.str.WriteString("\t// Node is the node of the rule\n\tNode Node\n")
+		if alts > 1 {
+			typeName = uniqueName(gen.types, name+"Type")
+			fmt.Fprintf(&gen.str, "\t// Type is the alternative that matched\n\tType %s\n", typeName)
+		}
+		for _, field := range fields {
+			if field.many {
+				fmt.Fprintf(&gen.str, "\t// %s are the matches of rule %s\n\t%s []*%s\n", field.name, field.rule, field.name, structs[field.rule])
+			} else {
+				fmt.Fprintf(&gen.str, "\t// %s is the match of rule %s, or nil\n\t%s *%s\n", field.name, field.rule, field.name, structs[field.rule])
+			}
+		}
+		gen.str.WriteString("}\n")
+
+		if alts > 1 {
+			fmt.Fprintf(&gen.str, "\n// %s is an alternative of rule %s\ntype %s int\n\n// %s constants\nconst (\n", typeName, rule.name, typeName, typeName)
+			var constants, methods []string
+			for a := range rule.expr.items {
+				altName := astAltName(rule, a)
+				constants = append(constants, uniqueName(gen.types, name+altName))
+				methods = append(methods, uniqueName(members, "Is"+altName))
+
+				if a == 0 {
+					fmt.Fprintf(&gen.str, "\t%s %s = iota + 1\n", constants[a], typeName)
+				} else {
+					fmt.Fprintf(&gen.str, "\t%s\n", constants[a])
+				}
+			}
+			gen.str.WriteString(")\n")
+
+			for a, alt := range rule.expr.items {
+				fmt.Fprintf(&gen.str, "\n// %s returns true if the alternative %s matched\n", methods[a], goComment(formatAlternative(alt)))
+				fmt.Fprintf(&gen.str, "func (n *%s) %s() bool {\n\treturn n.Type == %s\n}\n", name, methods[a], constants[a])
+			}
+		}
+
+		fmt.Fprintf(&gen.str, "\n// %s constructs the typed node of a node of rule %s\nfunc %s(node Node) *%s {\n", newName, rule.name, newName, name)
+		literal := fmt.Sprintf("&%s{Node: node", name)
+		if alts > 1 {
+			literal += fmt.Sprintf(", Type: %s(node.Alt)", typeName)
+		}
+		literal += "}"
+
+		if len(fields) == 0 {
+			fmt.Fprintf(&gen.str, "\treturn %s\n}\n", literal)
+			continue
+		}
+
+		fmt.Fprintf(&gen.str, "\tresult := %s\n", literal)
+		gen.str.WriteString("\tfor _, child := range node.Children {\n\t\tswitch child.Rule {\n")
+		for _, field := range fields {
+			fmt.Fprintf(&gen.str, "\t\tcase Rule%s:\n", gen.idents[field.rule])
+			if field.many {
+				fmt.Fprintf(&gen.str, "\t\t\tresult.%s = append(result.%s, %s(child))\n", field.name, field.name, constructors[field.rule])
+			} else {
+				fmt.Fprintf(&gen.str, "\t\t\tresult.%s = %s(child)\n", field.name, constructors[field.rule])
+			}
+		}
+		gen.str.WriteString("\t\t}\n\t}\n")
+
+		gen.str.WriteString("\n\treturn result\n}\n")
+	}
+}
@@ -0,0 +1,21 @@
+//go:build !tinygo
+// +build !tinygo
+
+package parser
+
+// goWASM is the source of the main function of a wasm module, which sets a JavaScript function that parses into the
+// JSON of a tree
+const goWASM = `
+// main sets the global goparseParse JavaScript function to ParseToJSON, and waits forever so that it can be called
+func main() {
+	js.Global().Set("goparseParse", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if len(args) == 0 {
+			return ParseToJSON("")
+		}
+
+		return ParseToJSON(args[0].String())
+	}))
+
+	select {}
+}
+`
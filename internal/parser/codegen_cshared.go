@@ -0,0 +1,66 @@
+//go:build !tinygo
+// +build !tinygo
+
+package parser
+
+// goParseJSON is the source of ParseToJSON, which the C entry points of a c-shared library and the JavaScript function
+// of a wasm module call
+const goParseJSON = `
+// nodeJSON is the JSON form of a Node
+type nodeJSON struct {
+	Rule     string ` + "`json:\"rule,omitempty\"`" + `
+	Text     string ` + "`json:\"text\"`" + `
+	Start    int    ` + "`json:\"start\"`" + `
+	End      int    ` + "`json:\"end\"`" + `
+	Children []Node ` + "`json:\"children,omitempty\"`" + `
+}
+
+// MarshalJSON is the json.Marshaler interface, which writes an object with rule, text, start, end, and children fields.
+// A terminal has no rule or children fields.
+func (n Node) MarshalJSON() ([]byte, error) {
+	return json.Marshal(nodeJSON{Rule: n.Rule, Text: n.Text, Start: n.Start, End: n.End, Children: n.Children})
+}
+
+// parseResultJSON is the JSON result of ParseToJSON
+type parseResultJSON struct {
+	Tree  *Node  ` + "`json:\"tree,omitempty\"`" + `
+	Error string ` + "`json:\"error,omitempty\"`" + `
+}
+
+// ParseToJSON parses input, and returns a JSON object with either a tree field containing the parse tree,
+// or an error field containing the error message
+func ParseToJSON(input string) string {
+	var result parseResultJSON
+
+	if node, err := ParseString(input); err != nil {
+		result.Error = err.Error()
+	} else {
+		result.Tree = &node
+	}
+
+	// A result of strings, ints, and nodes cannot fail to marshal
+	data, _ := json.Marshal(result)
+	return string(data)
+}
+`
+
+// goCShared is the source of the C entry points of a c-shared library, which parse bytes into the JSON of a tree
+const goCShared = `
+// GoparseParse parses length bytes of UTF-8 input, and returns the JSON of ParseToJSON as a C string, which the caller
+// must free with GoparseFree
+//
+//export GoparseParse
+func GoparseParse(input *C.char, length C.int) *C.char {
+	return C.CString(ParseToJSON(C.GoStringN(input, length)))
+}
+
+// GoparseFree frees a C string returned by GoparseParse
+//
+//export GoparseFree
+func GoparseFree(str *C.char) {
+	C.free(unsafe.Pointer(str))
+}
+
+// main is required to build a c-shared library, and is never called
+func main() {}
+`
@@ -0,0 +1,252 @@
+//go:build !tinygo
+// +build !tinygo
+
+package parser
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateGo(t *testing.T) {
+	g := testGrammar(
+		"list = item list-part",
+		"list-part = (',' item)*",
+		"item = [abcx] | 'if'",
+		"ws = ([_])+",
+	)
+
+	src, err := g.GenerateGo(GoOptions{Package: "list", Trivia: "ws"})
+	assert.Nil(t, err)
+
+	// The source is valid Go
+	_, err = parser.ParseFile(token.NewFileSet(), "list.go", src, 0)
+	assert.Nil(t, err)
+
+	code := string(src)
+	assert.Contains(t, code, "// Code generated by goparse; DO NOT EDIT.\n")
+	assert.Contains(t, code, "package list\n")
+	assert.Contains(t, code, "\tRuleListPart = \"list-part\"\n")
+	assert.Contains(t, code, "const peg = false\n")
+	assert.Contains(t, code, "\tmaxDepth = 10000\n\tmaxSteps = 0\n")
+	assert.Contains(t, code, "type LimitError struct {")
+	assert.Contains(t, code, "defer p.enter(name, offset)()")
+	assert.Contains(t, code, "for _, node := range p.ruleList(0) {")
+	assert.Contains(t, code, "matches := p.ruleWs(offset)")
+	assert.Contains(t, code, "// ruleItem matches item = [a-cx] | 'if'\nfunc (p *parser) ruleItem(offset int) []Node {\n\treturn p.match(RuleItem, offset, p.altItem1, p.altItem2)\n}")
+	assert.Contains(t, code, "return p.repeat(0, -1, offset, func(offset int) []result {")
+	assert.Contains(t, code, "return p.rule(p.ruleItem(offset))")
+	assert.Contains(t, code, `return p.str("if", offset)`)
+	assert.Contains(t, code, "class1 = []runeInterval{{'a', 'c'}, {'x', 'x'}}")
+
+	// The start rule and PEG are options
+	src, err = g.GenerateGo(GoOptions{Package: "item", Start: "item", PEG: true})
+	assert.Nil(t, err)
+	assert.Contains(t, string(src), "const peg = true\n")
+
+	// Limits are options
+	limited, err := g.GenerateGo(GoOptions{Package: "list", MaxDepth: -1, MaxSteps: 100})
+	assert.Nil(t, err)
+	assert.Contains(t, string(limited), "\tmaxDepth = -1\n\tmaxSteps = 100\n")
+	assert.Contains(t, string(src), "for _, node := range p.ruleItem(0) {")
+	assert.Contains(t, string(src), "func (p *parser) skipTrivia(offset int) int {\n\treturn offset\n}")
+
+	// Lazy repetitions have the fewest repetitions first
+	src, err = testGrammar("list = ([ab])*?").GenerateGo(GoOptions{Package: "list"})
+	assert.Nil(t, err)
+	assert.Contains(t, string(src), "return p.repeatLazy(0, -1, offset, func(offset int) []result {")
+
+	// Rule names that have the same identifier are numbered
+	g = testGrammar("a-b = aB", "aB = 'x'")
+	src, err = g.GenerateGo(GoOptions{Package: "ab"})
+	assert.Nil(t, err)
+	assert.Contains(t, string(src), "\tRuleAB  = \"a-b\"\n\tRuleAB2 = \"aB\"\n")
+
+	// A c-shared library exports C functions that parse to JSON
+	src, err = g.GenerateGo(GoOptions{Package: "main", CShared: true})
+	assert.Nil(t, err)
+	_, err = parser.ParseFile(token.NewFileSet(), "main.go", src, 0)
+	assert.Nil(t, err)
+	assert.Contains(t, string(src), "package main\n\n// #include <stdlib.h>\nimport \"C\"\n")
+	assert.Contains(t, string(src), "func ParseToJSON(input string) string {")
+	assert.Contains(t, string(src), "//export GoparseParse\nfunc GoparseParse(input *C.char, length C.int) *C.char {")
+	assert.Contains(t, string(src), "//export GoparseFree\nfunc GoparseFree(str *C.char) {")
+	assert.Contains(t, string(src), "func main() {}")
+
+	// A wasm module sets a JavaScript function that parses to JSON
+	src, err = g.GenerateGo(GoOptions{Package: "main", WASM: true})
+	assert.Nil(t, err)
+	_, err = parser.ParseFile(token.NewFileSet(), "main.go", src, 0)
+	assert.Nil(t, err)
+	assert.Contains(t, string(src), "//go:build js && wasm\n\n")
+	assert.Contains(t, string(src), "\t\"syscall/js\"\n")
+	assert.Contains(t, string(src), "func ParseToJSON(input string) string {")
+	assert.Contains(t, string(src), `js.Global().Set("goparseParse", `)
+
+	// Errors
+	_, err = g.GenerateGo(GoOptions{Package: "func"})
+	assert.Equal(t, fmt.Errorf("%s: func", ErrGoPackage), err)
+
+	_, err = g.GenerateGo(GoOptions{Package: "ab", CShared: true})
+	assert.Equal(t, fmt.Errorf("%s: ab", ErrGoCShared), err)
+
+	_, err = g.GenerateGo(GoOptions{Package: "ab", WASM: true})
+	assert.Equal(t, fmt.Errorf("%s: ab", ErrGoWASM), err)
+
+	_, err = g.GenerateGo(GoOptions{Package: "main", CShared: true, WASM: true})
+	assert.Equal(t, fmt.Errorf("%s: main", ErrGoWASM), err)
+
+	_, err = g.GenerateGo(GoOptions{Package: "ab", Start: "c"})
+	assert.Equal(t, fmt.Errorf("%s: c", ErrUndefinedStartRule), err)
+}
+
+// checkGo type checks generated source, returning the first error, such as a name declared twice
+func checkGo(src []byte) error {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "generated.go", src, 0)
+	if err != nil {
+		return err
+	}
+
+	config := types.Config{Importer: importer.Default(), FakeImportC: true}
+	_, err = config.Check(file.Name.Name, fset, []*ast.File{file}, nil)
+	return err
+}
+
+func TestGoIdentifier(t *testing.T) {
+	assert.Equal(t, "NodesSection", goIdentifier("nodes-section"))
+	assert.Equal(t, "NameChar", goIdentifier("NameChar"))
+	assert.Equal(t, "X1Y", goIdentifier("x_1.y"))
+	assert.Equal(t, "Rule", goIdentifier("--"))
+	assert.Equal(t, "Rule1st", goIdentifier("1st"))
+	assert.Equal(t, "Type", goIdentifier("type"))
+}
+
+func TestGenerateGoAST(t *testing.T) {
+	g := testGrammar(
+		"file = (comment)*",
+		"comment = comment-one-line | comment-multi-line | 'x'",
+		"comment-one-line = '#' name",
+		"comment-multi-line = '{' name name '}'",
+		"name = ([abc])+",
+	)
+
+	src, err := g.GenerateGo(GoOptions{Package: "comments", AST: true})
+	assert.Nil(t, err)
+	_, err = parser.ParseFile(token.NewFileSet(), "comments.go", src, 0)
+	assert.Nil(t, err)
+
+	code := string(src)
+	assert.Contains(t, code, "\tAlt int\n")
+	assert.Contains(t, code, "Alt:      i + 1,")
+	assert.Contains(t, code, "type File struct {\n\t// Node is the node of the rule\n\tNode Node\n\t// Comment are the matches of rule comment\n\tComment []*Comment\n}")
+	assert.Contains(t, code, "\t// OneLine is the match of rule comment-one-line, or nil\n\tOneLine *CommentOneLine\n")
+	assert.Contains(t, code, "\tCommentOneLine2 CommentType = iota + 1\n\tCommentMultiLine2\n\tCommentAlt3\n")
+	assert.Contains(t, code, "func (n *Comment) IsAlt3() bool {\n\treturn n.Type == CommentAlt3\n}")
+	assert.Contains(t, code, "result := &Comment{Node: node, Type: CommentType(node.Alt)}")
+	assert.Contains(t, code, "\t\tcase RuleCommentOneLine:\n\t\t\tresult.OneLine = NewCommentOneLine(child)\n")
+	assert.Contains(t, code, "\t// Name are the matches of rule name\n\tName []*Name\n")
+	assert.Contains(t, code, "func NewName(node Node) *Name {\n\treturn &Name{Node: node}\n}")
+
+	// Typed nodes are only generated if asked for
+	src, err = g.GenerateGo(GoOptions{Package: "comments"})
+	assert.Nil(t, err)
+	assert.NotContains(t, string(src), "type File struct")
+
+	// Typed node names do not collide with the other names of the package
+	g = testGrammar("node = parse", "parse = 'x'")
+	src, err = g.GenerateGo(GoOptions{Package: "node", AST: true})
+	assert.Nil(t, err)
+	assert.Contains(t, string(src), "type Node2 struct {\n\t// Node is the node of the rule\n\tNode Node\n\t// Parse is the match of rule parse, or nil\n\tParse *Parse2\n}")
+
+	// Typed node names do not collide with the error constants, LimitError, or the functions exported to C
+	g = testGrammar(
+		"limit-error = err-max-depth err-max-steps err-parse-failed ParseToJSON goparse-parse goparse-free",
+		"err-max-depth = 'x'",
+		"err-max-steps = 'y'",
+		"err-parse-failed = 'z'",
+		"ParseToJSON = 'u'",
+		"goparse-parse = 'v'",
+		"goparse-free = 'w'",
+	)
+	src, err = g.GenerateGo(GoOptions{Package: "main", AST: true, Visitor: true, CShared: true})
+	assert.Nil(t, err)
+	assert.Nil(t, checkGo(src))
+	assert.Contains(t, string(src), "type LimitError2 struct {\n\t// Node is the node of the rule\n\tNode Node\n\t// ErrMaxDepth is the match of rule err-max-depth, or nil\n\tErrMaxDepth *ErrMaxDepth2\n")
+	assert.Contains(t, string(src), "func NewGoparseFree2(node Node) *GoparseFree2 {")
+
+	// Typed node names do not collide with the rule name constants, such as RuleX of rule x
+	g = testGrammar("x = rule-x", "rule-x = 'x'")
+	src, err = g.GenerateGo(GoOptions{Package: "rules", AST: true})
+	assert.Nil(t, err)
+	assert.Nil(t, checkGo(src))
+	assert.Contains(t, string(src), "\tRuleX     = \"x\"\n\tRuleRuleX = \"rule-x\"\n")
+	assert.Contains(t, string(src), "type X struct {\n\t// Node is the node of the rule\n\tNode Node\n\t// RuleX is the match of rule rule-x, or nil\n\tRuleX *RuleX2\n}")
+
+	// Rule names that are Go keywords or begin with a digit are renamed to identifiers
+	g = testGrammar("type = func", "func = 'x'")
+	g.rules[1].name = "1st"
+	g.rules[0].expr.items[0].list[0].ruleName = "1st"
+	src, err = g.GenerateGo(GoOptions{Package: "keywords", AST: true, Visitor: true})
+	assert.Nil(t, err)
+	assert.Contains(t, string(src), "type Type struct {\n\t// Node is the node of the rule\n\tNode Node\n\t// Rule1st is the match of rule 1st, or nil\n\tRule1st *Rule1st\n}")
+	assert.Contains(t, string(src), "\tRuleRule1st = \"1st\"\n")
+}
+
+func TestASTFieldName(t *testing.T) {
+	assert.Equal(t, "OneLine", astFieldName("comment", "comment-one-line"))
+	assert.Equal(t, "Part", astFieldName("comment", "commentPart"))
+	assert.Equal(t, "Comment", astFieldName("comment", "comment"))
+	assert.Equal(t, "Name", astFieldName("comment", "name"))
+}
+
+func TestGenerateGoVisitor(t *testing.T) {
+	g := testGrammar(
+		"list = (item)+",
+		"item = 'x' | terminal",
+		"terminal = 'y'",
+	)
+
+	src, err := g.GenerateGo(GoOptions{Package: "list", Visitor: true})
+	assert.Nil(t, err)
+	_, err = parser.ParseFile(token.NewFileSet(), "list.go", src, 0)
+	assert.Nil(t, err)
+
+	code := string(src)
+	assert.Contains(t, code, "type Listener interface {\n\t// EnterList is called before the children of a node of rule list\n\tEnterList(node Node)\n")
+	assert.Contains(t, code, "func (BaseListener) ExitItem(Node) {}\n")
+	assert.Contains(t, code, "func Walk(listener Listener, node Node) {")
+	assert.Contains(t, code, "\tcase RuleItem:\n\t\tlistener.EnterItem(node)\n")
+	assert.Contains(t, code, "\tcase RuleItem:\n\t\tlistener.ExitItem(node)\n")
+	assert.Contains(t, code, "type Visitor interface {\n\t// VisitList returns the result of a node of rule list\n\tVisitList(node Node) interface{}\n")
+	assert.Contains(t, code, "func (BaseVisitor) VisitItem(Node) interface{} {\n\treturn nil\n}")
+	assert.Contains(t, code, "\tcase RuleItem:\n\t\treturn visitor.VisitItem(node)\n")
+	assert.Contains(t, code, "func VisitChildren(visitor Visitor, node Node) []interface{} {")
+
+	// A rule named terminal does not collide with VisitTerminal
+	assert.Contains(t, code, "\tVisitTerminal2(node Node) interface{}\n")
+	assert.Contains(t, code, "\tVisitTerminal(node Node) interface{}\n")
+
+	// Interfaces are only generated if asked for
+	src, err = g.GenerateGo(GoOptions{Package: "list"})
+	assert.Nil(t, err)
+	assert.NotContains(t, string(src), "type Visitor interface")
+}
+
+func TestGenerateGoConstantTime(t *testing.T) {
+	g := testGrammar("auth = 'token=' 'secret'")
+	g.rules[0].expr.items[0].list[1].options = []Option{OptionConstTime}
+
+	// Generated parsers compare the terminal in constant time
+	src, err := g.GenerateGo(GoOptions{Package: "auth"})
+	assert.Nil(t, err)
+	assert.Contains(t, string(src), `return p.str("token=", offset)`)
+	assert.Contains(t, string(src), `return p.strConstantTime("secret", offset)`)
+}
@@ -0,0 +1,94 @@
+//go:build !tinygo
+// +build !tinygo
+
+package parser
+
+import (
+	"fmt"
+)
+
+// writeVisitors writes a Listener interface with Enter and Exit methods for each rule, a Visitor interface with a Visit
+// method for each rule, BaseListener and BaseVisitor implementations that do nothing, and Walk and Visit functions
+func (gen *goGenerator) writeVisitors() {
+	var (
+		rules         = gen.grammar.rules
+		listenerNames = map[string]bool{"VisitTerminal": true}
+		visitorNames  = map[string]bool{"VisitTerminal": true}
+		enter         = make([]string, len(rules))
+		exit          = make([]string, len(rules))
+		visit         = make([]string, len(rules))
+	)
+	for i, rule := range rules {
+		enter[i] = uniqueName(listenerNames, "Enter"+gen.idents[rule.name])
+		exit[i] = uniqueName(listenerNames, "Exit"+gen.idents[rule.name])
+		visit[i] = uniqueName(visitorNames, "Visit"+gen.idents[rule.name])
+	}
+
+	gen.str.WriteString("\n// Listener is called by Walk as it enters and exits the node of each rule, and visits each terminal\ntype Listener interface {\n")
+	for i, rule := range rules {
+		fmt.Fprintf(&gen.str, "\t// %s is called before the children of a node of rule %s\n\t%s(node Node)\n", enter[i], rule.name, enter[i])
+		fmt.Fprintf(&gen.str, "\t// %s is called after the children of a node of rule %s\n\t%s(node Node)\n", exit[i], rule.name, exit[i])
+	}
+	gen.str.WriteString("\t// VisitTerminal is called for each terminal\n\tVisitTerminal(node Node)\n}\n")
+
+	gen.str.WriteString("\n// BaseListener is a Listener that does nothing, which can be embedded to implement only some of the methods\ntype BaseListener struct{}\n")
+	for i := range rules {
+		fmt.Fprintf(&gen.str, "\n// %s does nothing\nfunc (BaseListener) %s(Node) {}\n", enter[i], enter[i])
+		fmt.Fprintf(&gen.str, "\n// %s does nothing\nfunc (BaseListener) %s(Node) {}\n", exit[i], exit[i])
+	}
+	gen.str.WriteString("\n// VisitTerminal does nothing\nfunc (BaseListener) VisitTerminal(Node) {}\n")
+
+	gen.str.WriteString(`
+// Walk calls the listener for each node of the tree in depth first order
+func Walk(listener Listener, node Node) {
+	switch node.Rule {
+	case "":
+		listener.VisitTerminal(node)
+		return
+`)
+	for i, rule := range rules {
+		fmt.Fprintf(&gen.str, "\tcase Rule%s:\n\t\tlistener.%s(node)\n", gen.idents[rule.name], enter[i])
+	}
+	gen.str.WriteString("\t}\n\n\tfor _, child := range node.Children {\n\t\tWalk(listener, child)\n\t}\n\n\tswitch node.Rule {\n")
+	for i, rule := range rules {
+		fmt.Fprintf(&gen.str, "\tcase Rule%s:\n\t\tlistener.%s(node)\n", gen.idents[rule.name], exit[i])
+	}
+	gen.str.WriteString("\t}\n}\n")
+
+	gen.str.WriteString("\n// Visitor is called by Visit for a node, and returns a result, such as the value of an expression\ntype Visitor interface {\n")
+	for i, rule := range rules {
+		fmt.Fprintf(&gen.str, "\t// %s returns the result of a node of rule %s\n\t%s(node Node) interface{}\n", visit[i], rule.name, visit[i])
+	}
+	gen.str.WriteString("\t// VisitTerminal returns the result of a terminal\n\tVisitTerminal(node Node) interface{}\n}\n")
+
+	gen.str.WriteString("\n// BaseVisitor is a Visitor that returns nil for every node, which can be embedded to implement only some of the methods\ntype BaseVisitor struct{}\n")
+	for i := range rules {
+		fmt.Fprintf(&gen.str, "\n// %s returns nil\nfunc (BaseVisitor) %s(Node) interface{} {\n\treturn nil\n}\n", visit[i], visit[i])
+	}
+	gen.str.WriteString("\n// VisitTerminal returns nil\nfunc (BaseVisitor) VisitTerminal(Node) interface{} {\n\treturn nil\n}\n")
+
+	gen.str.WriteString(`
+// Visit calls the method of the visitor for the rule of the node, and returns its result.
+// The visitor decides whether to visit the children, such as with VisitChildren.
+func Visit(visitor Visitor, node Node) interface{} {
+	switch node.Rule {
+`)
+	for i, rule := range rules {
+		fmt.Fprintf(&gen.str, "\tcase Rule%s:\n\t\treturn visitor.%s(node)\n", gen.idents[rule.name], visit[i])
+	}
+	gen.str.WriteString(`	}
+
+	return visitor.VisitTerminal(node)
+}
+
+// VisitChildren visits each child of the node, and returns their results in order
+func VisitChildren(visitor Visitor, node Node) []interface{} {
+	results := make([]interface{}, len(node.Children))
+	for i, child := range node.Children {
+		results[i] = Visit(visitor, child)
+	}
+
+	return results
+}
+`)
+}
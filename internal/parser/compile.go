@@ -0,0 +1,189 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Compile error message constants
+const (
+	ErrCompileDialect    = "Unsupported grammar dialect"
+	ErrCompileFoldTrivia = "Terminals cannot be folded in a grammar parsed with trivia, which can occur between them"
+)
+
+// Dialect is a notation of grammar source that Compile can read
+type Dialect uint
+
+// Dialect constants
+const (
+	// DialectGoparse is the EBNF variant of goparse itself, which Grammar.Format writes, read by ImportGoparse
+	DialectGoparse Dialect = iota
+	// DialectISO is the EBNF of ISO/IEC 14977, read by ImportISOEBNF
+	DialectISO
+	// DialectW3C is the EBNF of the W3C XML specification, read by ImportW3CEBNF
+	DialectW3C
+	// DialectABNF is the Augmented BNF of RFC 5234, read by ImportABNF
+	DialectABNF
+	// DialectANTLR is an ANTLR v4 grammar, read by ImportANTLR
+	DialectANTLR
+)
+
+// CompileOptions are the options of Compile
+type CompileOptions struct {
+	// Dialect is the notation of the source
+	Dialect Dialect
+	// Budget is the maximum complexity of the grammar, which has no maximums by default
+	Budget GrammarBudget
+	// Sandbox, if not nil, restricts the compile of grammar source supplied by untrusted users
+	Sandbox *Sandbox
+	// Validate, if true, rejects a grammar that has any diagnostics of Grammar.Validate, returning all of them as
+	// Diagnostics
+	Validate bool
+	// Reserved are the words that Validate rejects rule names of, DefaultReservedWords if nil, where an empty list
+	// reserves no words
+	Reserved []string
+	// Resolver reads the source of a grammar that the source extends with an @extends directive, in the same dialect
+	Resolver func(name string) (io.Reader, error)
+	// TwoLevel, if true, reads the grammar as a two-level grammar of token rules and parser rules, where the strings of
+	// parser rules are replaced by token rules, as described by Grammar.SynthesizeTokens
+	TwoLevel bool
+	// Keywords is how keywords are disambiguated from the other token rules of a two-level grammar that match them,
+	// KeywordsNone by default
+	Keywords KeywordPolicy
+	// FoldTerminals, if true, combines adjacent terminals of the grammar after the token rules of a two-level grammar
+	// are synthesized, as described by Grammar.FoldTerminals, so that the parse makes fewer and larger matches.
+	// It is an error if Trivia is not empty, as trivia can occur between the terminals.
+	FoldTerminals bool
+	// FoldReport, if not nil, is written a line describing each fold of FoldTerminals, such as a: 'x' 'y' -> 'xy'
+	FoldReport io.Writer
+	// Positions are the conventions of the columns of the positions of the grammar, in its rules, diagnostics, and
+	// errors, as described by ParseOptions.Positions, where CR, LF, and CRLF each end a line
+	Positions PositionOptions
+	// Trivia is the trivia rule the grammar is parsed with, as described by ParseOptions.Trivia, if it is compiled for
+	// one, which CompileEngine sets from its parse options
+	Trivia string
+}
+
+// Compile reads grammar source in the notation of the dialect option, and converts it to a Grammar.
+// Source that begins with a line such as @extends "base.g" extends the grammar that the Resolver option reads for the
+// name, as described by Grammar.Extend, where super.name refers to a rule of the base grammar.
+// A byte order mark at the beginning of the source is removed, and source that begins with a UTF-16 byte order mark is
+// transcoded from UTF-16, so that grammars saved by Windows editors compile the same as UTF-8 grammars.
+// Compiling the same source with the same options always produces the same bytes of formatted source, generated code,
+// and other artifacts, which CheckReproducible verifies.
+// Returns an error if the source is invalid, the dialect is not supported, the grammar has diagnostics and the Validate
+// option is true, the grammar exceeds the budget, or the sandbox does not allow it.
+func Compile(source io.Reader, options CompileOptions) (Grammar, error) {
+	if options.Sandbox != nil {
+		return options.Sandbox.compile(source, options)
+	}
+
+	g, _, err := options.importExtended(source, 0, nil, map[string]bool{})
+	if err != nil {
+		return Grammar{}, err
+	}
+
+	if g, err = options.twoLevel(g); err != nil {
+		return Grammar{}, err
+	}
+	g = options.keywords(g)
+
+	if g, err = options.fold(g); err != nil {
+		return Grammar{}, err
+	}
+
+	if err := options.validate(g); err != nil {
+		return Grammar{}, err
+	}
+
+	if err := g.CheckBudget(options.Budget); err != nil {
+		return Grammar{}, err
+	}
+
+	return g, nil
+}
+
+// CompileString is the same as Compile, except that it compiles a string
+func CompileString(source string, options CompileOptions) (Grammar, error) {
+	return Compile(strings.NewReader(source), options)
+}
+
+// CompileBytes is the same as Compile, except that it compiles bytes
+func CompileBytes(source []byte, options CompileOptions) (Grammar, error) {
+	return Compile(bytes.NewReader(source), options)
+}
+
+// twoLevel returns the grammar with token rules synthesized for the strings of parser rules if the TwoLevel option is
+// true, else the grammar unchanged
+func (o CompileOptions) twoLevel(g Grammar) (Grammar, error) {
+	if !o.TwoLevel {
+		return g, nil
+	}
+
+	return g.SynthesizeTokens()
+}
+
+// keywords returns the grammar with keyword rules as described by the Keywords option
+func (o CompileOptions) keywords(g Grammar) Grammar {
+	if o.Keywords == KeywordsNone {
+		return g
+	}
+
+	return g.withKeywords()
+}
+
+// fold returns the grammar with its terminals folded if the FoldTerminals option is true, else the grammar unchanged.
+// Returns an error if terminals are to be folded and there is a trivia rule.
+func (o CompileOptions) fold(g Grammar) (Grammar, error) {
+	if !o.FoldTerminals {
+		return g, nil
+	}
+
+	if o.Trivia != "" {
+		return Grammar{}, fmt.Errorf("%s: %s", ErrCompileFoldTrivia, o.Trivia)
+	}
+
+	return g.FoldTerminals(o.FoldReport), nil
+}
+
+// validate returns the Diagnostics of Grammar.Validate if the Validate option is true and there are any
+func (o CompileOptions) validate(g Grammar) error {
+	if !o.Validate {
+		return nil
+	}
+
+	reserved := o.Reserved
+	if reserved == nil {
+		reserved = DefaultReservedWords()
+	}
+
+	return ofDiagnostic(g.validate(reserved)).Err()
+}
+
+// importDialect reads grammar source in the notation of a dialect, where the positions follow the options, returning the
+// grammar and the names of any grammars it imports
+func importDialect(source io.Reader, dialect Dialect, positions PositionOptions) (Grammar, []string, error) {
+	var (
+		g   Grammar
+		err error
+	)
+
+	switch dialect {
+	case DialectGoparse:
+		g, err = importGoparse(source, positions)
+	case DialectISO:
+		g, err = importISOEBNF(source, positions)
+	case DialectW3C:
+		g, err = importW3CEBNF(source, positions)
+	case DialectABNF:
+		g, err = importABNF(source, positions)
+	case DialectANTLR:
+		return importANTLR(source, positions)
+	default:
+		return Grammar{}, nil, fmt.Errorf("%s: %d", ErrCompileDialect, dialect)
+	}
+
+	return g, nil, err
+}
@@ -0,0 +1,170 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompile(t *testing.T) {
+	for dialect, source := range map[Dialect]string{
+		DialectGoparse: `a = 'x' a-part; a-part = b*; b = 'y';`,
+		DialectISO:     `a = "x", { b } ; b = 'y' ;`,
+		DialectW3C:     `a ::= 'x' b*  b ::= 'y'`,
+		DialectABNF:    "a = %s\"x\" *b\nb = %s\"y\"",
+		DialectANTLR:   `a : 'x' b* ; b : 'y' ;`,
+	} {
+		g, err := Compile(strings.NewReader(source), CompileOptions{Dialect: dialect})
+		assert.Nil(t, err)
+		assert.Equal(t, "a = 'x' a-part\na-part = (b)*\nb = 'y'", g.String(), source)
+	}
+
+	_, err := Compile(strings.NewReader(""), CompileOptions{Dialect: 99})
+	assert.Equal(t, ErrCompileDialect+": 99", err.Error())
+}
+
+func TestCompileString(t *testing.T) {
+	source := `a : 'x' b* ; b : 'y' ;`
+	g, err := CompileString(source, CompileOptions{Dialect: DialectANTLR})
+	assert.Nil(t, err)
+	assert.Equal(t, "a = 'x' a-part\na-part = (b)*\nb = 'y'", g.String())
+
+	g, err = CompileBytes([]byte(source), CompileOptions{Dialect: DialectANTLR})
+	assert.Nil(t, err)
+	assert.Equal(t, "a = 'x' a-part\na-part = (b)*\nb = 'y'", g.String())
+
+	_, err = CompileString(source, CompileOptions{Dialect: 99})
+	assert.Equal(t, ErrCompileDialect+": 99", err.Error())
+
+	// The default dialect is goparse notation
+	g, err = CompileString("a = 'x' b;\nb = 'y';", CompileOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "a = 'x' b\nb = 'y'", g.String())
+
+	// A byte order mark is removed, and UTF-16 is transcoded
+	g, err = CompileString("\ufeffa = 'x' ;", CompileOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "a = 'x'", g.String())
+
+	g, err = CompileBytes([]byte{0xFF, 0xFE, 'a', 0, ' ', 0, '=', 0, ' ', 0, '\'', 0, 'x', 0, '\'', 0, ';', 0}, CompileOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "a = 'x'", g.String())
+
+	// A raw string has no escapes
+	g, err = CompileString("a = `C:\\dir` ;", CompileOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "a = `C:\\dir`", g.String())
+}
+
+func TestCompileValidate(t *testing.T) {
+	source := "a : b ;\nc : 'x' d ;"
+	_, err := Compile(strings.NewReader(source), CompileOptions{Dialect: DialectANTLR})
+	assert.Nil(t, err)
+
+	_, err = Compile(strings.NewReader(source), CompileOptions{Dialect: DialectANTLR, Validate: true})
+	diags := err.(Diagnostics)
+	assert.Equal(t, 3, len(diags))
+	assert.Equal(t, "Undefined rule b at line 1 position 5\nUnreachable rule c at line 2 position 1\nUndefined rule d at line 2 position 9", err.Error())
+
+	var diag Diagnostic
+	assert.True(t, errors.As(err, &diag))
+	assert.Equal(t, DiagUndefinedRule, diag.Code())
+
+	_, err = Compile(strings.NewReader("a : 'x' ;"), CompileOptions{Dialect: DialectANTLR, Validate: true})
+	assert.Nil(t, err)
+
+	_, err = Compile(strings.NewReader(source), CompileOptions{Dialect: DialectANTLR, Validate: true, Sandbox: &Sandbox{}})
+	assert.Equal(t, diags, err)
+}
+
+func TestCompileSpans(t *testing.T) {
+	type span struct {
+		offset, end, line, position, endLine, endPosition int
+	}
+	spanOf := func(node SourceNode) span {
+		return span{node.Offset(), node.End(), node.Line(), node.Position(), node.EndLine(), node.EndPosition()}
+	}
+
+	// The é is two bytes and one position
+	for _, test := range []struct {
+		dialect Dialect
+		source  string
+		// spans of the grammar, 'é', the a-part rule for the repetition of b, and rule b
+		grammar, e, part, b span
+	}{
+		{DialectISO, "a = 'é', {b} ;\nb = 'x' | 'y' ;", span{0, 31, 1, 1, 2, 16}, span{4, 8, 1, 5, 1, 8}, span{10, 13, 1, 10, 1, 13}, span{16, 31, 2, 1, 2, 16}},
+		{DialectW3C, "a ::= 'é' b*\nb ::= 'x' | 'y'", span{0, 29, 1, 1, 2, 16}, span{6, 10, 1, 7, 1, 10}, span{11, 13, 1, 11, 1, 13}, span{14, 29, 2, 1, 2, 16}},
+		{DialectABNF, "a = %s\"é\" *b\nb = %s\"x\" / %s\"y\"", span{0, 31, 1, 1, 2, 18}, span{4, 10, 1, 5, 1, 10}, span{11, 13, 1, 11, 1, 13}, span{14, 31, 2, 1, 2, 18}},
+		{DialectANTLR, "a : 'é' b* ;\nb : 'x' | 'y' ;", span{0, 29, 1, 1, 2, 16}, span{4, 8, 1, 5, 1, 8}, span{9, 11, 1, 9, 1, 11}, span{14, 29, 2, 1, 2, 16}},
+	} {
+		g, err := Compile(strings.NewReader(test.source), CompileOptions{Dialect: test.dialect})
+		assert.Nil(t, err)
+		assert.Equal(t, test.grammar, spanOf(g.SourceNode), test.source)
+
+		e := g.rules[0].expr.items[0].list[0]
+		assert.Equal(t, test.e, spanOf(e.SourceNode), test.source)
+		assert.Equal(t, test.e, spanOf(e.terminal.SourceNode), test.source)
+
+		part := g.rules[1]
+		assert.Equal(t, test.part, spanOf(part.SourceNode), test.source)
+		assert.Equal(t, test.part, spanOf(part.expr.SourceNode), test.source)
+		assert.Equal(t, test.part, spanOf(g.rules[0].expr.items[0].list[1].SourceNode), test.source)
+
+		// The expression of b spans its alternatives
+		b := g.rules[2]
+		assert.Equal(t, test.b, spanOf(b.SourceNode), test.source)
+		alts := b.expr.items
+		assert.Equal(t, alts[0].Offset(), b.expr.Offset(), test.source)
+		assert.Equal(t, alts[1].End(), b.expr.End(), test.source)
+		assert.Equal(t, spanOf(alts[1].SourceNode), spanOf(alts[1].list[0].SourceNode), test.source)
+	}
+}
+
+func TestCompilePositions(t *testing.T) {
+	// A tab advances to the next tab stop, columns begin at 0, and a lone CR ends a line
+	for dialect, source := range map[Dialect]string{
+		DialectGoparse: "c = 'x' ;\ra =\t\tb ;",
+		DialectISO:     "c = 'x' ;\ra =\t\tb ;",
+		DialectW3C:     "c ::= 'x'\ra ::=\tb",
+		DialectABNF:    "c = %s\"x\"\ra =\t\tb",
+		DialectANTLR:   "c : 'x' ;\ra :\t\tb ;",
+	} {
+		_, err := CompileString(source, CompileOptions{Dialect: dialect, Validate: true, Positions: PositionOptions{TabWidth: 4, ZeroBased: true}})
+		assert.Equal(t, "Unreachable rule a at line 2 position 0\nUndefined rule b at line 2 position 8", err.Error(), source)
+	}
+}
+
+func TestCompileFoldTerminals(t *testing.T) {
+	source := "a = 'x' 'y' b;\nb = [abc] | [def];"
+	g, err := CompileString(source, CompileOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "a = 'x' 'y' b\nb = [abc] | [def]", g.String())
+
+	var report strings.Builder
+	g, err = CompileString(source, CompileOptions{FoldTerminals: true, FoldReport: &report})
+	assert.Nil(t, err)
+	assert.Equal(t, "a = 'xy' b\nb = [a-f]", g.String())
+	assert.Equal(t, "a: 'x' 'y' -> 'xy'\nb: [abc] | [def] -> [a-f]\n", report.String())
+
+	// Only token rules are folded in a two-level grammar, as the strings of parser rules are tokens
+	g, err = CompileString("a = 'x' 'y' B;\nB = 'b' 'c';", CompileOptions{TwoLevel: true, FoldTerminals: true})
+	assert.Nil(t, err)
+	assert.Equal(t, "a = X Y B\nB = 'bc'\nX = 'x'\nY = 'y'", g.String())
+
+	// Trivia can occur between terminals, so they are not folded
+	_, err = CompileString(source, CompileOptions{FoldTerminals: true, Trivia: "ws"})
+	assert.Equal(t, ErrCompileFoldTrivia+": ws", err.Error())
+
+	_, err = CompileString(source, CompileOptions{FoldTerminals: true, Trivia: "ws", Sandbox: &Sandbox{}})
+	assert.Equal(t, ErrCompileFoldTrivia+": ws", err.Error())
+
+	_, err = CompileEngine(strings.NewReader(source+"\nws = ' ';"), CompileOptions{FoldTerminals: true}, ParseOptions{Trivia: "ws"})
+	assert.Equal(t, ErrCompileFoldTrivia+": ws", err.Error())
+
+	engine, err := CompileEngine(strings.NewReader(source+"\nws = ' ';"), CompileOptions{}, ParseOptions{Trivia: "ws"})
+	assert.Nil(t, err)
+	_, err = engine.ParseString("x y a")
+	assert.Nil(t, err)
+}
@@ -0,0 +1,58 @@
+package parser
+
+// Constant time error message constants
+const (
+	ErrConstTimeBackend = "The option :CONSTTIME is only supported by the backtracking backend and generated parsers"
+)
+
+// ConstantTime returns true if the list item has the :CONSTTIME option, which means that the string terminal is compared
+// with the input in constant time, so that how long a match takes does not reveal how many of its leading runes match,
+// such as for a terminal of a secret or token validated by a security sensitive service.
+// A failed match is reported at the beginning of the terminal, rather than at the first rune that does not match.
+// Only the backtracking backend and generated parsers compare in constant time, so NewEngine returns ErrConstTimeBackend
+// for the Earley backend, and the event parsers return it, rather than compare a rune at a time.
+func (itm ListItem) ConstantTime() bool {
+	for _, option := range itm.options {
+		if option == OptionConstTime {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasConstantTime returns true if any list item of the grammar has the :CONSTTIME option
+func (g Grammar) hasConstantTime() bool {
+	for _, rule := range g.rules {
+		for _, alt := range rule.expr.items {
+			for _, item := range alt.list {
+				if item.ConstantTime() {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// matchConstantTime returns the offset after a string matched at offset in the input and true, or false if it does not
+// match, comparing every rune of the string whether or not an earlier rune differs
+func matchConstantTime(input []rune, offset int, str string) (int, bool) {
+	var (
+		end  = offset
+		diff rune
+	)
+	for _, char := range str {
+		// Past the end of the input, each rune is compared with a rune that differs from it
+		other := ^char
+		if end < len(input) {
+			other = input[end]
+		}
+
+		diff |= other ^ char
+		end++
+	}
+
+	return end, (diff == 0) && (end <= len(input))
+}
@@ -0,0 +1,68 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchConstantTime(t *testing.T) {
+	input := []rune("xsecret")
+
+	for _, test := range []struct {
+		str     string
+		offset  int
+		end     int
+		matched bool
+	}{
+		{"secret", 1, 7, true},
+		{"secreX", 1, 7, false},
+		{"Xecret", 1, 7, false},
+		{"secrets", 1, 8, false},
+		{"", 7, 7, true},
+		{"é", 7, 8, false},
+	} {
+		end, matched := matchConstantTime(input, test.offset, test.str)
+		assert.Equal(t, test.matched, matched, test.str)
+		if matched {
+			assert.Equal(t, test.end, end, test.str)
+		}
+	}
+}
+
+func TestConstantTime(t *testing.T) {
+	g := testGrammar(
+		"auth = 'token=' 'secret'",
+		"other = auth 'x'",
+	)
+	g.rules[0].expr.items[0].list[1].options = []Option{OptionConstTime}
+	assert.False(t, g.rules[0].expr.items[0].list[0].ConstantTime())
+	assert.True(t, g.rules[0].expr.items[0].list[1].ConstantTime())
+	assert.Equal(t, 0, len(g.InvalidOptions()))
+
+	engine, err := NewEngine(g, ParseOptions{})
+	assert.Nil(t, err)
+
+	tree, err := engine.Parse(strings.NewReader("token=secret"))
+	assert.Nil(t, err)
+	assert.Equal(t, `(auth "token=" "secret")`, tree.SExpr(false))
+
+	// A failed match is reported at the beginning of the terminal, not where the input first differs from it
+	for _, input := range []string{"token=secreX", "token=Xecret", "token=sec"} {
+		_, err = engine.Parse(strings.NewReader(input))
+		assert.Contains(t, err.Error(), " at line 1 position 7: ", input)
+	}
+
+	// The Earley backend and event parsers do not compare in constant time
+	_, err = NewEngine(g, ParseOptions{Backend: BackendEarley})
+	assert.Equal(t, ErrConstTimeBackend, err.Error())
+	assert.Equal(t, ErrConstTimeBackend, g.ParseEvents(strings.NewReader("token=secret"), &recordingHandler{}).Error())
+
+	// The option can only be used on string terminals
+	g.rules[1].expr.items[0].list[0].options = []Option{OptionConstTime}
+	diags := g.InvalidOptions()
+	assert.Equal(t, 1, len(diags))
+	assert.Equal(t, DiagConstTime, diags[0].Code())
+	assert.Equal(t, "The option :CONSTTIME can only be used on string terminals in rule other", diags[0].Message())
+}
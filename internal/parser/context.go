@@ -0,0 +1,48 @@
+package parser
+
+// ParseContext carries user defined values through one parse, so that semantic actions and predicates can share results
+// without global variables. Each parse has its own context, so an Engine can be used for several parses at the same time.
+type ParseContext struct {
+	values map[interface{}]interface{}
+	// decisions of the parse, if its replay log is being recorded
+	replay *replayRecord
+}
+
+// OfParseContext constructs an empty ParseContext
+func OfParseContext() *ParseContext {
+	return &ParseContext{values: map[interface{}]interface{}{}}
+}
+
+// Value returns the value for a key, or nil if there is no value.
+// As with context.Context, keys should be of an unexported type to avoid collisions between packages.
+func (c *ParseContext) Value(key interface{}) interface{} {
+	return c.values[key]
+}
+
+// SetValue sets the value for a key
+func (c *ParseContext) SetValue(key, value interface{}) {
+	c.values[key] = value
+}
+
+// withReplay returns a context with the same values, that records the decisions of the parse
+func (c *ParseContext) withReplay(record *replayRecord) *ParseContext {
+	return &ParseContext{values: c.values, replay: record}
+}
+
+// Action is a semantic action that is called for each node of a rule in the parse tree
+type Action func(ctx *ParseContext, node Node)
+
+// Predicate is a semantic predicate that decides if a match of a rule is acceptable.
+// A predicate may be called for matches that are not in the final parse tree, so it should not modify the context.
+type Predicate func(ctx *ParseContext, node Node) bool
+
+// runActions calls the actions for a node and its descendants, children before their parent
+func runActions(ctx *ParseContext, actions map[string]Action, node Node) {
+	for _, child := range node.children {
+		runActions(ctx, actions, child)
+	}
+
+	if action, haveIt := actions[node.rule]; haveIt && !node.IsTerminal() {
+		action(ctx, node)
+	}
+}
@@ -0,0 +1,64 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testContextKey string
+
+func TestParseContext(t *testing.T) {
+	ctx := OfParseContext()
+	assert.Nil(t, ctx.Value(testContextKey("a")))
+
+	ctx.SetValue(testContextKey("a"), 1)
+	assert.Equal(t, 1, ctx.Value(testContextKey("a")))
+	assert.Nil(t, ctx.Value("a"))
+}
+
+func TestParseActionsPredicates(t *testing.T) {
+	g := testGrammar(
+		"list = item rest",
+		"rest = (',' item)*",
+		"item = word | num",
+		"word = ([abcxyz])+",
+		"num = ([123])+",
+	)
+
+	for _, backend := range []Backend{BackendBacktrack, BackendEarley} {
+		engine, err := NewEngine(g, ParseOptions{
+			Backend: backend,
+			Actions: map[string]Action{
+				"item": func(ctx *ParseContext, node Node) {
+					items, _ := ctx.Value(testContextKey("items")).([]string)
+					ctx.SetValue(testContextKey("items"), append(items, node.Text()))
+				},
+			},
+			Predicates: map[string]Predicate{
+				// Reject reserved words
+				"word": func(ctx *ParseContext, node Node) bool {
+					return ctx.Value(testContextKey(node.Text())) == nil
+				},
+			},
+		})
+		assert.Nil(t, err)
+
+		// Each parse has its own context
+		ctx1, ctx2 := OfParseContext(), OfParseContext()
+		ctx2.SetValue(testContextKey("xyz"), true)
+
+		_, err = engine.ParseWithContext(ctx1, strings.NewReader("ab,12,xyz"))
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"ab", "12", "xyz"}, ctx1.Value(testContextKey("items")))
+
+		_, err = engine.ParseWithContext(ctx2, strings.NewReader("ab,12,xyz"))
+		assert.NotNil(t, err)
+		assert.Nil(t, ctx2.Value(testContextKey("items")))
+
+		// Parse uses an empty context
+		_, err = engine.Parse(strings.NewReader("xyz"))
+		assert.Nil(t, err)
+	}
+}
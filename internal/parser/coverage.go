@@ -0,0 +1,200 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Coverage counts how many times each rule, alternative, and terminal of a grammar was part of a successful parse,
+// over a corpus of inputs, so that grammar authors can see which alternatives have never been tested.
+// It is collected by setting ParseOptions.Coverage, and is safe to share between engines parsing concurrently.
+type Coverage struct {
+	mutex sync.Mutex
+	rules []Rule
+	// number of nodes of each rule
+	counts map[string]int
+	// number of nodes of each alternative of each rule
+	alts Profile
+	// number of nodes of each terminal, indexed by rule name, alternative, and list item
+	terminals map[string][][]int
+}
+
+// OfCoverage constructs an empty Coverage of a grammar
+func OfCoverage(g Grammar) *Coverage {
+	c := &Coverage{
+		rules:     g.rules,
+		counts:    map[string]int{},
+		alts:      Profile{},
+		terminals: map[string][][]int{},
+	}
+
+	for _, rule := range g.rules {
+		c.alts[rule.name] = make([]int, len(rule.expr.items))
+		c.terminals[rule.name] = make([][]int, len(rule.expr.items))
+		for i, alt := range rule.expr.items {
+			c.terminals[rule.name][i] = make([]int, len(alt.list))
+		}
+	}
+
+	return c
+}
+
+// add counts the rules, alternatives, and terminals of a parse tree, where children of the trivia rule are skipped if
+// the alternative cannot match them
+func (c *Coverage) add(root Node, trivia string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	byName := rulesByName(c.rules)
+	WalkPreOrder(root, func(node Node) bool {
+		rule, defined := byName[node.rule]
+		if node.IsTerminal() || !defined {
+			return true
+		}
+		c.counts[node.rule]++
+
+		children := node.children
+		alt, items := fitAlternative(rule, children)
+		if (alt < 0) && (trivia != "") {
+			children = nil
+			for _, child := range node.children {
+				if child.rule != trivia {
+					children = append(children, child)
+				}
+			}
+			alt, items = fitAlternative(rule, children)
+		}
+		if alt < 0 {
+			return true
+		}
+
+		c.alts[node.rule][alt]++
+		for i, child := range children {
+			if child.IsTerminal() {
+				c.terminals[node.rule][alt][items[i]]++
+			}
+		}
+
+		return true
+	})
+}
+
+// fitAlternative returns the first alternative of a rule that matches the children of a node of the rule, and the index
+// of the list item that matches each child, or -1 if no alternative matches
+func fitAlternative(rule Rule, children []Node) (int, []int) {
+	for a, alt := range rule.expr.items {
+		if (len(alt.list) == 0) || (len(children)%len(alt.list) != 0) {
+			continue
+		}
+
+		count := len(children) / len(alt.list)
+		if (count < alt.n) || ((alt.m != -1) && (count > alt.m)) {
+			continue
+		}
+
+		items := make([]int, len(children))
+		fits := true
+		for i, child := range children {
+			items[i] = i % len(alt.list)
+			if !childMatches(alt.list[items[i]], child) {
+				fits = false
+				break
+			}
+		}
+
+		if fits {
+			return a, items
+		}
+	}
+
+	return -1, nil
+}
+
+// childMatches returns true if a node can be the match of a list item
+func childMatches(item ListItem, child Node) bool {
+	if item.IsRuleName() {
+		return child.rule == item.ruleName
+	}
+
+	if !child.IsTerminal() {
+		return false
+	}
+
+	if item.terminal.IsString() {
+		return child.text == item.terminal.theString
+	}
+
+	chars := []rune(child.text)
+	return (len(chars) == 1) && item.terminal.theRange.Contains(chars[0])
+}
+
+// Profile returns the number of times each alternative of each rule was part of a successful parse,
+// which ReorderAlternatives can use to try the most frequent alternatives first
+func (c *Coverage) Profile() Profile {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	profile := Profile{}
+	for name, counts := range c.alts {
+		profile[name] = append([]int(nil), counts...)
+	}
+
+	return profile
+}
+
+// Report returns a coverage report, with a line for each rule, and an indented line for each of its alternatives
+// and each terminal of the alternative, giving the source and number of matches. Lines that were never matched end in
+// " UNCOVERED". The last two lines summarize how many alternatives and terminals were matched at least once.
+func (c *Coverage) Report() string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var (
+		str                                    strings.Builder
+		alts, altsCovered, terms, termsCovered int
+		line                                   = func(indent int, src string, count int) {
+			fmt.Fprintf(&str, "%s%s %d", strings.Repeat("  ", indent), src, count)
+			if count == 0 {
+				str.WriteString(" UNCOVERED")
+			}
+			str.WriteRune('\n')
+		}
+		percent = func(covered, total int) float64 {
+			if total == 0 {
+				return 100
+			}
+
+			return float64(covered) * 100 / float64(total)
+		}
+	)
+
+	for _, rule := range c.rules {
+		line(0, rule.name, c.counts[rule.name])
+
+		for a, alt := range rule.expr.items {
+			count := c.alts[rule.name][a]
+			line(1, formatAlternative(alt), count)
+			alts++
+			if count > 0 {
+				altsCovered++
+			}
+
+			for i, item := range alt.list {
+				if item.IsTerminal() {
+					count := c.terminals[rule.name][a][i]
+					line(2, item.terminal.Format(), count)
+					terms++
+					if count > 0 {
+						termsCovered++
+					}
+				}
+			}
+		}
+	}
+
+	fmt.Fprintf(&str, "alternatives: %d of %d covered (%.1f%%)\n", altsCovered, alts, percent(altsCovered, alts))
+	fmt.Fprintf(&str, "terminals: %d of %d covered (%.1f%%)\n", termsCovered, terms, percent(termsCovered, terms))
+
+	return str.String()
+}
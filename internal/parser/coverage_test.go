@@ -0,0 +1,67 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoverage(t *testing.T) {
+	g := testGrammar(
+		"list = item rest",
+		"rest = (',' item)*",
+		"item = [ab] | 'xy' | '(' list ')'",
+	)
+
+	for _, backend := range []Backend{BackendBacktrack, BackendEarley} {
+		coverage := OfCoverage(g)
+		engine, err := NewEngine(g, ParseOptions{Backend: backend, Coverage: coverage})
+		assert.Nil(t, err)
+
+		for _, source := range []string{"a,xy", "b", "c"} {
+			engine.Parse(strings.NewReader(source))
+		}
+
+		// Failed parses are not counted
+		assert.Equal(
+			t,
+			`list 2
+  item rest 2
+rest 2
+  (',' item)* 2
+    ',' 1
+item 3
+  [ab] 2
+    [ab] 2
+  'xy' 1
+    'xy' 1
+  '(' list ')' 0 UNCOVERED
+    '(' 0 UNCOVERED
+    ')' 0 UNCOVERED
+alternatives: 4 of 5 covered (80.0%)
+terminals: 3 of 5 covered (60.0%)
+`,
+			coverage.Report(),
+		)
+		assert.Equal(t, Profile{"list": {2}, "rest": {2}, "item": {2, 1, 0}}, coverage.Profile())
+	}
+
+	// Trivia children are skipped
+	g = testGrammar(
+		"list = item rest",
+		"rest = (',' item)*",
+		"item = 'a' | 'b'",
+		"ws = '_'",
+	)
+	coverage := OfCoverage(g)
+	engine, err := NewEngine(g, ParseOptions{Trivia: "ws", FullFidelity: true, Coverage: coverage})
+	assert.Nil(t, err)
+	_, err = engine.Parse(strings.NewReader("a_,b_"))
+	assert.Nil(t, err)
+	assert.Equal(t, Profile{"list": {1}, "rest": {1}, "item": {1, 1}, "ws": {2}}, coverage.Profile())
+
+	_, _, err = engine.ParseOne(strings.NewReader("b"))
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2}, coverage.Profile()["item"])
+}
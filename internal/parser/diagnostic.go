@@ -0,0 +1,135 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Diagnostic codes
+const (
+	DiagLeftRecursion = "leftrec"
+	DiagUndefinedRule = "undefrule"
+	DiagUnusedRule    = "unusedrule"
+	DiagDuplicateRule = "duprule"
+	DiagReservedName  = "reserved"
+	DiagEmptyGrammar  = "emptygrammar"
+	DiagEmptyRule     = "emptyrule"
+	DiagOptionsOnly   = "optionsonly"
+	DiagEmptyAlt      = "emptyalt"
+	DiagRepetition    = "repetition"
+	DiagRuleOption    = "ruleoption"
+	DiagItemOption    = "itemoption"
+	DiagLL1Conflict   = "ll1"
+	DiagIndependent   = "independent"
+	DiagConstTime     = "consttime"
+	DiagKeyword       = "keyword"
+)
+
+var (
+	// Diagnostic codes and their messages
+	diagMessages = map[string]string{
+		DiagLeftRecursion: "Left recursive rule cycle",
+		DiagUndefinedRule: "Undefined rule",
+		DiagUnusedRule:    "Unreachable rule",
+		DiagDuplicateRule: "Duplicate rule",
+		DiagReservedName:  "Reserved rule name",
+		DiagEmptyGrammar:  "A grammar must have at least one rule",
+		DiagEmptyRule:     "A rule must have at least one alternative",
+		DiagOptionsOnly:   "A rule with options must have at least one alternative for them to apply to",
+		DiagEmptyAlt:      "An alternative must have at least one list item",
+		DiagRepetition:    "A repetition {N,M} must have N >= 0, and M >= N and M >= 1 or M = -1 for no upper bound",
+		DiagRuleOption:    "A rule can only have one of the options :MEMO or :NOMEMO, the option :INDEPENDENT, and the option :KEYWORD",
+		DiagItemOption:    "The options :MEMO, :NOMEMO, :INDEPENDENT, and :KEYWORD can only be used on rules",
+		DiagLL1Conflict:   "LL(1) conflict",
+		DiagIndependent:   "Every alternative of an independent rule must match once and end with the same string",
+		DiagConstTime:     "The option :CONSTTIME can only be used on string terminals",
+		DiagKeyword:       "The option :KEYWORD can only be used on token rules that are one string",
+	}
+)
+
+// Diagnostic describes a problem found in a grammar, at the line and position of the node that has the problem, in the
+// file of the node if it was read from a grammar that an @extends directive names
+type Diagnostic struct {
+	code     string
+	message  string
+	file     string
+	line     int
+	position int
+}
+
+// newDiagnostic constructs a Diagnostic for a code, with details appended to the message of the code
+func newDiagnostic(code, details string, node SourceNode) Diagnostic {
+	message := diagMessages[code]
+	if details != "" {
+		message = message + " " + details
+	}
+
+	return Diagnostic{
+		code:     code,
+		message:  message,
+		file:     node.file,
+		line:     node.line,
+		position: node.position,
+	}
+}
+
+// Code is the diagnostic code
+func (d Diagnostic) Code() string {
+	return d.code
+}
+
+// Message is the diagnostic message, without the line and position
+func (d Diagnostic) Message() string {
+	return d.message
+}
+
+// File is the name of the grammar an @extends directive named that the node that has the problem was read from, or ""
+// if it was read from the grammar being compiled
+func (d Diagnostic) File() string {
+	return d.file
+}
+
+// Line is the line of the node that has the problem
+func (d Diagnostic) Line() int {
+	return d.line
+}
+
+// Position is the position of the node that has the problem
+func (d Diagnostic) Position() int {
+	return d.position
+}
+
+// String is the message followed by the file, if there is one, and the line and position
+func (d Diagnostic) String() string {
+	if d.file != "" {
+		return fmt.Sprintf("%s in %s at line %d position %d", d.message, d.file, d.line, d.position)
+	}
+
+	return fmt.Sprintf("%s at line %d position %d", d.message, d.line, d.position)
+}
+
+// Error is the error interface, which is the same as String
+func (d Diagnostic) Error() string {
+	return d.String()
+}
+
+// SortDiagnostics sorts diagnostics by file, line, position, then code, keeping diagnostics that are equal in that order in their
+// original order. Each check returns diagnostics in grammar order, and Validate sorts the diagnostics of all checks,
+// so that output is the same for the same grammar. Diagnostics collected from several checks should be sorted the same way.
+// Diagnostics of the grammar being compiled, which have no file, come before those of the grammars it extends, so that
+// the diagnostics of each file are together.
+func SortDiagnostics(diags []Diagnostic) {
+	sort.SliceStable(diags, func(i, j int) bool {
+		a, b := diags[i], diags[j]
+		switch {
+		case a.file != b.file:
+			return a.file < b.file
+		case a.line != b.line:
+			return a.line < b.line
+		case a.position != b.position:
+			return a.position < b.position
+		}
+
+		return a.code < b.code
+	})
+}
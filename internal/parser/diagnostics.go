@@ -0,0 +1,142 @@
+package parser
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// Diagnostics are errors that are all reported at once, such as the Diagnostic of each problem of a grammar, or the
+// ParseError of each region of input that failed to match, in order of line and position.
+// As with errors.Join, the message has the message of each error on its own line, and errors.Is and errors.As match
+// each error, so that errors.As can find the first Diagnostic or ParseError.
+type Diagnostics []error
+
+// positioned is an error at a line and position, such as a Diagnostic or ParseError
+type positioned interface {
+	Line() int
+	Position() int
+}
+
+// OfDiagnostics constructs Diagnostics of the errors that are not nil, sorted by Sort
+func OfDiagnostics(errs ...error) Diagnostics {
+	var result Diagnostics
+	for _, err := range errs {
+		if err != nil {
+			result = append(result, err)
+		}
+	}
+
+	result.Sort()
+	return result
+}
+
+// ofDiagnostic constructs Diagnostics of the diagnostics of a grammar check
+func ofDiagnostic(diags []Diagnostic) Diagnostics {
+	errs := make([]error, len(diags))
+	for i, diag := range diags {
+		errs[i] = diag
+	}
+
+	return OfDiagnostics(errs...)
+}
+
+// filed is an error that has the file it was found in, such as a Diagnostic
+type filed interface {
+	File() string
+}
+
+// coded is an error that has a code, such as a Diagnostic
+type coded interface {
+	Code() string
+}
+
+// Sort sorts the errors by file, line, position, then code, the same as SortDiagnostics, keeping errors that are equal
+// in that order in their original order.
+// Errors that have no File method are sorted as if their file is empty, errors that have no Line and Position methods
+// are sorted as if they are at line 0 position 0, and errors that have no Code method are sorted as if their code is empty.
+func (d Diagnostics) Sort() {
+	file := func(err error) string {
+		if f, isa := err.(filed); isa {
+			return f.File()
+		}
+
+		return ""
+	}
+	linePosition := func(err error) (int, int) {
+		if pos, isa := err.(positioned); isa {
+			return pos.Line(), pos.Position()
+		}
+
+		return 0, 0
+	}
+	code := func(err error) string {
+		if c, isa := err.(coded); isa {
+			return c.Code()
+		}
+
+		return ""
+	}
+
+	sort.SliceStable(d, func(i, j int) bool {
+		fi, fj := file(d[i]), file(d[j])
+		li, pi := linePosition(d[i])
+		lj, pj := linePosition(d[j])
+		switch {
+		case fi != fj:
+			return fi < fj
+		case li != lj:
+			return li < lj
+		case pi != pj:
+			return pi < pj
+		}
+
+		return code(d[i]) < code(d[j])
+	})
+}
+
+// Err returns the diagnostics, or nil if there are none
+func (d Diagnostics) Err() error {
+	if len(d) == 0 {
+		return nil
+	}
+
+	return d
+}
+
+// Error is the error interface, with the message of each error on its own line
+func (d Diagnostics) Error() string {
+	msgs := make([]string, len(d))
+	for i, err := range d {
+		msgs[i] = err.Error()
+	}
+
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap returns the errors, which errors.Is and errors.As match in order
+func (d Diagnostics) Unwrap() []error {
+	return d
+}
+
+// Is returns true if any error matches target, for versions of Go whose errors.Is does not use Unwrap() []error
+func (d Diagnostics) Is(target error) bool {
+	for _, err := range d {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// As sets target to the first error that matches it, for versions of Go whose errors.As does not use Unwrap() []error
+func (d Diagnostics) As(target interface{}) bool {
+	for _, err := range d {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,67 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiagnostics(t *testing.T) {
+	var (
+		other    = errors.New("other")
+		parseErr = newParseError([]rune("a\nbc"), parseFailure{offset: 3}, PositionOptions{})
+		diag     = Diagnostic{code: DiagEmptyRule, message: "empty", line: 2, position: 1}
+		diags    = OfDiagnostics(parseErr, nil, diag, other)
+	)
+
+	// Sorted by line and position, keeping the same line and position in order
+	assert.Equal(t, Diagnostics{other, diag, parseErr}, diags)
+	assert.Equal(t, "other\nempty at line 2 position 1\n"+parseErr.Error(), diags.Error())
+	assert.Equal(t, diags, diags.Err())
+	assert.Equal(t, []error(diags), diags.Unwrap())
+
+	// Empty
+	assert.Nil(t, OfDiagnostics().Err())
+	assert.Nil(t, OfDiagnostics(nil, nil).Err())
+	assert.Equal(t, "", Diagnostics{}.Error())
+
+	// errors.Is and errors.As match each error
+	var err error = diags
+	assert.True(t, errors.Is(err, other))
+	assert.False(t, errors.Is(err, errors.New("other")))
+	assert.True(t, diags.Is(other))
+
+	var found ParseError
+	assert.True(t, errors.As(err, &found))
+	assert.Equal(t, parseErr, found)
+
+	var foundDiag Diagnostic
+	assert.True(t, diags.As(&foundDiag))
+	assert.Equal(t, diag, foundDiag)
+
+	var limit LimitError
+	assert.False(t, errors.As(err, &limit))
+
+	// Diagnostics at the same line and position are sorted by code, the same as SortDiagnostics
+	var (
+		undefined = Diagnostic{code: DiagUndefinedRule, message: "undefined", line: 1, position: 1}
+		empty     = Diagnostic{code: DiagEmptyRule, message: "empty", line: 1, position: 1}
+		sorted    = []Diagnostic{undefined, empty}
+	)
+	SortDiagnostics(sorted)
+	assert.Equal(t, []Diagnostic{empty, undefined}, sorted)
+	assert.Equal(t, Diagnostics{empty, undefined}, OfDiagnostics(undefined, empty))
+	assert.Equal(t, Diagnostics{empty, undefined}, OfDiagnostics(empty, undefined))
+
+	// Diagnostics of other files are sorted after those without a file, by file
+	var (
+		core = Diagnostic{code: DiagEmptyRule, message: "core", file: "core", line: 1, position: 1}
+		base = Diagnostic{code: DiagEmptyRule, message: "base", file: "base", line: 2, position: 1}
+	)
+	assert.Equal(t, Diagnostics{other, empty, undefined, base, core}, OfDiagnostics(core, base, undefined, other, empty))
+
+	// Wrapped
+	assert.True(t, errors.As(fmt.Errorf("compile: %w", err), &foundDiag))
+}
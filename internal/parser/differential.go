@@ -0,0 +1,267 @@
+//go:build !tinygo
+// +build !tinygo
+
+package parser
+
+import (
+	"math/rand"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// CorpusOptions are the options of GenerateCorpus
+type CorpusOptions struct {
+	// Count is the number of inputs to generate
+	Count int
+	// Seed seeds the random choices, so that the same options generate the same corpus
+	Seed int64
+	// MaxDepth is the nesting of rules beyond which the shortest alternatives are chosen, 5 if <= 0
+	MaxDepth int
+	// MutateRatio is the fraction of inputs that have one rune deleted, duplicated, or replaced by another rune of the
+	// grammar after they are generated, so that the corpus has inputs that almost match
+	MutateRatio float64
+}
+
+// Reference is a reference implementation of a grammar, which returns true if it accepts an input
+type Reference func(input string) bool
+
+// Mismatch is an input that an Engine and a reference implementation disagree on
+type Mismatch struct {
+	Input string
+	// Matched is true if the Engine matched the input
+	Matched bool
+	// Err is the error of the Engine if it did not match
+	Err error
+}
+
+// corpusGenerator generates random inputs from a grammar
+type corpusGenerator struct {
+	byName map[string]Rule
+	random *rand.Rand
+	// minimum depth of rules needed to match each rule
+	heights map[string]int
+	// runes of each range
+	ranges map[corpusItem]RuneSet
+	// every rune of the grammar, sorted
+	alphabet []rune
+	maxDepth int
+}
+
+// corpusItem is a list item of an alternative of a rule
+type corpusItem struct {
+	rule      string
+	alt, item int
+}
+
+// GenerateCorpus returns inputs derived from the first rule of the grammar by random choices of alternatives,
+// repetitions, and runes of ranges, some of which are mutated so they may not match.
+// Rules that refer to undefined rules generate nothing for them.
+func (g Grammar) GenerateCorpus(options CorpusOptions) []string {
+	if len(g.rules) == 0 {
+		return nil
+	}
+
+	gen := &corpusGenerator{
+		byName:   rulesByName(g.rules),
+		random:   rand.New(rand.NewSource(options.Seed)),
+		heights:  ruleHeights(g),
+		ranges:   map[corpusItem]RuneSet{},
+		maxDepth: options.MaxDepth,
+	}
+	if gen.maxDepth <= 0 {
+		gen.maxDepth = 5
+	}
+
+	alphabet := map[rune]bool{}
+	for _, rule := range g.rules {
+		for a, alt := range rule.expr.items {
+			for i, item := range alt.list {
+				switch {
+				case item.IsRuleName():
+				case item.terminal.IsString():
+					for _, char := range item.terminal.theString {
+						alphabet[char] = true
+					}
+				default:
+					chars := item.terminal.theRange
+					gen.ranges[corpusItem{rule.name, a, i}] = chars
+					// Only a sample of a large range is added to the alphabet
+					for i, size := 0, chars.Len(); i < size; i += size/16 + 1 {
+						alphabet[chars.nth(i)] = true
+					}
+				}
+			}
+		}
+	}
+	gen.alphabet = sortedRunes(alphabet)
+
+	corpus := make([]string, options.Count)
+	for i := range corpus {
+		var str strings.Builder
+		gen.rule(&str, g.rules[0].name, 0)
+		corpus[i] = str.String()
+
+		if gen.random.Float64() < options.MutateRatio {
+			corpus[i] = gen.mutate(corpus[i])
+		}
+	}
+
+	return corpus
+}
+
+// sortedRunes returns the runes of a set in order
+func sortedRunes(set map[rune]bool) []rune {
+	result := make([]rune, 0, len(set))
+	for char := range set {
+		result = append(result, char)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+
+	return result
+}
+
+// ruleHeights returns the minimum depth of nested rules needed to match each rule, where a rule that can only match
+// terminals has a height of 1, and a rule that cannot match anything finite is left out
+func ruleHeights(g Grammar) map[string]int {
+	heights := map[string]int{}
+
+	for changed := true; changed; {
+		changed = false
+		for _, rule := range g.rules {
+			for _, alt := range rule.expr.items {
+				if height, ok := altHeight(alt, heights); ok {
+					if current, haveIt := heights[rule.name]; !haveIt || (height+1 < current) {
+						heights[rule.name] = height + 1
+						changed = true
+					}
+				}
+			}
+		}
+	}
+
+	return heights
+}
+
+// altHeight returns the height of the deepest rule an alternative needs, and false if it needs a rule with no height yet
+func altHeight(alt ExpressionItem, heights map[string]int) (int, bool) {
+	height := 0
+	if alt.n == 0 {
+		return height, true
+	}
+
+	for _, item := range alt.list {
+		if item.IsRuleName() {
+			itemHeight, haveIt := heights[item.ruleName]
+			if !haveIt {
+				return 0, false
+			}
+			if itemHeight > height {
+				height = itemHeight
+			}
+		}
+	}
+
+	return height, true
+}
+
+// rule writes a random match of the named rule
+func (gen *corpusGenerator) rule(str *strings.Builder, name string, depth int) {
+	rule, defined := gen.byName[name]
+	if !defined {
+		return
+	}
+
+	// Beyond the maximum depth, only the alternatives that end the derivation soonest are chosen
+	var (
+		choices []int
+		best    = -1
+	)
+	for a, alt := range rule.expr.items {
+		height, ok := altHeight(alt, gen.heights)
+		switch {
+		case depth < gen.maxDepth:
+			choices = append(choices, a)
+		case !ok:
+		case (best < 0) || (height < best):
+			best = height
+			choices = []int{a}
+		case height == best:
+			choices = append(choices, a)
+		}
+	}
+	if len(choices) == 0 {
+		return
+	}
+
+	var (
+		a     = choices[gen.random.Intn(len(choices))]
+		alt   = rule.expr.items[a]
+		count = alt.n
+	)
+	if depth < gen.maxDepth {
+		extra := 3
+		if (alt.m != -1) && (alt.m-alt.n < extra) {
+			extra = alt.m - alt.n
+		}
+		count += gen.random.Intn(extra + 1)
+	}
+
+	for ; count > 0; count-- {
+		for i, item := range alt.list {
+			switch {
+			case item.IsRuleName():
+				gen.rule(str, item.ruleName, depth+1)
+			case item.terminal.IsString():
+				str.WriteString(item.terminal.theString)
+			default:
+				chars := gen.ranges[corpusItem{name, a, i}]
+				str.WriteRune(chars.nth(gen.random.Intn(chars.Len())))
+			}
+		}
+	}
+}
+
+// mutate deletes, duplicates, or replaces a random rune of an input, or inserts a rune of the alphabet into an empty input
+func (gen *corpusGenerator) mutate(input string) string {
+	chars := []rune(input)
+	if len(chars) == 0 {
+		if len(gen.alphabet) == 0 {
+			return input
+		}
+		return string(gen.alphabet[gen.random.Intn(len(gen.alphabet))])
+	}
+
+	i := gen.random.Intn(len(chars))
+	switch gen.random.Intn(3) {
+	case 0:
+		chars = append(chars[:i], chars[i+1:]...)
+	case 1:
+		chars = append(chars[:i+1], chars[i:]...)
+	default:
+		chars[i] = gen.alphabet[gen.random.Intn(len(gen.alphabet))]
+	}
+
+	return string(chars)
+}
+
+// Differential parses each input of a corpus, and returns the inputs where whether the engine matches differs from
+// whether the reference implementation accepts, in corpus order
+func (e *Engine) Differential(corpus []string, reference Reference) []Mismatch {
+	var result []Mismatch
+
+	for _, input := range corpus {
+		_, err := e.Parse(strings.NewReader(input))
+		if matched := err == nil; matched != reference(input) {
+			result = append(result, Mismatch{Input: input, Matched: matched, Err: err})
+		}
+	}
+
+	return result
+}
+
+// RegexpReference returns a Reference that accepts an input if the regular expression matches all of it
+func RegexpReference(re *regexp.Regexp) Reference {
+	whole := regexp.MustCompile(`^(?:` + re.String() + `)$`)
+	return whole.MatchString
+}
@@ -0,0 +1,50 @@
+//go:build !tinygo
+// +build !tinygo
+
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDifferentialJSON(t *testing.T) {
+	g, err := ImportW3CEBNF(strings.NewReader(`
+value  ::= object | array | string | number | 'true' | 'false' | 'null'
+object ::= '{' ( member ( ',' member )* )? '}'
+member ::= string ':' value
+array  ::= '[' ( value ( ',' value )* )? ']'
+string ::= '"' char* '"'
+char   ::= [^"\#x0-#x1F] | '\' ["\/bfnrt] | '\u' hex hex hex hex
+hex    ::= [0-9a-fA-F]
+number ::= '-'? int frac? exp?
+int    ::= '0' | [1-9] [0-9]*
+frac   ::= '.' [0-9]+
+exp    ::= [eE] [+-]? [0-9]+
+`))
+	assert.Nil(t, err)
+
+	engine, err := NewEngine(g, ParseOptions{})
+	assert.Nil(t, err)
+
+	// The corpus has both valid and invalid JSON, which the grammar agrees with
+	var (
+		corpus = g.GenerateCorpus(CorpusOptions{Count: 300, Seed: 3, MaxDepth: 4, MutateRatio: 0.5})
+		valid  int
+	)
+	for _, input := range corpus {
+		if JSONReference(input) {
+			valid++
+		}
+	}
+	assert.True(t, (valid > 0) && (valid < len(corpus)))
+	assert.Nil(t, engine.Differential(corpus, JSONReference))
+
+	// The grammar has no whitespace, which the reference accepts
+	mismatches := engine.Differential([]string{"1", " 1"}, JSONReference)
+	assert.Equal(t, 1, len(mismatches))
+	assert.Equal(t, " 1", mismatches[0].Input)
+	assert.False(t, mismatches[0].Matched)
+}
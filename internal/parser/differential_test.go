@@ -0,0 +1,66 @@
+//go:build !tinygo
+// +build !tinygo
+
+package parser
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateCorpus(t *testing.T) {
+	g := testGrammar(
+		"list = '(' items ')'",
+		"items = (item)*",
+		"item = [ab] | list",
+	)
+
+	// The same seed generates the same corpus, and without mutations every input matches
+	options := CorpusOptions{Count: 50, Seed: 1, MaxDepth: 3}
+	corpus := g.GenerateCorpus(options)
+	assert.Equal(t, corpus, g.GenerateCorpus(options))
+	assert.Equal(t, 50, len(corpus))
+
+	engine, err := NewEngine(g, ParseOptions{})
+	assert.Nil(t, err)
+	for _, input := range corpus {
+		_, err := engine.Parse(strings.NewReader(input))
+		assert.Nil(t, err, input)
+	}
+
+	// Mutated inputs may not match
+	options.MutateRatio = 1
+	mismatches := engine.Differential(g.GenerateCorpus(options), func(string) bool { return true })
+	assert.True(t, len(mismatches) > 0)
+	for _, mismatch := range mismatches {
+		assert.False(t, mismatch.Matched)
+		assert.NotNil(t, mismatch.Err)
+	}
+
+	assert.Nil(t, OfGrammar("", nil).GenerateCorpus(options))
+}
+
+func TestDifferentialRegexp(t *testing.T) {
+	var (
+		g = testGrammar(
+			"ident = first rest",
+			"first = [abc_]",
+			"rest = ([abc_0123])*",
+		)
+		engine, _ = NewEngine(g, ParseOptions{})
+		corpus    = g.GenerateCorpus(CorpusOptions{Count: 200, Seed: 2, MutateRatio: 0.5})
+	)
+
+	assert.Nil(t, engine.Differential(corpus, RegexpReference(regexp.MustCompile(`[abc_][abc_0-3]*`))))
+
+	// A reference that disagrees is reported, where the regexp must match all of the input
+	mismatches := engine.Differential(corpus, RegexpReference(regexp.MustCompile(`[abc][abc_0-3]*`)))
+	assert.True(t, len(mismatches) > 0)
+	for _, mismatch := range mismatches {
+		assert.True(t, mismatch.Matched)
+		assert.True(t, strings.HasPrefix(mismatch.Input, "_"))
+	}
+}
@@ -0,0 +1,81 @@
+package parser
+
+// Prefix of a doc comment line that is the display name of the rule
+const docDisplayPrefix = "@display "
+
+// DisplayName returns the name of the rule for end users in error messages, such as ';' for a rule named SEMI,
+// which is the rest of a doc comment line that begins with @display and a space, such as
+//
+//	@display ';'
+//
+// or "" if it has none
+func (r Rule) DisplayName() string {
+	name, _ := r.docLine(docDisplayPrefix)
+	return name
+}
+
+// WithDisplayName returns a copy of the rule with a display name, which replaces the @display line of its doc comment,
+// or is added to the end of it. An empty name removes the @display line.
+func (r Rule) WithDisplayName(name string) Rule {
+	return r.withDocLine(docDisplayPrefix, name)
+}
+
+// displayNames returns the display name of each rule that has one, where options override the display names of the
+// rules, and an empty name removes the display name of a rule
+func displayNames(rules []Rule, options map[string]string) map[string]string {
+	result := map[string]string{}
+	for _, rule := range rules {
+		if name := rule.DisplayName(); name != "" {
+			result[rule.name] = name
+		}
+	}
+
+	for rule, name := range options {
+		if name == "" {
+			delete(result, rule)
+		} else {
+			result[rule] = name
+		}
+	}
+
+	return result
+}
+
+// parseError constructs a ParseError for a failure in the input, with the error message of the rules that failed,
+// where each rule with a display name that could have matched at the failure is expected by its display name,
+// in place of the terminals that can begin it
+func (e *Engine) parseError(input []rune, failure parseFailure) ParseError {
+	result := newParseError(input, failure, e.options.Positions)
+	if message, rule := e.errorMessage(failure.enclosing); message != "" {
+		result.message, result.rule, result.alt = message, rule.name, rule.alt
+	}
+	if len(e.displayNames) == 0 {
+		return result
+	}
+
+	var (
+		expected  = map[string]bool{}
+		displayed = map[string]bool{}
+	)
+	for _, str := range result.expected {
+		expected[str] = true
+	}
+
+	for _, name := range result.expectedRules {
+		display, haveIt := e.displayNames[name]
+		if !haveIt {
+			continue
+		}
+
+		lookahead, _ := e.LookaheadFor(name)
+		for _, terminal := range lookahead.Terminals {
+			if !displayed[terminal.Format()] {
+				delete(expected, terminal.Format())
+			}
+		}
+		expected[display], displayed[display] = true, true
+	}
+	result.expected = sortedKeys(expected)
+
+	return result
+}
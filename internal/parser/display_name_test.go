@@ -0,0 +1,61 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuleDisplayName(t *testing.T) {
+	rule := testGrammar("SEMI = ';'").rules[0]
+	assert.Equal(t, "", rule.DisplayName())
+
+	rule = rule.WithDisplayName("';'")
+	assert.Equal(t, "';'", rule.DisplayName())
+	assert.Equal(t, "@display ';'", rule.Doc())
+
+	rule = rule.WithDoc("A semicolon\n  @display a semicolon\nends a statement").WithDisplayName("';'")
+	assert.Equal(t, "';'", rule.DisplayName())
+	assert.Equal(t, "A semicolon\nends a statement\n@display ';'", rule.Doc())
+
+	rule = rule.WithDisplayName("")
+	assert.Equal(t, "", rule.DisplayName())
+	assert.Equal(t, "A semicolon\nends a statement", rule.Doc())
+}
+
+func TestParseErrorDisplayNames(t *testing.T) {
+	g := testGrammar(
+		"stmt = ID EQUALS value SEMI",
+		"value = ID | NUMBER",
+		"ID = ([abc])+",
+		"NUMBER = ([123])+",
+		"EQUALS = '='",
+		"SEMI = ';'",
+	)
+	g.rules[2] = g.rules[2].WithDisplayName("an identifier")
+	g.rules[5] = g.rules[5].WithDisplayName("a semicolon")
+
+	engine, err := NewEngine(g, ParseOptions{})
+	assert.Nil(t, err)
+
+	_, err = engine.Parse(strings.NewReader("a=!"))
+	assert.Equal(t, []string{"[1-3]", "an identifier"}, err.(ParseError).Expected())
+	assert.Equal(t, "The input does not match the grammar at line 1 position 3: found '!', expected [1-3] or an identifier in rule ID", err.Error())
+
+	// Terminals after the beginning of a rule are expected as terminals
+	_, err = engine.Parse(strings.NewReader("a=1!"))
+	assert.Equal(t, []string{"[1-3]", "a semicolon"}, err.(ParseError).Expected())
+
+	// Options override the display names of rules
+	engine, err = NewEngine(g, ParseOptions{DisplayNames: map[string]string{"NUMBER": "a number", "ID": ""}})
+	assert.Nil(t, err)
+	_, err = engine.Parse(strings.NewReader("a=!"))
+	assert.Equal(t, []string{"[a-c]", "a number"}, err.(ParseError).Expected())
+
+	// The Earley backend expects the same
+	engine, err = NewEngine(g, ParseOptions{Backend: BackendEarley})
+	assert.Nil(t, err)
+	_, err = engine.Parse(strings.NewReader("a=!"))
+	assert.Equal(t, []string{"[1-3]", "an identifier"}, err.(ParseError).Expected())
+}
@@ -1,2 +1,6 @@
 // Package parser implements an Extended Backus-Naur Form parser
+//
+// When built with TinyGo, which sets the tinygo build tag, JSON and SARIF output are left out, as they rely on reflection
+// that TinyGo only partly supports, as are GenerateGo, which formats source with go/format, and GenerateCorpus and
+// Differential, which use regexp. The rest of the package still uses fmt, and crypto/sha256 for replay logs.
 package parser
@@ -0,0 +1,200 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DOTOptions are the options of ToDOTWithOptions
+type DOTOptions struct {
+	// Expressions is true if each rule is drawn as a cluster with a node for each alternative and list item.
+	// An alternative is labelled with its number and repetitions, and a rule name item has a dashed edge to the rule
+	// it refers to. Otherwise each rule is one node.
+	Expressions bool
+}
+
+// ToDOT returns the rule dependency graph of the grammar in the Graphviz DOT language, where each rule is a node,
+// and each rule has an edge to each rule it refers to, in order of first reference.
+// Edges that are part of a cycle are red, and rules that are referred to but not defined are dashed.
+func (g Grammar) ToDOT() string {
+	return g.ToDOTWithOptions(DOTOptions{})
+}
+
+// ToDOTWithOptions is the same as ToDOT, except that it can also draw the expression of each rule
+func (g Grammar) ToDOTWithOptions(options DOTOptions) string {
+	var (
+		str       strings.Builder
+		defined   = rulesByName(g.rules)
+		undefined []string
+		seen      = map[string]bool{}
+		component = ruleComponents(g)
+		// cyclic returns true if an edge is part of a cycle
+		cyclic = func(from, to string) bool {
+			c, haveFrom := component[from]
+			d, haveTo := component[to]
+			return haveFrom && haveTo && (c == d) && ((from != to) || selfReferences(defined[from]))
+		}
+		edgeStyle = func(from, to string, attrs ...string) string {
+			if cyclic(from, to) {
+				attrs = append(attrs, "color=red")
+			}
+			if len(attrs) == 0 {
+				return ""
+			}
+
+			return " [" + strings.Join(attrs, ", ") + "]"
+		}
+	)
+
+	str.WriteString("digraph grammar {\n\tnode [shape=box];\n")
+
+	for i, rule := range g.rules {
+		if !options.Expressions {
+			fmt.Fprintf(&str, "\t%s;\n", dotQuote(rule.name))
+			continue
+		}
+
+		// A cluster of the rule, its alternatives, and their list items in sequence
+		fmt.Fprintf(&str, "\tsubgraph cluster_%d {\n\t\tlabel=%s;\n\t\t%s;\n", i, dotQuote(rule.name), dotQuote(rule.name))
+		for a, alt := range rule.expr.items {
+			altID := fmt.Sprintf("%s/%d", rule.name, a+1)
+			fmt.Fprintf(&str, "\t\t%s [shape=diamond, label=%s];\n", dotQuote(altID), dotQuote(fmt.Sprintf("%d%s", a+1, alt.repetition())))
+			fmt.Fprintf(&str, "\t\t%s -> %s;\n", dotQuote(rule.name), dotQuote(altID))
+
+			prev := altID
+			for l, item := range alt.list {
+				itemID := fmt.Sprintf("%s/%d/%d", rule.name, a+1, l+1)
+				shape := "ellipse"
+				if item.IsTerminal() {
+					shape = "plaintext"
+				}
+				fmt.Fprintf(&str, "\t\t%s [shape=%s, label=%s];\n", dotQuote(itemID), shape, dotQuote(formatListItem(item)))
+				fmt.Fprintf(&str, "\t\t%s -> %s;\n", dotQuote(prev), dotQuote(itemID))
+				prev = itemID
+			}
+		}
+		str.WriteString("\t}\n")
+	}
+
+	for _, rule := range g.rules {
+		var refs []string
+		for a, alt := range rule.expr.items {
+			for l, item := range alt.list {
+				if !item.IsRuleName() {
+					continue
+				}
+
+				if _, isDefined := defined[item.ruleName]; !isDefined && !seen[item.ruleName] {
+					seen[item.ruleName] = true
+					undefined = append(undefined, item.ruleName)
+				}
+
+				if options.Expressions {
+					itemID := fmt.Sprintf("%s/%d/%d", rule.name, a+1, l+1)
+					fmt.Fprintf(&str, "\t%s -> %s%s;\n", dotQuote(itemID), dotQuote(item.ruleName), edgeStyle(rule.name, item.ruleName, "style=dashed"))
+				} else if !containsString(refs, item.ruleName) {
+					refs = append(refs, item.ruleName)
+					fmt.Fprintf(&str, "\t%s -> %s%s;\n", dotQuote(rule.name), dotQuote(item.ruleName), edgeStyle(rule.name, item.ruleName))
+				}
+			}
+		}
+	}
+
+	for _, name := range undefined {
+		fmt.Fprintf(&str, "\t%s [style=dashed];\n", dotQuote(name))
+	}
+	str.WriteString("}\n")
+
+	return str.String()
+}
+
+// dotQuote returns a DOT quoted string
+func dotQuote(str string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(str) + `"`
+}
+
+// containsString returns true if strs contains str
+func containsString(strs []string, str string) bool {
+	for _, s := range strs {
+		if s == str {
+			return true
+		}
+	}
+
+	return false
+}
+
+// selfReferences returns true if a rule refers to itself
+func selfReferences(rule Rule) bool {
+	for _, alt := range rule.expr.items {
+		for _, item := range alt.list {
+			if item.IsRuleName() && (item.ruleName == rule.name) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// ruleComponents returns the strongly connected component of each defined rule, numbered from 0, using Tarjan's algorithm.
+// Rules in the same component can each reach the others by references, so they are part of a cycle if there is more than one,
+// or the one rule refers to itself.
+func ruleComponents(g Grammar) map[string]int {
+	var (
+		byName    = rulesByName(g.rules)
+		index     = map[string]int{}
+		lowLink   = map[string]int{}
+		onStack   = map[string]bool{}
+		stack     []string
+		component = map[string]int{}
+		count     int
+		visit     func(name string)
+	)
+
+	visit = func(name string) {
+		index[name] = len(index)
+		lowLink[name] = index[name]
+		stack = append(stack, name)
+		onStack[name] = true
+
+		for _, alt := range byName[name].expr.items {
+			for _, item := range alt.list {
+				if _, defined := byName[item.ruleName]; !item.IsRuleName() || !defined {
+					continue
+				}
+
+				if _, visited := index[item.ruleName]; !visited {
+					visit(item.ruleName)
+					if lowLink[item.ruleName] < lowLink[name] {
+						lowLink[name] = lowLink[item.ruleName]
+					}
+				} else if onStack[item.ruleName] && (index[item.ruleName] < lowLink[name]) {
+					lowLink[name] = index[item.ruleName]
+				}
+			}
+		}
+
+		// The rule is the root of a component, which is the rules above it on the stack
+		if lowLink[name] == index[name] {
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top] = false
+				component[top] = count
+				if top == name {
+					break
+				}
+			}
+			count++
+		}
+	}
+
+	for _, rule := range g.rules {
+		if _, visited := index[rule.name]; !visited {
+			visit(rule.name)
+		}
+	}
+
+	return component
+}
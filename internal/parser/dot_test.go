@@ -0,0 +1,73 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToDOT(t *testing.T) {
+	g := testGrammar(
+		"list = item rest",
+		"rest = (',' item)*",
+		"item = 'a' | '(' list ')' | item2",
+		"item2 = other",
+	)
+
+	assert.Equal(
+		t,
+		`digraph grammar {
+	node [shape=box];
+	"list";
+	"rest";
+	"item";
+	"item2";
+	"list" -> "item" [color=red];
+	"list" -> "rest" [color=red];
+	"rest" -> "item" [color=red];
+	"item" -> "list" [color=red];
+	"item" -> "item2";
+	"item2" -> "other";
+	"other" [style=dashed];
+}
+`,
+		g.ToDOT(),
+	)
+
+	// A rule that refers to itself is a cycle
+	g = testGrammar("a = 'x' a | b", "b = 'y'")
+	assert.Equal(
+		t,
+		`digraph grammar {
+	node [shape=box];
+	subgraph cluster_0 {
+		label="a";
+		"a";
+		"a/1" [shape=diamond, label="1"];
+		"a" -> "a/1";
+		"a/1/1" [shape=plaintext, label="'x'"];
+		"a/1" -> "a/1/1";
+		"a/1/2" [shape=ellipse, label="a"];
+		"a/1/1" -> "a/1/2";
+		"a/2" [shape=diamond, label="2"];
+		"a" -> "a/2";
+		"a/2/1" [shape=ellipse, label="b"];
+		"a/2" -> "a/2/1";
+	}
+	subgraph cluster_1 {
+		label="b";
+		"b";
+		"b/1" [shape=diamond, label="1"];
+		"b" -> "b/1";
+		"b/1/1" [shape=plaintext, label="'y'"];
+		"b/1" -> "b/1/1";
+	}
+	"a/1/2" -> "a" [style=dashed, color=red];
+	"a/2/1" -> "b" [style=dashed];
+}
+`,
+		g.ToDOTWithOptions(DOTOptions{Expressions: true}),
+	)
+
+	assert.Equal(t, `"a\"b\\c\n"`, dotQuote("a\"b\\c\n"))
+}
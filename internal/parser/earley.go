@@ -0,0 +1,473 @@
+package parser
+
+import (
+	"sort"
+)
+
+// earleyKind describes how a nonterminal of an earleyGrammar appears in a parse tree
+type earleyKind uint
+
+const (
+	// A rule of the grammar, which is a node with children
+	earleyRule earleyKind = iota
+	// A terminal list item, which is a node without children
+	earleyTerminal
+	// A group or repetition of list items, whose children are added to the enclosing node
+	earleyGroup
+)
+
+// earleySymbol is a nonterminal, or a terminal that matches one rune
+type earleySymbol struct {
+	// index of the nonterminal, or -1 for a terminal
+	nonterminal int
+	char        rune
+	chars       RuneSet
+	isRange     bool
+}
+
+// matches returns true if the symbol is a terminal that matches a rune
+func (s earleySymbol) matches(char rune) bool {
+	if s.nonterminal >= 0 {
+		return false
+	}
+
+	if s.isRange {
+		return s.chars.Contains(char)
+	}
+
+	return s.char == char
+}
+
+// earleyNonterminal is a nonterminal of an earleyGrammar
+type earleyNonterminal struct {
+	name        string
+	kind        earleyKind
+	productions []int
+	nullable    bool
+	// terminal list item of an earleyTerminal
+	terminal Terminal
+	// true if the shortest match is preferred when building a tree, for a rule with a lazy item and the nonterminals of its
+	// lazy repetitions
+	lazy bool
+}
+
+// earleyProduction is one sequence of symbols a nonterminal can match
+type earleyProduction struct {
+	lhs     int
+	symbols []earleySymbol
+}
+
+// earleyGrammar is a grammar converted to productions of single rune terminals and nonterminals.
+// Each repetition is converted to a group that is repeated the minimum number of times, followed by a chain of optional groups,
+// or a right recursive group if there is no maximum.
+type earleyGrammar struct {
+	nonterminals []earleyNonterminal
+	productions  []earleyProduction
+	ruleIndex    map[string]int
+}
+
+// earleyItem is a production with a dot before the next symbol to match, and the offset the production began at
+type earleyItem struct {
+	production int
+	dot        int
+	origin     int
+}
+
+// earleySpan is a nonterminal that matched input from start to end
+type earleySpan struct {
+	nonterminal int
+	start       int
+	end         int
+}
+
+// newEarleyGrammar converts a grammar to an earleyGrammar
+func newEarleyGrammar(g Grammar) *earleyGrammar {
+	eg := &earleyGrammar{ruleIndex: map[string]int{}}
+
+	var rules []Rule
+	for _, rule := range g.rules {
+		if _, haveIt := eg.ruleIndex[rule.name]; !haveIt {
+			eg.ruleIndex[rule.name] = eg.addNonterminal(rule.name, earleyRule)
+			rules = append(rules, rule)
+		}
+	}
+
+	for _, rule := range rules {
+		for _, alt := range rule.expr.items {
+			eg.addAlternative(eg.ruleIndex[rule.name], alt)
+			eg.nonterminals[eg.ruleIndex[rule.name]].lazy = eg.nonterminals[eg.ruleIndex[rule.name]].lazy || alt.lazy
+		}
+	}
+
+	// Compute nullable nonterminals until there are no more changes
+	for changed := true; changed; {
+		changed = false
+		for _, prod := range eg.productions {
+			if eg.nonterminals[prod.lhs].nullable {
+				continue
+			}
+
+			nullable := true
+			for _, sym := range prod.symbols {
+				if (sym.nonterminal < 0) || !eg.nonterminals[sym.nonterminal].nullable {
+					nullable = false
+					break
+				}
+			}
+
+			if nullable {
+				eg.nonterminals[prod.lhs].nullable = true
+				changed = true
+			}
+		}
+	}
+
+	return eg
+}
+
+// addNonterminal adds a nonterminal without productions, returning its index
+func (eg *earleyGrammar) addNonterminal(name string, kind earleyKind) int {
+	eg.nonterminals = append(eg.nonterminals, earleyNonterminal{name: name, kind: kind})
+	return len(eg.nonterminals) - 1
+}
+
+// addProduction adds a production to a nonterminal
+func (eg *earleyGrammar) addProduction(lhs int, symbols []earleySymbol) {
+	eg.productions = append(eg.productions, earleyProduction{lhs: lhs, symbols: symbols})
+	eg.nonterminals[lhs].productions = append(eg.nonterminals[lhs].productions, len(eg.productions)-1)
+}
+
+// addAlternative adds the productions for an alternative of a rule
+func (eg *earleyGrammar) addAlternative(lhs int, alt ExpressionItem) {
+	list := eg.listSymbols(alt.list)
+	if (alt.n == 1) && (alt.m == 1) {
+		eg.addProduction(lhs, list)
+		return
+	}
+
+	var (
+		group    = eg.addNonterminal("", earleyGroup)
+		groupSym = earleySymbol{nonterminal: group}
+		tail     = -1
+		symbols  []earleySymbol
+	)
+	eg.addProduction(group, list)
+
+	// More repetitions are listed first, so that the longest match is preferred when building a tree, unless it is lazy
+	addTail := func(symbols []earleySymbol) int {
+		next := eg.addNonterminal("", earleyGroup)
+		eg.nonterminals[next].lazy = alt.lazy
+		if alt.lazy {
+			eg.addProduction(next, nil)
+			eg.addProduction(next, symbols)
+		} else {
+			eg.addProduction(next, symbols)
+			eg.addProduction(next, nil)
+		}
+
+		return next
+	}
+	if alt.m == -1 {
+		// The tail repeats itself, and is the nonterminal addTail adds next
+		tail = len(eg.nonterminals)
+		addTail([]earleySymbol{groupSym, {nonterminal: tail}})
+	} else {
+		for i := alt.n; i < alt.m; i++ {
+			if tail < 0 {
+				tail = addTail([]earleySymbol{groupSym})
+			} else {
+				tail = addTail([]earleySymbol{groupSym, {nonterminal: tail}})
+			}
+		}
+	}
+
+	for i := 0; i < alt.n; i++ {
+		symbols = append(symbols, groupSym)
+	}
+	if tail >= 0 {
+		symbols = append(symbols, earleySymbol{nonterminal: tail})
+	}
+
+	eg.addProduction(lhs, symbols)
+}
+
+// listSymbols returns the symbols for a list of items, adding a terminal nonterminal for each terminal item
+func (eg *earleyGrammar) listSymbols(list []ListItem) []earleySymbol {
+	symbols := make([]earleySymbol, len(list))
+
+	for i, item := range list {
+		if item.IsRuleName() {
+			symbols[i] = earleySymbol{nonterminal: eg.ruleIndex[item.ruleName]}
+			continue
+		}
+
+		var (
+			terminal = eg.addNonterminal("", earleyTerminal)
+			chars    []earleySymbol
+		)
+		if item.terminal.IsString() {
+			for _, char := range item.terminal.theString {
+				chars = append(chars, earleySymbol{nonterminal: -1, char: char})
+			}
+		} else {
+			chars = []earleySymbol{{nonterminal: -1, chars: item.terminal.theRange, isRange: true}}
+		}
+		eg.addProduction(terminal, chars)
+		eg.nonterminals[terminal].terminal = item.terminal
+
+		symbols[i] = earleySymbol{nonterminal: terminal}
+	}
+
+	return symbols
+}
+
+// earleyParse is the state of parsing one input
+type earleyParse struct {
+	grammar    *earleyGrammar
+	ctx        *ParseContext
+	predicates map[string]Predicate
+	input      []rune
+	// ends of each nonterminal that completed at a start offset, longest first, or shortest first if it is lazy
+	ends map[[2]int][]int
+	// spans currently being built, to avoid building a cycle of nonterminals that match nothing forever
+	building map[earleySpan]bool
+}
+
+// parse recognizes the input with the Earley algorithm, then builds the first parse tree of the start rule
+// whose rule nodes are accepted by the predicates.
+// Returns the tree and true, or false and the furthest rune that could not be matched.
+func (eg *earleyGrammar) parse(ctx *ParseContext, predicates map[string]Predicate, input []rune, start string) (Node, bool, parseFailure) {
+	p, failure := eg.recognize(ctx, predicates, input, start)
+	if p == nil {
+		return Node{}, false, failure
+	}
+
+	// Predicates can reject every tree the input matches
+	nodes, ok := p.build(eg.ruleIndex[start], 0, len(input))
+	if !ok {
+		return Node{}, false, failure
+	}
+
+	return nodes[0], true, parseFailure{}
+}
+
+// recognize recognizes the input with the Earley algorithm.
+// Returns the state needed to build parse trees if the start rule matches all of the input, else nil.
+// The furthest rune that could be matched is always returned, with what was expected there if the input does not match.
+func (eg *earleyGrammar) recognize(ctx *ParseContext, predicates map[string]Predicate, input []rune, start string) (*earleyParse, parseFailure) {
+	var (
+		sets     = make([][]earleyItem, len(input)+1)
+		seen     = make([]map[earleyItem]bool, len(input)+1)
+		complete = map[earleySpan]bool{}
+		add      = func(i int, item earleyItem) {
+			if !seen[i][item] {
+				seen[i][item] = true
+				sets[i] = append(sets[i], item)
+			}
+		}
+		startIndex = eg.ruleIndex[start]
+		furthest   int
+	)
+
+	for i := range seen {
+		seen[i] = map[earleyItem]bool{}
+	}
+	for _, prod := range eg.nonterminals[startIndex].productions {
+		add(0, earleyItem{production: prod})
+	}
+
+	for i := 0; i <= len(input); i++ {
+		if len(sets[i]) > 0 {
+			furthest = i
+		}
+
+		for j := 0; j < len(sets[i]); j++ {
+			var (
+				item = sets[i][j]
+				prod = eg.productions[item.production]
+			)
+
+			// Complete
+			if item.dot == len(prod.symbols) {
+				complete[earleySpan{prod.lhs, item.origin, i}] = true
+				for k := 0; k < len(sets[item.origin]); k++ {
+					parent := sets[item.origin][k]
+					parentProd := eg.productions[parent.production]
+					if (parent.dot < len(parentProd.symbols)) && (parentProd.symbols[parent.dot].nonterminal == prod.lhs) {
+						add(i, earleyItem{parent.production, parent.dot + 1, parent.origin})
+					}
+				}
+				continue
+			}
+
+			sym := prod.symbols[item.dot]
+			if sym.nonterminal >= 0 {
+				// Predict, advancing over a nullable nonterminal immediately as it may have already completed in this set
+				for _, next := range eg.nonterminals[sym.nonterminal].productions {
+					add(i, earleyItem{production: next, origin: i})
+				}
+				if eg.nonterminals[sym.nonterminal].nullable {
+					add(i, earleyItem{item.production, item.dot + 1, item.origin})
+				}
+			} else if (i < len(input)) && sym.matches(input[i]) {
+				// Scan
+				add(i+1, earleyItem{item.production, item.dot + 1, item.origin})
+			}
+		}
+	}
+
+	if !complete[earleySpan{startIndex, 0, len(input)}] {
+		return nil, eg.failure(sets, furthest, startIndex, complete)
+	}
+
+	p := &earleyParse{
+		grammar:    eg,
+		ctx:        ctx,
+		predicates: predicates,
+		input:      input,
+		ends:       map[[2]int][]int{},
+		building:   map[earleySpan]bool{},
+	}
+	for span := range complete {
+		key := [2]int{span.nonterminal, span.start}
+		p.ends[key] = append(p.ends[key], span.end)
+	}
+	for key, ends := range p.ends {
+		if eg.nonterminals[key[0]].lazy {
+			sort.Ints(ends)
+		} else {
+			sort.Sort(sort.Reverse(sort.IntSlice(ends)))
+		}
+	}
+
+	return p, parseFailure{offset: furthest}
+}
+
+// failure returns what was expected at the furthest set of items of a recognition that did not match all of the input:
+// the terminals its items were matching, the rules predicted at the offset, the rules of the first terminal,
+// and the end of the input if the start rule completed at the offset
+func (eg *earleyGrammar) failure(sets [][]earleyItem, offset, start int, complete map[earleySpan]bool) parseFailure {
+	result := parseFailure{offset: offset, eof: complete[earleySpan{start, 0, offset}], rules: map[string]bool{}}
+
+	for _, item := range sets[offset] {
+		var (
+			prod = eg.productions[item.production]
+			nt   = eg.nonterminals[prod.lhs]
+		)
+
+		switch {
+		case (nt.kind == earleyTerminal) && (item.dot < len(prod.symbols)):
+			result.terminals = append(result.terminals, nt.terminal)
+			if result.rule == "" {
+				if result.enclosing = eg.enclosingRules(sets, item); len(result.enclosing) > 0 {
+					innermost := result.enclosing[len(result.enclosing)-1]
+					result.rule, result.alt = innermost.name, innermost.alt
+				}
+			}
+		case (nt.kind == earleyRule) && (item.dot == 0) && (item.origin == offset):
+			result.rules[nt.name] = true
+		}
+	}
+
+	return result
+}
+
+// enclosingRules returns the rules an item is part of, innermost last, with the alternatives and offsets they began at,
+// following the items that predicted its groups, terminals, and rules back to the start rule
+func (eg *earleyGrammar) enclosingRules(sets [][]earleyItem, item earleyItem) []activeRule {
+	var result []activeRule
+	for seen := map[earleyItem]bool{}; !seen[item]; {
+		seen[item] = true
+
+		lhs := eg.productions[item.production].lhs
+		if eg.nonterminals[lhs].kind == earleyRule {
+			// Each alternative of a rule is one of its productions, in order
+			rule := activeRule{name: eg.nonterminals[lhs].name, offset: item.origin}
+			for alt, production := range eg.nonterminals[lhs].productions {
+				if production == item.production {
+					rule.alt = alt + 1
+				}
+			}
+			result = append([]activeRule{rule}, result...)
+		}
+
+		predicted := false
+		for _, parent := range sets[item.origin] {
+			symbols := eg.productions[parent.production].symbols
+			if (parent.dot < len(symbols)) && (symbols[parent.dot].nonterminal == lhs) {
+				item, predicted = parent, true
+				break
+			}
+		}
+		if !predicted {
+			break
+		}
+	}
+
+	return result
+}
+
+// build returns the nodes for a nonterminal that completed from start to end, trying productions in order
+func (p *earleyParse) build(nonterminal, start, end int) ([]Node, bool) {
+	span := earleySpan{nonterminal, start, end}
+	if p.building[span] {
+		return nil, false
+	}
+	p.building[span] = true
+	defer delete(p.building, span)
+
+	nt := p.grammar.nonterminals[nonterminal]
+	for _, prod := range nt.productions {
+		children, ok := p.buildSequence(p.grammar.productions[prod].symbols, start, end)
+		if !ok {
+			continue
+		}
+
+		switch nt.kind {
+		case earleyRule:
+			node := Node{rule: nt.name, text: string(p.input[start:end]), start: start, end: end, children: children}
+			if predicate := p.predicates[nt.name]; (predicate != nil) && !predicate(p.ctx, node) {
+				continue
+			}
+			return []Node{node}, true
+		case earleyTerminal:
+			return []Node{{text: string(p.input[start:end]), start: start, end: end}}, true
+		default:
+			return children, true
+		}
+	}
+
+	return nil, false
+}
+
+// buildSequence returns the nodes for a sequence of symbols that matches from start to end
+func (p *earleyParse) buildSequence(symbols []earleySymbol, start, end int) ([]Node, bool) {
+	if len(symbols) == 0 {
+		return nil, start == end
+	}
+
+	sym := symbols[0]
+	if sym.nonterminal < 0 {
+		if (start < end) && sym.matches(p.input[start]) {
+			return p.buildSequence(symbols[1:], start+1, end)
+		}
+		return nil, false
+	}
+
+	for _, next := range p.ends[[2]int{sym.nonterminal, start}] {
+		if next > end {
+			continue
+		}
+
+		nodes, ok := p.build(sym.nonterminal, start, next)
+		if !ok {
+			continue
+		}
+
+		if rest, ok := p.buildSequence(symbols[1:], next, end); ok {
+			return append(nodes, rest...), true
+		}
+	}
+
+	return nil, false
+}
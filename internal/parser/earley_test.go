@@ -0,0 +1,50 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEarleyLeftRecursion(t *testing.T) {
+	var (
+		g = testGrammar(
+			"expr = expr op term | term",
+			"op = [+-]",
+			"term = [123]",
+		)
+		options = ParseOptions{Backend: BackendEarley}
+	)
+
+	assert.Equal(t, "expr(term('1'))", testParse(t, g, options, "1"))
+	assert.Equal(t, "expr(expr(expr(term('1')) op('+') term('2')) op('-') term('3'))", testParse(t, g, options, "1+2-3"))
+	assert.Equal(t, ErrParseFailed+" at line 1 position 3: found EOF, expected [1-3] in rule term", testParse(t, g, options, "1+"))
+}
+
+func TestEarleyAmbiguous(t *testing.T) {
+	var (
+		g = testGrammar(
+			"e = e '+' e | [123]",
+		)
+		options = ParseOptions{Backend: BackendEarley}
+	)
+
+	// Both groupings match, the first alternative is preferred with the longest first match
+	assert.Equal(t, "e(e(e('1') '+' e('2')) '+' e('3'))", testParse(t, g, options, "1+2+3"))
+}
+
+func TestEarleyNullable(t *testing.T) {
+	var (
+		g = testGrammar(
+			"a = b b 'x' | c",
+			"b = ('y')?",
+			"c = (c)?",
+		)
+		options = ParseOptions{Backend: BackendEarley}
+	)
+
+	assert.Equal(t, "a(b() b() 'x')", testParse(t, g, options, "x"))
+	assert.Equal(t, "a(b('y') b() 'x')", testParse(t, g, options, "yx"))
+	assert.Equal(t, "a(b('y') b('y') 'x')", testParse(t, g, options, "yyx"))
+	assert.Equal(t, "a(c())", testParse(t, g, options, ""))
+}
@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf16"
+)
+
+// Encoding error message constants
+const (
+	ErrEncodingOddLength = "The UTF-16 input has an odd number of bytes"
+)
+
+// Byte order marks of the encodings
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// InputEncoding is the encoding of the input of a parse
+type InputEncoding uint
+
+// InputEncoding constants
+const (
+	// EncodingUTF8 reads the input as UTF-8 as is, including any byte order mark
+	EncodingUTF8 InputEncoding = iota
+	// EncodingDetect detects the encoding from a byte order mark, as written by Windows editors: UTF-8 with the mark
+	// removed, or UTF-16 little or big endian transcoded to UTF-8, and UTF-8 if there is no mark
+	EncodingDetect
+	// EncodingUTF16LE transcodes UTF-16 little endian input to UTF-8, removing any byte order mark
+	EncodingUTF16LE
+	// EncodingUTF16BE transcodes UTF-16 big endian input to UTF-8, removing any byte order mark
+	EncodingUTF16BE
+)
+
+// decode returns the input as UTF-8 without a byte order mark, where an unpaired UTF-16 surrogate becomes U+FFFD.
+// Returns an error if UTF-16 input has an odd number of bytes.
+func (e InputEncoding) decode(data []byte) ([]byte, error) {
+	encoding := e
+	if e == EncodingDetect {
+		switch {
+		case bytes.HasPrefix(data, bomUTF8):
+			return data[len(bomUTF8):], nil
+		case bytes.HasPrefix(data, bomUTF16LE):
+			encoding = EncodingUTF16LE
+		case bytes.HasPrefix(data, bomUTF16BE):
+			encoding = EncodingUTF16BE
+		default:
+			return data, nil
+		}
+	}
+	if encoding == EncodingUTF8 {
+		return data, nil
+	}
+
+	if len(data)%2 != 0 {
+		return nil, fmt.Errorf("%s: %d", ErrEncodingOddLength, len(data))
+	}
+
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		if encoding == EncodingUTF16LE {
+			units[i] = uint16(data[2*i]) | uint16(data[2*i+1])<<8
+		} else {
+			units[i] = uint16(data[2*i])<<8 | uint16(data[2*i+1])
+		}
+	}
+	if (len(units) > 0) && (units[0] == 0xFEFF) {
+		units = units[1:]
+	}
+
+	return []byte(string(utf16.Decode(units))), nil
+}
@@ -0,0 +1,95 @@
+package parser
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// utf16Bytes returns a string in UTF-16, little or big endian
+func utf16Bytes(str string, little bool) []byte {
+	var result []byte
+	for _, char := range str {
+		units := []rune{char}
+		if char > 0xFFFF {
+			char -= 0x10000
+			units = []rune{0xD800 + (char >> 10), 0xDC00 + (char & 0x3FF)}
+		}
+		for _, unit := range units {
+			if little {
+				result = append(result, byte(unit), byte(unit>>8))
+			} else {
+				result = append(result, byte(unit>>8), byte(unit))
+			}
+		}
+	}
+
+	return result
+}
+
+func TestInputEncodingDecode(t *testing.T) {
+	for _, test := range []struct {
+		encoding InputEncoding
+		input    []byte
+		decoded  string
+	}{
+		{EncodingUTF8, []byte("\uFEFFab"), "\uFEFFab"},
+		{EncodingDetect, []byte("ab"), "ab"},
+		{EncodingDetect, []byte("\uFEFFaé"), "aé"},
+		{EncodingDetect, utf16Bytes("\uFEFFaé😀", true), "aé😀"},
+		{EncodingDetect, utf16Bytes("\uFEFFaé😀", false), "aé😀"},
+		{EncodingDetect, []byte{}, ""},
+		{EncodingUTF16LE, utf16Bytes("ab", true), "ab"},
+		{EncodingUTF16LE, utf16Bytes("\uFEFFab", true), "ab"},
+		{EncodingUTF16BE, utf16Bytes("ab", false), "ab"},
+		{EncodingUTF16BE, []byte{0xD8, 0x00, 0x00, 'a'}, "�a"},
+	} {
+		decoded, err := test.encoding.decode(test.input)
+		assert.Nil(t, err)
+		assert.Equal(t, test.decoded, string(decoded))
+	}
+
+	_, err := EncodingUTF16LE.decode([]byte{'a', 0, 'b'})
+	assert.Equal(t, ErrEncodingOddLength+": 3", err.Error())
+
+	_, err = EncodingDetect.decode([]byte{0xFF, 0xFE, 'a'})
+	assert.Equal(t, ErrEncodingOddLength+": 3", err.Error())
+}
+
+func TestParseEncoding(t *testing.T) {
+	g := testGrammar("word = ([abé])+")
+
+	// A byte order mark does not match without detection
+	engine, err := NewEngine(g, ParseOptions{})
+	assert.Nil(t, err)
+	_, err = engine.Parse(bytes.NewReader(utf16Bytes("\uFEFFab", true)))
+	assert.IsType(t, ParseError{}, err)
+
+	engine, err = NewEngine(g, ParseOptions{Encoding: EncodingDetect})
+	assert.Nil(t, err)
+
+	for _, input := range [][]byte{[]byte("\uFEFFabé"), utf16Bytes("\uFEFFabé", true), utf16Bytes("\uFEFFabé", false)} {
+		node, err := engine.Parse(bytes.NewReader(input))
+		assert.Nil(t, err)
+		assert.Equal(t, "abé", node.Text())
+
+		start, end := node.Span()
+		assert.Equal(t, []int{0, 3, 4}, []int{start.ByteOffset, end.Offset, end.ByteOffset})
+
+		forest, err := engine.ParseForest(OfParseContext(), bytes.NewReader(input))
+		assert.Nil(t, err)
+		assert.Equal(t, 1, forest.Count(0))
+	}
+
+	// Positions are of the decoded input
+	_, err = engine.Parse(bytes.NewReader(utf16Bytes("\uFEFFabx", false)))
+	assert.Equal(t, 3, err.(ParseError).Position())
+
+	_, err = engine.Parse(bytes.NewReader([]byte{0xFE, 0xFF, 0}))
+	assert.Equal(t, ErrEncodingOddLength+": 3", err.Error())
+
+	_, _, err = engine.ParseOne(strings.NewReader("ab"))
+	assert.Equal(t, ErrParseOneEncoding, err.Error())
+}
@@ -0,0 +1,485 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Engine error message constants
+const (
+	ErrUndefinedStartRule = "The start rule is not defined"
+	ErrParseFailed        = "The input does not match the grammar"
+	ErrPEGBackend         = "PEG ordered choice is only supported by the backtracking backend"
+	ErrUndefinedTrivia    = "The trivia rule is not defined"
+	ErrTriviaBackend      = "Trivia is only supported by the backtracking backend"
+)
+
+// Backend is an algorithm an Engine uses to match input
+type Backend uint
+
+// Backend constants
+const (
+	// BackendBacktrack tries each alternative and repetition in turn, backtracking when the rest of the input does not match.
+	// It cannot match left recursive rules.
+	BackendBacktrack Backend = iota
+	// BackendEarley uses the Earley algorithm, which matches any grammar including left recursive and ambiguous grammars,
+	// at the cost of more time and memory for grammars that need little backtracking
+	BackendEarley
+)
+
+// ParseOptions are the options of an Engine
+type ParseOptions struct {
+	// Backend is the matching algorithm, BackendBacktrack by default
+	Backend Backend
+	// Start is the name of the rule that must match all of the input, the first rule of the grammar by default
+	Start string
+	// EliminateLeftRecursion is true if the backtracking backend matches a left recursive grammar by matching the grammar
+	// Grammar.EliminateLeftRecursion rewrites it to, so that trees have the rules of the rewritten grammar, such as
+	// expr-tail. Other backends match left recursive grammars as they are.
+	EliminateLeftRecursion bool
+	// PEG is true if | is a PEG ordered choice, where the first alternative that matches is the only match of a rule,
+	// and repetitions match as many times as possible without giving any back.
+	// A lazy repetition matches only as many times as its lower bound, as it never takes more later, so that
+	// '/*' ([^])*? '*/' only matches /**/, and a delimiter must be excluded from the repeated range instead.
+	// Otherwise | is a generative alternation, and any match of an alternative or repetition that lets the rest of the
+	// input match is used. The two semantics can accept different inputs for the same grammar.
+	PEG bool
+	// Actions are called for each node of the named rules after a successful parse, children before their parent
+	Actions map[string]Action
+	// Predicates are called for each match of the named rules while parsing, and reject the match if they return false.
+	// The backtracking backend offers a predicate every way its rule can match, but only the first way each rule it
+	// refers to can match up to each offset.
+	Predicates map[string]Predicate
+	// Memo is true if the backtracking backend memoizes the matches of rules that have neither the :MEMO nor :NOMEMO option
+	Memo bool
+	// MemoSize is the maximum number of entries in the whole memo table of a parse, no maximum if <= 0, where an entry
+	// is the matches of one rule at one input position, so that a table of MemoSize entries is shared by every rule and
+	// position, and an evicted entry is matched again if it is needed again
+	MemoSize int
+	// MemoEviction chooses which memoized matches are removed when there are MemoSize of them, MemoEvictLRU by default
+	MemoEviction MemoEviction
+	// MemoStore, if not nil, constructs the memo store of each parse of the backtracking backend, instead of the default
+	// table limited by MemoSize and MemoEviction, such as OfArenaMemo or MemoCounter.NewStore
+	MemoStore func() MemoStore
+	// ShareSubexpressions is true if the backtracking backend matches each alternative that is identical in two or more
+	// rules, such as b 'x' in a = b 'x' | 'y' and c = 'z' | b 'x', once per offset for all of them, instead of once for
+	// each rule. The grammar and trees are the same either way. Alternatives of rules with a predicate or limits are not shared.
+	ShareSubexpressions bool
+	// Trivia is the name of a rule, such as whitespace and comments, that the backtracking backend matches as many times as
+	// possible before each terminal and at the end of the input, except while matching the trivia rule itself.
+	// Trivia is left out of the tree unless FullFidelity is true, but is still part of the text of the rules around it.
+	Trivia string
+	// FullFidelity is true if the tree keeps every rune of the input, so that the Source of the root is the input.
+	// Trivia nodes are children of the rule they precede a terminal of, and trailing trivia are the last children of the root.
+	// If the input does not match, Parse returns a tree along with the error, where the input that did not match is an error node:
+	// the backtracking backend returns the longest match of the start rule followed by an error node for the rest of the input,
+	// and the Earley backend returns the start rule with one error node for all of the input.
+	FullFidelity bool
+	// Limits are resource limits of the named rules, which override the limits of the rules themselves,
+	// where zero limits remove the limits of a rule. The backtracking backend stops the parse with a LimitError as soon as
+	// any attempt to match a rule exceeds one of its limits, even if a shorter match would let the input match.
+	Limits map[string]RuleLimits
+	// Coverage, if not nil, counts the rules, alternatives, and terminals of each successful parse.
+	// It must be constructed from the same grammar as the engine.
+	Coverage *Coverage
+	// MaxDepth is the maximum nesting of rules the backtracking backend matches, DefaultMaxDepth if 0, no maximum if < 0
+	MaxDepth int
+	// MaxRepetitions is the maximum number of times the backtracking backend repeats any alternative,
+	// DefaultMaxRepetitions if 0, no maximum if < 0, which the MaxRepetitions of a rule limit lowers for that rule
+	MaxRepetitions int
+	// MaxSteps is the maximum number of times the backtracking backend tries to match a rule or finds a way that a list of
+	// items or a repetition of an alternative matches, no maximum if <= 0, which bounds the time spent backtracking,
+	// including the time spent on the matches of memoized rules
+	MaxSteps int
+	// MaxInputSize is the maximum number of bytes of input that are read, no maximum if <= 0
+	MaxInputSize int
+	// NoCallbacks is true if Actions and Predicates cannot be used, so that no user code runs while parsing
+	NoCallbacks bool
+	// Parallelism is the maximum number of goroutines that parse regions of the input at the same time, if the start rule
+	// is a repetition of an independent rule with no upper bound, such as file = (record)*. The input is split after the
+	// string that ends each match of the independent rule, and the matches in each region are merged in order.
+	// If a region does not match, such as when the string also appears inside a match, the whole input is parsed at once.
+	// Predicates are called from several goroutines with nodes whose offsets are relative to the start of their region,
+	// and limits apply to each region. Parallelism <= 1, or FullFidelity, parses the whole input at once.
+	Parallelism int
+	// Sync are terminals, such as ; and }, that the backtracking backend recovers from errors with. When the input
+	// does not match, the input from the furthest rune that failed to match to the end of the next sync terminal is
+	// skipped, and the input is matched again, where a rule that ends with the sync terminal can match the skipped input
+	// as an error node, starting anywhere after the previous sync terminal and before the error. This is repeated until the
+	// input matches or there is no sync terminal to skip to, so the input is matched once more for each error.
+	// Parse then returns Diagnostics with the ParseError of each error, and the tree with error nodes if the input matched.
+	// Actions and coverage are only run for a parse without errors.
+	Sync []string
+	// Overrides replace the rules of the grammar with the same names, or are added to it if it has no rule of the name,
+	// so that a host can plug its own rules into a shared grammar without editing its source, such as an identifier rule
+	// of a product. Extensions add their alternatives after those of the rules with the same names, once overridden.
+	// The resulting grammar is validated, and is the grammar of the engine.
+	Overrides  []Rule
+	Extensions []Rule
+	// Indent, if not nil, parses indentation sensitive input, such as Python and YAML, where the engine inserts an INDENT
+	// token before the first rune of each line that is indented further than the line before it, and an OUTDENT token
+	// for each indentation that a line returns from, ignoring blank lines. The INDENT and OUTDENT rules match the tokens,
+	// unless the grammar defines them, and each item with the :INDENT or :OUTDENT option must be followed by the token,
+	// and each item with the :PREINDENT or :PREOUTDENT option preceded by it. The tokens match no text in the tree.
+	Indent *IndentOptions
+	// DisplayNames are the names of the named rules for end users in error messages, which override the display names
+	// of the rules themselves, where an empty name removes the display name of a rule. A ParseError expects a rule with a
+	// display name that could have matched by its display name, in place of the terminals that can begin the rule,
+	// such as an identifier instead of [a-z].
+	DisplayNames map[string]string
+	// EOL, if not nil, matches the end of a line where the :EOL and :PREEOL options are: each item with the :EOL option
+	// must be followed by a newline, and each item with the :PREEOL option preceded by one, which the EOL rule matches,
+	// unless the grammar defines it. The trivia rule should not match newlines, as it is matched before the newline.
+	EOL *EOLOptions
+	// Positions are the conventions of the columns of the positions the parse reports, in errors and node spans,
+	// where a tab advances one column, and the first column is 1, by default
+	Positions PositionOptions
+	// Locale, if not nil, defines the NUMBER, MONTH, and DATE rules where the grammar refers to them and does not define
+	// them, which match numbers with the decimal and group separators of the locale, the names of its months, and dates
+	// in its order, such as 1.234,5 and 31.12.2024 in de-DE. The trivia rule is skipped before each of their terminals.
+	Locale *Locale
+	// Encoding is the encoding of the input, which is decoded to UTF-8 before it is parsed, EncodingUTF8 by default.
+	// Offsets and positions are those of the decoded input, without a byte order mark. MaxInputSize limits the input
+	// before it is decoded.
+	Encoding InputEncoding
+	// Replay, if not nil, records a ReplayLog of each parse by the backtracking backend, with hashes of the grammar and
+	// input and every decision the parse made, which Engine.Replay re-executes. The input is not parsed in parallel regions.
+	Replay *ReplayRecorder
+	// NoQuickCheck is true if the backtracking backend tries every alternative of a rule. Otherwise, alternatives that
+	// cannot begin with the next rune of the input according to Grammar.QuickCheck are skipped without matching them,
+	// unless there is a Trivia rule or a Replay is recorded. Set it for grammars whose terminals defeat the analysis.
+	NoQuickCheck bool
+}
+
+// Engine parses input according to a grammar
+type Engine struct {
+	grammar Grammar
+	rules   map[string]Rule
+	options ParseOptions
+	earley  *earleyGrammar
+	// names of rules the backtracking backend memoizes
+	memoized map[string]bool
+	// id of each alternative shared between rules, by rule name and index of the alternative
+	shared map[string]map[int]int
+	// limits of the rules that have any
+	limits map[string]RuleLimits
+	// separator that the input can be split after into regions that are parsed in parallel, or "" if it cannot be split
+	separator string
+	// sync terminals that each rule ends with
+	syncRules map[string]map[string]bool
+	// display names of the rules that have any
+	displayNames map[string]string
+	// how keyword rules are disambiguated from the other token rules
+	keywords keywordTable
+	// quick check sets of the alternatives of each rule, or nil if alternatives are not skipped
+	quickCheck *QuickCheck
+	// first sets of each rule, computed the first time LookaheadFor is called
+	firstsOnce sync.Once
+	firsts     map[string]ruleFirstSets
+}
+
+// NewEngine constructs an Engine for a grammar, with any overrides and extensions of its rules.
+// Returns the first diagnostic as an error if the grammar has duplicate or undefined rules, empty alternatives,
+// impossible repetitions, or invalid options; or if the grammar is left recursive and the backend cannot match it,
+// or its left recursion cannot be eliminated.
+func NewEngine(g Grammar, options ParseOptions) (*Engine, error) {
+	g, err := g.withOverrides(options.Overrides, options.Extensions)
+	if err != nil {
+		return nil, err
+	}
+	if options.Locale != nil {
+		g = g.withLocale(*options.Locale)
+	}
+	var tokens []optionToken
+	if options.Indent != nil {
+		tokens = append(tokens, indentTokens()...)
+	}
+	if options.EOL != nil {
+		tokens = append(tokens, options.EOL.token())
+	}
+	if len(tokens) > 0 {
+		g = g.withOptionTokens(tokens)
+	}
+	if options.EliminateLeftRecursion && (options.Backend == BackendBacktrack) {
+		if g, err = g.EliminateLeftRecursion(); err != nil {
+			return nil, err
+		}
+	}
+
+	checks := g.checks()
+	if options.Backend == BackendBacktrack {
+		checks = append(checks, g.LeftRecursion)
+	} else if options.PEG {
+		return nil, fmt.Errorf("%s", ErrPEGBackend)
+	} else if options.Trivia != "" {
+		return nil, fmt.Errorf("%s", ErrTriviaBackend)
+	} else if len(options.Sync) > 0 {
+		return nil, fmt.Errorf("%s", ErrSyncBackend)
+	} else if options.Replay != nil {
+		return nil, fmt.Errorf("%s", ErrReplayBackend)
+	} else if g.hasKeywords() {
+		return nil, fmt.Errorf("%s", ErrKeywordsBackend)
+	} else if g.hasConstantTime() {
+		return nil, fmt.Errorf("%s", ErrConstTimeBackend)
+	}
+	for _, sync := range options.Sync {
+		if sync == "" {
+			return nil, fmt.Errorf("%s", ErrSyncEmpty)
+		}
+	}
+
+	limits := ruleLimits(g.rules, options.Limits)
+	if (options.Backend != BackendBacktrack) && ((len(limits) > 0) || (options.MaxDepth > 0) || (options.MaxSteps > 0) || (options.MaxRepetitions > 0)) {
+		return nil, fmt.Errorf("%s", ErrLimitsBackend)
+	}
+	if options.Backend == BackendBacktrack {
+		withDefault(&options.MaxDepth, DefaultMaxDepth)
+		withDefault(&options.MaxRepetitions, DefaultMaxRepetitions)
+	}
+	if options.NoCallbacks && ((len(options.Actions) > 0) || (len(options.Predicates) > 0)) {
+		return nil, fmt.Errorf("%s", ErrNoCallbacks)
+	}
+
+	if err := firstDiagnostic(checks...); err != nil {
+		return nil, err
+	}
+
+	if (options.Start == "") && (len(g.rules) > 0) {
+		options.Start = g.rules[0].name
+	}
+
+	rules := rulesByName(g.rules)
+	if _, defined := rules[options.Start]; !defined {
+		return nil, fmt.Errorf("%s: %s", ErrUndefinedStartRule, options.Start)
+	}
+	if _, defined := rules[options.Trivia]; (options.Trivia != "") && !defined {
+		return nil, fmt.Errorf("%s: %s", ErrUndefinedTrivia, options.Trivia)
+	}
+
+	memoized := map[string]bool{}
+	for name, rule := range rules {
+		if rule.Memoized(options.Memo) {
+			memoized[name] = true
+		}
+	}
+
+	var shared map[string]map[int]int
+	if (options.Backend == BackendBacktrack) && options.ShareSubexpressions {
+		shared = sharedAlternatives(g.rules, options.Predicates, limits)
+	}
+
+	var quickCheck *QuickCheck
+	if (options.Backend == BackendBacktrack) && !options.NoQuickCheck && (options.Trivia == "") {
+		sets := g.QuickCheck(true)
+		quickCheck = &sets
+	}
+
+	earley := newEarleyGrammar(g)
+	return &Engine{
+		grammar:      g,
+		rules:        rules,
+		options:      options,
+		earley:       earley,
+		memoized:     memoized,
+		shared:       shared,
+		limits:       limits,
+		separator:    regionSeparator(rules, options.Start),
+		syncRules:    syncRules(g.rules, options.Sync),
+		displayNames: displayNames(g.rules, options.DisplayNames),
+		keywords:     newKeywordTable(g, earley),
+		quickCheck:   quickCheck,
+	}, nil
+}
+
+// Parse reads all of the source, and returns the parse tree of the start rule matching all of it.
+// If the grammar is ambiguous, the first match found is returned.
+// Returns an error if the source cannot be read, a ParseError if it does not match, or a LimitError if a rule exceeds one
+// of its limits. If FullFidelity is true, a tree is also returned when the source does not match.
+// If there are Sync terminals, Diagnostics of each ParseError are returned instead of a ParseError, along with a tree if the source matched
+// after recovering from errors.
+func (e *Engine) Parse(source io.Reader) (Node, error) {
+	return e.ParseWithContext(OfParseContext(), source)
+}
+
+// ParseWithContext is the same as Parse, except that actions and predicates receive the given context
+func (e *Engine) ParseWithContext(ctx *ParseContext, source io.Reader) (Node, error) {
+	data, err := readInput(source, e.options.MaxInputSize)
+	if err != nil {
+		return Node{}, err
+	}
+
+	return e.parseBytes(ctx, data)
+}
+
+// ParseString is the same as Parse, except that it parses a string, without reading it from a reader
+func (e *Engine) ParseString(input string) (Node, error) {
+	return e.ParseStringWithContext(OfParseContext(), input)
+}
+
+// ParseStringWithContext is the same as ParseString, except that actions and predicates receive the given context
+func (e *Engine) ParseStringWithContext(ctx *ParseContext, input string) (Node, error) {
+	if err := checkInputSize(len(input), e.options.MaxInputSize); err != nil {
+		return Node{}, err
+	}
+
+	if e.options.Encoding == EncodingUTF8 {
+		return e.parseRunes(ctx, []rune(input))
+	}
+
+	return e.parseBytes(ctx, []byte(input))
+}
+
+// ParseBytes is the same as Parse, except that it parses bytes, without reading them from a reader
+func (e *Engine) ParseBytes(input []byte) (Node, error) {
+	return e.ParseBytesWithContext(OfParseContext(), input)
+}
+
+// ParseBytesWithContext is the same as ParseBytes, except that actions and predicates receive the given context
+func (e *Engine) ParseBytesWithContext(ctx *ParseContext, input []byte) (Node, error) {
+	if err := checkInputSize(len(input), e.options.MaxInputSize); err != nil {
+		return Node{}, err
+	}
+
+	return e.parseBytes(ctx, input)
+}
+
+// parseBytes decodes input in the encoding option, and parses it
+func (e *Engine) parseBytes(ctx *ParseContext, data []byte) (Node, error) {
+	data, err := e.options.Encoding.decode(data)
+	if err != nil {
+		return Node{}, err
+	}
+
+	return e.parseRunes(ctx, []rune(string(data)))
+}
+
+// parseRunes parses decoded input, as described by Parse
+func (e *Engine) parseRunes(ctx *ParseContext, input []rune) (Node, error) {
+	if (e.options.Replay != nil) && (ctx.replay == nil) {
+		log, node, err := e.parseReplay(ctx, input)
+		e.options.Replay.set(log)
+		return node, err
+	}
+
+	var (
+		runes   = input
+		failure parseFailure
+		errs    Diagnostics
+	)
+	mapped, err := e.mapInput(input)
+	if err != nil {
+		return Node{}, err
+	}
+	if mapped != nil {
+		runes = mapped.runes
+	}
+
+	node, matched, err := e.parseRegions(ctx, runes)
+	if (err == nil) && !matched {
+		if len(e.options.Sync) > 0 {
+			node, matched, failure, errs, err = e.recoverInput(ctx, runes)
+		} else {
+			node, matched, failure, err = e.matchInput(ctx, runes)
+		}
+	}
+	if err != nil {
+		return Node{}, err
+	}
+	errs = mapped.restoreErrors(input, errs, e.options.Positions)
+
+	if !matched {
+		err = mapped.restoreError(input, e.parseError(runes, failure), e.options.Positions)
+		if len(e.options.Sync) > 0 {
+			err = append(errs, err)
+		}
+		if e.options.FullFidelity {
+			node = mapped.restore(input, e.errorTree(runes, node))
+			node.index(newLineIndex(input, e.options.Positions))
+			return node, err
+		}
+
+		return Node{}, err
+	}
+	node = mapped.restore(input, node)
+	node.index(newLineIndex(input, e.options.Positions))
+	if len(errs) > 0 {
+		return node, errs
+	}
+	e.cover(node)
+	runActions(ctx, e.options.Actions, node)
+
+	return node, nil
+}
+
+// matchInput matches all of the input against the start rule with the backend, returning the same as backtracker.parse,
+// or a LimitError if a rule exceeds one of its limits
+func (e *Engine) matchInput(ctx *ParseContext, input []rune) (node Node, matched bool, failure parseFailure, err error) {
+	return e.matchRegion(ctx, input, 0, len(input))
+}
+
+// matchRegion matches the region of the input from start to end against the start rule as matchInput matches all of
+// the input, where the offsets of the matches and failure are relative to the start of the region, and the line and
+// position of a LimitError are those of the input
+func (e *Engine) matchRegion(ctx *ParseContext, input []rune, start, end int) (node Node, matched bool, failure parseFailure, err error) {
+	if e.options.Backend == BackendEarley {
+		node, matched, failure = e.earley.parse(ctx, e.options.Predicates, input[start:end], e.options.Start)
+		return node, matched, failure, nil
+	}
+
+	defer recoverLimit(&err)
+	b := newBacktracker(e, ctx, input[start:end])
+	b.whole, b.regionStart = input, start
+	if ctx.replay == nil {
+		b.quickCheck = e.quickCheck
+	}
+	node, matched, failure = b.parse()
+
+	return node, matched, failure, nil
+}
+
+// cover adds a successful parse to the coverage, if there is one
+func (e *Engine) cover(node Node) {
+	if e.options.Coverage != nil {
+		e.options.Coverage.add(node, e.options.Trivia)
+	}
+}
+
+// errorTree returns the tree of a full fidelity parse that failed, given the longest match of the start rule if there is one.
+// The input after the match is an error node.
+func (e *Engine) errorTree(input []rune, match Node) Node {
+	if match.rule == "" {
+		match = Node{rule: e.options.Start}
+	}
+
+	match.children = append(
+		append([]Node(nil), match.children...),
+		Node{text: string(input[match.end:]), start: match.end, end: len(input), invalid: true},
+	)
+	match.text = string(input)
+	match.end = len(input)
+
+	return match
+}
+
+// checks returns the checks of a grammar that every backend needs to pass
+func (g Grammar) checks() []func() []Diagnostic {
+	return []func() []Diagnostic{
+		g.DuplicateRules,
+		g.EmptyAlternatives,
+		g.Repetitions,
+		g.InvalidOptions,
+		g.UndefinedRules,
+	}
+}
+
+// firstDiagnostic runs checks in order, and returns the first diagnostic of the first check that has any, or nil
+func firstDiagnostic(checks ...func() []Diagnostic) error {
+	for _, check := range checks {
+		if diags := check(); len(diags) > 0 {
+			return diags[0]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,321 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// treeString returns a compact form of a parse tree, where a rule is name(children...) and a terminal is its quoted text
+func treeString(node Node) string {
+	if node.IsTerminal() {
+		return "'" + node.Text() + "'"
+	}
+
+	strs := make([]string, len(node.Children()))
+	for i, child := range node.Children() {
+		strs[i] = treeString(child)
+	}
+
+	return node.Rule() + "(" + strings.Join(strs, " ") + ")"
+}
+
+// testParse parses source with a new engine for a grammar, returning the tree string or the error string
+func testParse(t *testing.T, g Grammar, options ParseOptions, source string) string {
+	engine, err := NewEngine(g, options)
+	assert.Nil(t, err)
+
+	node, err := engine.Parse(strings.NewReader(source))
+	if err != nil {
+		return err.Error()
+	}
+
+	return treeString(node)
+}
+
+func TestEngineParse(t *testing.T) {
+	g := testGrammar(
+		"list = item rest",
+		"rest = (sep item)*",
+		"item = [abc] | 'x' 'y'",
+		"sep = ',' | ';;'",
+	)
+
+	for _, backend := range []Backend{BackendBacktrack, BackendEarley} {
+		options := ParseOptions{Backend: backend}
+
+		assert.Equal(t, "list(item('a') rest())", testParse(t, g, options, "a"))
+		assert.Equal(t, "list(item('a') rest(sep(';;') item('x' 'y') sep(',') item('b')))", testParse(t, g, options, "a;;xy,b"))
+		assert.Equal(t, ErrParseFailed+" at line 1 position 3: found 'b', expected ';;' in rule sep", testParse(t, g, options, "a;b"))
+		assert.Equal(t, "sep(';;')", testParse(t, g, ParseOptions{Backend: backend, Start: "sep"}, ";;"))
+	}
+
+	// A later item can only match if an earlier repetition gives up a match
+	g = testGrammar(
+		"a = xs b",
+		"xs = ([xy])*",
+		"b = 'y'",
+	)
+	for _, backend := range []Backend{BackendBacktrack, BackendEarley} {
+		assert.Equal(t, "a(xs('x' 'y') b('y'))", testParse(t, g, ParseOptions{Backend: backend}, "xyy"))
+	}
+
+	// Bounded repetitions
+	g = testGrammar("a = ('x'){2,3}")
+	for _, backend := range []Backend{BackendBacktrack, BackendEarley} {
+		options := ParseOptions{Backend: backend}
+		assert.Equal(t, "a('x' 'x')", testParse(t, g, options, "xx"))
+		assert.Equal(t, "a('x' 'x' 'x')", testParse(t, g, options, "xxx"))
+		assert.NotEqual(t, "a('x')", testParse(t, g, options, "x"))
+		assert.NotEqual(t, "a('x' 'x' 'x' 'x')", testParse(t, g, options, "xxxx"))
+	}
+}
+
+func TestNewEngine(t *testing.T) {
+	_, err := NewEngine(testGrammar("a = b"), ParseOptions{})
+	assert.Equal(t, DiagUndefinedRule, err.(Diagnostic).Code())
+
+	_, err = NewEngine(testGrammar("a = 'x'"), ParseOptions{Start: "b"})
+	assert.Equal(t, ErrUndefinedStartRule+": b", err.Error())
+
+	// Only the Earley backend can match left recursion, unless it is eliminated
+	g := testGrammar("a = a 'x' | 'y'")
+	_, err = NewEngine(g, ParseOptions{})
+	assert.Equal(t, DiagLeftRecursion, err.(Diagnostic).Code())
+
+	_, err = NewEngine(g, ParseOptions{Backend: BackendEarley})
+	assert.Nil(t, err)
+
+	engine, err := NewEngine(g, ParseOptions{EliminateLeftRecursion: true})
+	assert.Nil(t, err)
+	assert.True(t, engine.Supports(FeatureLeftRecursion))
+}
+
+func TestEngineEliminateLeftRecursion(t *testing.T) {
+	var (
+		g = testGrammar(
+			"expr = expr '+' term | expr '-' term | term",
+			"term = 'x' | 'y'",
+		)
+		options = ParseOptions{EliminateLeftRecursion: true}
+	)
+
+	assert.Equal(t, "expr(term('x') expr-tail())", testParse(t, g, options, "x"))
+	assert.Equal(
+		t,
+		"expr(term('x') expr-tail(expr-tail-item('+' term('y')) expr-tail-item('-' term('x'))))",
+		testParse(t, g, options, "x+y-x"),
+	)
+
+	// The Earley backend matches the grammar as it is
+	assert.Equal(t, "expr(expr(expr(term('x')) '+' term('y')) '-' term('x'))", testParse(t, g, ParseOptions{Backend: BackendEarley, EliminateLeftRecursion: true}, "x+y-x"))
+
+	// Left recursion that cannot be eliminated
+	_, err := NewEngine(testGrammar("a = a 'x'"), options)
+	assert.Equal(t, ErrLeftRecursionNoBase+": a", err.Error())
+}
+
+func TestLinePosition(t *testing.T) {
+	input := []rune("ab\ncd")
+
+	for offset, lp := range [][2]int{{1, 1}, {1, 2}, {1, 3}, {2, 1}, {2, 2}, {2, 3}} {
+		line, position := PositionOptions{}.linePosition(input, offset)
+		assert.Equal(t, lp, [2]int{line, position})
+	}
+}
+
+func TestEnginePEG(t *testing.T) {
+	var (
+		generative = ParseOptions{}
+		peg        = ParseOptions{PEG: true}
+	)
+
+	// The first alternative of b commits, so the rest of the input cannot match
+	g := testGrammar(
+		"a = b 'y'",
+		"b = 'x' | 'x' 'y'",
+	)
+	assert.Equal(t, "a(b('x' 'y') 'y')", testParse(t, g, generative, "xyy"))
+	assert.Equal(t, ErrParseFailed+" at line 1 position 3: found 'y', expected EOF", testParse(t, g, peg, "xyy"))
+	assert.Equal(t, "a(b('x') 'y')", testParse(t, g, peg, "xy"))
+
+	// A repetition does not give back matches
+	g = testGrammar(
+		"a = xs 'x'",
+		"xs = ('x')*",
+	)
+	assert.Equal(t, "a(xs('x') 'x')", testParse(t, g, generative, "xx"))
+	assert.Equal(t, ErrParseFailed+" at line 1 position 3: found EOF, expected 'x' in rule xs", testParse(t, g, peg, "xx"))
+
+	_, err := NewEngine(g, ParseOptions{Backend: BackendEarley, PEG: true})
+	assert.Equal(t, ErrPEGBackend, err.Error())
+}
+
+func TestEngineLazy(t *testing.T) {
+	var (
+		generative = ParseOptions{}
+		peg        = ParseOptions{PEG: true}
+		earley     = ParseOptions{Backend: BackendEarley}
+	)
+
+	// A lazy repetition matches as few times as it can, leaving the rest for a greedy one
+	g := testGrammar(
+		"pair = lazy greedy",
+		"lazy = ('a')*?",
+		"greedy = ('a')*",
+	)
+	assert.True(t, g.rules[1].expr.items[0].Lazy())
+	assert.Equal(t, "lazy = ('a')*?", g.rules[1].String())
+	for _, options := range []ParseOptions{generative, peg, earley} {
+		assert.Equal(t, "pair(lazy() greedy('a' 'a' 'a'))", testParse(t, g, options, "aaa"))
+	}
+
+	// Bounded repetitions begin with the lower bound
+	g = testGrammar(
+		"pair = lazy greedy",
+		"lazy = ('a'){1,3}?",
+		"greedy = ('a')*",
+	)
+	for _, options := range []ParseOptions{generative, peg, earley} {
+		assert.Equal(t, "pair(lazy('a') greedy('a' 'a'))", testParse(t, g, options, "aaa"))
+	}
+
+	// Everything up to the first closing delimiter
+	g = testGrammar(
+		"comments = (comment)+",
+		"comment = '<' body '>'",
+		"body = ([ab<>])*?",
+	)
+	assert.Equal(t, "comments(comment('<' body('a') '>') comment('<' body('b') '>'))", testParse(t, g, generative, "<a><b>"))
+
+	// An ordered choice never takes more repetitions later, so a lazy repetition only matches its lower bound
+	assert.Equal(t, "comments(comment('<' body() '>'))", testParse(t, g, peg, "<>"))
+	assert.Equal(t, ErrParseFailed+" at line 1 position 4: found EOF, expected [<>ab] in rule body", testParse(t, g, peg, "<a>"))
+}
+
+func TestEngineTrivia(t *testing.T) {
+	var (
+		g = testGrammar(
+			"list = item rest",
+			"rest = (',' item)*",
+			"item = 'a' | 'b'",
+			"ws = ('_')+ | '#' [xy]",
+		)
+		trivia   = ParseOptions{Trivia: "ws"}
+		fidelity = ParseOptions{Trivia: "ws", FullFidelity: true}
+		source   = "_a,#x_b_"
+	)
+
+	assert.Equal(t, "list(item('a') rest(',' item('b')))", testParse(t, g, trivia, source))
+	assert.Equal(
+		t,
+		"list(item(ws('_') 'a') rest(',' item(ws('#' 'x') ws('_') 'b')) ws('_'))",
+		testParse(t, g, fidelity, source),
+	)
+	assert.Equal(t, ErrParseFailed+" at line 1 position 5", testParse(t, g, trivia, "a,_#z"))
+
+	engine, _ := NewEngine(g, fidelity)
+	node, err := engine.Parse(strings.NewReader(source))
+	assert.Nil(t, err)
+	assert.Equal(t, source, node.Source())
+	assert.Equal(t, source, node.Text())
+
+	// Trivia is only skipped before terminals, and rule text includes it
+	engine, _ = NewEngine(g, trivia)
+	node, _ = engine.Parse(strings.NewReader(source))
+	assert.Equal(t, "a,b", node.Source())
+	assert.Equal(t, "_a", node.Children()[0].Text())
+
+	_, err = NewEngine(g, ParseOptions{Trivia: "space"})
+	assert.Equal(t, ErrUndefinedTrivia+": space", err.Error())
+
+	_, err = NewEngine(g, ParseOptions{Backend: BackendEarley, Trivia: "ws"})
+	assert.Equal(t, ErrTriviaBackend, err.Error())
+}
+
+func TestEngineFullFidelityError(t *testing.T) {
+	g := testGrammar(
+		"list = item rest",
+		"rest = (',' item)*",
+		"item = 'a' | 'b'",
+	)
+
+	engine, _ := NewEngine(g, ParseOptions{FullFidelity: true})
+	node, err := engine.Parse(strings.NewReader("a,c"))
+	assert.Equal(t, ErrParseFailed+" at line 1 position 3: found 'c', expected 'a' or 'b' in rule item", err.Error())
+	assert.Equal(t, `(list@0-3 (item@0-1 "a"@0-1) (rest@1-1) !",c"@1-3)`, node.SExpr(true))
+	assert.True(t, node.Children()[2].IsError())
+	assert.Equal(t, "a,c", node.Source())
+
+	// No match at all
+	node, _ = engine.Parse(strings.NewReader("c"))
+	assert.Equal(t, `(list !"c")`, node.SExpr(false))
+
+	engine, _ = NewEngine(g, ParseOptions{Backend: BackendEarley, FullFidelity: true})
+	node, err = engine.Parse(strings.NewReader("a,c"))
+	assert.Equal(t, ErrParseFailed+" at line 1 position 3: found 'c', expected 'a' or 'b' in rule item", err.Error())
+	assert.Equal(t, `(list !"a,c")`, node.SExpr(false))
+
+	// Without full fidelity there is no tree
+	engine, _ = NewEngine(g, ParseOptions{})
+	node, _ = engine.Parse(strings.NewReader("a,c"))
+	assert.Equal(t, Node{}, node)
+}
+
+func TestEngineParseString(t *testing.T) {
+	g := testGrammar(
+		"list = item rest",
+		"rest = (',' item)*",
+		"item = [abc]",
+	)
+
+	for _, options := range []ParseOptions{{}, {Encoding: EncodingDetect}, {MaxInputSize: 5}} {
+		engine, err := NewEngine(g, options)
+		assert.Nil(t, err)
+
+		node, err := engine.Parse(strings.NewReader("a,b,c"))
+		assert.Nil(t, err)
+		expected := treeString(node)
+
+		node, err = engine.ParseString("a,b,c")
+		assert.Nil(t, err)
+		assert.Equal(t, expected, treeString(node))
+
+		node, err = engine.ParseBytes([]byte("a,b,c"))
+		assert.Nil(t, err)
+		assert.Equal(t, expected, treeString(node))
+
+		_, err = engine.ParseString("a,b,")
+		assert.Equal(t, ErrParseFailed+" at line 1 position 5: found EOF, expected [a-c] in rule item", err.Error())
+	}
+
+	engine, err := NewEngine(g, ParseOptions{Encoding: EncodingUTF16LE, MaxInputSize: 4})
+	assert.Nil(t, err)
+
+	node, err := engine.ParseBytes([]byte("a\x00"))
+	assert.Nil(t, err)
+	assert.Equal(t, "list(item('a') rest())", treeString(node))
+
+	_, err = engine.ParseString("a\x00,\x00b\x00")
+	assert.Equal(t, ErrMaxInputSize+": 4", err.Error())
+	_, err = engine.ParseBytes([]byte("a\x00,\x00b\x00"))
+	assert.Equal(t, ErrMaxInputSize+": 4", err.Error())
+}
+
+func TestEngineAmbiguous(t *testing.T) {
+	// Every word can be split into shorter words, so the number of derivations doubles with each letter
+	g := testGrammar(
+		"s = (w)*",
+		"w = l ls",
+		"ls = (l)*",
+		"l = 'a' | 'b'",
+	)
+
+	// The first derivation is the one with the most repetitions of the outer rule
+	assert.Equal(t, "s(w(l('a') ls()) w(l('b') ls()))", testParse(t, g, ParseOptions{}, "ab"))
+
+	// Only the first derivation up to each offset is kept, instead of exponentially many
+	source := strings.Repeat("ab", 20)
+	assert.Equal(t, "s("+strings.Repeat("w(l('a') ls()) w(l('b') ls()) ", 19)+"w(l('a') ls()) w(l('b') ls()))", testParse(t, g, ParseOptions{Memo: true, MaxSteps: 50000}, source))
+}
@@ -0,0 +1,65 @@
+package parser
+
+// EOLRule is the name of the rule that matches the end of a line for the :EOL and :PREEOL options
+const EOLRule = "EOL"
+
+// EOLNewlines are the newlines that end a line
+type EOLNewlines uint
+
+// EOLNewlines constants
+const (
+	// EOLAny treats LF, CRLF, and CR as the same end of line
+	EOLAny EOLNewlines = iota
+	// EOLLF only ends a line with LF
+	EOLLF
+	// EOLCRLF only ends a line with CRLF
+	EOLCRLF
+)
+
+// EOLOptions are the options of matching the end of a line where the :EOL and :PREEOL options are
+type EOLOptions struct {
+	// Newlines are the newlines that end a line, EOLAny by default
+	Newlines EOLNewlines
+	// ImpliedAtEOF is true if input that does not end with a newline is matched as if it did, so that the last line
+	// need not end with one. The newline matches no text in the tree. ParseOne does not imply a newline.
+	ImpliedAtEOF bool
+}
+
+// newlines returns the newlines that end a line, in the order the EOL rule tries them
+func (o EOLOptions) newlines() []string {
+	switch o.Newlines {
+	case EOLLF:
+		return []string{"\n"}
+	case EOLCRLF:
+		return []string{"\r\n"}
+	default:
+		return []string{"\r\n", "\n", "\r"}
+	}
+}
+
+// token returns the option token of the end of a line, where each item with the :EOL option is followed by the EOL
+// rule, and each item with the :PREEOL option is preceded by it
+func (o EOLOptions) token() optionToken {
+	return optionToken{OptionEOL, OptionPreEOL, EOLRule, o.newlines()}
+}
+
+// impliedEOL returns the newline implied at the end of the input if ImpliedAtEOF is true and the input does not end
+// with a newline, which is CRLF if only CRLF ends a line and LF otherwise, or "" if no newline is implied.
+// Empty input has no last line, so no newline is implied.
+func (o *EOLOptions) impliedEOL(input []rune) string {
+	if (o == nil) || !o.ImpliedAtEOF || (len(input) == 0) {
+		return ""
+	}
+
+	for _, newline := range o.newlines() {
+		if offset := len(input) - len([]rune(newline)); (offset >= 0) && runesAt(input, offset, []rune(newline)) {
+			return ""
+		}
+	}
+
+	if o.Newlines == EOLCRLF {
+		return "\r\n"
+	}
+
+	return "\n"
+}
@@ -0,0 +1,102 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testEOLGrammar returns a grammar of lines of words, where each line must end with a newline
+func testEOLGrammar() Grammar {
+	g := testGrammar(
+		"lines = (line)+",
+		"line = word",
+		"word = ([abc])+",
+	)
+	g.rules[1].expr.items[0].list[0].options = []Option{OptionEOL}
+
+	return g
+}
+
+func TestEOLTokens(t *testing.T) {
+	g := testGrammar("block = head body", "head = 'a'", "body = 'b'")
+	g.rules[0].expr.items[0].list[0].options = []Option{OptionEOL, OptionIndent}
+	g.rules[0].expr.items[0].list[1].options = []Option{OptionPreEOL}
+
+	g = g.withOptionTokens(append(indentTokens(), EOLOptions{}.token()))
+	assert.Equal(t, "head:EOL:INDENT EOL INDENT EOL body:PREEOL", formatAlternative(g.rules[0].expr.items[0]))
+	assert.Equal(t, "EOL = '\\r\\n' | '\\n' | '\\r'", g.rules[len(g.rules)-1].String())
+
+	assert.Equal(t, []string{"\n"}, EOLOptions{Newlines: EOLLF}.newlines())
+	assert.Equal(t, []string{"\r\n"}, EOLOptions{Newlines: EOLCRLF}.newlines())
+}
+
+func TestEOL(t *testing.T) {
+	for _, test := range []struct {
+		options EOLOptions
+		input   string
+		valid   bool
+	}{
+		{EOLOptions{}, "a\nb\r\nc\r", true},
+		{EOLOptions{}, "a\nb", false},
+		{EOLOptions{}, "ab", false},
+		{EOLOptions{ImpliedAtEOF: true}, "a\nb", true},
+		{EOLOptions{ImpliedAtEOF: true}, "a\nb\n", true},
+		{EOLOptions{ImpliedAtEOF: true}, "", false},
+		{EOLOptions{Newlines: EOLLF}, "a\nb\n", true},
+		{EOLOptions{Newlines: EOLLF}, "a\r\nb\n", false},
+		{EOLOptions{Newlines: EOLCRLF}, "a\r\nb\r\n", true},
+		{EOLOptions{Newlines: EOLCRLF}, "a\r\nb\n", false},
+		{EOLOptions{Newlines: EOLCRLF, ImpliedAtEOF: true}, "a\r\nb", true},
+	} {
+		options := test.options
+		engine, err := NewEngine(testEOLGrammar(), ParseOptions{EOL: &options})
+		assert.Nil(t, err)
+
+		_, err = engine.Parse(strings.NewReader(test.input))
+		assert.Equal(t, test.valid, err == nil, "%+v %q", test.options, test.input)
+	}
+}
+
+func TestEOLImplied(t *testing.T) {
+	engine, err := NewEngine(testEOLGrammar(), ParseOptions{EOL: &EOLOptions{ImpliedAtEOF: true}})
+	assert.Nil(t, err)
+
+	// The implied newline matches no text
+	node, err := engine.Parse(strings.NewReader("a\nbc"))
+	assert.Nil(t, err)
+	assert.Equal(t, "a\nbc", node.Text())
+
+	line := node.Children()[1]
+	assert.Equal(t, "bc", line.Text())
+	eol := line.Children()[1]
+	assert.Equal(t, EOLRule, eol.Rule())
+	start, end := eol.Span()
+	assert.Equal(t, Position{Offset: 4, ByteOffset: 4, Line: 2, Column: 3}, start)
+	assert.Equal(t, start, end)
+
+	// The implied newline comes before the outdents at the end of the input
+	g := testIndentGrammar()
+	simple, block := g.rules[4].expr.items[0].list, g.rules[2].expr.items[0].list
+	simple[0].options = []Option{OptionEOL}
+	block[1].options = []Option{OptionEOL, OptionIndent}
+	g.rules[4].expr.items[0].list = simple[:1]
+	g.rules[2].expr.items[0].list = []ListItem{block[0], block[1], block[3]}
+
+	engine, err = NewEngine(g, ParseOptions{Trivia: "ws", Indent: &IndentOptions{}, EOL: &EOLOptions{ImpliedAtEOF: true}})
+	assert.Nil(t, err)
+	node, err = engine.Parse(strings.NewReader("a:\n  b:\n    c"))
+	assert.Nil(t, err)
+	assert.Equal(t, "a:\n  b:\n    c", node.Text())
+
+	// Forests map the input the same way
+	engine, err = NewEngine(testEOLGrammar(), ParseOptions{Backend: BackendEarley, EOL: &EOLOptions{ImpliedAtEOF: true}})
+	assert.Nil(t, err)
+	forest, err := engine.ParseForest(OfParseContext(), strings.NewReader("a\nbc"))
+	assert.Nil(t, err)
+	forest.Trees(func(tree Node) bool {
+		assert.Equal(t, "a\nbc", tree.Text())
+		return true
+	})
+}
@@ -0,0 +1,100 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Prefix of a doc comment line that is an error message of the rule or one of its alternatives
+const docErrorPrefix = "@error"
+
+// errorMessagePrefix returns the prefix of the doc comment line of the error message of an alternative starting at 1,
+// or of the rule if alt is 0
+func errorMessagePrefix(alt int) string {
+	if alt == 0 {
+		return docErrorPrefix + " "
+	}
+
+	return fmt.Sprintf("%s:%d ", docErrorPrefix, alt)
+}
+
+// ErrorMessage returns the error message for end users of an alternative starting at 1, or of the rule if alt is 0,
+// or "" if it has none. The message is the :ERROR option of the rule or alternative, such as
+//
+//	str:ERROR("a string must be closed with a quote") = '"' chars '"'
+//	    | '\\' escape :ERROR("an escape must be \\n, \\t, or \\\\");
+//
+// If it has no :ERROR option, which the other dialects do not have, the message of the rule is the rest of a doc
+// comment line that begins with @error and a space, and the message of an alternative is the rest of a line that
+// begins with @error, a colon, the alternative, and a space, such as
+//
+//	@error a string must be closed with a quote
+//	@error:2 an escape must be \n, \t, or \\
+func (r Rule) ErrorMessage(alt int) string {
+	switch {
+	case (alt == 0) && (r.errorMessage != ""):
+		return r.errorMessage
+	case (alt > 0) && (alt <= len(r.expr.items)) && (r.expr.items[alt-1].errorMessage != ""):
+		return r.expr.items[alt-1].errorMessage
+	}
+
+	message, _ := r.docLine(errorMessagePrefix(alt))
+	return message
+}
+
+// WithErrorMessage returns a copy of the rule with the :ERROR option of an alternative starting at 1, or of the rule
+// if alt is 0, which replaces its @error line of the doc comment. An empty message removes the message.
+// The rule is returned unchanged if it has no such alternative.
+func (r Rule) WithErrorMessage(alt int, message string) Rule {
+	switch {
+	case alt == 0:
+		r.errorMessage = message
+	case alt <= len(r.expr.items):
+		items := append([]ExpressionItem(nil), r.expr.items...)
+		items[alt-1].errorMessage = message
+		r.expr.items = items
+	default:
+		return r
+	}
+
+	return r.withDocLine(errorMessagePrefix(alt), "")
+}
+
+// withErrorMessageOrder returns a copy of the rule where the @error line of each alternative is renumbered for the
+// alternatives in the order of their indexes, as Grammar.withOrders reorders them, keeping the lines where they are.
+// The :ERROR options of the alternatives are reordered with them.
+func (r Rule) withErrorMessageOrder(order []int) Rule {
+	if r.doc == "" {
+		return r
+	}
+
+	lines := strings.Split(r.doc, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		for j, k := range order {
+			if prefix := errorMessagePrefix(k + 1); strings.HasPrefix(trimmed, prefix) {
+				lines[i] = errorMessagePrefix(j+1) + trimmed[len(prefix):]
+				break
+			}
+		}
+	}
+
+	r.doc = strings.Join(lines, "\n")
+	return r
+}
+
+// errorMessage returns the error message of the innermost rule being matched that has one, where the message of the
+// alternative is preferred, and the rule, or "" if none has one
+func (e *Engine) errorMessage(enclosing []activeRule) (string, activeRule) {
+	for i := len(enclosing) - 1; i >= 0; i-- {
+		rule := e.rules[enclosing[i].name]
+		if message := rule.ErrorMessage(enclosing[i].alt); (enclosing[i].alt > 0) && (message != "") {
+			return message, enclosing[i]
+		}
+		if message := rule.ErrorMessage(0); message != "" {
+			return message, enclosing[i]
+		}
+	}
+
+	return "", activeRule{}
+}
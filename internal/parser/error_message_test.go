@@ -0,0 +1,114 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuleErrorMessage(t *testing.T) {
+	rule := testGrammar("str = '\"' chars '\"' | '`' chars '`'").rules[0]
+	assert.Equal(t, "", rule.ErrorMessage(0))
+
+	rule = rule.WithDoc("A string").WithErrorMessage(0, "strings must be closed with a quote").WithErrorMessage(2, "raw strings must be closed with a backquote")
+	assert.Equal(t, "strings must be closed with a quote", rule.ErrorMessage(0))
+	assert.Equal(t, "", rule.ErrorMessage(1))
+	assert.Equal(t, "raw strings must be closed with a backquote", rule.ErrorMessage(2))
+	assert.Equal(t, "", rule.ErrorMessage(3))
+	assert.Equal(t, "A string", rule.Doc())
+	assert.Equal(
+		t,
+		"// A string\n"+
+			`str:ERROR("strings must be closed with a quote") = '"' chars '"'`+"\n"+
+			"                                                 | '`' chars '`' :ERROR(\"raw strings must be closed with a backquote\");",
+		rule.Format(),
+	)
+
+	rule = rule.WithErrorMessage(0, "")
+	assert.Equal(t, "", rule.ErrorMessage(0))
+	assert.Equal(t, "raw strings must be closed with a backquote", rule.ErrorMessage(2))
+	assert.Equal(t, rule, rule.WithErrorMessage(3, "no such alternative"))
+
+	// The alternative being changed is copied
+	changed := rule.WithErrorMessage(2, "changed")
+	assert.Equal(t, "changed", changed.ErrorMessage(2))
+	assert.Equal(t, "raw strings must be closed with a backquote", rule.ErrorMessage(2))
+
+	// @error lines of the doc are messages of rules and alternatives without :ERROR options, which replace them
+	rule = rule.WithDoc("A string\n@error strings must be closed with a quote\n@error:1 strings end with a quote\n@error:2 unused")
+	assert.Equal(t, "strings must be closed with a quote", rule.ErrorMessage(0))
+	assert.Equal(t, "strings end with a quote", rule.ErrorMessage(1))
+	assert.Equal(t, "raw strings must be closed with a backquote", rule.ErrorMessage(2))
+	assert.Equal(t, "A string", rule.RuleDoc().Doc)
+
+	rule = rule.WithErrorMessage(1, "a string ends with a quote")
+	assert.Equal(t, "a string ends with a quote", rule.ErrorMessage(1))
+	assert.Equal(t, "A string\n@error strings must be closed with a quote\n@error:2 unused", rule.Doc())
+}
+
+func TestRuleErrorMessageNotation(t *testing.T) {
+	source := `str:MEMO:ERROR("strings must be closed with a \"quote\"") = '"' chars '"' :ERROR('a string ends with "')
+    | ('\\' chars)+ :ERROR("escapes");`
+	g, err := CompileString(source, CompileOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, `strings must be closed with a "quote"`, g.rules[0].ErrorMessage(0))
+	assert.Equal(t, `a string ends with "`, g.rules[0].ErrorMessage(1))
+	assert.Equal(t, "escapes", g.rules[0].ErrorMessage(2))
+	assert.Equal(t, []Option{OptionMemo}, g.rules[0].Options())
+	assert.Equal(
+		t,
+		`str:MEMO:ERROR("strings must be closed with a \"quote\"") = '"' chars '"' :ERROR("a string ends with \"")`+"\n"+
+			`                                                          | ('\\' chars)+ :ERROR("escapes");`+"\n",
+		g.Format(),
+	)
+
+	formatted, err := CompileString(g.Format(), CompileOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, g.Format(), formatted.Format())
+
+	for source, msg := range map[string]string{
+		`a:ERROR = 'x';`:          fmt.Sprintf("%s expected '(', found '=' at line 1 position 9", ErrGoparseSyntax),
+		`a:ERROR(x) = 'x';`:       fmt.Sprintf("%s expected a string after :ERROR( at line 1 position 2", ErrGoparseSyntax),
+		`a:ERROR("x" = 'x';`:      fmt.Sprintf("%s expected ')', found '=' at line 1 position 13", ErrGoparseSyntax),
+		`a = 'x':ERROR("x"):EOL;`: fmt.Sprintf("%s expected ';', found ':EOL' at line 1 position 19", ErrGoparseSyntax),
+	} {
+		_, err := CompileString(source, CompileOptions{})
+		assert.Equal(t, msg, err.Error(), source)
+	}
+}
+
+func TestParseErrorMessage(t *testing.T) {
+	g := testGrammar(
+		"value = str | chars",
+		"str = '\"' chars '\"' | '`' chars '`'",
+		"chars = ([abc])*",
+	)
+	g.rules[1] = g.rules[1].WithErrorMessage(0, "strings must be closed with a quote").WithErrorMessage(2, "raw strings must be closed with a backquote")
+
+	for _, backend := range []Backend{BackendBacktrack, BackendEarley} {
+		engine, err := NewEngine(g, ParseOptions{Backend: backend})
+		assert.Nil(t, err)
+
+		// The message of the alternative is preferred
+		_, err = engine.Parse(strings.NewReader("`ab"))
+		parseError := err.(ParseError)
+		assert.Equal(t, "str", parseError.Rule())
+		assert.Equal(t, 2, parseError.Alt())
+		assert.Equal(t, "raw strings must be closed with a backquote", parseError.Message())
+		assert.Equal(t, fmt.Sprintf("%s at line 1 position 4: raw strings must be closed with a backquote in rule str", ErrParseFailed), err.Error())
+
+		// The message of the rule is used if the alternative has none
+		_, err = engine.Parse(strings.NewReader("\"ab"))
+		parseError = err.(ParseError)
+		assert.Equal(t, 1, parseError.Alt())
+		assert.Equal(t, "strings must be closed with a quote", parseError.Message())
+
+		// Rules without messages expect terminals
+		_, err = engine.Parse(strings.NewReader("ab!"))
+		parseError = err.(ParseError)
+		assert.Equal(t, "", parseError.Message())
+		assert.Contains(t, err.Error(), "expected")
+	}
+}
@@ -0,0 +1,347 @@
+package parser
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Event error message constants
+const (
+	ErrEventsEmptyGrammar = "Cannot parse events with a grammar that has no rules"
+)
+
+// EventHandler receives the events of a parse as each rule and terminal is matched.
+// Lines and positions begin at 1.
+type EventHandler interface {
+	// EnterRule is called before a rule is matched, with the line and position of the first rune the rule can match
+	EnterRule(name string, line, position int)
+	// ExitRule is called after a rule is matched, with the line and position of the rune after the match
+	ExitRule(name string, line, position int)
+	// Terminal is called after a terminal is matched, with the text matched and the line and position of its first rune
+	Terminal(text string, line, position int)
+}
+
+// eventFrame is a rule being matched by an eventParser
+type eventFrame struct {
+	rule string
+	// index of the alternative chosen
+	alt int
+	// number of repetitions of the alternative that have begun
+	count int
+	// index of the next list item of the current repetition
+	item int
+	// line and position where the current repetition began
+	line     int
+	position int
+}
+
+// eventParser is a predictive parser that decides which alternative to match with one rune of lookahead.
+// Rules being matched are kept on a stack rather than by recursion, so that the state can be saved in a checkpoint.
+type eventParser struct {
+	byName  map[string]Rule
+	firsts  map[string]ruleFirstSets
+	handler EventHandler
+	source  *bufio.Reader
+	stack   []eventFrame
+	// next rune, -1 for EOF, and its size in bytes
+	next     rune
+	nextSize int
+	// line and position of next rune
+	line     int
+	position int
+	// bytes and runes before the next rune
+	offset int64
+	runes  int64
+	// timeouts of reading the source
+	timeouts EventTimeoutOptions
+	// true while reading the rest of a terminal, and when its first rune was matched
+	partial      bool
+	partialStart time.Time
+	// number of reads in a row that timed out
+	timedOut int
+}
+
+// ParseEvents matches all of the source against the first rule, calling the handler as each rule and terminal is matched
+// instead of building a parse tree. The parser waits for each call to return, and a StoppableEventHandler can stop the parse.
+// Only one rune of input is read ahead, so memory use does not grow with the size of the input,
+// except for the text of each terminal and the nesting of rules.
+//
+// The grammar must be LL(1), as described by LL1Conflicts, so that no backtracking is needed.
+// Events that have been sent for input that turns out not to match cannot be retracted, so the handler should discard
+// any results if an error is returned.
+//
+// Returns an error if the grammar is invalid or not LL(1), ErrConstTimeBackend if it uses :CONSTTIME, an error if the source
+// cannot be read, or an error if the source does not match.
+func (g Grammar) ParseEvents(source io.Reader, handler EventHandler) error {
+	return g.ParseEventsWithCheckpoints(source, handler, EventCheckpointOptions{})
+}
+
+// ParseEventsWithCheckpoints is the same as ParseEvents, except that it can save checkpoints of the parse as it goes,
+// and resume from a saved checkpoint, so that a huge input can be parsed across restarts of a process.
+func (g Grammar) ParseEventsWithCheckpoints(source io.Reader, handler EventHandler, options EventCheckpointOptions) error {
+	return g.ParseEventsWithOptions(source, handler, EventOptions{Checkpoint: options})
+}
+
+// EventOptions are the options of ParseEventsWithOptions
+type EventOptions struct {
+	// Checkpoint are the options for saving and resuming checkpoints
+	Checkpoint EventCheckpointOptions
+	// Timeout are the options for reading from a network connection
+	Timeout EventTimeoutOptions
+}
+
+// ParseEventsWithOptions is the same as ParseEvents, except that it can save and resume checkpoints,
+// and limit the time spent waiting for input
+func (g Grammar) ParseEventsWithOptions(source io.Reader, handler EventHandler, options EventOptions) error {
+	if len(g.rules) == 0 {
+		return fmt.Errorf("%s", ErrEventsEmptyGrammar)
+	}
+	if g.hasConstantTime() {
+		return fmt.Errorf("%s", ErrConstTimeBackend)
+	}
+
+	if err := firstDiagnostic(
+		g.DuplicateRules,
+		g.EmptyAlternatives,
+		g.Repetitions,
+		g.InvalidOptions,
+		g.UndefinedRules,
+		g.LeftRecursion,
+		g.LL1Conflicts,
+	); err != nil {
+		return err
+	}
+
+	checkpoint := options.Checkpoint
+	if (checkpoint.Interval > 0) && (checkpoint.Save == nil) {
+		return fmt.Errorf("%s", ErrEventsCheckpointSave)
+	}
+
+	if deadliner, isa := source.(readDeadliner); isa && (options.Timeout.ReadTimeout > 0) {
+		source = deadlineReader{source: source, deadliner: deadliner, timeout: options.Timeout.ReadTimeout}
+		defer deadliner.SetReadDeadline(time.Time{})
+	}
+
+	p := &eventParser{
+		byName:   rulesByName(g.rules),
+		firsts:   firstSets(g),
+		handler:  handler,
+		source:   bufio.NewReader(source),
+		line:     1,
+		position: 1,
+		timeouts: options.Timeout,
+	}
+	withDefault(&p.timeouts.MaxTimeouts, DefaultMaxTimeouts)
+	if checkpoint.Resume != nil {
+		if err := p.resume(*checkpoint.Resume); err != nil {
+			return err
+		}
+	}
+	if err := p.read(); err != nil {
+		return err
+	}
+
+	if checkpoint.Resume == nil {
+		if err := p.enterRule(g.rules[0].name); err != nil {
+			return err
+		}
+	}
+
+	for lastCheckpoint := p.runes; len(p.stack) > 0; {
+		if err := p.stopped(); err != nil {
+			return err
+		}
+
+		if (checkpoint.Interval > 0) && (p.runes-lastCheckpoint >= int64(checkpoint.Interval)) {
+			lastCheckpoint = p.runes
+			if err := checkpoint.Save(p.checkpoint()); err != nil {
+				return err
+			}
+		}
+
+		if err := p.step(); err != nil {
+			return err
+		}
+	}
+
+	if err := p.stopped(); err != nil {
+		return err
+	}
+
+	if p.next >= 0 {
+		return p.fail()
+	}
+
+	return nil
+}
+
+// stopped returns the error of a StoppableEventHandler, if any
+func (p *eventParser) stopped() error {
+	if stoppable, isa := p.handler.(StoppableEventHandler); isa {
+		return stoppable.Err()
+	}
+
+	return nil
+}
+
+// read reads the next rune, retrying a read that timed out in the middle of a terminal if the timeout policy is to wait
+func (p *eventParser) read() error {
+	for {
+		char, size, err := p.source.ReadRune()
+		switch {
+		case err == io.EOF:
+			p.next, p.nextSize = -1, 0
+			return nil
+		case isTimeout(err) && p.partial:
+			if (p.timeouts.PartialTerminal == TimeoutWait) && !p.waitTimedOut() {
+				continue
+			}
+			return p.timeoutError(ErrEventsTerminalTimeout)
+		case isTimeout(err):
+			return p.timeoutError(ErrEventsReadTimeout)
+		case err != nil:
+			return err
+		}
+
+		if p.partial && p.terminalTimedOut() {
+			return p.timeoutError(ErrEventsTerminalTimeout)
+		}
+
+		p.next, p.nextSize, p.timedOut = char, size, 0
+		return nil
+	}
+}
+
+// advance moves past the next rune, and reads the rune after it
+func (p *eventParser) advance() error {
+	if p.next == '\n' {
+		p.line++
+		p.position = 1
+	} else {
+		p.position++
+	}
+	p.offset += int64(p.nextSize)
+	p.runes++
+
+	return p.read()
+}
+
+// fail returns an error at the next rune
+func (p *eventParser) fail() error {
+	return fmt.Errorf("%s at line %d position %d", ErrParseFailed, p.line, p.position)
+}
+
+// enterRule pushes the alternative of a rule chosen by the next rune
+func (p *eventParser) enterRule(name string) error {
+	var (
+		alts   = p.firsts[name].alternatives
+		chosen = -1
+	)
+
+	// An alternative that can begin with the next rune is preferred over one that can match nothing
+	for i := range alts {
+		if alts[i].chars.Contains(p.next) {
+			chosen = i
+			break
+		}
+
+		if alts[i].nullable && (chosen < 0) {
+			chosen = i
+		}
+	}
+
+	if chosen < 0 {
+		return p.fail()
+	}
+
+	p.handler.EnterRule(name, p.line, p.position)
+	p.stack = append(p.stack, eventFrame{rule: name, alt: chosen})
+
+	return nil
+}
+
+// step matches the next list item of the rule on top of the stack, or ends a repetition, or exits the rule
+func (p *eventParser) step() error {
+	var (
+		frame = &p.stack[len(p.stack)-1]
+		alt   = p.byName[frame.rule].expr.items[frame.alt]
+	)
+
+	if frame.item == 0 {
+		// Repetitions beyond the minimum are only matched if the next rune can begin one
+		if ((alt.m != -1) && (frame.count >= alt.m)) ||
+			((frame.count >= alt.n) && !p.firsts[frame.rule].alternatives[frame.alt].chars.Contains(p.next)) {
+			p.exitRule()
+			return nil
+		}
+
+		frame.line, frame.position = p.line, p.position
+		frame.count++
+	}
+
+	if frame.item == len(alt.list) {
+		// A repetition beyond the minimum must consume something, or it could be repeated forever
+		frame.item = 0
+		if (frame.count > alt.n) && (frame.line == p.line) && (frame.position == p.position) {
+			p.exitRule()
+		}
+		return nil
+	}
+
+	item := alt.list[frame.item]
+	frame.item++
+
+	if item.IsRuleName() {
+		return p.enterRule(item.ruleName)
+	}
+
+	return p.matchTerminal(item.terminal)
+}
+
+// exitRule pops the rule on top of the stack
+func (p *eventParser) exitRule() {
+	p.handler.ExitRule(p.stack[len(p.stack)-1].rule, p.line, p.position)
+	p.stack = p.stack[:len(p.stack)-1]
+}
+
+// matchTerminal matches a terminal at the next rune
+func (p *eventParser) matchTerminal(terminal Terminal) error {
+	var (
+		line, position = p.line, p.position
+		text           strings.Builder
+	)
+
+	if terminal.IsString() {
+		// The runes read after the first rune and before the last rune are the rest of the terminal
+		chars := []rune(terminal.theString)
+		p.partialStart = eventNow()
+		defer func() { p.partial = false }()
+
+		for i, char := range chars {
+			if p.next != char {
+				return p.fail()
+			}
+
+			text.WriteRune(char)
+			p.partial = i < len(chars)-1
+			if err := p.advance(); err != nil {
+				return err
+			}
+		}
+	} else {
+		if (p.next < 0) || !terminal.theRange.Contains(p.next) {
+			return p.fail()
+		}
+
+		text.WriteRune(p.next)
+		if err := p.advance(); err != nil {
+			return err
+		}
+	}
+
+	p.handler.Terminal(text.String(), line, position)
+	return nil
+}
@@ -0,0 +1,86 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+)
+
+// Event channel error message constants
+const (
+	ErrEventsCanceled = "The event parse was canceled"
+)
+
+// StoppableEventHandler is an EventHandler that can stop the parse, such as when the consumer of its events has gone away.
+// Err is called after each event is sent, and the parse stops with the error it returns, if any.
+type StoppableEventHandler interface {
+	EventHandler
+	Err() error
+}
+
+// EventKind is the kind of an Event
+type EventKind uint
+
+// EventKind constants
+const (
+	EventEnterRule EventKind = iota
+	EventExitRule
+	EventTerminal
+)
+
+// Event is an event sent by ParseEventsToChannel, which is a call of an EventHandler method
+type Event struct {
+	Kind EventKind
+	// Text is the rule name, or the text of the terminal
+	Text     string
+	Line     int
+	Position int
+}
+
+// channelHandler sends events to a channel, until done is closed
+type channelHandler struct {
+	events chan<- Event
+	done   <-chan struct{}
+	err    error
+}
+
+// send sends an event, blocking until the channel accepts it or done is closed
+func (h *channelHandler) send(event Event) {
+	if h.err != nil {
+		return
+	}
+
+	select {
+	case h.events <- event:
+	case <-h.done:
+		h.err = fmt.Errorf("%s", ErrEventsCanceled)
+	}
+}
+
+func (h *channelHandler) EnterRule(name string, line, position int) {
+	h.send(Event{Kind: EventEnterRule, Text: name, Line: line, Position: position})
+}
+
+func (h *channelHandler) ExitRule(name string, line, position int) {
+	h.send(Event{Kind: EventExitRule, Text: name, Line: line, Position: position})
+}
+
+func (h *channelHandler) Terminal(text string, line, position int) {
+	h.send(Event{Kind: EventTerminal, Text: text, Line: line, Position: position})
+}
+
+func (h *channelHandler) Err() error {
+	return h.err
+}
+
+// ParseEventsToChannel is the same as ParseEventsWithOptions, except that events are sent to a channel, which is closed
+// when the parse ends. It is meant to be called in its own goroutine, while the consumer receives from the channel.
+//
+// Each send blocks until the channel accepts the event, so the parser reads no further input while the consumer is behind,
+// and the only events buffered are those of the channel capacity. An unbuffered channel hands over each event as it occurs.
+// If done is closed, a blocked send is abandoned, and ErrEventsCanceled is returned, so that a consumer that stops
+// receiving does not leave the parser blocked forever. Done may be nil if the consumer always receives every event.
+func (g Grammar) ParseEventsToChannel(source io.Reader, events chan<- Event, done <-chan struct{}, options EventOptions) error {
+	defer close(events)
+
+	return g.ParseEventsWithOptions(source, &channelHandler{events: events, done: done}, options)
+}
@@ -0,0 +1,66 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stoppingHandler records events, and stops the parse after a number of them
+type stoppingHandler struct {
+	recordingHandler
+	limit int
+}
+
+func (h *stoppingHandler) Err() error {
+	if len(h.events) >= h.limit {
+		return fmt.Errorf("stopped")
+	}
+
+	return nil
+}
+
+func TestParseEventsToChannel(t *testing.T) {
+	var (
+		g = testGrammar(
+			"list = item rest",
+			"rest = (',' item)*",
+			"item = [ab]",
+		)
+		events = make(chan Event)
+		result = make(chan error, 1)
+		got    []Event
+	)
+
+	// Every event is received from an unbuffered channel, which is closed at the end
+	go func() { result <- g.ParseEventsToChannel(strings.NewReader("a,b"), events, nil, EventOptions{}) }()
+	for event := range events {
+		got = append(got, event)
+	}
+	assert.Nil(t, <-result)
+	assert.Equal(t, 11, len(got))
+	assert.Equal(t, Event{Kind: EventEnterRule, Text: "list", Line: 1, Position: 1}, got[0])
+	assert.Equal(t, Event{Kind: EventTerminal, Text: ",", Line: 1, Position: 2}, got[5])
+	assert.Equal(t, Event{Kind: EventExitRule, Text: "list", Line: 1, Position: 4}, got[10])
+
+	// A consumer that stops receiving cancels the parse, which is blocked sending the second event
+	var (
+		bounded = make(chan Event, 1)
+		done    = make(chan struct{})
+	)
+	go func() { result <- g.ParseEventsToChannel(strings.NewReader("a,b"), bounded, done, EventOptions{}) }()
+	assert.Equal(t, "list", (<-bounded).Text)
+	close(done)
+	assert.Equal(t, ErrEventsCanceled, (<-result).Error())
+	_, open := <-bounded
+	for open {
+		_, open = <-bounded
+	}
+
+	// A handler can stop the parse
+	handler := &stoppingHandler{limit: 3}
+	assert.Equal(t, "stopped", g.ParseEvents(strings.NewReader("a,b"), handler).Error())
+	assert.Equal(t, []string{"enter list 1:1", "enter item 1:1", `"a" 1:1`}, handler.events)
+}
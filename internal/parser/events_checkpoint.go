@@ -0,0 +1,131 @@
+package parser
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// Event checkpoint error message constants
+const (
+	ErrEventsCheckpoint      = "Invalid event parser checkpoint"
+	ErrEventsCheckpointSave  = "A checkpoint interval requires a Save function"
+	ErrEventsCheckpointRules = "The checkpoint does not match the grammar"
+)
+
+// The first line of a written checkpoint
+const (
+	eventsCheckpointHeader  = "goparse-events"
+	eventsCheckpointVersion = 1
+)
+
+// EventCheckpointOptions are the checkpoint options of ParseEventsWithCheckpoints
+type EventCheckpointOptions struct {
+	// Resume is a checkpoint to resume parsing from, or nil to begin at the start of the source.
+	// When resuming, the source must begin at the Offset of the checkpoint, and events are only sent for input after it.
+	Resume *EventCheckpoint
+	// Interval is the number of runes of input between checkpoints, where 0 saves no checkpoints
+	Interval int
+	// Save is called with each checkpoint, and parsing stops with the error it returns, if any
+	Save func(EventCheckpoint) error
+}
+
+// EventCheckpoint is the state of ParseEvents between two runes of input: the amount of input matched, the line and
+// position of the next rune, and the stack of rules being matched. As the parser is predictive, there is no memo to save.
+type EventCheckpoint struct {
+	// Offset is the number of bytes of the source that were matched, where a resumed parse must begin reading
+	Offset int64
+	// Runes is the number of runes of the source that were matched
+	Runes    int64
+	line     int
+	position int
+	stack    []eventFrame
+}
+
+// checkpoint returns the current state
+func (p *eventParser) checkpoint() EventCheckpoint {
+	return EventCheckpoint{
+		Offset:   p.offset,
+		Runes:    p.runes,
+		line:     p.line,
+		position: p.position,
+		stack:    append([]eventFrame(nil), p.stack...),
+	}
+}
+
+// resume restores the state of a checkpoint, returning an error if its rules and alternatives are not in the grammar
+func (p *eventParser) resume(c EventCheckpoint) error {
+	if len(c.stack) == 0 {
+		return fmt.Errorf("%s: no rules", ErrEventsCheckpointRules)
+	}
+
+	for _, frame := range c.stack {
+		rule, haveIt := p.byName[frame.rule]
+		if !haveIt || (frame.alt < 0) || (frame.alt >= len(rule.expr.items)) ||
+			(frame.item < 0) || (frame.item > len(rule.expr.items[frame.alt].list)) {
+			return fmt.Errorf("%s: %s", ErrEventsCheckpointRules, frame.rule)
+		}
+	}
+
+	p.offset, p.runes, p.line, p.position = c.Offset, c.Runes, c.line, c.position
+	p.stack = append([]eventFrame(nil), c.stack...)
+
+	return nil
+}
+
+// WriteTo is the io.WriterTo interface, which writes the checkpoint as lines of text that ReadEventCheckpoint reads
+func (c EventCheckpoint) WriteTo(w io.Writer) (int64, error) {
+	var (
+		total int64
+		write = func(format string, args ...interface{}) error {
+			n, err := fmt.Fprintf(w, format, args...)
+			total += int64(n)
+			return err
+		}
+	)
+
+	if err := write("%s %d\n%d %d %d %d %d\n", eventsCheckpointHeader, eventsCheckpointVersion,
+		c.Offset, c.Runes, c.line, c.position, len(c.stack)); err != nil {
+		return total, err
+	}
+
+	for _, frame := range c.stack {
+		if err := write("%s %d %d %d %d %d\n", frame.rule, frame.alt, frame.count, frame.item, frame.line, frame.position); err != nil {
+			return total, err
+		}
+	}
+
+	return total, nil
+}
+
+// ReadEventCheckpoint reads a checkpoint written by EventCheckpoint.WriteTo
+func ReadEventCheckpoint(r io.Reader) (EventCheckpoint, error) {
+	var (
+		source  = bufio.NewReader(r)
+		header  string
+		version int
+		c       EventCheckpoint
+		frames  int
+	)
+
+	if _, err := fmt.Fscan(source, &header, &version); (err != nil) || (header != eventsCheckpointHeader) {
+		return EventCheckpoint{}, fmt.Errorf("%s: header", ErrEventsCheckpoint)
+	}
+	if version != eventsCheckpointVersion {
+		return EventCheckpoint{}, fmt.Errorf("%s: version %d", ErrEventsCheckpoint, version)
+	}
+
+	if _, err := fmt.Fscan(source, &c.Offset, &c.Runes, &c.line, &c.position, &frames); (err != nil) || (frames < 0) {
+		return EventCheckpoint{}, fmt.Errorf("%s: position", ErrEventsCheckpoint)
+	}
+
+	for i := 0; i < frames; i++ {
+		var frame eventFrame
+		if _, err := fmt.Fscan(source, &frame.rule, &frame.alt, &frame.count, &frame.item, &frame.line, &frame.position); err != nil {
+			return EventCheckpoint{}, fmt.Errorf("%s: rule", ErrEventsCheckpoint)
+		}
+		c.stack = append(c.stack, frame)
+	}
+
+	return c, nil
+}
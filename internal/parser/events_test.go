@@ -0,0 +1,147 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingHandler records events as strings
+type recordingHandler struct {
+	events []string
+}
+
+func (h *recordingHandler) EnterRule(name string, line, position int) {
+	h.events = append(h.events, fmt.Sprintf("enter %s %d:%d", name, line, position))
+}
+
+func (h *recordingHandler) ExitRule(name string, line, position int) {
+	h.events = append(h.events, fmt.Sprintf("exit %s %d:%d", name, line, position))
+}
+
+func (h *recordingHandler) Terminal(text string, line, position int) {
+	h.events = append(h.events, fmt.Sprintf("%q %d:%d", text, line, position))
+}
+
+func TestParseEvents(t *testing.T) {
+	var (
+		g = testGrammar(
+			"list = item rest",
+			"rest = (',' item)*",
+			"item = [ab] | 'xy' | '(' list ')'",
+		)
+		handler = &recordingHandler{}
+	)
+
+	assert.Nil(t, g.ParseEvents(strings.NewReader("a,xy"), handler))
+	assert.Equal(
+		t,
+		[]string{
+			"enter list 1:1",
+			"enter item 1:1",
+			`"a" 1:1`,
+			"exit item 1:2",
+			"enter rest 1:2",
+			`"," 1:2`,
+			"enter item 1:3",
+			`"xy" 1:3`,
+			"exit item 1:5",
+			"exit rest 1:5",
+			"exit list 1:5",
+		},
+		handler.events,
+	)
+
+	handler = &recordingHandler{}
+	assert.Nil(t, g.ParseEvents(strings.NewReader("(b)"), handler))
+	assert.Equal(t, "enter list 1:2", handler.events[3])
+
+	assert.Equal(t, ErrParseFailed+" at line 1 position 3", g.ParseEvents(strings.NewReader("a,c"), &recordingHandler{}).Error())
+	assert.Equal(t, ErrParseFailed+" at line 1 position 2", g.ParseEvents(strings.NewReader("ab"), &recordingHandler{}).Error())
+	assert.Equal(t, ErrParseFailed+" at line 1 position 2", g.ParseEvents(strings.NewReader("xz"), &recordingHandler{}).Error())
+	assert.Equal(t, ErrParseFailed+" at line 1 position 1", g.ParseEvents(strings.NewReader(""), &recordingHandler{}).Error())
+
+	// Bounded repetition
+	g = testGrammar("a = ('x'){1,2}")
+	assert.Nil(t, g.ParseEvents(strings.NewReader("xx"), &recordingHandler{}))
+	assert.Equal(t, ErrParseFailed+" at line 1 position 3", g.ParseEvents(strings.NewReader("xxx"), &recordingHandler{}).Error())
+
+	// Grammars that need backtracking cannot be used
+	err := testGrammar("a = 'x' 'y' | 'x' 'z'").ParseEvents(strings.NewReader("xz"), &recordingHandler{})
+	assert.Equal(t, DiagLL1Conflict, err.(Diagnostic).Code())
+
+	assert.Equal(t, ErrEventsEmptyGrammar, OfGrammar("", nil).ParseEvents(strings.NewReader(""), &recordingHandler{}).Error())
+}
+
+func TestParseEventsCheckpoint(t *testing.T) {
+	var (
+		g = testGrammar(
+			"list = item rest",
+			"rest = (',' item)*",
+			"item = [ab] | 'xy' | '(' list ')'",
+		)
+		source      = "a,(é,xy),b"
+		full        = &recordingHandler{}
+		checkpoints []string
+		save        = func(c EventCheckpoint) error {
+			var buf strings.Builder
+			_, err := c.WriteTo(&buf)
+			checkpoints = append(checkpoints, buf.String())
+			return err
+		}
+	)
+	terminal := &g.rules[2].expr.items[0].list[0].terminal
+	terminal.theRange = terminal.theRange.Union(OfRuneSetRunes('é'))
+
+	assert.Nil(t, g.ParseEventsWithCheckpoints(strings.NewReader(source), full, EventCheckpointOptions{Interval: 3, Save: save}))
+	assert.Equal(t, 3, len(checkpoints))
+	assert.Equal(t, "goparse-events 1\n3 3 1 4 3\nlist 0 1 2 1 1\nrest 0 1 2 1 2\nitem 2 1 1 1 3\n", checkpoints[0])
+
+	// Resuming from each checkpoint sends the same events as the rest of the full parse
+	for _, text := range checkpoints {
+		checkpoint, err := ReadEventCheckpoint(strings.NewReader(text))
+		assert.Nil(t, err)
+
+		resumed := &recordingHandler{}
+		assert.Nil(t, g.ParseEventsWithCheckpoints(
+			strings.NewReader(source[checkpoint.Offset:]),
+			resumed,
+			EventCheckpointOptions{Resume: &checkpoint},
+		))
+		assert.Equal(t, full.events[len(full.events)-len(resumed.events):], resumed.events)
+		assert.NotEqual(t, 0, len(resumed.events))
+	}
+
+	// Errors
+	checkpoint, _ := ReadEventCheckpoint(strings.NewReader(checkpoints[0]))
+	assert.Equal(
+		t,
+		ErrEventsCheckpointRules+": list",
+		testGrammar("list = 'x'").ParseEventsWithCheckpoints(strings.NewReader(""), full, EventCheckpointOptions{Resume: &checkpoint}).Error(),
+	)
+	assert.Equal(
+		t,
+		ErrEventsCheckpointSave,
+		g.ParseEventsWithCheckpoints(strings.NewReader(source), full, EventCheckpointOptions{Interval: 1}).Error(),
+	)
+	assert.Equal(
+		t,
+		"stop",
+		g.ParseEventsWithCheckpoints(strings.NewReader(source), full, EventCheckpointOptions{
+			Interval: 1,
+			Save:     func(EventCheckpoint) error { return fmt.Errorf("stop") },
+		}).Error(),
+	)
+
+	for text, msg := range map[string]string{
+		"":                                ErrEventsCheckpoint + ": header",
+		"goparse-events 2":                ErrEventsCheckpoint + ": version 2",
+		"goparse-events 1\n1 2":           ErrEventsCheckpoint + ": position",
+		"goparse-events 1\n1 1 1 2 1\nx ": ErrEventsCheckpoint + ": rule",
+	} {
+		_, err := ReadEventCheckpoint(strings.NewReader(text))
+		assert.Equal(t, msg, err.Error(), text)
+	}
+}
@@ -0,0 +1,93 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// Event timeout error message constants
+const (
+	ErrEventsReadTimeout     = "Timed out waiting for input"
+	ErrEventsTerminalTimeout = "Timed out waiting for the rest of a terminal"
+)
+
+// DefaultMaxTimeouts is the number of consecutive reads that may time out while waiting for the rest of a terminal when
+// EventTimeoutOptions.MaxTimeouts is 0
+const DefaultMaxTimeouts = 1000
+
+// TimeoutPolicy is what an event parse does when a read times out in the middle of a terminal
+type TimeoutPolicy uint
+
+// TimeoutPolicy constants
+const (
+	// TimeoutFail fails the parse
+	TimeoutFail TimeoutPolicy = iota
+	// TimeoutWait keeps reading until the rest of the terminal arrives, the terminal timeout passes, or too many reads
+	// time out in a row
+	TimeoutWait
+)
+
+// EventTimeoutOptions limit the time an event parse waits for input from a source such as a network connection,
+// so that a peer that sends input very slowly or not at all cannot hold a parse open forever
+type EventTimeoutOptions struct {
+	// ReadTimeout is the time each read of the source may take, if the source has a SetReadDeadline method like net.Conn.
+	// A read that times out between terminals fails the parse. 0 means reads have no deadline.
+	ReadTimeout time.Duration
+	// PartialTerminal is what happens when a read times out after the first rune of a terminal and before its last rune
+	PartialTerminal TimeoutPolicy
+	// TerminalTimeout is the time the runes of a terminal may take to arrive after its first rune, regardless of the policy,
+	// which stops a peer from trickling in a long terminal one rune at a time. 0 means there is no limit.
+	TerminalTimeout time.Duration
+	// MaxTimeouts is the number of consecutive reads that may time out while waiting for the rest of a terminal with
+	// TimeoutWait, DefaultMaxTimeouts if 0, no maximum if < 0. It stops the parse of a source whose deadline has passed,
+	// where every read times out at once, which would otherwise spin forever if there is no TerminalTimeout.
+	MaxTimeouts int
+}
+
+// readDeadliner is a source with a read deadline, such as net.Conn
+type readDeadliner interface {
+	SetReadDeadline(t time.Time) error
+}
+
+// deadlineReader sets the read deadline of a source before each read
+type deadlineReader struct {
+	source    io.Reader
+	deadliner readDeadliner
+	timeout   time.Duration
+}
+
+// eventNow is the current time, which tests can replace
+var eventNow = time.Now
+
+// Read is the io.Reader interface
+func (r deadlineReader) Read(p []byte) (int, error) {
+	if err := r.deadliner.SetReadDeadline(eventNow().Add(r.timeout)); err != nil {
+		return 0, err
+	}
+
+	return r.source.Read(p)
+}
+
+// isTimeout returns true if an error is a timeout, such as a net.Error whose Timeout method returns true
+func isTimeout(err error) bool {
+	timeout, isa := err.(interface{ Timeout() bool })
+	return isa && timeout.Timeout()
+}
+
+// terminalTimedOut returns true if the terminal being read has taken longer than the terminal timeout
+func (p *eventParser) terminalTimedOut() bool {
+	return (p.timeouts.TerminalTimeout > 0) && (eventNow().Sub(p.partialStart) > p.timeouts.TerminalTimeout)
+}
+
+// waitTimedOut counts a read that timed out while waiting for the rest of a terminal, and returns true if the parse
+// should stop waiting, as the terminal timeout passed, or too many reads have timed out in a row
+func (p *eventParser) waitTimedOut() bool {
+	p.timedOut++
+	return p.terminalTimedOut() || ((p.timeouts.MaxTimeouts > 0) && (p.timedOut > p.timeouts.MaxTimeouts))
+}
+
+// timeoutError returns a timeout error at the next rune
+func (p *eventParser) timeoutError(msg string) error {
+	return fmt.Errorf("%s at line %d position %d", msg, p.line, p.position)
+}
@@ -0,0 +1,122 @@
+package parser
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// timeoutError is a read error like that of a net.Conn whose deadline passed
+type timeoutError struct{}
+
+func (timeoutError) Error() string { return "timeout" }
+func (timeoutError) Timeout() bool { return true }
+
+// trickleConn returns one chunk of input per read, where an empty chunk times out, and records read deadlines
+type trickleConn struct {
+	chunks    []string
+	deadlines []time.Time
+}
+
+func (c *trickleConn) Read(p []byte) (int, error) {
+	if len(c.chunks) == 0 {
+		return 0, io.EOF
+	}
+
+	chunk := c.chunks[0]
+	c.chunks = c.chunks[1:]
+	if chunk == "" {
+		return 0, timeoutError{}
+	}
+
+	return copy(p, chunk), nil
+}
+
+func (c *trickleConn) SetReadDeadline(t time.Time) error {
+	c.deadlines = append(c.deadlines, t)
+	return nil
+}
+
+func TestParseEventsTimeout(t *testing.T) {
+	var (
+		g = testGrammar(
+			"list = item rest",
+			"rest = (',' item)*",
+			"item = 'xyz' | [ab]",
+		)
+		parse = func(timeouts EventTimeoutOptions, chunks ...string) error {
+			return g.ParseEventsWithOptions(&trickleConn{chunks: chunks}, &recordingHandler{}, EventOptions{Timeout: timeouts})
+		}
+	)
+
+	// A read between terminals that times out fails, regardless of the policy
+	assert.Equal(t, ErrEventsReadTimeout+" at line 1 position 3", parse(EventTimeoutOptions{}, "a,", "", "b").Error())
+	assert.Equal(t, ErrEventsReadTimeout+" at line 1 position 3", parse(EventTimeoutOptions{PartialTerminal: TimeoutWait}, "a,", "", "b").Error())
+
+	// A read in the middle of a terminal that times out depends on the policy
+	assert.Equal(t, ErrEventsTerminalTimeout+" at line 1 position 4", parse(EventTimeoutOptions{}, "a,x", "", "yz").Error())
+	assert.Nil(t, parse(EventTimeoutOptions{PartialTerminal: TimeoutWait}, "a,x", "", "", "yz"))
+
+	// A terminal that trickles in too slowly fails, where the clock advances a second each time it is read
+	defer func(now func() time.Time) { eventNow = now }(eventNow)
+	clock := time.Unix(0, 0)
+	eventNow = func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+	timeouts := EventTimeoutOptions{PartialTerminal: TimeoutWait, TerminalTimeout: 1500 * time.Millisecond}
+	assert.Equal(t, ErrEventsTerminalTimeout+" at line 1 position 5", parse(timeouts, "a,x", "y", "z").Error())
+	assert.Equal(t, ErrEventsTerminalTimeout+" at line 1 position 4", parse(timeouts, "a,x", "", "", "yz").Error())
+
+	// Each read has a deadline, which is cleared at the end
+	conn := &trickleConn{chunks: []string{"a", ",b"}}
+	assert.Nil(t, g.ParseEventsWithOptions(conn, &recordingHandler{}, EventOptions{Timeout: EventTimeoutOptions{ReadTimeout: time.Minute}}))
+	assert.Equal(t, 4, len(conn.deadlines))
+	assert.False(t, conn.deadlines[2].IsZero())
+	assert.Equal(t, time.Time{}, conn.deadlines[3])
+
+	// A source without deadlines is read as usual
+	assert.Nil(t, g.ParseEventsWithOptions(strings.NewReader("a"), &recordingHandler{}, EventOptions{Timeout: EventTimeoutOptions{ReadTimeout: time.Minute}}))
+}
+
+// expiredConn is a source whose read deadline has passed, so every read times out at once
+type expiredConn struct {
+	reads int
+}
+
+func (c *expiredConn) Read(p []byte) (int, error) {
+	c.reads++
+	if c.reads == 1 {
+		return copy(p, "a,x"), nil
+	}
+
+	return 0, timeoutError{}
+}
+
+func TestParseEventsMaxTimeouts(t *testing.T) {
+	var (
+		g = testGrammar(
+			"list = item rest",
+			"rest = (',' item)*",
+			"item = 'xyz' | [ab]",
+		)
+		parse = func(timeouts EventTimeoutOptions, conn io.Reader) error {
+			return g.ParseEventsWithOptions(conn, &recordingHandler{}, EventOptions{Timeout: timeouts})
+		}
+	)
+
+	// Waiting for the rest of a terminal stops after the default maximum of reads in a row that time out
+	conn := &expiredConn{}
+	assert.Equal(t, ErrEventsTerminalTimeout+" at line 1 position 4", parse(EventTimeoutOptions{PartialTerminal: TimeoutWait}, conn).Error())
+	assert.Equal(t, DefaultMaxTimeouts+2, conn.reads)
+
+	conn = &expiredConn{}
+	assert.Equal(t, ErrEventsTerminalTimeout+" at line 1 position 4", parse(EventTimeoutOptions{PartialTerminal: TimeoutWait, MaxTimeouts: 3}, conn).Error())
+	assert.Equal(t, 5, conn.reads)
+
+	// The count restarts when a rune arrives
+	assert.Nil(t, parse(EventTimeoutOptions{PartialTerminal: TimeoutWait, MaxTimeouts: 2}, &trickleConn{chunks: []string{"a,x", "", "", "y", "", "", "z"}}))
+}
@@ -0,0 +1,116 @@
+package parser
+
+// firstSet is the set of characters that an alternative or rule can begin with
+type firstSet struct {
+	chars RuneSet
+	// true if nothing can be matched
+	nullable bool
+	// true if an undefined rule can occur first, so the set is incomplete
+	unknown bool
+}
+
+// add adds another set to this set, returning true if this set changed
+func (f *firstSet) add(other firstSet) bool {
+	changed := false
+	if chars := f.chars.Union(other.chars); !chars.Equal(f.chars) {
+		f.chars = chars
+		changed = true
+	}
+
+	if other.unknown && !f.unknown {
+		f.unknown = true
+		changed = true
+	}
+
+	return changed
+}
+
+// ruleFirstSets is the first set of a rule and each of its alternatives
+type ruleFirstSets struct {
+	rule         firstSet
+	alternatives []firstSet
+}
+
+// disjoint returns true if every alternative is known, cannot match nothing, and shares no characters with any other
+// alternative, so that at most one alternative can match any given input
+func (r ruleFirstSets) disjoint() bool {
+	var seen RuneSet
+	for _, alt := range r.alternatives {
+		if alt.nullable || alt.unknown || !seen.Intersect(alt.chars).IsEmpty() {
+			return false
+		}
+		seen = seen.Union(alt.chars)
+	}
+
+	return true
+}
+
+// firstSets computes the first sets of every rule and alternative of a grammar, indexed by rule name.
+// If a rule is defined more than once, the first definition is used.
+func firstSets(g Grammar) map[string]ruleFirstSets {
+	var (
+		byName = rulesByName(g.rules)
+		result = map[string]ruleFirstSets{}
+	)
+
+	for name, rule := range byName {
+		result[name] = ruleFirstSets{alternatives: make([]firstSet, len(rule.expr.items))}
+	}
+
+	// Rules can refer to each other in any order, so keep adding to the sets until none of them change
+	for changed := true; changed; {
+		changed = false
+
+		for name, rule := range byName {
+			sets := result[name]
+
+			for i, alt := range rule.expr.items {
+				altSet := &sets.alternatives[i]
+				nullable := true
+
+				for _, item := range alt.list {
+					itemSet := itemFirstSet(item, result)
+					changed = altSet.add(itemSet) || changed
+					if !itemSet.nullable {
+						nullable = false
+						break
+					}
+				}
+
+				if (nullable || (alt.n == 0)) && !altSet.nullable {
+					altSet.nullable = true
+					changed = true
+				}
+
+				changed = sets.rule.add(*altSet) || changed
+				if altSet.nullable && !sets.rule.nullable {
+					sets.rule.nullable = true
+					changed = true
+				}
+			}
+
+			result[name] = sets
+		}
+	}
+
+	return result
+}
+
+// itemFirstSet returns the first set of a list item, using the sets computed so far for rules
+func itemFirstSet(item ListItem, sets map[string]ruleFirstSets) firstSet {
+	switch {
+	case item.IsRuleName():
+		if ruleSets, defined := sets[item.ruleName]; defined {
+			return ruleSets.rule
+		}
+
+		return firstSet{unknown: true}
+
+	case item.terminal.IsString():
+		for _, char := range item.terminal.theString {
+			return firstSet{chars: OfRuneSetRunes(char)}
+		}
+	}
+
+	return firstSet{chars: item.terminal.theRange}
+}
@@ -0,0 +1,39 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFirstSets(t *testing.T) {
+	sets := firstSets(testGrammar(
+		"a = b 'x' | c",
+		"b = ('y')? | [za]",
+		"c = 'w' | undefined",
+	))
+
+	assert.Equal(t, OfRuneSetRunes('y', 'z', 'a', 'x'), sets["a"].alternatives[0].chars)
+	assert.False(t, sets["a"].alternatives[0].nullable)
+	assert.True(t, sets["a"].alternatives[1].unknown)
+	assert.Equal(t, OfRuneSetRunes('y', 'z', 'a', 'x', 'w'), sets["a"].rule.chars)
+	assert.True(t, sets["a"].rule.unknown)
+	assert.False(t, sets["a"].disjoint())
+
+	assert.True(t, sets["b"].alternatives[0].nullable)
+	assert.True(t, sets["b"].rule.nullable)
+	assert.False(t, sets["b"].disjoint())
+
+	sets = firstSets(testGrammar(
+		"a = 'x' a | b | [yz]",
+		"b = 'w' | 'v'",
+	))
+	assert.True(t, sets["a"].disjoint())
+	assert.True(t, sets["b"].disjoint())
+
+	sets = firstSets(testGrammar(
+		"a = 'x' a | b",
+		"b = 'x' | 'v'",
+	))
+	assert.False(t, sets["a"].disjoint())
+}
@@ -0,0 +1,111 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+)
+
+// FoldTerminals returns a grammar where adjacent terminals are combined, so that fewer and larger matches are made:
+// - adjacent string list items in an expression item are concatenated ('a' 'b' becomes 'ab')
+// - adjacent alternatives that are a single character range with the same repetitions are merged ([a-c] | [d-f] becomes [a-f])
+//
+// Folding assumes nothing can occur between adjacent terminals, so it changes what a grammar parsed with
+// ParseOptions.Trivia matches. CompileOptions.FoldTerminals folds when compiling, and refuses to with a trivia rule.
+// A list item with options is not folded with the list item that follows it, since the options apply between them.
+//
+// If report is not nil, a line describing each fold is written to it.
+// Rules keep their doc comments, limits, error messages, and positions.
+// If nothing is folded, the grammar is returned unchanged.
+func (g Grammar) FoldTerminals(report io.Writer) Grammar {
+	var (
+		rules   = make([]Rule, len(g.rules))
+		changed bool
+	)
+
+	for i, rule := range g.rules {
+		var (
+			items       []ExpressionItem
+			ruleChanged bool
+		)
+
+		for _, alt := range rule.expr.items {
+			if folded, ok := foldStrings(rule.name, alt, report); ok {
+				alt = folded
+				ruleChanged = true
+			}
+
+			if last := len(items) - 1; (last >= 0) && isRangeAlternative(items[last]) && isRangeAlternative(alt) &&
+				(items[last].n == alt.n) && (items[last].m == alt.m) && (items[last].lazy == alt.lazy) &&
+				(items[last].errorMessage == alt.errorMessage) {
+				merged := items[last].list[0].terminal.theRange.Union(alt.list[0].terminal.theRange)
+				src := rangeSource(merged)
+				item := alt.withList([]ListItem{OfListItemTerminal(src, OfTerminalRuneSet(src, merged), nil)})
+				if report != nil {
+					fmt.Fprintf(report, "%s: %s | %s -> %s\n", rule.name, items[last], alt, item)
+				}
+
+				items[last] = item
+				ruleChanged = true
+				continue
+			}
+
+			items = append(items, alt)
+		}
+
+		rules[i] = rule
+		if ruleChanged {
+			rules[i] = newRule(rule.name, rule.options, newExpression(items))
+			rules[i].SourceNode = rules[i].withSpan(rule.Span())
+			rules[i].doc, rules[i].limits, rules[i].errorMessage = rule.doc, rule.limits, rule.errorMessage
+			changed = true
+		}
+	}
+
+	if !changed {
+		return g
+	}
+
+	return newGrammar(rules)
+}
+
+// foldStrings concatenates adjacent string list items of an alternative.
+// Returns the new alternative and true if any strings were concatenated.
+func foldStrings(ruleName string, alt ExpressionItem, report io.Writer) (ExpressionItem, bool) {
+	var (
+		list   []ListItem
+		folded bool
+	)
+
+	for _, item := range alt.list {
+		if last := len(list) - 1; (last >= 0) && isStringItem(list[last]) && (len(list[last].options) == 0) && isStringItem(item) {
+			str := list[last].terminal.theString + item.terminal.theString
+			src := stringSource(str)
+			concat := OfListItemTerminal(src, OfTerminalString(src, str), item.options)
+			if report != nil {
+				fmt.Fprintf(report, "%s: %s %s -> %s\n", ruleName, list[last], item, concat)
+			}
+
+			list[last] = concat
+			folded = true
+			continue
+		}
+
+		list = append(list, item)
+	}
+
+	if !folded {
+		return alt, false
+	}
+
+	return alt.withList(list), true
+}
+
+// isStringItem returns true if a list item is a string terminal
+func isStringItem(item ListItem) bool {
+	return item.IsTerminal() && item.terminal.IsString()
+}
+
+// isRangeAlternative returns true if an alternative is a single character range without options
+func isRangeAlternative(alt ExpressionItem) bool {
+	return (len(alt.list) == 1) && alt.list[0].IsTerminal() && alt.list[0].terminal.IsRange() && (len(alt.list[0].options) == 0)
+}
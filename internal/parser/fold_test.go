@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFoldTerminals(t *testing.T) {
+	g := testGrammar(
+		"a = 'x' b 'y'",
+		"b = [abc] | 'y'",
+	)
+	assert.Equal(t, g, g.FoldTerminals(nil))
+
+	var report strings.Builder
+	g = testGrammar(
+		"a = 'x' 'y' b 'z' 'w' 'v'",
+		"b = [abc] | [def] | [-] | ([a])* | 'y'",
+		"c = ('a' 'b')+",
+	).FoldTerminals(&report)
+	assert.Equal(
+		t,
+		"a = 'xy' b 'zwv'\nb = [a-f-] | ([a])* | 'y'\nc = ('ab')+",
+		g.String(),
+	)
+	assert.Equal(
+		t,
+		"a: 'x' 'y' -> 'xy'\n"+
+			"a: 'z' 'w' -> 'zw'\n"+
+			"a: 'zw' 'v' -> 'zwv'\n"+
+			"b: [abc] | [def] -> [a-f]\n"+
+			"b: [a-f] | [-] -> [a-f-]\n"+
+			"c: 'a' 'b' -> 'ab'\n",
+		report.String(),
+	)
+
+	// Options prevent folding with the following item, but are kept when folding with the preceding item
+	g = testGrammar("a = 'x' 'y' 'z'")
+	list := g.rules[0].expr.items[0].list
+	list[0].options = []Option{OptionEOL}
+	list[2].options = []Option{OptionIndent}
+	folded := g.FoldTerminals(nil).rules[0].expr.items[0].list
+	assert.Equal(t, 2, len(folded))
+	assert.Equal(t, "x", folded[0].terminal.theString)
+	assert.Equal(t, []Option{OptionEOL}, folded[0].options)
+	assert.Equal(t, "yz", folded[1].terminal.theString)
+	assert.Equal(t, []Option{OptionIndent}, folded[1].options)
+
+	// Rules keep their doc comments, limits, error messages, and positions
+	g, err := CompileString("// doc\na:ERROR(\"msg\") = 'x' 'y' :ERROR(\"alt msg\");", CompileOptions{})
+	assert.Nil(t, err)
+	g.rules[0] = g.rules[0].WithLimits(RuleLimits{MaxLength: 2})
+	rule := g.FoldTerminals(nil).rules[0]
+	assert.Equal(t, "a = 'xy'", rule.String())
+	assert.Equal(t, "doc", rule.Doc())
+	assert.Equal(t, RuleLimits{MaxLength: 2}, rule.Limits())
+	assert.Equal(t, "msg", rule.ErrorMessage(0))
+	assert.Equal(t, "alt msg", rule.ErrorMessage(1))
+	assert.Equal(t, 2, rule.Line())
+}
+
+func TestRangeSource(t *testing.T) {
+	assert.Equal(t, "[ab]", rangeSource(OfRuneSetRunes('a', 'b')))
+	assert.Equal(t, "[a-dz]", rangeSource(OfRuneSetRunes('a', 'b', 'c', 'd', 'z')))
+	assert.Equal(t, `[\t\n\\\]]`, rangeSource(OfRuneSetRunes('\t', '\n', '\\', ']')))
+	assert.Equal(t, "[a^-]", rangeSource(OfRuneSetRunes('a', '^', '-')))
+	assert.Equal(t, "[-^]", rangeSource(OfRuneSetRunes('^', '-')))
+}
+
+func TestStringSource(t *testing.T) {
+	assert.Equal(t, `'a\\\t\n\r\'"'`, stringSource("a\\\t\n\r'\""))
+}
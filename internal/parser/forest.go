@@ -0,0 +1,176 @@
+package parser
+
+import (
+	"io"
+	"io/ioutil"
+)
+
+// Forest is a shared packed parse forest of every derivation of the start rule that matches all of the input.
+// Derivations share the matches of each rule over each span of input, which are only combined into trees as they are visited,
+// so that grammars with exponentially many derivations can still be parsed.
+//
+// A derivation that uses a rule over a span of input inside a match of the same rule over the same span is not visited,
+// as such cycles of rules that match nothing would produce infinitely many derivations.
+type Forest struct {
+	parse *earleyParse
+	start int
+	// input, and the mapped input the parse matched, or nil
+	input  []rune
+	mapped *mappedInput
+	// conventions of the positions of the spans of trees
+	positions PositionOptions
+}
+
+// spanList is a linked list of the spans that enclose a derivation
+type spanList struct {
+	span earleySpan
+	next *spanList
+}
+
+// contains returns true if the list contains a span
+func (l *spanList) contains(span earleySpan) bool {
+	for ; l != nil; l = l.next {
+		if l.span == span {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ParseForest reads all of the source, and returns every derivation of the start rule that matches all of it.
+// Matching uses the Earley algorithm regardless of the backend, so the grammar may be left recursive and ambiguous.
+// Predicates receive the given context as each derivation is visited, and actions are not called.
+// Returns an error if the source cannot be read, or a ParseError if it does not match.
+func (e *Engine) ParseForest(ctx *ParseContext, source io.Reader) (Forest, error) {
+	data, err := ioutil.ReadAll(source)
+	if err == nil {
+		data, err = e.options.Encoding.decode(data)
+	}
+	if err != nil {
+		return Forest{}, err
+	}
+
+	input := []rune(string(data))
+	mapped, err := e.mapInput(input)
+	if err != nil {
+		return Forest{}, err
+	}
+	runes := input
+	if mapped != nil {
+		runes = mapped.runes
+	}
+
+	parse, failure := e.earley.recognize(ctx, e.options.Predicates, runes, e.options.Start)
+	if parse == nil {
+		return Forest{}, mapped.restoreError(input, e.parseError(runes, failure), e.options.Positions)
+	}
+
+	return Forest{
+		positions: e.options.Positions,
+		parse:     parse,
+		start:     e.earley.ruleIndex[e.options.Start],
+		input:     input,
+		mapped:    mapped,
+	}, nil
+}
+
+// Trees visits each derivation as a parse tree, in the same order the Earley backend prefers them,
+// until the visitor returns false or there are no more derivations.
+// Returns false if the visitor stopped the visit.
+func (f Forest) Trees(visit func(Node) bool) bool {
+	lines := newLineIndex(f.input, f.positions)
+	return f.parse.derive(f.start, 0, len(f.parse.input), nil, func(nodes []Node) bool {
+		tree := f.mapped.restore(f.input, nodes[0])
+		tree.index(lines)
+		return visit(tree)
+	})
+}
+
+// Count returns the number of derivations, stopping at max if max > 0
+func (f Forest) Count(max int) int {
+	count := 0
+	f.Trees(func(Node) bool {
+		count++
+		return (max <= 0) || (count < max)
+	})
+
+	return count
+}
+
+// Ambiguous returns true if there is more than one derivation
+func (f Forest) Ambiguous() bool {
+	return f.Count(2) > 1
+}
+
+// derive visits the nodes of each derivation of a nonterminal from start to end that is not inside the same span in enclosing,
+// until the visitor returns false. Returns false if the visitor stopped the visit.
+func (p *earleyParse) derive(nonterminal, start, end int, enclosing *spanList, visit func([]Node) bool) bool {
+	span := earleySpan{nonterminal, start, end}
+	if enclosing.contains(span) {
+		return true
+	}
+	enclosing = &spanList{span: span, next: enclosing}
+
+	nt := p.grammar.nonterminals[nonterminal]
+	for _, prod := range nt.productions {
+		more := p.deriveSequence(p.grammar.productions[prod].symbols, start, end, enclosing, func(children []Node) bool {
+			switch nt.kind {
+			case earleyRule:
+				node := Node{rule: nt.name, text: string(p.input[start:end]), start: start, end: end, children: children}
+				if predicate := p.predicates[nt.name]; (predicate != nil) && !predicate(p.ctx, node) {
+					return true
+				}
+				return visit([]Node{node})
+			case earleyTerminal:
+				return visit([]Node{{text: string(p.input[start:end]), start: start, end: end}})
+			default:
+				return visit(children)
+			}
+		})
+
+		if !more {
+			return false
+		}
+	}
+
+	return true
+}
+
+// deriveSequence visits the nodes of each derivation of a sequence of symbols from start to end,
+// until the visitor returns false. Returns false if the visitor stopped the visit.
+func (p *earleyParse) deriveSequence(symbols []earleySymbol, start, end int, enclosing *spanList, visit func([]Node) bool) bool {
+	if len(symbols) == 0 {
+		if start == end {
+			return visit(nil)
+		}
+		return true
+	}
+
+	sym := symbols[0]
+	if sym.nonterminal < 0 {
+		if (start < end) && sym.matches(p.input[start]) {
+			return p.deriveSequence(symbols[1:], start+1, end, enclosing, visit)
+		}
+		return true
+	}
+
+	for _, next := range p.ends[[2]int{sym.nonterminal, start}] {
+		if next > end {
+			continue
+		}
+
+		next := next
+		more := p.derive(sym.nonterminal, start, next, enclosing, func(nodes []Node) bool {
+			return p.deriveSequence(symbols[1:], next, end, enclosing, func(rest []Node) bool {
+				return visit(append(append([]Node(nil), nodes...), rest...))
+			})
+		})
+
+		if !more {
+			return false
+		}
+	}
+
+	return true
+}
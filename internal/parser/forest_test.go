@@ -0,0 +1,77 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testForest returns the tree strings of each derivation of source
+func testForest(t *testing.T, g Grammar, source string) []string {
+	engine, err := NewEngine(g, ParseOptions{Backend: BackendEarley})
+	assert.Nil(t, err)
+
+	forest, err := engine.ParseForest(OfParseContext(), strings.NewReader(source))
+	assert.Nil(t, err)
+
+	var trees []string
+	forest.Trees(func(node Node) bool {
+		trees = append(trees, treeString(node))
+		return true
+	})
+
+	return trees
+}
+
+func TestForest(t *testing.T) {
+	g := testGrammar("e = e '+' e | [123]")
+
+	assert.Equal(t, []string{"e('1')"}, testForest(t, g, "1"))
+	assert.Equal(
+		t,
+		[]string{
+			"e(e(e('1') '+' e('2')) '+' e('3'))",
+			"e(e('1') '+' e(e('2') '+' e('3')))",
+		},
+		testForest(t, g, "1+2+3"),
+	)
+
+	// The first derivation is the tree Parse returns
+	assert.Equal(t, testParse(t, g, ParseOptions{Backend: BackendEarley}, "1+2+3"), testForest(t, g, "1+2+3")[0])
+
+	engine, _ := NewEngine(g, ParseOptions{Backend: BackendEarley})
+	forest, err := engine.ParseForest(OfParseContext(), strings.NewReader("1+2+3+1+2"))
+	assert.Nil(t, err)
+	assert.Equal(t, 14, forest.Count(0))
+	assert.Equal(t, 3, forest.Count(3))
+	assert.True(t, forest.Ambiguous())
+
+	// Stop visiting early
+	visited := 0
+	assert.False(t, forest.Trees(func(Node) bool {
+		visited++
+		return false
+	}))
+	assert.Equal(t, 1, visited)
+
+	forest, _ = engine.ParseForest(OfParseContext(), strings.NewReader("1"))
+	assert.False(t, forest.Ambiguous())
+
+	_, err = engine.ParseForest(OfParseContext(), strings.NewReader("1+"))
+	assert.Equal(t, ErrParseFailed+" at line 1 position 3: found EOF, expected [1-3] in rule e", err.Error())
+}
+
+func TestForestCycles(t *testing.T) {
+	// A nullable rule used twice over the same empty span, and a cycle of rules that match nothing
+	g := testGrammar(
+		"a = b b 'x' | c",
+		"b = ('y')?",
+		"c = c | d",
+		"d = ('z')?",
+	)
+
+	assert.Equal(t, []string{"a(b() b() 'x')"}, testForest(t, g, "x"))
+	assert.Equal(t, []string{"a(b('y') b() 'x')", "a(b() b('y') 'x')"}, testForest(t, g, "yx"))
+	assert.Equal(t, []string{"a(c(d()))"}, testForest(t, g, ""))
+}
@@ -0,0 +1,130 @@
+package parser
+
+import (
+	"strings"
+)
+
+// formatWidth is the line width Format wraps definitions at
+const formatWidth = 100
+
+// Format returns the grammar in canonical source form, so that grammar files can be normalized the same way gofmt
+// normalizes Go source:
+// - The doc comment of a rule is written before it as // comments
+// - A definition is written on one line, ending in a semicolon, if it fits in 100 characters
+// - Otherwise each alternative after the first is written on its own line, with the | aligned under the =
+// - Rules are separated by a blank line
+// - Strings are single quoted, and ranges list runs of three or more characters as X-Y
+// - A repetition of a single item follows the item, and a repetition of several items follows them in parentheses
+// - The error message of a rule follows its options, and that of an alternative follows it, as :ERROR("message")
+// - A grammar with no rules is an empty string
+func (g Grammar) Format() string {
+	if len(g.rules) == 0 {
+		return ""
+	}
+
+	rules := make([]string, len(g.rules))
+	for i, rule := range g.rules {
+		rules[i] = rule.Format()
+	}
+
+	return strings.Join(rules, "\n\n") + "\n"
+}
+
+// Format returns the rule in canonical source form, as described by Grammar.Format, without a trailing EOL
+func (r Rule) Format() string {
+	var (
+		str  strings.Builder
+		head = r.name
+	)
+
+	if r.doc != "" {
+		for _, line := range strings.Split(r.doc, "\n") {
+			str.WriteString(strings.TrimRight("// "+line, " "))
+			str.WriteRune('\n')
+		}
+	}
+
+	for _, option := range r.options {
+		head += option.String()
+	}
+	head += errorOptionSource(r.errorMessage) + " = "
+
+	alts := make([]string, len(r.expr.items))
+	for i, alt := range r.expr.items {
+		alts[i] = formatAlternative(alt)
+	}
+
+	if line := head + strings.Join(alts, " | ") + ";"; len(line) <= formatWidth {
+		str.WriteString(line)
+		return str.String()
+	}
+
+	indent := strings.Repeat(" ", len(head)-2)
+	str.WriteString(head)
+	for i, alt := range alts {
+		if i > 0 {
+			str.WriteString("\n" + indent + "| ")
+		}
+		str.WriteString(alt)
+	}
+	str.WriteRune(';')
+
+	return str.String()
+}
+
+// formatAlternative returns the canonical source form of an alternative
+func formatAlternative(alt ExpressionItem) string {
+	items := make([]string, len(alt.list))
+	for i, item := range alt.list {
+		items[i] = formatListItem(item)
+	}
+
+	src := strings.Join(items, " ")
+	if rep := alt.repetition(); rep != "" {
+		if len(items) > 1 {
+			src = "(" + src + ")"
+		}
+		src += rep
+	}
+
+	if alt.errorMessage != "" {
+		src += " " + errorOptionSource(alt.errorMessage)
+	}
+
+	return src
+}
+
+// errorOptionSource returns the source form of the :ERROR option of an error message, or "" if it is empty
+func errorOptionSource(message string) string {
+	if message == "" {
+		return ""
+	}
+
+	return errorOption + "(" + quotedSource(message, '"') + ")"
+}
+
+// formatListItem returns the canonical source form of a list item
+func formatListItem(item ListItem) string {
+	var src string
+	switch {
+	case item.IsRuleName():
+		src = item.ruleName
+	default:
+		src = item.terminal.Format()
+	}
+
+	for _, option := range item.options {
+		src += option.String()
+	}
+
+	return src
+}
+
+// Format returns the canonical source form of the terminal
+func (t Terminal) Format() string {
+	if t.IsString() {
+		return stringSource(t.theString)
+	}
+
+	return rangeSource(t.theRange)
+}
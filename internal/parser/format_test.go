@@ -0,0 +1,52 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormat(t *testing.T) {
+	g := testGrammar(
+		"list:MEMO = item rest",
+		"rest = (',' item)*",
+		"item = [cab] | 'x' | ([0123456789])+ | long-name-number-one long-name-number-two | long-name-number-three long-name-number-four",
+	)
+	g.rules[0] = g.rules[0].WithDoc("A list of items\n\nSeparated by commas")
+	g.rules[2].expr.items[1].list[0].options = []Option{OptionEOL, OptionIndent}
+
+	assert.Equal(
+		t,
+		`// A list of items
+//
+// Separated by commas
+list:MEMO = item rest;
+
+rest = (',' item)*;
+
+item = [a-c]
+     | 'x':EOL:INDENT
+     | [0-9]+
+     | long-name-number-one long-name-number-two
+     | long-name-number-three long-name-number-four;
+`,
+		g.Format(),
+	)
+
+	// Strings are escaped, and a single repeated item has no parentheses
+	quote := OfListItemTerminal(`"'\\"`, OfTerminalString(`"'\\"`, `'\`), nil)
+	rule := newRule("a", nil, newExpression([]ExpressionItem{
+		newExpressionItem([]ListItem{quote}, 1, 1),
+		newExpressionItem([]ListItem{quote}, 0, 1),
+	}))
+	assert.Equal(t, `a = '\'\\' | '\'\\'?;`, rule.Format())
+
+	// A lazy repetition is followed by ?
+	assert.Equal(t, "a = 'x'*? | ('x' 'y'){2,3}?;", testGrammar("a = ('x')*? | ('x' 'y'){2,3}?").rules[0].Format())
+
+	assert.Equal(t, `'\'\\'`, quote.Terminal().Format())
+	assert.Equal(t, "[a-ex]", OfTerminalRange("", map[rune]bool{'a': true, 'b': true, 'c': true, 'd': true, 'e': true, 'x': true}).Format())
+
+	// A grammar of no rules formats as nothing
+	assert.Equal(t, "", OfGrammar("", nil).Format())
+}
@@ -0,0 +1,394 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// errorOption is the option of an error message for end users of a rule or alternative, such as :ERROR("message")
+const errorOption = ":ERROR"
+
+// goparse notation import error message constants
+const (
+	ErrGoparseSyntax      = "Invalid goparse grammar"
+	ErrGoparseUnsupported = "Unsupported goparse construct"
+)
+
+// goparseToken is a lexical token of a grammar in goparse notation
+type goparseToken struct {
+	Token
+	// text of a string without its quotes and escapes
+	value string
+	// lines of the // comments that precede the token, without a blank line between them and the token
+	doc []string
+}
+
+// describe returns a description of the token for error messages
+func (t goparseToken) describe() string {
+	if t.lexType == TokenEOF {
+		return "EOF"
+	}
+
+	return "'" + t.token + "'"
+}
+
+// start is where the token begins
+func (t goparseToken) start() SourcePosition {
+	start, _ := t.Span()
+	return start
+}
+
+// end is just after the last rune of the token
+func (t goparseToken) end() SourcePosition {
+	_, end := t.Span()
+	return end
+}
+
+// isRepetition returns true if the token is a repetition ? * + or {N,M}
+func (t goparseToken) isRepetition() bool {
+	switch t.lexType {
+	case TokenZeroOrOne, TokenZeroOrMore, TokenOneOrMore, TokenRepetition:
+		return true
+	}
+
+	return false
+}
+
+// goparseParser parses the tokens of a grammar in goparse notation into rules
+type goparseParser struct {
+	tokens []goparseToken
+	index  int
+}
+
+// ImportGoparse reads a grammar in goparse notation, which is the notation that Grammar.Format writes, and converts its
+// rules to a Grammar, in the order they are defined.
+//
+// A rule is a name, any rule options such as :MEMO, an =, alternatives separated by |, and a ;.
+// The rule options and each alternative can end with an error message for end users, such as
+// :ERROR("strings must be closed with a quote"), which Rule.ErrorMessage returns.
+// An alternative is a sequence of rule names, 'single' or "double" quoted strings, and [ ] ranges, each followed by any
+// item options such as :EOL. A repetition ? * + {N} {N,} {,M} or {N,M}, which is lazy if followed by ?, can follow an
+// alternative of one item, or an alternative of several items in parentheses, as the model repeats whole alternatives.
+// The tokens are those of the Lexer, which defines the notation: strings can have the escapes \\ \t \n \r \' \" \xNN
+// and \u{N...}, a `raw` string in backquotes has none, and a range such as [a-z] has the runes from a through z, where
+// a - that is first or last is a literal -, and can have the escapes \\ \t \n \r \] \xNN and \u{N...}. A range such as
+// [^a-z] that begins with ^ has every rune of the universe except the excluded runes and the ones it lists, as the
+// //goparse:universe and //goparse:exclude directives set, and a range followed by i, such as [a-z]i, folds case.
+// The // comments on the lines just before a rule, without a blank line between them, are its doc comment, where one
+// space after the // is removed, and other comments, including /* */ comments and directives, are skipped.
+// super.name refers to a rule of the grammar this one extends.
+//
+// Returns an error if the grammar is invalid, or a repetition is not of a whole alternative.
+func ImportGoparse(source io.Reader) (Grammar, error) {
+	return importGoparse(source, PositionOptions{})
+}
+
+// importGoparse is ImportGoparse, where the positions of the grammar follow the options
+func importGoparse(source io.Reader, positions PositionOptions) (grammar Grammar, err error) {
+	// The conversion shares the ANTLR importer's error handling
+	defer func() {
+		if r := recover(); r != nil {
+			if e, isa := r.(antlrError); isa {
+				err = e.err
+				return
+			}
+			panic(r)
+		}
+	}()
+
+	data, err := ioutil.ReadAll(source)
+	if err != nil {
+		return Grammar{}, err
+	}
+
+	var (
+		p     = &goparseParser{tokens: goparseTokens(NewLexerFromBytesWithOptions(data, LexOptions{TabWidth: positions.TabWidth, ZeroBased: positions.ZeroBased}))}
+		rules []Rule
+	)
+	for p.peek().lexType != TokenEOF {
+		rules = append(rules, p.parseRule())
+	}
+
+	return newSpannedGrammar(rules, p.peek().end()), nil
+}
+
+// ==== Lexer
+
+// goparseTokens reads the tokens of a grammar in goparse notation from a Lexer, ending with EOF, skipping comments.
+// The // comments on the lines just before a token, which are not directives and have no blank line between them, are
+// the doc comment of the token.
+func goparseTokens(lexer *Lexer) []goparseToken {
+	var (
+		tokens []goparseToken
+		doc    []string
+		// line of the last comment of the doc comment
+		docLine int
+	)
+
+	for {
+		token, err := lexer.Next()
+		if err != nil {
+			panic(antlrError{fmt.Errorf("%s: %s", ErrGoparseSyntax, err)})
+		}
+
+		switch {
+		case token.lexType == TokenCommentOneLine:
+			switch {
+			case strings.HasPrefix(token.token, lexDirectivePrefix):
+				doc = nil
+			// A comment after a token on the same line is not a doc comment
+			case (len(tokens) > 0) && (tokens[len(tokens)-1].endLine == token.line):
+			case (len(doc) > 0) && (token.line != docLine+1):
+				doc = []string{goparseDocLine(token.token)}
+			default:
+				doc = append(doc, goparseDocLine(token.token))
+			}
+			docLine = token.line
+			continue
+
+		case token.lexType == TokenCommentMultiLine:
+			doc = nil
+			continue
+
+		case token.line != docLine+1:
+			doc = nil
+		}
+
+		result := goparseToken{Token: token, doc: doc}
+		if token.lexType == TokenString {
+			result.value = lexStringValue(token.token, token.line, token.position)
+		}
+
+		if tokens, doc = append(tokens, result), nil; token.lexType == TokenEOF {
+			return tokens
+		}
+	}
+}
+
+// goparseDocLine returns the line of a doc comment of a // comment, where one space after the // is removed
+func goparseDocLine(comment string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(comment, "//"), " ")
+}
+
+// ==== Parser
+
+// peek returns the next token
+func (p *goparseParser) peek() goparseToken {
+	return p.tokens[p.index]
+}
+
+// next consumes the next token
+func (p *goparseParser) next() goparseToken {
+	token := p.tokens[p.index]
+	if token.lexType != TokenEOF {
+		p.index++
+	}
+
+	return token
+}
+
+// isPunct returns true if the next token is the punctuation
+func (p *goparseParser) isPunct(text string) bool {
+	token := p.peek()
+	return (token.lexType == TokenPunctuation) && (token.token == text)
+}
+
+// expect consumes the next token, which must be the punctuation
+func (p *goparseParser) expect(text string) goparseToken {
+	if !p.isPunct(text) {
+		p.fail(ErrGoparseSyntax, fmt.Sprintf("expected '%s', found %s", text, p.peek().describe()), p.peek())
+	}
+
+	return p.next()
+}
+
+// fail panics with an error at a token
+func (p *goparseParser) fail(msg, details string, token goparseToken) {
+	antlrFail(msg, details, token.line, token.position)
+}
+
+// options parses any options after a rule name or list item
+func (p *goparseParser) options() []Option {
+	var result []Option
+
+nextOption:
+	for (p.peek().lexType == TokenOption) && (p.peek().token != errorOption) {
+		token := p.next()
+		for i, optionString := range optionStrings {
+			if token.token == optionString {
+				result = append(result, Option(i))
+				continue nextOption
+			}
+		}
+
+		p.fail(ErrGoparseSyntax, "unknown option "+token.token, token)
+	}
+
+	return result
+}
+
+// parseRule parses a rule
+func (p *goparseParser) parseRule() Rule {
+	name := p.next()
+	if name.lexType != TokenIdentifier {
+		p.fail(ErrGoparseSyntax, "expected a rule, found "+name.describe(), name)
+	}
+	p.checkName(name)
+
+	var (
+		options = p.options()
+		message = p.errorMessage()
+	)
+	p.expect("=")
+
+	var alts []ExpressionItem
+	for {
+		alts = append(alts, p.parseAlternative())
+		if !p.isPunct("|") {
+			break
+		}
+		p.next()
+	}
+	end := p.expect(";")
+
+	rule := newRule(name.token, options, newExpression(alts)).WithDoc(strings.Join(name.doc, "\n"))
+	rule.SourceNode, rule.errorMessage = rule.withSpan(name.start(), end.end()), message
+
+	return rule
+}
+
+// parseAlternative parses an alternative, which can be empty
+func (p *goparseParser) parseAlternative() ExpressionItem {
+	var (
+		start   = p.peek()
+		grouped = p.isPunct("(")
+		list    []ListItem
+	)
+	if grouped {
+		p.next()
+	}
+
+	for {
+		item, rep, ok := p.parseListItem()
+		if !ok {
+			break
+		}
+		list = append(list, item)
+
+		if rep.isRepetition() {
+			if grouped || (len(list) > 1) || p.atListItem() {
+				p.fail(ErrGoparseUnsupported, "repetition of part of an alternative", rep)
+			}
+
+			return p.alternative(list, rep, start)
+		}
+	}
+
+	if !grouped {
+		return p.alternative(list, goparseToken{}, start)
+	}
+
+	p.expect(")")
+	if len(list) == 0 {
+		p.fail(ErrGoparseSyntax, "empty group", start)
+	}
+	if !p.peek().isRepetition() {
+		p.fail(ErrGoparseUnsupported, "group without a repetition", start)
+	}
+	rep := p.next()
+	if p.atListItem() || p.isPunct("(") {
+		p.fail(ErrGoparseUnsupported, "group that is part of an alternative", start)
+	}
+
+	return p.alternative(list, rep, start)
+}
+
+// alternative constructs an alternative of a list of items and repetition, which is once if it is not a repetition
+// token, and parses any :ERROR option after it
+func (p *goparseParser) alternative(list []ListItem, rep goparseToken, start goparseToken) ExpressionItem {
+	n, m := 1, 1
+	if rep.isRepetition() {
+		n, m = rep.n, rep.m
+	}
+
+	alt := newLazyExpressionItem(list, n, m, rep.lazy)
+	if len(list) > 0 {
+		alt.SourceNode = alt.withSpan(start.start(), p.tokens[p.index-1].end())
+	}
+	alt.errorMessage = p.errorMessage()
+
+	return alt
+}
+
+// errorMessage parses the message of an :ERROR("message") option, returning "" if the next token is not one
+func (p *goparseParser) errorMessage() string {
+	if (p.peek().lexType != TokenOption) || (p.peek().token != errorOption) {
+		return ""
+	}
+
+	option := p.next()
+	p.expect("(")
+	if p.peek().lexType != TokenString {
+		p.fail(ErrGoparseSyntax, "expected a string after "+errorOption+"(", option)
+	}
+	message := p.next().value
+	p.expect(")")
+
+	return message
+}
+
+// checkName fails if a name has a . and is not a reference such as super.name to a rule of the grammar this one extends
+func (p *goparseParser) checkName(token goparseToken) {
+	if name := strings.TrimPrefix(token.token, SuperPrefix); strings.Contains(name, ".") {
+		p.fail(ErrGoparseSyntax, "invalid name "+token.token, token)
+	}
+}
+
+// atListItem returns true if the next token begins a list item
+func (p *goparseParser) atListItem() bool {
+	switch p.peek().lexType {
+	case TokenIdentifier, TokenString, TokenRange:
+		return true
+	}
+
+	return false
+}
+
+// parseListItem parses a list item, its options, and any repetition after it, returning false if the next token does
+// not begin a list item
+func (p *goparseParser) parseListItem() (ListItem, goparseToken, bool) {
+	if !p.atListItem() {
+		return ListItem{}, goparseToken{}, false
+	}
+
+	var (
+		token   = p.next()
+		options = p.options()
+		item    ListItem
+	)
+
+	src := token.token
+	for _, option := range options {
+		src += option.String()
+	}
+
+	switch token.lexType {
+	case TokenIdentifier:
+		p.checkName(token)
+		item = OfListItemRuleName(src, token.token, options)
+	case TokenString:
+		item = OfListItemTerminal(src, OfTerminalString(token.token, token.value), options)
+	default:
+		item = OfListItemTerminal(src, OfTerminalRuneSet(token.token, token.chars), options)
+	}
+	item.SourceNode = item.withSpan(token.start(), p.tokens[p.index-1].end())
+
+	var rep goparseToken
+	if p.peek().isRepetition() {
+		rep = p.next()
+	}
+
+	return item, rep, true
+}
@@ -0,0 +1,101 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportGoparse(t *testing.T) {
+	// Formatted source is read back unchanged
+	source := `// A list of items
+//
+// @test x, y
+list:MEMO = item list-rest;
+
+list-rest = (', ' item)*?;
+
+item = [a-z]+ | '\'' [\\\]] '\'' | digits | super.item;
+
+digits = [0-9]{1,3};
+
+nl = '\r\n':EOL | [\n\r]:EOL | [-^] | ;
+`
+	g, err := ImportGoparse(strings.NewReader(source))
+	assert.Nil(t, err)
+	assert.Equal(t, source, g.Format())
+
+	assert.Equal(t, "A list of items\n\n@test x, y", g.rules[0].Doc())
+	assert.Equal(t, []Option{OptionMemo}, g.rules[0].Options())
+	assert.True(t, g.rules[2].expr.items[1].list[1].terminal.theRange.Contains(']'))
+
+	// Positions of rules and items are kept for diagnostics
+	assert.Equal(t, 4, g.rules[0].Line())
+	assert.Equal(t, 1, g.rules[0].Position())
+	assert.Equal(t, 8, g.rules[2].expr.items[0].list[0].Line())
+	assert.Equal(t, 8, g.rules[2].expr.items[0].list[0].Position())
+
+	// Other spellings are accepted
+	g, err = ImportGoparse(strings.NewReader(`// Not a doc comment
+
+a = "x\"" b // trailing
+  | ( b ) {,2}? ; // not doc
+b = 'y' {2} ;`))
+	assert.Nil(t, err)
+	assert.Equal(t, "a = 'x\"' b | b{,2}?;\n\nb = 'y'{2};\n", g.Format())
+	assert.Equal(t, "", g.rules[0].Doc())
+	assert.Equal(t, "", g.rules[1].Doc())
+
+	// A range that begins with ^ has every rune except the ones it lists, the surrogates, and the useless control
+	// characters
+	g, err = ImportGoparse(strings.NewReader(`a = [^\]a-z];`))
+	assert.Nil(t, err)
+	assert.True(t, g.rules[0].expr.items[0].list[0].terminal.theRange.Contains('A'))
+	assert.False(t, g.rules[0].expr.items[0].list[0].terminal.theRange.Contains(']'))
+	assert.False(t, g.rules[0].expr.items[0].list[0].terminal.theRange.Contains('q'))
+	assert.False(t, g.rules[0].expr.items[0].list[0].terminal.theRange.Contains('\x00'))
+	assert.False(t, g.rules[0].expr.items[0].list[0].terminal.theRange.Contains(0xD800))
+
+	// The notation is the one the Lexer reads: /* */ comments, directives, raw strings, code point escapes, and ranges
+	// that fold case
+	g, err = ImportGoparse(strings.NewReader(`/* header */
+//goparse:universe ascii
+a = [^a] ` + "`\\d`" + ` '\x41\u{1F600}' [a-c]i ;`))
+	assert.Nil(t, err)
+	assert.Equal(t, "", g.rules[0].Doc())
+	list := g.rules[0].expr.items[0].list
+	assert.False(t, list[0].terminal.theRange.Contains('é'))
+	assert.Equal(t, `\d`, list[1].terminal.theString)
+	assert.Equal(t, "A\U0001F600", list[2].terminal.theString)
+	assert.True(t, list[3].terminal.theRange.Contains('B'))
+}
+
+func TestImportGoparseErrors(t *testing.T) {
+	for source, msg := range map[string]string{
+		`a = 'x`:          fmt.Sprintf("%s: Invalid EOF at line 1 position 6", ErrGoparseSyntax),
+		`a = ''`:          fmt.Sprintf("%s: A string cannot be empty at line 1 position 6", ErrGoparseSyntax),
+		`a = [x`:          fmt.Sprintf("%s: Invalid EOF at line 1 position 6", ErrGoparseSyntax),
+		`a = []`:          fmt.Sprintf("%s: A range cannot be empty at line 1 position 6", ErrGoparseSyntax),
+		`a = [z-a]`:       fmt.Sprintf("%s: A range cannot end before it begins at line 1 position 5", ErrGoparseSyntax),
+		`a = 'x\q'`:       fmt.Sprintf("%s: %s at line 1 position 8", ErrGoparseSyntax, lexErrors["stringesc"]),
+		`a = 'x'{2,1};`:   fmt.Sprintf("%s: %s at line 1 position 8", ErrGoparseSyntax, lexErrors["repform"]),
+		`a = 'x'{};`:      fmt.Sprintf("%s: %s at line 1 position 9", ErrGoparseSyntax, lexErrors["repform"]),
+		`a = 'x'`:         fmt.Sprintf("%s expected ';', found EOF at line 1 position 8", ErrGoparseSyntax),
+		`a 'x';`:          fmt.Sprintf("%s expected '=', found ''x'' at line 1 position 3", ErrGoparseSyntax),
+		`; a = 'x';`:      fmt.Sprintf("%s expected a rule, found ';' at line 1 position 1", ErrGoparseSyntax),
+		`a:FAST = 'x';`:   fmt.Sprintf("%s unknown option :FAST at line 1 position 2", ErrGoparseSyntax),
+		`a = 'x' # 'y';`:  fmt.Sprintf("%s: Syntax error at line 1 position 9", ErrGoparseSyntax),
+		`a = b.c;`:        fmt.Sprintf("%s invalid name b.c at line 1 position 5", ErrGoparseSyntax),
+		`a = ();`:         fmt.Sprintf("%s empty group at line 1 position 5", ErrGoparseSyntax),
+		`a = b 'x'*;`:     fmt.Sprintf("%s repetition of part of an alternative at line 1 position 10", ErrGoparseUnsupported),
+		`a = 'x'* b;`:     fmt.Sprintf("%s repetition of part of an alternative at line 1 position 8", ErrGoparseUnsupported),
+		`a = (b 'x'*)?;`:  fmt.Sprintf("%s repetition of part of an alternative at line 1 position 11", ErrGoparseUnsupported),
+		`a = (b 'x');`:    fmt.Sprintf("%s group without a repetition at line 1 position 5", ErrGoparseUnsupported),
+		`a = (b 'x')? c;`: fmt.Sprintf("%s group that is part of an alternative at line 1 position 5", ErrGoparseUnsupported),
+	} {
+		_, err := ImportGoparse(strings.NewReader(source))
+		assert.Equal(t, msg, err.Error(), source)
+	}
+}
@@ -0,0 +1,127 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GoString methods describe the contents of each node, in the form parser.Type{Field: value, ...}, where each field is
+// named after the accessor that returns it. They are for debugging output and golden tests, so they do not depend on
+// the layout of the structs, and leave out the source text, line, and position that String, Line, and Position provide.
+// Strings are quoted as by %q, ranges are written as by Format, and empty options are left out.
+
+var (
+	// Option constant names, in same order as Option constants
+	optionNames = []string{
+		"OptionAST", "OptionEOL", "OptionIndent", "OptionOutdent", "OptionPreEOL", "OptionPreIndent", "OptionPreOutdent", "OptionMemo",
+		"OptionNoMemo", "OptionIndependent", "OptionConstTime", "OptionKeyword",
+	}
+)
+
+// GoString is the name of the option constant, such as parser.OptionMemo
+func (o Option) GoString() string {
+	return "parser." + optionNames[o]
+}
+
+// optionsGoString returns the Options field of options, or "" if there are none
+func optionsGoString(options []Option) string {
+	if len(options) == 0 {
+		return ""
+	}
+
+	strs := make([]string, len(options))
+	for i, option := range options {
+		strs[i] = option.GoString()
+	}
+
+	return ", Options: [" + strings.Join(strs, " ") + "]"
+}
+
+// GoString is parser.Terminal{TerminalString: "str"} or parser.Terminal{TerminalRange: [chars]}
+func (t Terminal) GoString() string {
+	if t.IsRange() {
+		return fmt.Sprintf("parser.Terminal{TerminalRange: %s}", rangeSource(t.theRange))
+	}
+
+	return fmt.Sprintf("parser.Terminal{TerminalString: %q}", t.theString)
+}
+
+// GoString is parser.ListItem{RuleName: "name", Options: [...]} or parser.ListItem{Terminal: ..., Options: [...]}
+func (itm ListItem) GoString() string {
+	if itm.IsRuleName() {
+		return fmt.Sprintf("parser.ListItem{RuleName: %q%s}", itm.ruleName, optionsGoString(itm.options))
+	}
+
+	return fmt.Sprintf("parser.ListItem{Terminal: %#v%s}", itm.terminal, optionsGoString(itm.options))
+}
+
+// GoString is parser.ExpressionItem{Items: [...], N: n, M: m}, followed by Lazy: true if it is lazy, and
+// ErrorMessage: "message" if it has one
+func (itm ExpressionItem) GoString() string {
+	strs := make([]string, len(itm.list))
+	for i, item := range itm.list {
+		strs[i] = item.GoString()
+	}
+
+	lazy := ""
+	if itm.lazy {
+		lazy = ", Lazy: true"
+	}
+
+	return fmt.Sprintf("parser.ExpressionItem{Items: [%s], N: %d, M: %d%s%s}", strings.Join(strs, " "), itm.n, itm.m, lazy, errorMessageGoString(itm.errorMessage))
+}
+
+// GoString is parser.Expression{Items: [...]}
+func (e Expression) GoString() string {
+	strs := make([]string, len(e.items))
+	for i, item := range e.items {
+		strs[i] = item.GoString()
+	}
+
+	return fmt.Sprintf("parser.Expression{Items: [%s]}", strings.Join(strs, " "))
+}
+
+// GoString is parser.Rule{Name: "name", Options: [...], ErrorMessage: "message", Doc: "doc", Expr: ...}, where an empty
+// error message and doc are left out
+func (r Rule) GoString() string {
+	doc := ""
+	if r.doc != "" {
+		doc = fmt.Sprintf(", Doc: %q", r.doc)
+	}
+
+	return fmt.Sprintf("parser.Rule{Name: %q%s%s%s, Expr: %#v}", r.name, optionsGoString(r.options), errorMessageGoString(r.errorMessage), doc, r.expr)
+}
+
+// errorMessageGoString returns the ErrorMessage field of an error message, or "" if it is empty
+func errorMessageGoString(message string) string {
+	if message == "" {
+		return ""
+	}
+
+	return fmt.Sprintf(", ErrorMessage: %q", message)
+}
+
+// GoString is parser.Grammar{Rules: [...]}
+func (g Grammar) GoString() string {
+	strs := make([]string, len(g.rules))
+	for i, rule := range g.rules {
+		strs[i] = rule.GoString()
+	}
+
+	return fmt.Sprintf("parser.Grammar{Rules: [%s]}", strings.Join(strs, " "))
+}
+
+// GoString is parser.Diagnostic{Code: "code", Message: "message", Line: line, Position: position}
+func (d Diagnostic) GoString() string {
+	return fmt.Sprintf("parser.Diagnostic{Code: %q, Message: %q, Line: %d, Position: %d}", d.code, d.message, d.line, d.position)
+}
+
+// String is the S-expression of the node without positions, as returned by SExpr(false)
+func (n Node) String() string {
+	return n.SExpr(false)
+}
+
+// GoString is the S-expression of the node with positions, as returned by SExpr(true)
+func (n Node) GoString() string {
+	return n.SExpr(true)
+}
@@ -0,0 +1,43 @@
+package parser
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoString(t *testing.T) {
+	g := testGrammar(
+		"a:MEMO = b 'x' | ([cab])*",
+		"b = 'y'",
+	)
+	g.rules[1] = g.rules[1].WithDoc("doc").WithErrorMessage(0, "expected b").WithErrorMessage(1, "expected y")
+	g.rules[1].expr.items[0].list[0].options = []Option{OptionEOL, OptionIndent}
+
+	assert.Equal(t, "parser.OptionNoMemo", fmt.Sprintf("%#v", OptionNoMemo))
+	assert.Equal(t, ":NOMEMO", fmt.Sprintf("%v", OptionNoMemo))
+
+	assert.Equal(
+		t,
+		`parser.Grammar{Rules: [`+
+			`parser.Rule{Name: "a", Options: [parser.OptionMemo], Expr: parser.Expression{Items: [`+
+			`parser.ExpressionItem{Items: [parser.ListItem{RuleName: "b"} parser.ListItem{Terminal: parser.Terminal{TerminalString: "x"}}], N: 1, M: 1} `+
+			`parser.ExpressionItem{Items: [parser.ListItem{Terminal: parser.Terminal{TerminalRange: [a-c]}}], N: 0, M: -1}]}} `+
+			`parser.Rule{Name: "b", ErrorMessage: "expected b", Doc: "doc", Expr: parser.Expression{Items: [parser.ExpressionItem{Items: [`+
+			`parser.ListItem{Terminal: parser.Terminal{TerminalString: "y"}, Options: [parser.OptionEOL parser.OptionIndent]}], N: 1, M: 1, ErrorMessage: "expected y"}]}}]}`,
+		fmt.Sprintf("%#v", g),
+	)
+
+	// String is the source
+	assert.Equal(t, "b 'x'", fmt.Sprintf("%v", g.rules[0].expr.items[0]))
+
+	assert.Equal(
+		t,
+		`parser.Diagnostic{Code: "undefrule", Message: "Undefined rule b", Line: 1, Position: 2}`,
+		fmt.Sprintf("%#v", newDiagnostic(DiagUndefinedRule, "b", OfSourceNodeAt("", 1, 2))),
+	)
+
+	assert.Equal(t, `(c (b "y"))`, fmt.Sprintf("%v", testTree().children[1]))
+	assert.Equal(t, `(c@1-2 (b@1-2 "y"@1-2))`, fmt.Sprintf("%#v", testTree().children[1]))
+}
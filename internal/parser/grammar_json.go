@@ -0,0 +1,291 @@
+//go:build !tinygo
+// +build !tinygo
+
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Grammar JSON error message constants
+const (
+	ErrJSONUnknownOption = "Unknown option"
+	ErrJSONTerminal      = "A terminal must have exactly one of a non-empty string or range"
+	ErrJSONVersion       = "Unsupported grammar format version"
+)
+
+// GrammarJSONVersion is the version of the JSON grammar format written by Grammar.MarshalJSON.
+// It is incremented whenever the format changes, and Grammar.UnmarshalJSON migrates older versions to it.
+//
+// Versions:
+// - 0: no version field, otherwise the same as version 1
+// - 1: first versioned format
+// - 2: the span of source of each node, with offset, end, endLine, and endPosition fields
+// - 3: the :ERROR option of rules and alternatives, with error fields
+const GrammarJSONVersion = 3
+
+// JSON forms of the grammar nodes, where each node has the source, line, position, and span of its SourceNode
+type (
+	sourceJSON struct {
+		Source      string `json:"source,omitempty"`
+		Line        int    `json:"line,omitempty"`
+		Position    int    `json:"position,omitempty"`
+		Offset      int    `json:"offset,omitempty"`
+		End         int    `json:"end,omitempty"`
+		EndLine     int    `json:"endLine,omitempty"`
+		EndPosition int    `json:"endPosition,omitempty"`
+	}
+
+	terminalJSON struct {
+		sourceJSON
+		String string `json:"string,omitempty"`
+		Range  string `json:"range,omitempty"`
+	}
+
+	listItemJSON struct {
+		sourceJSON
+		Rule     string    `json:"rule,omitempty"`
+		Terminal *Terminal `json:"terminal,omitempty"`
+		Options  []string  `json:"options,omitempty"`
+	}
+
+	expressionItemJSON struct {
+		sourceJSON
+		List  []ListItem `json:"list"`
+		N     int        `json:"n"`
+		M     int        `json:"m"`
+		Lazy  bool       `json:"lazy,omitempty"`
+		Error string     `json:"error,omitempty"`
+	}
+
+	expressionJSON struct {
+		sourceJSON
+		Items []ExpressionItem `json:"items"`
+	}
+
+	ruleJSON struct {
+		sourceJSON
+		Name    string      `json:"name"`
+		Options []string    `json:"options,omitempty"`
+		Doc     string      `json:"doc,omitempty"`
+		Error   string      `json:"error,omitempty"`
+		Limits  *RuleLimits `json:"limits,omitempty"`
+		Expr    Expression  `json:"expr"`
+	}
+
+	grammarJSON struct {
+		Version int `json:"version"`
+		sourceJSON
+		Rules []Rule `json:"rules"`
+	}
+)
+
+// toJSON returns the JSON form of a SourceNode
+func (s SourceNode) toJSON() sourceJSON {
+	return sourceJSON{
+		Source:      s.sourceString,
+		Line:        s.line,
+		Position:    s.position,
+		Offset:      s.offset,
+		End:         s.end,
+		EndLine:     s.endLine,
+		EndPosition: s.endPosition,
+	}
+}
+
+// fromJSON returns the SourceNode of a JSON form
+func (s sourceJSON) fromJSON() SourceNode {
+	return OfSourceNodeSpan(
+		s.Source,
+		SourcePosition{Offset: s.Offset, Line: s.Line, Position: s.Position},
+		SourcePosition{Offset: s.End, Line: s.EndLine, Position: s.EndPosition},
+	)
+}
+
+// optionsToJSON returns the source form of each option
+func optionsToJSON(options []Option) []string {
+	var result []string
+	for _, option := range options {
+		result = append(result, option.String())
+	}
+
+	return result
+}
+
+// optionsFromJSON returns the option of each source form
+func optionsFromJSON(strs []string) ([]Option, error) {
+	var result []Option
+
+nextOption:
+	for _, str := range strs {
+		for i, optionString := range optionStrings {
+			if str == optionString {
+				result = append(result, Option(i))
+				continue nextOption
+			}
+		}
+
+		return nil, fmt.Errorf("%s: %s", ErrJSONUnknownOption, str)
+	}
+
+	return result, nil
+}
+
+// MarshalJSON is the json.Marshaler interface, where a range is a string of its characters in order
+func (t Terminal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(terminalJSON{sourceJSON: t.toJSON(), String: t.theString, Range: string(t.theRange.Runes())})
+}
+
+// UnmarshalJSON is the json.Unmarshaler interface
+func (t *Terminal) UnmarshalJSON(data []byte) error {
+	var tj terminalJSON
+	if err := json.Unmarshal(data, &tj); err != nil {
+		return err
+	}
+
+	switch {
+	case (tj.String != "") && (tj.Range == ""):
+		*t = OfTerminalString(tj.Source, tj.String)
+	case (tj.String == "") && (tj.Range != ""):
+		*t = OfTerminalRuneSet(tj.Source, OfRuneSetRunes([]rune(tj.Range)...))
+	default:
+		return fmt.Errorf("%s", ErrJSONTerminal)
+	}
+	t.SourceNode = tj.fromJSON()
+
+	return nil
+}
+
+// MarshalJSON is the json.Marshaler interface, where a rule name item has a rule field, and a terminal item has a terminal field
+func (itm ListItem) MarshalJSON() ([]byte, error) {
+	lj := listItemJSON{sourceJSON: itm.toJSON(), Rule: itm.ruleName, Options: optionsToJSON(itm.options)}
+	if itm.IsTerminal() {
+		lj.Terminal = &itm.terminal
+	}
+
+	return json.Marshal(lj)
+}
+
+// UnmarshalJSON is the json.Unmarshaler interface
+func (itm *ListItem) UnmarshalJSON(data []byte) error {
+	var lj listItemJSON
+	if err := json.Unmarshal(data, &lj); err != nil {
+		return err
+	}
+
+	options, err := optionsFromJSON(lj.Options)
+	if err != nil {
+		return err
+	}
+
+	if lj.Terminal == nil {
+		*itm = OfListItemRuleName(lj.Source, lj.Rule, options)
+	} else {
+		*itm = OfListItemTerminal(lj.Source, *lj.Terminal, options)
+	}
+	itm.SourceNode = lj.fromJSON()
+
+	return nil
+}
+
+// MarshalJSON is the json.Marshaler interface
+func (itm ExpressionItem) MarshalJSON() ([]byte, error) {
+	return json.Marshal(expressionItemJSON{sourceJSON: itm.toJSON(), List: itm.list, N: itm.n, M: itm.m, Lazy: itm.lazy, Error: itm.errorMessage})
+}
+
+// UnmarshalJSON is the json.Unmarshaler interface
+func (itm *ExpressionItem) UnmarshalJSON(data []byte) error {
+	var ej expressionItemJSON
+	if err := json.Unmarshal(data, &ej); err != nil {
+		return err
+	}
+
+	*itm = OfExpressionItem(ej.Source, ej.List, ej.N, ej.M)
+	itm.SourceNode, itm.lazy, itm.errorMessage = ej.fromJSON(), ej.Lazy, ej.Error
+
+	return nil
+}
+
+// MarshalJSON is the json.Marshaler interface
+func (e Expression) MarshalJSON() ([]byte, error) {
+	return json.Marshal(expressionJSON{sourceJSON: e.toJSON(), Items: e.items})
+}
+
+// UnmarshalJSON is the json.Unmarshaler interface
+func (e *Expression) UnmarshalJSON(data []byte) error {
+	var ej expressionJSON
+	if err := json.Unmarshal(data, &ej); err != nil {
+		return err
+	}
+
+	*e = OfExpression(ej.Source, ej.Items)
+	e.SourceNode = ej.fromJSON()
+
+	return nil
+}
+
+// MarshalJSON is the json.Marshaler interface
+func (r Rule) MarshalJSON() ([]byte, error) {
+	rj := ruleJSON{sourceJSON: r.toJSON(), Name: r.name, Options: optionsToJSON(r.options), Doc: r.doc, Error: r.errorMessage, Expr: r.expr}
+	if r.limits != (RuleLimits{}) {
+		rj.Limits = &r.limits
+	}
+
+	return json.Marshal(rj)
+}
+
+// UnmarshalJSON is the json.Unmarshaler interface
+func (r *Rule) UnmarshalJSON(data []byte) error {
+	var rj ruleJSON
+	if err := json.Unmarshal(data, &rj); err != nil {
+		return err
+	}
+
+	options, err := optionsFromJSON(rj.Options)
+	if err != nil {
+		return err
+	}
+
+	*r = OfRuleOptions(rj.Source, rj.Name, options, rj.Expr).WithDoc(rj.Doc)
+	if rj.Limits != nil {
+		*r = r.WithLimits(*rj.Limits)
+	}
+	r.SourceNode, r.errorMessage = rj.fromJSON(), rj.Error
+
+	return nil
+}
+
+// MarshalJSON is the json.Marshaler interface, which includes the format version GrammarJSONVersion
+func (g Grammar) MarshalJSON() ([]byte, error) {
+	return json.Marshal(grammarJSON{Version: GrammarJSONVersion, sourceJSON: g.toJSON(), Rules: g.rules})
+}
+
+// UnmarshalJSON is the json.Unmarshaler interface.
+// A grammar written in an older format version is migrated to the current version.
+// Returns an error if the grammar was written in a newer version, rather than risk misreading it.
+func (g *Grammar) UnmarshalJSON(data []byte) error {
+	// Read the version first, as the rest of the format depends on it
+	var version struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &version); err != nil {
+		return err
+	}
+
+	if (version.Version < 0) || (version.Version > GrammarJSONVersion) {
+		return fmt.Errorf("%s %d, the newest supported version is %d", ErrJSONVersion, version.Version, GrammarJSONVersion)
+	}
+
+	// Version 0 only lacks the version field, version 1 the span fields, and version 2 the error fields, so they are read
+	// the same way as version 3
+	var gj grammarJSON
+	if err := json.Unmarshal(data, &gj); err != nil {
+		return err
+	}
+
+	*g = OfGrammar(gj.Source, gj.Rules)
+	g.SourceNode = gj.fromJSON()
+
+	return nil
+}
@@ -0,0 +1,104 @@
+//go:build !tinygo
+// +build !tinygo
+
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGrammarJSON(t *testing.T) {
+	g := testGrammar(
+		"a:MEMO = b 'x' | ([ba])*",
+		"b = 'y'",
+	)
+	g.rules[0] = g.rules[0].WithDoc("doc")
+	g.rules[1] = g.rules[1].WithLimits(RuleLimits{MaxLength: 3})
+	g.rules[1].SourceNode = OfSourceNodeSpan(g.rules[1].sourceString, SourcePosition{Offset: 30, Line: 2, Position: 1}, SourcePosition{Offset: 37, Line: 2, Position: 8})
+	g.rules[1].expr.items[0].list[0].options = []Option{OptionAST}
+
+	data, err := json.Marshal(g)
+	assert.Nil(t, err)
+
+	var (
+		b = `{"source":"b = 'y'","line":2,"position":1,"offset":30,"end":37,"endLine":2,"endPosition":8,"name":"b","limits":{"maxLength":3},"expr":{"source":"'y'","items":[{"source":"'y'","list":[{"source":"'y'",` +
+			`"terminal":{"source":"'y'","string":"y"},"options":[":AST"]}],"n":1,"m":1}]}}`
+		a = `{"source":"a:MEMO = b 'x' | ([ba])*","name":"a","options":[":MEMO"],"doc":"doc","expr":{"source":"b 'x' | ([ba])*","items":[` +
+			`{"source":"b 'x'","list":[{"source":"b","rule":"b"},{"source":"'x'","terminal":{"source":"'x'","string":"x"}}],"n":1,"m":1},` +
+			`{"source":"([ba])*","list":[{"source":"[ba]","terminal":{"source":"[ba]","range":"ab"}}],"n":0,"m":-1}]}}`
+	)
+	assert.Equal(t, `{"version":3,"source":"`+jsonEscape(g.String())+`","rules":[`+a+","+b+"]}", string(data))
+
+	var loaded Grammar
+	assert.Nil(t, json.Unmarshal(data, &loaded))
+	assert.Equal(t, g, loaded)
+
+	// Lazy repetitions are kept
+	g = testGrammar("a = ('x')+?")
+	data, err = json.Marshal(g)
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), `"n":1,"m":-1,"lazy":true`)
+	loaded = Grammar{}
+	assert.Nil(t, json.Unmarshal(data, &loaded))
+	assert.Equal(t, g, loaded)
+
+	// Error messages are kept
+	g = testGrammar("a = 'x' | 'y'")
+	g.rules[0] = g.rules[0].WithErrorMessage(0, "expected a").WithErrorMessage(2, "expected y")
+	data, err = json.Marshal(g)
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), `"name":"a","error":"expected a"`)
+	assert.Contains(t, string(data), `"n":1,"m":1,"error":"expected y"`)
+	loaded = Grammar{}
+	assert.Nil(t, json.Unmarshal(data, &loaded))
+	assert.Equal(t, g, loaded)
+}
+
+func TestGrammarJSONVersion(t *testing.T) {
+	// Version 0 has no version field
+	var g Grammar
+	assert.Nil(t, json.Unmarshal([]byte(`{"rules":[{"name":"a","expr":{"items":[{"list":[{"rule":"a"}],"n":1,"m":1}]}}]}`), &g))
+	assert.Equal(t, "a", g.rules[0].name)
+
+	// Version 1 has no spans
+	assert.Nil(t, json.Unmarshal([]byte(`{"version":1,"rules":[{"name":"a","line":1,"position":1,"expr":{"items":[{"list":[{"rule":"a"}],"n":1,"m":1}]}}]}`), &g))
+	assert.Equal(t, OfSourceNodeAt("", 1, 1), g.rules[0].SourceNode)
+
+	// Version 2 has no error messages, which are read from @error lines of the doc
+	assert.Nil(t, json.Unmarshal([]byte(`{"version":2,"rules":[{"name":"a","doc":"@error expected a","expr":{"items":[{"list":[{"rule":"a"}],"n":1,"m":1}]}}]}`), &g))
+	assert.Equal(t, "expected a", g.rules[0].ErrorMessage(0))
+
+	assert.Equal(
+		t,
+		fmt.Errorf("%s 4, the newest supported version is 3", ErrJSONVersion),
+		json.Unmarshal([]byte(`{"version":4,"rules":[]}`), &g),
+	)
+	assert.Equal(
+		t,
+		fmt.Errorf("%s -1, the newest supported version is 3", ErrJSONVersion),
+		json.Unmarshal([]byte(`{"version":-1,"rules":[]}`), &g),
+	)
+}
+
+func TestGrammarJSONErrors(t *testing.T) {
+	var itm ListItem
+	assert.Equal(
+		t,
+		fmt.Errorf("%s: :FOO", ErrJSONUnknownOption),
+		json.Unmarshal([]byte(`{"rule":"a","options":[":FOO"]}`), &itm),
+	)
+
+	var term Terminal
+	assert.Equal(t, fmt.Errorf("%s", ErrJSONTerminal), json.Unmarshal([]byte(`{"string":"a","range":"b"}`), &term))
+	assert.Equal(t, fmt.Errorf("%s", ErrJSONTerminal), json.Unmarshal([]byte(`{}`), &term))
+}
+
+// jsonEscape returns a string escaped as it is inside a JSON string
+func jsonEscape(str string) string {
+	data, _ := json.Marshal(str)
+	return string(data[1 : len(data)-1])
+}
@@ -0,0 +1,138 @@
+package parser
+
+import (
+	"fmt"
+)
+
+// Indentation error message constants
+const (
+	ErrIndentWhitespace = "The line is indented with whitespace that is not allowed"
+	ErrIndentMismatch   = "The line is outdented to a column of no enclosing indentation"
+	ErrIndentReserved   = "The input contains a rune reserved for indentation tokens"
+)
+
+// Names of the rules that match the tokens of indentation
+const (
+	IndentRule  = "INDENT"
+	OutdentRule = "OUTDENT"
+)
+
+// Runes of the tokens of indentation, which are Unicode noncharacters that are reserved for internal use
+const (
+	indentRune  = '\uFDD0'
+	outdentRune = '\uFDD1'
+)
+
+// IndentWhitespace is the whitespace a line can be indented with
+type IndentWhitespace uint
+
+// IndentWhitespace constants
+const (
+	// IndentSpacesAndTabs allows spaces and tabs, which may be mixed
+	IndentSpacesAndTabs IndentWhitespace = iota
+	// IndentSpaces allows only spaces
+	IndentSpaces
+	// IndentTabs allows only tabs
+	IndentTabs
+)
+
+// IndentOptions are the options of indentation sensitive parsing, where the engine tracks the indentation of each line,
+// as in Python and YAML, and inserts an INDENT token where a line is indented further than the line before it, and an
+// OUTDENT token for each indentation that a line returns from.
+type IndentOptions struct {
+	// TabWidth is the number of columns between tab stops, where a tab indents to the next tab stop, 8 if <= 0
+	TabWidth int
+	// Whitespace is the whitespace a line can be indented with, spaces and tabs by default
+	Whitespace IndentWhitespace
+}
+
+// indentTokens returns the option tokens of indentation, where each item with the :INDENT or :OUTDENT option is
+// followed by the INDENT or OUTDENT rule, and each item with the :PREINDENT or :PREOUTDENT option is preceded by it
+func indentTokens() []optionToken {
+	return []optionToken{
+		{OptionIndent, OptionPreIndent, IndentRule, []string{string(indentRune)}},
+		{OptionOutdent, OptionPreOutdent, OutdentRule, []string{string(outdentRune)}},
+	}
+}
+
+// indent returns the input with an INDENT token before the first rune of each line that is indented further than the
+// line before it, and an OUTDENT token there for each indentation the line returns from, where blank lines are ignored.
+// An OUTDENT token is added to the end of the input for each indentation left.
+// Returns an error at a position of the conventions of positions if a line is indented with whitespace that is not
+// allowed, or outdented to a column between indentations, or the input contains the runes of the tokens.
+func (o IndentOptions) indent(input []rune, positions PositionOptions) (*mappedInput, error) {
+	var (
+		result   = &mappedInput{runes: make([]rune, 0, len(input)), offsets: make([]int, 0, len(input)+1)}
+		columns  = []int{0}
+		tabWidth = o.TabWidth
+		token    = func(char rune, offset int) {
+			result.runes = append(result.runes, char)
+			result.offsets = append(result.offsets, offset)
+		}
+	)
+	if tabWidth <= 0 {
+		tabWidth = 8
+	}
+
+	for offset := 0; offset < len(input); {
+		// Measure the indentation of the line
+		var (
+			start  = offset
+			column int
+		)
+		for ; (offset < len(input)) && ((input[offset] == ' ') || (input[offset] == '\t')); offset++ {
+			if ((input[offset] == '\t') && (o.Whitespace == IndentSpaces)) || ((input[offset] == ' ') && (o.Whitespace == IndentTabs)) {
+				line, position := positions.linePosition(input, offset)
+				return nil, fmt.Errorf("%s at line %d position %d", ErrIndentWhitespace, line, position)
+			}
+
+			if input[offset] == '\t' {
+				column += tabWidth - column%tabWidth
+			} else {
+				column++
+			}
+		}
+		for i := start; i < offset; i++ {
+			token(input[i], i)
+		}
+
+		// Blank lines have no indentation
+		if blank := (offset == len(input)) || (input[offset] == '\n') || (input[offset] == '\r'); !blank {
+			switch top := columns[len(columns)-1]; {
+			case column > top:
+				columns = append(columns, column)
+				token(indentRune, offset)
+			case column < top:
+				for column < columns[len(columns)-1] {
+					columns = columns[:len(columns)-1]
+					token(outdentRune, offset)
+				}
+				if column != columns[len(columns)-1] {
+					line, position := positions.linePosition(input, offset)
+					return nil, fmt.Errorf("%s at line %d position %d", ErrIndentMismatch, line, position)
+				}
+			}
+		}
+
+		// Copy the rest of the line
+		for ; offset < len(input); offset++ {
+			if (input[offset] == indentRune) || (input[offset] == outdentRune) {
+				line, position := positions.linePosition(input, offset)
+				return nil, fmt.Errorf("%s at line %d position %d", ErrIndentReserved, line, position)
+			}
+
+			token(input[offset], offset)
+			if input[offset] == '\n' {
+				offset++
+				break
+			}
+		}
+	}
+
+	for range columns[1:] {
+		token(outdentRune, len(input))
+	}
+	result.offsets = append(result.offsets, len(input))
+
+	return result, nil
+}
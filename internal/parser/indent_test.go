@@ -0,0 +1,144 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testIndentGrammar returns a grammar of statements, where a block is a name and colon followed by indented statements
+func testIndentGrammar() Grammar {
+	g := testGrammar(
+		"file = (stmt)+",
+		"stmt = block | simple",
+		"block = name ':' nl body",
+		"body = (stmt)+",
+		"simple = name nl",
+		"name = ([abcd])+",
+		"nl = ([n])+",
+		"ws = ([s])+",
+	)
+	g.rules[2].expr.items[0].list[2].options = []Option{OptionIndent}
+	g.rules[2].expr.items[0].list[3].options = []Option{OptionOutdent}
+	g.rules[6].expr.items[0].list[0].terminal = OfTerminalRange("[\\n]", map[rune]bool{'\n': true})
+	g.rules[7].expr.items[0].list[0].terminal = OfTerminalRange("[ \\t]", map[rune]bool{' ': true, '\t': true})
+
+	return g
+}
+
+func TestIndentTokens(t *testing.T) {
+	g := testIndentGrammar().withOptionTokens(indentTokens())
+	assert.Equal(t, "name ':' nl:INDENT INDENT body:OUTDENT OUTDENT", formatAlternative(rulesByName(g.rules)["block"].expr.items[0]))
+	assert.Equal(t, []string{IndentRule, OutdentRule}, []string{g.rules[8].name, g.rules[9].name})
+
+	// Rules the grammar defines are not added
+	g = testGrammar("a = INDENT 'a'", "INDENT = 'x'").withOptionTokens(indentTokens())
+	assert.Equal(t, "a = INDENT 'a'\nINDENT = 'x'\nOUTDENT = '\uFDD1'", g.String())
+}
+
+func TestIndent(t *testing.T) {
+	engine, err := NewEngine(testIndentGrammar(), ParseOptions{Trivia: "ws", Indent: &IndentOptions{}})
+	assert.Nil(t, err)
+
+	// Indentation and blank lines
+	source := "a\nb:\n  c\n\n  d:\n    a\nb\n"
+	node, err := engine.Parse(strings.NewReader(source))
+	assert.Nil(t, err)
+	assert.Equal(t, source, node.Text())
+
+	block := node.Children()[1].Children()[0]
+	assert.Equal(t, "block", block.Rule())
+	assert.Equal(t, "b:\n  c\n\n  d:\n    a\n", block.Text())
+
+	// The INDENT rule matches the trivia before the token, which matches no text
+	indent := block.Children()[3]
+	assert.Equal(t, IndentRule, indent.Rule())
+	assert.Equal(t, "  ", indent.Text())
+	token := indent.Children()[0]
+	assert.Equal(t, "", token.Text())
+	start, end := token.Span()
+	assert.Equal(t, Position{Offset: 7, ByteOffset: 7, Line: 3, Column: 3}, start)
+	assert.Equal(t, start, end)
+
+	// Indentations left at the end of the input are outdented
+	_, err = engine.Parse(strings.NewReader("a:\n  b:\n    c\n"))
+	assert.Nil(t, err)
+
+	// A missing indentation is an error
+	_, err = engine.Parse(strings.NewReader("a:\nb\n"))
+	parseError := err.(ParseError)
+	assert.Equal(t, 2, parseError.Line())
+	assert.Equal(t, 1, parseError.Position())
+	assert.Contains(t, parseError.Expected(), IndentRule)
+
+	// An outdent must return to an enclosing indentation
+	_, err = engine.Parse(strings.NewReader("a:\n    b\n  c\n"))
+	assert.Equal(t, fmt.Errorf("%s at line 3 position 3", ErrIndentMismatch), err)
+
+	// The tokens cannot be in the input
+	_, err = engine.Parse(strings.NewReader("a\n\uFDD0\n"))
+	assert.Equal(t, fmt.Errorf("%s at line 2 position 1", ErrIndentReserved), err)
+
+	_, _, err = engine.ParseOne(strings.NewReader("a\n"))
+	assert.Equal(t, fmt.Errorf("%s", ErrParseOneIndent), err)
+}
+
+func TestIndentWhitespace(t *testing.T) {
+	source := "a:\n\tb\n        c\n"
+
+	// A tab indents to the next tab stop
+	engine, err := NewEngine(testIndentGrammar(), ParseOptions{Trivia: "ws", Indent: &IndentOptions{}})
+	assert.Nil(t, err)
+	_, err = engine.Parse(strings.NewReader(source))
+	assert.Nil(t, err)
+
+	engine, err = NewEngine(testIndentGrammar(), ParseOptions{Trivia: "ws", Indent: &IndentOptions{TabWidth: 4}})
+	assert.Nil(t, err)
+	_, err = engine.Parse(strings.NewReader(source))
+	assert.Equal(t, 3, err.(ParseError).Line())
+
+	// Only the allowed whitespace can indent a line
+	engine, err = NewEngine(testIndentGrammar(), ParseOptions{Trivia: "ws", Indent: &IndentOptions{Whitespace: IndentSpaces}})
+	assert.Nil(t, err)
+	_, err = engine.Parse(strings.NewReader(source))
+	assert.Equal(t, fmt.Errorf("%s at line 2 position 1", ErrIndentWhitespace), err)
+
+	engine, err = NewEngine(testIndentGrammar(), ParseOptions{Trivia: "ws", Indent: &IndentOptions{Whitespace: IndentTabs}})
+	assert.Nil(t, err)
+	_, err = engine.Parse(strings.NewReader(source))
+	assert.Equal(t, fmt.Errorf("%s at line 3 position 1", ErrIndentWhitespace), err)
+}
+
+func TestIndentForest(t *testing.T) {
+	g := testGrammar(
+		"file = (stmt)+",
+		"stmt = block | simple",
+		"block = name ':' nl INDENT body OUTDENT",
+		"body = (stmt)+",
+		"simple = name nl",
+		"name = ([abcd])+",
+		"nl = eol pad",
+		"eol = ([n])+",
+		"pad = (space)*",
+		"space = [s]",
+	)
+	g.rules[7].expr.items[0].list[0].terminal = OfTerminalRange("[\\n]", map[rune]bool{'\n': true})
+	g.rules[9].expr.items[0].list[0].terminal = OfTerminalRange("[ ]", map[rune]bool{' ': true})
+
+	engine, err := NewEngine(g, ParseOptions{Backend: BackendEarley, Indent: &IndentOptions{}})
+	assert.Nil(t, err)
+
+	source := "a:\n  b\n  c\nd\n"
+	forest, err := engine.ParseForest(OfParseContext(), strings.NewReader(source))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, forest.Count(0))
+	forest.Trees(func(tree Node) bool {
+		assert.Equal(t, source, tree.Text())
+		return true
+	})
+
+	_, err = engine.ParseForest(OfParseContext(), strings.NewReader("a:\nb\n"))
+	assert.Equal(t, 2, err.(ParseError).Line())
+}
@@ -0,0 +1,228 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// Inheritance error message constants
+const (
+	ErrExtendsSyntax   = "An @extends directive must name a grammar in double quotes, alone on its line"
+	ErrExtendsResolver = "The grammar extends another grammar, which needs a Resolver to read"
+	ErrExtendsCycle    = "The grammar extends itself"
+	ErrSuperUndefined  = "A super reference refers to a rule the base grammar does not define"
+)
+
+const (
+	// ExtendsDirective begins grammar source that extends another grammar, such as @extends "base.g"
+	ExtendsDirective = "@extends"
+	// SuperPrefix begins a reference to a rule of the grammar being extended, such as super.expr
+	SuperPrefix = "super."
+)
+
+// Extend returns the composite of the grammar extending a base grammar, which has the rules of the base grammar in
+// order, where each rule of the grammar replaces the base rule of the same name, followed by the rules of the grammar
+// that the base does not define.
+//
+// A rule that the grammar overrides is overridden everywhere, including references to it from base rules, so that a
+// family of dialects can share a core grammar and each change only the rules that differ. A rule of the grammar can
+// refer to the base rule that it or another rule overrides as super.name, where the base rule is added after the other
+// rules named name-super, or name if it is not overridden.
+//
+// Returns an error if a super reference names a rule the base does not define.
+func (g Grammar) Extend(base Grammar) (Grammar, error) {
+	var (
+		baseRules = rulesByName(base.rules)
+		derived   = rulesByName(g.rules)
+		rules     = append([]Rule(nil), base.rules...)
+		index     = map[string]int{}
+		names     = map[string]bool{}
+		// name of the rule of each base rule that a super reference refers to
+		supers     = map[string]string{}
+		superRules []Rule
+	)
+	for i := len(rules) - 1; i >= 0; i-- {
+		index[rules[i].name] = i
+		names[rules[i].name] = true
+	}
+	for _, rule := range g.rules {
+		names[rule.name] = true
+	}
+
+	superName := func(ref string) (string, error) {
+		name := strings.TrimPrefix(ref, SuperPrefix)
+		baseRule, defined := baseRules[name]
+		if !defined {
+			return "", fmt.Errorf("%s: %s", ErrSuperUndefined, ref)
+		}
+
+		if _, overridden := derived[name]; !overridden {
+			return name, nil
+		}
+
+		if _, haveIt := supers[name]; !haveIt {
+			supers[name] = uniqueRuleName(name+"-super", names)
+			superRule := newRule(supers[name], baseRule.options, baseRule.expr)
+			superRule.doc, superRule.limits = baseRule.doc, baseRule.limits
+			superRule.file = baseRule.file
+			superRules = append(superRules, superRule)
+		}
+
+		return supers[name], nil
+	}
+
+	for _, rule := range g.rules {
+		var (
+			items   = make([]ExpressionItem, len(rule.expr.items))
+			changed bool
+		)
+		for a, alt := range rule.expr.items {
+			list := make([]ListItem, len(alt.list))
+			for i, item := range alt.list {
+				list[i] = item
+				if item.IsRuleName() && strings.HasPrefix(item.ruleName, SuperPrefix) {
+					name, err := superName(item.ruleName)
+					if err != nil {
+						return Grammar{}, err
+					}
+
+					list[i] = OfListItemRuleName(name, name, item.options)
+					changed = true
+				}
+			}
+			items[a] = alt.withList(list)
+		}
+
+		if changed {
+			resolved := newRule(rule.name, rule.options, newExpression(items))
+			resolved.doc, resolved.limits = rule.doc, rule.limits
+			rule = resolved
+		}
+
+		if i, defined := index[rule.name]; defined {
+			rules[i] = rule
+		} else {
+			index[rule.name] = len(rules)
+			rules = append(rules, rule)
+		}
+	}
+
+	return newGrammar(append(rules, superRules...)), nil
+}
+
+// inFile returns the grammar with every node that has no file read from the named file, so that the nodes of a grammar
+// that the base extends keep the name of their own file
+func (g Grammar) inFile(file string) Grammar {
+	rules := make([]Rule, len(g.rules))
+	for r, rule := range g.rules {
+		items := make([]ExpressionItem, len(rule.expr.items))
+		for a, alt := range rule.expr.items {
+			list := make([]ListItem, len(alt.list))
+			for i, item := range alt.list {
+				item.SourceNode = item.SourceNode.inFile(file)
+				item.terminal.SourceNode = item.terminal.SourceNode.inFile(file)
+				list[i] = item
+			}
+			alt.list = list
+			alt.SourceNode = alt.SourceNode.inFile(file)
+			items[a] = alt
+		}
+		rule.expr.items = items
+		rule.expr.SourceNode = rule.expr.SourceNode.inFile(file)
+		rule.SourceNode = rule.SourceNode.inFile(file)
+		rules[r] = rule
+	}
+
+	result := g
+	result.rules = rules
+	result.SourceNode = g.SourceNode.inFile(file)
+	return result
+}
+
+// extendsDirective returns the name of the grammar that the source extends, and the source with the @extends directive
+// replaced by spaces so that the lines and positions of the rest are the same, or "" and the source if it has none.
+// The directive can only be preceded by whitespace.
+func extendsDirective(data []byte) (string, []byte, error) {
+	start := len(data) - len(bytes.TrimLeftFunc(data, unicode.IsSpace))
+	if !bytes.HasPrefix(data[start:], []byte(ExtendsDirective)) {
+		return "", data, nil
+	}
+
+	var (
+		line    = data[start:]
+		lineEnd = bytes.IndexByte(line, '\n')
+	)
+	if lineEnd >= 0 {
+		line = line[:lineEnd]
+	}
+
+	name := strings.TrimSpace(string(line[len(ExtendsDirective):]))
+	if (len(name) < 3) || !strings.HasPrefix(name, `"`) || (strings.Index(name[1:], `"`) != len(name)-2) {
+		return "", nil, fmt.Errorf("%s: %s", ErrExtendsSyntax, line)
+	}
+
+	result := append([]byte(nil), data...)
+	for i := start; i < start+len(line); i++ {
+		result[i] = ' '
+	}
+
+	return name[1 : len(name)-1], result, nil
+}
+
+// isSuperDot returns true if the input has a dot at offset followed by a rune that can begin a name, after the name super
+func isSuperDot(input []rune, offset int, isNameStart func(rune) bool) bool {
+	return (offset+1 < len(input)) && (input[offset] == '.') && isNameStart(input[offset+1])
+}
+
+// importExtended reads grammar source like importDialect, of at most max bytes each where max <= 0 is no maximum.
+// A byte order mark at the beginning of the source is removed, and UTF-16 source is transcoded into UTF8.
+// If the source begins with an @extends directive, the base grammar it names is read from the Resolver option and
+// imported the same way, and the grammar extending it is returned.
+// The names of the grammars that are imported or extended are also returned, where an extended grammar must be allowed,
+// unless allowed is nil.
+func (o CompileOptions) importExtended(source io.Reader, max int, allowed map[string]bool, extending map[string]bool) (Grammar, []string, error) {
+	data, err := readInput(applyBOM(source), max)
+	if err != nil {
+		return Grammar{}, nil, err
+	}
+
+	baseName, data, err := extendsDirective(data)
+	if err != nil {
+		return Grammar{}, nil, err
+	}
+
+	g, imports, err := importDialect(bytes.NewReader(data), o.Dialect, o.Positions)
+	if (err != nil) || (baseName == "") {
+		return g, imports, err
+	}
+
+	switch {
+	case (allowed != nil) && !allowed[baseName]:
+		return Grammar{}, nil, fmt.Errorf("%s: %s", ErrSandboxImport, baseName)
+	case extending[baseName]:
+		return Grammar{}, nil, fmt.Errorf("%s: %s", ErrExtendsCycle, baseName)
+	case o.Resolver == nil:
+		return Grammar{}, nil, fmt.Errorf("%s: %s", ErrExtendsResolver, baseName)
+	}
+	extending[baseName] = true
+
+	baseSource, err := o.Resolver(baseName)
+	if err != nil {
+		return Grammar{}, nil, err
+	}
+
+	base, baseImports, err := o.importExtended(baseSource, max, allowed, extending)
+	if err != nil {
+		return Grammar{}, nil, err
+	}
+
+	g, err = g.Extend(base.inFile(baseName))
+	if err != nil {
+		return Grammar{}, nil, err
+	}
+
+	return g, append(imports, baseImports...), nil
+}
@@ -0,0 +1,169 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtend(t *testing.T) {
+	base := testGrammar(
+		"expr = term | term '+' expr",
+		"term = ident | '(' expr ')'",
+		"ident = ([a])+",
+	)
+	base.rules[2] = base.rules[2].WithDoc("identifiers")
+
+	// Overrides replace base rules in place, and are used by base rules
+	g, err := testGrammar(
+		"number = ([0123])+",
+		"ident = ([xyz])+",
+	).Extend(base)
+	assert.Nil(t, err)
+	assert.Equal(t, "expr = term | term '+' expr\nterm = ident | '(' expr ')'\nident = ([xyz])+\nnumber = ([0123])+", g.String())
+
+	// Super references refer to the base rules
+	g, err = testGrammar(
+		"term = super.term | number",
+		"number = ([0123])+",
+		"ident = super.ident | '$' super.ident",
+		"expr = super.expr",
+	).Extend(base)
+	assert.Nil(t, err)
+	assert.Equal(t, "expr = expr-super\n"+
+		"term = term-super | number\n"+
+		"ident = ident-super | '$' ident-super\n"+
+		"number = ([0123])+\n"+
+		"term-super = ident | '(' expr ')'\n"+
+		"ident-super = ([a])+\n"+
+		"expr-super = term | term '+' expr", g.String())
+	assert.Equal(t, "identifiers", rulesByName(g.rules)["ident-super"].Doc())
+
+	engine, err := NewEngine(g, ParseOptions{})
+	assert.Nil(t, err)
+	_, err = engine.Parse(strings.NewReader("a+(12+$aa)"))
+	assert.Nil(t, err)
+
+	// A super reference to a rule that is not overridden is the rule
+	g, err = testGrammar("number = super.ident").Extend(base)
+	assert.Nil(t, err)
+	assert.Equal(t, "number = ident", rulesByName(g.rules)["number"].String())
+
+	// Super rule names are unique
+	g, err = testGrammar(
+		"ident = super.ident",
+		"ident-super = 'x'",
+	).Extend(base)
+	assert.Nil(t, err)
+	assert.Equal(t, "ident = ident-super-2", g.rules[2].String())
+
+	_, err = testGrammar("term = super.number").Extend(base)
+	assert.Equal(t, fmt.Errorf("%s: super.number", ErrSuperUndefined), err)
+}
+
+func TestExtendsDirective(t *testing.T) {
+	name, data, err := extendsDirective([]byte("a = 'x'"))
+	assert.Nil(t, err)
+	assert.Equal(t, "", name)
+	assert.Equal(t, "a = 'x'", string(data))
+
+	name, data, err = extendsDirective([]byte("\n  @extends \"base.g\"  \na = 'x'"))
+	assert.Nil(t, err)
+	assert.Equal(t, "base.g", name)
+	assert.Equal(t, "\n"+strings.Repeat(" ", 21)+"\na = 'x'", string(data))
+
+	name, data, err = extendsDirective([]byte(`@extends "base.g"`))
+	assert.Nil(t, err)
+	assert.Equal(t, "base.g", name)
+	assert.Equal(t, strings.Repeat(" ", 17), string(data))
+
+	for _, src := range []string{`@extends`, `@extends base.g`, `@extends "base.g`, `@extends ""`, `@extends "a" "b"`, "@extends \"a\" b\n"} {
+		_, _, err = extendsDirective([]byte(src))
+		assert.NotNil(t, err, src)
+	}
+	_, _, err = extendsDirective([]byte("@extends \"a\" b\nc"))
+	assert.Equal(t, fmt.Errorf(`%s: @extends "a" b`, ErrExtendsSyntax), err)
+}
+
+func TestCompileExtends(t *testing.T) {
+	resolver := func(sources map[string]string) func(string) (io.Reader, error) {
+		return func(name string) (io.Reader, error) {
+			source, haveIt := sources[name]
+			if !haveIt {
+				return nil, fmt.Errorf("no grammar %s", name)
+			}
+
+			return strings.NewReader(source), nil
+		}
+	}
+
+	for dialect, sources := range map[Dialect][]string{
+		DialectISO:   {`a = "x", b ; b = 'y' ;`, "@extends \"base\"\nb = super.b, 'z' ;"},
+		DialectW3C:   {`a ::= 'x' b  b ::= 'y'`, "@extends \"base\"\nb ::= super.b 'z'"},
+		DialectABNF:  {"a = %s\"x\" b\nb = %s\"y\"", "@extends \"base\"\nb = super.b %s\"z\""},
+		DialectANTLR: {`a : 'x' b ; b : 'y' ;`, "@extends \"base\"\nb : super.b 'z' ;"},
+	} {
+		g, err := Compile(strings.NewReader(sources[1]), CompileOptions{
+			Dialect:  dialect,
+			Resolver: resolver(map[string]string{"base": sources[0]}),
+		})
+		assert.Nil(t, err, sources[1])
+		assert.Equal(t, "a = 'x' b\nb = b-super 'z'\nb-super = 'y'", g.String(), sources[1])
+	}
+
+	// Grammars extend grammars that extend others
+	options := CompileOptions{
+		Dialect: DialectANTLR,
+		Resolver: resolver(map[string]string{
+			"core":  "a : b c ; b : 'x' ; c : 'y' ;",
+			"base":  "@extends \"core\"\nc : 'z' | super.c ;",
+			"self":  "@extends \"self\"\na : 'x' ;",
+			"cycle": "@extends \"base2\"\na : 'x' ;",
+			"base2": "@extends \"cycle\"\na : 'x' ;",
+			"extra": "@extends \"core\"\nd : 'x' ;",
+		}),
+	}
+	g, err := Compile(strings.NewReader("@extends \"base\"\nb : 'w' ;"), options)
+	assert.Nil(t, err)
+	assert.Equal(t, "a = b c\nb = 'w'\nc = 'z' | c-super\nc-super = 'y'", g.String())
+
+	for src, msg := range map[string]string{
+		`@extends "self"`:                  ErrExtendsCycle + ": self",
+		`@extends "cycle"`:                 ErrExtendsCycle + ": cycle",
+		`@extends "none"`:                  "no grammar none",
+		`@extends "core" d : super.d ;`:    ErrExtendsSyntax + `: @extends "core" d : super.d ;`,
+		"@extends \"core\"\nd : super.d ;": ErrSuperUndefined + ": super.d",
+	} {
+		_, err = Compile(strings.NewReader(src), options)
+		assert.Equal(t, msg, err.Error(), src)
+	}
+
+	// Lines and positions of the source are unchanged
+	options.Validate = true
+	_, err = Compile(strings.NewReader("@extends \"core\"\nd : 'x' ;"), options)
+	assert.Equal(t, "Unreachable rule d at line 2 position 1", err.Error())
+
+	// Diagnostics of the grammars that are extended have their file, and are sorted after the grammar being compiled
+	_, err = Compile(strings.NewReader("@extends \"extra\"\n\ne : 'x' ;"), options)
+	assert.Equal(t, "Unreachable rule e at line 3 position 1\nUnreachable rule d in extra at line 2 position 1", err.Error())
+
+	_, err = Compile(strings.NewReader("@extends \"core\"\nb : 'x' ;"), CompileOptions{Dialect: DialectANTLR})
+	assert.Equal(t, ErrExtendsResolver+": core", err.Error())
+
+	// A sandbox only allows extending the allowed imports
+	options.Validate = false
+	options.Sandbox = &Sandbox{Imports: []string{"base"}}
+	_, err = Compile(strings.NewReader("@extends \"base\"\nb : 'w' ;"), options)
+	assert.Equal(t, ErrSandboxImport+": core", err.Error())
+
+	options.Sandbox.Imports = append(options.Sandbox.Imports, "core")
+	_, err = Compile(strings.NewReader("@extends \"base\"\nb : 'w' ;"), options)
+	assert.Nil(t, err)
+
+	options.Sandbox.MaxSourceSize = 20
+	_, err = Compile(strings.NewReader("@extends \"base\"\nb : 'w' ;"), options)
+	assert.Equal(t, ErrMaxInputSize+": 20", err.Error())
+}
@@ -0,0 +1,105 @@
+package parser
+
+// Lookahead is what can begin a match of a rule
+type Lookahead struct {
+	// Terminals are the terminals that can begin a match, in order of the alternatives that lead to them
+	Terminals []Terminal
+	// Runes are the runes that can begin a match, in order
+	Runes []rune
+	// Empty is true if the rule can match nothing, so that whatever follows the rule can also begin a match
+	Empty bool
+}
+
+// Grammar returns the grammar of the engine
+func (e *Engine) Grammar() Grammar {
+	return e.grammar
+}
+
+// Start returns the name of the start rule
+func (e *Engine) Start() string {
+	return e.options.Start
+}
+
+// Rules returns the rules of the grammar, in grammar order, so that a host application can describe what input its
+// grammar accepts, such as listing the commands of a DSL along with their docs
+func (e *Engine) Rules() []Rule {
+	return append([]Rule(nil), e.grammar.rules...)
+}
+
+// Rule returns the named rule and true, or false if it is not defined
+func (e *Engine) Rule(name string) (Rule, bool) {
+	rule, defined := e.rules[name]
+	return rule, defined
+}
+
+// Terminals returns each different terminal of the grammar, in order of first appearance
+func (e *Engine) Terminals() []Terminal {
+	var (
+		seen   = map[string]bool{}
+		result []Terminal
+	)
+
+	for _, rule := range e.grammar.rules {
+		for _, alt := range rule.expr.items {
+			for _, item := range alt.list {
+				if item.IsTerminal() {
+					if format := item.terminal.Format(); !seen[format] {
+						seen[format] = true
+						result = append(result, item.terminal)
+					}
+				}
+			}
+		}
+	}
+
+	return result
+}
+
+// LookaheadFor returns what can begin a match of the named rule and true, or false if it is not defined.
+// Predicates and the trivia rule are not taken into account.
+func (e *Engine) LookaheadFor(name string) (Lookahead, bool) {
+	if _, defined := e.rules[name]; !defined {
+		return Lookahead{}, false
+	}
+
+	e.firstsOnce.Do(func() { e.firsts = firstSets(e.grammar) })
+
+	var (
+		sets    = e.firsts[name]
+		result  = Lookahead{Empty: sets.rule.nullable}
+		seen    = map[string]bool{}
+		visited = map[string]bool{}
+		visit   func(name string)
+	)
+
+	if !sets.rule.chars.IsEmpty() {
+		result.Runes = sets.rule.chars.Runes()
+	}
+
+	// The first terminals of each alternative, and of the items after each item that can match nothing
+	visit = func(name string) {
+		visited[name] = true
+
+		for _, alt := range e.rules[name].expr.items {
+			for _, item := range alt.list {
+				if item.IsTerminal() {
+					if format := item.terminal.Format(); !seen[format] {
+						seen[format] = true
+						result.Terminals = append(result.Terminals, item.terminal)
+					}
+					break
+				}
+
+				if _, defined := e.rules[item.ruleName]; defined && !visited[item.ruleName] {
+					visit(item.ruleName)
+				}
+				if !e.firsts[item.ruleName].rule.nullable {
+					break
+				}
+			}
+		}
+	}
+	visit(name)
+
+	return result, true
+}
@@ -0,0 +1,52 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEngineIntrospection(t *testing.T) {
+	g := testGrammar(
+		"command = ws get | ws set | help",
+		"get = 'get' ws name",
+		"set = 'set' ws name '=' name",
+		"help = '?' | 'help'",
+		"name = ([abc])+",
+		"ws = ([_])*",
+	)
+	engine, err := NewEngine(g, ParseOptions{Start: "help"})
+	assert.Nil(t, err)
+
+	assert.Equal(t, g, engine.Grammar())
+	assert.Equal(t, "help", engine.Start())
+	assert.Equal(t, g.rules, engine.Rules())
+
+	rule, defined := engine.Rule("set")
+	assert.True(t, defined)
+	assert.Equal(t, "set", rule.Name())
+	_, defined = engine.Rule("delete")
+	assert.False(t, defined)
+
+	var formats []string
+	for _, terminal := range engine.Terminals() {
+		formats = append(formats, terminal.Format())
+	}
+	assert.Equal(t, []string{"'get'", "'set'", "'='", "'?'", "'help'", "[a-c]", "[_]"}, formats)
+
+	lookahead, defined := engine.LookaheadFor("command")
+	assert.True(t, defined)
+	formats = nil
+	for _, terminal := range lookahead.Terminals {
+		formats = append(formats, terminal.Format())
+	}
+	assert.Equal(t, []string{"[_]", "'get'", "'set'", "'?'", "'help'"}, formats)
+	assert.Equal(t, []rune("?_ghs"), lookahead.Runes)
+	assert.False(t, lookahead.Empty)
+
+	lookahead, _ = engine.LookaheadFor("ws")
+	assert.Equal(t, Lookahead{Terminals: []Terminal{g.rules[5].expr.items[0].list[0].terminal}, Runes: []rune("_"), Empty: true}, lookahead)
+
+	_, defined = engine.LookaheadFor("delete")
+	assert.False(t, defined)
+}
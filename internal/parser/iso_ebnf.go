@@ -0,0 +1,439 @@
+package parser
+
+import (
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ISO EBNF import error message constants
+const (
+	ErrISOSyntax      = "Invalid ISO EBNF grammar"
+	ErrISOUnsupported = "Unsupported ISO EBNF construct"
+	ErrISOEmptyRule   = "An ISO EBNF rule must be able to match something"
+)
+
+// isoTokenType is the type of a token of an ISO EBNF grammar
+type isoTokenType uint
+
+const (
+	isoEOF isoTokenType = iota
+	isoName
+	isoInteger
+	isoString
+	isoSpecial
+	isoPunct
+)
+
+// isoToken is a token of an ISO EBNF grammar, where alternative representations of punctuation such as (/ and / are
+// converted to their usual representations [ and |
+type isoToken struct {
+	tokenType isoTokenType
+	// meta identifier with whitespace replaced by dashes, integer, string, or punctuation
+	text     string
+	line     int
+	position int
+	// span of source of the token
+	start, end SourcePosition
+}
+
+// isoParser parses ISO EBNF tokens into rules, which are the same as W3C EBNF rules
+type isoParser struct {
+	tokens []isoToken
+	index  int
+	// end of the last token consumed
+	end SourcePosition
+}
+
+// ImportISOEBNF reads a grammar in the EBNF of ISO/IEC 14977, and converts its rules to a Grammar, in the order they are defined.
+//
+// Supported constructs are meta identifiers, quoted terminals, definitions separated by | (or / or !) and concatenated with ",",
+// optional [ ] and repeated { } sequences, ( ) groups, N * repetitions, exceptions, empty sequences, and (* comments *),
+// along with the alternative representations (/ /) and (: :), and the . terminator.
+// A special sequence, such as ? any character ?, refers to a rule named after its words of letters and digits, such as
+// any-character, which the grammar must define, or a grammar it extends, as the meaning of a special sequence is not
+// defined by the notation.
+//
+// Whitespace inside a meta identifier is replaced by a dash, so "digit excluding zero" is the rule digit-excluding-zero.
+// Exceptions are converted the same way as ImportW3CEBNF: to a range if both sides are characters or alternatives of them,
+// otherwise only the left side is kept. Groups and repetitions are converted the same way as ImportANTLR, so an empty
+// sequence makes the other definitions optional, and an optional or repeated group of empty sequences, such as [ ], is left out.
+//
+// Returns an error if the grammar is invalid, or a special sequence has no words or begins with a digit.
+func ImportISOEBNF(source io.Reader) (Grammar, error) {
+	return importISOEBNF(source, PositionOptions{})
+}
+
+// importISOEBNF is ImportISOEBNF, where the positions of the grammar follow the options
+func importISOEBNF(source io.Reader, positions PositionOptions) (grammar Grammar, err error) {
+	// The conversion shares the ANTLR importer's error handling
+	defer func() {
+		if r := recover(); r != nil {
+			if e, isa := r.(antlrError); isa {
+				err = e.err
+				return
+			}
+			panic(r)
+		}
+	}()
+
+	data, err := ioutil.ReadAll(source)
+	if err != nil {
+		return Grammar{}, err
+	}
+
+	var (
+		p     = &isoParser{tokens: isoTokens([]rune(string(data)), positions)}
+		w3c   = &w3cParser{rules: map[string]w3cRule{}}
+		rules []w3cRule
+		names = map[string]bool{}
+	)
+	for rule, ok := p.parseRule(); ok; rule, ok = p.parseRule() {
+		rules = append(rules, rule)
+		names[rule.name] = true
+		if _, haveIt := w3c.rules[rule.name]; !haveIt {
+			w3c.rules[rule.name] = rule
+		}
+	}
+
+	var result []Rule
+	for _, rule := range rules {
+		conv := &antlrConverter{ruleName: rule.name, names: names, emptyRuleErr: ErrISOEmptyRule}
+		items := conv.alternatives(w3c.elements(rule.alts), rule.line, rule.position)
+
+		converted := newRule(rule.name, nil, conv.expression(items))
+		converted.SourceNode = OfSourceNodeSpan(converted.sourceString, rule.start, rule.end)
+		result = append(append(result, converted), conv.parts...)
+	}
+
+	return newSpannedGrammar(result, p.tokens[len(p.tokens)-1].end), nil
+}
+
+// ==== Lexer
+
+// isoTokens splits an ISO EBNF grammar into tokens, ending with EOF, skipping whitespace and comments
+func isoTokens(input []rune, opts PositionOptions) []isoToken {
+	var (
+		tokens    []isoToken
+		positions = sourcePositions(input, opts)
+		i         int
+		advance   = func(count int) {
+			if i += count; i > len(input) {
+				i = len(input)
+			}
+		}
+		at = func(str string) bool {
+			return strings.HasPrefix(string(input[i:]), str)
+		}
+		isLetter = func(char rune) bool {
+			return ((char >= 'a') && (char <= 'z')) || ((char >= 'A') && (char <= 'Z'))
+		}
+		isDigit = func(char rune) bool {
+			return (char >= '0') && (char <= '9')
+		}
+		// Alternative representations of punctuation
+		punct = map[string]string{
+			"(/": "[", "/)": "]", "(:": "{", ":)": "}", "/": "|", "!": "|", ".": ";",
+		}
+	)
+
+	for {
+		token := isoToken{line: positions[i].Line, position: positions[i].Position, start: positions[i], end: positions[i]}
+
+		switch {
+		case i >= len(input):
+			token.tokenType = isoEOF
+			return append(tokens, token)
+
+		case unicode.IsSpace(input[i]):
+			advance(1)
+			continue
+
+		case at("(*"):
+			// Comments can be nested
+			for depth := 0; ; {
+				switch {
+				case i >= len(input):
+					antlrFail(ErrISOSyntax, "unterminated comment", token.line, token.position)
+				case at("(*"):
+					depth++
+					advance(2)
+				case at("*)"):
+					depth--
+					advance(2)
+				default:
+					advance(1)
+				}
+
+				if depth == 0 {
+					break
+				}
+			}
+			continue
+
+		case isLetter(input[i]):
+			var words []string
+			// Only the first word must begin with a letter
+			for (i < len(input)) && (isLetter(input[i]) || ((len(words) > 0) && isDigit(input[i]))) {
+				start := i
+				for (i < len(input)) && (isLetter(input[i]) || isDigit(input[i])) {
+					advance(1)
+				}
+				// super.name refers to a rule of the grammar this one extends, where . is not the end of the rule
+				if (len(words) == 0) && (string(input[start:i]) == "super") && isSuperDot(input, i, isLetter) {
+					for advance(1); (i < len(input)) && (isLetter(input[i]) || isDigit(input[i])); {
+						advance(1)
+					}
+				}
+				words = append(words, string(input[start:i]))
+
+				// Whitespace followed by a letter or digit continues the meta identifier
+				next := i
+				for (next < len(input)) && ((input[next] == ' ') || (input[next] == '\t')) {
+					next++
+				}
+				if (next < len(input)) && (next > i) && (isLetter(input[next]) || isDigit(input[next])) {
+					advance(next - i)
+				}
+			}
+			token.tokenType, token.text = isoName, strings.Join(words, "-")
+
+		case isDigit(input[i]):
+			start := i
+			for (i < len(input)) && isDigit(input[i]) {
+				advance(1)
+			}
+			token.tokenType, token.text = isoInteger, string(input[start:i])
+
+		case (input[i] == '"') || (input[i] == '\''):
+			quote := input[i]
+			advance(1)
+			start := i
+			for (i < len(input)) && (input[i] != quote) {
+				advance(1)
+			}
+			if i >= len(input) {
+				antlrFail(ErrISOSyntax, "unterminated terminal", token.line, token.position)
+			}
+			token.tokenType, token.text = isoString, string(input[start:i])
+			advance(1)
+
+			if token.text == "" {
+				antlrFail(ErrISOSyntax, "empty terminal", token.line, token.position)
+			}
+
+		case input[i] == '?':
+			// The words of letters and digits of a special sequence are the name of the rule it refers to
+			var words []string
+			for advance(1); (i < len(input)) && (input[i] != '?'); {
+				start := i
+				for (i < len(input)) && (isLetter(input[i]) || isDigit(input[i])) {
+					advance(1)
+				}
+				if i > start {
+					words = append(words, string(input[start:i]))
+				} else {
+					advance(1)
+				}
+			}
+			if i >= len(input) {
+				antlrFail(ErrISOSyntax, "unterminated special sequence", token.line, token.position)
+			}
+			token.tokenType, token.text = isoSpecial, strings.Join(words, "-")
+			advance(1)
+
+		case at("(/") || at("/)") || at("(:") || at(":)"):
+			token.tokenType, token.text = isoPunct, punct[string(input[i:i+2])]
+			advance(2)
+
+		case strings.ContainsRune("/!.", input[i]):
+			token.tokenType, token.text = isoPunct, punct[string(input[i])]
+			advance(1)
+
+		case strings.ContainsRune("=;|,-*[]{}()", input[i]):
+			token.tokenType, token.text = isoPunct, string(input[i])
+			advance(1)
+
+		default:
+			antlrFail(ErrISOSyntax, strconv.Quote(string(input[i])), positions[i].Line, positions[i].Position)
+		}
+
+		token.end = positions[i]
+		tokens = append(tokens, token)
+	}
+}
+
+// ==== Parser
+
+// peek returns the next token
+func (p *isoParser) peek() isoToken {
+	return p.tokens[p.index]
+}
+
+// next consumes and returns the next token
+func (p *isoParser) next() isoToken {
+	token := p.tokens[p.index]
+	if token.tokenType != isoEOF {
+		p.index++
+	}
+
+	p.end = token.end
+	return token
+}
+
+// isPunct returns true if a token is the given punctuation
+func (t isoToken) isPunct(punct string) bool {
+	return (t.tokenType == isoPunct) && (t.text == punct)
+}
+
+// describe returns a description of a token for error messages
+func (t isoToken) describe() string {
+	switch t.tokenType {
+	case isoEOF:
+		return "EOF"
+	case isoName, isoInteger:
+		return t.text
+	case isoString:
+		return stringSource(t.text)
+	case isoSpecial:
+		return "special sequence"
+	}
+
+	return "'" + t.text + "'"
+}
+
+// expect consumes a punctuation token, failing if the next token is different
+func (p *isoParser) expect(punct string) {
+	if token := p.next(); !token.isPunct(punct) {
+		antlrFail(ErrISOSyntax, "expected '"+punct+"', found "+token.describe(), token.line, token.position)
+	}
+}
+
+// parseRule parses the next rule, returning false at EOF
+func (p *isoParser) parseRule() (w3cRule, bool) {
+	name := p.next()
+	switch name.tokenType {
+	case isoEOF:
+		return w3cRule{}, false
+	case isoName:
+	default:
+		antlrFail(ErrISOSyntax, "expected a rule, found "+name.describe(), name.line, name.position)
+	}
+
+	p.expect("=")
+	rule := w3cRule{name: name.text, alts: p.parseDefinitions(), line: name.line, position: name.position, start: name.start}
+	p.expect(";")
+	rule.end = p.end
+
+	return rule, true
+}
+
+// parseDefinitions parses sequences separated by |
+func (p *isoParser) parseDefinitions() [][]w3cExpr {
+	alts := [][]w3cExpr{p.parseSequence()}
+	for p.peek().isPunct("|") {
+		p.next()
+		alts = append(alts, p.parseSequence())
+	}
+
+	return alts
+}
+
+// parseSequence parses terms separated by commas, which is empty if there are none
+func (p *isoParser) parseSequence() []w3cExpr {
+	var seq []w3cExpr
+
+	for {
+		switch token := p.peek(); {
+		case token.isPunct("|"), token.isPunct(";"), token.isPunct(")"), token.isPunct("]"), token.isPunct("}"), token.tokenType == isoEOF:
+			if len(seq) > 0 {
+				antlrFail(ErrISOSyntax, "expected a term, found "+token.describe(), token.line, token.position)
+			}
+			return seq
+		}
+
+		seq = append(seq, p.parseTerm())
+		if !p.peek().isPunct(",") {
+			return seq
+		}
+		p.next()
+	}
+}
+
+// parseTerm parses a factor and an optional exception
+func (p *isoParser) parseTerm() w3cExpr {
+	expr := p.parseFactor()
+	if minus := p.peek(); minus.isPunct("-") {
+		p.next()
+		expr = w3cExpr{kind: w3cExcept, alts: [][]w3cExpr{{expr}}, except: []w3cExpr{p.parseFactor()}, n: 1, m: 1, line: minus.line, position: minus.position, start: expr.start}
+		expr.end = p.end
+	}
+
+	return expr
+}
+
+// parseFactor parses a primary with an optional N * repetition
+func (p *isoParser) parseFactor() w3cExpr {
+	count, start := 1, p.peek().start
+	if token := p.peek(); token.tokenType == isoInteger {
+		p.next()
+		count, _ = strconv.Atoi(token.text)
+		if count == 0 {
+			antlrFail(ErrISOSyntax, "invalid repetition", token.line, token.position)
+		}
+		p.expect("*")
+	}
+
+	expr := p.parsePrimary()
+	if count == 1 {
+		return expr
+	}
+
+	// A primary that already has a repetition is grouped so both apply
+	if (expr.n != 1) || (expr.m != 1) {
+		expr = w3cExpr{kind: w3cGroup, alts: [][]w3cExpr{{expr}}, line: expr.line, position: expr.position, end: expr.end}
+	}
+	expr.n, expr.m, expr.start = count, count, start
+
+	return expr
+}
+
+// parsePrimary parses an optional, repeated, or grouped sequence, a meta identifier, or a terminal
+func (p *isoParser) parsePrimary() w3cExpr {
+	token := p.next()
+	expr := w3cExpr{n: 1, m: 1, line: token.line, position: token.position, start: token.start}
+
+	switch {
+	case token.tokenType == isoName:
+		expr.kind, expr.text = w3cRef, token.text
+
+	case token.tokenType == isoString:
+		expr.kind, expr.text = w3cLiteral, token.text
+
+	case token.tokenType == isoSpecial:
+		if (token.text == "") || !unicode.IsLetter([]rune(token.text)[0]) {
+			antlrFail(ErrISOUnsupported, token.describe(), token.line, token.position)
+		}
+		expr.kind, expr.text = w3cRef, token.text
+
+	case token.isPunct("["), token.isPunct("{"), token.isPunct("("):
+		closing := map[string]string{"[": "]", "{": "}", "(": ")"}[token.text]
+		switch token.text {
+		case "[":
+			expr.n, expr.m = 0, 1
+		case "{":
+			expr.n, expr.m = 0, -1
+		}
+
+		expr.kind, expr.alts = w3cGroup, p.parseDefinitions()
+		p.expect(closing)
+
+	default:
+		antlrFail(ErrISOSyntax, "unexpected "+token.describe(), token.line, token.position)
+	}
+
+	expr.end = p.end
+	return expr
+}
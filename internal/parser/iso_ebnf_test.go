@@ -0,0 +1,85 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportISOEBNF(t *testing.T) {
+	g, err := ImportISOEBNF(strings.NewReader(`
+(* A (* nested *) comment *)
+rule = meta identifier, '=', [definitions], ';' .
+meta identifier = letter, {letter | digit} ;
+letter = "a" | "b" | "c" ;
+digit excluding zero = "1" / "2" ! "3" ;
+digit = "0" | digit excluding zero ;
+definitions = 3 * letter, (/ "x" /), (: "y" :), (letter - "b"), (rule - ";") ;
+empty = | "z" ;
+`))
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		`rule = meta-identifier '=' rule-part ';'
+rule-part = (definitions)?
+meta-identifier = letter meta-identifier-part-2
+meta-identifier-part = letter | digit
+meta-identifier-part-2 = (meta-identifier-part)*
+letter = 'a' | 'b' | 'c'
+digit-excluding-zero = '1' | '2' | '3'
+digit = '0' | digit-excluding-zero
+definitions = definitions-part definitions-part-2 definitions-part-3 [ac] rule
+definitions-part = (letter){3}
+definitions-part-2 = ('x')?
+definitions-part-3 = ('y')*
+empty = ('z')?`,
+		g.String(),
+	)
+
+	// Positions of rules are kept for diagnostics
+	assert.Equal(t, 3, g.rules[0].Line())
+	assert.Equal(t, 1, g.rules[0].Position())
+}
+
+func TestImportISOEBNFEmptyGroups(t *testing.T) {
+	g, err := ImportISOEBNF(strings.NewReader("a = { }, 'x' | ( [ ] | { } ), 'y' ;"))
+	assert.Nil(t, err)
+	assert.Equal(t, "a = 'x' | 'y'", g.String())
+}
+
+func TestImportISOEBNFSpecialSequences(t *testing.T) {
+	// A special sequence refers to a rule named after its words, which the grammar defines
+	g, err := ImportISOEBNF(strings.NewReader("a = 'x', ? any character ?, ?horizontal tab, 0x09? ;\nany character = 'y' ;\nhorizontal tab 0x09 = '\t' ;"))
+	assert.Nil(t, err)
+	assert.Equal(t, "a = 'x' any-character horizontal-tab-0x09\nany-character = 'y'\nhorizontal-tab-0x09 = '\\t'", g.String())
+	assert.Equal(t, 0, len(g.Validate()))
+
+	// A special sequence the grammar does not define is an undefined rule
+	_, err = Compile(strings.NewReader("a = ? any character ? ;"), CompileOptions{Dialect: DialectISO, Validate: true})
+	assert.Equal(t, "Undefined rule any-character at line 1 position 5", err.Error())
+}
+
+func TestImportISOEBNFErrors(t *testing.T) {
+	for source, msg := range map[string]string{
+		`a = 'x`:          fmt.Sprintf("%s unterminated terminal at line 1 position 5", ErrISOSyntax),
+		`a = ''`:          fmt.Sprintf("%s empty terminal at line 1 position 5", ErrISOSyntax),
+		`a = 'x' (* x`:    fmt.Sprintf("%s unterminated comment at line 1 position 9", ErrISOSyntax),
+		`a = ? ? ;`:       fmt.Sprintf("%s special sequence at line 1 position 5", ErrISOUnsupported),
+		`a = ? 1st ? ;`:   fmt.Sprintf("%s special sequence at line 1 position 5", ErrISOUnsupported),
+		`a = ? any ;`:     fmt.Sprintf("%s unterminated special sequence at line 1 position 5", ErrISOSyntax),
+		`a = 'x'`:         fmt.Sprintf("%s expected ';', found EOF at line 1 position 8", ErrISOSyntax),
+		`a 'x' ;`:         fmt.Sprintf("%s expected '=', found 'x' at line 1 position 3", ErrISOSyntax),
+		`a = 'x', ;`:      fmt.Sprintf("%s expected a term, found ';' at line 1 position 10", ErrISOSyntax),
+		`a = 0 * 'x' ;`:   fmt.Sprintf("%s invalid repetition at line 1 position 5", ErrISOSyntax),
+		`a = ( 'x' ] ;`:   fmt.Sprintf("%s expected ')', found ']' at line 1 position 11", ErrISOSyntax),
+		`; a = 'x' ;`:     fmt.Sprintf("%s expected a rule, found ';' at line 1 position 1", ErrISOSyntax),
+		`a = 'x' # 'y' ;`: fmt.Sprintf("%s \"#\" at line 1 position 9", ErrISOSyntax),
+		`a = ;`:           fmt.Sprintf("%s a at line 1 position 1", ErrISOEmptyRule),
+		`a = [ ] | { } ;`: fmt.Sprintf("%s a at line 1 position 1", ErrISOEmptyRule),
+	} {
+		_, err := ImportISOEBNF(strings.NewReader(source))
+		assert.Equal(t, msg, err.Error(), source)
+	}
+}
@@ -0,0 +1,139 @@
+package parser
+
+// Keyword error message constants
+const (
+	ErrKeywordsBackend = "Keyword rules are only supported by the backtracking backend"
+)
+
+// KeywordPolicy chooses how Compile disambiguates keywords from the other token rules of a two-level grammar that match
+// them, such as the keyword IF = 'if' from the identifier ID = [a-z] ([a-z0-9])*, which also matches "if"
+type KeywordPolicy uint
+
+// KeywordPolicy constants
+const (
+	// KeywordsNone does not disambiguate keywords, so that ID matches "if" wherever IF does
+	KeywordsNone KeywordPolicy = iota
+	// KeywordsExact gives each token rule that is one string another token rule matches the :KEYWORD option, so that the
+	// keyword wins where the other rule matches exactly its string, and the longest match wins otherwise: ID does not
+	// match "if", and IF does not match the beginning of "iffy", which ID matches
+	KeywordsExact
+)
+
+// Keyword returns true if the rule has the :KEYWORD option, which means that the rule is a token rule of one string,
+// such as IF = 'if', that the other token rules cannot match exactly, and that cannot match where one of them matches
+// more of the input. Only the backtracking backend supports keywords, which generated parsers match as other rules.
+func (r Rule) Keyword() bool {
+	for _, option := range r.options {
+		if option == OptionKeyword {
+			return true
+		}
+	}
+
+	return false
+}
+
+// keywordTable is how the keyword rules of a grammar are disambiguated from the other token rules
+type keywordTable struct {
+	// strings of the keywords that each other token rule matches, which it cannot match exactly
+	reserved map[string]map[string]bool
+	// other token rules that match the string of each keyword rule
+	competitors map[string][]string
+}
+
+// newKeywordTable returns the keyword table of a grammar and its earley grammar
+func newKeywordTable(g Grammar, eg *earleyGrammar) keywordTable {
+	table := keywordTable{reserved: map[string]map[string]bool{}, competitors: map[string][]string{}}
+
+	for _, keyword := range g.rules {
+		literal, isLiteral := keyword.literal()
+		if !keyword.Keyword() || !isLiteral || (literal == "") {
+			continue
+		}
+
+		for _, rule := range g.rules {
+			if !rule.IsToken() || rule.Keyword() || !eg.matchesAll(rule.name, literal) {
+				continue
+			}
+
+			if table.reserved[rule.name] == nil {
+				table.reserved[rule.name] = map[string]bool{}
+			}
+			table.reserved[rule.name][literal] = true
+			table.competitors[keyword.name] = append(table.competitors[keyword.name], rule.name)
+		}
+	}
+
+	return table
+}
+
+// matchesAll returns true if the named rule matches all of a string
+func (eg *earleyGrammar) matchesAll(name, str string) bool {
+	p, _ := eg.recognize(OfParseContext(), nil, []rune(str), name)
+	return p != nil
+}
+
+// withKeywords returns the grammar where each token rule that is one string another token rule matches has the :KEYWORD
+// option, as described by KeywordsExact, or the grammar unchanged if there are no such rules
+func (g Grammar) withKeywords() Grammar {
+	var (
+		eg      = newEarleyGrammar(g)
+		rules   = make([]Rule, len(g.rules))
+		changed bool
+	)
+	for r, rule := range g.rules {
+		rules[r] = rule
+
+		literal, isLiteral := rule.literal()
+		if !isLiteral || (literal == "") || rule.Keyword() {
+			continue
+		}
+
+		for _, other := range g.rules {
+			if (other.name != rule.name) && other.IsToken() && eg.matchesAll(other.name, literal) {
+				rules[r] = newRule(rule.name, append(append([]Option(nil), rule.options...), OptionKeyword), rule.expr)
+				rules[r].SourceNode = rules[r].withSpan(rule.Span())
+				rules[r].doc, rules[r].limits = rule.doc, rule.limits
+				changed = true
+				break
+			}
+		}
+	}
+
+	if !changed {
+		return g
+	}
+
+	result := newGrammar(rules)
+	result.SourceNode = result.withSpan(g.Span())
+
+	return result
+}
+
+// keywordAllows returns true unless a match of the named rule is a match of a token rule that is exactly the string of a
+// keyword, or a match of a keyword rule where another token rule matches more of the input
+func (b *backtracker) keywordAllows(name string, node Node) bool {
+	if b.engine.keywords.reserved[name][node.text] {
+		return false
+	}
+
+	for _, competitor := range b.engine.keywords.competitors[name] {
+		for _, match := range b.matchRule(competitor, node.start) {
+			if match.end > node.end {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// hasKeywords returns true if any rule of the grammar is a keyword rule
+func (g Grammar) hasKeywords() bool {
+	for _, rule := range g.rules {
+		if rule.Keyword() {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,77 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompileKeywords(t *testing.T) {
+	source := "stmt : 'if' ID | ID '=' ID ;\nID : [a-z]+ ;"
+
+	// Without a policy, ID matches "if", and IF matches the beginning of "ifa"
+	g, err := Compile(strings.NewReader(source), CompileOptions{Dialect: DialectANTLR, TwoLevel: true})
+	assert.Nil(t, err)
+	assert.False(t, g.hasKeywords())
+
+	engine, err := NewEngine(g, ParseOptions{})
+	assert.Nil(t, err)
+	tree, err := engine.ParseString("if=a")
+	assert.Nil(t, err)
+	assert.Equal(t, `(stmt (ID "i" "f") (EQUALS "=") (ID "a"))`, tree.SExpr(false))
+	tree, err = engine.ParseString("ifa")
+	assert.Nil(t, err)
+	assert.Equal(t, `(stmt (IF "if") (ID "a"))`, tree.SExpr(false))
+
+	// Keywords win on an exact match, and the longest match wins otherwise
+	g, err = Compile(strings.NewReader(source), CompileOptions{Dialect: DialectANTLR, TwoLevel: true, Keywords: KeywordsExact, Validate: true})
+	assert.Nil(t, err)
+	assert.Equal(t, "stmt = IF ID | ID EQUALS ID\nID = ([a-z])+\nIF:KEYWORD = 'if'\nEQUALS = '='", g.String())
+	assert.True(t, g.rules[2].Keyword())
+	assert.False(t, g.rules[3].Keyword())
+
+	engine, err = NewEngine(g, ParseOptions{})
+	assert.Nil(t, err)
+	assert.True(t, engine.Supports(FeatureKeywords))
+	_, err = engine.ParseString("if=a")
+	assert.NotNil(t, err)
+	_, err = engine.ParseString("ifa")
+	assert.NotNil(t, err)
+	tree, err = engine.ParseString("iffy=a")
+	assert.Nil(t, err)
+	assert.Equal(t, `(stmt (ID "i" "f" "f" "y") (EQUALS "=") (ID "a"))`, tree.SExpr(false))
+
+	// Memoized rules are disambiguated the same way
+	engine, err = NewEngine(g, ParseOptions{Memo: true})
+	assert.Nil(t, err)
+	_, err = engine.ParseString("if=a")
+	assert.NotNil(t, err)
+	_, err = engine.ParseString("ifx")
+	assert.NotNil(t, err)
+	_, err = engine.ParseString("x=if")
+	assert.NotNil(t, err)
+
+	// Only the backtracking backend supports keywords
+	_, err = NewEngine(g, ParseOptions{Backend: BackendEarley})
+	assert.Equal(t, ErrKeywordsBackend, err.Error())
+
+	// A grammar without keywords is unchanged
+	g, err = Compile(strings.NewReader("stmt : 'if' ID ;\nID : [0-9]+ ;"), CompileOptions{Dialect: DialectANTLR, TwoLevel: true, Keywords: KeywordsExact})
+	assert.Nil(t, err)
+	assert.False(t, g.hasKeywords())
+}
+
+func TestInvalidKeywords(t *testing.T) {
+	g := testGrammar("stmt = IF", "IF = 'if'", "ID = ([a-z])+")
+	g.rules[0].options = []Option{OptionKeyword}
+	g.rules[1].options = []Option{OptionKeyword, OptionKeyword}
+	g.rules[2].options = []Option{OptionKeyword}
+
+	diags := g.InvalidOptions()
+	assert.Equal(t, 3, len(diags))
+	assert.Equal(t, DiagKeyword, diags[0].Code())
+	assert.Equal(t, "The option :KEYWORD can only be used on token rules that are one string stmt", diags[0].Message())
+	assert.Equal(t, DiagRuleOption, diags[1].Code())
+	assert.Equal(t, DiagKeyword, diags[2].Code())
+}
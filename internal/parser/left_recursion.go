@@ -0,0 +1,342 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Left recursion error message constants
+const (
+	ErrLeftRecursionNoBase     = "A left recursive rule must have at least one alternative that is not left recursive"
+	ErrLeftRecursionRepetition = "A left recursive alternative must occur exactly once, it cannot have a repetition"
+	ErrLeftRecursionNullable   = "A left recursion that follows rules that can match nothing cannot be eliminated"
+)
+
+// EliminateLeftRecursion returns a grammar that accepts the same language without direct or indirect left recursion,
+// so that it can be matched by a recursive descent parser.
+//
+// A direct left recursive rule such as
+//
+//	expr = expr '+' term | expr '-' term | term
+//
+// is rewritten as
+//
+//	expr = term expr-tail
+//	expr-tail = (expr-tail-item)*
+//	expr-tail-item = '+' term | '-' term
+//
+// Indirect left recursion is first made direct by substituting the alternatives of the earlier rule in the cycle.
+// New rules are inserted after the rule they were created for, and a numeric suffix is added if a name is already used.
+// Options of a list item that refers to a substituted rule are lost.
+//
+// Only the first list item of an alternative is rewritten, so a left recursion hidden behind a rule that can match
+// nothing, as described by LeftRecursion, is not eliminated.
+//
+// Returns an error if a left recursive rule has no alternative that is not left recursive,
+// a left recursive alternative has a repetition other than exactly once,
+// or a left recursion hidden behind a rule that can match nothing remains.
+// If there is no left recursion, the grammar is returned unchanged.
+func (g Grammar) EliminateLeftRecursion() (Grammar, error) {
+	var (
+		rules   = append([]Rule(nil), g.rules...)
+		names   = map[string]bool{}
+		changed bool
+	)
+
+	for _, rule := range rules {
+		names[rule.name] = true
+	}
+
+	for i := 0; i < len(rules); i++ {
+		// Substitute earlier rules that lead back to this rule until none remain
+		for substituted := true; substituted; {
+			substituted = false
+
+			for j := 0; j < i; j++ {
+				if !leftDerives(rulesByName(rules), nil, rules[j].name, rules[i].name) {
+					continue
+				}
+
+				rule, ok, err := substituteLeftCorner(rules[i], rules[j])
+				if err != nil {
+					return Grammar{}, err
+				}
+				if ok {
+					rules[i] = rule
+					substituted = true
+					changed = true
+				}
+			}
+		}
+
+		newRules, err := eliminateDirectLeftRecursion(rules[i], names)
+		if err != nil {
+			return Grammar{}, err
+		}
+		if newRules != nil {
+			rules = append(rules[:i], append(newRules, rules[i+1:]...)...)
+			changed = true
+		}
+	}
+
+	result := g
+	if changed {
+		result = newGrammar(rules)
+	}
+
+	if diags := result.LeftRecursion(); len(diags) > 0 {
+		return Grammar{}, fmt.Errorf("%s: %s", ErrLeftRecursionNullable, diags[0].Message())
+	}
+
+	return result, nil
+}
+
+// LeftRecursion returns a diagnostic for each set of rules that are directly or indirectly left recursive,
+// which would cause a recursive descent parser to recurse forever.
+// Each diagnostic is at the first rule of the set in grammar order, and describes the shortest cycle from that rule
+// back to itself, with the position of each rule in the cycle.
+// A rule that follows rules that can match nothing in an alternative is also examined, such as a in a = opt a 'x',
+// where opt = ('z')?.
+func (g Grammar) LeftRecursion() []Diagnostic {
+	var (
+		byName   = rulesByName(g.rules)
+		firsts   = firstSets(g)
+		reported = map[string]bool{}
+		result   []Diagnostic
+	)
+
+	for _, rule := range g.rules {
+		if reported[rule.name] {
+			continue
+		}
+
+		cycle := shortestLeftCycle(byName, firsts, rule.name)
+		if cycle == nil {
+			continue
+		}
+
+		// Every rule that is in a cycle with this rule is reported by this diagnostic
+		for _, other := range g.rules {
+			if leftDerives(byName, firsts, rule.name, other.name) && leftDerives(byName, firsts, other.name, rule.name) {
+				reported[other.name] = true
+			}
+		}
+
+		steps := make([]string, len(cycle))
+		for i, name := range cycle {
+			steps[i] = fmt.Sprintf("%s at line %d position %d", name, byName[name].line, byName[name].position)
+		}
+		steps = append(steps, rule.name)
+
+		result = append(result, newDiagnostic(DiagLeftRecursion, strings.Join(steps, " -> "), rule.SourceNode))
+	}
+
+	return result
+}
+
+// shortestLeftCycle returns the names of the rules in the shortest cycle of left corners, as described by leftCorners,
+// from the named rule back to itself, beginning with the named rule, or nil if there is no such cycle
+func shortestLeftCycle(byName map[string]Rule, firsts map[string]ruleFirstSets, name string) []string {
+	var (
+		parents = map[string]string{}
+		queue   = []string{name}
+	)
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, alt := range byName[current].expr.items {
+			for _, first := range leftCorners(alt.list, firsts) {
+				if first == name {
+					// Walk parents back to the named rule
+					var cycle []string
+					for step := current; step != name; step = parents[step] {
+						cycle = append([]string{step}, cycle...)
+					}
+
+					return append([]string{name}, cycle...)
+				}
+
+				if _, haveParent := parents[first]; !haveParent {
+					if _, defined := byName[first]; defined {
+						parents[first] = current
+						queue = append(queue, first)
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// leftDerives returns true if the named rule from can begin with the named rule to after one or more steps through
+// left corners, as described by leftCorners
+func leftDerives(byName map[string]Rule, firsts map[string]ruleFirstSets, from, to string) bool {
+	var (
+		visited = map[string]bool{}
+		visit   func(name string) bool
+	)
+
+	visit = func(name string) bool {
+		if visited[name] {
+			return false
+		}
+		visited[name] = true
+
+		for _, alt := range byName[name].expr.items {
+			for _, first := range leftCorners(alt.list, firsts) {
+				if (first == to) || visit(first) {
+					return true
+				}
+			}
+		}
+
+		return false
+	}
+
+	return visit(from)
+}
+
+// leftCorners returns the names of the rules that a list of items can begin matching at the offset the list begins at,
+// which are the first item and each item after rules that can match nothing, according to the first sets.
+// If the first sets are nil, only the first item is examined.
+func leftCorners(list []ListItem, firsts map[string]ruleFirstSets) []string {
+	var result []string
+
+	for _, item := range list {
+		if item.IsTerminal() {
+			break
+		}
+
+		result = append(result, item.ruleName)
+		if !firsts[item.ruleName].rule.nullable {
+			break
+		}
+	}
+
+	return result
+}
+
+// substituteLeftCorner replaces each alternative of rule that begins with a reference to other with the alternatives of other.
+// Returns the new rule and true if any substitution occurred, or an error if a substituted alternative has a repetition.
+func substituteLeftCorner(rule, other Rule) (Rule, bool, error) {
+	var (
+		items       []ExpressionItem
+		substituted bool
+	)
+
+	for _, alt := range rule.expr.items {
+		if (len(alt.list) == 0) || (alt.list[0].ruleName != other.name) {
+			items = append(items, alt)
+			continue
+		}
+
+		if (alt.n != 1) || (alt.m != 1) {
+			return Rule{}, false, fmt.Errorf("%s: %s", ErrLeftRecursionRepetition, rule.name)
+		}
+
+		for _, otherAlt := range other.expr.items {
+			if (otherAlt.n != 1) || (otherAlt.m != 1) {
+				return Rule{}, false, fmt.Errorf("%s: %s", ErrLeftRecursionRepetition, other.name)
+			}
+
+			list := append(append([]ListItem(nil), otherAlt.list...), alt.list[1:]...)
+			items = append(items, newExpressionItem(list, 1, 1))
+		}
+
+		substituted = true
+	}
+
+	if !substituted {
+		return rule, false, nil
+	}
+
+	return newRule(rule.name, rule.options, newExpression(items)), true, nil
+}
+
+// eliminateDirectLeftRecursion returns the rules that replace a directly left recursive rule, or nil if it is not left recursive.
+// The names map is updated with any new rule names.
+// Returns an error if the rule has no base alternative, or a left recursive alternative has a repetition.
+func eliminateDirectLeftRecursion(rule Rule, names map[string]bool) ([]Rule, error) {
+	var (
+		recursive []ExpressionItem
+		base      []ExpressionItem
+		repeated  bool
+	)
+
+	for _, alt := range rule.expr.items {
+		if (len(alt.list) == 0) || (alt.list[0].ruleName != rule.name) {
+			base = append(base, alt)
+			repeated = repeated || (alt.n != 1) || (alt.m != 1)
+			continue
+		}
+
+		if (alt.n != 1) || (alt.m != 1) {
+			return nil, fmt.Errorf("%s: %s", ErrLeftRecursionRepetition, rule.name)
+		}
+
+		// An alternative that is only a reference to the rule itself matches nothing new
+		if len(alt.list) > 1 {
+			recursive = append(recursive, newExpressionItem(alt.list[1:], 1, 1))
+		}
+	}
+
+	if len(recursive) == 0 {
+		if len(base) == len(rule.expr.items) {
+			return nil, nil
+		}
+
+		return []Rule{newRule(rule.name, rule.options, newExpression(base))}, nil
+	}
+
+	if len(base) == 0 {
+		return nil, fmt.Errorf("%s: %s", ErrLeftRecursionNoBase, rule.name)
+	}
+
+	var (
+		tailName = uniqueRuleName(rule.name+"-tail", names)
+		result   = []Rule{{}}
+		items    []ExpressionItem
+	)
+
+	// A repeated base alternative cannot be followed by the tail, so move the base alternatives into their own rule
+	if repeated {
+		headName := uniqueRuleName(rule.name+"-head", names)
+		items = []ExpressionItem{newExpressionItem([]ListItem{newListItemRuleName(headName), newListItemRuleName(tailName)}, 1, 1)}
+		result = append(result, newRule(headName, nil, newExpression(base)))
+	} else {
+		for _, alt := range base {
+			list := append(append([]ListItem(nil), alt.list...), newListItemRuleName(tailName))
+			items = append(items, newExpressionItem(list, 1, 1))
+		}
+	}
+	result[0] = newRule(rule.name, rule.options, newExpression(items))
+
+	// A single recursive alternative can be repeated directly, multiple alternatives need a rule to choose between them
+	if len(recursive) == 1 {
+		result = append(result, newRule(tailName, nil, newExpression([]ExpressionItem{newExpressionItem(recursive[0].list, 0, -1)})))
+	} else {
+		itemName := uniqueRuleName(rule.name+"-tail-item", names)
+		result = append(
+			result,
+			newRule(tailName, nil, newExpression([]ExpressionItem{newExpressionItem([]ListItem{newListItemRuleName(itemName)}, 0, -1)})),
+			newRule(itemName, nil, newExpression(recursive)),
+		)
+	}
+
+	return result, nil
+}
+
+// uniqueRuleName returns name if it is not in names, else name with the lowest numeric suffix that is not in names.
+// The result is added to names.
+func uniqueRuleName(name string, names map[string]bool) string {
+	result := name
+	for i := 2; names[result]; i++ {
+		result = fmt.Sprintf("%s-%d", name, i)
+	}
+	names[result] = true
+
+	return result
+}
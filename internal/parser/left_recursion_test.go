@@ -0,0 +1,157 @@
+package parser
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEliminateLeftRecursionNone(t *testing.T) {
+	g := testGrammar(
+		"expr = term '+' expr | term",
+		"term = 'x'",
+	)
+	result, err := g.EliminateLeftRecursion()
+	assert.Nil(t, err)
+	assert.Equal(t, g, result)
+}
+
+// eliminateLeftRecursion returns the grammar with left recursion eliminated, failing the test if it cannot be
+func eliminateLeftRecursion(t *testing.T, g Grammar) Grammar {
+	result, err := g.EliminateLeftRecursion()
+	assert.Nil(t, err)
+
+	return result
+}
+
+func TestEliminateDirectLeftRecursion(t *testing.T) {
+	g := eliminateLeftRecursion(t, testGrammar(
+		"expr = expr '+' term | term",
+		"term = 'x'",
+	))
+	assert.Equal(
+		t,
+		"expr = term expr-tail\nexpr-tail = ('+' term)*\nterm = 'x'",
+		g.String(),
+	)
+
+	g = eliminateLeftRecursion(t, testGrammar(
+		"expr = expr '+' term | expr '-' term | term | 'y'",
+		"term = 'x'",
+	))
+	assert.Equal(
+		t,
+		"expr = term expr-tail | 'y' expr-tail\nexpr-tail = (expr-tail-item)*\nexpr-tail-item = '+' term | '-' term\nterm = 'x'",
+		g.String(),
+	)
+
+	// Repeated base alternative and a name that is already used
+	g = eliminateLeftRecursion(t, testGrammar(
+		"expr = expr '+' 'x' | ('x')+",
+		"expr-tail = 'y'",
+	))
+	assert.Equal(
+		t,
+		"expr = expr-head expr-tail-2\nexpr-head = ('x')+\nexpr-tail-2 = ('+' 'x')*\nexpr-tail = 'y'",
+		g.String(),
+	)
+
+	// A self reference alone is dropped
+	g = eliminateLeftRecursion(t, testGrammar("expr = expr | 'x'"))
+	assert.Equal(t, "expr = 'x'", g.String())
+}
+
+func TestEliminateIndirectLeftRecursion(t *testing.T) {
+	g := eliminateLeftRecursion(t, testGrammar(
+		"a = b 'x' | 'y'",
+		"b = a 'z' | 'w'",
+	))
+	assert.Equal(
+		t,
+		"a = b 'x' | 'y'\nb = 'y' 'z' b-tail | 'w' b-tail\nb-tail = ('x' 'z')*",
+		g.String(),
+	)
+
+	g = eliminateLeftRecursion(t, testGrammar(
+		"a = b 'x'",
+		"b = c 'y' | 'v'",
+		"c = a 'z' | 'w'",
+	))
+	assert.Equal(
+		t,
+		"a = b 'x'\nb = c 'y' | 'v'\nc = 'v' 'x' 'z' c-tail | 'w' c-tail\nc-tail = ('y' 'x' 'z')*",
+		g.String(),
+	)
+}
+
+func TestEliminateLeftRecursionErrors(t *testing.T) {
+	_, err := testGrammar("expr = expr '+' 'x'").EliminateLeftRecursion()
+	assert.Equal(t, fmt.Errorf("%s: expr", ErrLeftRecursionNoBase), err)
+
+	_, err = testGrammar("expr = (expr '+')* | 'x'").EliminateLeftRecursion()
+	assert.Equal(t, fmt.Errorf("%s: expr", ErrLeftRecursionRepetition), err)
+
+	_, err = testGrammar(
+		"a = opt a 'x' | 'y'",
+		"opt = ('z')?",
+	).EliminateLeftRecursion()
+	assert.Equal(t, ErrLeftRecursionNullable+": Left recursive rule cycle a at line 0 position 0 -> a", err.Error())
+}
+
+func TestLeftRecursion(t *testing.T) {
+	g := testGrammar(
+		"expr = term '+' expr | term",
+		"term = 'x'",
+	)
+	assert.Nil(t, g.LeftRecursion())
+
+	g = testGrammar(
+		"expr = expr '+' term | term",
+		"a = b 'x' | c 'y' | 'y'",
+		"b = c 'z' | a 'z'",
+		"c = a 'w' | undefined",
+		"term = 'x'",
+	)
+	for i := range g.rules {
+		g.rules[i].SourceNode = OfSourceNodeAt(g.rules[i].String(), i+1, 1)
+	}
+
+	diags := g.LeftRecursion()
+	assert.Equal(t, 2, len(diags))
+
+	assert.Equal(t, DiagLeftRecursion, diags[0].Code())
+	assert.Equal(t, "Left recursive rule cycle expr at line 1 position 1 -> expr", diags[0].Message())
+	assert.Equal(t, 1, diags[0].Line())
+	assert.Equal(t, 1, diags[0].Position())
+
+	assert.Equal(t, DiagLeftRecursion, diags[1].Code())
+	assert.Equal(
+		t,
+		"Left recursive rule cycle a at line 2 position 1 -> b at line 3 position 1 -> a at line 2 position 1",
+		diags[1].String(),
+	)
+}
+
+func TestLeftRecursionNullablePrefix(t *testing.T) {
+	// The recursion is hidden behind a rule that can match nothing
+	g := testGrammar(
+		"a = opt a 'x' | 'y'",
+		"opt = ('z')?",
+	)
+
+	diags := g.LeftRecursion()
+	assert.Equal(t, 1, len(diags))
+	assert.Equal(t, "Left recursive rule cycle a at line 0 position 0 -> a", diags[0].Message())
+
+	assert.Equal(t, DiagLeftRecursion, g.Validate()[0].Code())
+
+	_, err := NewEngine(g, ParseOptions{})
+	assert.Equal(t, DiagLeftRecursion, err.(Diagnostic).Code())
+
+	// A rule that cannot match nothing hides what follows it
+	assert.Nil(t, testGrammar(
+		"a = z a 'x' | 'y'",
+		"z = 'z'",
+	).LeftRecursion())
+}
@@ -0,0 +1,819 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// TokenType is the type of a lexical token
+type TokenType uint
+
+// Lexical token types
+const (
+	TokenInvalid TokenType = iota
+	TokenEOF
+	TokenCommentOneLine
+	TokenCommentMultiLine
+	TokenString
+	TokenRange
+	TokenN
+	TokenM
+	TokenZeroOrOne
+	TokenZeroOrMore
+	TokenOneOrMore
+	TokenIdentifier
+	TokenJoin
+	// An invalid token, when LexOptions.RecoverErrors is true
+	TokenError
+	TokenRepetition
+	// One of the punctuation runes = | ; ( ) of a rule
+	TokenPunctuation
+	// An option of a rule or list item, such as :MEMO
+	TokenOption
+)
+
+// Lexical token type names, in same order as TokenType constants
+var lexTypeNames = []string{
+	"TokenInvalid",
+	"TokenEOF",
+	"TokenCommentOneLine",
+	"TokenCommentMultiLine",
+	"TokenString",
+	"TokenRange",
+	"TokenN",
+	"TokenM",
+	"TokenZeroOrOne",
+	"TokenZeroOrMore",
+	"TokenOneOrMore",
+	"TokenIdentifier",
+	"TokenJoin",
+	"TokenError",
+	"TokenRepetition",
+	"TokenPunctuation",
+	"TokenOption",
+}
+
+// String is the name of the TokenType constant
+func (t TokenType) String() string {
+	if int(t) < len(lexTypeNames) {
+		return lexTypeNames[t]
+	}
+
+	return fmt.Sprintf("TokenType(%d)", uint(t))
+}
+
+// Lexical table actions
+const (
+	lexSkip    uint = 0x01
+	lexAdvance uint = 0x02
+	lexUnread  uint = 0x04
+	lexDone    uint = 0x08
+	lexEOFOK   uint = 0x10
+	lexError   uint = 0x20
+)
+
+// Keys of a table row for the runes of a class, which are used for a rune that has no entry of its own, before -1
+const (
+	// Any letter
+	lexLetter rune = -2
+	// Any decimal digit
+	lexDigit rune = -3
+)
+
+// The next table row to jump to and/or which actions to take
+type lexActions struct {
+	actions uint
+	row     uint
+	lexType TokenType
+	errCode string
+}
+
+// Lexical errors
+const (
+	lexErrPosition     = " at line %d position %d"
+	lexErrSyntax       = "Syntax error"
+	lexErrSyntaxCode   = "-1"
+	lexErrEOF          = "Invalid EOF"
+	lexErrEOFCode      = "-2"
+	lexErrMixedEOL     = "Mixed EOL sequences"
+	lexErrMixedEOLCode = "-3"
+)
+
+// LexError describes a lexical error
+type LexError struct {
+	err      string
+	code     string
+	line     int
+	position int
+}
+
+// Panic with a LexError
+func panicLexError(msg string, code string, line, position int) {
+	panic(
+		LexError{
+			err:      fmt.Sprintf("%s%s", msg, fmt.Sprintf(lexErrPosition, line, position)),
+			code:     code,
+			line:     line,
+			position: position,
+		},
+	)
+}
+
+// Error is error interface
+func (l LexError) Error() string {
+	return l.err
+}
+
+// Token is a lexical token of a grammar
+type Token struct {
+	lexType  TokenType
+	token    string
+	line     int
+	position int
+	// byte offset the token begins at, and the line, position, and byte offset just after it
+	offset      int
+	endLine     int
+	endPosition int
+	endOffset   int
+	// runes of a range token
+	chars RuneSet
+	// bounds of a repetition token, where m is -1 if there is no upper bound, and true if it is lazy
+	n, m int
+	lazy bool
+	// LexError of an error token
+	err error
+}
+
+// String is the type, quoted token, and line and position, such as TokenString "'abc'" at line 1 position 2
+func (t Token) String() string {
+	return fmt.Sprintf("%s %q"+lexErrPosition, t.lexType, t.token, t.line, t.position)
+}
+
+// GoString is Token{lexType: type, token: "token", line: line, position: position}
+func (t Token) GoString() string {
+	return fmt.Sprintf("Token{lexType: %s, token: %q, line: %d, position: %d}", t.lexType, t.token, t.line, t.position)
+}
+
+// Type is the type of the token
+func (t Token) Type() TokenType {
+	return t.lexType
+}
+
+// Text is the text of the token as it appears in the input
+func (t Token) Text() string {
+	return t.token
+}
+
+// Line is the line the token begins at
+func (t Token) Line() int {
+	return t.line
+}
+
+// Position is the position in its line that the token begins at
+func (t Token) Position() int {
+	return t.position
+}
+
+// Span is where the token begins, and where it ends just after its last rune, where offsets are in bytes from the
+// beginning of the input after any byte order mark is removed
+func (t Token) Span() (start, end SourcePosition) {
+	return SourcePosition{Offset: t.offset, Line: t.line, Position: t.position}, SourcePosition{Offset: t.endOffset, Line: t.endLine, Position: t.endPosition}
+}
+
+// Chars are the runes of a TokenRange
+func (t Token) Chars() RuneSet {
+	return t.chars
+}
+
+// Repetition is the bounds of a repetition token, where m is -1 if there is no upper bound, and true if it is lazy
+func (t Token) Repetition() (n, m int, lazy bool) {
+	return t.n, t.m, t.lazy
+}
+
+// Err is the LexError of a TokenError
+func (t Token) Err() error {
+	return t.err
+}
+
+// Maximum number of bytes of input passed to an encoding hook
+const lexEncodingPrefixLen = 1024
+
+// Byte order marks of UTF-8, and UTF-16 little and big endian
+var (
+	lexBOMUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	lexBOMUTF16LE = []byte{0xFF, 0xFE}
+	lexBOMUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// LexOptions are the optional settings of a Lexer
+type LexOptions struct {
+	// DetectBOM removes a UTF-8 byte order mark from the beginning of the input, and transcodes input beginning with a
+	// UTF-16 little or big endian byte order mark into UTF8, as saved by Windows editors, before any encoding hook
+	DetectBOM bool
+	// EncodingHook examines the ASCII compatible prefix of the input, which is the first line up to and including the LF,
+	// or the first 1024 bytes if there is no LF (eg, an XML style <?xml encoding="..."?> prolog).
+	// It returns a function that decodes the remainder of the input into UTF8.
+	// If the hook is nil or returns nil, the remainder is assumed to be UTF8.
+	EncodingHook func(prefix []byte) func(io.Reader) io.Reader
+	// EOLPolicy determines how CR, LF, and CRLF sequences are read
+	EOLPolicy EOLPolicy
+	// TabWidth is the number of positions between tab stops, so reported positions match the columns of an editor.
+	// If it is 0 or 1, a tab is one position like any other character.
+	TabWidth int
+	// ZeroBased is true if the first position of a line is 0, as in editors that count columns from 0, instead of 1
+	ZeroBased bool
+	// Universe is the runes an inverted range such as [^a] matches, before the excluded runes and the runes of the range
+	// are removed, which a //goparse:universe directive changes
+	Universe Universe
+	// Exclude are the runes an inverted range never matches, which are the useless ASCII control characters if it is nil,
+	// and which a //goparse:exclude directive changes
+	Exclude *RuneSet
+	// Trace, if not nil, is called for each rune read with the table row it is read in and the actions taken for it, the
+	// end of the input, and each token emitted, such as LexTraceWriter(os.Stderr)
+	Trace func(LexTrace)
+	// RecoverErrors returns an invalid token as a TokenError of the text read up to and including the rune that made
+	// it invalid, with its LexError, and continues after it, instead of failing, so that editor tooling can tokenize a
+	// file that is being edited. Mixed EOL sequences still fail, as they are an error of the whole input.
+	RecoverErrors bool
+}
+
+// Lexer reads the lexical tokens of a grammar in goparse notation, which ImportGoparse parses. Its methods return a
+// LexError for an invalid token, unless LexOptions.RecoverErrors is true, or the error of the reader, such as invalid
+// UTF8, after which it cannot be used.
+type Lexer struct {
+	reader *lexReader
+	// tokens read ahead by peekN and not yet returned by next, in a ring buffer of count tokens beginning at head
+	ahead []Token
+	head  int
+	count int
+	// tokens returned by next since the oldest mark that is not released or reset, and where each mark begins in them
+	marked []Token
+	marks  []int
+	// runes an inverted range matches, before the runes of the range are removed
+	universe Universe
+	exclude  RuneSet
+	// trace hook, if any
+	trace func(LexTrace)
+	// true if invalid tokens are returned as error tokens
+	recoverErrors bool
+}
+
+// LexMark is a mark of a Lexer, which Reset rewinds to
+type LexMark int
+
+// NewLexer constructs a Lexer of a reader
+func NewLexer(source io.Reader) *Lexer {
+	return NewLexerWithOptions(source, LexOptions{})
+}
+
+// NewLexerWithOptions constructs a Lexer of a reader with options
+func NewLexerWithOptions(source io.Reader, opts LexOptions) *Lexer {
+	if opts.DetectBOM {
+		source = applyBOM(source)
+	}
+	if opts.EncodingHook != nil {
+		source = applyEncodingHook(source, opts.EncodingHook)
+	}
+
+	return newLexerOfReader(newLexReader(source, opts.EOLPolicy, opts.TabWidth, opts.ZeroBased), opts)
+}
+
+// NewLexerFromString constructs a Lexer of a string, where tokens are slices of it unless an EOL sequence in them is
+// normalized
+func NewLexerFromString(source string) *Lexer {
+	return NewLexerFromStringWithOptions(source, LexOptions{})
+}
+
+// NewLexerFromStringWithOptions constructs a Lexer of a string with options, where tokens are slices of it unless an EOL
+// sequence in them is normalized, or the input is transcoded from UTF-16 or decoded by an encoding hook
+func NewLexerFromStringWithOptions(source string, opts LexOptions) *Lexer {
+	if opts.DetectBOM {
+		switch {
+		case strings.HasPrefix(source, string(lexBOMUTF8)):
+			source = source[len(lexBOMUTF8):]
+		case strings.HasPrefix(source, string(lexBOMUTF16LE)), strings.HasPrefix(source, string(lexBOMUTF16BE)):
+			return NewLexerWithOptions(strings.NewReader(source), opts)
+		}
+	}
+
+	if opts.EncodingHook != nil {
+		// Same prefix as applyEncodingHook
+		prefix := source
+		if i := strings.IndexByte(source, '\n'); i >= 0 {
+			prefix = source[:i+1]
+		}
+		if len(prefix) > lexEncodingPrefixLen {
+			prefix = prefix[:lexEncodingPrefixLen]
+		}
+
+		if decoder := opts.EncodingHook([]byte(prefix)); decoder != nil {
+			return newLexerOfReader(
+				newLexReader(
+					io.MultiReader(strings.NewReader(prefix), decoder(strings.NewReader(source[len(prefix):]))),
+					opts.EOLPolicy,
+					opts.TabWidth,
+					opts.ZeroBased,
+				),
+				opts,
+			)
+		}
+	}
+
+	return newLexerOfReader(newLexReaderString(source, opts.EOLPolicy, opts.TabWidth, opts.ZeroBased), opts)
+}
+
+// NewLexerFromBytes constructs a Lexer of bytes, which are copied once into a string that tokens are slices of
+func NewLexerFromBytes(source []byte) *Lexer {
+	return NewLexerFromStringWithOptions(string(source), LexOptions{})
+}
+
+// NewLexerFromBytesWithOptions constructs a Lexer of bytes with options, as described by NewLexerFromStringWithOptions
+func NewLexerFromBytesWithOptions(source []byte, opts LexOptions) *Lexer {
+	return NewLexerFromStringWithOptions(string(source), opts)
+}
+
+// NewLexerFromRuneReader constructs a Lexer of runes that are already decoded
+func NewLexerFromRuneReader(source io.RuneReader) *Lexer {
+	return NewLexerFromRuneReaderWithOptions(source, LexOptions{})
+}
+
+// NewLexerFromRuneReaderWithOptions constructs a Lexer of runes that are already decoded with options, where DetectBOM
+// and EncodingHook do not apply
+func NewLexerFromRuneReaderWithOptions(source io.RuneReader, opts LexOptions) *Lexer {
+	return newLexerOfReader(newLexReaderRunes(source, opts.EOLPolicy, opts.TabWidth, opts.ZeroBased), opts)
+}
+
+// Construct lexer of a reader with the range options of opts
+func newLexerOfReader(reader *lexReader, opts LexOptions) *Lexer {
+	exclude := lexUselessChars
+	if opts.Exclude != nil {
+		exclude = *opts.Exclude
+	}
+
+	return &Lexer{
+		reader:        reader,
+		universe:      opts.Universe,
+		exclude:       exclude,
+		trace:         opts.Trace,
+		recoverErrors: opts.RecoverErrors,
+	}
+}
+
+// Pass the ASCII compatible prefix of the source to the hook, and return a reader of the prefix followed by the decoded remainder
+func applyEncodingHook(source io.Reader, hook func(prefix []byte) func(io.Reader) io.Reader) io.Reader {
+	var (
+		buf = bufio.NewReaderSize(source, lexEncodingPrefixLen)
+		// Any read error other than a full buffer is returned again by subsequent reads of buf
+		line, _ = buf.ReadSlice('\n')
+		// ReadSlice result is only valid until the next read
+		prefix = append([]byte(nil), line...)
+	)
+
+	if decoder := hook(prefix); decoder != nil {
+		return io.MultiReader(bytes.NewReader(prefix), decoder(buf))
+	}
+
+	return io.MultiReader(bytes.NewReader(prefix), buf)
+}
+
+// Return a reader of the source without a byte order mark at the beginning, transcoding UTF-16 into UTF8
+func applyBOM(source io.Reader) io.Reader {
+	var (
+		buf = bufio.NewReader(source)
+		// Any read error is returned again by subsequent reads of buf
+		prefix, _ = buf.Peek(len(lexBOMUTF8))
+	)
+
+	switch {
+	case bytes.HasPrefix(prefix, lexBOMUTF8):
+		buf.Discard(len(lexBOMUTF8))
+	case bytes.HasPrefix(prefix, lexBOMUTF16LE):
+		buf.Discard(len(lexBOMUTF16LE))
+		return &lexUTF16Reader{source: buf}
+	case bytes.HasPrefix(prefix, lexBOMUTF16BE):
+		buf.Discard(len(lexBOMUTF16BE))
+		return &lexUTF16Reader{source: buf, bigEndian: true}
+	}
+
+	return buf
+}
+
+// Reader that transcodes UTF-16 into UTF8, where an unpaired surrogate or a trailing odd byte is read as U+FFFD
+type lexUTF16Reader struct {
+	source    io.Reader
+	bigEndian bool
+	// UTF8 bytes of the last rune decoded that have not been read yet
+	pending []byte
+	buf     [utf8.UTFMax]byte
+	// code unit read after an unpaired high surrogate, which is decoded next
+	haveUnit bool
+	unit     rune
+	err      error
+}
+
+// Read code units from the source and decode them into UTF8
+func (r *lexUTF16Reader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(r.pending) == 0 {
+			if r.err != nil {
+				break
+			}
+
+			char, err := r.readRune()
+			if err != nil {
+				r.err = err
+				break
+			}
+			r.pending = r.buf[:utf8.EncodeRune(r.buf[:], char)]
+		}
+
+		copied := copy(p[n:], r.pending)
+		n += copied
+		r.pending = r.pending[copied:]
+	}
+
+	if n == 0 {
+		return 0, r.err
+	}
+
+	return n, nil
+}
+
+// Read one rune of one or two code units
+func (r *lexUTF16Reader) readRune() (rune, error) {
+	unit, err := r.readUnit()
+	if (err != nil) || !utf16.IsSurrogate(unit) {
+		return unit, err
+	}
+
+	next, err := r.readUnit()
+	if err == io.EOF {
+		return utf8.RuneError, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	if char := utf16.DecodeRune(unit, next); char != utf8.RuneError {
+		return char, nil
+	}
+
+	// The next code unit is not part of a pair with the surrogate
+	r.haveUnit, r.unit = true, next
+	return utf8.RuneError, nil
+}
+
+// Read one code unit
+func (r *lexUTF16Reader) readUnit() (rune, error) {
+	if r.haveUnit {
+		r.haveUnit = false
+		return r.unit, nil
+	}
+
+	var data [2]byte
+	if n, err := io.ReadFull(r.source, data[:]); err == io.ErrUnexpectedEOF {
+		return utf8.RuneError, nil
+	} else if n == 0 {
+		return 0, err
+	}
+
+	if r.bigEndian {
+		return rune(data[0])<<8 | rune(data[1]), nil
+	}
+
+	return rune(data[1])<<8 | rune(data[0]), nil
+}
+
+// Text of a token, which is a slice of the input string of the reader while the runes written are read one after another
+// as they appear in it, and is copied into a builder otherwise
+type lexTokenText struct {
+	reader *lexReader
+	// byte offsets in the input string of the runes written, if any have been written and none have been copied
+	start   int
+	end     int
+	copied  bool
+	builder strings.Builder
+}
+
+// Write the rune read by the last call to next of the reader
+func (t *lexTokenText) write(char rune) {
+	r := t.reader
+	switch {
+	case (r.iter != nil) || t.copied:
+		t.builder.WriteRune(char)
+
+	case (t.start < 0) && r.charRaw:
+		t.start, t.end = r.charStart, r.offset
+
+	case r.charRaw && (r.charStart == t.end):
+		t.end = r.offset
+
+	default:
+		if t.start >= 0 {
+			t.builder.WriteString(r.input[t.start:t.end])
+		}
+		t.copied = true
+		t.builder.WriteRune(char)
+	}
+}
+
+// String is the text written
+func (t *lexTokenText) String() string {
+	if (t.reader.iter == nil) && !t.copied && (t.start >= 0) {
+		return t.reader.input[t.start:t.end]
+	}
+
+	return t.builder.String()
+}
+
+// Read next lexical token, which is the first token read ahead by peek or peekN if there are any.
+// Panics with a LexError if the token is invalid.
+func (l *Lexer) next() Token {
+	var token Token
+	if l.count > 0 {
+		token = l.ahead[l.head]
+		l.head = (l.head + 1) % len(l.ahead)
+		l.count--
+	} else {
+		token = l.scan()
+	}
+
+	if len(l.marks) > 0 {
+		l.marked = append(l.marked, token)
+	}
+
+	return token
+}
+
+// Mark marks the position of the lexer, so that Reset can rewind to it after reading tokens speculatively, such as for
+// an alternative of a backtracking parser. Tokens read after the oldest mark are kept until it is released or reset.
+// Marks nest, where an inner mark must be released or reset before an outer one.
+func (l *Lexer) Mark() LexMark {
+	l.marks = append(l.marks, len(l.marked))
+	return LexMark(len(l.marks) - 1)
+}
+
+// Reset rewinds the lexer to a mark, so that the next calls to Next return the tokens read since it again, without
+// reading them from the reader again. The mark and any marks after it are released.
+func (l *Lexer) Reset(m LexMark) {
+	var (
+		begin  = l.marks[m]
+		tokens = l.marked[begin:]
+	)
+	if len(tokens) > 0 {
+		l.grow(len(tokens))
+		l.head = (l.head - len(tokens) + len(l.ahead)) % len(l.ahead)
+		for i, token := range tokens {
+			l.ahead[(l.head+i)%len(l.ahead)] = token
+		}
+		l.count += len(tokens)
+	}
+
+	l.marked = l.marked[:begin]
+	l.Release(m)
+}
+
+// Release releases a mark and any marks after it without rewinding, such as when an alternative matches, discarding the
+// tokens kept for it if it is the oldest mark
+func (l *Lexer) Release(m LexMark) {
+	if l.marks = l.marks[:m]; len(l.marks) == 0 {
+		l.marked = l.marked[:0]
+	}
+}
+
+// Next reads the next lexical token, which is the first token read ahead by Peek or PeekN if there are any,
+// or returns the error if the token is invalid
+func (l *Lexer) Next() (token Token, err error) {
+	defer recoverLexError(&err)
+
+	return l.next(), nil
+}
+
+// Peek returns the next lexical token without reading it, so the next call to Next returns it,
+// or the error if the token is invalid
+func (l *Lexer) Peek() (token Token, err error) {
+	defer recoverLexError(&err)
+
+	return l.peek(), nil
+}
+
+// PeekN returns the next n lexical tokens without reading them, so the next n calls to Next return them, where each
+// token after the end of the input is EOF, or the error if one of them is invalid, keeping the tokens before it
+func (l *Lexer) PeekN(n int) (tokens []Token, err error) {
+	defer recoverLexError(&err)
+
+	return l.peekN(n), nil
+}
+
+// recoverLexError recovers a LexError, or anything else the reader panicked with, such as invalid UTF8, into err,
+// the same as Stream does
+func recoverLexError(err *error) {
+	if r := recover(); r != nil {
+		if e, isa := r.(error); isa {
+			*err = e
+		} else {
+			*err = fmt.Errorf("%v", r)
+		}
+	}
+}
+
+// Return the next lexical token without reading it, so the next call to next returns it.
+// Panics with a LexError if the token is invalid.
+func (l *Lexer) peek() Token {
+	return l.peekN(1)[0]
+}
+
+// Return the next n lexical tokens without reading them, so the next n calls to next return them, where each token after
+// the end of the input is EOF.
+// Panics with a LexError if one of the tokens is invalid, keeping the tokens before it.
+func (l *Lexer) peekN(n int) []Token {
+	for l.count < n {
+		token := l.scan()
+		l.grow(1)
+		l.ahead[(l.head+l.count)%len(l.ahead)] = token
+		l.count++
+	}
+
+	var result []Token
+	for i := 0; i < n; i++ {
+		result = append(result, l.ahead[(l.head+i)%len(l.ahead)])
+	}
+
+	return result
+}
+
+// Grow the ring buffer if it does not have room for n more tokens, moving the tokens to the beginning
+func (l *Lexer) grow(n int) {
+	if l.count+n <= len(l.ahead) {
+		return
+	}
+
+	size := 2*len(l.ahead) + 1
+	if size < l.count+n {
+		size = l.count + n
+	}
+
+	ahead := make([]Token, size)
+	for i := 0; i < l.count; i++ {
+		ahead[i] = l.ahead[(l.head+i)%len(l.ahead)]
+	}
+	l.ahead, l.head = ahead, 0
+}
+
+// Scan the next lexical token from the reader.
+// Panics with a LexError if the token is invalid, unless errors are recovered from.
+func (l *Lexer) scan() (result Token) {
+	var (
+		nextChar rune
+		token    = lexTokenText{reader: l.reader, start: -1}
+		// line, position, and byte offset where token started, which is the next rune, or EOF if there are no more runes
+		line     = l.reader.line()
+		position = l.reader.position()
+		offset   = l.reader.byteOffset()
+		rowIndex uint
+		row      = lexTable[0]
+		// initial actions in case we read EOF on first call to iter.Next
+		theLexActions = lexActions{actions: lexSkip | lexEOFOK, lexType: TokenEOF}
+		haveActions   bool
+		eofOK         bool
+		writeChar     bool
+	)
+	if l.recoverErrors {
+		defer func() {
+			if r := recover(); r != nil {
+				lexErr, isa := r.(LexError)
+				if !isa || (lexErr.code == lexErrMixedEOLCode) {
+					panic(r)
+				}
+
+				result = Token{
+					lexType:     TokenError,
+					token:       token.String(),
+					line:        line,
+					position:    position,
+					offset:      offset,
+					endLine:     l.reader.line(),
+					endPosition: l.reader.position(),
+					endOffset:   l.reader.byteOffset(),
+					err:         lexErr,
+				}
+				if l.trace != nil {
+					l.trace(LexTrace{kind: LexTraceToken, token: result})
+				}
+			}
+		}()
+	}
+
+	for {
+		haveActions = false
+		if l.reader.next() {
+			nextChar = l.reader.value()
+
+			// get actions for char if they exist, then for its class
+			theLexActions, haveActions = row[nextChar]
+			if !haveActions && unicode.IsLetter(nextChar) {
+				theLexActions, haveActions = row[lexLetter]
+			}
+			if !haveActions && unicode.IsDigit(nextChar) {
+				theLexActions, haveActions = row[lexDigit]
+			}
+			if !haveActions {
+				// get default actions, if they exist
+				theLexActions, haveActions = row[-1]
+			}
+			if !haveActions {
+				// an error token includes the invalid rune
+				token.write(nextChar)
+				// panic at current line and position, not where token started
+				panicLexError(lexErrSyntax, lexErrSyntaxCode, l.reader.line(), l.reader.position()-1)
+			}
+			if l.trace != nil {
+				l.trace(LexTrace{kind: LexTraceRune, row: rowIndex, char: nextChar, actions: theLexActions, line: l.reader.prevLine, position: l.reader.prevPosition})
+			}
+		} else {
+			if l.trace != nil {
+				l.trace(LexTrace{kind: LexTraceEOF, row: rowIndex, line: l.reader.line(), position: l.reader.position()})
+			}
+			if eofOK = (theLexActions.actions & lexEOFOK) > 0; !eofOK {
+				// panic at current line and position, not where token started
+				panicLexError(lexErrEOF, lexErrEOFCode, l.reader.line(), l.reader.position()-1)
+			}
+			break
+		}
+
+		writeChar = true
+
+		// A char to be skipped is a delimiter at the beginning or end of a token
+		if (theLexActions.actions & lexSkip) > 0 {
+			writeChar = false
+		}
+
+		// Advance the position, this character is not part of a token
+		if (theLexActions.actions & lexAdvance) > 0 {
+			line = l.reader.line()
+			position = l.reader.position()
+			offset = l.reader.byteOffset()
+		}
+
+		// either the char is unread because it belongs to next token, or we write it as part of this token
+		if (theLexActions.actions & lexUnread) > 0 {
+			l.reader.unread(nextChar)
+			writeChar = false
+		}
+
+		if writeChar {
+			token.write(nextChar)
+		}
+
+		if (theLexActions.actions & lexError) > 0 {
+			panicLexError(lexErrors[theLexActions.errCode], theLexActions.errCode, l.reader.line(), l.reader.position()-1)
+		}
+
+		if (theLexActions.actions & lexDone) > 0 {
+			break
+		}
+
+		// jump to next row (which could be same row)
+		rowIndex = theLexActions.row
+		row = lexTable[rowIndex]
+	}
+
+	// cannot not encounter EOF in the middle of a token unless allowed
+	if (theLexActions.lexType == TokenEOF) && (!eofOK) {
+		panicLexError(lexErrEOF, lexErrEOFCode, l.reader.line(), l.reader.position())
+	}
+
+	// have a valid token
+	result = Token{
+		lexType:     theLexActions.lexType,
+		token:       token.String(),
+		line:        line,
+		position:    position,
+		offset:      offset,
+		endLine:     l.reader.line(),
+		endPosition: l.reader.position(),
+		endOffset:   l.reader.byteOffset(),
+	}
+	switch {
+	case (result.lexType == TokenString) && strings.Contains(result.token, `\u`):
+		// Only for the code point escapes it checks, so that other strings are not copied
+		lexStringValue(result.token, line, position)
+	case result.lexType == TokenRange:
+		result.chars = l.rangeSet(result.token, line, position)
+	case (result.lexType >= TokenZeroOrOne) && (result.lexType <= TokenOneOrMore), result.lexType == TokenRepetition:
+		result.n, result.m, result.lazy = lexRepetitionBounds(result.token, line, position)
+	case (result.lexType == TokenCommentOneLine) && strings.HasPrefix(result.token, lexDirectivePrefix):
+		l.directive(result.token, line, position)
+	}
+	if l.trace != nil {
+		l.trace(LexTrace{kind: LexTraceToken, token: result})
+	}
+
+	return result
+}
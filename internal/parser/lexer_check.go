@@ -0,0 +1,104 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Lexer invariant error messages
+const (
+	lexErrLostChars     = "Characters are not part of any token or whitespace"
+	lexErrTokenMismatch = "Token does not match the input"
+	lexErrNotMonotonic  = "Token does not begin after the previous token"
+)
+
+// lexAll returns all tokens of the source up to and including EOF, or the LexError of an invalid source
+func lexAll(source string) ([]Token, error) {
+	var tokens []Token
+	for l := NewLexerFromString(source); ; {
+		token, err := l.Next()
+		if err != nil {
+			return tokens, err
+		}
+
+		tokens = append(tokens, token)
+		if token.lexType == TokenEOF {
+			return tokens, nil
+		}
+	}
+}
+
+// CheckLexerPositions lexes the source, and returns an error if a token does not begin at a later line and position than
+// the previous token, or the LexError if the source is invalid. Embedders can run it against their own inputs.
+func CheckLexerPositions(source string) error {
+	tokens, err := lexAll(source)
+	if err != nil {
+		return err
+	}
+
+	for i := 1; i < len(tokens); i++ {
+		prev, token := tokens[i-1], tokens[i]
+		if (token.line < prev.line) || ((token.line == prev.line) && (token.position <= prev.position)) {
+			return fmt.Errorf("%s %s"+lexErrPosition, lexErrNotMonotonic, token, prev.line, prev.position)
+		}
+	}
+
+	return nil
+}
+
+// CheckLexerLossless lexes the source, and returns an error if the tokens and the whitespace between them do not
+// reconstruct the source, or the LexError if the source is invalid. EOL sequences are compared as a single \n,
+// as that is how the lexer reads them. Embedders can run it against their own inputs.
+func CheckLexerLossless(source string) error {
+	tokens, err := lexAll(source)
+	if err != nil {
+		return err
+	}
+
+	var (
+		input = []rune(strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(source))
+		// index of the rune at each line and position
+		index    = map[[2]int]int{}
+		line     = 1
+		position = 1
+		cursor   int
+		isSpace  = func(runes []rune) bool {
+			return strings.Trim(string(runes), " \t\n") == ""
+		}
+	)
+
+	for i, char := range input {
+		index[[2]int{line, position}] = i
+		if char == '\n' {
+			line++
+			position = 1
+		} else {
+			position++
+		}
+	}
+	index[[2]int{line, position}] = len(input)
+
+	for _, token := range tokens[:len(tokens)-1] {
+		var (
+			start, found = index[[2]int{token.line, token.position}]
+			text         = []rune(token.token)
+		)
+
+		switch {
+		case !found || (start+len(text) > len(input)) || (string(input[start:start+len(text)]) != token.token):
+			return fmt.Errorf("%s: %s", lexErrTokenMismatch, token)
+		case start < cursor:
+			return fmt.Errorf("%s: %s", lexErrNotMonotonic, token)
+		case !isSpace(input[cursor:start]):
+			return fmt.Errorf("%s: %q before %s", lexErrLostChars, string(input[cursor:start]), token)
+		}
+
+		cursor = start + len(text)
+	}
+
+	if !isSpace(input[cursor:]) {
+		return fmt.Errorf("%s: %q at end of input", lexErrLostChars, string(input[cursor:]))
+	}
+
+	return nil
+}
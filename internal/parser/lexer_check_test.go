@@ -0,0 +1,37 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckLexerLossless(t *testing.T) {
+	for _, source := range []string{
+		"",
+		" \n\t",
+		"// c\n'x' \"y\"",
+		"/* a\r\nb */ 'x'\r'y'\n",
+		`'sq \t\'"'  "dq"`,
+	} {
+		assert.Nil(t, CheckLexerLossless(source), source)
+		assert.Nil(t, CheckLexerPositions(source), source)
+	}
+
+	assert.Equal(t, "A string cannot be empty at line 1 position 2", CheckLexerLossless("''").Error())
+	assert.Equal(t, "A string cannot be empty at line 1 position 2", CheckLexerPositions("''").Error())
+}
+
+func TestLexAll(t *testing.T) {
+	tokens, err := lexAll("'x'\n 'y'")
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		[]Token{
+			{lexType: TokenString, token: "'x'", line: 1, position: 1, endLine: 1, endPosition: 4, endOffset: 3},
+			{lexType: TokenString, token: "'y'", line: 2, position: 2, offset: 5, endLine: 2, endPosition: 5, endOffset: 8},
+			{lexType: TokenEOF, token: "", line: 2, position: 5, offset: 8, endLine: 2, endPosition: 5, endOffset: 8},
+		},
+		tokens,
+	)
+}
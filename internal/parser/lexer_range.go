@@ -0,0 +1,131 @@
+package parser
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Universe is the runes an inverted range can match
+type Universe uint
+
+const (
+	// Every rune, except the surrogates
+	UniverseUnicode Universe = iota
+	// The runes of 7 bit ASCII
+	UniverseASCII
+)
+
+// Prefix of a one line comment that is a directive to the lexer, such as //goparse:universe ascii
+const lexDirectivePrefix = "//goparse:"
+
+var (
+	// Runes of each universe, where the surrogates are not runes that UTF8 input can have
+	lexUniverseRunes = map[Universe]RuneSet{
+		UniverseUnicode: OfRuneSet(RuneInterval{Lo: 0, Hi: 0xD7FF}, RuneInterval{Lo: 0xE000, Hi: unicode.MaxRune}),
+		UniverseASCII:   OfRuneSet(RuneInterval{Lo: 0, Hi: unicode.MaxASCII}),
+	}
+
+	// Universe of each universe directive
+	lexUniverseNames = map[string]Universe{
+		"unicode": UniverseUnicode,
+		"ascii":   UniverseASCII,
+	}
+
+	// Useless ASCII control characters, which are all of them except tab, newline, and carriage return
+	lexUselessChars = OfRuneSet(
+		RuneInterval{Lo: '\x00', Hi: '\x08'},
+		RuneInterval{Lo: '\x0B', Hi: '\x0C'},
+		RuneInterval{Lo: '\x0E', Hi: '\x1F'},
+		RuneInterval{Lo: '\x7F', Hi: '\x7F'},
+	)
+)
+
+// Return the runes of a range token that begins at a line and position, such as [a-z_], where a - between two runes is
+// the runes from the first through the second, and \\, \t, \n, \r, \], \xNN, and \u{N...} are escapes.
+// A range followed by the flag i, such as [a-z]i, also has the runes each of its runes is equivalent to under simple
+// Unicode case folding, so that it matches letters case insensitively without listing both cases. The table only reads
+// the flag when a rune that cannot continue an identifier follows it, so that it is not confused with a rule name.
+// A range that begins with ^, such as [^a-z], is inverted: it has the runes of the universe of the lexer except the
+// excluded runes and the runes it lists, after they are folded, where [^] has every rune that is not excluded.
+// Panics with a LexError if a range ends before it begins.
+func (l *Lexer) rangeSet(token string, line, position int) RuneSet {
+	var (
+		fold     = strings.HasSuffix(token, "]i")
+		body     = []rune(strings.TrimSuffix(strings.TrimSuffix(token, "i"), "]")[1:])
+		inverted = (len(body) > 0) && (body[0] == '^')
+		chars    []rune
+		// true for each rune of chars that is an unescaped -
+		dash []bool
+	)
+	if inverted {
+		body = body[1:]
+	}
+
+	for i := 0; i < len(body); i++ {
+		if body[i] != '\\' {
+			chars, dash = append(chars, body[i]), append(dash, body[i] == '-')
+			continue
+		}
+
+		var char rune
+		char, i = lexEscape(body, i, line, position)
+		chars, dash = append(chars, char), append(dash, false)
+	}
+
+	var intervals []RuneInterval
+	for i := 0; i < len(chars); i++ {
+		if (i+2 < len(chars)) && dash[i+1] {
+			if chars[i+2] < chars[i] {
+				panicLexError(lexErrors["rangerev"], "rangerev", line, position)
+			}
+
+			intervals = append(intervals, RuneInterval{Lo: chars[i], Hi: chars[i+2]})
+			i += 2
+			continue
+		}
+
+		intervals = append(intervals, RuneInterval{Lo: chars[i], Hi: chars[i]})
+	}
+
+	set := OfRuneSet(intervals...)
+	if fold {
+		set = set.SimpleFold()
+	}
+	if inverted {
+		set = lexUniverseRunes[l.universe].Subtract(l.exclude).Subtract(set)
+	}
+
+	return set
+}
+
+// Apply a directive comment that begins at a line and position to the ranges after it:
+// //goparse:universe unicode or ascii sets the universe of inverted ranges, and //goparse:exclude sets the runes inverted
+// ranges never match to none, or to the runes of a range, such as //goparse:exclude [\t].
+// Panics with a LexError if the directive is not one of these.
+func (l *Lexer) directive(token string, line, position int) {
+	// The value can have spaces, such as a range of a space
+	fields := strings.SplitN(strings.TrimSpace(strings.TrimPrefix(token, lexDirectivePrefix)), " ", 2)
+	if len(fields) == 2 {
+		fields[1] = strings.TrimSpace(fields[1])
+		switch fields[0] {
+		case "universe":
+			if universe, haveIt := lexUniverseNames[fields[1]]; haveIt {
+				l.universe = universe
+				return
+			}
+
+		case "exclude":
+			if fields[1] == "none" {
+				l.exclude = RuneSet{}
+				return
+			}
+
+			if tokens, err := lexAll(fields[1]); (err == nil) && (len(tokens) == 2) && (tokens[0].lexType == TokenRange) {
+				l.exclude = tokens[0].chars
+				return
+			}
+		}
+	}
+
+	panicLexError(lexErrors["directive"], "directive", line, position)
+}
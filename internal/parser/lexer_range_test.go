@@ -0,0 +1,113 @@
+package parser
+
+import (
+	"testing"
+	"unicode"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRange(t *testing.T) {
+	for _, test := range []struct {
+		source string
+		token  string
+		chars  string
+	}{
+		{"[a-c_]", "[a-c_]", "[_a-c]"},
+		{" [-a-] ", "[-a-]", "[a-]"},
+		{`[\]\\\t]`, `[\]\\\t]`, `[\t\\\]]`},
+		{"[a-cK]i", "[a-cK]i", "[A-CKa-ck\u212a]"},
+		{"[é]i\n", "[é]i", "[Éé]"},
+	} {
+		lexer := NewLexerFromString(test.source)
+		token := lexer.next()
+		assert.Equal(t, TokenRange, token.lexType, test.source)
+		assert.Equal(t, test.token, token.token, test.source)
+		assert.Equal(t, test.chars, token.chars.String(), test.source)
+		assert.Equal(t, TokenEOF, lexer.next().lexType, test.source)
+		assert.Nil(t, CheckLexerLossless(test.source), test.source)
+	}
+
+	// Other tokens can follow a range without whitespace
+	tokens, err := lexAll("[a]'i'[b]i[c]")
+	assert.Nil(t, err)
+	assert.Equal(t, 5, len(tokens))
+	assert.Equal(t, OfRuneSetRunes('B', 'b'), tokens[2].chars)
+
+	tokens, err = lexAll("[a]x")
+	assert.Nil(t, err)
+	assert.Equal(t, []TokenType{TokenRange, TokenIdentifier, TokenEOF}, []TokenType{tokens[0].lexType, tokens[1].lexType, tokens[2].lexType})
+
+	_, err = lexAll("[a]#")
+	assert.Equal(t, "Syntax error at line 1 position 4", err.Error())
+
+	// The i flag cannot be followed by a rune that continues an identifier, so that it is not read as part of one
+	tokens, err = lexAll("[a-z] ident [a-z]i;")
+	assert.Nil(t, err)
+	assert.Equal(t, "ident", tokens[1].token)
+	assert.Equal(t, "[a-z]i", tokens[2].token)
+
+	for _, source := range []string{"[a-z]ident", "[a-z]i2", "[a-z]i_", "[a-z]i-"} {
+		_, err = lexAll(source)
+		assert.Equal(t, LexError{err: lexErrors["rangeflag"] + " at line 1 position 7", code: "rangeflag", line: 1, position: 7}, err, source)
+	}
+
+	_, err = lexAll(" [a-cz-a]")
+	assert.Equal(
+		t,
+		LexError{err: "A range cannot end before it begins at line 1 position 2", code: "rangerev", line: 1, position: 2},
+		err,
+	)
+
+	_, err = lexAll("[]")
+	assert.Equal(t, "rangene", err.(LexError).code)
+}
+
+func TestRangeInverted(t *testing.T) {
+	unicodeAll := OfRuneSet(RuneInterval{Lo: 0, Hi: 0xD7FF}, RuneInterval{Lo: 0xE000, Hi: unicode.MaxRune})
+
+	// By default, an inverted range is every rune except the surrogates, the useless ASCII control characters, and the
+	// runes it lists
+	tokens, err := lexAll("[^a-c] [^] [^^] [^a]i")
+	assert.Nil(t, err)
+	assert.Equal(t, unicodeAll.Subtract(lexUselessChars).Subtract(OfRuneSet(RuneInterval{Lo: 'a', Hi: 'c'})), tokens[0].chars)
+	assert.Equal(t, unicodeAll.Subtract(lexUselessChars), tokens[1].chars)
+	assert.True(t, tokens[1].chars.Contains('\t'))
+	assert.False(t, tokens[1].chars.Contains('\x00'))
+	assert.False(t, tokens[2].chars.Contains('^'))
+	assert.False(t, tokens[3].chars.Contains('A'))
+	assert.True(t, tokens[3].chars.Contains('b'))
+
+	// The universe and excluded runes are options of the lexer
+	none := RuneSet{}
+	lexer := NewLexerFromStringWithOptions("[^a]", LexOptions{Universe: UniverseASCII, Exclude: &none})
+	assert.Equal(t, []RuneInterval{{Lo: 0, Hi: '`'}, {Lo: 'b', Hi: unicode.MaxASCII}}, lexer.next().chars.Intervals())
+
+	// and directives change them for the ranges after them
+	tokens, err = lexAll("//goparse:universe ascii\n[^]\n//goparse:exclude [ -~]\n[^]\n//goparse:exclude none\n//goparse:universe unicode\n[^]")
+	assert.Nil(t, err)
+	assert.Equal(t, OfRuneSet(RuneInterval{Lo: 0, Hi: unicode.MaxASCII}).Subtract(lexUselessChars), tokens[1].chars)
+	assert.Equal(t, []RuneInterval{{Lo: 0, Hi: '\x1F'}, {Lo: '\x7F', Hi: '\x7F'}}, tokens[3].chars.Intervals())
+	assert.Equal(t, unicodeAll, tokens[6].chars)
+
+	for _, source := range []string{
+		"//goparse:universe",
+		"//goparse:universe latin1",
+		"//goparse:exclude",
+		"//goparse:exclude 'a'",
+		"//goparse:exclude [a] [b]",
+		"//goparse:other x",
+	} {
+		_, err = lexAll(" " + source)
+		assert.Equal(
+			t,
+			LexError{err: lexErrors["directive"] + " at line 1 position 2", code: "directive", line: 1, position: 2},
+			err,
+			source,
+		)
+	}
+
+	// Other comments are not directives
+	_, err = lexAll("// goparse:universe latin1\n//goparse")
+	assert.Nil(t, err)
+}
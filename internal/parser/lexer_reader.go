@@ -0,0 +1,274 @@
+package parser
+
+import (
+	"io"
+	"unicode/utf8"
+
+	"github.com/bantling/goiter"
+)
+
+// EOLPolicy is how a Lexer reads EOL sequences
+type EOLPolicy uint
+
+const (
+	// CR, LF, and CRLF are all read as a single LF
+	EOLNormalize EOLPolicy = iota
+	// CR, LF, and CRLF are read as they are
+	EOLPreserve
+	// Same as EOLNormalize, except it is an error for the input to contain more than one kind of EOL sequence
+	EOLErrorMixed
+)
+
+// Reads runes, handling EOL sequences according to a policy, and tracking the line and position of the next rune.
+// Regardless of policy, a CR, LF, or CRLF sequence is counted as a single EOL.
+// When a CRLF is preserved, the CR is the last character of the line, and the LF ends the line.
+type lexReader struct {
+	// iter reads the runes of a reader, or is nil if the source is the input string
+	iter *goiter.Iter
+	// input is the source when it is a string, whose runes are decoded in place so that tokens can be slices of it
+	input string
+	// byte offset in input of the next rune to decode, and the size of the last rune decoded, which are the number of
+	// bytes read from the reader when the source is not the input string
+	offset int
+	size   int
+	// byte offset in input of the beginning of the last rune read, which ends at offset, and whether it was read as it
+	// appears in input, which it is not if an EOL sequence was normalized
+	charStart int
+	charRaw   bool
+	eolPolicy EOLPolicy
+	// a tab advances the position to the next tab stop, which is a multiple of tabWidth after the first position,
+	// if tabWidth > 1
+	tabWidth int
+	// first position of a line, which is 0 if positions are zero based, and 1 otherwise
+	firstPosition int
+	// first kind of EOL sequence read
+	eolKind string
+	// true if the source is exhausted
+	eof bool
+	// true if the last rune read from the source was the CR of a preserved CRLF
+	afterCR bool
+	// last rune read
+	char rune
+	// line, position, and byte offset of next rune
+	curLine     int
+	curPosition int
+	curOffset   int
+	// line, position, and byte offset before the last rune read
+	prevLine     int
+	prevPosition int
+	prevOffset   int
+	// rune that was unread, and the line, position, and byte offset after it
+	haveUnread     bool
+	unreadChar     rune
+	unreadLine     int
+	unreadPosition int
+	unreadOffset   int
+}
+
+// Construct lexReader
+func newLexReader(source io.Reader, eolPolicy EOLPolicy, tabWidth int, zeroBased bool) *lexReader {
+	return newLexReaderOf(goiter.OfReaderRunes(source), "", eolPolicy, tabWidth, zeroBased)
+}
+
+// Construct lexReader of a string, whose runes are read without copying it
+func newLexReaderString(source string, eolPolicy EOLPolicy, tabWidth int, zeroBased bool) *lexReader {
+	return newLexReaderOf(nil, source, eolPolicy, tabWidth, zeroBased)
+}
+
+// Construct lexReader of runes that are already decoded, where an invalid rune panics the same as invalid UTF8 read
+// by newLexReader
+func newLexReaderRunes(source io.RuneReader, eolPolicy EOLPolicy, tabWidth int, zeroBased bool) *lexReader {
+	iter := goiter.NewIter(func() (interface{}, bool) {
+		char, size, err := source.ReadRune()
+		switch {
+		case err == io.EOF:
+			return utf8.RuneError, false
+		case err != nil:
+			panic(err)
+		case (char == utf8.RuneError) && (size == 1):
+			panic(goiter.InvalidUTF8EncodingError)
+		}
+
+		return char, true
+	})
+
+	return newLexReaderOf(iter, "", eolPolicy, tabWidth, zeroBased)
+}
+
+// Construct lexReader of an iterator of runes, or of the input string if the iterator is nil
+func newLexReaderOf(iter *goiter.Iter, input string, eolPolicy EOLPolicy, tabWidth int, zeroBased bool) *lexReader {
+	firstPosition := 1
+	if zeroBased {
+		firstPosition = 0
+	}
+
+	return &lexReader{
+		iter:          iter,
+		input:         input,
+		eolPolicy:     eolPolicy,
+		tabWidth:      tabWidth,
+		firstPosition: firstPosition,
+		curLine:       1,
+		curPosition:   firstPosition,
+	}
+}
+
+// Read next rune, returning false if there are no more runes
+func (r *lexReader) next() bool {
+	if r.haveUnread {
+		r.haveUnread = false
+		r.prevLine, r.prevPosition, r.prevOffset = r.curLine, r.curPosition, r.curOffset
+		r.char = r.unreadChar
+		r.curLine, r.curPosition, r.curOffset = r.unreadLine, r.unreadPosition, r.unreadOffset
+		return true
+	}
+
+	if r.eof {
+		return false
+	}
+
+	start := r.offset
+	char, haveChar := r.readRune()
+	if !haveChar {
+		r.eof = true
+		return false
+	}
+
+	r.prevLine, r.prevPosition, r.prevOffset = r.curLine, r.curPosition, r.curOffset
+	r.char = char
+	r.charStart, r.charRaw = start, true
+
+	afterCR := r.afterCR
+	r.afterCR = false
+
+	switch r.char {
+	case '\r':
+		eolKind := "\r"
+		if peek, havePeek := r.readRune(); havePeek {
+			if peek == '\n' {
+				eolKind = "\r\n"
+				if r.eolPolicy == EOLPreserve {
+					// The LF is read next, and ends the line
+					r.unreadRune(peek)
+					r.afterCR = true
+					r.checkEOL(eolKind)
+					r.curPosition, r.curOffset = r.curPosition+1, r.offset
+					return true
+				}
+			} else {
+				r.unreadRune(peek)
+			}
+		} else {
+			r.eof = true
+		}
+
+		r.checkEOL(eolKind)
+		if r.eolPolicy != EOLPreserve {
+			r.char, r.charRaw = '\n', false
+		}
+		r.curLine++
+		r.curPosition = r.firstPosition
+
+	case '\n':
+		// The LF of a preserved CRLF has already been checked
+		if !afterCR {
+			r.checkEOL("\n")
+		}
+		r.curLine++
+		r.curPosition = r.firstPosition
+
+	case '\t':
+		// Advance to the next tab stop, so positions match the column shown by an editor
+		if r.tabWidth > 1 {
+			r.curPosition = ((r.curPosition-r.firstPosition)/r.tabWidth+1)*r.tabWidth + r.firstPosition
+		} else {
+			r.curPosition++
+		}
+
+	default:
+		r.curPosition++
+	}
+	// The offset is after the LF of a CRLF that is normalized
+	r.curOffset = r.offset
+
+	return true
+}
+
+// Read the next rune from the iterator or the input string, returning false if there are no more runes.
+// Invalid UTF8 in the input string panics the same as invalid UTF8 read by the iterator.
+func (r *lexReader) readRune() (rune, bool) {
+	if r.iter != nil {
+		if !r.iter.Next() {
+			return utf8.RuneError, false
+		}
+
+		char := r.iter.RuneValue()
+		r.size = utf8.RuneLen(char)
+		r.offset += r.size
+
+		return char, true
+	}
+
+	if r.offset >= len(r.input) {
+		return utf8.RuneError, false
+	}
+
+	char, size := utf8.DecodeRuneInString(r.input[r.offset:])
+	if (char == utf8.RuneError) && (size == 1) {
+		panic(goiter.InvalidUTF8EncodingError)
+	}
+	r.offset += size
+	r.size = size
+
+	return char, true
+}
+
+// Unread the rune read by the last call to readRune
+func (r *lexReader) unreadRune(char rune) {
+	if r.iter != nil {
+		r.iter.Unread(char)
+	}
+
+	r.offset -= r.size
+}
+
+// Check an EOL sequence against the first EOL sequence read
+func (r *lexReader) checkEOL(eolKind string) {
+	if r.eolKind == "" {
+		r.eolKind = eolKind
+		return
+	}
+
+	if (r.eolPolicy == EOLErrorMixed) && (eolKind != r.eolKind) {
+		panicLexError(lexErrMixedEOL, lexErrMixedEOLCode, r.prevLine, r.prevPosition)
+	}
+}
+
+// The rune read by the last call to next
+func (r *lexReader) value() rune {
+	return r.char
+}
+
+// Unread the rune read by the last call to next, restoring the line and position.
+// Only the last rune read can be unread.
+func (r *lexReader) unread(char rune) {
+	r.haveUnread = true
+	r.unreadChar = char
+	r.unreadLine, r.unreadPosition, r.unreadOffset = r.curLine, r.curPosition, r.curOffset
+	r.curLine, r.curPosition, r.curOffset = r.prevLine, r.prevPosition, r.prevOffset
+}
+
+// The line of the next rune, starting at 1
+func (r *lexReader) line() int {
+	return r.curLine
+}
+
+// The position of the next rune on the line, starting at 1, or 0 if positions are zero based
+func (r *lexReader) position() int {
+	return r.curPosition
+}
+
+// The byte offset of the next rune from the beginning of the input
+func (r *lexReader) byteOffset() int {
+	return r.curOffset
+}
@@ -0,0 +1,99 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readAll(reader *lexReader) (chars string, lines []int, positions []int) {
+	var str strings.Builder
+	for reader.next() {
+		str.WriteRune(reader.value())
+		lines = append(lines, reader.line())
+		positions = append(positions, reader.position())
+	}
+
+	return str.String(), lines, positions
+}
+
+func TestLexReaderNormalize(t *testing.T) {
+	chars, lines, positions := readAll(newLexReader(strings.NewReader("a\r\nb\rc\nd"), EOLNormalize, 0, false))
+	assert.Equal(t, "a\nb\nc\nd", chars)
+	assert.Equal(t, []int{1, 2, 2, 3, 3, 4, 4}, lines)
+	assert.Equal(t, []int{2, 1, 2, 1, 2, 1, 2}, positions)
+
+	chars, lines, _ = readAll(newLexReader(strings.NewReader("\r\n\n\r"), EOLNormalize, 0, false))
+	assert.Equal(t, "\n\n\n", chars)
+	assert.Equal(t, []int{2, 3, 4}, lines)
+}
+
+func TestLexReaderPreserve(t *testing.T) {
+	chars, lines, positions := readAll(newLexReader(strings.NewReader("a\r\nb\rc\nd"), EOLPreserve, 0, false))
+	assert.Equal(t, "a\r\nb\rc\nd", chars)
+	assert.Equal(t, []int{1, 1, 2, 2, 3, 3, 4, 4}, lines)
+	assert.Equal(t, []int{2, 3, 1, 2, 1, 2, 1, 2}, positions)
+}
+
+func TestLexReaderErrorMixed(t *testing.T) {
+	chars, lines, _ := readAll(newLexReader(strings.NewReader("a\r\nb\r\n"), EOLErrorMixed, 0, false))
+	assert.Equal(t, "a\nb\n", chars)
+	assert.Equal(t, []int{1, 2, 2, 3}, lines)
+
+	func() {
+		defer func() {
+			assert.Equal(
+				t,
+				LexError{
+					err:      "Mixed EOL sequences at line 2 position 2",
+					code:     "-3",
+					line:     2,
+					position: 2,
+				},
+				recover(),
+			)
+		}()
+
+		readAll(newLexReader(strings.NewReader("a\nb\r\nc"), EOLErrorMixed, 0, false))
+		assert.Fail(t, "Must panic")
+	}()
+}
+
+func TestLexReaderUnread(t *testing.T) {
+	reader := newLexReader(strings.NewReader("a\nb"), EOLNormalize, 0, false)
+	assert.True(t, reader.next())
+	assert.True(t, reader.next())
+	assert.Equal(t, '\n', reader.value())
+	assert.Equal(t, 2, reader.line())
+	assert.Equal(t, 1, reader.position())
+
+	reader.unread('\n')
+	assert.Equal(t, 1, reader.line())
+	assert.Equal(t, 2, reader.position())
+
+	chars, lines, positions := readAll(reader)
+	assert.Equal(t, "\nb", chars)
+	assert.Equal(t, []int{2, 2}, lines)
+	assert.Equal(t, []int{1, 2}, positions)
+}
+
+func TestLexReaderTabWidth(t *testing.T) {
+	_, _, positions := readAll(newLexReader(strings.NewReader("\ta\t\tb\n\t"), EOLNormalize, 0, false))
+	assert.Equal(t, []int{2, 3, 4, 5, 6, 1, 2}, positions)
+
+	_, _, positions = readAll(newLexReader(strings.NewReader("\ta\t\tb\n\t"), EOLNormalize, 4, false))
+	assert.Equal(t, []int{5, 6, 9, 13, 14, 1, 5}, positions)
+
+	_, _, positions = readAll(newLexReader(strings.NewReader("abc\td"), EOLNormalize, 8, false))
+	assert.Equal(t, []int{2, 3, 4, 9, 10}, positions)
+}
+
+func TestLexReaderZeroBased(t *testing.T) {
+	_, lines, positions := readAll(newLexReader(strings.NewReader("a\tb\nc"), EOLNormalize, 0, true))
+	assert.Equal(t, []int{1, 1, 1, 2, 2}, lines)
+	assert.Equal(t, []int{1, 2, 3, 0, 1}, positions)
+
+	_, _, positions = readAll(newLexReader(strings.NewReader("\ta\t\tb\r\n\t"), EOLNormalize, 4, true))
+	assert.Equal(t, []int{4, 5, 8, 12, 13, 0, 4}, positions)
+}
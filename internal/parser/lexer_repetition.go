@@ -0,0 +1,64 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Return the bounds of a repetition token that begins at a line and position, and true if it is lazy:
+// ? is 0 to 1, * is 0 or more, + is 1 or more, {N} is exactly N, {N,} is N or more, {,N} is 0 to N, and {N,M} is N to M,
+// where m is -1 if there is no upper bound. A repetition followed by ?, such as *? or {2,}?, is lazy.
+// Panics with a LexError if the bounds of a {} repetition are not one of these forms, where N > 0 in {N} and {,N},
+// N <= M and M > 0 in {N,M}, and every number fits in an int.
+func lexRepetitionBounds(token string, line, position int) (n, m int, lazy bool) {
+	if lazy = (len(token) > 1) && strings.HasSuffix(token, "?"); lazy {
+		token = token[:len(token)-1]
+	}
+
+	switch token {
+	case "?":
+		return 0, 1, lazy
+	case "*":
+		return 0, -1, lazy
+	case "+":
+		return 1, -1, lazy
+	}
+
+	var (
+		bounds = strings.Split(token[1:len(token)-1], ",")
+		err    error
+		valid  bool
+	)
+	// An empty bound is 0 for N, and no upper bound for M
+	number := func(str string, empty int) int {
+		if str == "" {
+			return empty
+		}
+
+		var result int
+		if err == nil {
+			result, err = strconv.Atoi(str)
+		}
+		return result
+	}
+
+	if n = number(bounds[0], 0); len(bounds) == 1 {
+		m, valid = n, n > 0
+	} else {
+		m = number(bounds[1], -1)
+		switch {
+		case bounds[0] == "":
+			valid = m > 0
+		case m == -1:
+			valid = true
+		default:
+			valid = (n <= m) && (m > 0)
+		}
+	}
+
+	if (err != nil) || !valid {
+		panicLexError(lexErrors["repform"], "repform", line, position)
+	}
+
+	return n, m, lazy
+}
@@ -0,0 +1,62 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepetition(t *testing.T) {
+	for _, test := range []struct {
+		source  string
+		lexType TokenType
+		n, m    int
+		lazy    bool
+	}{
+		{"?", TokenZeroOrOne, 0, 1, false},
+		{"??", TokenZeroOrOne, 0, 1, true},
+		{"*", TokenZeroOrMore, 0, -1, false},
+		{"*?", TokenZeroOrMore, 0, -1, true},
+		{"+", TokenOneOrMore, 1, -1, false},
+		{" +? ", TokenOneOrMore, 1, -1, true},
+		{"{3}", TokenRepetition, 3, 3, false},
+		{"{0,}", TokenRepetition, 0, -1, false},
+		{"{2,}?", TokenRepetition, 2, -1, true},
+		{"{,4}", TokenRepetition, 0, 4, false},
+		{"{0,1}", TokenRepetition, 0, 1, false},
+		{"{2,5}?", TokenRepetition, 2, 5, true},
+	} {
+		lexer := NewLexerFromString(test.source)
+		token := lexer.next()
+		assert.Equal(t, test.lexType, token.lexType, test.source)
+		assert.Equal(t, test.n, token.n, test.source)
+		assert.Equal(t, test.m, token.m, test.source)
+		assert.Equal(t, test.lazy, token.lazy, test.source)
+		assert.Equal(t, TokenEOF, lexer.next().lexType, test.source)
+		assert.Nil(t, CheckLexerLossless(test.source), test.source)
+	}
+
+	// A repetition follows a term without whitespace, and a second ? is another repetition
+	tokens, err := lexAll("'a'*?'b'{2}???")
+	assert.Nil(t, err)
+	assert.Equal(t, 6, len(tokens))
+	assert.Equal(t, "*?", tokens[1].token)
+	assert.Equal(t, "{2}?", tokens[3].token)
+	assert.Equal(t, "??", tokens[4].token)
+
+	for source, msg := range map[string]string{
+		"{}":                      "A repetition must be {N} or {,N} where N > 0, {N,}, or {N,M} where N <= M and M > 0 at line 1 position 2",
+		"{a}":                     "A repetition must be {N} or {,N} where N > 0, {N,}, or {N,M} where N <= M and M > 0 at line 1 position 2",
+		"{1,2,3}":                 "A repetition must be {N} or {,N} where N > 0, {N,}, or {N,M} where N <= M and M > 0 at line 1 position 5",
+		"{0}":                     "A repetition must be {N} or {,N} where N > 0, {N,}, or {N,M} where N <= M and M > 0 at line 1 position 1",
+		"{,}":                     "A repetition must be {N} or {,N} where N > 0, {N,}, or {N,M} where N <= M and M > 0 at line 1 position 1",
+		"{,0}":                    "A repetition must be {N} or {,N} where N > 0, {N,}, or {N,M} where N <= M and M > 0 at line 1 position 1",
+		"{3,2}":                   "A repetition must be {N} or {,N} where N > 0, {N,}, or {N,M} where N <= M and M > 0 at line 1 position 1",
+		"{0,0}":                   "A repetition must be {N} or {,N} where N > 0, {N,}, or {N,M} where N <= M and M > 0 at line 1 position 1",
+		" {99999999999999999999}": "A repetition must be {N} or {,N} where N > 0, {N,}, or {N,M} where N <= M and M > 0 at line 1 position 2",
+		"{2":                      "Invalid EOF at line 1 position 2",
+	} {
+		_, err := lexAll(source)
+		assert.Equal(t, msg, err.Error(), source)
+	}
+}
@@ -0,0 +1,30 @@
+//go:build go1.23
+// +build go1.23
+
+package parser
+
+import (
+	"iter"
+)
+
+// Tokens is the sequence of the lexical tokens up to EOF, which can be ranged over, as in
+// for token, err := range lexer.Tokens(). The sequence ends after the last token before EOF, or after the error of an
+// invalid token, which is the only element with an error.
+//
+// Tokens only exists when building with Go 1.23 or later, which has the iter package.
+func (l *Lexer) Tokens() iter.Seq2[Token, error] {
+	return func(yield func(Token, error) bool) {
+		for {
+			token, err := l.Next()
+			switch {
+			case err != nil:
+				yield(Token{}, err)
+				return
+			case token.lexType == TokenEOF:
+				return
+			case !yield(token, nil):
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,42 @@
+//go:build go1.23
+// +build go1.23
+
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokens(t *testing.T) {
+	var tokens []string
+	for token, err := range NewLexerFromString("'a' // b\n'c'").Tokens() {
+		assert.Nil(t, err)
+		tokens = append(tokens, token.token)
+	}
+	assert.Equal(t, []string{"'a'", "// b", "'c'"}, tokens)
+
+	// Breaking out of the loop leaves the rest of the tokens
+	lexer := NewLexerFromString("'a' 'b'")
+	for range lexer.Tokens() {
+		break
+	}
+	assert.Equal(t, "'b'", lexer.next().token)
+
+	// An invalid token ends the sequence with its error
+	tokens = nil
+	for token, err := range NewLexerFromString("'a' ''").Tokens() {
+		if err != nil {
+			assert.Equal(t, "A string cannot be empty at line 1 position 6", err.Error())
+			tokens = append(tokens, "error")
+			continue
+		}
+		tokens = append(tokens, token.token)
+	}
+	assert.Equal(t, []string{"'a'", "error"}, tokens)
+
+	for range NewLexerFromString("").Tokens() {
+		assert.Fail(t, "Must not yield EOF")
+	}
+}
@@ -0,0 +1,62 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+)
+
+// Number of tokens the channel of a stream holds, so that lexing can run ahead of a consumer that is parsing
+const lexStreamBuffer = 64
+
+// Stream lexes the tokens up to EOF in a goroutine, sending each to the returned token channel, so that a consumer can
+// parse tokens while later tokens are lexed. The lexer must not be used by anything else until the token channel is closed.
+// When lexing ends, the token channel is closed, then the error channel receives the error lexing ended with, if any,
+// and is closed: the LexError of an invalid token, any other error the reader panicked with, such as invalid UTF8,
+// or the error of the context when it is canceled.
+func (l *Lexer) Stream(ctx context.Context) (<-chan Token, <-chan error) {
+	var (
+		tokens = make(chan Token, lexStreamBuffer)
+		errs   = make(chan error, 1)
+	)
+
+	go func() {
+		err := l.stream(ctx, tokens)
+		close(tokens)
+		if err != nil {
+			errs <- err
+		}
+		close(errs)
+	}()
+
+	return tokens, errs
+}
+
+// stream sends the tokens up to EOF to the channel, returning the error lexing ended with, if any
+func (l *Lexer) stream(ctx context.Context, tokens chan<- Token) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if e, isa := r.(error); isa {
+				err = e
+			} else {
+				err = fmt.Errorf("%v", r)
+			}
+		}
+	}()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		token := l.next()
+		if token.lexType == TokenEOF {
+			return nil
+		}
+
+		select {
+		case tokens <- token:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
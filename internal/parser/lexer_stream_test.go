@@ -0,0 +1,63 @@
+package parser
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStream(t *testing.T) {
+	// Every token before EOF is received, and the channels are closed without an error
+	var (
+		tokens, errs = NewLexerFromString("'a' // b\n'c'").Stream(context.Background())
+		got          []string
+	)
+	for token := range tokens {
+		got = append(got, token.token)
+	}
+	assert.Equal(t, []string{"'a'", "// b", "'c'"}, got)
+	assert.Nil(t, <-errs)
+
+	// An invalid token ends the stream with its error
+	tokens, errs = NewLexerFromString("'a' ''").Stream(context.Background())
+	got = nil
+	for token := range tokens {
+		got = append(got, token.token)
+	}
+	assert.Equal(t, []string{"'a'"}, got)
+	assert.Equal(t, "A string cannot be empty at line 1 position 6", (<-errs).Error())
+	_, open := <-errs
+	assert.False(t, open)
+
+	// The token channel is closed before the error is sent
+	tokens, errs = NewLexerFromString("'a' ''").Stream(context.Background())
+	assert.Equal(t, "A string cannot be empty at line 1 position 6", (<-errs).Error())
+	assert.Equal(t, "'a'", (<-tokens).token)
+	select {
+	case _, open = <-tokens:
+		assert.False(t, open)
+	default:
+		assert.Fail(t, "The token channel is not closed")
+	}
+
+	// Invalid UTF8 is an error instead of a panic
+	tokens, errs = NewLexerFromString("'\xFF'").Stream(context.Background())
+	for range tokens {
+	}
+	assert.Equal(t, "Invalid UTF 8 encoding", (<-errs).Error())
+
+	// Canceling the context stops a lexer that is ahead of its consumer
+	ctx, cancel := context.WithCancel(context.Background())
+	tokens, errs = NewLexerFromString(strings.Repeat("'a' ", 2*lexStreamBuffer)).Stream(ctx)
+	assert.Equal(t, "'a'", (<-tokens).token)
+	cancel()
+
+	count := 1
+	for range tokens {
+		count++
+	}
+	assert.True(t, count < 2*lexStreamBuffer)
+	assert.Equal(t, context.Canceled, <-errs)
+}
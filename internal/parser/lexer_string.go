@@ -0,0 +1,68 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// Return the text of a string token that begins at a line and position without its quotes, where \\, \t, \n, \r, \', \",
+// \xNN, and \u{N...} are escapes in a quoted string, and a raw string in backquotes, such as `C:\dir`, has no escapes,
+// as in Go, so that a terminal of backslashes such as a regular expression or path can be written as it is.
+// Panics with a LexError if a code point escape is invalid.
+func lexStringValue(token string, line, position int) string {
+	if token[0] == '`' {
+		return token[1 : len(token)-1]
+	}
+
+	var (
+		body  = []rune(token[1 : len(token)-1])
+		value strings.Builder
+	)
+	for i := 0; i < len(body); i++ {
+		char := body[i]
+		if char == '\\' {
+			char, i = lexEscape(body, i, line, position)
+		}
+		value.WriteRune(char)
+	}
+
+	return value.String()
+}
+
+// Return the rune of the escape that begins with the backslash at index i of the runes of a token that begins at a line
+// and position, and the index of the last rune of the escape, where \xNN is the rune of two hex digits, and \u{N...} the
+// rune of one or more hex digits, such as \u{1F600}, and any other rune after the backslash is itself, except \t, \n, and \r.
+// The lexer table only accepts the escapes of a string or range.
+// Panics with a LexError if a code point escape is a surrogate or greater than unicode.MaxRune.
+func lexEscape(runes []rune, i int, line, position int) (rune, int) {
+	i++
+	switch runes[i] {
+	case 't':
+		return '\t', i
+	case 'n':
+		return '\n', i
+	case 'r':
+		return '\r', i
+	case 'x':
+		value, _ := strconv.ParseUint(string(runes[i+1:i+3]), 16, 8)
+		return rune(value), i + 2
+	case 'u':
+		end := i + 2
+		for runes[end] != '}' {
+			end++
+		}
+
+		value, err := strconv.ParseUint(string(runes[i+2:end]), 16, 32)
+		switch {
+		case (err != nil) || (value > unicode.MaxRune):
+			panicLexError(lexErrors["escrange"], "escrange", line, position)
+		case utf16.IsSurrogate(rune(value)):
+			panicLexError(lexErrors["escsurr"], "escsurr", line, position)
+		}
+		return rune(value), end
+	}
+
+	return runes[i], i
+}
@@ -0,0 +1,72 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRawString(t *testing.T) {
+	tokens, err := lexAll(" `\\d+\\.` `'\"\n\\`'`'")
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		[]Token{
+			{lexType: TokenString, token: "`\\d+\\.`", line: 1, position: 2, offset: 1, endLine: 1, endPosition: 9, endOffset: 8},
+			{lexType: TokenString, token: "`'\"\n\\`", line: 1, position: 10, offset: 9, endLine: 2, endPosition: 3, endOffset: 15},
+			{lexType: TokenString, token: "'`'", line: 2, position: 3, offset: 15, endLine: 2, endPosition: 6, endOffset: 18},
+			{lexType: TokenEOF, token: "", line: 2, position: 6, offset: 18, endLine: 2, endPosition: 6, endOffset: 18},
+		},
+		tokens,
+	)
+	assert.Equal(t, `\d+\.`, lexStringValue(tokens[0].token, 1, 1))
+	assert.Equal(t, "'\"\n\\", lexStringValue(tokens[1].token, 1, 1))
+	assert.Nil(t, CheckLexerLossless(" `\\d+\\.` `'\"\n\\`'`'"))
+
+	_, err = lexAll("``")
+	assert.Equal(t, LexError{err: "A string cannot be empty at line 1 position 2", code: "stringne", line: 1, position: 2}, err)
+
+	_, err = lexAll("`a")
+	assert.Equal(t, "Invalid EOF at line 1 position 2", err.Error())
+}
+
+func TestStringValue(t *testing.T) {
+	assert.Equal(t, "a\tb\n\\'\"", lexStringValue(`'a\tb\n\\\'\"'`, 1, 1))
+	assert.Equal(t, "é", lexStringValue(`"é"`, 1, 1))
+}
+
+func TestCodePointEscapes(t *testing.T) {
+	tokens, err := lexAll(`'\x41\u{1F600}' "\x7e\u{0}\u{10FFFF}" [\x41-\x43\u{1F600}]`)
+	assert.Nil(t, err)
+	assert.Equal(t, "A\U0001F600", lexStringValue(tokens[0].token, 1, 1))
+	assert.Equal(t, "~\x00\U0010FFFF", lexStringValue(tokens[1].token, 1, 1))
+	assert.Equal(t, OfRuneSetRunes('A', 'B', 'C', '\U0001F600'), tokens[2].chars)
+
+	for source, code := range map[string]string{
+		` '\u{D800}'`:      "escsurr",
+		` "\u{DFFF}"`:      "escsurr",
+		` [\u{D800}]`:      "escsurr",
+		` '\u{110000}'`:    "escrange",
+		` '\u{100000000}'`: "escrange",
+	} {
+		_, err = lexAll(source)
+		assert.Equal(t, code, err.(LexError).code, source)
+		assert.Equal(t, lexErrors[code]+" at line 1 position 2", err.Error(), source)
+	}
+
+	for _, source := range []string{`'\uD800'`, `'\x4'`, `'\xG0'`, `'\u{}'`, `'\u{41'`, `"\x"`, `[\u{x}]`} {
+		_, err = lexAll(source)
+		assert.NotNil(t, err, source)
+	}
+	// Every kind of quoted string and range reports an invalid escape the same way
+	for source, code := range map[string]string{`'\q'`: "stringesc", `"\q"`: "stringesc", `[\q]`: "rangeesc"} {
+		_, err = lexAll(source)
+		assert.Equal(t, LexError{err: lexErrors[code] + " at line 1 position 3", code: code, line: 1, position: 3}, err, source)
+	}
+
+	tokens, err = lexAll(`'\r' "\r" [\r]`)
+	assert.Nil(t, err)
+	assert.Equal(t, "\r", lexStringValue(tokens[0].token, 1, 1))
+	assert.Equal(t, "\r", lexStringValue(tokens[1].token, 1, 1))
+	assert.Equal(t, OfRuneSetRunes('\r'), tokens[2].chars)
+}
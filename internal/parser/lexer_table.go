@@ -0,0 +1,306 @@
+package parser
+
+var (
+	// Lexical error codes and their strings
+	lexErrors = map[string]string{
+		"stringne":  "A string cannot be empty",
+		"stringesc": `A string escape must be \\, \t, \n, \r, \', \", \xNN, or \u{N...}`,
+		"rangene":   "A range cannot be empty",
+		"rangeesc":  `A range escape must be \\, \t, \n, \r, \], \xNN, or \u{N...}`,
+		"rangeflag": "A range flag i must not be followed by a letter, digit, _, or -, such as [a-z]i [0-9]",
+		"rangerev":  "A range cannot end before it begins",
+		"escsurr":   "A code point escape cannot be a surrogate",
+		"escrange":  "A code point escape cannot be greater than 10FFFF",
+		"directive": "A directive must be //goparse:universe unicode or ascii, or //goparse:exclude none or a range",
+		"repform":   "A repetition must be {N} or {,N} where N > 0, {N,}, or {N,M} where N <= M and M > 0",
+	}
+
+	// Lexical analyzer table, where each row is compressed into a map.
+	// Since a rune is actually an int32, use -1 to refer to any other character, and lexLetter and lexDigit to refer to any
+	// letter or decimal digit that has no entry of its own.
+	// If a row does not contain an entry for a given rune, its class, or -1, it is a syntax error.
+	lexTable = []map[rune]lexActions{
+		// 0 - start
+		{
+			'\t': {actions: lexSkip | lexAdvance | lexEOFOK, lexType: TokenEOF},
+			// lexReader coalesces all EOL sequences into \n, unless they are preserved
+			'\n': {actions: lexSkip | lexAdvance | lexEOFOK, lexType: TokenEOF},
+			'\r': {actions: lexSkip | lexAdvance | lexEOFOK, lexType: TokenEOF},
+			' ':  {actions: lexSkip | lexAdvance | lexEOFOK, lexType: TokenEOF},
+			'/':  {row: 1},
+			'\'': {row: 5},
+			'"':  {row: 8},
+			'[':  {row: 11},
+			'`':  {row: 16},
+			'*':  {actions: lexEOFOK, row: 33, lexType: TokenZeroOrMore},
+			'+':  {actions: lexEOFOK, row: 34, lexType: TokenOneOrMore},
+			'?':  {actions: lexEOFOK, row: 35, lexType: TokenZeroOrOne},
+			'{':  {row: 36},
+			// identifier: (letter | "_") (letter | digit | "_" | "-")* ("." (letter | "_") ...)*, such as super.name
+			lexLetter: {actions: lexEOFOK, row: 41, lexType: TokenIdentifier},
+			'_':       {actions: lexEOFOK, row: 41, lexType: TokenIdentifier},
+			'=':       {actions: lexDone, lexType: TokenPunctuation},
+			'|':       {actions: lexDone, lexType: TokenPunctuation},
+			';':       {actions: lexDone, lexType: TokenPunctuation},
+			'(':       {actions: lexDone, lexType: TokenPunctuation},
+			')':       {actions: lexDone, lexType: TokenPunctuation},
+			':':       {row: 43},
+		},
+		// 1
+		{
+			'/': {actions: lexEOFOK, row: 2, lexType: TokenCommentOneLine},
+			'*': {row: 3},
+		},
+		// 2 - comment-one-line
+		{
+			'\n': {actions: lexUnread | lexDone, lexType: TokenCommentOneLine},
+			'\r': {actions: lexUnread | lexDone, lexType: TokenCommentOneLine},
+			-1:   {actions: lexEOFOK, lexType: TokenCommentOneLine, row: 2},
+		},
+		// 3 - comment-multi-line
+		{
+			'*': {row: 4},
+			-1:  {row: 3},
+		},
+		// 4
+		{
+			'*': {row: 4},
+			'/': {actions: lexDone, lexType: TokenCommentMultiLine},
+			-1:  {row: 3},
+		},
+		// 5 - string: "'" string-sq-chars+ "'"
+		{
+			'\'': {actions: lexError, errCode: "stringne"},
+			'\\': {row: 6},
+			-1:   {row: 7},
+		},
+		// 6
+		{
+			'\\': {row: 7},
+			't':  {row: 7},
+			'n':  {row: 7},
+			'r':  {row: 7},
+			'\'': {row: 7},
+			'"':  {row: 7},
+			'x':  {row: 18},
+			'u':  {row: 20},
+			-1:   {actions: lexError, errCode: "stringesc"},
+		},
+		// 7
+		{
+			'\'': {actions: lexDone, lexType: TokenString},
+			'\\': {row: 6},
+			-1:   {row: 7},
+		},
+		// 8 - string: '"' string-qq-chars+ '"'
+		{
+			'"':  {actions: lexError, errCode: "stringne"},
+			'\\': {row: 9},
+			-1:   {row: 10},
+		},
+		// 9
+		{
+			'\\': {row: 10},
+			't':  {row: 10},
+			'n':  {row: 10},
+			'r':  {row: 10},
+			'\'': {row: 10},
+			'"':  {row: 10},
+			'x':  {row: 23},
+			'u':  {row: 25},
+			-1:   {actions: lexError, errCode: "stringesc"},
+		},
+		// 10
+		{
+			'"':  {actions: lexDone, lexType: TokenString},
+			'\\': {row: 9},
+			-1:   {row: 10},
+		},
+		// 11 - range: "[" "^"? range-chars+ "]" "i"?, where a range of only "^" is inverted and empty
+		{
+			']':  {actions: lexError, errCode: "rangene"},
+			'^':  {row: 15},
+			'\\': {row: 12},
+			-1:   {row: 13},
+		},
+		// 12
+		{
+			'\\': {row: 13},
+			't':  {row: 13},
+			'n':  {row: 13},
+			'r':  {row: 13},
+			']':  {row: 13},
+			'x':  {row: 28},
+			'u':  {row: 30},
+			-1:   {actions: lexError, errCode: "rangeesc"},
+		},
+		// 13
+		{
+			']':  {actions: lexEOFOK, row: 14, lexType: TokenRange},
+			'\\': {row: 12},
+			-1:   {row: 13},
+		},
+		// 14 - range flags: "i" folds case, which must be followed by a rune that cannot continue an identifier, so that
+		// [a-z]ident is not read as [a-z]i dent
+		{
+			'i': {actions: lexEOFOK, row: 45, lexType: TokenRange},
+			-1:  {actions: lexUnread | lexDone, lexType: TokenRange},
+		},
+		// 15 - inverted range
+		{
+			']':  {actions: lexEOFOK, row: 14, lexType: TokenRange},
+			'\\': {row: 12},
+			-1:   {row: 13},
+		},
+		// 16 - raw string: "`" raw-chars+ "`", where a backslash is literal
+		{
+			'`': {actions: lexError, errCode: "stringne"},
+			-1:  {row: 17},
+		},
+		// 17
+		{
+			'`': {actions: lexDone, lexType: TokenString},
+			-1:  {row: 17},
+		},
+		// 18 - \x hex hex escape of a single quoted string
+		lexHexDigits(19, nil),
+		// 19
+		lexHexDigits(7, nil),
+		// 20 - \u{ hex+ } escape of a single quoted string
+		{
+			'{': {row: 21},
+		},
+		// 21
+		lexHexDigits(22, nil),
+		// 22
+		lexHexDigits(22, map[rune]lexActions{'}': {row: 7}}),
+		// 23 - \x hex hex escape of a double quoted string
+		lexHexDigits(24, nil),
+		// 24
+		lexHexDigits(10, nil),
+		// 25 - \u{ hex+ } escape of a double quoted string
+		{
+			'{': {row: 26},
+		},
+		// 26
+		lexHexDigits(27, nil),
+		// 27
+		lexHexDigits(27, map[rune]lexActions{'}': {row: 10}}),
+		// 28 - \x hex hex escape of a range
+		lexHexDigits(29, nil),
+		// 29
+		lexHexDigits(13, nil),
+		// 30 - \u{ hex+ } escape of a range
+		{
+			'{': {row: 31},
+		},
+		// 31
+		lexHexDigits(32, nil),
+		// 32
+		lexHexDigits(32, map[rune]lexActions{'}': {row: 13}}),
+		// 33 - repetition: "*" "?"?, where "?" is lazy
+		{
+			'?': {actions: lexDone, lexType: TokenZeroOrMore},
+			-1:  {actions: lexUnread | lexDone, lexType: TokenZeroOrMore},
+		},
+		// 34 - repetition: "+" "?"?
+		{
+			'?': {actions: lexDone, lexType: TokenOneOrMore},
+			-1:  {actions: lexUnread | lexDone, lexType: TokenOneOrMore},
+		},
+		// 35 - repetition: "?" "?"?
+		{
+			'?': {actions: lexDone, lexType: TokenZeroOrOne},
+			-1:  {actions: lexUnread | lexDone, lexType: TokenZeroOrOne},
+		},
+		// 36 - repetition: "{" digit* ("," digit*)? "}" "?"?, where lexRepetitionBounds checks the digits
+		lexDecDigits(37, map[rune]lexActions{
+			',': {row: 38},
+			-1:  {actions: lexError, errCode: "repform"},
+		}),
+		// 37
+		lexDecDigits(37, map[rune]lexActions{
+			',': {row: 38},
+			'}': {actions: lexEOFOK, row: 40, lexType: TokenRepetition},
+			-1:  {actions: lexError, errCode: "repform"},
+		}),
+		// 38
+		lexDecDigits(39, map[rune]lexActions{
+			'}': {actions: lexEOFOK, row: 40, lexType: TokenRepetition},
+			-1:  {actions: lexError, errCode: "repform"},
+		}),
+		// 39
+		lexDecDigits(39, map[rune]lexActions{
+			'}': {actions: lexEOFOK, row: 40, lexType: TokenRepetition},
+			-1:  {actions: lexError, errCode: "repform"},
+		}),
+		// 40
+		{
+			'?': {actions: lexDone, lexType: TokenRepetition},
+			-1:  {actions: lexUnread | lexDone, lexType: TokenRepetition},
+		},
+		// 41 - identifier
+		{
+			lexLetter: {actions: lexEOFOK, row: 41, lexType: TokenIdentifier},
+			lexDigit:  {actions: lexEOFOK, row: 41, lexType: TokenIdentifier},
+			'_':       {actions: lexEOFOK, row: 41, lexType: TokenIdentifier},
+			'-':       {actions: lexEOFOK, row: 41, lexType: TokenIdentifier},
+			'.':       {row: 42},
+			-1:        {actions: lexUnread | lexDone, lexType: TokenIdentifier},
+		},
+		// 42 - identifier after a "."
+		{
+			lexLetter: {actions: lexEOFOK, row: 41, lexType: TokenIdentifier},
+			'_':       {actions: lexEOFOK, row: 41, lexType: TokenIdentifier},
+		},
+		// 43 - option: ":" upper+, such as :MEMO
+		lexOptionLetters(nil),
+		// 44
+		lexOptionLetters(map[rune]lexActions{-1: {actions: lexUnread | lexDone, lexType: TokenOption}}),
+		// 45 - after the range flag "i"
+		{
+			lexLetter: {actions: lexError, errCode: "rangeflag"},
+			lexDigit:  {actions: lexError, errCode: "rangeflag"},
+			'_':       {actions: lexError, errCode: "rangeflag"},
+			'-':       {actions: lexError, errCode: "rangeflag"},
+			-1:        {actions: lexUnread | lexDone, lexType: TokenRange},
+		},
+	}
+)
+
+// Return a table row where each upper case letter of an option jumps to the row of the rest of the option, along with
+// the actions of other runes
+func lexOptionLetters(other map[rune]lexActions) map[rune]lexActions {
+	result := map[rune]lexActions{}
+	for char, actions := range other {
+		result[char] = actions
+	}
+	for char := 'A'; char <= 'Z'; char++ {
+		result[char] = lexActions{actions: lexEOFOK, row: 44, lexType: TokenOption}
+	}
+
+	return result
+}
+
+// Return a table row where each hexadecimal digit jumps to a row, along with the actions of other runes
+func lexHexDigits(row uint, other map[rune]lexActions) map[rune]lexActions {
+	return lexDigits("0123456789abcdefABCDEF", row, other)
+}
+
+// Return a table row where each decimal digit jumps to a row, along with the actions of other runes
+func lexDecDigits(row uint, other map[rune]lexActions) map[rune]lexActions {
+	return lexDigits("0123456789", row, other)
+}
+
+// Return a table row where each of the digits jumps to a row, along with the actions of other runes
+func lexDigits(digits string, row uint, other map[rune]lexActions) map[rune]lexActions {
+	result := map[rune]lexActions{}
+	for char, actions := range other {
+		result[char] = actions
+	}
+	for _, char := range digits {
+		result[char] = lexActions{row: row}
+	}
+
+	return result
+}
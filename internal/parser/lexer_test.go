@@ -0,0 +1,685 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/bantling/goiter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSkipWhitespaceEOF(t *testing.T) {
+	var (
+		tests = []string{
+			"",
+			" \n \r\n  \t\t\r\r\n\n\r\n\r\n",
+		}
+		lines = []int{
+			1,
+			8,
+		}
+		reader io.Reader
+		lexer  *Lexer
+		token  Token
+	)
+
+	for i, test := range tests {
+		reader = strings.NewReader(test)
+		lexer = NewLexer(reader)
+		token = lexer.next()
+		assert.Equal(t, TokenEOF, token.lexType)
+		assert.Equal(t, "", token.token)
+		assert.Equal(t, lines[i], token.line)
+		assert.Equal(t, 1, token.position)
+	}
+}
+
+func TestCommentOneLine(t *testing.T) {
+	var (
+		tests = []string{
+			"//",
+			" // yahdy //*/",
+			"  // yahdy //*/\rhk",
+		}
+		results = []string{
+			"//",
+			"// yahdy //*/",
+			"// yahdy //*/",
+		}
+		reader io.Reader
+		lexer  *Lexer
+		token  Token
+	)
+
+	for i, test := range tests {
+		reader = strings.NewReader(test)
+		lexer = NewLexer(reader)
+		token = lexer.next()
+		assert.Equal(t, TokenCommentOneLine, token.lexType)
+		assert.Equal(t, results[i], token.token)
+		assert.Equal(t, 1, token.line)
+		assert.Equal(t, strings.IndexRune(test, '/')+1, token.position)
+	}
+}
+
+func TestString(t *testing.T) {
+	var (
+		tests = []string{
+			`'sq \t\'"'`,
+			`"dq \t'\""`,
+		}
+		reader io.Reader
+		lexer  *Lexer
+		token  Token
+	)
+
+	for i, test := range tests {
+		reader = strings.NewReader(test)
+		lexer = NewLexer(reader)
+		token = lexer.next()
+		assert.Equal(t, TokenString, token.lexType)
+		assert.Equal(t, tests[i], token.token)
+		assert.Equal(t, 1, token.line)
+		assert.Equal(t, 1, token.position)
+	}
+
+	func() {
+		defer func() {
+			assert.Equal(
+				t,
+				LexError{
+					err:      "A string cannot be empty at line 1 position 2",
+					code:     "stringne",
+					line:     1,
+					position: 2,
+				},
+				recover(),
+			)
+		}()
+
+		reader = strings.NewReader(`''`)
+		lexer = NewLexer(reader)
+		lexer.next()
+		assert.Fail(t, "Must panic")
+	}()
+
+	func() {
+		defer func() {
+			assert.Equal(
+				t,
+				LexError{
+					err:      `A string escape must be \\, \t, \n, \r, \', \", \xNN, or \u{N...} at line 1 position 3`,
+					code:     "stringesc",
+					line:     1,
+					position: 3,
+				},
+				recover(),
+			)
+		}()
+
+		reader = strings.NewReader(`'\q'`)
+		lexer = NewLexer(reader)
+		lexer.next()
+		assert.Fail(t, "Must panic")
+	}()
+
+	func() {
+		defer func() {
+			assert.Equal(
+				t,
+				LexError{
+					err:      `Invalid EOF at line 1 position 3`,
+					code:     "-2",
+					line:     1,
+					position: 3,
+				},
+				recover(),
+			)
+		}()
+
+		reader = strings.NewReader(`'\'`)
+		lexer = NewLexer(reader)
+		lexer.next()
+		assert.Fail(t, "Must panic")
+	}()
+}
+
+func TestEncodingHook(t *testing.T) {
+	var (
+		// Decode ISO-8859-1 into UTF8, where each byte is the code point of the same value
+		latin1 = func(src io.Reader) io.Reader {
+			data, _ := ioutil.ReadAll(src)
+			runes := make([]rune, len(data))
+			for i, b := range data {
+				runes[i] = rune(b)
+			}
+
+			return strings.NewReader(string(runes))
+		}
+		prefixes []string
+		hook     = func(prefix []byte) func(io.Reader) io.Reader {
+			prefixes = append(prefixes, string(prefix))
+			if strings.Contains(string(prefix), "latin1") {
+				return latin1
+			}
+
+			return nil
+		}
+		reader io.Reader
+		lexer  *Lexer
+		token  Token
+	)
+
+	reader = strings.NewReader("// encoding latin1\n'caf\xe9'")
+	lexer = NewLexerWithOptions(reader, LexOptions{EncodingHook: hook})
+	token = lexer.next()
+	assert.Equal(t, TokenCommentOneLine, token.lexType)
+	assert.Equal(t, "// encoding latin1", token.token)
+	token = lexer.next()
+	assert.Equal(t, TokenString, token.lexType)
+	assert.Equal(t, "'café'", token.token)
+	assert.Equal(t, 2, token.line)
+	assert.Equal(t, 1, token.position)
+
+	// No EOL, so the whole input is the prefix
+	reader = strings.NewReader("'café'")
+	lexer = NewLexerWithOptions(reader, LexOptions{EncodingHook: hook})
+	token = lexer.next()
+	assert.Equal(t, TokenString, token.lexType)
+	assert.Equal(t, "'café'", token.token)
+
+	assert.Equal(t, []string{"// encoding latin1\n", "'café'"}, prefixes)
+}
+
+func TestEOLPolicy(t *testing.T) {
+	var (
+		reader io.Reader
+		lexer  *Lexer
+		token  Token
+	)
+
+	reader = strings.NewReader("/* a\r\nb */\r\n// c\r\n")
+	lexer = NewLexerWithOptions(reader, LexOptions{EOLPolicy: EOLPreserve})
+	token = lexer.next()
+	assert.Equal(t, TokenCommentMultiLine, token.lexType)
+	assert.Equal(t, "/* a\r\nb */", token.token)
+	token = lexer.next()
+	assert.Equal(t, TokenCommentOneLine, token.lexType)
+	assert.Equal(t, "// c", token.token)
+	assert.Equal(t, 3, token.line)
+	assert.Equal(t, 1, token.position)
+	token = lexer.next()
+	assert.Equal(t, TokenEOF, token.lexType)
+
+	reader = strings.NewReader("/* a\r\nb */\r\n// c\r\n")
+	lexer = NewLexer(reader)
+	token = lexer.next()
+	assert.Equal(t, "/* a\nb */", token.token)
+	token = lexer.next()
+	assert.Equal(t, "// c", token.token)
+	assert.Equal(t, 3, token.line)
+}
+
+func TestTabWidth(t *testing.T) {
+	var (
+		reader io.Reader
+		lexer  *Lexer
+		token  Token
+	)
+
+	reader = strings.NewReader("\t\t'a'")
+	lexer = NewLexerWithOptions(reader, LexOptions{TabWidth: 4})
+	token = lexer.next()
+	assert.Equal(t, TokenString, token.lexType)
+	assert.Equal(t, 9, token.position)
+
+	func() {
+		defer func() {
+			assert.Equal(
+				t,
+				LexError{
+					err:      "A string cannot be empty at line 1 position 10",
+					code:     "stringne",
+					line:     1,
+					position: 10,
+				},
+				recover(),
+			)
+		}()
+
+		reader = strings.NewReader("\t\t''")
+		lexer = NewLexerWithOptions(reader, LexOptions{TabWidth: 4})
+		lexer.next()
+		assert.Fail(t, "Must panic")
+	}()
+}
+
+func TestZeroBased(t *testing.T) {
+	lexer := NewLexerWithOptions(strings.NewReader("'a'\n\t'b'"), LexOptions{TabWidth: 4, ZeroBased: true})
+	token := lexer.next()
+	assert.Equal(t, []int{1, 0}, []int{token.line, token.position})
+	token = lexer.next()
+	assert.Equal(t, []int{2, 4}, []int{token.line, token.position})
+
+	func() {
+		defer func() {
+			assert.Equal(
+				t,
+				LexError{
+					err:      "A string cannot be empty at line 1 position 5",
+					code:     "stringne",
+					line:     1,
+					position: 5,
+				},
+				recover(),
+			)
+		}()
+
+		NewLexerWithOptions(strings.NewReader("\t''"), LexOptions{TabWidth: 4, ZeroBased: true}).next()
+		assert.Fail(t, "Must panic")
+	}()
+}
+
+func TestTokenString(t *testing.T) {
+	token := Token{lexType: TokenString, token: "'abc'", line: 1, position: 2}
+	assert.Equal(t, `TokenString "'abc'" at line 1 position 2`, fmt.Sprintf("%v", token))
+	assert.Equal(t, `Token{lexType: TokenString, token: "'abc'", line: 1, position: 2}`, fmt.Sprintf("%#v", token))
+	assert.Equal(t, "TokenType(99)", TokenType(99).String())
+}
+
+func TestDetectBOM(t *testing.T) {
+	var (
+		// UTF-16 of a string, little or big endian
+		encode = func(str string, bigEndian bool) string {
+			var data []byte
+			for _, unit := range utf16.Encode([]rune(str)) {
+				if bigEndian {
+					data = append(data, byte(unit>>8), byte(unit))
+				} else {
+					data = append(data, byte(unit), byte(unit>>8))
+				}
+			}
+
+			return string(data)
+		}
+		lexer *Lexer
+		token Token
+	)
+
+	for _, source := range []string{
+		"\xEF\xBB\xBF'café😀' 'b'",
+		encode("\uFEFF'café😀' 'b'", false),
+		encode("\uFEFF'café😀' 'b'", true),
+	} {
+		lexer = NewLexerWithOptions(strings.NewReader(source), LexOptions{DetectBOM: true})
+		token = lexer.next()
+		assert.Equal(t, TokenString, token.lexType)
+		assert.Equal(t, "'café😀'", token.token)
+		assert.Equal(t, 1, token.position)
+		token = lexer.next()
+		assert.Equal(t, "'b'", token.token)
+		assert.Equal(t, 9, token.position)
+		assert.Equal(t, TokenEOF, lexer.next().lexType)
+	}
+
+	// Without detection, the UTF-8 mark is a syntax error
+	func() {
+		defer func() {
+			assert.Equal(t, "Syntax error at line 1 position 1", recover().(LexError).Error())
+		}()
+
+		NewLexerWithOptions(strings.NewReader("\xEF\xBB\xBF'a'"), LexOptions{}).next()
+		assert.Fail(t, "Must panic")
+	}()
+
+	// Input without a mark is read as is, before the encoding hook
+	var prefix string
+	lexer = NewLexerWithOptions(strings.NewReader("'a'"), LexOptions{
+		DetectBOM: true,
+		EncodingHook: func(p []byte) func(io.Reader) io.Reader {
+			prefix = string(p)
+			return nil
+		},
+	})
+	assert.Equal(t, "'a'", lexer.next().token)
+	assert.Equal(t, "'a'", prefix)
+
+	// Unpaired surrogates and a trailing odd byte are U+FFFD
+	data, err := ioutil.ReadAll(applyBOM(strings.NewReader("\xFF\xFE" + "a\x00" + "\x00\xD8" + "b\x00" + "\x00\xDC" + "c")))
+	assert.Nil(t, err)
+	assert.Equal(t, "a�b��", string(data))
+
+	data, err = ioutil.ReadAll(applyBOM(strings.NewReader("\xFE\xFF" + "\xD8\x00")))
+	assert.Nil(t, err)
+	assert.Equal(t, "�", string(data))
+}
+
+func TestLexerFromString(t *testing.T) {
+	lexTokens := func(lexer *Lexer) (tokens []Token) {
+		for {
+			token := lexer.next()
+			tokens = append(tokens, token)
+			if token.lexType == TokenEOF {
+				return tokens
+			}
+		}
+	}
+
+	for _, source := range []string{
+		"",
+		"'café😀' \"b\"\t// x",
+		"/* a\r\nb */\r\n// c\r\n'd'",
+		"/* a\rb */\n\t'e'",
+		"\xEF\xBB\xBF'f'",
+	} {
+		for _, opts := range []LexOptions{
+			{},
+			{EOLPolicy: EOLPreserve},
+			{TabWidth: 4, ZeroBased: true},
+			{DetectBOM: true},
+		} {
+			if strings.HasPrefix(source, "\xEF") && !opts.DetectBOM {
+				continue
+			}
+
+			expected := lexTokens(NewLexerWithOptions(strings.NewReader(source), opts))
+			assert.Equal(t, expected, lexTokens(NewLexerFromStringWithOptions(source, opts)), source)
+			assert.Equal(t, expected, lexTokens(NewLexerFromBytesWithOptions([]byte(source), opts)), source)
+			if !opts.DetectBOM {
+				assert.Equal(t, expected, lexTokens(NewLexerFromRuneReaderWithOptions(strings.NewReader(source), opts)), source)
+			}
+		}
+	}
+
+	assert.Equal(t, "'a'", NewLexerFromString("'a'").next().token)
+	assert.Equal(t, "'a'", NewLexerFromBytes([]byte("'a'")).next().token)
+	assert.Equal(t, "'a'", NewLexerFromRuneReader(strings.NewReader("'a'")).next().token)
+
+	// Tokens are slices of the source, so lexing allocates no more for more tokens
+	var (
+		short  = strings.Repeat("'abc' // d\n", 10)
+		long   = strings.Repeat(short, 10)
+		allocs = func(source string) float64 {
+			return testing.AllocsPerRun(10, func() {
+				for l := NewLexerFromString(source); l.next().lexType != TokenEOF; {
+				}
+			})
+		}
+	)
+	assert.Equal(t, allocs(short), allocs(long))
+
+	// UTF-16 and encoding hooks are decoded as a reader
+	l := NewLexerFromStringWithOptions("'a'\n'b'", LexOptions{
+		EncodingHook: func(p []byte) func(io.Reader) io.Reader {
+			assert.Equal(t, "'a'\n", string(p))
+			return func(r io.Reader) io.Reader { return strings.NewReader("'c'") }
+		},
+	})
+	assert.Equal(t, "'a'", l.next().token)
+	assert.Equal(t, "'c'", l.next().token)
+
+	l = NewLexerFromStringWithOptions("\xFF\xFE'\x00a\x00'\x00", LexOptions{DetectBOM: true})
+	assert.Equal(t, "'a'", l.next().token)
+
+	// Invalid UTF-8 panics the same as a reader
+	for _, l := range []*Lexer{
+		NewLexerFromString("'\xFF'"),
+		NewLexerFromRuneReader(strings.NewReader("'\xFF'")),
+	} {
+		func() {
+			defer func() {
+				assert.Equal(t, recoverNext(NewLexer(strings.NewReader("'\xFF'"))), recover())
+			}()
+
+			l.next()
+			assert.Fail(t, "Must panic")
+		}()
+	}
+}
+
+// recoverNext returns the value the next call of the lexer panics with
+func recoverNext(lexer *Lexer) (r interface{}) {
+	defer func() { r = recover() }()
+	lexer.next()
+	return nil
+}
+
+func TestPeek(t *testing.T) {
+	var (
+		lexer  = NewLexerFromString("'a' 'b'\n// c\n'd'")
+		tokens []string
+	)
+
+	assert.Equal(t, "'a'", lexer.peek().token)
+	assert.Equal(t, "'a'", lexer.peek().token)
+	assert.Equal(t, "'a'", lexer.next().token)
+
+	for _, token := range lexer.peekN(5) {
+		tokens = append(tokens, token.String())
+	}
+	assert.Equal(
+		t,
+		[]string{
+			`TokenString "'b'" at line 1 position 5`,
+			`TokenCommentOneLine "// c" at line 2 position 1`,
+			`TokenString "'d'" at line 3 position 1`,
+			`TokenEOF "" at line 3 position 4`,
+			`TokenEOF "" at line 3 position 4`,
+		},
+		tokens,
+	)
+	assert.Nil(t, lexer.peekN(0))
+
+	// Reading and peeking wrap around the ring buffer
+	assert.Equal(t, "'b'", lexer.next().token)
+	assert.Equal(t, "// c", lexer.next().token)
+	assert.Equal(t, []Token{lexer.peek()}, lexer.peekN(1))
+	assert.Equal(t, TokenString, lexer.peekN(6)[0].lexType)
+	assert.Equal(t, "'d'", lexer.next().token)
+	for i := 0; i < 6; i++ {
+		assert.Equal(t, TokenEOF, lexer.next().lexType)
+	}
+
+	// An invalid token panics when it is peeked, keeping the valid tokens before it
+	lexer = NewLexerFromString("'a' ''")
+	func() {
+		defer func() {
+			assert.Equal(t, "A string cannot be empty at line 1 position 6", recover().(LexError).Error())
+		}()
+
+		lexer.peekN(2)
+		assert.Fail(t, "Must panic")
+	}()
+	assert.Equal(t, "'a'", lexer.next().token)
+
+	// Peek and PeekN return the error instead
+	lexer = NewLexerFromString("'a' ''")
+	token, err := lexer.Peek()
+	assert.Equal(t, "'a'", token.Text())
+	assert.Nil(t, err)
+	peeked, err := lexer.PeekN(2)
+	assert.Nil(t, peeked)
+	assert.Equal(t, "A string cannot be empty at line 1 position 6", err.Error())
+	token, err = lexer.Next()
+	assert.Equal(t, "'a'", token.Text())
+	assert.Nil(t, err)
+
+	// Invalid UTF8 is an error
+	_, err = NewLexerFromString("'\xFF'").Next()
+	assert.Equal(t, goiter.InvalidUTF8EncodingError, err.Error())
+}
+
+func TestToken(t *testing.T) {
+	lexer := NewLexerFromString("[a-c] 'x'{2,}?")
+
+	token, err := lexer.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, TokenRange, token.Type())
+	assert.Equal(t, "[a-c]", token.Text())
+	assert.Equal(t, 1, token.Line())
+	assert.Equal(t, 1, token.Position())
+	assert.Equal(t, OfRuneSet(RuneInterval{Lo: 'a', Hi: 'c'}), token.Chars())
+
+	_, err = lexer.Next()
+	assert.Nil(t, err)
+	token, err = lexer.Next()
+	assert.Nil(t, err)
+	assert.Equal(t, TokenRepetition, token.Type())
+	n, m, lazy := token.Repetition()
+	assert.Equal(t, []interface{}{2, -1, true}, []interface{}{n, m, lazy})
+
+	// An error token has its error
+	token, err = NewLexerFromStringWithOptions("!", LexOptions{RecoverErrors: true}).Next()
+	assert.Nil(t, err)
+	assert.Equal(t, TokenError, token.Type())
+	assert.Equal(t, "Syntax error at line 1 position 1", token.Err().Error())
+}
+
+func TestMarkReset(t *testing.T) {
+	var (
+		l      = NewLexer(strings.NewReader("'a' 'b' 'c' 'd'"))
+		tokens = func(n int) string {
+			var result []string
+			for i := 0; i < n; i++ {
+				result = append(result, l.next().token)
+			}
+			return strings.Join(result, " ")
+		}
+	)
+
+	// Resetting rewinds to the mark, and the tokens are read again without reading the reader
+	outer := l.Mark()
+	assert.Equal(t, "'a' 'b'", tokens(2))
+	inner := l.Mark()
+	assert.Equal(t, "'c'", tokens(1))
+	l.Reset(inner)
+	assert.Equal(t, "'c'", l.peek().token)
+	l.Reset(outer)
+	assert.Equal(t, "'a' 'b' 'c' 'd'", tokens(4))
+	assert.Equal(t, TokenEOF, l.next().lexType)
+
+	// Releasing keeps the tokens read, and discards the kept tokens once no mark is left
+	l = NewLexerFromString("'a' 'b' 'c'")
+	outer = l.Mark()
+	assert.Equal(t, "'a'", tokens(1))
+	inner = l.Mark()
+	assert.Equal(t, "'b'", tokens(1))
+	l.Release(inner)
+	l.Reset(outer)
+	assert.Equal(t, "'a' 'b'", tokens(2))
+	l.Release(l.Mark())
+	assert.Equal(t, 0, len(l.marked))
+
+	// Tokens peeked before a reset follow the tokens it rewinds
+	l = NewLexerFromString("'a' 'b' 'c'")
+	outer = l.Mark()
+	assert.Equal(t, "'a'", tokens(1))
+	assert.Equal(t, "'b'", l.peek().token)
+	l.Reset(outer)
+	assert.Equal(t, "'a' 'b' 'c'", tokens(3))
+
+	// Resetting a mark with no tokens read since it changes nothing
+	l.Reset(l.Mark())
+	assert.Equal(t, TokenEOF, l.next().lexType)
+}
+
+func TestRecoverErrors(t *testing.T) {
+	var (
+		l      = NewLexerFromStringWithOptions("'a' ! '' /x [b-a] 'c' 'd", LexOptions{RecoverErrors: true})
+		tokens []string
+		errs   []string
+	)
+	for {
+		token := l.next()
+		tokens = append(tokens, fmt.Sprintf("%s %q %d", token.lexType, token.token, token.position))
+		if token.err != nil {
+			errs = append(errs, token.err.Error())
+		}
+		if token.lexType == TokenEOF {
+			break
+		}
+	}
+
+	// Each error token spans the text read up to and including the rune that made it invalid, and lexing continues after it
+	assert.Equal(
+		t,
+		[]string{
+			`TokenString "'a'" 1`,
+			`TokenError "!" 5`,
+			`TokenError "''" 7`,
+			`TokenError "/x" 10`,
+			`TokenError "[b-a]" 13`,
+			`TokenString "'c'" 19`,
+			`TokenError "'d" 23`,
+			`TokenEOF "" 25`,
+		},
+		tokens,
+	)
+	assert.Equal(
+		t,
+		[]string{
+			"Syntax error at line 1 position 5",
+			"A string cannot be empty at line 1 position 8",
+			"Syntax error at line 1 position 11",
+			"A range cannot end before it begins at line 1 position 13",
+			"Invalid EOF at line 1 position 24",
+		},
+		errs,
+	)
+
+	// Mixed EOL sequences are still an error of the whole input
+	assert.Panics(t, func() {
+		l = NewLexerWithOptions(strings.NewReader("'a'\n'b'\r'c'"), LexOptions{EOLPolicy: EOLErrorMixed, RecoverErrors: true})
+		for l.next().lexType != TokenEOF {
+		}
+	})
+}
+
+func TestIdentifierPunctuationOption(t *testing.T) {
+	tokens, err := lexAll("élan_2-x:MEMO:EOL=(super.b)|;")
+	assert.Nil(t, err)
+
+	var (
+		types []TokenType
+		texts []string
+	)
+	for _, token := range tokens {
+		types, texts = append(types, token.lexType), append(texts, token.token)
+	}
+	assert.Equal(
+		t,
+		[]TokenType{
+			TokenIdentifier,
+			TokenOption,
+			TokenOption,
+			TokenPunctuation,
+			TokenPunctuation,
+			TokenIdentifier,
+			TokenPunctuation,
+			TokenPunctuation,
+			TokenPunctuation,
+			TokenEOF,
+		},
+		types,
+	)
+	assert.Equal(t, []string{"élan_2-x", ":MEMO", ":EOL", "=", "(", "super.b", ")", "|", ";", ""}, texts)
+
+	// The span of a token is in bytes
+	start, end := tokens[0].Span()
+	assert.Equal(t, SourcePosition{Offset: 0, Line: 1, Position: 1}, start)
+	assert.Equal(t, SourcePosition{Offset: 9, Line: 1, Position: 9}, end)
+
+	for source, msg := range map[string]string{
+		"a.":    "Invalid EOF at line 1 position 2",
+		"a.1":   "Syntax error at line 1 position 3",
+		":":     "Invalid EOF at line 1 position 1",
+		":memo": "Syntax error at line 1 position 2",
+	} {
+		_, err = lexAll(source)
+		assert.Equal(t, msg, err.Error(), source)
+	}
+}
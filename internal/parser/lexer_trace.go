@@ -0,0 +1,102 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// LexTraceKind is the kind of a LexTrace event
+type LexTraceKind uint
+
+// Kinds of LexTrace events
+const (
+	// A rune was read, and the actions of the table row for it were taken
+	LexTraceRune LexTraceKind = iota
+	// The end of the input was read
+	LexTraceEOF
+	// A token was emitted
+	LexTraceToken
+)
+
+// Names of the lexical table actions, in the order of their bits
+var lexActionNames = []string{"skip", "advance", "unread", "done", "eofok", "error"}
+
+// LexTrace is an event of a lexer trace, which is a rune read in a row of the table and the actions taken for it,
+// the end of the input read in a row, or a token emitted
+type LexTrace struct {
+	kind LexTraceKind
+	// row of the table the rune or EOF was read in
+	row uint
+	// rune read, and the actions taken for it, which include the row to jump to
+	char    rune
+	actions lexActions
+	// line and position of the rune or EOF
+	line     int
+	position int
+	// token emitted
+	token Token
+}
+
+// Kind is the kind of the event
+func (t LexTrace) Kind() LexTraceKind {
+	return t.kind
+}
+
+// Row is the row of the table the rune or EOF was read in
+func (t LexTrace) Row() uint {
+	return t.row
+}
+
+// Char is the rune read
+func (t LexTrace) Char() rune {
+	return t.char
+}
+
+// Line is the line of the rune or EOF read
+func (t LexTrace) Line() int {
+	return t.line
+}
+
+// Position is the position of the rune or EOF read
+func (t LexTrace) Position() int {
+	return t.position
+}
+
+// Token is the token emitted
+func (t LexTrace) Token() Token {
+	return t.token
+}
+
+// String is the event for people, such as row 0 '/' -> row 1 at line 1 position 1, row 2 EOF at line 1 position 4,
+// or token TokenCommentOneLine "//a" at line 1 position 1
+func (t LexTrace) String() string {
+	switch t.kind {
+	case LexTraceEOF:
+		return fmt.Sprintf("row %d EOF"+lexErrPosition, t.row, t.line, t.position)
+	case LexTraceToken:
+		return "token " + t.token.String()
+	}
+
+	var str strings.Builder
+	fmt.Fprintf(&str, "row %d %q", t.row, t.char)
+	for i, name := range lexActionNames {
+		if (t.actions.actions & (1 << uint(i))) > 0 {
+			str.WriteString(" " + name)
+		}
+	}
+	if (t.actions.actions & (lexDone | lexError)) == 0 {
+		fmt.Fprintf(&str, " -> row %d", t.actions.row)
+	}
+	fmt.Fprintf(&str, lexErrPosition, t.line, t.position)
+
+	return str.String()
+}
+
+// LexTraceWriter returns a trace hook that writes each event to a writer on a line of its own, ignoring write errors,
+// so that a trace can be logged to a file or os.Stderr while diagnosing why a grammar tokenizes as it does
+func LexTraceWriter(w io.Writer) func(LexTrace) {
+	return func(t LexTrace) {
+		fmt.Fprintln(w, t)
+	}
+}
@@ -0,0 +1,56 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLexTrace(t *testing.T) {
+	var (
+		str   strings.Builder
+		lexer = NewLexerFromStringWithOptions("//a\n 'b'", LexOptions{Trace: LexTraceWriter(&str)})
+	)
+	assert.Equal(t, TokenCommentOneLine, lexer.next().lexType)
+	assert.Equal(t, TokenString, lexer.next().lexType)
+	assert.Equal(t, TokenEOF, lexer.next().lexType)
+	assert.Equal(
+		t,
+		`row 0 '/' -> row 1 at line 1 position 1
+row 1 '/' eofok -> row 2 at line 1 position 2
+row 2 'a' eofok -> row 2 at line 1 position 3
+row 2 '\n' unread done at line 1 position 4
+token TokenCommentOneLine "//a" at line 1 position 1
+row 0 '\n' skip advance eofok -> row 0 at line 1 position 4
+row 0 ' ' skip advance eofok -> row 0 at line 2 position 1
+row 0 '\'' -> row 5 at line 2 position 2
+row 5 'b' -> row 7 at line 2 position 3
+row 7 '\'' done at line 2 position 4
+token TokenString "'b'" at line 2 position 2
+row 0 EOF at line 2 position 5
+token TokenEOF "" at line 2 position 5
+`,
+		str.String(),
+	)
+
+	// Tokens are traced when they are scanned, not again when they are read after being peeked
+	var tokens []string
+	trace := func(event LexTrace) {
+		if event.Kind() == LexTraceToken {
+			tokens = append(tokens, event.Token().Text())
+		}
+	}
+	lexer = NewLexerFromStringWithOptions("'a''b'", LexOptions{Trace: trace})
+	lexer.peekN(2)
+	lexer.next()
+	lexer.next()
+	assert.Equal(t, []string{"'a'", "'b'"}, tokens)
+
+	// The rune of an error is traced before the error
+	str.Reset()
+	lexer = NewLexerFromStringWithOptions("''", LexOptions{Trace: LexTraceWriter(&str)})
+	_, err := lexer.Next()
+	assert.Equal(t, "A string cannot be empty at line 1 position 2", err.Error())
+	assert.Equal(t, "row 0 '\\'' -> row 5 at line 1 position 1\nrow 5 '\\'' error at line 1 position 2\n", str.String())
+}
@@ -0,0 +1,91 @@
+package parser
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// Position is a position in the input of a parse
+type Position struct {
+	// Offset is the offset of a rune, and ByteOffset is the offset of its first byte
+	Offset     int
+	ByteOffset int
+	// Line is the line of the rune, starting at 1, and Column is its column, starting at 1 unless the conventions of
+	// positions are zero based
+	Line   int
+	Column int
+}
+
+// LineIndex is the offset of the beginning of each line of an input, which converts offsets of runes into lines and
+// columns without scanning the input. A parse builds one for its input, which every node of the tree shares.
+type LineIndex struct {
+	input     []rune
+	positions PositionOptions
+	// offset and byte offset of the first rune of each line
+	starts     []int
+	byteStarts []int
+}
+
+// OfLineIndex constructs a LineIndex of an input, where a tab advances one column, and the first column is 1
+func OfLineIndex(input string) *LineIndex {
+	return newLineIndex([]rune(input), PositionOptions{})
+}
+
+// OfLineIndexOptions constructs a LineIndex of an input, with columns of the conventions of positions
+func OfLineIndexOptions(input string, positions PositionOptions) *LineIndex {
+	return newLineIndex([]rune(input), positions)
+}
+
+// newLineIndex constructs a LineIndex of an input of runes, with columns of the conventions of positions
+func newLineIndex(input []rune, positions PositionOptions) *LineIndex {
+	var (
+		result     = &LineIndex{input: input, positions: positions, starts: []int{0}, byteStarts: []int{0}}
+		byteOffset int
+	)
+	for i, char := range input {
+		byteOffset += utf8.RuneLen(char)
+		if char == '\n' {
+			result.starts = append(result.starts, i+1)
+			result.byteStarts = append(result.byteStarts, byteOffset)
+		}
+	}
+
+	return result
+}
+
+// Lines returns the number of lines, which is one more than the number of newlines
+func (l *LineIndex) Lines() int {
+	return len(l.starts)
+}
+
+// Position returns the position of the rune at offset, where an offset past the end of the input is the end of the input
+func (l *LineIndex) Position(offset int) Position {
+	if offset < 0 {
+		offset = 0
+	} else if offset > len(l.input) {
+		offset = len(l.input)
+	}
+
+	line := sort.Search(len(l.starts), func(i int) bool { return l.starts[i] > offset }) - 1
+
+	return Position{
+		Offset:     offset,
+		ByteOffset: l.byteStarts[line] + len(string(l.input[l.starts[line]:offset])),
+		Line:       line + 1,
+		Column:     l.positions.column(l.input, l.starts[line], offset),
+	}
+}
+
+// LineText returns the text of a line starting at 1, without its newline, or "" if there is no such line
+func (l *LineIndex) LineText(line int) string {
+	if (line < 1) || (line > len(l.starts)) {
+		return ""
+	}
+
+	end := len(l.input)
+	if line < len(l.starts) {
+		end = l.starts[line] - 1
+	}
+
+	return string(l.input[l.starts[line-1]:end])
+}
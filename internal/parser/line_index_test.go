@@ -0,0 +1,75 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineIndex(t *testing.T) {
+	lines := OfLineIndex("ab\néc\n\nd")
+	assert.Equal(t, 4, lines.Lines())
+
+	assert.Equal(t, Position{Offset: 0, ByteOffset: 0, Line: 1, Column: 1}, lines.Position(0))
+	assert.Equal(t, Position{Offset: 2, ByteOffset: 2, Line: 1, Column: 3}, lines.Position(2))
+	assert.Equal(t, Position{Offset: 3, ByteOffset: 3, Line: 2, Column: 1}, lines.Position(3))
+	assert.Equal(t, Position{Offset: 4, ByteOffset: 5, Line: 2, Column: 2}, lines.Position(4))
+	assert.Equal(t, Position{Offset: 6, ByteOffset: 7, Line: 3, Column: 1}, lines.Position(6))
+	assert.Equal(t, Position{Offset: 8, ByteOffset: 9, Line: 4, Column: 2}, lines.Position(8))
+	assert.Equal(t, lines.Position(8), lines.Position(100))
+	assert.Equal(t, lines.Position(0), lines.Position(-1))
+
+	assert.Equal(t, "ab", lines.LineText(1))
+	assert.Equal(t, "éc", lines.LineText(2))
+	assert.Equal(t, "", lines.LineText(3))
+	assert.Equal(t, "d", lines.LineText(4))
+	assert.Equal(t, "", lines.LineText(5))
+	assert.Equal(t, "", lines.LineText(0))
+
+	lines = OfLineIndex("")
+	assert.Equal(t, 1, lines.Lines())
+	assert.Equal(t, Position{Line: 1, Column: 1}, lines.Position(0))
+}
+
+func TestNodeSpan(t *testing.T) {
+	g := testGrammar(
+		"stmts = (stmt)+",
+		"stmt = word ';' eol",
+		"word = ([ab])+",
+		"eol = ([n])?",
+	)
+	g.rules[3].expr.items[0].list[0].terminal = OfTerminalRange("[\\n]", map[rune]bool{'\n': true})
+
+	engine, err := NewEngine(g, ParseOptions{})
+	assert.Nil(t, err)
+
+	node, err := engine.Parse(strings.NewReader("ab;\nba;"))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, node.Lines().Lines())
+
+	start, end := node.Children()[1].Span()
+	assert.Equal(t, Position{Offset: 4, ByteOffset: 4, Line: 2, Column: 1}, start)
+	assert.Equal(t, Position{Offset: 7, ByteOffset: 7, Line: 2, Column: 4}, end)
+	assert.Equal(t, "ba;", node.Lines().LineText(start.Line))
+
+	// Every node shares the line index
+	WalkPreOrder(node, func(n Node) bool {
+		assert.True(t, n.Lines() == node.Lines())
+		return true
+	})
+
+	// A node that is not from a parse has offsets only
+	start, end = Node{start: 1, end: 2}.Span()
+	assert.Equal(t, Position{Offset: 1}, start)
+	assert.Equal(t, Position{Offset: 2}, end)
+
+	// Trees of a forest have spans
+	forest, err := engine.ParseForest(OfParseContext(), strings.NewReader("a;\nb;"))
+	assert.Nil(t, err)
+	forest.Trees(func(tree Node) bool {
+		start, _ := tree.Children()[1].Span()
+		assert.Equal(t, 2, start.Line)
+		return true
+	})
+}
@@ -0,0 +1,157 @@
+package parser
+
+import (
+	"fmt"
+)
+
+// followSets computes the set of characters that can follow each rule, indexed by rule name, where -1 is EOF.
+// EOF can follow the start rule, which is the first rule of the grammar.
+func followSets(g Grammar, firsts map[string]ruleFirstSets) map[string]RuneSet {
+	var (
+		byName = rulesByName(g.rules)
+		result = map[string]RuneSet{}
+		add    = func(to string, from RuneSet) bool {
+			if chars := result[to].Union(from); !chars.Equal(result[to]) {
+				result[to] = chars
+				return true
+			}
+
+			return false
+		}
+	)
+
+	for name := range byName {
+		result[name] = RuneSet{}
+	}
+
+	if len(g.rules) > 0 {
+		result[g.rules[0].name] = OfRuneSetRunes(-1)
+	}
+
+	// Rules can refer to each other in any order, so keep adding to the sets until none of them change
+	for changed := true; changed; {
+		changed = false
+
+		for name, rule := range byName {
+			for i, alt := range rule.expr.items {
+				for k, item := range alt.list {
+					follow := item.ruleName
+					if _, defined := result[follow]; !item.IsRuleName() || !defined {
+						continue
+					}
+
+					// The first set of the rest of the list follows the item
+					restNullable := true
+					for _, next := range alt.list[k+1:] {
+						nextSet := itemFirstSet(next, firsts)
+						changed = add(follow, nextSet.chars) || changed
+						if !nextSet.nullable {
+							restNullable = false
+							break
+						}
+					}
+
+					if restNullable {
+						// If the alternative can repeat, it can follow itself
+						if (alt.m == -1) || (alt.m > 1) {
+							changed = add(follow, firsts[name].alternatives[i].chars) || changed
+						}
+
+						changed = add(follow, result[name]) || changed
+					}
+				}
+			}
+		}
+	}
+
+	return result
+}
+
+// LL1Conflicts returns a diagnostic for each place in the grammar where one character of lookahead is not enough to
+// decide how to continue, which is where a parser would have to backtrack. Each diagnostic describes the rule,
+// the alternatives involved, and the overlapping lookahead characters, at the position of the later alternative.
+// Alternatives are numbered from 1. The grammar is LL(1) if there are no diagnostics.
+//
+// There are two kinds of conflicts:
+// - two alternatives of a rule can begin with the same character, or both can match nothing
+// - an alternative that can repeat a varying number of times can begin with a character that can also follow the rule
+//
+// An alternative that can match nothing can begin with any character that can follow the rule.
+// Alternatives that refer to an undefined rule are not examined.
+func (g Grammar) LL1Conflicts() []Diagnostic {
+	var (
+		firsts  = firstSets(g)
+		follows = followSets(g, firsts)
+		seen    = map[string]bool{}
+		result  []Diagnostic
+	)
+
+	for _, rule := range g.rules {
+		if seen[rule.name] {
+			continue
+		}
+		seen[rule.name] = true
+
+		var (
+			alts       = firsts[rule.name].alternatives
+			follow     = follows[rule.name]
+			lookaheads = make([]RuneSet, len(alts))
+		)
+
+		for i, alt := range alts {
+			lookaheads[i] = alt.chars
+			if alt.nullable {
+				lookaheads[i] = lookaheads[i].Union(follow)
+			}
+		}
+
+		for j, altJ := range alts {
+			if altJ.unknown {
+				continue
+			}
+
+			for i, altI := range alts[:j] {
+				if altI.unknown {
+					continue
+				}
+
+				if altI.nullable && altJ.nullable {
+					details := fmt.Sprintf("in rule %s between alternatives %d and %d, which can both match nothing", rule.name, i+1, j+1)
+					result = append(result, newDiagnostic(DiagLL1Conflict, details, rule.expr.items[j].SourceNode))
+					continue
+				}
+
+				if overlap := lookaheads[i].Intersect(lookaheads[j]); !overlap.IsEmpty() {
+					details := fmt.Sprintf("in rule %s between alternatives %d and %d on %s", rule.name, i+1, j+1, lookaheadString(overlap))
+					result = append(result, newDiagnostic(DiagLL1Conflict, details, rule.expr.items[j].SourceNode))
+				}
+			}
+
+			if item := rule.expr.items[j]; item.n != item.m {
+				if overlap := altJ.chars.Intersect(follow); !overlap.IsEmpty() {
+					details := fmt.Sprintf("in rule %s on repeating alternative %d on %s", rule.name, j+1, lookaheadString(overlap))
+					result = append(result, newDiagnostic(DiagLL1Conflict, details, item.SourceNode))
+				}
+			}
+		}
+	}
+
+	return result
+}
+
+// lookaheadString describes a set of lookahead characters as a character range, where -1 is EOF
+func lookaheadString(chars RuneSet) string {
+	var (
+		others = chars.Subtract(OfRuneSetRunes(-1))
+		eof    = chars.Contains(-1)
+	)
+
+	switch {
+	case eof && !others.IsEmpty():
+		return rangeSource(others) + " or EOF"
+	case eof:
+		return "EOF"
+	}
+
+	return rangeSource(others)
+}
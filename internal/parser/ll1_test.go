@@ -0,0 +1,52 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFollowSets(t *testing.T) {
+	g := testGrammar(
+		"a = b 'x' | (c)* | b",
+		"b = 'y' c",
+		"c = [zw]",
+	)
+
+	follows := followSets(g, firstSets(g))
+	assert.Equal(t, OfRuneSetRunes(-1), follows["a"])
+	assert.Equal(t, OfRuneSetRunes('x', -1), follows["b"])
+	assert.Equal(t, OfRuneSetRunes('x', 'z', 'w', -1), follows["c"])
+}
+
+func TestLL1Conflicts(t *testing.T) {
+	assert.Nil(t, testGrammar(
+		"a = b 'x' | c",
+		"b = 'y' c",
+		"c = [zw]",
+	).LL1Conflicts())
+
+	diags := testGrammar(
+		"a = b 'x' | c | 'y' | undefined | e",
+		"b = 'y' | ('w')? | ('v')*",
+		"c = ('z')+",
+		"e = c 'z'",
+	).LL1Conflicts()
+
+	var messages []string
+	for _, diag := range diags {
+		assert.Equal(t, DiagLL1Conflict, diag.Code())
+		messages = append(messages, diag.Message())
+	}
+
+	assert.Equal(
+		t,
+		[]string{
+			"LL(1) conflict in rule a between alternatives 1 and 3 on [y]",
+			"LL(1) conflict in rule a between alternatives 2 and 5 on [z]",
+			"LL(1) conflict in rule b between alternatives 2 and 3, which can both match nothing",
+			"LL(1) conflict in rule c on repeating alternative 1 on [z]",
+		},
+		messages,
+	)
+}
@@ -0,0 +1,264 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Locale error message constants
+const (
+	ErrLocaleUndefined = "The locale is not defined"
+)
+
+// Names of the rules that match the terminals of a locale
+const (
+	NumberRule = "NUMBER"
+	MonthRule  = "MONTH"
+	DateRule   = "DATE"
+)
+
+// DateOrder is the order of the day, month, and year of a date
+type DateOrder uint
+
+// DateOrder constants
+const (
+	// DateDMY is day, month, year, such as 31/12/2024
+	DateDMY DateOrder = iota
+	// DateMDY is month, day, year, such as 12/31/2024
+	DateMDY
+	// DateYMD is year, month, day, such as 2024-12-31
+	DateYMD
+)
+
+// Locale is how numbers and dates are written in a region, for business documents whose format varies by region,
+// such as the decimal comma of 1.234,5 in Germany and the decimal point of 1,234.5 in the United States
+type Locale struct {
+	// Tag is the language tag of the locale, such as en-US
+	Tag string
+	// DecimalSeparator separates the integer and fraction of a number
+	DecimalSeparator rune
+	// GroupSeparators can each separate groups of three digits of the integer of a number, where none means digits are
+	// not grouped
+	GroupSeparators []rune
+	// Months are the names of the months from January, and MonthAbbreviations their abbreviations
+	Months             [12]string
+	MonthAbbreviations [12]string
+	// DateOrder is the order of the day, month, and year of a date
+	DateOrder DateOrder
+	// DateSeparator separates the day, month, and year of a date
+	DateSeparator rune
+}
+
+// locales are the locales of OfLocale
+var locales = []Locale{
+	{
+		Tag:                "en-US",
+		DecimalSeparator:   '.',
+		GroupSeparators:    []rune{','},
+		Months:             [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+		MonthAbbreviations: [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+		DateOrder:          DateMDY,
+		DateSeparator:      '/',
+	},
+	{
+		Tag:                "en-GB",
+		DecimalSeparator:   '.',
+		GroupSeparators:    []rune{','},
+		Months:             [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+		MonthAbbreviations: [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+		DateOrder:          DateDMY,
+		DateSeparator:      '/',
+	},
+	{
+		Tag:                "de-DE",
+		DecimalSeparator:   ',',
+		GroupSeparators:    []rune{'.'},
+		Months:             [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		MonthAbbreviations: [12]string{"Jan", "Feb", "Mär", "Apr", "Mai", "Jun", "Jul", "Aug", "Sep", "Okt", "Nov", "Dez"},
+		DateOrder:          DateDMY,
+		DateSeparator:      '.',
+	},
+	{
+		Tag:                "fr-FR",
+		DecimalSeparator:   ',',
+		GroupSeparators:    []rune{' ', '\u00A0', '\u202F'},
+		Months:             [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		MonthAbbreviations: [12]string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+		DateOrder:          DateDMY,
+		DateSeparator:      '/',
+	},
+	{
+		Tag:                "es-ES",
+		DecimalSeparator:   ',',
+		GroupSeparators:    []rune{'.'},
+		Months:             [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		MonthAbbreviations: [12]string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sept", "oct", "nov", "dic"},
+		DateOrder:          DateDMY,
+		DateSeparator:      '/',
+	},
+}
+
+// OfLocale returns the locale of a language tag, ignoring case, where an underscore can separate the language and
+// region: en-US, en-GB, de-DE, fr-FR, or es-ES.
+// Returns an error if the locale is not defined.
+func OfLocale(tag string) (Locale, error) {
+	for _, locale := range locales {
+		if strings.EqualFold(locale.Tag, strings.Replace(tag, "_", "-", -1)) {
+			locale.GroupSeparators = append([]rune(nil), locale.GroupSeparators...)
+			return locale, nil
+		}
+	}
+
+	return Locale{}, fmt.Errorf("%s: %s", ErrLocaleUndefined, tag)
+}
+
+// digitItem returns a list item of the range of digits from first to last
+func digitItem(first, last rune) ListItem {
+	digits := OfRuneSet(RuneInterval{first, last})
+	return OfListItemTerminal(rangeSource(digits), OfTerminalRuneSet(rangeSource(digits), digits), nil)
+}
+
+// runeItem returns a list item of a string of one rune
+func runeItem(char rune) ListItem {
+	src := stringSource(string(char))
+	return OfListItemTerminal(src, OfTerminalString(src, string(char)), nil)
+}
+
+// localeRules returns the rules of the terminals of the locale, where each rule is followed by the rules it refers to,
+// named after it with a unique suffix among the names:
+//
+//	NUMBER is an optional minus sign, an integer, and an optional fraction after the decimal separator, where the
+//	integer can have three digits between group separators
+//	MONTH is the name or abbreviation of a month, longest first
+//	DATE is the day, month, and year of a date in numbers, in the date order, between date separators, where the day
+//	and month can have a leading zero
+func (l Locale) localeRules(names map[string]bool) map[string][]Rule {
+	var (
+		digit   = digitItem('0', '9')
+		rule    = func(name string, alts ...ExpressionItem) Rule { return newRule(name, nil, newExpression(alts)) }
+		ref     = newListItemRuleName
+		alt     = func(items ...ListItem) ExpressionItem { return newExpressionItem(items, 1, 1) }
+		repeat  = func(item ListItem, n, m int) ExpressionItem { return newExpressionItem([]ListItem{item}, n, m) }
+		unique  = func(rule, suffix string) string { return uniqueRuleName(rule+"-"+suffix, names) }
+		sign    = unique(NumberRule, "SIGN")
+		integer = unique(NumberRule, "INTEGER")
+		digits  = unique(NumberRule, "DIGITS")
+		frac    = unique(NumberRule, "FRACTION")
+		decimal = unique(NumberRule, "DECIMALS")
+		number  = []Rule{
+			rule(NumberRule, alt(ref(sign), ref(integer), ref(frac))).WithDisplayName("a number"),
+			rule(sign, repeat(runeItem('-'), 0, 1)),
+		}
+	)
+
+	if len(l.GroupSeparators) == 0 {
+		number = append(number, rule(integer, alt(ref(digits))))
+	} else {
+		var (
+			lead       = unique(NumberRule, "LEAD")
+			groups     = unique(NumberRule, "GROUPS")
+			group      = unique(NumberRule, "GROUP")
+			separators = OfRuneSetRunes(l.GroupSeparators...)
+		)
+		separator := OfListItemTerminal(rangeSource(separators), OfTerminalRuneSet(rangeSource(separators), separators), nil)
+
+		number = append(
+			number,
+			rule(integer, alt(ref(lead), ref(groups)), alt(ref(digits))),
+			rule(lead, repeat(digit, 1, 3)),
+			rule(groups, repeat(ref(group), 1, -1)),
+			rule(group, alt(separator, digit, digit, digit)),
+		)
+	}
+	number = append(
+		number,
+		rule(digits, repeat(digit, 1, -1)),
+		rule(frac, repeat(ref(decimal), 0, 1)),
+		rule(decimal, alt(runeItem(l.DecimalSeparator), ref(digits))),
+	)
+
+	// Longer names are tried first, so that a PEG ordered choice does not match an abbreviation of a name
+	var (
+		months = []string{}
+		seen   = map[string]bool{}
+	)
+	for _, month := range append(l.Months[:], l.MonthAbbreviations[:]...) {
+		if (month != "") && !seen[month] {
+			seen[month] = true
+			months = append(months, month)
+		}
+	}
+	sort.SliceStable(months, func(i, j int) bool { return len([]rune(months[i])) > len([]rune(months[j])) })
+
+	monthAlts := make([]ExpressionItem, len(months))
+	for i, month := range months {
+		src := stringSource(month)
+		monthAlts[i] = alt(OfListItemTerminal(src, OfTerminalString(src, month), nil))
+	}
+
+	var (
+		day   = unique(DateRule, "DAY")
+		month = unique(DateRule, "MONTH")
+		year  = unique(DateRule, "YEAR")
+		parts = []string{day, month, year}
+	)
+	switch l.DateOrder {
+	case DateMDY:
+		parts = []string{month, day, year}
+	case DateYMD:
+		parts = []string{year, month, day}
+	}
+
+	return map[string][]Rule{
+		NumberRule: number,
+		MonthRule:  {rule(MonthRule, monthAlts...).WithDisplayName("a month")},
+		DateRule: {
+			rule(DateRule, alt(ref(parts[0]), runeItem(l.DateSeparator), ref(parts[1]), runeItem(l.DateSeparator), ref(parts[2]))).WithDisplayName("a date"),
+			rule(day, alt(runeItem('0'), digitItem('1', '9')), alt(digitItem('1', '2'), digit), alt(runeItem('3'), digitItem('0', '1')), alt(digitItem('1', '9'))),
+			rule(month, alt(runeItem('0'), digitItem('1', '9')), alt(runeItem('1'), digitItem('0', '2')), alt(digitItem('1', '9'))),
+			rule(year, repeat(digit, 4, 4)),
+		},
+	}
+}
+
+// withLocale returns the grammar with the rules of the terminals of the locale that it refers to and does not define,
+// added to the end of it in the order NUMBER, MONTH, DATE
+func (g Grammar) withLocale(l Locale) Grammar {
+	var (
+		names      = map[string]bool{}
+		referenced = map[string]bool{}
+	)
+	for _, rule := range g.rules {
+		names[rule.name] = true
+		for _, alt := range rule.expr.items {
+			for _, item := range alt.list {
+				if item.IsRuleName() {
+					referenced[item.ruleName] = true
+				}
+			}
+		}
+	}
+
+	var (
+		added = map[string]bool{}
+		rules = append([]Rule(nil), g.rules...)
+	)
+	for _, name := range []string{NumberRule, MonthRule, DateRule} {
+		if referenced[name] && !names[name] {
+			added[name] = true
+		}
+	}
+	if len(added) == 0 {
+		return g
+	}
+
+	localeRules := l.localeRules(names)
+	for _, name := range []string{NumberRule, MonthRule, DateRule} {
+		if added[name] {
+			rules = append(rules, localeRules[name]...)
+		}
+	}
+
+	return newGrammar(rules)
+}
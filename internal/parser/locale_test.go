@@ -0,0 +1,97 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOfLocale(t *testing.T) {
+	locale, err := OfLocale("de_de")
+	assert.Nil(t, err)
+	assert.Equal(t, "de-DE", locale.Tag)
+	assert.Equal(t, ',', locale.DecimalSeparator)
+
+	// The locale is a copy
+	locale.GroupSeparators[0] = 'x'
+	locale, _ = OfLocale("de-DE")
+	assert.Equal(t, []rune{'.'}, locale.GroupSeparators)
+
+	_, err = OfLocale("xx-XX")
+	assert.Equal(t, ErrLocaleUndefined+": xx-XX", err.Error())
+}
+
+func TestLocaleRules(t *testing.T) {
+	locale, _ := OfLocale("en-US")
+
+	// Only rules that are referred to and not defined are added
+	g := testGrammar("amount = NUMBER", "NUMBER-SIGN = 'x'").withLocale(locale)
+	assert.Equal(t, []string{"amount", "NUMBER-SIGN", NumberRule, "NUMBER-SIGN-2"}, []string{g.rules[0].name, g.rules[1].name, g.rules[2].name, g.rules[3].name})
+	assert.Equal(t, "a number", g.rules[2].DisplayName())
+	for _, rule := range g.rules {
+		assert.NotEqual(t, MonthRule, rule.name)
+	}
+
+	g = testGrammar("amount = NUMBER", "NUMBER = 'x'")
+	assert.Equal(t, g, g.withLocale(locale))
+
+	// Names are tried longest first
+	g = testGrammar("when = MONTH").withLocale(locale)
+	assert.Equal(t, "'September' | 'February' | 'November' | 'December'", strings.Join(strings.Split(g.rules[1].expr.String(), " | ")[:4], " | "))
+	assert.Equal(t, "'May' | 'Jan'", strings.Join(strings.Split(g.rules[1].expr.String(), " | ")[11:13], " | "))
+}
+
+func TestLocale(t *testing.T) {
+	g := testGrammar(
+		"fields = field more",
+		"more = (next)*",
+		"next = ';' field",
+		"field = NUMBER | MONTH | DATE",
+	)
+
+	for _, test := range []struct {
+		tag   string
+		input string
+		valid bool
+	}{
+		{"en-US", "1;-12;1,234;12,345,678.9;0.5", true},
+		{"en-US", "March;Mar;May;12/31/2024;1/2/2024", true},
+		{"en-US", "1.234,5", false},
+		{"en-US", "1,23", false},
+		{"en-US", "31/12/2024", false},
+		{"de-DE", "1.234,5;1234,5;März;Dez;31.12.2024", true},
+		{"de-DE", "1,234.5", false},
+		{"fr-FR", "1 234,5;1 234;1 234;janv.;août;31/12/2024", true},
+		{"es-ES", "1.234,5;septiembre;sept;31/12/2024", true},
+		{"en-GB", "31/12/2024", true},
+		{"en-GB", "12/31/2024", false},
+		{"en-GB", "01/09/2024;1/9/2024;30/10/2024", true},
+		{"en-GB", "32/10/2024", false},
+		{"en-GB", "00/10/2024", false},
+	} {
+		locale, err := OfLocale(test.tag)
+		assert.Nil(t, err)
+
+		engine, err := NewEngine(g, ParseOptions{Locale: &locale})
+		assert.Nil(t, err)
+
+		_, err = engine.Parse(strings.NewReader(test.input))
+		assert.Equal(t, test.valid, err == nil, "%s %q %v", test.tag, test.input, err)
+	}
+
+	// A locale without group separators, in year, month, day order
+	locale, _ := OfLocale("en-US")
+	locale.GroupSeparators, locale.DateOrder, locale.DateSeparator = nil, DateYMD, '-'
+	engine, err := NewEngine(g, ParseOptions{Locale: &locale})
+	assert.Nil(t, err)
+
+	_, err = engine.Parse(strings.NewReader("1234.5;2024-12-31"))
+	assert.Nil(t, err)
+	_, err = engine.Parse(strings.NewReader("1,234"))
+	assert.IsType(t, ParseError{}, err)
+
+	// Without a locale, the rules are not defined
+	_, err = NewEngine(g, ParseOptions{})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,128 @@
+package parser
+
+// mappedInput is the input an engine matches, where the engine inserted runes into the input, such as the tokens of
+// indentation, with the offset in the input of each rune
+type mappedInput struct {
+	runes []rune
+	// offset in the input of each rune, and of the end of the input
+	offsets []int
+}
+
+// newMappedInput returns the input mapped to itself, followed by runes the engine inserted at the end
+func newMappedInput(input []rune, end ...rune) *mappedInput {
+	result := &mappedInput{
+		runes:   append(append(make([]rune, 0, len(input)+len(end)), input...), end...),
+		offsets: make([]int, len(input)+len(end)+1),
+	}
+	for i := range result.offsets {
+		result.offsets[i] = i
+		if i > len(input) {
+			result.offsets[i] = len(input)
+		}
+	}
+
+	return result
+}
+
+// then returns the mapped input of the input that next maps, which is the runes of this mapped input.
+// Returns next if this input is not mapped.
+func (m *mappedInput) then(next *mappedInput) *mappedInput {
+	if m == nil {
+		return next
+	}
+
+	result := &mappedInput{runes: next.runes, offsets: make([]int, len(next.offsets))}
+	for i, offset := range next.offsets {
+		result.offsets[i] = m.offsets[offset]
+	}
+
+	return result
+}
+
+// mapInput returns the input with the runes the engine inserts for the Indent and EOL options, or nil if there are none
+func (e *Engine) mapInput(input []rune) (*mappedInput, error) {
+	var result *mappedInput
+	if end := e.options.EOL.impliedEOL(input); end != "" {
+		result = newMappedInput(input, []rune(end)...)
+	}
+
+	if e.options.Indent != nil {
+		runes := input
+		if result != nil {
+			runes = result.runes
+		}
+
+		indented, err := e.options.Indent.indent(runes, e.options.Positions)
+		if err != nil {
+			return nil, err
+		}
+		result = result.then(indented)
+	}
+
+	return result, nil
+}
+
+// restore returns a copy of a tree matched against the mapped input, with the offsets and text of the input,
+// where the runes the engine inserted match no text. Returns the tree if the input is not mapped.
+func (m *mappedInput) restore(input []rune, n Node) Node {
+	if m == nil {
+		return n
+	}
+
+	n.start, n.end = m.offsets[n.start], m.offsets[n.end]
+	n.text = string(input[n.start:n.end])
+
+	if len(n.children) > 0 {
+		children := make([]Node, len(n.children))
+		for c, child := range n.children {
+			children[c] = m.restore(input, child)
+		}
+		n.children = children
+	}
+
+	return n
+}
+
+// restoreError returns a ParseError of the mapped input as an error of the input, where the tokens of indentation are
+// expected as INDENT and OUTDENT. Returns the error if the input is not mapped.
+func (m *mappedInput) restoreError(input []rune, p ParseError, positions PositionOptions) ParseError {
+	if m == nil {
+		return p
+	}
+
+	var (
+		result   = newParseError(input, parseFailure{offset: m.offsets[p.offset]}, positions)
+		expected = map[string]bool{}
+		tokens   = map[string]string{
+			stringSource(string(indentRune)):  IndentRule,
+			stringSource(string(outdentRune)): OutdentRule,
+		}
+	)
+	for _, str := range p.expected {
+		if token, isToken := tokens[str]; isToken {
+			str = token
+		}
+		expected[str] = true
+	}
+
+	result.rule, result.alt, result.message = p.rule, p.alt, p.message
+	result.expected, result.expectedRules = sortedKeys(expected), p.expectedRules
+	return result
+}
+
+// restoreErrors returns Diagnostics of the mapped input with each ParseError restored by restoreError
+func (m *mappedInput) restoreErrors(input []rune, errs Diagnostics, positions PositionOptions) Diagnostics {
+	if m == nil {
+		return errs
+	}
+
+	result := make(Diagnostics, len(errs))
+	for e, err := range errs {
+		result[e] = err
+		if parseError, isa := err.(ParseError); isa {
+			result[e] = m.restoreError(input, parseError, positions)
+		}
+	}
+
+	return result
+}
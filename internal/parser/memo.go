@@ -0,0 +1,78 @@
+package parser
+
+import (
+	"sort"
+)
+
+// MemoStats are the statistics of a rule collected while parsing representative inputs
+type MemoStats struct {
+	// Calls is the number of times the rule was tried
+	Calls int
+	// Repeats is the number of times the rule was tried at an input position it had already been tried at,
+	// which is the number of matches memoization would have saved
+	Repeats int
+}
+
+// MemoSuggestion is a suggested memoization option for a rule
+type MemoSuggestion struct {
+	// Rule is the rule name
+	Rule string
+	// Option is OptionMemo or OptionNoMemo
+	Option Option
+	// RepeatRatio is Repeats / Calls
+	RepeatRatio float64
+}
+
+// Memoized returns true if the rule has the :MEMO option, false if it has the :NOMEMO option, and defaultMemo otherwise
+func (r Rule) Memoized(defaultMemo bool) bool {
+	for _, option := range r.options {
+		switch option {
+		case OptionMemo:
+			return true
+		case OptionNoMemo:
+			return false
+		}
+	}
+
+	return defaultMemo
+}
+
+// SuggestMemo suggests which rules are worth memoizing, based on how often each rule was retried at the same input
+// position while parsing representative inputs. A rule is worth memoizing if Repeats / Calls >= threshold.
+//
+// A suggestion is made for each rule in stats whose memoization differs from whether it is worth memoizing,
+// where rules that have neither the :MEMO nor :NOMEMO option are memoized if defaultMemo is true.
+// Suggestions are sorted by descending repeat ratio, then by rule name.
+func (g Grammar) SuggestMemo(stats map[string]MemoStats, threshold float64, defaultMemo bool) []MemoSuggestion {
+	var result []MemoSuggestion
+
+	for name, rule := range rulesByName(g.rules) {
+		ruleStats, haveStats := stats[name]
+		if !haveStats || (ruleStats.Calls == 0) {
+			continue
+		}
+
+		ratio := float64(ruleStats.Repeats) / float64(ruleStats.Calls)
+		worthIt := ratio >= threshold
+		if worthIt == rule.Memoized(defaultMemo) {
+			continue
+		}
+
+		option := OptionNoMemo
+		if worthIt {
+			option = OptionMemo
+		}
+
+		result = append(result, MemoSuggestion{Rule: name, Option: option, RepeatRatio: ratio})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].RepeatRatio != result[j].RepeatRatio {
+			return result[i].RepeatRatio > result[j].RepeatRatio
+		}
+
+		return result[i].Rule < result[j].Rule
+	})
+
+	return result
+}
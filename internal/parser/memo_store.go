@@ -0,0 +1,145 @@
+package parser
+
+import (
+	"sync"
+)
+
+// MemoKey is a rule tried at an input offset
+type MemoKey struct {
+	Rule   string
+	Offset int
+}
+
+// MemoStore stores the memoized matches of rules at input offsets for one parse, so that alternative stores can be used
+// by setting ParseOptions.MemoStore. A store is only used by one parse at a time, and may forget any matches.
+type MemoStore interface {
+	// Get returns the matches of a rule at an input offset and true, or false if they are not stored
+	Get(key MemoKey) ([]Node, bool)
+	// Put stores the matches of a rule at an input offset
+	Put(key MemoKey, nodes []Node)
+}
+
+// arenaSpan is the nodes of an ArenaMemo entry
+type arenaSpan struct {
+	start, end int
+}
+
+// ArenaMemo is a MemoStore that copies the matches it stores into one growing arena, instead of keeping each slice,
+// so that memoizing many small matches makes few allocations. Reset empties it for another parse, reusing the arena.
+type ArenaMemo struct {
+	entries map[MemoKey]arenaSpan
+	nodes   []Node
+}
+
+// OfArenaMemo constructs an ArenaMemo with room for capacity nodes before the arena grows
+func OfArenaMemo(capacity int) *ArenaMemo {
+	return &ArenaMemo{
+		entries: map[MemoKey]arenaSpan{},
+		nodes:   make([]Node, 0, capacity),
+	}
+}
+
+// Get is the MemoStore interface
+func (a *ArenaMemo) Get(key MemoKey) ([]Node, bool) {
+	span, haveIt := a.entries[key]
+	if !haveIt {
+		return nil, false
+	}
+
+	// The capacity is limited so that appending to the result cannot overwrite other entries
+	return a.nodes[span.start:span.end:span.end], true
+}
+
+// Put is the MemoStore interface
+func (a *ArenaMemo) Put(key MemoKey, nodes []Node) {
+	start := len(a.nodes)
+	a.nodes = append(a.nodes, nodes...)
+	a.entries[key] = arenaSpan{start, len(a.nodes)}
+}
+
+// Len returns the number of nodes in the arena
+func (a *ArenaMemo) Len() int {
+	return len(a.nodes)
+}
+
+// Reset removes all entries, keeping the arena for reuse. Trees returned by a parse do not refer to the arena,
+// so it can be reset once the parse is done.
+func (a *ArenaMemo) Reset() {
+	for key := range a.entries {
+		delete(a.entries, key)
+	}
+
+	// Clear the nodes so that the arena does not keep their children alive
+	for i := range a.nodes {
+		a.nodes[i] = Node{}
+	}
+	a.nodes = a.nodes[:0]
+}
+
+// MemoCounter counts how many times each rule is looked up in memo stores, and how many of those were already stored,
+// over any number of parses, so that the counts can be passed to SuggestMemo.
+// A lookup happens each time a memoized rule is tried, so counts are only collected for memoized rules.
+// It is safe to share between engines parsing concurrently.
+type MemoCounter struct {
+	mutex    sync.Mutex
+	newStore func() MemoStore
+	stats    map[string]MemoStats
+}
+
+// countingStore is a MemoStore that counts lookups in a MemoCounter
+type countingStore struct {
+	counter *MemoCounter
+	store   MemoStore
+}
+
+// OfMemoCounter constructs a MemoCounter of the stores newStore constructs, or of unlimited default stores if it is nil
+func OfMemoCounter(newStore func() MemoStore) *MemoCounter {
+	if newStore == nil {
+		newStore = func() MemoStore { return newMemoTable(0, MemoEvictLRU) }
+	}
+
+	return &MemoCounter{
+		newStore: newStore,
+		stats:    map[string]MemoStats{},
+	}
+}
+
+// NewStore constructs a MemoStore that counts lookups, which can be used as ParseOptions.MemoStore
+func (c *MemoCounter) NewStore() MemoStore {
+	return countingStore{counter: c, store: c.newStore()}
+}
+
+// Stats returns the counts of each rule looked up so far
+func (c *MemoCounter) Stats() map[string]MemoStats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	result := map[string]MemoStats{}
+	for name, stats := range c.stats {
+		result[name] = stats
+	}
+
+	return result
+}
+
+// Get is the MemoStore interface
+func (s countingStore) Get(key MemoKey) ([]Node, bool) {
+	nodes, haveIt := s.store.Get(key)
+
+	s.counter.mutex.Lock()
+	defer s.counter.mutex.Unlock()
+
+	stats := s.counter.stats[key.Rule]
+	stats.Calls++
+	if haveIt {
+		stats.Repeats++
+	}
+	s.counter.stats[key.Rule] = stats
+
+	return nodes, haveIt
+}
+
+// Put is the MemoStore interface
+func (s countingStore) Put(key MemoKey, nodes []Node) {
+	s.store.Put(key, nodes)
+}
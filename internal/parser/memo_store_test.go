@@ -0,0 +1,118 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// forgetfulStore is a MemoStore that stores nothing
+type forgetfulStore struct{}
+
+func (forgetfulStore) Get(MemoKey) ([]Node, bool) { return nil, false }
+func (forgetfulStore) Put(MemoKey, []Node)        {}
+
+func TestArenaMemo(t *testing.T) {
+	var (
+		a     = MemoKey{"a", 0}
+		b     = MemoKey{"b", 1}
+		nodes = []Node{{rule: "a"}, {rule: "b"}}
+		arena = OfArenaMemo(4)
+	)
+
+	_, haveIt := arena.Get(a)
+	assert.False(t, haveIt)
+
+	arena.Put(a, nodes)
+	arena.Put(b, nil)
+	assert.Equal(t, 2, arena.Len())
+
+	got, haveIt := arena.Get(a)
+	assert.True(t, haveIt)
+	assert.Equal(t, nodes, got)
+	assert.Equal(t, 2, cap(got))
+
+	got, haveIt = arena.Get(b)
+	assert.True(t, haveIt)
+	assert.Empty(t, got)
+
+	// The nodes are copied
+	nodes[0].rule = "c"
+	got, _ = arena.Get(a)
+	assert.Equal(t, "a", got[0].rule)
+
+	// Reset keeps the arena
+	arena.Reset()
+	assert.Equal(t, 0, arena.Len())
+	assert.Equal(t, 4, cap(arena.nodes))
+	_, haveIt = arena.Get(a)
+	assert.False(t, haveIt)
+}
+
+func TestParseMemoStore(t *testing.T) {
+	var (
+		// Every alternative of a retries b at the same offset
+		g = testGrammar(
+			"a = b 'x' | b 'y' | b 'z'",
+			"b = ([123])+",
+		)
+		calls   int
+		options = ParseOptions{
+			Memo: true,
+			Predicates: map[string]Predicate{
+				"b": func(*ParseContext, Node) bool {
+					calls++
+					return true
+				},
+			},
+		}
+		parse = func(options ParseOptions) int {
+			calls = 0
+			engine, err := NewEngine(g, options)
+			assert.Nil(t, err)
+
+			node, err := engine.Parse(strings.NewReader("123z"))
+			assert.Nil(t, err)
+			assert.Equal(t, "a(b('1' '2' '3') 'z')", treeString(node))
+
+			return calls
+		}
+	)
+
+	// An arena memoizes the same as the default store
+	arena := OfArenaMemo(0)
+	options.MemoStore = func() MemoStore {
+		arena.Reset()
+		return arena
+	}
+	assert.Equal(t, 3, parse(options))
+	assert.Equal(t, 4, arena.Len())
+
+	// A store that forgets everything does not memoize
+	options.MemoStore = func() MemoStore { return forgetfulStore{} }
+	assert.Equal(t, 9, parse(options))
+
+	// The default store can be constructed
+	options.MemoStore = func() MemoStore { return OfMemoTable(1, MemoEvictFIFO) }
+	assert.Equal(t, 3, parse(options))
+
+	// A counter counts lookups over parses
+	counter := OfMemoCounter(nil)
+	options.MemoStore = counter.NewStore
+	parse(options)
+	parse(options)
+	assert.Equal(
+		t,
+		map[string]MemoStats{
+			"a": {Calls: 2, Repeats: 0},
+			"b": {Calls: 6, Repeats: 4},
+		},
+		counter.Stats(),
+	)
+	assert.Equal(
+		t,
+		[]MemoSuggestion{{Rule: "a", Option: OptionNoMemo, RepeatRatio: 0}},
+		g.SuggestMemo(counter.Stats(), 0.5, true),
+	)
+}
@@ -0,0 +1,82 @@
+package parser
+
+import (
+	"container/list"
+)
+
+// MemoEviction is the policy for choosing which entry to remove when a memo table is full
+type MemoEviction uint
+
+// MemoEviction constants
+const (
+	// MemoEvictLRU removes the least recently used entry
+	MemoEvictLRU MemoEviction = iota
+	// MemoEvictFIFO removes the oldest entry, which is usually the one furthest behind in the input
+	MemoEvictFIFO
+)
+
+// memoEntry is the result of trying a rule at an input offset
+type memoEntry struct {
+	key   MemoKey
+	nodes []Node
+}
+
+// memoTable is the default MemoStore, a packrat memo table of the matches of rules at input offsets, with an optional maximum size
+type memoTable struct {
+	size     int
+	eviction MemoEviction
+	entries  map[MemoKey]*list.Element
+	// order of entries, front is the next to be evicted
+	order *list.List
+}
+
+// OfMemoTable constructs the default MemoStore, which has no maximum size if size <= 0
+func OfMemoTable(size int, eviction MemoEviction) MemoStore {
+	return newMemoTable(size, eviction)
+}
+
+// newMemoTable constructs a memoTable, which has no maximum size if size <= 0
+func newMemoTable(size int, eviction MemoEviction) *memoTable {
+	return &memoTable{
+		size:     size,
+		eviction: eviction,
+		entries:  map[MemoKey]*list.Element{},
+		order:    list.New(),
+	}
+}
+
+// Get is the MemoStore interface
+func (t *memoTable) Get(key MemoKey) ([]Node, bool) {
+	elem, haveIt := t.entries[key]
+	if !haveIt {
+		return nil, false
+	}
+
+	if t.eviction == MemoEvictLRU {
+		t.order.MoveToBack(elem)
+	}
+
+	return elem.Value.(memoEntry).nodes, true
+}
+
+// Put is the MemoStore interface, which evicts an entry if the table is full
+func (t *memoTable) Put(key MemoKey, nodes []Node) {
+	if elem, haveIt := t.entries[key]; haveIt {
+		elem.Value = memoEntry{key: key, nodes: nodes}
+		t.order.MoveToBack(elem)
+		return
+	}
+
+	if (t.size > 0) && (len(t.entries) >= t.size) {
+		oldest := t.order.Front()
+		t.order.Remove(oldest)
+		delete(t.entries, oldest.Value.(memoEntry).key)
+	}
+
+	t.entries[key] = t.order.PushBack(memoEntry{key: key, nodes: nodes})
+}
+
+// len returns the number of entries
+func (t *memoTable) len() int {
+	return len(t.entries)
+}
@@ -0,0 +1,130 @@
+package parser
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoTable(t *testing.T) {
+	var (
+		a     = MemoKey{"a", 0}
+		b     = MemoKey{"b", 0}
+		c     = MemoKey{"c", 1}
+		nodes = []Node{{rule: "a"}}
+	)
+
+	// Unlimited
+	table := newMemoTable(0, MemoEvictLRU)
+	_, haveIt := table.Get(a)
+	assert.False(t, haveIt)
+
+	table.Put(a, nodes)
+	table.Put(b, nil)
+	table.Put(c, nil)
+	assert.Equal(t, 3, table.len())
+
+	got, haveIt := table.Get(a)
+	assert.True(t, haveIt)
+	assert.Equal(t, nodes, got)
+
+	// LRU evicts b, as a was used after b was stored
+	table = newMemoTable(2, MemoEvictLRU)
+	table.Put(a, nodes)
+	table.Put(b, nil)
+	table.Get(a)
+	table.Put(c, nil)
+	assert.Equal(t, 2, table.len())
+	_, haveIt = table.Get(b)
+	assert.False(t, haveIt)
+	_, haveIt = table.Get(a)
+	assert.True(t, haveIt)
+
+	// FIFO evicts a, as it was stored first
+	table = newMemoTable(2, MemoEvictFIFO)
+	table.Put(a, nodes)
+	table.Put(b, nil)
+	table.Get(a)
+	table.Put(c, nil)
+	_, haveIt = table.Get(a)
+	assert.False(t, haveIt)
+	_, haveIt = table.Get(b)
+	assert.True(t, haveIt)
+}
+
+func TestParseMemo(t *testing.T) {
+	var (
+		// Every alternative of a retries b at the same offset
+		g = testGrammar(
+			"a = b 'x' | b 'y' | b 'z'",
+			"b = ([123])+",
+		)
+		calls   int
+		options = func(memo bool, size int) ParseOptions {
+			return ParseOptions{
+				Memo:     memo,
+				MemoSize: size,
+				Predicates: map[string]Predicate{
+					"b": func(*ParseContext, Node) bool {
+						calls++
+						return true
+					},
+				},
+			}
+		}
+		parse = func(options ParseOptions) int {
+			calls = 0
+			engine, err := NewEngine(g, options)
+			assert.Nil(t, err)
+
+			node, err := engine.Parse(strings.NewReader("123z"))
+			assert.Nil(t, err)
+			assert.Equal(t, "a(b('1' '2' '3') 'z')", treeString(node))
+
+			return calls
+		}
+	)
+
+	// b matches 3 ways for each alternative of a
+	assert.Equal(t, 9, parse(options(false, 0)))
+	assert.Equal(t, 3, parse(options(true, 0)))
+	assert.Equal(t, 3, parse(options(true, 1)))
+
+	// The :MEMO option memoizes a rule when memoization is off by default
+	g = testGrammar(
+		"a = b 'x' | b 'y' | b 'z'",
+		"b:MEMO = ([123])+",
+	)
+	assert.Equal(t, 3, parse(options(false, 0)))
+}
+
+func TestParseMemoPEGLinear(t *testing.T) {
+	var (
+		g = testGrammar(
+			"s = (w)*",
+			"w = l ls",
+			"ls = (l)*",
+			"l = 'a' | 'b'",
+		)
+		// allocated returns the bytes allocated to parse n runes that fail to match at the end
+		allocated = func(n int) uint64 {
+			engine, err := NewEngine(g, ParseOptions{PEG: true, Memo: true})
+			assert.Nil(t, err)
+
+			var before, after runtime.MemStats
+			runtime.ReadMemStats(&before)
+			_, err = engine.ParseString(strings.Repeat("a", n) + "c")
+			runtime.ReadMemStats(&after)
+			assert.NotNil(t, err)
+
+			return after.TotalAlloc - before.TotalAlloc
+		}
+	)
+
+	// Eight times the input allocates about eight times as much, where copying the children of each repetition would
+	// allocate about 64 times as much
+	small, large := allocated(1000), allocated(8000)
+	assert.Less(t, large, 16*small)
+}
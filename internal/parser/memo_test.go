@@ -0,0 +1,57 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoized(t *testing.T) {
+	g := testGrammar(
+		"a:MEMO = 'x'",
+		"b:NOMEMO = 'x'",
+		"c:AST = 'x'",
+	)
+	assert.True(t, g.rules[0].Memoized(false))
+	assert.False(t, g.rules[1].Memoized(true))
+	assert.True(t, g.rules[2].Memoized(true))
+	assert.False(t, g.rules[2].Memoized(false))
+	assert.Equal(t, "a:MEMO = 'x'", g.rules[0].String())
+}
+
+func TestSuggestMemo(t *testing.T) {
+	g := testGrammar(
+		"a:MEMO = b | c | d | e",
+		"b:NOMEMO = 'x'",
+		"c = 'y'",
+		"d = 'z'",
+		"e = 'w'",
+	)
+	stats := map[string]MemoStats{
+		"a": {Calls: 10, Repeats: 1},
+		"b": {Calls: 10, Repeats: 5},
+		"c": {Calls: 10, Repeats: 6},
+		"d": {Calls: 10, Repeats: 0},
+		"e": {Calls: 0, Repeats: 0},
+	}
+
+	assert.Equal(
+		t,
+		[]MemoSuggestion{
+			{Rule: "c", Option: OptionMemo, RepeatRatio: 0.6},
+			{Rule: "b", Option: OptionMemo, RepeatRatio: 0.5},
+			{Rule: "a", Option: OptionNoMemo, RepeatRatio: 0.1},
+		},
+		g.SuggestMemo(stats, 0.5, false),
+	)
+
+	assert.Equal(
+		t,
+		[]MemoSuggestion{
+			{Rule: "b", Option: OptionMemo, RepeatRatio: 0.5},
+			{Rule: "a", Option: OptionNoMemo, RepeatRatio: 0.1},
+			{Rule: "d", Option: OptionNoMemo, RepeatRatio: 0},
+		},
+		g.SuggestMemo(stats, 0.5, true),
+	)
+}
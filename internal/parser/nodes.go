@@ -1,222 +1,645 @@
 package parser
 
-//import (
-//	"github.com/bantling/goparse/internal/lexer"
-//)
-//
-//// ====
-//
-//// SourceNode is the base structure for all nodes that provides the original source text via String()
-//type SourceNode struct {
-//	sourceString string
-//}
-//
-//// OfSourceNode constructs a SourceNode
-//func OfSourceNode(sourceString string) SourceNode {
-//	return SourceNode{sourceString: sourceString}
-//}
-//
-//// String returns the origin source string
-//func (s SourceNode) String() string {
-//	return s.sourceString
-//}
-//
-//// ====
-//
-//// TerminalPart is a string or character range
-//type TerminalPart struct {
-//	SourceNode
-//	theString string
-//	theRange  map[rune]bool
-//}
-//
-//// OfTerminalPartString constructs a Terminal from a string
-//func OfTerminalPartString(sourceString, terminalString string) Terminal {
-//	return Terminal{
-//		SourceNode: OfSourceNode(sourceString),
-//		theString:  terminalString,
-//	}
-//}
-//
-//// OfTerminalRange constructs a Terminal from a range
-//func OfTerminalRange(sourceString string, theRange map[rune]bool) Terminal {
-//	return Terminal{
-//		SourceNode: OfSourceNode(sourceString),
-//		theRange:   theRange,
-//	}
-//}
-//
-//// IsString returns true of the terminal is a string
-//func (t Terminal) IsString() bool {
-//	return len(t.theString) > 0
-//}
-//
-//// IsRange returns true of the terminal is a character range
-//func (t Terminal) IsRange() bool {
-//	return len(t.theRange) > 0
-//}
-//
-//// TerminalString is the terminal string
-//func (t Terminal) TerminalString() string {
-//	return t.theString
-//}
-//
-//// TerminalRange is the terminal range
-//func (t Terminal) TerminalRange() map[rune]bool {
-//	return t.theRange
-//}
-//
-//// ====
-//
-//// ListItem is a rule name or a terminal, and possibly some options.
-//// If the rule name is "", then the item is a terminal, else it is a rule name.
-//// Options can be applied to a rule name or a terminal.
-//type ListItem struct {
-//	SourceNode
-//	ruleName string
-//	terminal Terminal
-//	options  []lexer.LexType
-//}
-//
-//// OfListItemRuleName constructs a ListItem from a rule name and options
-//func OfListItemRuleName(sourceString string, ruleName string, options []lexer.LexType) ListItem {
-//	return ListItem{
-//		SourceNode: OfSourceNode(sourceString),
-//		ruleName:   ruleName,
-//		options:    options,
-//	}
-//}
-//
-//// OfListItemTerminal constructs a ListItem from a terminal and options
-//func OfListItemTerminal(sourceString string, terminal Terminal, options []lexer.LexType) ListItem {
-//	return ListItem{
-//		SourceNode: OfSourceNode(sourceString),
-//		terminal:   terminal,
-//		options:    options,
-//	}
-//}
-//
-//// IsRuleName returns true if the ListItem was constructed with a rule name
-//func (itm ListItem) IsRuleName() bool {
-//	return len(itm.ruleName) > 0
-//}
-//
-//// IsTerminal returns true if the ListItem was constructed with a terminal
-//func (itm ListItem) IsTerminal() bool {
-//	return len(itm.ruleName) == 0
-//}
-//
-//// RuleName is the rule name
-//func (itm ListItem) RuleName() string {
-//	return itm.ruleName
-//}
-//
-//// Terminal is the terminal
-//func (itm ListItem) Terminal() Terminal {
-//	return itm.terminal
-//}
-//
-//// ====
-//
-//// ExpressionItem is a group of one or more list items that are repeated.
-//// N and M are the lower and upper bounds, respectively.
-//// There is always a lower bound.
-//// If M == -1, there is no upper bound.
-//type ExpressionItem struct {
-//	SourceNode
-//	list []ListItem
-//	n    int
-//	m    int
-//}
-//
-//// OfExpressionItem constructs an ExpressionItem from a list of ListItem and n, m repetitions
-//func OfExpressionItem(sourceString string, list []ListItem, n, m int) ExpressionItem {
-//	return ExpressionItem{
-//		SourceNode: OfSourceNode(sourceString),
-//		list:       list,
-//		n:          n,
-//		m:          m,
-//	}
-//}
-//
-//// Items is the list items
-//func (itm ExpressionItem) Items() []ListItem {
-//	return itm.list
-//}
-//
-//// Repetitions returns the number of repetitions (N, M) of the item.
-//// N is the lower bound, it is >= 0.
-//// M is the upper bound, it is -1 if there is no upper bound, else >= 0.
-//func (itm ExpressionItem) Repetitions() (n, m int) {
-//	return itm.n, itm.m
-//}
-//
-//// ====
-//
-//// Expression is one or more expression items
-//type Expression struct {
-//	SourceNode
-//	items []ExpressionItem
-//}
-//
-//// OfExpression constructs a Expression from a list of expression items
-//func OfExpression(sourceString string, items []ExpressionItem) Expression {
-//	return Expression{
-//		SourceNode: OfSourceNode(sourceString),
-//		items:      items,
-//	}
-//}
-//
-//// Items is the expression items
-//func (e Expression) Items() []ExpressionItem {
-//	return e.items
-//}
-//
-//// ====
-//
-//// Rule is a rule name and expression
-//type Rule struct {
-//	SourceNode
-//	name string
-//	expr Expression
-//}
-//
-//// OfRule constructs a rule from a name and expression
-//func OfRule(sourceString string, name string, expr Expression) Rule {
-//	return Rule{
-//		SourceNode: OfSourceNode(sourceString),
-//		name:       name,
-//		expr:       expr,
-//	}
-//}
-//
-//// Name the rule name
-//func (r Rule) Name() string {
-//	return r.name
-//}
-//
-//// Expr the expression
-//func (r Rule) Expr() Expression {
-//	return r.expr
-//}
-//
-//// ====
-//
-//// Grammar is one or more rules
-//type Grammar struct {
-//	SourceNode
-//	rules []Rule
-//}
-//
-//// OfGrammar constructs a Grammar from a list of rules
-//func OfGrammar(sourceString string, rules []Rule) Grammar {
-//	return Grammar{
-//		SourceNode: OfSourceNode(sourceString),
-//		rules:      rules,
-//	}
-//}
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Option is a list item option that affects the AST or pretty printing, or a rule option that affects matching
+type Option uint
+
+// Option constants
+const (
+	OptionAST Option = iota
+	OptionEOL
+	OptionIndent
+	OptionOutdent
+	OptionPreEOL
+	OptionPreIndent
+	OptionPreOutdent
+	OptionMemo
+	OptionNoMemo
+	OptionIndependent
+	OptionConstTime
+	OptionKeyword
+)
+
+var (
+	// Option strings, in same order as Option constants
+	optionStrings = []string{":AST", ":EOL", ":INDENT", ":OUTDENT", ":PREEOL", ":PREINDENT", ":PREOUTDENT", ":MEMO", ":NOMEMO", ":INDEPENDENT", ":CONSTTIME", ":KEYWORD"}
+)
+
+// String is the option as it appears in source
+func (o Option) String() string {
+	return optionStrings[o]
+}
+
+// ====
+
+// SourcePosition is a place in source: the offset in bytes from the beginning, and the line and position, starting at 1
+type SourcePosition struct {
+	Offset   int
+	Line     int
+	Position int
+}
+
+// SourceNode is the base structure for all nodes that provides the original source text via String(),
+// and the span of source the node was read from: the line and position where it begins, and the byte offsets and
+// line and position of its beginning and end, where the end is just after the last rune of the node.
+// A line and position of 0 means the node was not read from source.
+// A node read from a grammar that an @extends directive names has the name of that grammar as its file.
+type SourceNode struct {
+	sourceString string
+	file         string
+	line         int
+	position     int
+	offset       int
+	end          int
+	endLine      int
+	endPosition  int
+}
+
+// OfSourceNode constructs a SourceNode
+func OfSourceNode(sourceString string) SourceNode {
+	return SourceNode{sourceString: sourceString}
+}
+
+// OfSourceNodeAt constructs a SourceNode that begins at the given line and position
+func OfSourceNodeAt(sourceString string, line, position int) SourceNode {
+	return SourceNode{
+		sourceString: sourceString,
+		line:         line,
+		position:     position,
+	}
+}
+
+// OfSourceNodeSpan constructs a SourceNode read from the span of source between start and end
+func OfSourceNodeSpan(sourceString string, start, end SourcePosition) SourceNode {
+	return SourceNode{
+		sourceString: sourceString,
+		line:         start.Line,
+		position:     start.Position,
+		offset:       start.Offset,
+		end:          end.Offset,
+		endLine:      end.Line,
+		endPosition:  end.Position,
+	}
+}
+
+// String returns the origin source string
+func (s SourceNode) String() string {
+	return s.sourceString
+}
+
+// File returns the name of the grammar an @extends directive named that the source was read from, or "" if the source
+// was read from the grammar being compiled
+func (s SourceNode) File() string {
+	return s.file
+}
+
+// Line returns the line the source begins on, starting at 1
+func (s SourceNode) Line() int {
+	return s.line
+}
+
+// Position returns the position on the line the source begins at, starting at 1
+func (s SourceNode) Position() int {
+	return s.position
+}
+
+// Offset returns the offset in bytes the source begins at
+func (s SourceNode) Offset() int {
+	return s.offset
+}
+
+// End returns the offset in bytes just after the source
+func (s SourceNode) End() int {
+	return s.end
+}
+
+// EndLine returns the line just after the source, starting at 1
+func (s SourceNode) EndLine() int {
+	return s.endLine
+}
+
+// EndPosition returns the position on EndLine just after the source, starting at 1
+func (s SourceNode) EndPosition() int {
+	return s.endPosition
+}
+
+// Span returns where the source begins, and where it ends just after its last rune
+func (s SourceNode) Span() (start, end SourcePosition) {
+	return SourcePosition{Offset: s.offset, Line: s.line, Position: s.position}, SourcePosition{Offset: s.end, Line: s.endLine, Position: s.endPosition}
+}
+
+// withSpan returns the SourceNode read from the span of source between start and end
+func (s SourceNode) withSpan(start, end SourcePosition) SourceNode {
+	result := OfSourceNodeSpan(s.sourceString, start, end)
+	result.file = s.file
+	return result
+}
+
+// inFile returns the SourceNode read from the named file, unless it already has a file
+func (s SourceNode) inFile(file string) SourceNode {
+	if s.file == "" {
+		s.file = file
+	}
+
+	return s
+}
+
+// sourcePositions returns the position of each rune of the input, and of the end of the input after the last rune,
+// where CR, LF, and CRLF each end a line, and the columns follow the options
+func sourcePositions(input []rune, opts PositionOptions) []SourcePosition {
+	var (
+		result   = make([]SourcePosition, len(input)+1)
+		position = SourcePosition{Line: 1, Position: opts.first()}
+	)
+	for i, char := range input {
+		result[i] = position
+		position.Offset += utf8.RuneLen(char)
+
+		next := rune(-1)
+		if i+1 < len(input) {
+			next = input[i+1]
+		}
+
+		if endsLine(char, next) {
+			position.Line++
+			position.Position = opts.first()
+		} else {
+			position.Position = opts.next(position.Position, char)
+		}
+	}
+	result[len(input)] = position
+
+	return result
+}
+
+// ====
+
+// Terminal is a string or character range
+type Terminal struct {
+	SourceNode
+	theString string
+	theRange  RuneSet
+}
+
+// OfTerminalString constructs a Terminal from a string
+func OfTerminalString(sourceString, terminalString string) Terminal {
+	return Terminal{
+		SourceNode: OfSourceNode(sourceString),
+		theString:  terminalString,
+	}
+}
+
+// OfTerminalRange constructs a Terminal from a range of the runes that map to true
+func OfTerminalRange(sourceString string, theRange map[rune]bool) Terminal {
+	return OfTerminalRuneSet(sourceString, OfRuneSetMap(theRange))
+}
+
+// OfTerminalRuneSet constructs a Terminal from a range of a set of runes
+func OfTerminalRuneSet(sourceString string, theRange RuneSet) Terminal {
+	return Terminal{
+		SourceNode: OfSourceNode(sourceString),
+		theRange:   theRange,
+	}
+}
+
+// IsString returns true of the terminal is a string
+func (t Terminal) IsString() bool {
+	return len(t.theString) > 0
+}
+
+// IsRange returns true of the terminal is a character range
+func (t Terminal) IsRange() bool {
+	return !t.theRange.IsEmpty()
+}
+
+// TerminalString is the terminal string
+func (t Terminal) TerminalString() string {
+	return t.theString
+}
+
+// TerminalRange is the terminal range as a map of each of its runes to true.
 //
-//// Rules returns the set of rules
-//func (g Grammar) Rules() []Rule {
-//	return g.rules
-//}
+// Deprecated: Use RuneSet, as the map has an entry for every rune of the range, which is over a million for [^a].
+func (t Terminal) TerminalRange() map[rune]bool {
+	if t.theRange.IsEmpty() {
+		return nil
+	}
+
+	result := map[rune]bool{}
+	for _, char := range t.theRange.Runes() {
+		result[char] = true
+	}
+
+	return result
+}
+
+// RuneSet is the terminal range
+func (t Terminal) RuneSet() RuneSet {
+	return t.theRange
+}
+
+// ====
+
+// ListItem is a rule name or a terminal, and possibly some options.
+// If the rule name is "", then the item is a terminal, else it is a rule name.
+// Options can be applied to a rule name or a terminal.
+type ListItem struct {
+	SourceNode
+	ruleName string
+	terminal Terminal
+	options  []Option
+}
+
+// OfListItemRuleName constructs a ListItem from a rule name and options
+func OfListItemRuleName(sourceString string, ruleName string, options []Option) ListItem {
+	return ListItem{
+		SourceNode: OfSourceNode(sourceString),
+		ruleName:   ruleName,
+		options:    options,
+	}
+}
+
+// OfListItemTerminal constructs a ListItem from a terminal and options
+func OfListItemTerminal(sourceString string, terminal Terminal, options []Option) ListItem {
+	return ListItem{
+		SourceNode: OfSourceNode(sourceString),
+		terminal:   terminal,
+		options:    options,
+	}
+}
+
+// IsRuleName returns true if the ListItem was constructed with a rule name
+func (itm ListItem) IsRuleName() bool {
+	return len(itm.ruleName) > 0
+}
+
+// IsTerminal returns true if the ListItem was constructed with a terminal
+func (itm ListItem) IsTerminal() bool {
+	return len(itm.ruleName) == 0
+}
+
+// RuleName is the rule name
+func (itm ListItem) RuleName() string {
+	return itm.ruleName
+}
+
+// Terminal is the terminal
+func (itm ListItem) Terminal() Terminal {
+	return itm.terminal
+}
+
+// Options is the options
+func (itm ListItem) Options() []Option {
+	return itm.options
+}
+
+// ====
+
+// ExpressionItem is a group of one or more list items that are repeated.
+// N and M are the lower and upper bounds, respectively.
+// There is always a lower bound.
+// If M == -1, there is no upper bound.
+type ExpressionItem struct {
+	SourceNode
+	list         []ListItem
+	n            int
+	m            int
+	lazy         bool
+	errorMessage string
+}
+
+// OfExpressionItem constructs an ExpressionItem from a list of ListItem and n, m repetitions
+func OfExpressionItem(sourceString string, list []ListItem, n, m int) ExpressionItem {
+	return ExpressionItem{
+		SourceNode: OfSourceNode(sourceString),
+		list:       list,
+		n:          n,
+		m:          m,
+	}
+}
+
+// Items is the list items
+func (itm ExpressionItem) Items() []ListItem {
+	return itm.list
+}
+
+// Repetitions returns the number of repetitions (N, M) of the item.
+// N is the lower bound, it is >= 0.
+// M is the upper bound, it is -1 if there is no upper bound, else >= 0.
+func (itm ExpressionItem) Repetitions() (n, m int) {
+	return itm.n, itm.m
+}
+
+// Lazy returns true if the item repeats lazily, which is a repetition followed by ?, such as (x)*?, +?, or {2,5}?,
+// where as few repetitions as possible are tried first, instead of as many as possible, so that a rule such as
+// comment = '/*' (char)*? '*/' ends at the first closing delimiter.
+// The Earley backend prefers the shortest match of a rule that has a lazy item, and event parsers repeat greedily.
+func (itm ExpressionItem) Lazy() bool {
+	return itm.lazy
+}
+
+// repetition returns the source form of the repetitions of the item, followed by ? if it is lazy, or "" for one repetition
+func (itm ExpressionItem) repetition() string {
+	rep := repetitionString(itm.n, itm.m)
+	if itm.lazy && (rep != "") {
+		rep += "?"
+	}
+
+	return rep
+}
+
+// ====
+
+// Expression is one or more expression items
+type Expression struct {
+	SourceNode
+	items []ExpressionItem
+}
+
+// OfExpression constructs a Expression from a list of expression items
+func OfExpression(sourceString string, items []ExpressionItem) Expression {
+	return Expression{
+		SourceNode: OfSourceNode(sourceString),
+		items:      items,
+	}
+}
+
+// Items is the expression items
+func (e Expression) Items() []ExpressionItem {
+	return e.items
+}
+
+// ====
+
+// Rule is a rule name, options, and expression
+type Rule struct {
+	SourceNode
+	name         string
+	options      []Option
+	expr         Expression
+	doc          string
+	limits       RuleLimits
+	errorMessage string
+}
+
+// OfRule constructs a rule from a name and expression
+func OfRule(sourceString string, name string, expr Expression) Rule {
+	return Rule{
+		SourceNode: OfSourceNode(sourceString),
+		name:       name,
+		expr:       expr,
+	}
+}
+
+// OfRuleOptions constructs a rule from a name, options, and expression
+func OfRuleOptions(sourceString string, name string, options []Option, expr Expression) Rule {
+	return Rule{
+		SourceNode: OfSourceNode(sourceString),
+		name:       name,
+		options:    options,
+		expr:       expr,
+	}
+}
+
+// Name the rule name
+func (r Rule) Name() string {
+	return r.name
+}
+
+// Options the rule options
+func (r Rule) Options() []Option {
+	return r.options
+}
+
+// Expr the expression
+func (r Rule) Expr() Expression {
+	return r.expr
+}
+
+// WithDoc returns a copy of the rule with a doc comment, which is the text of the comments that precede the rule in source
+func (r Rule) WithDoc(doc string) Rule {
+	r.doc = doc
+	return r
+}
+
+// Doc the doc comment, which is empty if the rule has no doc comment
+func (r Rule) Doc() string {
+	return r.doc
+}
+
+// ====
+
+// Grammar is one or more rules
+type Grammar struct {
+	SourceNode
+	rules []Rule
+}
+
+// OfGrammar constructs a Grammar from a list of rules
+func OfGrammar(sourceString string, rules []Rule) Grammar {
+	return Grammar{
+		SourceNode: OfSourceNode(sourceString),
+		rules:      rules,
+	}
+}
+
+// Rules returns the set of rules
+func (g Grammar) Rules() []Rule {
+	return g.rules
+}
+
+// ====
+
+// repetitionString returns the source form of n, m repetitions, which is empty for exactly one repetition
+func repetitionString(n, m int) string {
+	switch {
+	case (n == 1) && (m == 1):
+		return ""
+	case (n == 0) && (m == 1):
+		return "?"
+	case (n == 0) && (m == -1):
+		return "*"
+	case (n == 1) && (m == -1):
+		return "+"
+	case n == m:
+		return fmt.Sprintf("{%d}", n)
+	case m == -1:
+		return fmt.Sprintf("{%d,}", n)
+	case n == 0:
+		return fmt.Sprintf("{,%d}", m)
+	}
+
+	return fmt.Sprintf("{%d,%d}", n, m)
+}
+
+// stringSource returns the single quoted source form of a string, escaping \\, \t, \n, \r, and \'
+func stringSource(str string) string {
+	return quotedSource(str, '\'')
+}
+
+// quotedSource returns the source form of a string in quotes, escaping \\, \t, \n, \r, and the quote
+func quotedSource(str string, quote rune) string {
+	var src strings.Builder
+	src.WriteRune(quote)
+
+	for _, char := range str {
+		switch char {
+		case '\\':
+			src.WriteString(`\\`)
+		case '\t':
+			src.WriteString(`\t`)
+		case '\n':
+			src.WriteString(`\n`)
+		case '\r':
+			src.WriteString(`\r`)
+		case quote:
+			src.WriteRune('\\')
+			src.WriteRune(quote)
+		default:
+			src.WriteRune(char)
+		}
+	}
+
+	src.WriteRune(quote)
+	return src.String()
+}
+
+// rangeSource returns the source form of a character range, where runs of three or more consecutive characters are
+// written as X-Y. A - is placed last, and a ^ is not placed first, so they are literal characters.
+func rangeSource(theRange RuneSet) string {
+	var (
+		src    strings.Builder
+		dash   = theRange.Contains('-')
+		hat    = theRange.Contains('^')
+		escape = func(char rune) {
+			switch char {
+			case '\\':
+				src.WriteString(`\\`)
+			case '\t':
+				src.WriteString(`\t`)
+			case '\n':
+				src.WriteString(`\n`)
+			case '\r':
+				src.WriteString(`\r`)
+			case ']':
+				src.WriteString(`\]`)
+			default:
+				src.WriteRune(char)
+			}
+		}
+	)
+
+	chars := theRange.Subtract(OfRuneSetRunes('-', '^'))
+
+	src.WriteRune('[')
+	for _, interval := range chars.intervals {
+		escape(interval.Lo)
+		switch {
+		case interval.Hi-interval.Lo >= 2:
+			src.WriteRune('-')
+			escape(interval.Hi)
+		case interval.Hi-interval.Lo == 1:
+			escape(interval.Hi)
+		}
+	}
+
+	switch {
+	case hat && dash && chars.IsEmpty():
+		// A leading - is also literal
+		src.WriteString("-^")
+	case hat && dash:
+		src.WriteString("^-")
+	case hat:
+		src.WriteRune('^')
+	case dash:
+		src.WriteRune('-')
+	}
+	src.WriteRune(']')
+
+	return src.String()
+}
+
+// rulesByName returns a map of rule names to rules.
+// If a rule name is defined more than once, the first definition is used.
+func rulesByName(rules []Rule) map[string]Rule {
+	result := map[string]Rule{}
+	for _, rule := range rules {
+		if _, haveIt := result[rule.name]; !haveIt {
+			result[rule.name] = rule
+		}
+	}
+
+	return result
+}
+
+// newListItemRuleName constructs a ListItem that refers to a rule, generating the source
+func newListItemRuleName(ruleName string) ListItem {
+	return OfListItemRuleName(ruleName, ruleName, nil)
+}
+
+// newExpressionItem constructs an ExpressionItem, generating the source from the list items
+func newExpressionItem(list []ListItem, n, m int) ExpressionItem {
+	return newLazyExpressionItem(list, n, m, false)
+}
+
+// newLazyExpressionItem constructs an ExpressionItem that repeats lazily if lazy is true, generating the source from the
+// list items
+func newLazyExpressionItem(list []ListItem, n, m int, lazy bool) ExpressionItem {
+	strs := make([]string, len(list))
+	for i, item := range list {
+		strs[i] = item.String()
+	}
+
+	item := ExpressionItem{list: list, n: n, m: m, lazy: lazy}
+	src := strings.Join(strs, " ")
+	if rep := item.repetition(); rep != "" {
+		src = "(" + src + ")" + rep
+	}
+	item.SourceNode = OfSourceNode(src)
+
+	return item
+}
+
+// withList returns a copy of the item with a list of items, generating the source, which keeps the repetitions and
+// error message of the item
+func (itm ExpressionItem) withList(list []ListItem) ExpressionItem {
+	result := newLazyExpressionItem(list, itm.n, itm.m, itm.lazy)
+	result.errorMessage = itm.errorMessage
+
+	return result
+}
+
+// newExpression constructs an Expression, generating the source from the expression items
+func newExpression(items []ExpressionItem) Expression {
+	strs := make([]string, len(items))
+	for i, item := range items {
+		strs[i] = item.String()
+	}
+
+	return OfExpression(strings.Join(strs, " | "), items)
+}
+
+// newRule constructs a Rule, generating the source from the name, options, and expression
+func newRule(name string, options []Option, expr Expression) Rule {
+	src := name
+	for _, option := range options {
+		src += option.String()
+	}
+
+	return OfRuleOptions(src+" = "+expr.String(), name, options, expr)
+}
+
+// newGrammar constructs a Grammar, generating the source from the rules
+func newGrammar(rules []Rule) Grammar {
+	strs := make([]string, len(rules))
+	for i, rule := range rules {
+		strs[i] = rule.String()
+	}
+
+	return OfGrammar(strings.Join(strs, "\n"), rules)
+}
@@ -1,160 +1,275 @@
 package parser
 
-//import (
-//	"testing"
-//
-//	"github.com/bantling/goparse/internal/lexer"
-//	"github.com/stretchr/testify/assert"
-//)
-//
-//func TestTerminal(t *testing.T) {
-//	src := "'single \\\\ \\t \\r \\n \\' \" quoted'"
-//	str := "single \\ \t \r \n ' \" quoted"
-//	term := OfTerminalString(src, str)
-//	assert.True(t, term.IsString())
-//	assert.False(t, term.IsRange())
-//	assert.Equal(t, str, term.TerminalString())
-//	assert.Equal(t, map[rune]bool(nil), term.TerminalRange())
-//	assert.Equal(t, src, term.String())
-//
-//	src = "[A-C]"
-//	rng := map[rune]bool{'A': true, 'B': true, 'C': true}
-//	term = OfTerminalRange(src, rng)
-//	assert.False(t, term.IsString())
-//	assert.True(t, term.IsRange())
-//	assert.Equal(t, "", term.TerminalString())
-//	assert.Equal(t, rng, term.TerminalRange())
-//	assert.Equal(t, src, term.String())
-//}
-//
-//func TestListItem(t *testing.T) {
-//	src := "myrulename"
-//	name := src
-//	item := OfListItemRuleName(src, name, nil)
-//	assert.True(t, item.IsRuleName())
-//	assert.False(t, item.IsTerminal())
-//	assert.Equal(t, name, item.RuleName())
-//	assert.Equal(t, Terminal{}, item.Terminal())
-//	assert.Equal(t, src, item.String())
-//
-//	src = "myrulename:AST"
-//	name = "myrulename"
-//	item = OfListItemRuleName(src, name, []lexer.LexType{lexer.OptionAST})
-//	assert.True(t, item.IsRuleName())
-//	assert.False(t, item.IsTerminal())
-//	assert.Equal(t, name, item.RuleName())
-//	assert.Equal(t, Terminal{}, item.Terminal())
-//	assert.Equal(t, src, item.String())
-//
-//	src = "[A-C]"
-//	term := OfTerminalRange(src, map[rune]bool{'A': true, 'B': true, 'C': true})
-//	item = OfListItemTerminal(src, term, nil)
-//	assert.False(t, item.IsRuleName())
-//	assert.True(t, item.IsTerminal())
-//	assert.Equal(t, "", item.RuleName())
-//	assert.Equal(t, term, item.Terminal())
-//	assert.Equal(t, src, item.String())
-//
-//	src = "[A-C]:OUTDENT"
-//	term = OfTerminalRange(src, map[rune]bool{'A': true, 'B': true, 'C': true})
-//	item = OfListItemTerminal(src, term, []lexer.LexType{lexer.OptionOutdent})
-//	assert.False(t, item.IsRuleName())
-//	assert.True(t, item.IsTerminal())
-//	assert.Equal(t, "", item.RuleName())
-//	assert.Equal(t, term, item.Terminal())
-//	assert.Equal(t, src, item.String())
-//}
-//
-//func TestExpressionItem(t *testing.T) {
-//	src := "myrulename"
-//	name := src
-//	item := OfListItemRuleName(src, name, nil)
-//	items := []ListItem{item}
-//	exprItem := OfExpressionItem(src, items, 1, 1)
-//	n, m := exprItem.Repetitions()
-//
-//	assert.Equal(t, items, exprItem.Items())
-//	assert.Equal(t, 1, n)
-//	assert.Equal(t, 1, m)
-//	assert.Equal(t, src, exprItem.String())
-//
-//	src = "(myrulename){2,3}"
-//	name = "myrulename"
-//	item = OfListItemRuleName(src, name, nil)
-//	items = []ListItem{item}
-//	exprItem = OfExpressionItem(src, items, 2, 3)
-//	n, m = exprItem.Repetitions()
-//
-//	assert.Equal(t, items, exprItem.Items())
-//	assert.Equal(t, 2, n)
-//	assert.Equal(t, 3, m)
-//	assert.Equal(t, src, exprItem.String())
-//}
-//
-//func TestExpression(t *testing.T) {
-//	var (
-//		allSrc   string
-//		allItems []ExpressionItem
-//	)
-//
-//	src := "myfirstrulename"
-//	name := src
-//	item := OfListItemRuleName(src, name, nil)
-//	items := []ListItem{item}
-//	exprItem := OfExpressionItem(src, items, 1, 1)
-//	exprItems := []ExpressionItem{exprItem}
-//	expr := OfExpression(src, exprItems)
-//	assert.Equal(t, exprItems, expr.Items())
-//	assert.Equal(t, src, expr.String())
-//
-//	allSrc = src
-//	allItems = append(allItems, exprItem)
-//
-//	src = "mysecondrulename"
-//	name = src
-//	item = OfListItemRuleName(src, name, nil)
-//	items = []ListItem{item}
-//	exprItem = OfExpressionItem(src, items, 1, 1)
-//	exprItems = []ExpressionItem{exprItem}
-//	expr = OfExpression(src, exprItems)
-//	assert.Equal(t, exprItems, expr.Items())
-//	assert.Equal(t, src, expr.String())
-//
-//	allSrc = allSrc + " | " + src
-//	allItems = append(allItems, exprItem)
-//
-//	// Multiple items
-//	expr = OfExpression(allSrc, allItems)
-//	assert.Equal(t, allItems, expr.Items())
-//	assert.Equal(t, allSrc, expr.String())
-//}
-//
-//func TestRule(t *testing.T) {
-//	src := "lhsrulename = rhsrulename"
-//	name := src
-//	item := OfListItemRuleName(src, name, nil)
-//	items := []ListItem{item}
-//	exprItem := OfExpressionItem(src, items, 1, 1)
-//	exprItems := []ExpressionItem{exprItem}
-//	expr := OfExpression(src, exprItems)
-//	rule := OfRule(src, "lhsrulename", expr)
-//	assert.Equal(t, "lhsrulename", rule.Name())
-//	assert.Equal(t, expr, rule.Expr())
-//	assert.Equal(t, src, expr.String())
-//}
-//
-//func TestGrammar(t *testing.T) {
-//	src := "lhsrulename = rhsrulename"
-//	name := src
-//	item := OfListItemRuleName(src, name, nil)
-//	items := []ListItem{item}
-//	exprItem := OfExpressionItem(src, items, 1, 1)
-//	exprItems := []ExpressionItem{exprItem}
-//	expr := OfExpression(src, exprItems)
-//	rule := OfRule(src, "lhsrulename", expr)
-//	rules := []Rule{rule}
-//	grammar := OfGrammar(src, rules)
-//	assert.Equal(t, "lhsrulename", rule.Name())
-//	assert.Equal(t, rules, grammar.Rules())
-//	assert.Equal(t, src, grammar.String())
-//}
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSourceNode(t *testing.T) {
+	var (
+		start = SourcePosition{Offset: 3, Line: 1, Position: 4}
+		end   = SourcePosition{Offset: 9, Line: 2, Position: 2}
+		node  = OfSourceNodeSpan("a\nb", start, end)
+	)
+	assert.Equal(t, "a\nb", node.String())
+	assert.Equal(t, 1, node.Line())
+	assert.Equal(t, 4, node.Position())
+	assert.Equal(t, 3, node.Offset())
+	assert.Equal(t, 9, node.End())
+	assert.Equal(t, 2, node.EndLine())
+	assert.Equal(t, 2, node.EndPosition())
+
+	gotStart, gotEnd := node.Span()
+	assert.Equal(t, start, gotStart)
+	assert.Equal(t, end, gotEnd)
+
+	node = OfSourceNodeAt("a", 2, 3)
+	assert.Equal(t, 0, node.End())
+	assert.Equal(t, OfSourceNodeAt("a", 2, 3), node.withSpan(SourcePosition{Line: 2, Position: 3}, SourcePosition{}))
+
+	// The position of each rune, where é is two bytes
+	assert.Equal(t, []SourcePosition{
+		{Offset: 0, Line: 1, Position: 1},
+		{Offset: 2, Line: 1, Position: 2},
+		{Offset: 3, Line: 2, Position: 1},
+		{Offset: 4, Line: 2, Position: 2},
+	}, sourcePositions([]rune("é\nb"), PositionOptions{}))
+	assert.Equal(t, []SourcePosition{{Offset: 0, Line: 1, Position: 1}}, sourcePositions(nil, PositionOptions{}))
+
+	// A lone CR ends a line, the CR of a CRLF does not, and a tab advances to the next tab stop
+	assert.Equal(t, []SourcePosition{
+		{Offset: 0, Line: 1, Position: 0},
+		{Offset: 1, Line: 2, Position: 0},
+		{Offset: 2, Line: 2, Position: 1},
+		{Offset: 3, Line: 2, Position: 2},
+		{Offset: 4, Line: 3, Position: 0},
+		{Offset: 5, Line: 3, Position: 4},
+		{Offset: 6, Line: 3, Position: 5},
+	}, sourcePositions([]rune("\ra\r\n\tb"), PositionOptions{TabWidth: 4, ZeroBased: true}))
+}
+
+func TestTerminal(t *testing.T) {
+	src := "'single \\\\ \\t \\r \\n \\' \" quoted'"
+	str := "single \\ \t \r \n ' \" quoted"
+	term := OfTerminalString(src, str)
+	assert.True(t, term.IsString())
+	assert.False(t, term.IsRange())
+	assert.Equal(t, str, term.TerminalString())
+	assert.Equal(t, map[rune]bool(nil), term.TerminalRange())
+	assert.Equal(t, src, term.String())
+
+	src = "[A-C]"
+	rng := map[rune]bool{'A': true, 'B': true, 'C': true}
+	term = OfTerminalRange(src, rng)
+	assert.False(t, term.IsString())
+	assert.True(t, term.IsRange())
+	assert.Equal(t, "", term.TerminalString())
+	assert.Equal(t, rng, term.TerminalRange())
+	assert.Equal(t, OfRuneSet(RuneInterval{'A', 'C'}), term.RuneSet())
+	assert.Equal(t, src, term.String())
+}
+
+func TestListItem(t *testing.T) {
+	src := "myrulename"
+	name := src
+	item := OfListItemRuleName(src, name, nil)
+	assert.True(t, item.IsRuleName())
+	assert.False(t, item.IsTerminal())
+	assert.Equal(t, name, item.RuleName())
+	assert.Equal(t, Terminal{}, item.Terminal())
+	assert.Equal(t, src, item.String())
+
+	src = "myrulename:AST"
+	name = "myrulename"
+	item = OfListItemRuleName(src, name, []Option{OptionAST})
+	assert.True(t, item.IsRuleName())
+	assert.False(t, item.IsTerminal())
+	assert.Equal(t, name, item.RuleName())
+	assert.Equal(t, Terminal{}, item.Terminal())
+	assert.Equal(t, src, item.String())
+
+	src = "[A-C]"
+	term := OfTerminalRange(src, map[rune]bool{'A': true, 'B': true, 'C': true})
+	item = OfListItemTerminal(src, term, nil)
+	assert.False(t, item.IsRuleName())
+	assert.True(t, item.IsTerminal())
+	assert.Equal(t, "", item.RuleName())
+	assert.Equal(t, term, item.Terminal())
+	assert.Equal(t, src, item.String())
+
+	src = "[A-C]:OUTDENT"
+	term = OfTerminalRange(src, map[rune]bool{'A': true, 'B': true, 'C': true})
+	item = OfListItemTerminal(src, term, []Option{OptionOutdent})
+	assert.False(t, item.IsRuleName())
+	assert.True(t, item.IsTerminal())
+	assert.Equal(t, "", item.RuleName())
+	assert.Equal(t, term, item.Terminal())
+	assert.Equal(t, src, item.String())
+}
+
+func TestExpressionItem(t *testing.T) {
+	src := "myrulename"
+	name := src
+	item := OfListItemRuleName(src, name, nil)
+	items := []ListItem{item}
+	exprItem := OfExpressionItem(src, items, 1, 1)
+	n, m := exprItem.Repetitions()
+
+	assert.Equal(t, items, exprItem.Items())
+	assert.Equal(t, 1, n)
+	assert.Equal(t, 1, m)
+	assert.Equal(t, src, exprItem.String())
+
+	src = "(myrulename){2,3}"
+	name = "myrulename"
+	item = OfListItemRuleName(src, name, nil)
+	items = []ListItem{item}
+	exprItem = OfExpressionItem(src, items, 2, 3)
+	n, m = exprItem.Repetitions()
+
+	assert.Equal(t, items, exprItem.Items())
+	assert.Equal(t, 2, n)
+	assert.Equal(t, 3, m)
+	assert.Equal(t, src, exprItem.String())
+}
+
+func TestExpression(t *testing.T) {
+	var (
+		allSrc   string
+		allItems []ExpressionItem
+	)
+
+	src := "myfirstrulename"
+	name := src
+	item := OfListItemRuleName(src, name, nil)
+	items := []ListItem{item}
+	exprItem := OfExpressionItem(src, items, 1, 1)
+	exprItems := []ExpressionItem{exprItem}
+	expr := OfExpression(src, exprItems)
+	assert.Equal(t, exprItems, expr.Items())
+	assert.Equal(t, src, expr.String())
+
+	allSrc = src
+	allItems = append(allItems, exprItem)
+
+	src = "mysecondrulename"
+	name = src
+	item = OfListItemRuleName(src, name, nil)
+	items = []ListItem{item}
+	exprItem = OfExpressionItem(src, items, 1, 1)
+	exprItems = []ExpressionItem{exprItem}
+	expr = OfExpression(src, exprItems)
+	assert.Equal(t, exprItems, expr.Items())
+	assert.Equal(t, src, expr.String())
+
+	allSrc = allSrc + " | " + src
+	allItems = append(allItems, exprItem)
+
+	// Multiple items
+	expr = OfExpression(allSrc, allItems)
+	assert.Equal(t, allItems, expr.Items())
+	assert.Equal(t, allSrc, expr.String())
+}
+
+func TestRule(t *testing.T) {
+	src := "lhsrulename = rhsrulename"
+	name := src
+	item := OfListItemRuleName(src, name, nil)
+	items := []ListItem{item}
+	exprItem := OfExpressionItem(src, items, 1, 1)
+	exprItems := []ExpressionItem{exprItem}
+	expr := OfExpression(src, exprItems)
+	rule := OfRule(src, "lhsrulename", expr)
+	assert.Equal(t, "lhsrulename", rule.Name())
+	assert.Equal(t, expr, rule.Expr())
+	assert.Equal(t, src, expr.String())
+}
+
+func TestGrammar(t *testing.T) {
+	src := "lhsrulename = rhsrulename"
+	name := src
+	item := OfListItemRuleName(src, name, nil)
+	items := []ListItem{item}
+	exprItem := OfExpressionItem(src, items, 1, 1)
+	exprItems := []ExpressionItem{exprItem}
+	expr := OfExpression(src, exprItems)
+	rule := OfRule(src, "lhsrulename", expr)
+	rules := []Rule{rule}
+	grammar := OfGrammar(src, rules)
+	assert.Equal(t, "lhsrulename", rule.Name())
+	assert.Equal(t, rules, grammar.Rules())
+	assert.Equal(t, src, grammar.String())
+}
+
+// testGrammar builds a grammar from rules of the form name:OPTION = alternative | alternative,
+// where each alternative is a space separated list of rule names, 'strings' and [ranges],
+// optionally in parentheses followed by a repetition of ?, *, +, or {N,M}
+func testGrammar(rules ...string) Grammar {
+	var ruleNodes []Rule
+
+	for _, rule := range rules {
+		nameExpr := strings.SplitN(rule, " = ", 2)
+
+		var items []ExpressionItem
+		for _, alt := range strings.Split(nameExpr[1], " | ") {
+			n, m, lazy := 1, 1, false
+			if strings.HasPrefix(alt, "(") {
+				closing := strings.LastIndex(alt, ")")
+				rep := alt[closing+1:]
+				if lazy = (len(rep) > 1) && strings.HasSuffix(rep, "?"); lazy {
+					rep = rep[:len(rep)-1]
+				}
+				switch rep {
+				case "?":
+					n, m = 0, 1
+				case "*":
+					n, m = 0, -1
+				case "+":
+					n, m = 1, -1
+				default:
+					bounds := strings.Split(strings.Trim(rep, "{}"), ",")
+					n, _ = strconv.Atoi(bounds[0])
+					m, _ = strconv.Atoi(bounds[1])
+				}
+				alt = alt[1:closing]
+			}
+
+			var list []ListItem
+			for _, field := range strings.Fields(alt) {
+				switch field[0] {
+				case '\'':
+					list = append(list, OfListItemTerminal(field, OfTerminalString(field, field[1:len(field)-1]), nil))
+				case '[':
+					rng := map[rune]bool{}
+					for _, c := range field[1 : len(field)-1] {
+						rng[c] = true
+					}
+					list = append(list, OfListItemTerminal(field, OfTerminalRange(field, rng), nil))
+				default:
+					list = append(list, newListItemRuleName(field))
+				}
+			}
+
+			items = append(items, newLazyExpressionItem(list, n, m, lazy))
+		}
+
+		var (
+			nameOptions = strings.Split(nameExpr[0], ":")
+			options     []Option
+		)
+		for _, str := range nameOptions[1:] {
+			for i, optionString := range optionStrings {
+				if optionString == ":"+str {
+					options = append(options, Option(i))
+				}
+			}
+		}
+
+		ruleNodes = append(ruleNodes, newRule(nameOptions[0], options, newExpression(items)))
+	}
+
+	return newGrammar(ruleNodes)
+}
@@ -0,0 +1,77 @@
+package parser
+
+// optionToken is a token that list items with an option must be followed by, or preceded by with the pre option,
+// which a rule of the token matches, such as the INDENT token of the :INDENT and :PREINDENT options
+type optionToken struct {
+	option    Option
+	preOption Option
+	rule      string
+	// strings the rule matches, one for each alternative
+	strs []string
+}
+
+// withOptionTokens returns the grammar where each list item with the option of a token is followed by the rule of the
+// token, and each list item with the pre option is preceded by it, in the order of the options of the item.
+// The rule of each token is added to the end of the grammar, unless it defines the rule.
+func (g Grammar) withOptionTokens(tokens []optionToken) Grammar {
+	var (
+		rules   = make([]Rule, 0, len(g.rules)+len(tokens))
+		defined = rulesByName(g.rules)
+		after   = map[Option]string{}
+		before  = map[Option]string{}
+	)
+	for _, token := range tokens {
+		after[token.option], before[token.preOption] = token.rule, token.rule
+	}
+
+	for _, rule := range g.rules {
+		var (
+			items   = make([]ExpressionItem, len(rule.expr.items))
+			changed bool
+		)
+		for a, alt := range rule.expr.items {
+			var list []ListItem
+			for _, item := range alt.list {
+				var pre, post []ListItem
+				for _, option := range item.options {
+					if name, haveIt := before[option]; haveIt {
+						pre = append(pre, newListItemRuleName(name))
+					}
+					if name, haveIt := after[option]; haveIt {
+						post = append(post, newListItemRuleName(name))
+					}
+				}
+
+				list = append(append(append(list, pre...), item), post...)
+			}
+
+			items[a] = alt
+			if len(list) > len(alt.list) {
+				items[a], changed = alt.withList(list), true
+			}
+		}
+
+		if changed {
+			tokenized := newRule(rule.name, rule.options, newExpression(items))
+			tokenized.doc, tokenized.limits = rule.doc, rule.limits
+			rule = tokenized
+		}
+		rules = append(rules, rule)
+	}
+
+	for _, token := range tokens {
+		if _, haveIt := defined[token.rule]; haveIt {
+			continue
+		}
+
+		alts := make([]ExpressionItem, len(token.strs))
+		for i, str := range token.strs {
+			src := stringSource(str)
+			alts[i] = newExpressionItem([]ListItem{OfListItemTerminal(src, OfTerminalString(src, str), nil)}, 1, 1)
+		}
+		rules = append(rules, newRule(token.rule, nil, newExpression(alts)))
+		defined[token.rule] = rules[len(rules)-1]
+	}
+
+	return newGrammar(rules)
+}
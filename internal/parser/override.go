@@ -0,0 +1,53 @@
+package parser
+
+import (
+	"fmt"
+)
+
+// Override error message constants
+const (
+	ErrExtendUndefined = "An extension adds alternatives to a rule that is not defined"
+)
+
+// withOverrides returns the grammar with each override replacing the first rule of the same name, or added after the
+// rules if there is none, and then the alternatives of each extension added after those of the first rule of the same
+// name, keeping the options, doc comment, and limits of the rule. The grammar is returned unchanged if there are no
+// overrides or extensions. Returns an error if an extension has no rule of the same name.
+func (g Grammar) withOverrides(overrides, extensions []Rule) (Grammar, error) {
+	if (len(overrides) == 0) && (len(extensions) == 0) {
+		return g, nil
+	}
+
+	var (
+		rules = append([]Rule(nil), g.rules...)
+		index = map[string]int{}
+	)
+	for i := len(rules) - 1; i >= 0; i-- {
+		index[rules[i].name] = i
+	}
+
+	for _, override := range overrides {
+		if i, defined := index[override.name]; defined {
+			rules[i] = override
+		} else {
+			index[override.name] = len(rules)
+			rules = append(rules, override)
+		}
+	}
+
+	for _, extension := range extensions {
+		i, defined := index[extension.name]
+		if !defined {
+			return Grammar{}, fmt.Errorf("%s: %s", ErrExtendUndefined, extension.name)
+		}
+
+		var (
+			rule  = rules[i]
+			items = append(append([]ExpressionItem(nil), rule.expr.items...), extension.expr.items...)
+		)
+		rules[i] = newRule(rule.name, rule.options, newExpression(items))
+		rules[i].doc, rules[i].limits = rule.doc, rule.limits
+	}
+
+	return newGrammar(rules), nil
+}
@@ -0,0 +1,65 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOverrides(t *testing.T) {
+	var (
+		base = testGrammar(
+			"expr = term | term '+' expr",
+			"term = ident | '(' expr ')'",
+			"ident = ([a])+",
+		)
+		product = testGrammar(
+			"ident = '$' ident-name | ident-path",
+			"ident-name = ([abc])+",
+			"ident-path = ([xyz])+",
+			"term = number",
+			"number = ([0123])+",
+		)
+	)
+	product.rules[0] = product.rules[0].WithDoc("product identifiers")
+
+	// Base grammar is unchanged when there are no overrides or extensions
+	engine, err := NewEngine(base, ParseOptions{Start: "expr"})
+	assert.Nil(t, err)
+	assert.Equal(t, base, engine.Grammar())
+
+	// Override the identifier and add a rule
+	engine, err = NewEngine(base, ParseOptions{Start: "expr", Overrides: product.rules[:3]})
+	assert.Nil(t, err)
+	assert.Equal(t, 5, len(engine.Rules()))
+	rule, _ := engine.Rule("ident")
+	assert.Equal(t, "product identifiers", rule.Doc())
+
+	_, err = engine.Parse(strings.NewReader("($ab)+x"))
+	assert.Nil(t, err)
+	_, err = engine.Parse(strings.NewReader("aa"))
+	assert.NotNil(t, err)
+
+	// Extend the term with numbers, keeping the doc comment of the base rule
+	base.rules[1] = base.rules[1].WithDoc("terms")
+	engine, err = NewEngine(base, ParseOptions{Start: "expr", Extensions: product.rules[3:4], Overrides: product.rules[4:]})
+	assert.Nil(t, err)
+	rule, _ = engine.Rule("term")
+	assert.Equal(t, 3, len(rule.Expr().Items()))
+	assert.Equal(t, "terms", rule.Doc())
+
+	_, err = engine.Parse(strings.NewReader("a+12+(3)"))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(base.rules[1].expr.items[0].list))
+	assert.Equal(t, 2, len(base.rules[1].expr.items))
+
+	// Extension of an undefined rule
+	_, err = NewEngine(base, ParseOptions{Start: "expr", Extensions: product.rules[4:]})
+	assert.Equal(t, fmt.Errorf("%s: number", ErrExtendUndefined), err)
+
+	// Composite is validated
+	_, err = NewEngine(base, ParseOptions{Start: "expr", Extensions: product.rules[3:4]})
+	assert.Contains(t, err.Error(), "Undefined rule number")
+}
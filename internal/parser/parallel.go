@@ -0,0 +1,172 @@
+package parser
+
+import (
+	"sync"
+)
+
+// Independent returns true if the rule has the :INDEPENDENT option, which means that a sequence of its matches can be
+// split after the string that ends each match, and each part matched on its own
+func (r Rule) Independent() bool {
+	for _, option := range r.options {
+		if option == OptionIndependent {
+			return true
+		}
+	}
+
+	return false
+}
+
+// separator returns the string that every alternative of the rule ends with and true, or false if an alternative
+// can match other than once, or does not end with the same string as the others
+func (r Rule) separator() (string, bool) {
+	var result string
+
+	for i, alt := range r.expr.items {
+		if (alt.n != 1) || (alt.m != 1) || (len(alt.list) == 0) {
+			return "", false
+		}
+
+		last := alt.list[len(alt.list)-1]
+		if last.IsRuleName() || !last.terminal.IsString() || (last.terminal.theString == "") {
+			return "", false
+		}
+
+		if i == 0 {
+			result = last.terminal.theString
+		} else if last.terminal.theString != result {
+			return "", false
+		}
+	}
+
+	return result, len(r.expr.items) > 0
+}
+
+// regionSeparator returns the separator of the input if the start rule is a repetition of an independent rule with no
+// upper bound, such as file = (record)*, or "" if the input cannot be split
+func regionSeparator(rules map[string]Rule, start string) string {
+	startRule := rules[start]
+	if len(startRule.expr.items) != 1 {
+		return ""
+	}
+
+	alt := startRule.expr.items[0]
+	if (alt.m != -1) || (len(alt.list) != 1) || !alt.list[0].IsRuleName() {
+		return ""
+	}
+
+	item := rules[alt.list[0].ruleName]
+	if !item.Independent() {
+		return ""
+	}
+
+	separator, _ := item.separator()
+	return separator
+}
+
+// splitRegions returns the offsets of the start of at most count regions of the input of roughly equal size,
+// where the first region starts at 0, and the others start after a separator
+func splitRegions(input []rune, separator string, count int) []int {
+	var (
+		sep     = []rune(separator)
+		size    = len(input) / count
+		offsets = []int{0}
+	)
+
+	for i := 0; (i+len(sep) < len(input)) && (len(offsets) < count); {
+		if !runesAt(input, i, sep) {
+			i++
+			continue
+		}
+
+		i += len(sep)
+		if i-offsets[len(offsets)-1] >= size {
+			offsets = append(offsets, i)
+		}
+	}
+
+	return offsets
+}
+
+// runesAt returns true if the input has the runes at offset
+func runesAt(input []rune, offset int, runes []rune) bool {
+	if offset+len(runes) > len(input) {
+		return false
+	}
+
+	for i, char := range runes {
+		if input[offset+i] != char {
+			return false
+		}
+	}
+
+	return true
+}
+
+// shift returns a copy of the tree with the offsets of every node moved by offset
+func (n Node) shift(offset int) Node {
+	n.start += offset
+	n.end += offset
+
+	if len(n.children) > 0 {
+		children := make([]Node, len(n.children))
+		for i, child := range n.children {
+			children[i] = child.shift(offset)
+		}
+		n.children = children
+	}
+
+	return n
+}
+
+// parseRegions splits the input into regions after the separator of the independent rule the start rule repeats,
+// and matches each region against the start rule in its own goroutine. The matches of the independent rule in each
+// region are merged in order into one tree, which is returned with true.
+//
+// Returns false if the input cannot be split, or a region does not match, in which case the whole input must be matched.
+// Returns an error if a region exceeds a limit.
+func (e *Engine) parseRegions(ctx *ParseContext, input []rune) (Node, bool, error) {
+	if (e.options.Parallelism < 2) || (e.separator == "") || e.options.FullFidelity || (ctx.replay != nil) {
+		return Node{}, false, nil
+	}
+
+	offsets := splitRegions(input, e.separator, e.options.Parallelism)
+	if len(offsets) < 2 {
+		return Node{}, false, nil
+	}
+
+	var (
+		nodes   = make([]Node, len(offsets))
+		matched = make([]bool, len(offsets))
+		errs    = make([]error, len(offsets))
+		wait    sync.WaitGroup
+	)
+	for i, start := range offsets {
+		end := len(input)
+		if i+1 < len(offsets) {
+			end = offsets[i+1]
+		}
+
+		wait.Add(1)
+		go func(i, start, end int) {
+			defer wait.Done()
+			nodes[i], matched[i], _, errs[i] = e.matchRegion(ctx, input, start, end)
+		}(i, start, end)
+	}
+	wait.Wait()
+
+	root := Node{rule: e.options.Start, text: string(input), end: len(input)}
+	for i, node := range nodes {
+		if errs[i] != nil {
+			return Node{}, false, errs[i]
+		}
+		if !matched[i] {
+			return Node{}, false, nil
+		}
+
+		for _, child := range node.children {
+			root.children = append(root.children, child.shift(offsets[i]))
+		}
+	}
+
+	return root, true, nil
+}
@@ -0,0 +1,104 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIndependent(t *testing.T) {
+	g := testGrammar(
+		"a:INDEPENDENT = b ';' | 'x' ';'",
+		"b:MEMO = 'x' ';' | 'y'",
+		"c:INDEPENDENT = ('x' ';')+",
+	)
+	assert.True(t, g.rules[0].Independent())
+	assert.False(t, g.rules[1].Independent())
+
+	sep, ok := g.rules[0].separator()
+	assert.Equal(t, ";", sep)
+	assert.True(t, ok)
+
+	_, ok = g.rules[1].separator()
+	assert.False(t, ok)
+
+	_, ok = g.rules[2].separator()
+	assert.False(t, ok)
+}
+
+func TestSplitRegions(t *testing.T) {
+	input := []rune("ab;c;aa;b;")
+	assert.Equal(t, []int{0, 3, 8}, splitRegions(input, ";", 3))
+	assert.Equal(t, []int{0, 3, 5, 8}, splitRegions(input, ";", 10))
+	assert.Equal(t, []int{0}, splitRegions(input, ";", 1))
+	assert.Equal(t, []int{0, 3}, splitRegions([]rune("a;;b;;"), ";;", 2))
+	assert.Equal(t, []int{0}, splitRegions([]rune("abc"), ";", 2))
+}
+
+func TestParseParallel(t *testing.T) {
+	var (
+		g = testGrammar(
+			"file = (record)*",
+			"record:INDEPENDENT = field ';' | '(' file ')' ';'",
+			"field = ([abc])+",
+		)
+		parse = func(options ParseOptions, input string) (Node, error) {
+			engine, err := NewEngine(g, options)
+			assert.Nil(t, err)
+
+			return engine.Parse(strings.NewReader(input))
+		}
+	)
+
+	for _, backend := range []Backend{BackendBacktrack, BackendEarley} {
+		for _, input := range []string{
+			"ab;c;aa;b;",
+			// A region split inside the parentheses does not match, so the whole input is parsed
+			"a;(b;c;);c;",
+			"",
+			"a;",
+		} {
+			sequential, err := parse(ParseOptions{Backend: backend}, input)
+			assert.Nil(t, err)
+
+			parallel, err := parse(ParseOptions{Backend: backend, Parallelism: 4}, input)
+			assert.Nil(t, err)
+			assert.Equal(t, sequential.SExpr(true), parallel.SExpr(true))
+			assert.Equal(t, input, parallel.Text())
+		}
+
+		// An input that does not match has the same error
+		_, err := parse(ParseOptions{Backend: backend}, "ab;c;aa;b")
+		assert.Equal(t, ErrParseFailed+" at line 1 position 10: found EOF, expected ';' or [a-c] in rule field", err.Error())
+		_, err = parse(ParseOptions{Backend: backend, Parallelism: 4}, "ab;c;aa;b")
+		assert.Equal(t, ErrParseFailed+" at line 1 position 10: found EOF, expected ';' or [a-c] in rule field", err.Error())
+	}
+
+	// Limits apply to each region
+	_, err := parse(ParseOptions{MaxSteps: 23}, "ab;c;aa;b;")
+	assert.IsType(t, LimitError{}, err)
+	_, err = parse(ParseOptions{Parallelism: 2, MaxSteps: 23}, "ab;c;aa;b;")
+	assert.Nil(t, err)
+	_, err = parse(ParseOptions{Parallelism: 2, MaxSteps: 20}, "ab;c;aa;b;")
+	assert.IsType(t, LimitError{}, err)
+
+	// A limit error of any region is at the same position in the input as it is when the input is not split
+	long := "ab;c;aa;b;" + strings.Repeat("c", 25) + ";"
+	limits := map[string]RuleLimits{"record": {MaxLength: 20}}
+	_, sequential := parse(ParseOptions{Limits: limits}, long)
+	assert.Equal(t, LimitError{Err: ErrRuleMaxLength, Rule: "record", Max: 20, Line: 1, Position: 31}, sequential)
+	_, err = parse(ParseOptions{Limits: limits, Parallelism: 4}, long)
+	assert.Equal(t, sequential, err)
+
+	// The input is not split if the start rule is not a repetition of an independent rule
+	for _, rules := range [][]string{
+		{"file = (record)*", "record = 'x' ';'"},
+		{"file = (record){0,3}", "record:INDEPENDENT = 'x' ';'"},
+		{"file = record record", "record:INDEPENDENT = 'x' ';'"},
+	} {
+		engine, err := NewEngine(testGrammar(rules...), ParseOptions{Parallelism: 2})
+		assert.Nil(t, err)
+		assert.Equal(t, "", engine.separator)
+	}
+}
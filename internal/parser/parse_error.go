@@ -0,0 +1,248 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// ExpectedEOF is the expected terminal of a ParseError where the end of the input could have matched
+const ExpectedEOF = "EOF"
+
+// parseFailure is where a parse failed: the offset of the furthest rune that failed to match, the innermost rule and
+// alternative being matched when it first failed there, and the terminals and rules that were expected there
+type parseFailure struct {
+	offset int
+	rule   string
+	// alternative of the rule starting at 1, or 0 if it is not known
+	alt int
+	// rules being matched when it first failed there, innermost last
+	enclosing []activeRule
+	// terminals that failed to match, which are only formatted for a ParseError as a parse can fail many times,
+	// and true if the end of the input could have matched
+	terminals []Terminal
+	eof       bool
+	rules     map[string]bool
+}
+
+// reach moves the failure to offset if it is further, forgetting what was expected before.
+// Returns false if the offset is before the failure.
+func (f *parseFailure) reach(offset int) bool {
+	if offset < f.offset {
+		return false
+	}
+
+	if (offset > f.offset) || (f.rules == nil) {
+		*f = parseFailure{offset: offset, rules: map[string]bool{}}
+	}
+
+	return true
+}
+
+// fail records that a terminal, or the end of the input if it is nil, failed to match at offset while matching rules.
+// The rules of a failure are the rules that started at the offset.
+func (f *parseFailure) fail(offset int, terminal *Terminal, rules []activeRule) {
+	if !f.reach(offset) {
+		return
+	}
+
+	if (f.rule == "") && (len(rules) > 0) {
+		f.rule, f.alt = rules[len(rules)-1].name, rules[len(rules)-1].alt
+		f.enclosing = append([]activeRule(nil), rules...)
+	}
+
+	if terminal == nil {
+		f.eof = true
+	} else if !terminal.IsString() || !f.failedString(terminal.theString) {
+		f.terminals = append(f.terminals, *terminal)
+	}
+
+	for _, rule := range rules {
+		if rule.offset == offset {
+			f.rules[rule.name] = true
+		}
+	}
+}
+
+// failedString returns true if a string terminal already failed
+func (f *parseFailure) failedString(str string) bool {
+	for _, terminal := range f.terminals {
+		if terminal.IsString() && (terminal.theString == str) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// expected returns the Format of each terminal that failed, and ExpectedEOF if the end of the input could have matched
+func (f parseFailure) expected() map[string]bool {
+	result := map[string]bool{}
+	for _, terminal := range f.terminals {
+		result[terminal.Format()] = true
+	}
+	if f.eof {
+		result[ExpectedEOF] = true
+	}
+
+	return result
+}
+
+// activeRule is a rule being matched, the offset it started at, and the alternative being matched starting at 1
+type activeRule struct {
+	name   string
+	offset int
+	alt    int
+}
+
+// ParseError is an error where the input does not match the grammar, at the furthest rune that failed to match.
+// The span of the error is the rune that was found there, or the end of the input.
+type ParseError struct {
+	rule          string
+	alt           int
+	message       string
+	offset        int
+	end           int
+	byteOffset    int
+	byteEnd       int
+	line          int
+	position      int
+	found         string
+	expected      []string
+	expectedRules []string
+}
+
+// newParseError constructs a ParseError for a failure in the input, with a position of the conventions of positions
+func newParseError(input []rune, failure parseFailure, positions PositionOptions) ParseError {
+	var (
+		line, position = positions.linePosition(input, failure.offset)
+		byteOffset     = len(string(input[:failure.offset]))
+		result         = ParseError{
+			rule:          failure.rule,
+			alt:           failure.alt,
+			offset:        failure.offset,
+			end:           failure.offset,
+			byteOffset:    byteOffset,
+			byteEnd:       byteOffset,
+			line:          line,
+			position:      position,
+			expected:      sortedKeys(failure.expected()),
+			expectedRules: sortedKeys(failure.rules),
+		}
+	)
+
+	if failure.offset < len(input) {
+		result.found = string(input[failure.offset])
+		result.end++
+		result.byteEnd += utf8.RuneLen(input[failure.offset])
+	}
+
+	return result
+}
+
+// sortedKeys returns the keys of a set in order
+func sortedKeys(set map[string]bool) []string {
+	result := make([]string, 0, len(set))
+	for key := range set {
+		result = append(result, key)
+	}
+	sort.Strings(result)
+
+	return result
+}
+
+// Rule is the innermost rule being matched where the input first failed to match, or the rule of Message if there is
+// one, or "" if a match of the start rule ended before the end of the input
+func (p ParseError) Rule() string {
+	return p.rule
+}
+
+// Alt is the alternative of Rule being matched, starting at 1, or 0 if it is not known
+func (p ParseError) Alt() int {
+	return p.alt
+}
+
+// Message is the error message for end users of the innermost rule being matched that has one, where the message of
+// the alternative being matched is preferred to that of the rule, as described by Rule.ErrorMessage, or "" if none has one
+func (p ParseError) Message() string {
+	return p.message
+}
+
+// Offset is the offset of the rune that failed to match, which is the length of the input if the input ended too soon
+func (p ParseError) Offset() int {
+	return p.offset
+}
+
+// End is the offset after the rune that failed to match, which is Offset if the input ended too soon
+func (p ParseError) End() int {
+	return p.end
+}
+
+// ByteOffset is the offset in bytes of the rune that failed to match
+func (p ParseError) ByteOffset() int {
+	return p.byteOffset
+}
+
+// ByteEnd is the offset in bytes after the rune that failed to match
+func (p ParseError) ByteEnd() int {
+	return p.byteEnd
+}
+
+// Line is the line of the rune that failed to match, starting at 1
+func (p ParseError) Line() int {
+	return p.line
+}
+
+// Position is the column of the rune that failed to match, starting at 1 unless ParseOptions.Positions is zero based
+func (p ParseError) Position() int {
+	return p.position
+}
+
+// Found is the rune that failed to match, or "" if the input ended too soon
+func (p ParseError) Found() string {
+	return p.found
+}
+
+// Expected are the terminals that could have matched, in Format notation, including ExpectedEOF if the input could
+// have ended, in order
+func (p ParseError) Expected() []string {
+	return p.expected
+}
+
+// ExpectedRules are the rules that could have matched starting at the rune that failed to match, in order
+func (p ParseError) ExpectedRules() []string {
+	return p.expectedRules
+}
+
+// Error is the error interface, such as:
+// The input does not match the grammar at line 1 position 3: found 'x', expected ';' or [abc] in rule stmt
+//
+// If there is an error message, it is used instead of what was found and expected, such as:
+// The input does not match the grammar at line 1 position 3: a statement must end with ; in rule stmt
+func (p ParseError) Error() string {
+	var str strings.Builder
+	fmt.Fprintf(&str, "%s at line %d position %d", ErrParseFailed, p.line, p.position)
+
+	if p.message != "" {
+		fmt.Fprintf(&str, ": %s", p.message)
+	} else if len(p.expected) > 0 {
+		found := ExpectedEOF
+		if p.found != "" {
+			found = stringSource(p.found)
+		}
+
+		expected := strings.Join(p.expected, ", ")
+		if n := len(p.expected); n > 1 {
+			expected = strings.Join(p.expected[:n-1], ", ") + " or " + p.expected[n-1]
+		}
+
+		fmt.Fprintf(&str, ": found %s, expected %s", found, expected)
+	}
+
+	if p.rule != "" {
+		fmt.Fprintf(&str, " in rule %s", p.rule)
+	}
+
+	return str.String()
+}
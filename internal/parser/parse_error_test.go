@@ -0,0 +1,69 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseError(t *testing.T) {
+	var (
+		digit   = OfTerminalRange("", map[rune]bool{'0': true, '1': true, '2': true, '3': true, '4': true, '5': true, '6': true, '7': true, '8': true, '9': true})
+		eacute  = OfTerminalString("", "é")
+		x       = OfTerminalString("", "x")
+		failure parseFailure
+	)
+	failure.fail(1, &x, []activeRule{{"a", 0, 1}})
+	failure.reach(3)
+	failure.fail(3, &digit, []activeRule{{"a", 0, 1}, {"b", 3, 1}, {"c", 3, 1}})
+	failure.fail(3, &eacute, []activeRule{{"a", 0, 1}, {"d", 3, 1}})
+	failure.fail(3, &eacute, []activeRule{{"a", 0, 1}, {"d", 3, 1}})
+	failure.fail(2, &x, []activeRule{{"a", 2, 1}})
+	assert.Equal(t, 2, len(failure.terminals))
+
+	err := newParseError([]rune("ab\né"), failure, PositionOptions{})
+	assert.Equal(t, "c", err.Rule())
+	assert.Equal(t, 3, err.Offset())
+	assert.Equal(t, 4, err.End())
+	assert.Equal(t, 3, err.ByteOffset())
+	assert.Equal(t, 5, err.ByteEnd())
+	assert.Equal(t, 2, err.Line())
+	assert.Equal(t, 1, err.Position())
+	assert.Equal(t, "é", err.Found())
+	assert.Equal(t, []string{"'é'", "[0-9]"}, err.Expected())
+	assert.Equal(t, []string{"b", "c", "d"}, err.ExpectedRules())
+	assert.Equal(t, ErrParseFailed+" at line 2 position 1: found 'é', expected 'é' or [0-9] in rule c", err.Error())
+
+	// The end of the input
+	err = newParseError([]rune("ab"), parseFailure{offset: 2, terminals: []Terminal{OfTerminalString("", "c")}, eof: true}, PositionOptions{})
+	assert.Equal(t, 2, err.End())
+	assert.Equal(t, "", err.Found())
+	assert.Equal(t, ErrParseFailed+" at line 1 position 3: found EOF, expected 'c' or EOF", err.Error())
+
+	assert.Equal(t, ErrParseFailed+" at line 1 position 1", newParseError(nil, parseFailure{}, PositionOptions{}).Error())
+}
+
+func TestEngineParseError(t *testing.T) {
+	g := testGrammar(
+		"list = item rest",
+		"rest = (sep item)*",
+		"item = [abc] | 'x' 'y'",
+		"sep = ',' | ';;'",
+	)
+
+	for _, backend := range []Backend{BackendBacktrack, BackendEarley} {
+		engine, _ := NewEngine(g, ParseOptions{Backend: backend})
+
+		_, err := engine.Parse(strings.NewReader("a;b"))
+		assert.Equal(t, ErrParseFailed+" at line 1 position 3: found 'b', expected ';;' in rule sep", err.Error())
+
+		_, err = engine.Parse(strings.NewReader("a,"))
+		assert.Equal(t, ErrParseFailed+" at line 1 position 3: found EOF, expected 'x' or [a-c] in rule item", err.Error())
+		assert.Equal(t, []string{"item"}, err.(ParseError).ExpectedRules())
+
+		_, err = engine.Parse(strings.NewReader("ab"))
+		assert.Equal(t, ErrParseFailed+" at line 1 position 2: found 'b', expected ',', ';;' or EOF in rule sep", err.Error())
+		assert.Equal(t, []string{"rest", "sep"}, err.(ParseError).ExpectedRules())
+	}
+}
@@ -0,0 +1,175 @@
+package parser
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// ParseOne error message constants
+const (
+	ErrParseOneBackend   = "ParseOne is only supported by the backtracking backend"
+	ErrParseOneIndent    = "ParseOne does not support indentation"
+	ErrParseOneEncoding  = "ParseOne only supports UTF-8 input"
+	ErrParseOneLookahead = "The end of the match depends on input after it, which requires a *bufio.Reader source"
+)
+
+// oneReader reads the input of ParseOne without consuming more of the source than the match
+type oneReader struct {
+	// the source, if it is not a *bufio.Reader
+	source io.Reader
+	// the source, if it is a *bufio.Reader, which is peeked and only discarded up to the end of the match
+	buffered *bufio.Reader
+	// the bytes read or peeked so far
+	data []byte
+	eof  bool
+}
+
+// fill reads or peeks one more byte, setting eof if there are no more
+func (r *oneReader) fill() error {
+	if r.buffered != nil {
+		data, err := r.buffered.Peek(len(r.data) + 1)
+		r.data = data
+		if err == io.EOF {
+			r.eof = true
+			return nil
+		}
+
+		return err
+	}
+
+	var buf [1]byte
+	for {
+		n, err := r.source.Read(buf[:])
+		if n == 1 {
+			r.data = append(r.data, buf[0])
+			return nil
+		} else if err == io.EOF {
+			r.eof = true
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// consume returns the number of bytes consumed from the source once the match is n bytes long.
+// Returns an error if the source is not a *bufio.Reader, and more than n bytes have been read from it.
+func (r *oneReader) consume(n int) (int, error) {
+	if r.buffered != nil {
+		return r.buffered.Discard(n)
+	}
+
+	if n < len(r.data) {
+		return len(r.data), fmt.Errorf("%s", ErrParseOneLookahead)
+	}
+
+	return n, nil
+}
+
+// read returns the number of bytes consumed from the source when there is no match
+func (r *oneReader) read() int {
+	if r.buffered != nil {
+		return 0
+	}
+
+	return len(r.data)
+}
+
+// ParseOne reads one match of the start rule from the source, and returns its parse tree and the number of bytes consumed,
+// leaving the source positioned after the match, so that it can be called repeatedly to decode messages from a stream.
+//
+// The input is read one rune at a time. After each rune, the start rule is matched against the input read so far, until
+// the matches do not depend on any more input, and the first of them is returned, in the same order as Parse.
+// As the input is matched again for each rune, messages should be short.
+// Trivia before the first terminal is part of the match, and trivia after the last terminal is left for the next call.
+//
+// If the source is a *bufio.Reader, runes are peeked, and only the match is consumed. Otherwise bytes are read one at a time,
+// and the end of the match must not depend on any input after it, as it would be consumed. For example, a match of
+// [0-9]+ only ends when a rune that is not a digit is read, which requires a *bufio.Reader.
+//
+// Returns io.EOF if the source is at EOF, an error if the source cannot be read, ErrParseFailed if the input does not
+// begin with a match, ErrParseOneLookahead if the source was read past the match, ErrParseOneBackend for the Earley backend,
+// ErrParseOneIndent for indentation, and ErrParseOneEncoding for an encoding other than EncodingUTF8.
+// The number of bytes consumed is also returned with an error.
+func (e *Engine) ParseOne(source io.Reader) (Node, int, error) {
+	if e.options.Backend != BackendBacktrack {
+		return Node{}, 0, fmt.Errorf("%s", ErrParseOneBackend)
+	}
+	if e.options.Indent != nil {
+		return Node{}, 0, fmt.Errorf("%s", ErrParseOneIndent)
+	}
+	if e.options.Encoding != EncodingUTF8 {
+		return Node{}, 0, fmt.Errorf("%s", ErrParseOneEncoding)
+	}
+
+	var (
+		ctx    = OfParseContext()
+		r      = &oneReader{source: source}
+		input  []rune
+		offset int
+		// byte offset of the end of each rune
+		ends []int
+	)
+	if buffered, isa := source.(*bufio.Reader); isa {
+		r.source, r.buffered = nil, buffered
+	}
+
+	for {
+		// Read the next rune, which may be more than one byte
+		for !r.eof && !utf8.FullRune(r.data[offset:]) {
+			if err := r.fill(); err != nil {
+				return Node{}, r.read(), err
+			}
+			if max := e.options.MaxInputSize; (max > 0) && (len(r.data) > max) {
+				return Node{}, r.read(), fmt.Errorf("%s: %d", ErrMaxInputSize, max)
+			}
+		}
+
+		if offset < len(r.data) {
+			char, size := utf8.DecodeRune(r.data[offset:])
+			input = append(input, char)
+			offset += size
+			ends = append(ends, offset)
+		} else if len(input) == 0 {
+			return Node{}, 0, io.EOF
+		}
+
+		var (
+			b       = newBacktracker(e, ctx, input)
+			matches []Node
+		)
+		if err := func() (err error) {
+			defer recoverLimit(&err)
+			matches = b.matchRule(e.options.Start, 0)
+			return nil
+		}(); err != nil {
+			return Node{}, r.read(), err
+		}
+
+		// The matches depend on more input if a terminal tried to match past the end of the input read so far
+		if !r.eof && (b.failure.offset >= len(input)) {
+			continue
+		}
+
+		if len(matches) == 0 {
+			return Node{}, r.read(), e.parseError(input, b.failure)
+		}
+
+		match, size := matches[0], 0
+		if match.end > 0 {
+			size = ends[match.end-1]
+		}
+
+		consumed, err := r.consume(size)
+		if err != nil {
+			return Node{}, consumed, err
+		}
+
+		match.index(newLineIndex(input[:match.end], e.options.Positions))
+		e.cover(match)
+		runActions(ctx, e.options.Actions, match)
+		return match, consumed, nil
+	}
+}
@@ -0,0 +1,68 @@
+package parser
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEngineParseOne(t *testing.T) {
+	var (
+		g = testGrammar(
+			"msg = 'x' num ';'",
+			"num = ([0123456789])+",
+		)
+		engine, _ = NewEngine(g, ParseOptions{})
+		source    = strings.NewReader("x12;x3;x")
+	)
+
+	// Messages that end with a terminal are read exactly
+	node, n, err := engine.ParseOne(source)
+	assert.Nil(t, err)
+	assert.Equal(t, "msg('x' num('1' '2') ';')", treeString(node))
+	assert.Equal(t, 4, n)
+
+	node, n, err = engine.ParseOne(source)
+	assert.Nil(t, err)
+	assert.Equal(t, "x3;", node.Text())
+	assert.Equal(t, 3, n)
+
+	// A message cut short by EOF fails
+	_, n, err = engine.ParseOne(source)
+	assert.Equal(t, ErrParseFailed+" at line 1 position 2: found EOF, expected [0-9] in rule num", err.Error())
+	assert.Equal(t, 1, n)
+
+	_, n, err = engine.ParseOne(source)
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, 0, n)
+
+	// A message that needs lookahead to end requires a bufio.Reader, where only the match is consumed
+	engine, _ = NewEngine(testGrammar("num = ([0123456789])+"), ParseOptions{})
+	_, n, err = engine.ParseOne(strings.NewReader("12é"))
+	assert.Equal(t, ErrParseOneLookahead, err.Error())
+	assert.Equal(t, 4, n)
+
+	buffered := bufio.NewReader(strings.NewReader("12é"))
+	node, n, err = engine.ParseOne(buffered)
+	assert.Nil(t, err)
+	assert.Equal(t, "12", node.Text())
+	assert.Equal(t, 2, n)
+	rest, _ := ioutil.ReadAll(buffered)
+	assert.Equal(t, "é", string(rest))
+
+	// A message that does not match fails without consuming a bufio.Reader
+	buffered = bufio.NewReader(strings.NewReader("a"))
+	_, n, err = engine.ParseOne(buffered)
+	assert.Equal(t, ErrParseFailed+" at line 1 position 1: found 'a', expected [0-9] in rule num", err.Error())
+	assert.Equal(t, 0, n)
+	rest, _ = ioutil.ReadAll(buffered)
+	assert.Equal(t, "a", string(rest))
+
+	engine, _ = NewEngine(g, ParseOptions{Backend: BackendEarley})
+	_, _, err = engine.ParseOne(strings.NewReader("x1;"))
+	assert.Equal(t, ErrParseOneBackend, err.Error())
+}
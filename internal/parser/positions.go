@@ -0,0 +1,73 @@
+package parser
+
+// PositionOptions are the conventions of the positions in the input that a parse reports, such as the column of a
+// ParseError, so that they match those of an editor
+type PositionOptions struct {
+	// TabWidth is the number of columns between tab stops, where a tab advances the column to the next tab stop,
+	// or one column if <= 0
+	TabWidth int
+	// ZeroBased is true if the first column of a line is 0, otherwise it is 1
+	ZeroBased bool
+}
+
+// column returns the column of the rune at offset in a line that begins at start
+func (o PositionOptions) column(input []rune, start, offset int) int {
+	column := offset - start
+	if o.TabWidth > 0 {
+		column = 0
+		for _, char := range input[start:offset] {
+			if char == '\t' {
+				column += o.TabWidth - column%o.TabWidth
+			} else {
+				column++
+			}
+		}
+	}
+
+	if o.ZeroBased {
+		return column
+	}
+
+	return column + 1
+}
+
+// linePosition returns the line of the rune at offset in the input, starting at 1, and its column
+func (o PositionOptions) linePosition(input []rune, offset int) (line, position int) {
+	if offset > len(input) {
+		offset = len(input)
+	}
+
+	line, start := 1, 0
+	for i := 0; i < offset; i++ {
+		if input[i] == '\n' {
+			line++
+			start = i + 1
+		}
+	}
+
+	return line, o.column(input, start, offset)
+}
+
+// first returns the column of the first rune of a line
+func (o PositionOptions) first() int {
+	if o.ZeroBased {
+		return 0
+	}
+
+	return 1
+}
+
+// next returns the column after a rune at a column, which does not end the line
+func (o PositionOptions) next(column int, char rune) int {
+	if (char == '\t') && (o.TabWidth > 0) {
+		return column + o.TabWidth - (column-o.first())%o.TabWidth
+	}
+
+	return column + 1
+}
+
+// endsLine returns true if a rune followed by another ends a line, where the other is -1 at the end of the input.
+// A CR ends a line unless it is the CR of a CRLF, whose LF ends the line.
+func endsLine(char, next rune) bool {
+	return (char == '\n') || ((char == '\r') && (next != '\n'))
+}
@@ -0,0 +1,75 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPositionOptions(t *testing.T) {
+	input := []rune("ab\n\tc\t\td\n x\ty")
+
+	for _, test := range []struct {
+		options        PositionOptions
+		offset         int
+		line, position int
+	}{
+		{PositionOptions{}, 0, 1, 1},
+		{PositionOptions{}, 2, 1, 3},
+		{PositionOptions{}, 4, 2, 2},
+		{PositionOptions{}, 7, 2, 5},
+		{PositionOptions{ZeroBased: true}, 0, 1, 0},
+		{PositionOptions{ZeroBased: true}, 7, 2, 4},
+		{PositionOptions{TabWidth: 4}, 4, 2, 5},
+		{PositionOptions{TabWidth: 4}, 5, 2, 6},
+		{PositionOptions{TabWidth: 4}, 6, 2, 9},
+		{PositionOptions{TabWidth: 4}, 7, 2, 13},
+		{PositionOptions{TabWidth: 4}, 11, 3, 3},
+		{PositionOptions{TabWidth: 4}, 12, 3, 5},
+		{PositionOptions{TabWidth: 4, ZeroBased: true}, 7, 2, 12},
+		{PositionOptions{TabWidth: 4, ZeroBased: true}, 100, 3, 5},
+	} {
+		line, position := test.options.linePosition(input, test.offset)
+		assert.Equal(t, []int{test.line, test.position}, []int{line, position}, "%+v %d", test.options, test.offset)
+	}
+
+	lines := OfLineIndexOptions("ab\n\tc\t\td", PositionOptions{TabWidth: 8, ZeroBased: true})
+	assert.Equal(t, Position{Offset: 4, ByteOffset: 4, Line: 2, Column: 8}, lines.Position(4))
+	assert.Equal(t, Position{Offset: 7, ByteOffset: 7, Line: 2, Column: 24}, lines.Position(7))
+	assert.Equal(t, OfLineIndex("ab\n\tc").Position(4), OfLineIndexOptions("ab\n\tc", PositionOptions{}).Position(4))
+}
+
+func TestParsePositions(t *testing.T) {
+	g := testGrammar(
+		"line = tabs word",
+		"tabs = ([t])*",
+		"word = ([ab])+",
+	)
+	g.rules[1].expr.items[0].list[0].terminal = OfTerminalRange("[\\t]", map[rune]bool{'\t': true})
+
+	for _, test := range []struct {
+		options  PositionOptions
+		position int
+	}{
+		{PositionOptions{}, 3},
+		{PositionOptions{ZeroBased: true}, 2},
+		{PositionOptions{TabWidth: 4}, 9},
+		{PositionOptions{TabWidth: 4, ZeroBased: true}, 8},
+	} {
+		engine, err := NewEngine(g, ParseOptions{Positions: test.options})
+		assert.Nil(t, err)
+
+		// Errors are at the column of the options
+		_, err = engine.Parse(strings.NewReader("\t\tx"))
+		assert.IsType(t, ParseError{}, err)
+		assert.Equal(t, 1, err.(ParseError).Line())
+		assert.Equal(t, test.position, err.(ParseError).Position())
+
+		// Spans are at the column of the options
+		node, err := engine.Parse(strings.NewReader("\t\tab"))
+		assert.Nil(t, err)
+		start, end := node.Children()[1].Span()
+		assert.Equal(t, []int{test.position, test.position + 2}, []int{start.Column, end.Column})
+	}
+}
@@ -0,0 +1,128 @@
+package parser
+
+import (
+	"sort"
+)
+
+// Profile is the number of times each alternative of each rule matched while parsing representative inputs,
+// indexed by rule name and then by the index of the alternative in the rule
+type Profile map[string][]int
+
+// ReorderAlternatives returns a grammar where the alternatives of each rule in the profile are sorted from most to least
+// frequently matched, so the most likely alternative is tried first. Alternatives with the same count, or that are not
+// in the profile, keep their relative order after the counted alternatives.
+//
+// Reordering is only done where it cannot change what the grammar matches, which is when no two alternatives of the rule
+// can begin with the same character, and no alternative can match nothing. Rules that do not meet this condition are left unchanged.
+// If no rule is reordered, the grammar is returned unchanged.
+func (g Grammar) ReorderAlternatives(profile Profile) Grammar {
+	return g.withOrders(g.alternativeOrders(profile), nil)
+}
+
+// alternativeOrders returns the order ReorderAlternatives tries the alternatives of each rule it reorders in,
+// as the index of each alternative in the rule
+func (g Grammar) alternativeOrders(profile Profile) map[string][]int {
+	var (
+		result = map[string][]int{}
+		firsts = firstSets(g)
+	)
+
+	for _, rule := range g.rules {
+		counts, haveCounts := profile[rule.name]
+		if !haveCounts || !firsts[rule.name].disjoint() {
+			continue
+		}
+
+		order := make([]int, len(rule.expr.items))
+		for j := range order {
+			order[j] = j
+		}
+
+		count := func(j int) int {
+			if j < len(counts) {
+				return counts[j]
+			}
+
+			return 0
+		}
+		sort.SliceStable(order, func(a, b int) bool { return count(order[a]) > count(order[b]) })
+
+		for j, k := range order {
+			if j != k {
+				result[rule.name] = order
+				break
+			}
+		}
+	}
+
+	return result
+}
+
+// withOrders returns a grammar where the alternatives of each rule in orders are in the order of their indexes,
+// with their error messages, and each rule in memo has the :MEMO option if it is true, or the :NOMEMO option if it is
+// false, in place of either.
+// If no rule is changed, the grammar is returned unchanged.
+func (g Grammar) withOrders(orders map[string][]int, memo map[string]bool) Grammar {
+	var (
+		rules   = make([]Rule, len(g.rules))
+		changed bool
+	)
+
+	for i, rule := range g.rules {
+		rules[i] = rule
+
+		var (
+			order, reorder = orders[rule.name]
+			memoize, memod = memo[rule.name]
+			options        = rule.options
+		)
+		if memod {
+			options = nil
+			for _, option := range rule.options {
+				if (option != OptionMemo) && (option != OptionNoMemo) {
+					options = append(options, option)
+				}
+			}
+
+			if memoize {
+				options = append(options, OptionMemo)
+			} else {
+				options = append(options, OptionNoMemo)
+			}
+		}
+		if !reorder && (formatOptions(options) == formatOptions(rule.options)) {
+			continue
+		}
+
+		items := rule.expr.items
+		if reorder {
+			items = make([]ExpressionItem, len(order))
+			for j, k := range order {
+				items[j] = rule.expr.items[k]
+			}
+		}
+
+		rules[i] = newRule(rule.name, options, newExpression(items))
+		rules[i].doc, rules[i].limits = rule.doc, rule.limits
+		if reorder {
+			rules[i] = rules[i].withErrorMessageOrder(order)
+		}
+		changed = true
+	}
+
+	if !changed {
+		return g
+	}
+
+	return newGrammar(rules)
+}
+
+// formatOptions returns the options in the notation of a rule, such as :MEMO:NOMEMO
+func formatOptions(options []Option) string {
+	result := ""
+	for _, option := range options {
+		result += option.String()
+	}
+
+	return result
+}
@@ -0,0 +1,24 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReorderAlternatives(t *testing.T) {
+	g := testGrammar(
+		"a = 'x' a | b | [yz] | 'u'",
+		"b = 'w' | 'v'",
+		"c = 'x' | 'x' 'y'",
+	)
+	assert.Equal(t, g, g.ReorderAlternatives(nil))
+	assert.Equal(t, g, g.ReorderAlternatives(Profile{"a": {3, 2, 1}, "b": {2, 1}}))
+
+	g = g.ReorderAlternatives(Profile{"a": {1, 5, 1}, "b": {0, 1}, "c": {0, 10}})
+	assert.Equal(
+		t,
+		"a = b | 'x' a | [yz] | 'u'\nb = 'v' | 'w'\nc = 'x' | 'x' 'y'",
+		g.String(),
+	)
+}
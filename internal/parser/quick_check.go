@@ -0,0 +1,167 @@
+package parser
+
+// QuickCheck determines which alternatives of a rule can possibly match given the next character of input,
+// so a parser can skip alternatives that cannot match without descending into them.
+// The sets are computed once from the first characters of each alternative, as a bitset for ASCII characters,
+// and a RuneSet for all other characters.
+type QuickCheck struct {
+	rules map[string][]quickCheckSet
+}
+
+// quickCheckSet is the first characters of one alternative
+type quickCheckSet struct {
+	ascii [2]uint64
+	other RuneSet
+	// true if the alternative may match regardless of the next character
+	always bool
+	// terminals the alternative can begin with, which fail when it is skipped
+	expected []quickCheckTerminal
+}
+
+// quickCheckTerminal is a terminal an alternative can begin with, and the rules and alternatives that are matched before
+// it in the alternative, outermost first
+type quickCheckTerminal struct {
+	terminal Terminal
+	rules    []activeRule
+	// true if the terminal has the :CONSTTIME option
+	constantTime bool
+}
+
+// contains returns true if the alternative may match when the next character is char
+func (q quickCheckSet) contains(char rune) bool {
+	switch {
+	case q.always:
+		return true
+	case char < 0:
+		return false
+	case char < 128:
+		return (q.ascii[char/64] & (1 << uint(char%64))) != 0
+	}
+
+	return q.other.Contains(char)
+}
+
+// QuickCheck computes the quick check sets of the grammar.
+// An alternative that can begin with a string terminal with the :CONSTTIME option is always a candidate, as skipping it
+// when the next character differs from the first of the string would reveal how the input compares with the string.
+// If enabled is false, every alternative is always a candidate, which is needed when terminals can match characters that
+// the analysis does not know about.
+func (g Grammar) QuickCheck(enabled bool) QuickCheck {
+	result := QuickCheck{rules: map[string][]quickCheckSet{}}
+
+	if !enabled {
+		for name, rule := range rulesByName(g.rules) {
+			altSets := make([]quickCheckSet, len(rule.expr.items))
+			for i := range altSets {
+				altSets[i].always = true
+			}
+
+			result.rules[name] = altSets
+		}
+
+		return result
+	}
+
+	var (
+		firsts = firstSets(g)
+		rules  = rulesByName(g.rules)
+	)
+	for name, sets := range firsts {
+		altSets := make([]quickCheckSet, len(sets.alternatives))
+
+		for i, alt := range sets.alternatives {
+			altSet := quickCheckSet{
+				other: alt.chars.Subtract(OfRuneSet(RuneInterval{0, 127})),
+				// An alternative that can match nothing, or whose first characters are not known, cannot be skipped
+				always: alt.nullable || alt.unknown,
+			}
+			if !altSet.always {
+				altSet.expected = expectedTerminals(rules, firsts, rules[name].expr.items[i].list, nil, map[string]bool{})
+				for _, expected := range altSet.expected {
+					altSet.always = altSet.always || expected.constantTime
+				}
+				if altSet.always {
+					altSet.expected = nil
+				}
+			}
+
+			for _, char := range alt.chars.Intersect(OfRuneSet(RuneInterval{0, 127})).Runes() {
+				altSet.ascii[char/64] |= 1 << uint(char%64)
+			}
+
+			altSets[i] = altSet
+		}
+
+		result.rules[name] = altSets
+	}
+
+	return result
+}
+
+// expectedTerminals returns the terminals a list of items can begin with, in the order that matching the list tries them,
+// where enclosing are the rules and alternatives entered before the list, and visited are the rules already visited
+func expectedTerminals(rules map[string]Rule, firsts map[string]ruleFirstSets, list []ListItem, enclosing []activeRule, visited map[string]bool) []quickCheckTerminal {
+	var result []quickCheckTerminal
+
+	for _, item := range list {
+		if item.IsTerminal() {
+			return append(result, quickCheckTerminal{terminal: item.terminal, rules: enclosing, constantTime: item.ConstantTime()})
+		}
+
+		if rule, defined := rules[item.ruleName]; defined && !visited[item.ruleName] {
+			visited[item.ruleName] = true
+			for a, alt := range rule.expr.items {
+				nested := append(enclosing[:len(enclosing):len(enclosing)], activeRule{name: item.ruleName, alt: a + 1})
+				result = append(result, expectedTerminals(rules, firsts, alt.list, nested, visited)...)
+			}
+		}
+		if !firsts[item.ruleName].rule.nullable {
+			break
+		}
+	}
+
+	return result
+}
+
+// Candidates returns the indexes of the alternatives of the named rule that may match when the next character is next,
+// in the order they occur in the rule. A negative next character means EOF, where only alternatives that can match
+// nothing are candidates. Returns nil if the rule is not defined.
+func (q QuickCheck) Candidates(ruleName string, next rune) []int {
+	var result []int
+
+	for i, altSet := range q.rules[ruleName] {
+		if altSet.contains(next) {
+			result = append(result, i)
+		}
+	}
+
+	return result
+}
+
+// candidate returns true if the numbered alternative of the named rule may match when the next character is next,
+// or if the rule is not defined
+func (q QuickCheck) candidate(ruleName string, alt int, next rune) bool {
+	altSets, defined := q.rules[ruleName]
+	return !defined || altSets[alt].contains(next)
+}
+
+// skip records that the numbered alternative of the named rule failed at offset without matching it, as each terminal
+// it can begin with fails there, in the rules that matching the alternative would have entered to reach the terminal
+func (b *backtracker) skip(name string, alt int, offset int) {
+	if offset < b.failure.offset {
+		return
+	}
+
+	active := b.active
+	defer func() { b.active = active }()
+
+	for _, expected := range b.quickCheck.rules[name][alt].expected {
+		b.active = active[:len(active):len(active)]
+		for _, rule := range expected.rules {
+			b.active = append(b.active, activeRule{name: rule.name, offset: offset, alt: rule.alt})
+		}
+
+		terminal := expected.terminal
+		b.fail(offset, &terminal)
+	}
+}
@@ -0,0 +1,118 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuickCheck(t *testing.T) {
+	g := testGrammar(
+		"a = 'x' a | b | [yé] | ('u')? | undefined",
+		"b = 'w' | 'v' | 'x'",
+	)
+
+	qc := g.QuickCheck(true)
+	assert.Equal(t, []int{0, 1, 3, 4}, qc.Candidates("a", 'x'))
+	assert.Equal(t, []int{1, 3, 4}, qc.Candidates("a", 'w'))
+	assert.Equal(t, []int{2, 3, 4}, qc.Candidates("a", 'é'))
+	assert.Equal(t, []int{3, 4}, qc.Candidates("a", 'z'))
+	assert.Equal(t, []int{3, 4}, qc.Candidates("a", -1))
+	assert.Equal(t, []int{2}, qc.Candidates("b", 'x'))
+	assert.Nil(t, qc.Candidates("b", 'z'))
+	assert.Nil(t, qc.Candidates("undefined", 'x'))
+
+	qc = g.QuickCheck(false)
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, qc.Candidates("a", 'z'))
+	assert.Equal(t, []int{0, 1, 2}, qc.Candidates("b", -1))
+	assert.Nil(t, qc.Candidates("undefined", 'x'))
+}
+
+func TestQuickCheckConstantTime(t *testing.T) {
+	g := testGrammar(
+		"s = 'secret' | t | 'other'",
+		"t = u",
+		"u = 'key'",
+	)
+	g.rules[0].expr.items[0].list[0].options = []Option{OptionConstTime}
+	g.rules[2].expr.items[0].list[0].options = []Option{OptionConstTime}
+
+	// An alternative that begins with a :CONSTTIME terminal, directly or through other rules, is not skipped, so the
+	// terminal is compared with the input whatever its first character is
+	qc := g.QuickCheck(true)
+	assert.Equal(t, []int{0, 1}, qc.Candidates("s", 'x'))
+	assert.Equal(t, []int{0, 1, 2}, qc.Candidates("s", 'o'))
+	assert.Equal(t, []int{0}, qc.Candidates("t", -1))
+
+	engine, err := NewEngine(g, ParseOptions{})
+	assert.Nil(t, err)
+	b := newBacktracker(engine, OfParseContext(), []rune("xecret"))
+	b.quickCheck = engine.quickCheck
+	assert.True(t, b.candidate("s", 0, 0))
+	assert.True(t, b.candidate("s", 1, 0))
+	assert.False(t, b.candidate("s", 2, 0))
+}
+
+func TestQuickCheckSkipsAlternatives(t *testing.T) {
+	g := testGrammar(
+		"s = a | b",
+		"a = 'x' c",
+		"b = 'z'",
+		"c = 'y'",
+	)
+
+	// The a alternative cannot begin with z, so it is skipped, and only s and b are tried, with a match of the list and
+	// alternative of each
+	engine, err := NewEngine(g, ParseOptions{})
+	assert.Nil(t, err)
+	b := newBacktracker(engine, OfParseContext(), []rune("z"))
+	b.quickCheck = engine.quickCheck
+	_, matched, _ := b.parse()
+	assert.True(t, matched)
+	assert.Equal(t, 6, b.steps)
+
+	engine, err = NewEngine(g, ParseOptions{MaxSteps: 6})
+	assert.Nil(t, err)
+	_, err = engine.ParseString("z")
+	assert.Nil(t, err)
+
+	// Every alternative is tried without the quick check
+	engine, err = NewEngine(g, ParseOptions{NoQuickCheck: true})
+	assert.Nil(t, err)
+	assert.Nil(t, engine.quickCheck)
+	b = newBacktracker(engine, OfParseContext(), []rune("z"))
+	_, matched, _ = b.parse()
+	assert.True(t, matched)
+	assert.Equal(t, 7, b.steps)
+
+	engine, err = NewEngine(g, ParseOptions{MaxSteps: 6, NoQuickCheck: true})
+	assert.Nil(t, err)
+	_, err = engine.ParseString("z")
+	assert.Equal(t, ErrMaxSteps, err.(LimitError).Err)
+
+	// Input that does not match expects the terminals of skipped alternatives
+	engine, err = NewEngine(g, ParseOptions{})
+	assert.Nil(t, err)
+	_, err = engine.ParseString("w")
+	assert.Equal(t, []string{"'x'", "'z'"}, err.(ParseError).Expected())
+
+	// The input is only matched once, and fails the same way as it does when every alternative is tried
+	g = testGrammar(
+		"s = a | b 'y' | c",
+		"a = 'x' a | 'w'",
+		"b = o p",
+		"c = 'v'",
+		"o = ('o')?",
+		"p = 'p' | 'q'",
+	)
+	quick, err := NewEngine(g, ParseOptions{})
+	assert.Nil(t, err)
+	slow, err := NewEngine(g, ParseOptions{NoQuickCheck: true})
+	assert.Nil(t, err)
+	for _, input := range []string{"", "z", "xxz", "opz", "qz"} {
+		_, quickErr := quick.ParseString(input)
+		_, slowErr := slow.ParseString(input)
+		assert.IsType(t, ParseError{}, quickErr)
+		assert.Equal(t, slowErr, quickErr)
+	}
+}
@@ -0,0 +1,349 @@
+// Package railroad renders the rules of a grammar as railroad diagrams, in SVG for a single rule,
+// and in HTML for a page with a diagram of every rule of a grammar
+package railroad
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/bantling/goparse/internal/parser"
+)
+
+// Layout constants, in pixels
+const (
+	// width of a character of a box label, which is a monospace font
+	charWidth = 8
+	// space between a box label and the sides of the box
+	boxPad = 10
+	// half the height of a box
+	boxHalf = 11
+	// radius of the curves of choices and loops
+	arc = 10
+	// horizontal space between the items of a sequence
+	gap = 10
+	// vertical space between alternatives, and between a repeated item and its loop
+	vgap = 8
+	// height of the label of a loop
+	labelHeight = 14
+	// space around a diagram
+	margin = 10
+	// length of the lines leading into and out of a diagram
+	endWidth = 20
+)
+
+// svgStyle is the style of the SVG elements of a diagram
+const svgStyle = `<style>` +
+	`.railroad path{fill:none;stroke:#333;stroke-width:2}` +
+	`.railroad rect{fill:#eef;stroke:#333;stroke-width:2}` +
+	`.railroad rect.terminal{fill:#ffd}` +
+	`.railroad text{font:13px monospace;text-anchor:middle;fill:#000}` +
+	`.railroad text.label{font-size:11px}` +
+	`</style>`
+
+// diagram is a part of a railroad diagram, which has a line through it that enters on the left and exits on the right
+type diagram interface {
+	// size returns the width, and the heights above and below the line
+	size() (width, up, down int)
+	// write writes the SVG elements of the diagram, where the line enters at x, y
+	write(str *strings.Builder, x, y int)
+}
+
+// box is a terminal or a rule name, where a rule name links to the diagram of the rule
+type box struct {
+	text     string
+	terminal bool
+}
+
+// sequence is diagrams that are matched one after the other
+type sequence []diagram
+
+// choice is alternative diagrams, where the first is on the line and the rest are below it
+type choice []diagram
+
+// loop is a diagram that is repeated, with an optional label describing the number of repetitions
+type loop struct {
+	item  diagram
+	label string
+}
+
+// skip is an empty diagram, for an optional choice
+type skip struct{}
+
+// writeLine writes a horizontal line
+func writeLine(str *strings.Builder, x, y, width int) {
+	if width > 0 {
+		fmt.Fprintf(str, `<path d="M%d %dh%d"/>`, x, y, width)
+	}
+}
+
+func (b box) size() (int, int, int) {
+	return len([]rune(b.text))*charWidth + 2*boxPad, boxHalf, boxHalf
+}
+
+func (b box) write(str *strings.Builder, x, y int) {
+	width, _, _ := b.size()
+	text := html.EscapeString(b.text)
+
+	if b.terminal {
+		fmt.Fprintf(str, `<rect class="terminal" x="%d" y="%d" width="%d" height="%d" rx="%d"/>`, x, y-boxHalf, width, 2*boxHalf, boxHalf)
+		fmt.Fprintf(str, `<text x="%d" y="%d">%s</text>`, x+width/2, y+4, text)
+		return
+	}
+
+	fmt.Fprintf(str, `<a href="#%s">`, text)
+	fmt.Fprintf(str, `<rect x="%d" y="%d" width="%d" height="%d"/>`, x, y-boxHalf, width, 2*boxHalf)
+	fmt.Fprintf(str, `<text x="%d" y="%d">%s</text>`, x+width/2, y+4, text)
+	str.WriteString(`</a>`)
+}
+
+func (s sequence) size() (int, int, int) {
+	var width, up, down int
+	for i, item := range s {
+		w, u, d := item.size()
+		if i > 0 {
+			width += gap
+		}
+		width += w
+		if u > up {
+			up = u
+		}
+		if d > down {
+			down = d
+		}
+	}
+
+	return width, up, down
+}
+
+func (s sequence) write(str *strings.Builder, x, y int) {
+	for i, item := range s {
+		if i > 0 {
+			writeLine(str, x, y, gap)
+			x += gap
+		}
+		item.write(str, x, y)
+		w, _, _ := item.size()
+		x += w
+	}
+}
+
+// offsets returns the offset of the line of each alternative below the line of the choice, and the widest alternative
+func (c choice) offsets() ([]int, int) {
+	var (
+		offsets []int
+		width   int
+		bottom  int
+	)
+
+	for i, alt := range c {
+		w, up, down := alt.size()
+		if w > width {
+			width = w
+		}
+
+		offset := 0
+		if i > 0 {
+			// The curves down to an alternative need room
+			if offset = bottom + vgap + up; offset < 2*arc {
+				offset = 2 * arc
+			}
+		}
+		offsets = append(offsets, offset)
+		bottom = offset + down
+	}
+
+	return offsets, width
+}
+
+func (c choice) size() (int, int, int) {
+	offsets, width := c.offsets()
+	_, up, _ := c[0].size()
+	_, _, down := c[len(c)-1].size()
+
+	return width + 4*arc, up, offsets[len(offsets)-1] + down
+}
+
+func (c choice) write(str *strings.Builder, x, y int) {
+	offsets, width := c.offsets()
+
+	for i, alt := range c {
+		w, _, _ := alt.size()
+		altY := y + offsets[i]
+
+		if i == 0 {
+			writeLine(str, x, y, 2*arc)
+			alt.write(str, x+2*arc, y)
+			writeLine(str, x+2*arc+w, y, width-w+2*arc)
+			continue
+		}
+
+		// Curve down from the line to the alternative, and back up to the line after it
+		fmt.Fprintf(str, `<path d="M%d %dq%d 0 %d %dV%dq0 %d %d %d"/>`, x, y, arc, arc, arc, altY-arc, arc, arc, arc)
+		alt.write(str, x+2*arc, altY)
+		fmt.Fprintf(str, `<path d="M%d %dH%dq%d 0 %d %dV%dq0 %d %d %d"/>`,
+			x+2*arc+w, altY, x+2*arc+width, arc, arc, -arc, y+arc, -arc, arc, -arc)
+	}
+}
+
+// bottom returns the offset of the line that loops back below the line of the loop
+func (l loop) bottom() int {
+	_, _, down := l.item.size()
+	if down+vgap < 2*arc {
+		return 2 * arc
+	}
+
+	return down + vgap
+}
+
+func (l loop) size() (int, int, int) {
+	width, up, _ := l.item.size()
+	down := l.bottom()
+	if l.label != "" {
+		down += labelHeight
+	}
+
+	return width + 4*arc, up, down
+}
+
+func (l loop) write(str *strings.Builder, x, y int) {
+	var (
+		width, _, _ = l.item.size()
+		bottom      = y + l.bottom()
+	)
+
+	writeLine(str, x, y, 2*arc)
+	l.item.write(str, x+2*arc, y)
+	writeLine(str, x+2*arc+width, y, 2*arc)
+
+	// Curve down from the end of the item, back to the left, and up to the start of the item
+	fmt.Fprintf(str, `<path d="M%d %dq%d 0 %d %dV%dq0 %d %d %dH%dq%d 0 %d %dV%dq0 %d %d %d"/>`,
+		x+2*arc+width, y, arc, arc, arc, bottom-arc, arc, -arc, arc, x+2*arc, -arc, -arc, -arc, y+arc, -arc, arc, -arc)
+
+	if l.label != "" {
+		fmt.Fprintf(str, `<text class="label" x="%d" y="%d">%s</text>`, x+2*arc+width/2, bottom+labelHeight-2, html.EscapeString(l.label))
+	}
+}
+
+func (skip) size() (int, int, int) {
+	return 0, 0, 0
+}
+
+func (skip) write(*strings.Builder, int, int) {}
+
+// repeat returns the diagram of an item repeated n to m times, where m == -1 is no upper bound
+func repeat(item diagram, n, m int) diagram {
+	switch {
+	case (n == 1) && (m == 1):
+		return item
+	case (n == 0) && (m == 1):
+		return choice{item, skip{}}
+	case (n == 1) && (m == -1):
+		return loop{item: item}
+	case (n == 0) && (m == -1):
+		return choice{loop{item: item}, skip{}}
+	case n == m:
+		return loop{item: item, label: fmt.Sprintf("%d times", n)}
+	case m == -1:
+		return loop{item: item, label: fmt.Sprintf("%d or more times", n)}
+	case n == 0:
+		return choice{loop{item: item, label: fmt.Sprintf("at most %d times", m)}, skip{}}
+	}
+
+	return loop{item: item, label: fmt.Sprintf("%d to %d times", n, m)}
+}
+
+// ruleDiagram returns the diagram of the definition of a rule
+func ruleDiagram(r parser.Rule) diagram {
+	var alts choice
+	for _, alt := range r.Expr().Items() {
+		var seq sequence
+		for _, item := range alt.Items() {
+			if item.IsRuleName() {
+				seq = append(seq, box{text: item.RuleName()})
+			} else {
+				seq = append(seq, box{text: item.Terminal().Format(), terminal: true})
+			}
+		}
+
+		n, m := alt.Repetitions()
+		alts = append(alts, repeat(seq, n, m))
+	}
+
+	if len(alts) == 1 {
+		return alts[0]
+	}
+
+	return alts
+}
+
+// RuleSVG returns an SVG railroad diagram of the definition of a rule.
+// A rule name in the definition links to the fragment of the same name, which is the diagram of the rule in the HTML page.
+func RuleSVG(r parser.Rule) string {
+	var (
+		str             strings.Builder
+		d               = ruleDiagram(r)
+		width, up, down = d.size()
+	)
+
+	// The ends of the line are as tall as a box
+	if up < boxHalf {
+		up = boxHalf
+	}
+	if down < boxHalf {
+		down = boxHalf
+	}
+
+	var (
+		svgWidth  = 2*margin + 2*endWidth + width
+		svgHeight = 2*margin + up + down
+		y         = margin + up
+		exit      = margin + endWidth + width
+	)
+
+	fmt.Fprintf(
+		&str,
+		`<svg xmlns="http://www.w3.org/2000/svg" class="railroad" width="%d" height="%d" viewBox="0 0 %d %d">`,
+		svgWidth, svgHeight, svgWidth, svgHeight,
+	)
+	fmt.Fprintf(&str, `<title>%s</title>`, html.EscapeString(r.Name()))
+	str.WriteString(svgStyle)
+
+	// A bar at each end, with a line to the definition
+	fmt.Fprintf(&str, `<path d="M%d %dv%dM%d %dh%d"/>`, margin, y-boxHalf/2, boxHalf, margin, y, endWidth)
+	d.write(&str, margin+endWidth, y)
+	fmt.Fprintf(&str, `<path d="M%d %dh%dM%d %dv%d"/>`, exit, y, endWidth, exit+endWidth, y-boxHalf/2, boxHalf)
+	str.WriteString(`</svg>`)
+
+	return str.String()
+}
+
+// HTML returns an HTML page with the given title, an index of the rules of a grammar, and for each rule its name,
+// its doc comment, and its railroad diagram. The diagram of a rule has the rule name as its fragment, so a rule name in a
+// diagram links to the diagram of the rule.
+func HTML(g parser.Grammar, title string) string {
+	var str strings.Builder
+
+	title = html.EscapeString(title)
+	fmt.Fprintf(&str, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n", title)
+	str.WriteString("<style>body{font-family:sans-serif}p.doc{white-space:pre-line}</style>\n")
+	fmt.Fprintf(&str, "</head>\n<body>\n<h1>%s</h1>\n<ul>\n", title)
+	for _, rule := range g.Rules() {
+		name := html.EscapeString(rule.Name())
+		fmt.Fprintf(&str, "<li><a href=\"#%s\">%s</a></li>\n", name, name)
+	}
+	str.WriteString("</ul>\n")
+
+	for _, rule := range g.Rules() {
+		name := html.EscapeString(rule.Name())
+		fmt.Fprintf(&str, "<h2 id=\"%s\">%s</h2>\n", name, name)
+		if doc := rule.Doc(); doc != "" {
+			fmt.Fprintf(&str, "<p class=\"doc\">%s</p>\n", html.EscapeString(doc))
+		}
+		str.WriteString(RuleSVG(rule))
+		str.WriteRune('\n')
+	}
+	str.WriteString("</body>\n</html>\n")
+
+	return str.String()
+}
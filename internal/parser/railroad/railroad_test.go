@@ -0,0 +1,71 @@
+package railroad
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bantling/goparse/internal/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuleSVG(t *testing.T) {
+	g, err := parser.ImportABNF(strings.NewReader(
+		"list = item *(%s\",\" item) / [%s\"x\"] 2*3%x61-63\nitem = %s\"<\" / %s\"b\" 1*%s\"c\"\n",
+	))
+	assert.Nil(t, err)
+	rules := map[string]parser.Rule{}
+	for _, rule := range g.Rules() {
+		rules[rule.Name()] = rule
+	}
+
+	// An optional terminal
+	assert.Equal(
+		t,
+		`<svg xmlns="http://www.w3.org/2000/svg" class="railroad" width="144" height="51" viewBox="0 0 144 51">`+
+			`<title>list-part-2</title>`+svgStyle+
+			`<path d="M10 16v11M10 21h20"/>`+
+			`<path d="M30 21h20"/>`+
+			`<rect class="terminal" x="50" y="10" width="44" height="22" rx="11"/><text x="72" y="25">&#39;x&#39;</text>`+
+			`<path d="M94 21h20"/>`+
+			`<path d="M30 21q10 0 10 10V31q0 10 10 10"/>`+
+			`<path d="M50 41H94q10 0 10 -10V31q0 -10 10 -10"/>`+
+			`<path d="M114 21h20M134 16v11"/>`+
+			`</svg>`,
+		RuleSVG(rules["list-part-2"]),
+	)
+
+	// Rule names link to their diagrams, and terminals are escaped
+	svg := RuleSVG(rules["list"])
+	assert.Contains(t, svg, `<a href="#item"><rect x="50" y="10" width="52" height="22"/><text x="76" y="25">item</text></a>`)
+	assert.Contains(t, svg, `<a href="#list-part-3">`)
+	assert.Contains(t, RuleSVG(rules["item"]), `<text x="72" y="25">&#39;&lt;&#39;</text>`)
+
+	// Repetitions other than ?, *, and + are labelled
+	assert.Contains(t, RuleSVG(rules["list-part-3"]), `<text class="label" x="80" y="53">2 to 3 times</text>`)
+	assert.NotContains(t, RuleSVG(rules["item-part"]), `class="label"`)
+}
+
+func TestRepeat(t *testing.T) {
+	item := box{text: "a"}
+	assert.Equal(t, item, repeat(item, 1, 1))
+	assert.Equal(t, choice{item, skip{}}, repeat(item, 0, 1))
+	assert.Equal(t, loop{item: item}, repeat(item, 1, -1))
+	assert.Equal(t, choice{loop{item: item}, skip{}}, repeat(item, 0, -1))
+	assert.Equal(t, loop{item: item, label: "3 times"}, repeat(item, 3, 3))
+	assert.Equal(t, loop{item: item, label: "2 or more times"}, repeat(item, 2, -1))
+	assert.Equal(t, choice{loop{item: item, label: "at most 3 times"}, skip{}}, repeat(item, 0, 3))
+	assert.Equal(t, loop{item: item, label: "2 to 3 times"}, repeat(item, 2, 3))
+}
+
+func TestHTML(t *testing.T) {
+	g, err := parser.ImportANTLR(strings.NewReader("grammar g;\nlist : item+ ;\nitem : 'a' ;\n"))
+	assert.Nil(t, err)
+	g = parser.OfGrammar("", []parser.Rule{g.Rules()[0].WithDoc("A list of <items>\nin a row"), g.Rules()[1]})
+
+	page := HTML(g, "Lists & items")
+	assert.True(t, strings.HasPrefix(page, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>Lists &amp; items</title>\n"))
+	assert.Contains(t, page, "<h1>Lists &amp; items</h1>\n<ul>\n<li><a href=\"#list\">list</a></li>\n<li><a href=\"#item\">item</a></li>\n</ul>\n")
+	assert.Contains(t, page, "<h2 id=\"list\">list</h2>\n<p class=\"doc\">A list of &lt;items&gt;\nin a row</p>\n<svg")
+	assert.Contains(t, page, "<h2 id=\"item\">item</h2>\n"+RuleSVG(g.Rules()[1])+"\n")
+	assert.True(t, strings.HasSuffix(page, "</svg>\n</body>\n</html>\n"))
+}
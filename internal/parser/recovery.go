@@ -0,0 +1,141 @@
+package parser
+
+// Recovery error message constants
+const (
+	ErrSyncBackend = "Error recovery is only supported by the backtracking backend"
+	ErrSyncEmpty   = "A sync terminal cannot be empty"
+)
+
+// recoveryRegion is input skipped to recover from an error. A rule that ends with the sync terminal that ends the region
+// can match from any offset between start and failed to end, as an error node.
+type recoveryRegion struct {
+	start  int
+	failed int
+	end    int
+	sync   string
+}
+
+// syncRules returns the sync terminals that each rule ends with, for the rules that end with any
+func syncRules(rules []Rule, syncs []string) map[string]map[string]bool {
+	result := map[string]map[string]bool{}
+
+	for _, rule := range rules {
+		for _, alt := range rule.expr.items {
+			if len(alt.list) == 0 {
+				continue
+			}
+
+			last := alt.list[len(alt.list)-1]
+			if last.IsRuleName() || !last.terminal.IsString() {
+				continue
+			}
+
+			for _, sync := range syncs {
+				if last.terminal.theString == sync {
+					if result[rule.name] == nil {
+						result[rule.name] = map[string]bool{}
+					}
+					result[rule.name][sync] = true
+				}
+			}
+		}
+	}
+
+	return result
+}
+
+// nextRegion returns the region to skip for a failure at offset and true, which ends after the first sync terminal
+// at or after the offset, and starts after the last sync terminal or region before it.
+// Returns false if there is no sync terminal after the offset, or the region overlaps a region already skipped.
+func (e *Engine) nextRegion(input []rune, regions []recoveryRegion, offset int) (recoveryRegion, bool) {
+	region := recoveryRegion{failed: offset, end: -1}
+
+	for i := offset; (i < len(input)) && (region.end == -1); i++ {
+		for _, sync := range e.options.Sync {
+			if runesAt(input, i, []rune(sync)) {
+				region.end, region.sync = i+len([]rune(sync)), sync
+				break
+			}
+		}
+	}
+	if region.end == -1 {
+		return recoveryRegion{}, false
+	}
+
+	for i := offset - 1; (i >= 0) && (region.start == 0); i-- {
+		for _, sync := range e.options.Sync {
+			if end := i + len([]rune(sync)); (end <= offset) && runesAt(input, i, []rune(sync)) {
+				region.start = end
+				break
+			}
+		}
+	}
+
+	for _, skipped := range regions {
+		if (region.start < skipped.end) && (skipped.start < region.end) {
+			return recoveryRegion{}, false
+		}
+		if (skipped.end <= offset) && (skipped.end > region.start) {
+			region.start = skipped.end
+		}
+	}
+
+	return region, true
+}
+
+// recoverInput matches all of the input against the start rule like matchInput, except that when the input does not
+// match, the input from the furthest rune that failed to match to the end of the next sync terminal is skipped,
+// and the input is matched again, until it matches or there is no sync terminal to skip to.
+// Returns the same as matchInput, and a ParseError for each region that was skipped.
+func (e *Engine) recoverInput(ctx *ParseContext, input []rune) (node Node, matched bool, failure parseFailure, errs Diagnostics, err error) {
+	defer recoverLimit(&err)
+
+	var regions []recoveryRegion
+	for {
+		b := newBacktracker(e, ctx, input)
+		b.regions = regions
+
+		node, matched, failure = b.parse()
+		if matched {
+			return node, true, parseFailure{}, errs, nil
+		}
+
+		region, found := e.nextRegion(input, regions, failure.offset)
+		if !found {
+			return node, false, failure, errs, nil
+		}
+
+		regions = append(regions, region)
+		errs = append(errs, e.parseError(input, failure))
+	}
+}
+
+// recover returns an error node of the named rule at offset, if it is in a skipped region and the rule ends with
+// the sync terminal that ends the region
+func (b *backtracker) recover(name string, offset int) []Node {
+	for _, region := range b.regions {
+		if (offset >= region.start) && (offset <= region.failed) && b.engine.syncRules[name][region.sync] {
+			text := b.text(offset, region.end)
+			return []Node{{
+				rule:     name,
+				text:     text,
+				start:    offset,
+				end:      region.end,
+				children: []Node{{text: text, start: offset, end: region.end, invalid: true}},
+			}}
+		}
+	}
+
+	return nil
+}
+
+// skipped returns true if the offset is in a skipped region
+func (b *backtracker) skipped(offset int) bool {
+	for _, region := range b.regions {
+		if (offset >= region.start) && (offset < region.end) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,64 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecovery(t *testing.T) {
+	g := testGrammar(
+		"file = (stmt)*",
+		"stmt = [abc] ';' | '{' file '}'",
+	)
+
+	for _, peg := range []bool{false, true} {
+		engine, err := NewEngine(g, ParseOptions{PEG: peg, Sync: []string{";", "}"}})
+		assert.Nil(t, err)
+
+		node, err := engine.Parse(strings.NewReader("a;{b;}"))
+		assert.Nil(t, err)
+		assert.Equal(t, "file(stmt('a' ';') stmt('{' file(stmt('b' ';')) '}'))", treeString(node))
+
+		// Each error is skipped up to the next ;
+		node, err = engine.Parse(strings.NewReader("a;bc;c;{cc;}"))
+		errs := err.(Diagnostics)
+		assert.Equal(t, 2, len(errs))
+		assert.Equal(t, 3, errs[0].(ParseError).Offset())
+		assert.Equal(t, 9, errs[1].(ParseError).Offset())
+		assert.Equal(t, ErrParseFailed+" at line 1 position 4: found 'c', expected ';' in rule stmt\n"+
+			ErrParseFailed+" at line 1 position 10: found 'c', expected ';' in rule stmt", err.Error())
+		assert.Equal(t, "file(stmt('a' ';') stmt('bc;') stmt('c' ';') stmt('{' file(stmt('cc;')) '}'))", treeString(node))
+		assert.True(t, node.Children()[1].Children()[0].IsError())
+
+		// There is no ; to skip to after the last error
+		node, err = engine.Parse(strings.NewReader("a;bc;c"))
+		errs = err.(Diagnostics)
+		assert.Equal(t, 2, len(errs))
+		assert.Equal(t, 3, errs[0].(ParseError).Offset())
+		assert.Equal(t, 6, errs[1].(ParseError).Offset())
+		assert.Equal(t, Node{}, node)
+	}
+
+	// Without sync terminals, the error is a ParseError
+	engine, _ := NewEngine(g, ParseOptions{})
+	_, err := engine.Parse(strings.NewReader("a;bc;c;"))
+	assert.Equal(t, 3, err.(ParseError).Offset())
+
+	_, err = NewEngine(g, ParseOptions{Backend: BackendEarley, Sync: []string{";"}})
+	assert.Equal(t, ErrSyncBackend, err.Error())
+
+	_, err = NewEngine(g, ParseOptions{Sync: []string{""}})
+	assert.Equal(t, ErrSyncEmpty, err.Error())
+}
+
+func TestSyncRules(t *testing.T) {
+	g := testGrammar(
+		"a = 'x' ';' | b '}'",
+		"b = 'y' c",
+		"c = ';'",
+	)
+
+	assert.Equal(t, map[string]map[string]bool{"a": {";": true, "}": true}, "c": {";": true}}, syncRules(g.rules, []string{";", "}"}))
+}
@@ -0,0 +1,304 @@
+package parser
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Replay error message constants
+const (
+	ErrReplayBackend  = "Replay logs are only supported by the backtracking backend"
+	ErrReplayLog      = "The replay log is malformed"
+	ErrReplayGrammar  = "The replay log was recorded with a different grammar"
+	ErrReplayInput    = "The replay log was recorded with different input"
+	ErrReplayStart    = "The replay log was recorded with a different start rule"
+	ErrReplayDiverged = "The replay diverged from the replay log"
+)
+
+// replayHeader is the first line of the text of a replay log, with the version of the format
+const replayHeader = "goparse-replay 1"
+
+// replayKeys begin the lines of the text of a replay log after the header, in order
+var replayKeys = []string{"grammar", "input", "start", "error", "rules"}
+
+// ReplayStep is a decision of the backtracking backend: how many matches an alternative of a rule had at an offset of the
+// input, where Alternative is 1 for the first alternative, or 0 for the matches of a memoized rule
+type ReplayStep struct {
+	Rule        string
+	Offset      int
+	Alternative int
+	Matches     int
+}
+
+// String is the step for people, such as expr at 3 alternative 2 matched 1
+func (s ReplayStep) String() string {
+	if s.Alternative == 0 {
+		return fmt.Sprintf("%s at %d memoized matched %d", s.Rule, s.Offset, s.Matches)
+	}
+
+	return fmt.Sprintf("%s at %d alternative %d matched %d", s.Rule, s.Offset, s.Alternative, s.Matches)
+}
+
+// ReplayLog is a compact record of one parse by the backtracking backend: hashes of the grammar and decoded input, the start
+// rule, the error the parse returned if any, and every decision in the order it was made, so that a bug report can include
+// a trace that Engine.Replay re-executes and checks step by step. The input itself is not recorded, as it may be private.
+type ReplayLog struct {
+	// hex SHA-256 of the formatted grammar of the engine, and of the input as UTF-8
+	GrammarHash string
+	InputHash   string
+	Start       string
+	// message of the error the parse returned, or "" if it succeeded
+	Error string
+	Steps []ReplayStep
+}
+
+// String is the text of the log, which OfReplayLog reads: a header line, a line for each hash, the start rule, and the
+// error, a line of the rule names the steps refer to, and a line for each step of the indexes of its rule name, offset,
+// alternative, and matches
+func (l ReplayLog) String() string {
+	var (
+		str     strings.Builder
+		names   []string
+		indexes = map[string]int{}
+	)
+	for _, step := range l.Steps {
+		if _, haveIt := indexes[step.Rule]; !haveIt {
+			indexes[step.Rule] = len(names)
+			names = append(names, step.Rule)
+		}
+	}
+
+	fmt.Fprintf(&str, "%s\ngrammar %s\ninput %s\nstart %s\nerror %s\nrules %s\n", replayHeader, l.GrammarHash, l.InputHash,
+		l.Start, strconv.Quote(l.Error), strings.Join(names, " "))
+	for _, step := range l.Steps {
+		fmt.Fprintf(&str, "%d %d %d %d\n", indexes[step.Rule], step.Offset, step.Alternative, step.Matches)
+	}
+
+	return str.String()
+}
+
+// OfReplayLog reads the text of a replay log written by ReplayLog.String.
+// Returns an error with the line number of the first line that is malformed.
+func OfReplayLog(text string) (ReplayLog, error) {
+	var (
+		log     ReplayLog
+		names   []string
+		scanner = bufio.NewScanner(strings.NewReader(text))
+		line    int
+	)
+	malformed := func() (ReplayLog, error) {
+		return ReplayLog{}, fmt.Errorf("%s: line %d", ErrReplayLog, line)
+	}
+
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		key := strings.SplitN(text, " ", 2)
+		if (line > 1) && (line <= len(replayKeys)+1) && ((len(key) != 2) || (key[0] != replayKeys[line-2])) {
+			return malformed()
+		}
+
+		switch line {
+		case 1:
+			if text != replayHeader {
+				return malformed()
+			}
+		case 2:
+			log.GrammarHash = key[1]
+		case 3:
+			log.InputHash = key[1]
+		case 4:
+			log.Start = key[1]
+		case 5:
+			msg, err := strconv.Unquote(key[1])
+			if err != nil {
+				return malformed()
+			}
+			log.Error = msg
+		case 6:
+			names = strings.Fields(key[1])
+		default:
+			fields := strings.Fields(text)
+			if len(fields) != 4 {
+				return malformed()
+			}
+
+			var numbers [4]int
+			for i, field := range fields {
+				n, err := strconv.Atoi(field)
+				if (err != nil) || (n < 0) {
+					return malformed()
+				}
+				numbers[i] = n
+			}
+			if numbers[0] >= len(names) {
+				return malformed()
+			}
+
+			log.Steps = append(log.Steps, ReplayStep{names[numbers[0]], numbers[1], numbers[2], numbers[3]})
+		}
+	}
+	if line <= len(replayKeys) {
+		line++
+		return malformed()
+	}
+
+	return log, nil
+}
+
+// ReplayRecorder keeps the replay log of the last parse of the engines it is given to by ParseOptions.Replay.
+// It is safe to share between engines parsing concurrently.
+type ReplayRecorder struct {
+	mutex  sync.Mutex
+	last   ReplayLog
+	haveIt bool
+}
+
+// OfReplayRecorder constructs a ReplayRecorder that has no log
+func OfReplayRecorder() *ReplayRecorder {
+	return &ReplayRecorder{}
+}
+
+// Last returns the replay log of the parse that finished last and true, or false if no parse has finished
+func (r *ReplayRecorder) Last() (ReplayLog, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.last, r.haveIt
+}
+
+// set sets the last replay log
+func (r *ReplayRecorder) set(log ReplayLog) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.last, r.haveIt = log, true
+}
+
+// replayRecord collects the decisions of one parse
+type replayRecord struct {
+	steps []ReplayStep
+}
+
+// record adds a decision to the replay log of the parse, if it is being recorded
+func (b *backtracker) record(rule string, offset, alternative, matches int) {
+	if b.replay != nil {
+		b.replay.steps = append(b.replay.steps, ReplayStep{rule, offset, alternative, matches})
+	}
+}
+
+// parseReplay parses decoded input as parseRunes does, and returns the replay log of the parse.
+// The input is matched as a whole, not in parallel regions, so that the decisions are in a reproducible order.
+func (e *Engine) parseReplay(ctx *ParseContext, input []rune) (ReplayLog, Node, error) {
+	record := &replayRecord{}
+	node, err := e.parseRunes(ctx.withReplay(record), input)
+
+	log := ReplayLog{
+		GrammarHash: hashReplay(e.grammar.Format()),
+		InputHash:   hashReplay(string(input)),
+		Start:       e.options.Start,
+		Steps:       record.steps,
+	}
+	if err != nil {
+		log.Error = err.Error()
+	}
+
+	return log, node, err
+}
+
+// hashReplay returns the hex SHA-256 of a string
+func hashReplay(str string) string {
+	sum := sha256.Sum256([]byte(str))
+	return hex.EncodeToString(sum[:])
+}
+
+// Replayer steps through a re-execution of a replay log
+type Replayer struct {
+	recorded ReplayLog
+	replayed ReplayLog
+	node     Node
+	err      error
+	next     int
+}
+
+// Replay reads all of the source, which must be the input the log was recorded with, and parses it again while recording
+// its decisions, which the returned Replayer compares with those of the log one step at a time, so that a bug report can be
+// reproduced up to the first decision that differs.
+// The engine must have the same grammar and start rule as the engine that recorded the log. Its other options can differ,
+// such as to add predicates that log what is being matched, although options such as PEG and Memo change the decisions.
+// Returns an error if the engine does not use the backtracking backend, the grammar, input, or start rule differ from those
+// of the log, or the source cannot be read.
+func (e *Engine) Replay(log ReplayLog, source io.Reader) (*Replayer, error) {
+	if e.options.Backend != BackendBacktrack {
+		return nil, fmt.Errorf("%s", ErrReplayBackend)
+	}
+	if hashReplay(e.grammar.Format()) != log.GrammarHash {
+		return nil, fmt.Errorf("%s", ErrReplayGrammar)
+	}
+	if e.options.Start != log.Start {
+		return nil, fmt.Errorf("%s: %s", ErrReplayStart, log.Start)
+	}
+
+	data, err := readInput(source, e.options.MaxInputSize)
+	if err != nil {
+		return nil, err
+	}
+	if data, err = e.options.Encoding.decode(data); err != nil {
+		return nil, err
+	}
+	input := []rune(string(data))
+	if hashReplay(string(input)) != log.InputHash {
+		return nil, fmt.Errorf("%s", ErrReplayInput)
+	}
+
+	replayed, node, err := e.parseReplay(OfParseContext(), input)
+	return &Replayer{recorded: log, replayed: replayed, node: node, err: err}, nil
+}
+
+// Next returns the next step of the replay, which is the same as the step of the log.
+// Returns io.EOF after the last step, or an error naming the step and how it differs once the replay diverges from the log,
+// including when one of them has more steps, or the parse returns a different error at the end.
+func (r *Replayer) Next() (ReplayStep, error) {
+	var (
+		i        = r.next
+		recorded = r.recorded.Steps
+		replayed = r.replayed.Steps
+	)
+
+	switch {
+	case (i >= len(recorded)) && (i >= len(replayed)):
+		if r.recorded.Error != r.replayed.Error {
+			return ReplayStep{}, fmt.Errorf("%s: the log has error %q, the replay has error %q", ErrReplayDiverged,
+				r.recorded.Error, r.replayed.Error)
+		}
+		return ReplayStep{}, io.EOF
+	case i >= len(recorded):
+		return ReplayStep{}, fmt.Errorf("%s: step %d: the log has no more steps, the replay has %s", ErrReplayDiverged, i+1,
+			replayed[i])
+	case i >= len(replayed):
+		return ReplayStep{}, fmt.Errorf("%s: step %d: the log has %s, the replay has no more steps", ErrReplayDiverged, i+1,
+			recorded[i])
+	case recorded[i] != replayed[i]:
+		return ReplayStep{}, fmt.Errorf("%s: step %d: the log has %s, the replay has %s", ErrReplayDiverged, i+1, recorded[i],
+			replayed[i])
+	}
+
+	r.next++
+	return replayed[i], nil
+}
+
+// Log returns the replay log of the re-execution
+func (r *Replayer) Log() ReplayLog {
+	return r.replayed
+}
+
+// Result returns the tree and error of the re-execution, the same as Parse
+func (r *Replayer) Result() (Node, error) {
+	return r.node, r.err
+}
@@ -0,0 +1,137 @@
+package parser
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplay(t *testing.T) {
+	var (
+		g        = testGrammar("list = item ','", "item = 'a' | 'b'")
+		recorder = OfReplayRecorder()
+	)
+	_, haveIt := recorder.Last()
+	assert.False(t, haveIt)
+
+	engine, err := NewEngine(g, ParseOptions{Replay: recorder})
+	assert.Nil(t, err)
+
+	_, err = engine.ParseString("b,")
+	assert.Nil(t, err)
+
+	log, haveIt := recorder.Last()
+	assert.True(t, haveIt)
+	assert.Equal(t, hashReplay(g.Format()), log.GrammarHash)
+	assert.Equal(t, hashReplay("b,"), log.InputHash)
+	assert.Equal(t, "list", log.Start)
+	assert.Equal(t, "", log.Error)
+	assert.Equal(t, []ReplayStep{{"item", 0, 1, 0}, {"item", 0, 2, 1}, {"list", 0, 1, 1}}, log.Steps)
+	assert.Equal(t, "item at 0 alternative 2 matched 1", log.Steps[1].String())
+	assert.Equal(t, "item at 0 memoized matched 1", ReplayStep{"item", 0, 0, 1}.String())
+
+	// The text of a log is read back
+	text := log.String()
+	assert.Equal(t, "goparse-replay 1\ngrammar "+log.GrammarHash+"\ninput "+log.InputHash+"\nstart list\nerror \"\"\nrules item list\n0 0 1 0\n0 0 2 1\n1 0 1 1\n", text)
+	read, err := OfReplayLog(text)
+	assert.Nil(t, err)
+	assert.Equal(t, log, read)
+
+	// A replay steps through the same decisions
+	replayer, err := engine.Replay(read, strings.NewReader("b,"))
+	assert.Nil(t, err)
+	for _, step := range log.Steps {
+		next, err := replayer.Next()
+		assert.Nil(t, err)
+		assert.Equal(t, step, next)
+	}
+	_, err = replayer.Next()
+	assert.Equal(t, io.EOF, err)
+	tree, err := replayer.Result()
+	assert.Nil(t, err)
+	assert.Equal(t, `(list (item "b") ",")`, tree.SExpr(false))
+	assert.Equal(t, log, replayer.Log())
+
+	// A failed parse is recorded with its error
+	_, err = engine.ParseString("c,")
+	assert.NotNil(t, err)
+	log, _ = recorder.Last()
+	assert.Equal(t, err.Error(), log.Error)
+	assert.Equal(t, []ReplayStep{{"item", 0, 1, 0}, {"item", 0, 2, 0}, {"list", 0, 1, 0}}, log.Steps)
+
+	// A replay diverges where a decision differs
+	changed := log
+	changed.Steps = append([]ReplayStep{}, log.Steps...)
+	changed.Steps[1].Matches = 1
+	replayer, err = engine.Replay(changed, strings.NewReader("c,"))
+	assert.Nil(t, err)
+	_, err = replayer.Next()
+	assert.Nil(t, err)
+	_, err = replayer.Next()
+	assert.Equal(t, ErrReplayDiverged+": step 2: the log has item at 0 alternative 2 matched 1, the replay has item at 0 alternative 2 matched 0", err.Error())
+
+	changed.Steps = log.Steps[:2]
+	replayer, _ = engine.Replay(changed, strings.NewReader("c,"))
+	replayer.Next()
+	replayer.Next()
+	_, err = replayer.Next()
+	assert.Equal(t, ErrReplayDiverged+": step 3: the log has no more steps, the replay has list at 0 alternative 1 matched 0", err.Error())
+
+	changed.Steps = log.Steps
+	changed.Error = ""
+	replayer, _ = engine.Replay(changed, strings.NewReader("c,"))
+	for i := 0; i < 3; i++ {
+		replayer.Next()
+	}
+	_, err = replayer.Next()
+	assert.Contains(t, err.Error(), ErrReplayDiverged+`: the log has error "", the replay has error `)
+
+	// The grammar, input, and start rule must be the same
+	_, err = engine.Replay(log, strings.NewReader("b,"))
+	assert.Equal(t, ErrReplayInput, err.Error())
+
+	other, _ := NewEngine(testGrammar("list = item ','", "item = 'a' | 'c'"), ParseOptions{})
+	_, err = other.Replay(log, strings.NewReader("c,"))
+	assert.Equal(t, ErrReplayGrammar, err.Error())
+
+	other, _ = NewEngine(g, ParseOptions{Start: "item"})
+	_, err = other.Replay(log, strings.NewReader("c,"))
+	assert.Equal(t, ErrReplayStart+": list", err.Error())
+
+	// Memoized matches are a step without an alternative
+	engine, _ = NewEngine(testGrammar("list = item 'x' | item 'y'", "item = 'a'"), ParseOptions{Memo: true, Replay: recorder})
+	_, err = engine.ParseString("ay")
+	assert.Nil(t, err)
+	log, _ = recorder.Last()
+	assert.Equal(t, []ReplayStep{{"item", 0, 1, 1}, {"list", 0, 1, 0}, {"item", 0, 0, 1}, {"list", 0, 2, 1}}, log.Steps)
+
+	// Only the backtracking backend records decisions
+	_, err = NewEngine(g, ParseOptions{Backend: BackendEarley, Replay: recorder})
+	assert.Equal(t, ErrReplayBackend, err.Error())
+	other, _ = NewEngine(g, ParseOptions{Backend: BackendEarley})
+	_, err = other.Replay(log, strings.NewReader("b,"))
+	assert.Equal(t, ErrReplayBackend, err.Error())
+}
+
+func TestOfReplayLog(t *testing.T) {
+	log, err := OfReplayLog("goparse-replay 1\ngrammar g\ninput i\nstart s\nerror \"\"\nrules \n")
+	assert.Nil(t, err)
+	assert.Equal(t, ReplayLog{GrammarHash: "g", InputHash: "i", Start: "s"}, log)
+
+	for text, line := range map[string]int{
+		"":                          1,
+		"goparse-replay 2\n":        1,
+		"goparse-replay 1\n":        2,
+		"goparse-replay 1\ninput i": 2,
+		"goparse-replay 1\ngrammar g\ninput i\nstart s\nerror x\nrules a\n":              5,
+		"goparse-replay 1\ngrammar g\ninput i\nstart s\nerror \"\"\nrules a\n0 0 1\n":    7,
+		"goparse-replay 1\ngrammar g\ninput i\nstart s\nerror \"\"\nrules a\n1 0 1 0":    7,
+		"goparse-replay 1\ngrammar g\ninput i\nstart s\nerror \"\"\nrules a\n0 -1 1 0":   7,
+		"goparse-replay 1\ngrammar g\ninput i\nstart s\nerror \"\"\nrules a\n0 0 1 0\nx": 8,
+	} {
+		_, err := OfReplayLog(text)
+		assert.Equal(t, ErrReplayLog+": line "+string(rune('0'+line)), err.Error(), text)
+	}
+}
@@ -0,0 +1,59 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Reproducibility error message constants
+const (
+	ErrNotReproducible = "Compiling the same source produced different bytes of an artifact"
+)
+
+// Artifact serializes a compiled grammar, such as its formatted source or generated code
+type Artifact func(g Grammar) ([]byte, error)
+
+// namedArtifact is an artifact that CheckReproducible compares, with a name for errors
+type namedArtifact struct {
+	name     string
+	artifact Artifact
+}
+
+// artifacts are the artifacts that CheckReproducible compares
+var artifacts = []namedArtifact{
+	{"Format", func(g Grammar) ([]byte, error) { return []byte(g.Format()), nil }},
+	{"GoString", func(g Grammar) ([]byte, error) { return []byte(g.GoString()), nil }},
+	{"ToDOT", func(g Grammar) ([]byte, error) { return []byte(g.ToDOT()), nil }},
+}
+
+// CheckReproducible compiles the source count times, and returns an error naming the first artifact that has different
+// bytes for any compile, so that a build system can check that compiled artifacts can be cached by their content.
+// The artifacts are Format, GoString, ToDOT, and unless built with tinygo, GenerateGo with typed nodes and visitors and
+// MarshalJSON. As maps are iterated in a random order, compiling more times is more likely to find an artifact that depends
+// on the order.
+// Returns an error if the source does not compile, or an artifact cannot be produced.
+func CheckReproducible(source []byte, options CompileOptions, count int) error {
+	var first [][]byte
+
+	for i := 0; i < count; i++ {
+		g, err := Compile(bytes.NewReader(source), options)
+		if err != nil {
+			return err
+		}
+
+		for j, artifact := range artifacts {
+			data, err := artifact.artifact(g)
+			if err != nil {
+				return err
+			}
+
+			if i == 0 {
+				first = append(first, data)
+			} else if !bytes.Equal(data, first[j]) {
+				return fmt.Errorf("%s: %s", ErrNotReproducible, artifact.name)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,11 @@
+//go:build !tinygo
+// +build !tinygo
+
+package parser
+
+// Generated code is an artifact except with tinygo, which leaves out code generation
+func init() {
+	artifacts = append(artifacts, namedArtifact{"GenerateGo", func(g Grammar) ([]byte, error) {
+		return g.GenerateGo(GoOptions{Package: "parser", AST: true, Visitor: true})
+	}})
+}
@@ -0,0 +1,9 @@
+//go:build !tinygo
+// +build !tinygo
+
+package parser
+
+// The JSON of a grammar is an artifact except with tinygo, which does not support encoding/json
+func init() {
+	artifacts = append(artifacts, namedArtifact{"MarshalJSON", Grammar.MarshalJSON})
+}
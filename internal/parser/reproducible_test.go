@@ -0,0 +1,33 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckReproducible(t *testing.T) {
+	for dialect, source := range map[Dialect]string{
+		DialectGoparse: `a = [a-z]+ | b c; b = 'y' | ('z' a)?; c = 'w':EOL;`,
+		DialectISO:     `a = "x", { b | c } ; b = 'y' | "z", [ a ] ; c = 'w' ;`,
+		DialectW3C:     `a ::= [a-z]+ (b | c)? b ::= [#x41-#x5A] - [#x45] c ::= 'x' - 'y'`,
+		DialectABNF:    "a = 1*DIGIT / ALPHA *b\nb = %x41-5A / \"q\"",
+		DialectANTLR:   `grammar G; a : 'x' (b | c)* ; b : [a-zA-Z_]+ ; c : 'y'? 'z' ;`,
+	} {
+		assert.Nil(t, CheckReproducible([]byte(source), CompileOptions{Dialect: dialect}, 20), source)
+	}
+
+	assert.Equal(t, ErrCompileDialect+": 99", CheckReproducible(nil, CompileOptions{Dialect: 99}, 1).Error())
+
+	// An artifact that depends on map order is found
+	saved := artifacts
+	defer func() { artifacts = saved }()
+	artifacts = []namedArtifact{{"order", func(g Grammar) ([]byte, error) {
+		var result []byte
+		for name := range rulesByName(g.rules) {
+			result = append(result, name...)
+		}
+		return result, nil
+	}}}
+	assert.Equal(t, ErrNotReproducible+": order", CheckReproducible([]byte("a : b c d e f ; b : 'x' ; c : 'x' ; d : 'x' ; e : 'x' ; f : 'x' ;"), CompileOptions{Dialect: DialectANTLR}, 100).Error())
+}
@@ -0,0 +1,51 @@
+package parser
+
+import (
+	"strings"
+)
+
+// DefaultReservedWords returns the words that rule names cannot be by default, as they collide with other names:
+//
+//	STRINGS and NODES, the section keywords of goparse grammar source
+//	The names of options without the colon, such as MEMO and ERROR, where INDENT, OUTDENT, and EOL are also the rules of the
+//	tokens of indentation and newline options
+//
+// Go keywords are not reserved by default, as generated code renames rules whose names are not Go identifiers.
+// A grammar that should not use them can reserve GoKeywords as well.
+func DefaultReservedWords() []string {
+	words := []string{"STRINGS", "NODES"}
+	for _, option := range optionStrings {
+		words = append(words, strings.TrimPrefix(option, ":"))
+	}
+
+	return append(words, strings.TrimPrefix(errorOption, ":"))
+}
+
+// GoKeywords returns the keywords of Go, which can be reserved along with DefaultReservedWords, such as
+// CompileOptions{Reserved: append(DefaultReservedWords(), GoKeywords()...)}
+func GoKeywords() []string {
+	return []string{
+		"break", "case", "chan", "const", "continue", "default", "defer", "else", "fallthrough", "for", "func", "go",
+		"goto", "if", "import", "interface", "map", "package", "range", "return", "select", "struct", "switch", "type",
+		"var",
+	}
+}
+
+// ReservedNames returns a diagnostic for each rule whose name is one of the reserved words, at the position of the rule
+func (g Grammar) ReservedNames(reserved []string) []Diagnostic {
+	var (
+		words  = map[string]bool{}
+		result []Diagnostic
+	)
+	for _, word := range reserved {
+		words[word] = true
+	}
+
+	for _, rule := range g.rules {
+		if words[rule.name] {
+			result = append(result, newDiagnostic(DiagReservedName, rule.name, rule.SourceNode))
+		}
+	}
+
+	return result
+}
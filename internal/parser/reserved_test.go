@@ -0,0 +1,60 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultReservedWords(t *testing.T) {
+	words := DefaultReservedWords()
+	for _, word := range []string{"STRINGS", "NODES", "MEMO", "NOMEMO", "INDENT", "OUTDENT", "EOL", "INDEPENDENT", "ERROR"} {
+		assert.Contains(t, words, word)
+	}
+	assert.NotContains(t, words, ":MEMO")
+	assert.NotContains(t, words, "type")
+	assert.Equal(t, 3+len(optionStrings), len(words))
+
+	keywords := GoKeywords()
+	for _, word := range []string{"func", "type", "var"} {
+		assert.Contains(t, keywords, word)
+	}
+	assert.Equal(t, 25, len(keywords))
+}
+
+func TestReservedNames(t *testing.T) {
+	g := testGrammar("a = type MEMO", "type = 'x'", "MEMO = 'y'")
+	g.rules[1].SourceNode = OfSourceNodeAt("", 2, 1)
+	g.rules[2].SourceNode = OfSourceNodeAt("", 3, 1)
+
+	// Go keywords are only reserved if asked for
+	diags := g.ReservedNames(DefaultReservedWords())
+	assert.Equal(t, 1, len(diags))
+	assert.Equal(t, DiagReservedName, diags[0].Code())
+	assert.Equal(t, "Reserved rule name MEMO at line 3 position 1", diags[0].String())
+	assert.Equal(t, diags, g.Validate())
+
+	diags = g.ReservedNames(append(DefaultReservedWords(), GoKeywords()...))
+	assert.Equal(t, 2, len(diags))
+	assert.Equal(t, "Reserved rule name type at line 2 position 1", diags[0].String())
+	assert.Equal(t, "Reserved rule name MEMO at line 3 position 1", diags[1].String())
+
+	diags = g.ReservedNames([]string{"a"})
+	assert.Equal(t, 1, len(diags))
+	assert.Equal(t, "Reserved rule name a", diags[0].Message())
+	assert.Nil(t, g.ReservedNames(nil))
+
+	// Compile validates against the reserved words option
+	source := "a : type ;\ntype : 'x' ;"
+	_, err := CompileString(source, CompileOptions{Dialect: DialectANTLR, Validate: true})
+	assert.Nil(t, err)
+
+	_, err = CompileString(source, CompileOptions{Dialect: DialectANTLR, Validate: true, Reserved: GoKeywords()})
+	assert.Equal(t, "Reserved rule name type at line 2 position 1", err.Error())
+
+	_, err = CompileString(source, CompileOptions{Dialect: DialectANTLR, Validate: true, Reserved: []string{}})
+	assert.Nil(t, err)
+
+	_, err = CompileString(source, CompileOptions{Dialect: DialectANTLR, Validate: true, Reserved: []string{"a"}})
+	assert.Equal(t, "Reserved rule name a at line 1 position 1", err.Error())
+}
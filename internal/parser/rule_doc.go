@@ -0,0 +1,109 @@
+package parser
+
+import (
+	"strings"
+)
+
+// Prefix of a doc comment line that is an example input the rule matches
+const docTestPrefix = "@test "
+
+// RuleDoc is the documentation of a rule, for generating reference documentation and editor hover text
+type RuleDoc struct {
+	// Name is the rule name
+	Name string
+	// Doc is the doc comment without example lines, and with leading and trailing blank lines removed
+	Doc string
+	// Definition is the source of the rule
+	Definition string
+	// References are the names of the rules the rule refers to, in order of first reference
+	References []string
+	// Examples are the inputs of the @test lines of the doc comment, in order
+	Examples []string
+	// DisplayName is the name of the rule for end users, from the @display line of the doc comment
+	DisplayName string
+}
+
+// RuleDocs returns the documentation of each rule, in grammar order.
+// A doc comment line that begins with @test and a space is an example input the rule matches, such as
+//
+//	@test 1 + 2
+//
+// A doc comment line that begins with @display and a space is the display name of the rule, as described by DisplayName.
+func (g Grammar) RuleDocs() []RuleDoc {
+	result := make([]RuleDoc, len(g.rules))
+
+	for i, rule := range g.rules {
+		result[i] = rule.RuleDoc()
+	}
+
+	return result
+}
+
+// RuleDoc returns the documentation of the rule
+func (r Rule) RuleDoc() RuleDoc {
+	var (
+		doc        []string
+		examples   []string
+		references []string
+		referenced = map[string]bool{}
+	)
+
+	for _, line := range strings.Split(r.doc, "\n") {
+		switch trimmed := strings.TrimSpace(line); {
+		case strings.HasPrefix(trimmed, docTestPrefix):
+			examples = append(examples, trimmed[len(docTestPrefix):])
+		case strings.HasPrefix(trimmed, docDisplayPrefix), strings.HasPrefix(trimmed, docErrorPrefix+" "), strings.HasPrefix(trimmed, docErrorPrefix+":"):
+		default:
+			doc = append(doc, line)
+		}
+	}
+
+	for _, alt := range r.expr.items {
+		for _, item := range alt.list {
+			if item.IsRuleName() && !referenced[item.ruleName] {
+				referenced[item.ruleName] = true
+				references = append(references, item.ruleName)
+			}
+		}
+	}
+
+	return RuleDoc{
+		Name:        r.name,
+		Doc:         strings.Trim(strings.Join(doc, "\n"), "\n"),
+		Definition:  r.String(),
+		References:  references,
+		Examples:    examples,
+		DisplayName: r.DisplayName(),
+	}
+}
+
+// docLine returns the rest of the first doc comment line that begins with a prefix, without surrounding whitespace,
+// and true, or false if there is no such line
+func (r Rule) docLine(prefix string) (string, bool) {
+	for _, line := range strings.Split(r.doc, "\n") {
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, prefix) {
+			return strings.TrimSpace(trimmed[len(prefix):]), true
+		}
+	}
+
+	return "", false
+}
+
+// withDocLine returns a copy of the rule where a doc comment line of a prefix and value replaces the lines that begin
+// with the prefix, or is added to the end of the doc comment. An empty value removes the lines.
+func (r Rule) withDocLine(prefix, value string) Rule {
+	var lines []string
+	if r.doc != "" {
+		for _, line := range strings.Split(r.doc, "\n") {
+			if !strings.HasPrefix(strings.TrimSpace(line), prefix) {
+				lines = append(lines, line)
+			}
+		}
+	}
+	if value != "" {
+		lines = append(lines, prefix+value)
+	}
+
+	r.doc = strings.Join(lines, "\n")
+	return r
+}
@@ -0,0 +1,36 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuleDocs(t *testing.T) {
+	g := testGrammar(
+		"sum = num | num '+' sum | sum2",
+		"num = [123]",
+		"sum2 = ('-' num)*",
+	)
+	g = OfGrammar(g.String(), []Rule{
+		g.rules[0].WithDoc("\nA sum of numbers\n  @test 1+2\n\n@test 3\nRight associative\n@display a sum\n"),
+		g.rules[1],
+		g.rules[2],
+	})
+
+	docs := g.RuleDocs()
+	assert.Equal(
+		t,
+		RuleDoc{
+			Name:        "sum",
+			Doc:         "A sum of numbers\n\nRight associative",
+			Definition:  "sum = num | num '+' sum | sum2",
+			References:  []string{"num", "sum", "sum2"},
+			Examples:    []string{"1+2", "3"},
+			DisplayName: "a sum",
+		},
+		docs[0],
+	)
+	assert.Equal(t, RuleDoc{Name: "num", Definition: "num = [123]"}, docs[1])
+	assert.Equal(t, []string{"num"}, docs[2].References)
+}
@@ -0,0 +1,103 @@
+package parser
+
+import (
+	"fmt"
+)
+
+// Rule limit error message constants
+const (
+	ErrRuleMaxLength      = "A rule matched more than its maximum length"
+	ErrRuleMaxRepetitions = "A rule repeated an alternative more than its maximum repetitions"
+	ErrLimitsBackend      = "Rule, depth, step, and repetition limits are only supported by the backtracking backend"
+)
+
+// RuleLimits are the resource limits of a rule, so that untrusted input cannot make one rule consume all of it.
+// A limit of 0 is no limit.
+type RuleLimits struct {
+	// MaxLength is the maximum number of runes a match of the rule can contain, including any trivia
+	MaxLength int `json:"maxLength,omitempty"`
+	// MaxRepetitions is the maximum number of times an alternative of the rule can repeat, even if it has no upper bound
+	MaxRepetitions int `json:"maxRepetitions,omitempty"`
+}
+
+// LimitError is the error returned when a match of a rule exceeds one of its limits, or the maximum depth or steps of a parse
+type LimitError struct {
+	// Err is ErrRuleMaxLength, ErrRuleMaxRepetitions, ErrMaxDepth, ErrMaxSteps, or ErrMaxRepetitions
+	Err string
+	// Rule is the name of the rule
+	Rule string
+	// Max is the limit that was exceeded
+	Max int
+	// Line and Position are of the rune that exceeded the limit
+	Line     int
+	Position int
+}
+
+// Error is the error interface
+func (e LimitError) Error() string {
+	return fmt.Sprintf("%s: %s (%d) at line %d position %d", e.Err, e.Rule, e.Max, e.Line, e.Position)
+}
+
+// WithLimits returns a copy of the rule with resource limits, which ParseOptions.Limits can override
+func (r Rule) WithLimits(limits RuleLimits) Rule {
+	r.limits = limits
+	return r
+}
+
+// Limits returns the resource limits of the rule
+func (r Rule) Limits() RuleLimits {
+	return r.limits
+}
+
+// activeLimit is a rule with a maximum length that the backtracker is matching
+type activeLimit struct {
+	rule  string
+	start int
+	max   int
+}
+
+// ruleLimits returns the limits of each rule that has any, where options override the limits of the rules
+func ruleLimits(rules []Rule, options map[string]RuleLimits) map[string]RuleLimits {
+	result := map[string]RuleLimits{}
+	for _, rule := range rules {
+		if rule.limits != (RuleLimits{}) {
+			result[rule.name] = rule.limits
+		}
+	}
+
+	for name, limits := range options {
+		if limits == (RuleLimits{}) {
+			delete(result, name)
+		} else {
+			result[name] = limits
+		}
+	}
+
+	return result
+}
+
+// exceeded aborts the parse with a LimitError, which recoverLimit recovers
+func (b *backtracker) exceeded(err, rule string, max, offset int) {
+	line, position := b.engine.options.Positions.linePosition(b.whole, b.regionStart+offset)
+	panic(LimitError{Err: err, Rule: rule, Max: max, Line: line, Position: position})
+}
+
+// checkLength aborts the parse if a match ending at end is too long for any rule being matched
+func (b *backtracker) checkLength(end int) {
+	for _, limit := range b.lengths {
+		if end-limit.start > limit.max {
+			b.exceeded(ErrRuleMaxLength, limit.rule, limit.max, limit.start+limit.max)
+		}
+	}
+}
+
+// recoverLimit recovers a LimitError into err, and repanics anything else
+func recoverLimit(err *error) {
+	if r := recover(); r != nil {
+		if e, isa := r.(LimitError); isa {
+			*err = e
+			return
+		}
+		panic(r)
+	}
+}
@@ -0,0 +1,55 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuleLimits(t *testing.T) {
+	var (
+		g = testGrammar(
+			"list = '[' items ']'",
+			"items = (item)*",
+			"item = 'x' | 'yy'",
+		)
+		parse = func(limits map[string]RuleLimits, source string) error {
+			engine, err := NewEngine(g, ParseOptions{Limits: limits})
+			assert.Nil(t, err)
+
+			_, err = engine.Parse(strings.NewReader(source))
+			return err
+		}
+	)
+
+	// Inputs within the limits match
+	assert.Nil(t, parse(map[string]RuleLimits{"items": {MaxLength: 4, MaxRepetitions: 3}}, "[xyyx]"))
+
+	// The parse stops at the rune that makes a match too long
+	err := parse(map[string]RuleLimits{"items": {MaxLength: 4}}, "[xxxxx]")
+	assert.Equal(t, LimitError{Err: ErrRuleMaxLength, Rule: "items", Max: 4, Line: 1, Position: 6}, err)
+	assert.Equal(t, ErrRuleMaxLength+": items (4) at line 1 position 6", err.Error())
+
+	// The parse stops at the repetition that is one too many, despite the repetition having no upper bound
+	err = parse(map[string]RuleLimits{"items": {MaxRepetitions: 3}}, "[xyyxyy]")
+	assert.Equal(t, LimitError{Err: ErrRuleMaxRepetitions, Rule: "items", Max: 3, Line: 1, Position: 6}, err)
+
+	// Limits can be annotations of the rules, which options override
+	rules := g.Rules()
+	rules[1] = rules[1].WithLimits(RuleLimits{MaxRepetitions: 1})
+	g = OfGrammar("", rules)
+	assert.Equal(t, ErrRuleMaxRepetitions, parse(nil, "[xx]").(LimitError).Err)
+	assert.Equal(t, RuleLimits{MaxRepetitions: 1}, g.Rules()[1].Limits())
+	assert.Nil(t, parse(map[string]RuleLimits{"items": {}}, "[xx]"))
+	assert.Equal(t, ErrRuleMaxLength, parse(map[string]RuleLimits{"items": {MaxLength: 1}}, "[xx]").(LimitError).Err)
+
+	// ParseOne also enforces limits
+	engine, err := NewEngine(g, ParseOptions{})
+	assert.Nil(t, err)
+	_, _, err = engine.ParseOne(strings.NewReader("[xx]"))
+	assert.Equal(t, ErrRuleMaxRepetitions, err.(LimitError).Err)
+
+	_, err = NewEngine(g, ParseOptions{Backend: BackendEarley})
+	assert.Equal(t, ErrLimitsBackend, err.Error())
+}
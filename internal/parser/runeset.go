@@ -0,0 +1,300 @@
+package parser
+
+import (
+	"sort"
+	"sync"
+	"unicode"
+)
+
+// RuneInterval is the runes from Lo through Hi inclusive
+type RuneInterval struct {
+	Lo rune
+	Hi rune
+}
+
+// casedRunes are the runes that simple case folding changes, which are found the first time SimpleFold is called, as
+// some of them are not in unicode.CaseRanges, such as \u0390, which folds to \u1fd3 but has no upper case of its own
+var (
+	casedRunesOnce sync.Once
+	casedRunes     RuneSet
+)
+
+// RuneSet is a set of runes, stored as sorted intervals that do not overlap or touch, so that a large range such as
+// [^a] takes no more memory than a small one. The zero value is the empty set, and a RuneSet is never modified, so it can
+// be shared.
+type RuneSet struct {
+	intervals []RuneInterval
+}
+
+// OfRuneSet constructs a RuneSet of the runes of intervals, which can be in any order and overlap, where an interval whose
+// Hi is less than its Lo is empty
+func OfRuneSet(intervals ...RuneInterval) RuneSet {
+	var sorted []RuneInterval
+	for _, interval := range intervals {
+		if interval.Lo <= interval.Hi {
+			sorted = append(sorted, interval)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Lo < sorted[j].Lo })
+
+	var result []RuneInterval
+	for _, interval := range sorted {
+		// An interval that overlaps or touches the last one extends it
+		if last := len(result) - 1; (last >= 0) && (int64(interval.Lo) <= int64(result[last].Hi)+1) {
+			if interval.Hi > result[last].Hi {
+				result[last].Hi = interval.Hi
+			}
+			continue
+		}
+
+		result = append(result, interval)
+	}
+
+	return RuneSet{intervals: result}
+}
+
+// OfRuneSetRunes constructs a RuneSet of runes
+func OfRuneSetRunes(runes ...rune) RuneSet {
+	intervals := make([]RuneInterval, len(runes))
+	for i, char := range runes {
+		intervals[i] = RuneInterval{char, char}
+	}
+
+	return OfRuneSet(intervals...)
+}
+
+// OfRuneSetMap constructs a RuneSet of the runes that map to true
+func OfRuneSetMap(set map[rune]bool) RuneSet {
+	var runes []rune
+	for char, ok := range set {
+		if ok {
+			runes = append(runes, char)
+		}
+	}
+
+	return OfRuneSetRunes(runes...)
+}
+
+// OfRuneSetRangeTable constructs a RuneSet of the runes of unicode range tables, such as unicode.Letter, so that a grammar
+// constructed in Go can match the runes of a Unicode category, script, or property
+func OfRuneSetRangeTable(tables ...*unicode.RangeTable) RuneSet {
+	var intervals []RuneInterval
+	for _, table := range tables {
+		for _, r := range table.R16 {
+			intervals = appendStrided(intervals, rune(r.Lo), rune(r.Hi), rune(r.Stride))
+		}
+		for _, r := range table.R32 {
+			intervals = appendStrided(intervals, rune(r.Lo), rune(r.Hi), rune(r.Stride))
+		}
+	}
+
+	return OfRuneSet(intervals...)
+}
+
+// appendStrided appends the runes from lo through hi that are a multiple of stride after lo, as one interval if stride is 1
+func appendStrided(intervals []RuneInterval, lo, hi, stride rune) []RuneInterval {
+	if stride <= 1 {
+		return append(intervals, RuneInterval{lo, hi})
+	}
+
+	for char := lo; char <= hi; char += stride {
+		intervals = append(intervals, RuneInterval{char, char})
+	}
+
+	return intervals
+}
+
+// Contains returns true if the set contains the rune
+func (s RuneSet) Contains(char rune) bool {
+	i := sort.Search(len(s.intervals), func(i int) bool { return s.intervals[i].Hi >= char })
+	return (i < len(s.intervals)) && (s.intervals[i].Lo <= char)
+}
+
+// IsEmpty returns true if the set has no runes
+func (s RuneSet) IsEmpty() bool {
+	return len(s.intervals) == 0
+}
+
+// Len returns the number of runes in the set
+func (s RuneSet) Len() int {
+	count := 0
+	for _, interval := range s.intervals {
+		count += int(interval.Hi-interval.Lo) + 1
+	}
+
+	return count
+}
+
+// Intervals returns the intervals of the set in order, which do not overlap or touch
+func (s RuneSet) Intervals() []RuneInterval {
+	return append([]RuneInterval(nil), s.intervals...)
+}
+
+// Runes returns every rune of the set in order, which can be a great many for a large set
+func (s RuneSet) Runes() []rune {
+	result := make([]rune, 0, s.Len())
+	for _, interval := range s.intervals {
+		for char := interval.Lo; char <= interval.Hi; char++ {
+			result = append(result, char)
+		}
+	}
+
+	return result
+}
+
+// nth returns the rune at index i of the runes of the set in order, or -1 if i is not less than Len
+func (s RuneSet) nth(i int) rune {
+	for _, interval := range s.intervals {
+		size := int(interval.Hi-interval.Lo) + 1
+		if i < size {
+			return interval.Lo + rune(i)
+		}
+		i -= size
+	}
+
+	return -1
+}
+
+// Union returns the runes that are in either set
+func (s RuneSet) Union(other RuneSet) RuneSet {
+	return OfRuneSet(append(append([]RuneInterval(nil), s.intervals...), other.intervals...)...)
+}
+
+// Intersect returns the runes that are in both sets
+func (s RuneSet) Intersect(other RuneSet) RuneSet {
+	var result []RuneInterval
+	for i, j := 0, 0; (i < len(s.intervals)) && (j < len(other.intervals)); {
+		a, b := s.intervals[i], other.intervals[j]
+		if lo, hi := maxRune(a.Lo, b.Lo), minRune(a.Hi, b.Hi); lo <= hi {
+			result = append(result, RuneInterval{lo, hi})
+		}
+
+		// The interval that ends first cannot overlap any more intervals of the other set
+		if a.Hi < b.Hi {
+			i++
+		} else {
+			j++
+		}
+	}
+
+	return RuneSet{intervals: result}
+}
+
+// Invert returns the runes from 0 through unicode.MaxRune that are not in the set
+func (s RuneSet) Invert() RuneSet {
+	return OfRuneSet(RuneInterval{0, unicode.MaxRune}).Subtract(s)
+}
+
+// Subtract returns the runes of the set that are not in the other set
+func (s RuneSet) Subtract(other RuneSet) RuneSet {
+	var (
+		result []RuneInterval
+		j      int
+	)
+	for _, interval := range s.intervals {
+		for (j < len(other.intervals)) && (other.intervals[j].Hi < interval.Lo) {
+			j++
+		}
+
+		// Each interval of the other set that overlaps the interval removes its runes, leaving the runes before it
+		lo, done := interval.Lo, false
+		for k := j; !done && (k < len(other.intervals)) && (other.intervals[k].Lo <= interval.Hi); k++ {
+			if other.intervals[k].Lo > lo {
+				result = append(result, RuneInterval{lo, other.intervals[k].Lo - 1})
+			}
+
+			if other.intervals[k].Hi >= interval.Hi {
+				done = true
+			} else {
+				lo = other.intervals[k].Hi + 1
+			}
+		}
+
+		if !done {
+			result = append(result, RuneInterval{lo, interval.Hi})
+		}
+	}
+
+	return RuneSet{intervals: result}
+}
+
+// RangeTable returns the runes of the set as a unicode range table, so that the set can be used with unicode.Is and other
+// APIs of the standard library that accept range tables
+func (s RuneSet) RangeTable() *unicode.RangeTable {
+	table := &unicode.RangeTable{}
+	for _, interval := range s.intervals {
+		// An interval is split where runes stop fitting in 16 bits
+		if interval.Lo <= 0xFFFF {
+			table.R16 = append(table.R16, unicode.Range16{Lo: uint16(interval.Lo), Hi: uint16(minRune(interval.Hi, 0xFFFF)), Stride: 1})
+			if interval.Hi <= unicode.MaxLatin1 {
+				table.LatinOffset++
+			}
+		}
+		if interval.Hi > 0xFFFF {
+			table.R32 = append(table.R32, unicode.Range32{Lo: uint32(maxRune(interval.Lo, 0x10000)), Hi: uint32(interval.Hi), Stride: 1})
+		}
+	}
+
+	return table
+}
+
+// SimpleFold returns the runes of the set and the runes that each of them is equivalent to under simple Unicode case
+// folding, such as A and a, or K, k, and the Kelvin sign, so that a range matches letters case insensitively
+func (s RuneSet) SimpleFold() RuneSet {
+	casedRunesOnce.Do(func() {
+		var runes []rune
+		for char := rune(0); char <= unicode.MaxRune; char++ {
+			if unicode.SimpleFold(char) != char {
+				runes = append(runes, char)
+			}
+		}
+		casedRunes = OfRuneSetRunes(runes...)
+	})
+
+	var runes []rune
+	for _, char := range s.Intersect(casedRunes).Runes() {
+		for other := unicode.SimpleFold(char); other != char; other = unicode.SimpleFold(other) {
+			runes = append(runes, other)
+		}
+	}
+
+	return s.Union(OfRuneSetRunes(runes...))
+}
+
+// Equal returns true if the sets have the same runes
+func (s RuneSet) Equal(other RuneSet) bool {
+	if len(s.intervals) != len(other.intervals) {
+		return false
+	}
+
+	for i, interval := range s.intervals {
+		if interval != other.intervals[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// String is the set in the source form of a character range, such as [a-z_]
+func (s RuneSet) String() string {
+	return rangeSource(s)
+}
+
+// minRune returns the lesser of two runes
+func minRune(a, b rune) rune {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+// maxRune returns the greater of two runes
+func maxRune(a, b rune) rune {
+	if a > b {
+		return a
+	}
+
+	return b
+}
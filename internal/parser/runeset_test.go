@@ -0,0 +1,107 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuneSet(t *testing.T) {
+	// Intervals are sorted, and overlapping or touching intervals are merged
+	set := OfRuneSet(RuneInterval{'x', 'z'}, RuneInterval{'a', 'c'}, RuneInterval{'b', 'd'}, RuneInterval{'e', 'e'}, RuneInterval{'q', 'p'})
+	assert.Equal(t, []RuneInterval{{'a', 'e'}, {'x', 'z'}}, set.Intervals())
+	assert.Equal(t, 8, set.Len())
+	assert.Equal(t, []rune("abcdexyz"), set.Runes())
+	assert.Equal(t, "[a-ex-z]", set.String())
+	assert.True(t, set.Contains('a'))
+	assert.True(t, set.Contains('e'))
+	assert.True(t, set.Contains('y'))
+	assert.False(t, set.Contains('f'))
+	assert.False(t, set.Contains('~'))
+	assert.Equal(t, 'x', set.nth(5))
+	assert.Equal(t, rune(-1), set.nth(8))
+
+	assert.True(t, RuneSet{}.IsEmpty())
+	assert.Equal(t, RuneSet{}, OfRuneSetRunes())
+	assert.Equal(t, OfRuneSetRunes('a', 'b', 'c'), OfRuneSetMap(map[rune]bool{'c': true, 'a': true, 'b': true, 'd': false}))
+
+	// Set operations
+	other := OfRuneSetRunes('c', 'd', 'y', '0')
+	assert.Equal(t, "[0a-ex-z]", set.Union(other).String())
+	assert.Equal(t, "[cdy]", set.Intersect(other).String())
+	assert.Equal(t, "[abexz]", set.Subtract(other).String())
+	assert.Equal(t, "[0]", other.Subtract(set).String())
+	assert.True(t, set.Equal(OfRuneSetRunes([]rune("zyxedcba")...)))
+	assert.False(t, set.Equal(other))
+
+	// Inverting covers every rune from 0, without materializing them
+	inverted := set.Invert()
+	assert.Equal(t, []RuneInterval{{0, 'a' - 1}, {'f', 'x' - 1}, {'z' + 1, unicode.MaxRune}}, inverted.Intervals())
+	assert.Equal(t, unicode.MaxRune+1-8, rune(inverted.Len()))
+	assert.Equal(t, set, inverted.Invert())
+	assert.Equal(t, []RuneInterval{{0, unicode.MaxRune}}, RuneSet{}.Invert().Intervals())
+	assert.True(t, OfRuneSet(RuneInterval{0, unicode.MaxRune}).Invert().IsEmpty())
+	assert.Equal(t, []RuneInterval{{'a', 'a'}}, OfRuneSetRunes(-1, 'a').Subtract(OfRuneSetRunes(-1)).Intervals())
+}
+
+func TestRuneSetTerminal(t *testing.T) {
+	// A range of every rune matches with a single interval
+	var (
+		all = OfRuneSet(RuneInterval{'a', unicode.MaxRune})
+		g   = testGrammar("word = ([a])+")
+	)
+	g.rules[0].expr.items[0].list[0].terminal = OfTerminalRuneSet(rangeSource(all), all)
+	assert.Equal(t, 1, len(g.rules[0].expr.items[0].list[0].terminal.RuneSet().Intervals()))
+
+	engine, err := NewEngine(g, ParseOptions{})
+	assert.Nil(t, err)
+
+	tree, err := engine.Parse(strings.NewReader("a\U0010FFFF世"))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(tree.Children()))
+
+	_, err = engine.Parse(strings.NewReader("a0"))
+	assert.NotNil(t, err)
+}
+
+func TestRuneSetRangeTable(t *testing.T) {
+	// Strided ranges are converted to single runes
+	set := OfRuneSetRangeTable(&unicode.RangeTable{
+		R16: []unicode.Range16{{Lo: 'a', Hi: 'c', Stride: 1}, {Lo: 'x', Hi: 0x100, Stride: 0x88}},
+		R32: []unicode.Range32{{Lo: 0x10000, Hi: 0x10002, Stride: 2}},
+	}, unicode.ASCII_Hex_Digit)
+	assert.Equal(t, []RuneInterval{{'0', '9'}, {'A', 'F'}, {'a', 'f'}, {'x', 'x'}, {0x100, 0x100}, {0x10000, 0x10000}, {0x10002, 0x10002}}, set.Intervals())
+
+	// An interval is split between 16 and 32 bit ranges
+	table := OfRuneSet(RuneInterval{'a', 'b'}, RuneInterval{0xFF, 0x10001}, RuneInterval{0x20000, unicode.MaxRune}).RangeTable()
+	assert.Equal(t, &unicode.RangeTable{
+		R16:         []unicode.Range16{{Lo: 'a', Hi: 'b', Stride: 1}, {Lo: 0xFF, Hi: 0xFFFF, Stride: 1}},
+		R32:         []unicode.Range32{{Lo: 0x10000, Hi: 0x10001, Stride: 1}, {Lo: 0x20000, Hi: unicode.MaxRune, Stride: 1}},
+		LatinOffset: 1,
+	}, table)
+	assert.Equal(t, &unicode.RangeTable{}, RuneSet{}.RangeTable())
+
+	// A set converted from a table has the same runes as the table, when converted back
+	letters := OfRuneSetRangeTable(unicode.Letter)
+	table = letters.RangeTable()
+	for char := rune(0); char <= unicode.MaxRune; char++ {
+		if unicode.IsLetter(char) != unicode.Is(table, char) {
+			assert.Fail(t, "rune differs", "%U", char)
+			break
+		}
+	}
+	assert.True(t, letters.Equal(OfRuneSetRangeTable(table)))
+}
+
+func TestRuneSetSimpleFold(t *testing.T) {
+	assert.Equal(t, "[0-9A-Za-z\u017f\u212a]", OfRuneSet(RuneInterval{'0', '9'}, RuneInterval{'a', 'z'}).SimpleFold().String())
+	assert.Equal(t, "[Kk\u212a]", OfRuneSetRunes('\u212a').SimpleFold().String())
+	assert.True(t, RuneSet{}.SimpleFold().IsEmpty())
+
+	// Every rune that folds is found
+	all := OfRuneSet(RuneInterval{0, unicode.MaxRune})
+	assert.True(t, all.Equal(all.SimpleFold()))
+	assert.Equal(t, "[\u0390\u1fd3]", OfRuneSetRunes('\u0390').SimpleFold().String())
+}
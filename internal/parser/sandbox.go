@@ -0,0 +1,124 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+)
+
+// Sandbox error message constants
+const (
+	ErrSandboxImport = "The grammar imports a grammar that is not allowed"
+)
+
+// The limits a Sandbox sets, unless they are already lower
+const (
+	SandboxMaxSourceSize = 1 << 20
+	SandboxMaxRules      = 1000
+	SandboxMaxDepth      = 100
+	SandboxMaxStates     = 100000
+)
+
+// Sandbox isolates Compile from grammar source supplied by the untrusted users of a multi-tenant service:
+// - the source, and that of each grammar it extends, is read up to MaxSourceSize bytes
+// - the budget is lowered to the Sandbox limits
+// - an ANTLR grammar can only import, and any grammar can only extend, the allowed Imports
+// - the grammar must pass the checks of NewEngine that every backend needs
+// CompileEngine also parses with ParseOptions.WithUntrustedInput, so that no user code runs while parsing.
+type Sandbox struct {
+	// ValidateOnly is true if Compile only checks that the grammar is valid, and returns an empty Grammar,
+	// so that a service can check grammars that it does not keep
+	ValidateOnly bool
+	// MaxSourceSize is the maximum number of bytes of source, lowered to SandboxMaxSourceSize
+	MaxSourceSize int
+	// Imports are the names of the grammars that an ANTLR grammar can import, or a grammar can extend
+	Imports []string
+}
+
+// lower lowers a limit to max, where a limit <= 0 is no limit
+func lower(limit *int, max int) {
+	if (*limit <= 0) || (*limit > max) {
+		*limit = max
+	}
+}
+
+// compile is the part of Compile that a sandbox restricts
+func (s Sandbox) compile(source io.Reader, options CompileOptions) (Grammar, error) {
+	lower(&s.MaxSourceSize, SandboxMaxSourceSize)
+	lower(&options.Budget.MaxRules, SandboxMaxRules)
+	lower(&options.Budget.MaxDepth, SandboxMaxDepth)
+	lower(&options.Budget.MaxStates, SandboxMaxStates)
+
+	allowed := map[string]bool{}
+	for _, name := range s.Imports {
+		allowed[name] = true
+	}
+
+	g, imports, err := options.importExtended(source, s.MaxSourceSize, allowed, map[string]bool{})
+	if err != nil {
+		return Grammar{}, err
+	}
+	for _, name := range imports {
+		if !allowed[name] {
+			return Grammar{}, fmt.Errorf("%s: %s", ErrSandboxImport, name)
+		}
+	}
+
+	if g, err = options.twoLevel(g); err != nil {
+		return Grammar{}, err
+	}
+	g = options.keywords(g)
+
+	if g, err = options.fold(g); err != nil {
+		return Grammar{}, err
+	}
+
+	if err := options.validate(g); err != nil {
+		return Grammar{}, err
+	}
+
+	if err := firstDiagnostic(g.checks()...); err != nil {
+		return Grammar{}, err
+	}
+
+	if err := g.CheckBudget(options.Budget); err != nil {
+		return Grammar{}, err
+	}
+
+	if s.ValidateOnly {
+		return Grammar{}, nil
+	}
+
+	return g, nil
+}
+
+// CompileEngine compiles grammar source with Compile, and constructs an Engine for it with NewEngine.
+// The Trivia compile option is the trivia rule of the parse options if they have one.
+// If the compile options have a Sandbox, the parse options are restricted by ParseOptions.WithUntrustedInput,
+// so that it is an error to have actions or predicates, and if it is ValidateOnly, the Engine is nil.
+func CompileEngine(source io.Reader, options CompileOptions, parse ParseOptions) (*Engine, error) {
+	if parse.Trivia != "" {
+		options.Trivia = parse.Trivia
+	}
+
+	sandbox := options.Sandbox
+	if sandbox != nil {
+		// The grammar is needed to validate the parse options
+		compileSandbox := *sandbox
+		compileSandbox.ValidateOnly = false
+		options.Sandbox = &compileSandbox
+
+		parse = parse.WithUntrustedInput()
+	}
+
+	g, err := Compile(source, options)
+	if err != nil {
+		return nil, err
+	}
+
+	engine, err := NewEngine(g, parse)
+	if (err != nil) || ((sandbox != nil) && sandbox.ValidateOnly) {
+		return nil, err
+	}
+
+	return engine, nil
+}
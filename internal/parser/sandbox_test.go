@@ -0,0 +1,83 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSandbox(t *testing.T) {
+	var (
+		source  = "grammar G; import Common, L = Lexer; a : 'x' b* ; b : 'y' ;"
+		options = CompileOptions{Dialect: DialectANTLR, Sandbox: &Sandbox{Imports: []string{"Common", "Lexer"}}}
+	)
+
+	g, err := Compile(strings.NewReader(source), options)
+	assert.Nil(t, err)
+	assert.Equal(t, "a = 'x' a-part\na-part = (b)*\nb = 'y'", g.String())
+
+	// Only allowed grammars can be imported, not local names
+	options.Sandbox.Imports = []string{"Common", "L"}
+	_, err = Compile(strings.NewReader(source), options)
+	assert.Equal(t, ErrSandboxImport+": Lexer", err.Error())
+
+	// The source size is limited
+	options.Sandbox = &Sandbox{MaxSourceSize: 10}
+	_, err = Compile(strings.NewReader("a : 'x' ;"), options)
+	assert.Nil(t, err)
+	_, err = Compile(strings.NewReader("a : 'xyz' ;"), options)
+	assert.Equal(t, ErrMaxInputSize+": 10", err.Error())
+
+	// The grammar is validated
+	options.Sandbox = &Sandbox{}
+	_, err = Compile(strings.NewReader("a : b ;"), options)
+	assert.Equal(t, DiagUndefinedRule, err.(Diagnostic).Code())
+
+	// The budget is lowered
+	_, err = Compile(strings.NewReader(strings.Repeat("a : 'x' ;", SandboxMaxRules+1)), options)
+	assert.Equal(t, DiagDuplicateRule, err.(Diagnostic).Code())
+	var str strings.Builder
+	for i := 0; i <= SandboxMaxRules; i++ {
+		str.WriteString("a" + strings.Repeat("b", i) + " : 'x' ;")
+	}
+	_, err = Compile(strings.NewReader(str.String()), options)
+	assert.Equal(t, ErrBudgetRules+": 1001 > 1000", err.Error())
+
+	options.Budget.MaxRules = 1
+	_, err = Compile(strings.NewReader("a : b ; b : 'x' ;"), options)
+	assert.Equal(t, ErrBudgetRules+": 2 > 1", err.Error())
+
+	// A validate only compile has no grammar
+	options = CompileOptions{Dialect: DialectANTLR, Sandbox: &Sandbox{ValidateOnly: true}}
+	g, err = Compile(strings.NewReader("a : 'x' ;"), options)
+	assert.Nil(t, err)
+	assert.Equal(t, Grammar{}, g)
+}
+
+func TestCompileEngine(t *testing.T) {
+	engine, err := CompileEngine(strings.NewReader("a : 'x' ;"), CompileOptions{Dialect: DialectANTLR}, ParseOptions{})
+	assert.Nil(t, err)
+	node, err := engine.Parse(strings.NewReader("x"))
+	assert.Nil(t, err)
+	assert.Equal(t, "a('x')", treeString(node))
+
+	// A sandbox has no callbacks, and limits the parse
+	sandbox := CompileOptions{Dialect: DialectANTLR, Sandbox: &Sandbox{}}
+	engine, err = CompileEngine(strings.NewReader("a : 'x' ;"), sandbox, ParseOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, ParseOptions{Start: "a"}.WithUntrustedInput(), engine.options)
+
+	actions := ParseOptions{Actions: map[string]Action{"a": func(*ParseContext, Node) {}}}
+	_, err = CompileEngine(strings.NewReader("a : 'x' ;"), sandbox, actions)
+	assert.Equal(t, ErrNoCallbacks, err.Error())
+
+	// A validate only compile validates the parse options too, and has no engine
+	sandbox.Sandbox.ValidateOnly = true
+	engine, err = CompileEngine(strings.NewReader("a : 'x' ;"), sandbox, ParseOptions{})
+	assert.Nil(t, err)
+	assert.Nil(t, engine)
+
+	_, err = CompileEngine(strings.NewReader("a : 'x' ;"), sandbox, ParseOptions{Start: "b"})
+	assert.Equal(t, ErrUndefinedStartRule+": b", err.Error())
+}
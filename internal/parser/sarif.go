@@ -0,0 +1,166 @@
+//go:build !tinygo
+// +build !tinygo
+
+package parser
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// SARIF constants
+const (
+	sarifVersion  = "2.1.0"
+	sarifSchema   = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifToolName = "goparse"
+)
+
+var (
+	// Diagnostic codes that are reported as warnings rather than errors, as they do not prevent parsing
+	diagWarnings = map[string]bool{
+		DiagUnusedRule:  true,
+		DiagLL1Conflict: true,
+	}
+)
+
+// diagnosticJSON is the JSON form of a Diagnostic
+type diagnosticJSON struct {
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line"`
+	Position int    `json:"position"`
+}
+
+// MarshalJSON is the json.Marshaler interface, which writes an object with code, message, line, and position fields,
+// and a file field if the diagnostic has a file
+func (d Diagnostic) MarshalJSON() ([]byte, error) {
+	return json.Marshal(diagnosticJSON{
+		Code:     d.code,
+		Message:  d.message,
+		File:     d.file,
+		Line:     d.line,
+		Position: d.position,
+	})
+}
+
+// DiagnosticsJSON returns a JSON array of diagnostics
+func DiagnosticsJSON(diags []Diagnostic) ([]byte, error) {
+	if diags == nil {
+		diags = []Diagnostic{}
+	}
+
+	return json.Marshal(diags)
+}
+
+// SARIF log structure, containing only the properties goparse uses
+type (
+	sarifLog struct {
+		Version string     `json:"version"`
+		Schema  string     `json:"$schema"`
+		Runs    []sarifRun `json:"runs"`
+	}
+
+	sarifRun struct {
+		Tool    sarifTool     `json:"tool"`
+		Results []sarifResult `json:"results"`
+	}
+
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+
+	sarifDriver struct {
+		Name  string      `json:"name"`
+		Rules []sarifRule `json:"rules"`
+	}
+
+	sarifRule struct {
+		ID               string       `json:"id"`
+		ShortDescription sarifMessage `json:"shortDescription"`
+	}
+
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+
+	sarifResult struct {
+		RuleID    string          `json:"ruleId"`
+		Level     string          `json:"level"`
+		Message   sarifMessage    `json:"message"`
+		Locations []sarifLocation `json:"locations"`
+	}
+
+	sarifLocation struct {
+		PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	}
+
+	sarifPhysicalLocation struct {
+		ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+		Region           *sarifRegion          `json:"region,omitempty"`
+	}
+
+	sarifArtifactLocation struct {
+		URI string `json:"uri"`
+	}
+
+	sarifRegion struct {
+		StartLine   int `json:"startLine"`
+		StartColumn int `json:"startColumn"`
+	}
+)
+
+// DiagnosticsSARIF returns a SARIF 2.1.0 log of diagnostics found in the grammar at the given URI,
+// for code review tools that display static analysis results.
+// Each diagnostic code is a SARIF rule, unreachable rules and LL(1) conflicts are warnings, and all other codes are errors.
+// A diagnostic without a line has a location without a region, and a diagnostic with a file is located at that file
+// rather than the given URI.
+func DiagnosticsSARIF(diags []Diagnostic, uri string) ([]byte, error) {
+	var (
+		codes   []string
+		results = []sarifResult{}
+	)
+
+	for _, diag := range diags {
+		level := "error"
+		if diagWarnings[diag.code] {
+			level = "warning"
+		}
+
+		artifact := sarifArtifactLocation{URI: uri}
+		if diag.file != "" {
+			artifact.URI = diag.file
+		}
+
+		location := sarifLocation{PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: artifact}}
+		if diag.line > 0 {
+			location.PhysicalLocation.Region = &sarifRegion{StartLine: diag.line, StartColumn: diag.position}
+		}
+
+		results = append(results, sarifResult{
+			RuleID:    diag.code,
+			Level:     level,
+			Message:   sarifMessage{Text: diag.message},
+			Locations: []sarifLocation{location},
+		})
+	}
+
+	for code := range diagMessages {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	rules := make([]sarifRule, len(codes))
+	for i, code := range codes {
+		rules[i] = sarifRule{ID: code, ShortDescription: sarifMessage{Text: diagMessages[code]}}
+	}
+
+	return json.Marshal(sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs: []sarifRun{{
+			Tool:    sarifTool{Driver: sarifDriver{Name: sarifToolName, Rules: rules}},
+			Results: results,
+		}},
+	})
+}
@@ -0,0 +1,67 @@
+//go:build !tinygo
+// +build !tinygo
+
+package parser
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiagnosticsJSON(t *testing.T) {
+	data, err := DiagnosticsJSON(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "[]", string(data))
+
+	data, err = DiagnosticsJSON([]Diagnostic{newDiagnostic(DiagUndefinedRule, "b", OfSourceNodeAt("b", 2, 5))})
+	assert.Nil(t, err)
+	assert.Equal(t, `[{"code":"undefrule","message":"Undefined rule b","line":2,"position":5}]`, string(data))
+
+	data, err = DiagnosticsJSON([]Diagnostic{newDiagnostic(DiagUndefinedRule, "b", OfSourceNodeAt("b", 2, 5).inFile("base"))})
+	assert.Nil(t, err)
+	assert.Equal(t, `[{"code":"undefrule","message":"Undefined rule b","file":"base","line":2,"position":5}]`, string(data))
+}
+
+func TestDiagnosticsSARIF(t *testing.T) {
+	data, err := DiagnosticsSARIF(
+		[]Diagnostic{
+			newDiagnostic(DiagUndefinedRule, "b", OfSourceNodeAt("b", 2, 5)),
+			newDiagnostic(DiagUnusedRule, "c", OfSourceNode("c")),
+			newDiagnostic(DiagUnusedRule, "d", OfSourceNodeAt("d", 1, 1).inFile("base.ebnf")),
+		},
+		"file:///grammar.ebnf",
+	)
+	assert.Nil(t, err)
+
+	var log map[string]interface{}
+	assert.Nil(t, json.Unmarshal(data, &log))
+	assert.Equal(t, "2.1.0", log["version"])
+
+	run := log["runs"].([]interface{})[0].(map[string]interface{})
+	driver := run["tool"].(map[string]interface{})["driver"].(map[string]interface{})
+	assert.Equal(t, "goparse", driver["name"])
+	assert.Equal(t, len(diagMessages), len(driver["rules"].([]interface{})))
+
+	results := run["results"].([]interface{})
+	assert.Equal(t, 3, len(results))
+
+	result := results[0].(map[string]interface{})
+	assert.Equal(t, "undefrule", result["ruleId"])
+	assert.Equal(t, "error", result["level"])
+	assert.Equal(t, "Undefined rule b", result["message"].(map[string]interface{})["text"])
+	location := result["locations"].([]interface{})[0].(map[string]interface{})["physicalLocation"].(map[string]interface{})
+	assert.Equal(t, "file:///grammar.ebnf", location["artifactLocation"].(map[string]interface{})["uri"])
+	assert.Equal(t, map[string]interface{}{"startLine": 2.0, "startColumn": 5.0}, location["region"])
+
+	result = results[1].(map[string]interface{})
+	assert.Equal(t, "warning", result["level"])
+	location = result["locations"].([]interface{})[0].(map[string]interface{})["physicalLocation"].(map[string]interface{})
+	assert.Nil(t, location["region"])
+
+	// A diagnostic of a grammar that is extended is located at its file
+	result = results[2].(map[string]interface{})
+	location = result["locations"].([]interface{})[0].(map[string]interface{})["physicalLocation"].(map[string]interface{})
+	assert.Equal(t, "base.ebnf", location["artifactLocation"].(map[string]interface{})["uri"])
+}
@@ -0,0 +1,51 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SExpr returns the tree as an S-expression, for debugging and golden file tests.
+// A rule is (name children...), a terminal is its text as a quoted Go string, and an error node is ! followed by its quoted text.
+// If positions is true, each node is followed by @start-end, which are rune offsets in the input;
+// leaving them out keeps the output the same when unrelated input before a node changes.
+//
+// EG, a(b('x') 'y') is
+//
+//	(a (b "x") "y")
+//
+// or with positions
+//
+//	(a@0-2 (b@0-1 "x"@0-1) "y"@1-2)
+func (n Node) SExpr(positions bool) string {
+	var str strings.Builder
+	n.writeSExpr(&str, positions)
+	return str.String()
+}
+
+// writeSExpr writes the S-expression of the node
+func (n Node) writeSExpr(str *strings.Builder, positions bool) {
+	position := ""
+	if positions {
+		position = fmt.Sprintf("@%d-%d", n.start, n.end)
+	}
+
+	if n.IsTerminal() {
+		if n.invalid {
+			str.WriteRune('!')
+		}
+		str.WriteString(strconv.Quote(n.text))
+		str.WriteString(position)
+		return
+	}
+
+	str.WriteRune('(')
+	str.WriteString(n.rule)
+	str.WriteString(position)
+	for _, child := range n.children {
+		str.WriteRune(' ')
+		child.writeSExpr(str, positions)
+	}
+	str.WriteRune(')')
+}
@@ -0,0 +1,22 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSExpr(t *testing.T) {
+	tree := testTree()
+	assert.Equal(t, `(a (b "x") (c (b "y")) "z")`, tree.SExpr(false))
+	assert.Equal(t, `(a@0-3 (b@0-1 "x"@0-1) (c@1-2 (b@1-2 "y"@1-2)) "z"@2-3)`, tree.SExpr(true))
+
+	assert.Equal(t, `(e)`, Node{rule: "e"}.SExpr(false))
+	assert.Equal(t, `"\"\n"`, Node{text: "\"\n"}.SExpr(false))
+}
+
+func TestSExprError(t *testing.T) {
+	tree := Node{rule: "a", end: 3, children: []Node{{text: "x", end: 1}, {text: "yz", start: 1, end: 3, invalid: true}}}
+	assert.Equal(t, `(a "x" !"yz")`, tree.SExpr(false))
+	assert.Equal(t, `(a@0-3 "x"@0-1 !"yz"@1-3)`, tree.SExpr(true))
+}
@@ -0,0 +1,109 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// sharedKey is a shared alternative tried at an input offset
+type sharedKey struct {
+	alt    int
+	offset int
+}
+
+// sharedAlternatives returns the id of each alternative that is identical in two or more of the rules, by rule name
+// and index of the alternative, so that the backtracking backend matches it once per offset for all of them.
+//
+// Only alternatives with more than one list item, or with repetitions other than exactly once, are shared,
+// since a lone list item is already a single match. Alternatives of rules with a predicate or limits are not shared,
+// as the matches of their alternatives depend on the rule.
+func sharedAlternatives(rules []Rule, predicates map[string]Predicate, limits map[string]RuleLimits) map[string]map[int]int {
+	var (
+		// rules each alternative key is used in
+		usedBy = map[string]map[string]bool{}
+		ids    = map[string]int{}
+		shared = map[string]map[int]int{}
+	)
+
+	shareable := func(rule Rule) bool {
+		_, haveLimits := limits[rule.name]
+		return (predicates[rule.name] == nil) && !haveLimits
+	}
+
+	for _, rule := range rules {
+		if !shareable(rule) {
+			continue
+		}
+
+		for _, alt := range rule.expr.items {
+			if isShareable(alt) {
+				key := alternativeKey(alt)
+				if usedBy[key] == nil {
+					usedBy[key] = map[string]bool{}
+				}
+				usedBy[key][rule.name] = true
+			}
+		}
+	}
+
+	for _, rule := range rules {
+		if !shareable(rule) {
+			continue
+		}
+
+		for a, alt := range rule.expr.items {
+			key := ""
+			if isShareable(alt) {
+				key = alternativeKey(alt)
+			}
+			if len(usedBy[key]) < 2 {
+				continue
+			}
+
+			id, haveID := ids[key]
+			if !haveID {
+				id = len(ids)
+				ids[key] = id
+			}
+			if shared[rule.name] == nil {
+				shared[rule.name] = map[int]int{}
+			}
+			shared[rule.name][a] = id
+		}
+	}
+
+	return shared
+}
+
+// isShareable returns true if an alternative is worth sharing
+func isShareable(alt ExpressionItem) bool {
+	return (len(alt.list) > 1) || (alt.n != 1) || (alt.m != 1)
+}
+
+// alternativeKey returns a key that is the same for alternatives that match the same input in the same way,
+// regardless of how they are written in the source
+func alternativeKey(alt ExpressionItem) string {
+	var key strings.Builder
+
+	for _, item := range alt.list {
+		switch {
+		case item.IsRuleName():
+			key.WriteString(item.ruleName)
+		case item.terminal.IsString():
+			key.WriteString(stringSource(item.terminal.theString))
+		default:
+			key.WriteString(rangeSource(item.terminal.theRange))
+		}
+
+		for _, option := range item.options {
+			key.WriteString(option.String())
+		}
+		key.WriteRune(' ')
+	}
+	fmt.Fprintf(&key, "{%d,%d}", alt.n, alt.m)
+	if alt.lazy {
+		key.WriteRune('?')
+	}
+
+	return key.String()
+}
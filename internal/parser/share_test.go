@@ -0,0 +1,86 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSharedAlternatives(t *testing.T) {
+	g := testGrammar(
+		"a = b 'x' | 'y'",
+		"b = b 'y' | 'y'",
+		"c = ('z')* | b 'z'",
+	)
+	assert.Equal(t, map[string]map[int]int{}, sharedAlternatives(g.rules, nil, nil))
+
+	g = testGrammar(
+		"a = b 'x' | ([ab])* | 'y'",
+		"b = 'y' | b 'x' | ([ba])*",
+		"c = b 'x' | 'z'",
+	)
+	assert.Equal(
+		t,
+		map[string]map[int]int{"a": {0: 0, 1: 1}, "b": {1: 0, 2: 1}, "c": {0: 0}},
+		sharedAlternatives(g.rules, nil, nil),
+	)
+
+	// Alternatives of rules with a predicate or limits are not shared
+	assert.Equal(
+		t,
+		map[string]map[int]int{"a": {0: 0}, "c": {0: 0}},
+		sharedAlternatives(
+			g.rules,
+			map[string]Predicate{"b": func(*ParseContext, Node) bool { return true }},
+			map[string]RuleLimits{"d": {MaxLength: 1}},
+		),
+	)
+	assert.Equal(
+		t,
+		map[string]map[int]int{},
+		sharedAlternatives(g.rules, nil, map[string]RuleLimits{"a": {MaxLength: 1}, "b": {MaxRepetitions: 1}}),
+	)
+}
+
+func TestShareSubexpressions(t *testing.T) {
+	var (
+		// s tries b 'x' in a, then again in c at the same offset
+		g = testGrammar(
+			"s = a 'y' | c 'z'",
+			"a = b 'x' | 'q'",
+			"b = ([123])+",
+			"c = 'r' | b 'x'",
+		)
+		source = g.String()
+		calls  int
+		parse  = func(share bool) Node {
+			calls = 0
+			engine, err := NewEngine(g, ParseOptions{
+				ShareSubexpressions: share,
+				Predicates: map[string]Predicate{
+					"b": func(*ParseContext, Node) bool {
+						calls++
+						return true
+					},
+				},
+			})
+			assert.Nil(t, err)
+
+			node, err := engine.Parse(strings.NewReader("123xz"))
+			assert.Nil(t, err)
+
+			return node
+		}
+	)
+
+	// The tree is the same, and b is only matched once for both a and c
+	node := parse(false)
+	assert.Equal(t, 6, calls)
+	assert.Equal(t, node, parse(true))
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, "c", node.Children()[0].Rule())
+
+	// The grammar is unchanged
+	assert.Equal(t, source, g.String())
+}
@@ -0,0 +1,97 @@
+package parser
+
+import (
+	"sort"
+	"strings"
+)
+
+// maxSuggestions is the maximum number of names suggested for a misspelled name
+const maxSuggestions = 3
+
+// editDistance returns the Levenshtein distance between two strings, which is the number of runes that must be
+// inserted, deleted, or replaced to change one into the other
+func editDistance(a, b string) int {
+	var (
+		ra, rb = []rune(a), []rune(b)
+		prev   = make([]int, len(rb)+1)
+		cur    = make([]int, len(rb)+1)
+	)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			cur[j] = prev[j-1] + cost
+			if prev[j]+1 < cur[j] {
+				cur[j] = prev[j] + 1
+			}
+			if cur[j-1]+1 < cur[j] {
+				cur[j] = cur[j-1] + 1
+			}
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(rb)]
+}
+
+// suggestNames returns the candidates closest to a misspelled name, in order, up to maxSuggestions of them.
+// A candidate is close if it differs in case only, or its edit distance is less than the length of the name, and at most
+// a third of it or 1.
+func suggestNames(name string, candidates []string) []string {
+	var (
+		length = len([]rune(name))
+		max    = length / 3
+		best   = -1
+		result []string
+	)
+	if max < 1 {
+		max = 1
+	}
+	if max >= length {
+		max = length - 1
+	}
+
+	for _, candidate := range candidates {
+		distance := editDistance(name, candidate)
+		if strings.EqualFold(name, candidate) {
+			distance = 0
+		}
+
+		switch {
+		case distance > max, (best >= 0) && (distance > best):
+		case distance == best:
+			result = append(result, candidate)
+		default:
+			best, result = distance, []string{candidate}
+		}
+	}
+
+	sort.Strings(result)
+	if len(result) > maxSuggestions {
+		result = result[:maxSuggestions]
+	}
+
+	return result
+}
+
+// didYouMean returns a suggestion such as "did you mean a, b, or c?", or "" if there are no names
+func didYouMean(names []string) string {
+	switch len(names) {
+	case 0:
+		return ""
+	case 1:
+		return "did you mean " + names[0] + "?"
+	case 2:
+		return "did you mean " + names[0] + " or " + names[1] + "?"
+	}
+
+	return "did you mean " + strings.Join(names[:len(names)-1], ", ") + ", or " + names[len(names)-1] + "?"
+}
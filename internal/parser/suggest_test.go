@@ -0,0 +1,37 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance("", ""))
+	assert.Equal(t, 3, editDistance("", "abc"))
+	assert.Equal(t, 3, editDistance("abc", ""))
+	assert.Equal(t, 0, editDistance("expr", "expr"))
+	assert.Equal(t, 1, editDistance("expr", "exp"))
+	assert.Equal(t, 1, editDistance("expr", "expt"))
+	assert.Equal(t, 2, editDistance("expr", "epxr"))
+	assert.Equal(t, 3, editDistance("kitten", "sitting"))
+	assert.Equal(t, 1, editDistance("é", "e"))
+}
+
+func TestSuggestNames(t *testing.T) {
+	names := []string{"expr", "term", "factor", "Number", "exp", "expt", "a"}
+
+	assert.Equal(t, []string{"expr", "expt"}, suggestNames("exprt", names))
+	assert.Equal(t, []string{"abcw", "abcx", "abcy"}, suggestNames("abcd", []string{"abcz", "abcy", "abcx", "abcw"}))
+	assert.Equal(t, []string{"expr"}, suggestNames("exprr", names))
+	assert.Equal(t, []string{"factor"}, suggestNames("facter", names))
+	assert.Equal(t, []string{"Number"}, suggestNames("number", names))
+	assert.Equal(t, []string{"a"}, suggestNames("A", names))
+	assert.Equal(t, []string(nil), suggestNames("b", names))
+	assert.Equal(t, []string(nil), suggestNames("statement", names))
+
+	assert.Equal(t, "", didYouMean(nil))
+	assert.Equal(t, "did you mean a?", didYouMean([]string{"a"}))
+	assert.Equal(t, "did you mean a or b?", didYouMean([]string{"a", "b"}))
+	assert.Equal(t, "did you mean a, b, or c?", didYouMean([]string{"a", "b", "c"}))
+}
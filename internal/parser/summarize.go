@@ -0,0 +1,71 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Summarize error message constants
+const (
+	ErrSummarizeUndefinedRule = "Cannot summarize an undefined rule"
+)
+
+// Summarize returns the definition of the named rule, with references to other rules replaced by their alternatives,
+// up to depth levels of references. A depth of 0 is the definition as is.
+// An inlined rule is enclosed in parentheses if it has more than one alternative.
+// A reference to a rule that is already being inlined, or is not defined, is not inlined, so recursive rules remain finite.
+// Options of inlined list items are lost.
+//
+// EG, with depth 1:
+//
+//	sum = num | num '+' sum
+//	num = [0-9] | '(' sum ')'
+//
+// sum is summarized as
+//
+//	sum = ([0-9] | '(' sum ')') | ([0-9] | '(' sum ')') '+' sum
+//
+// Panics if the rule is not defined.
+func (g Grammar) Summarize(ruleName string, depth int) string {
+	byName := rulesByName(g.rules)
+
+	rule, defined := byName[ruleName]
+	if !defined {
+		panic(fmt.Errorf("%s: %s", ErrSummarizeUndefinedRule, ruleName))
+	}
+
+	return ruleName + " = " + summarizeExpression(byName, rule.expr, depth, map[string]bool{ruleName: true})
+}
+
+// summarizeExpression returns the source of an expression with references inlined up to depth,
+// except for references to rules that are in inlining
+func summarizeExpression(byName map[string]Rule, expr Expression, depth int, inlining map[string]bool) string {
+	alts := make([]string, len(expr.items))
+
+	for i, alt := range expr.items {
+		items := make([]string, len(alt.list))
+
+		for j, item := range alt.list {
+			rule, defined := byName[item.ruleName]
+			if item.IsTerminal() || (depth == 0) || !defined || inlining[item.ruleName] {
+				items[j] = item.String()
+				continue
+			}
+
+			inlining[item.ruleName] = true
+			items[j] = summarizeExpression(byName, rule.expr, depth-1, inlining)
+			delete(inlining, item.ruleName)
+
+			if len(rule.expr.items) > 1 {
+				items[j] = "(" + items[j] + ")"
+			}
+		}
+
+		alts[i] = strings.Join(items, " ")
+		if rep := alt.repetition(); rep != "" {
+			alts[i] = "(" + alts[i] + ")" + rep
+		}
+	}
+
+	return strings.Join(alts, " | ")
+}
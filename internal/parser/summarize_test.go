@@ -0,0 +1,31 @@
+package parser
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummarize(t *testing.T) {
+	g := testGrammar(
+		"sum = num | num '+' sum",
+		"num = [123] | '(' sum ')' | digits",
+		"digits = ([456])+",
+	)
+
+	assert.Equal(t, "sum = num | num '+' sum", g.Summarize("sum", 0))
+	assert.Equal(t, "sum = ([123] | '(' sum ')' | digits) | ([123] | '(' sum ')' | digits) '+' sum", g.Summarize("sum", 1))
+	assert.Equal(t, "sum = ([123] | '(' sum ')' | ([456])+) | ([123] | '(' sum ')' | ([456])+) '+' sum", g.Summarize("sum", 2))
+	assert.Equal(t, "num = [123] | '(' (num | num '+' sum) ')' | ([456])+", g.Summarize("num", 1))
+	assert.Equal(t, "digits = ([456])+", g.Summarize("digits", 5))
+
+	func() {
+		defer func() {
+			assert.Equal(t, fmt.Errorf("%s: foo", ErrSummarizeUndefinedRule), recover())
+		}()
+
+		g.Summarize("foo", 1)
+		assert.Fail(t, "Must panic")
+	}()
+}
@@ -0,0 +1,186 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Token synthesis error message constants
+const (
+	ErrTokenConflict = "More than one token rule is the same literal"
+)
+
+var (
+	// Names of punctuation in synthesized token rule names
+	tokenPunctNames = map[rune]string{
+		'!': "BANG", '"': "DQUOTE", '#': "HASH", '$': "DOLLAR", '%': "PERCENT", '&': "AMP", '\'': "QUOTE",
+		'(': "LPAREN", ')': "RPAREN", '*': "STAR", '+': "PLUS", ',': "COMMA", '-': "MINUS", '.': "DOT", '/': "SLASH",
+		':': "COLON", ';': "SEMI", '<': "LT", '=': "EQUALS", '>': "GT", '?': "QUESTION", '@': "AT",
+		'[': "LBRACK", '\\': "BACKSLASH", ']': "RBRACK", '^': "CARET", '_': "UNDERSCORE", '`': "BACKQUOTE",
+		'{': "LBRACE", '|': "PIPE", '}': "RBRACE", '~': "TILDE",
+		' ': "SPACE", '\t': "TAB", '\n': "NEWLINE", '\r': "CR",
+	}
+)
+
+// IsToken returns true if the rule is a token rule of a two-level grammar, which is a rule whose name begins with an
+// upper case letter, as in ANTLR, such as ID or PLUS. The other rules are parser rules.
+func (r Rule) IsToken() bool {
+	for _, char := range r.name {
+		return unicode.IsUpper(char)
+	}
+
+	return false
+}
+
+// literal returns the string of a token rule that has one alternative of one string matched once, and true,
+// or false if the rule is not such a token rule
+func (r Rule) literal() (string, bool) {
+	if !r.IsToken() || (len(r.expr.items) != 1) {
+		return "", false
+	}
+
+	alt := r.expr.items[0]
+	if (alt.n != 1) || (alt.m != 1) || (len(alt.list) != 1) || !alt.list[0].IsTerminal() || !alt.list[0].terminal.IsString() {
+		return "", false
+	}
+
+	return alt.list[0].terminal.theString, true
+}
+
+// tokenName returns the name of a synthesized token rule for a literal, where each run of letters and digits is in
+// upper case, and each other rune is the name of the punctuation or its code point, separated by dashes
+func tokenName(literal string) string {
+	var (
+		parts []string
+		word  strings.Builder
+		flush = func() {
+			if word.Len() > 0 {
+				parts = append(parts, word.String())
+				word.Reset()
+			}
+		}
+	)
+
+	for _, char := range literal {
+		switch name, isPunct := tokenPunctNames[char]; {
+		case isPunct:
+			flush()
+			parts = append(parts, name)
+		case (char < 0x80) && (unicode.IsLetter(char) || unicode.IsDigit(char)):
+			word.WriteRune(unicode.ToUpper(char))
+		default:
+			flush()
+			parts = append(parts, fmt.Sprintf("U%04X", char))
+		}
+	}
+	flush()
+
+	// A name cannot begin with a digit
+	if name := strings.Join(parts, "-"); !unicode.IsDigit(rune(name[0])) {
+		return name
+	}
+
+	return "T-" + strings.Join(parts, "-")
+}
+
+// SynthesizeTokens returns the grammar as a two-level grammar, where each string in a parser rule is replaced by a
+// reference to a token rule that matches the string, as ANTLR does for literals such as '+' and ';' in parser rules,
+// so that every punctuation token need not be declared by hand.
+//
+// The token rule is the explicit token rule whose only alternative is the string, such as PLUS = '+', or if there is
+// none, a new token rule named after the string, such as PLUS for '+', IF for 'if', and LT-EQUALS for '<=', with a
+// number added if the name is taken. New token rules are added to the end of the grammar in order of first use.
+//
+// Returns the grammar unchanged if no parser rule has a string, or an error if more than one explicit token rule is a
+// string that a parser rule has.
+func (g Grammar) SynthesizeTokens() (Grammar, error) {
+	var (
+		names = map[string]bool{}
+		// token rules of each literal
+		explicit = map[string][]string{}
+		// token rule name of each literal
+		tokens    = map[string]string{}
+		newTokens []Rule
+		rules     = make([]Rule, len(g.rules))
+		changed   bool
+	)
+	for _, rule := range g.rules {
+		names[rule.name] = true
+		if literal, isLiteral := rule.literal(); isLiteral {
+			explicit[literal] = append(explicit[literal], rule.name)
+		}
+	}
+
+	token := func(literal string) (string, error) {
+		if name, haveIt := tokens[literal]; haveIt {
+			return name, nil
+		}
+
+		switch rules := explicit[literal]; len(rules) {
+		case 0:
+			tokens[literal] = uniqueRuleName(tokenName(literal), names)
+			newTokens = append(newTokens, newRule(tokens[literal], nil, newExpression([]ExpressionItem{
+				newExpressionItem([]ListItem{OfListItemTerminal(stringSource(literal), OfTerminalString(stringSource(literal), literal), nil)}, 1, 1),
+			})))
+		case 1:
+			tokens[literal] = rules[0]
+		default:
+			return "", fmt.Errorf("%s: %s (%s)", ErrTokenConflict, stringSource(literal), strings.Join(rules, ", "))
+		}
+
+		return tokens[literal], nil
+	}
+
+	for r, rule := range g.rules {
+		rules[r] = rule
+		if rule.IsToken() {
+			continue
+		}
+
+		var (
+			items       = make([]ExpressionItem, len(rule.expr.items))
+			ruleChanged bool
+		)
+		for a, alt := range rule.expr.items {
+			list := make([]ListItem, len(alt.list))
+			for i, item := range alt.list {
+				list[i] = item
+				if !item.IsTerminal() || !item.terminal.IsString() {
+					continue
+				}
+
+				name, err := token(item.terminal.theString)
+				if err != nil {
+					return Grammar{}, err
+				}
+
+				list[i] = OfListItemRuleName(name, name, item.options)
+				list[i].SourceNode = OfSourceNode(formatListItem(list[i])).withSpan(item.Span())
+				ruleChanged = true
+			}
+
+			items[a] = alt.withList(list)
+			items[a].SourceNode = items[a].withSpan(alt.Span())
+		}
+
+		if ruleChanged {
+			expr := newExpression(items)
+			expr.SourceNode = expr.withSpan(rule.expr.Span())
+
+			rules[r] = newRule(rule.name, rule.options, expr)
+			rules[r].SourceNode = rules[r].withSpan(rule.Span())
+			rules[r].doc, rules[r].limits = rule.doc, rule.limits
+			changed = true
+		}
+	}
+
+	if !changed {
+		return g, nil
+	}
+
+	result := newGrammar(append(rules, newTokens...))
+	result.SourceNode = result.withSpan(g.Span())
+
+	return result, nil
+}
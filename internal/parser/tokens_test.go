@@ -0,0 +1,90 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuleIsToken(t *testing.T) {
+	g := testGrammar(
+		"expr = 'x'",
+		"PLUS = '+'",
+	)
+	assert.False(t, g.rules[0].IsToken())
+	assert.True(t, g.rules[1].IsToken())
+}
+
+func TestTokenName(t *testing.T) {
+	for literal, name := range map[string]string{
+		"+":     "PLUS",
+		";":     "SEMI",
+		"<=":    "LT-EQUALS",
+		"if":    "IF",
+		"else2": "ELSE2",
+		"a+b":   "A-PLUS-B",
+		"1":     "T-1",
+		"é":     "U00E9",
+	} {
+		assert.Equal(t, name, tokenName(literal), literal)
+	}
+}
+
+func TestSynthesizeTokens(t *testing.T) {
+	// Literals in parser rules refer to explicit token rules, or to new token rules in order of first use
+	g, err := testGrammar(
+		"expr = term | term '+' expr",
+		"term = ID | 'if' term | '(' expr ')' | '+' term",
+		"ID = ([abc])+",
+		"LPAREN = '('",
+		"PLUS = 'plus'",
+	).SynthesizeTokens()
+	assert.Nil(t, err)
+	assert.Equal(t, "expr = term | term PLUS-2 expr\n"+
+		"term = ID | IF term | LPAREN expr RPAREN | PLUS-2 term\n"+
+		"ID = ([abc])+\n"+
+		"LPAREN = '('\n"+
+		"PLUS = 'plus'\n"+
+		"PLUS-2 = '+'\n"+
+		"IF = 'if'\n"+
+		"RPAREN = ')'", g.String())
+
+	engine, err := NewEngine(g, ParseOptions{})
+	assert.Nil(t, err)
+	_, err = engine.Parse(strings.NewReader("a+(ifb)+c"))
+	assert.Nil(t, err)
+
+	// Item options are kept
+	g = testGrammar("stmt = 'x' ';'")
+	g.rules[0].expr.items[0].list[1].options = []Option{OptionEOL}
+	g, err = g.SynthesizeTokens()
+	assert.Nil(t, err)
+	assert.Equal(t, "stmt = X SEMI:EOL\nX = 'x'\nSEMI = ';'", g.String())
+
+	// A grammar with no literals in parser rules is unchanged
+	g = testGrammar("expr = ID", "ID = 'x'")
+	synthesized, err := g.SynthesizeTokens()
+	assert.Nil(t, err)
+	assert.Equal(t, g, synthesized)
+
+	// Explicit token rules cannot conflict
+	_, err = testGrammar(
+		"expr = ID '+' ID",
+		"ID = 'x'",
+		"PLUS = '+'",
+		"ADD = '+'",
+	).SynthesizeTokens()
+	assert.Equal(t, fmt.Errorf("%s: '+' (PLUS, ADD)", ErrTokenConflict), err)
+}
+
+func TestCompileTwoLevel(t *testing.T) {
+	source := "expr : ID ('+' ID)* ;\nID : [a-z]+ ;\nPLUS : '+' ;"
+	g, err := Compile(strings.NewReader(source), CompileOptions{Dialect: DialectANTLR, TwoLevel: true})
+	assert.Nil(t, err)
+	assert.Equal(t, "expr = ID expr-part\nexpr-part = (PLUS ID)*\nID = ([a-z])+\nPLUS = '+'", g.String())
+
+	_, err = Compile(strings.NewReader(source+"\nADD : '+' ;"), CompileOptions{Dialect: DialectANTLR, TwoLevel: true, Sandbox: &Sandbox{}})
+	assert.Equal(t, fmt.Errorf("%s: '+' (PLUS, ADD)", ErrTokenConflict), err)
+}
@@ -0,0 +1,92 @@
+package parser
+
+import (
+	"strings"
+)
+
+// Node is a node of a parse tree, which is either a rule or a terminal that matched some input.
+// Start and end are offsets of runes in the input, where end is exclusive.
+type Node struct {
+	rule     string
+	text     string
+	start    int
+	end      int
+	children []Node
+	// true for input that did not match in a full fidelity parse
+	invalid bool
+	// line index of the input, shared by the tree
+	lines *LineIndex
+}
+
+// Rule is the name of the rule that matched, or "" for a terminal
+func (n Node) Rule() string {
+	return n.rule
+}
+
+// IsTerminal returns true if the node is a terminal, which includes an error node
+func (n Node) IsTerminal() bool {
+	return n.rule == ""
+}
+
+// IsError returns true if the node is input that did not match in a full fidelity parse
+func (n Node) IsError() bool {
+	return n.invalid
+}
+
+// Text is the input that matched
+func (n Node) Text() string {
+	return n.text
+}
+
+// Start is the offset of the first rune of input that matched
+func (n Node) Start() int {
+	return n.start
+}
+
+// End is the offset of the rune after the last rune of input that matched
+func (n Node) End() int {
+	return n.end
+}
+
+// Span returns the position of the first rune of input that matched, and of the rune after the last, so that the
+// input can be underlined. The lines and columns are 0 if the node is not from a parse.
+func (n Node) Span() (start, end Position) {
+	if n.lines == nil {
+		return Position{Offset: n.start}, Position{Offset: n.end}
+	}
+
+	return n.lines.Position(n.start), n.lines.Position(n.end)
+}
+
+// Lines returns the line index of the input the node was parsed from, or nil if the node is not from a parse
+func (n Node) Lines() *LineIndex {
+	return n.lines
+}
+
+// index sets the line index of every node of the tree, which is only done for a new tree
+func (n *Node) index(lines *LineIndex) {
+	n.lines = lines
+	for i := range n.children {
+		n.children[i].index(lines)
+	}
+}
+
+// Children are the nodes that matched the list items of the rule, in order.
+// A terminal has no children.
+func (n Node) Children() []Node {
+	return n.children
+}
+
+// Source returns the text of the terminals of the tree in order.
+// If the tree is the result of a full fidelity parse, this is all of the input.
+func (n Node) Source() string {
+	var str strings.Builder
+	WalkPreOrder(n, func(node Node) bool {
+		if node.IsTerminal() {
+			str.WriteString(node.text)
+		}
+		return true
+	})
+
+	return str.String()
+}
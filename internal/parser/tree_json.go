@@ -0,0 +1,57 @@
+//go:build !tinygo
+// +build !tinygo
+
+package parser
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// nodeJSON is the JSON form of a Node
+type nodeJSON struct {
+	Rule     string `json:"rule,omitempty"`
+	Text     string `json:"text"`
+	Start    int    `json:"start"`
+	End      int    `json:"end"`
+	Children []Node `json:"children,omitempty"`
+	Error    bool   `json:"error,omitempty"`
+}
+
+// MarshalJSON is the json.Marshaler interface, which writes an object with rule, text, start, end, and children fields.
+// A terminal has no rule or children fields, and only an error node has an error field, which is true.
+func (n Node) MarshalJSON() ([]byte, error) {
+	return json.Marshal(nodeJSON{
+		Rule:     n.rule,
+		Text:     n.text,
+		Start:    n.start,
+		End:      n.end,
+		Children: n.children,
+		Error:    n.invalid,
+	})
+}
+
+// parseResultJSON is the JSON result of ParseToJSON
+type parseResultJSON struct {
+	Tree  *Node  `json:"tree,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// ParseToJSON parses input with the default options, and returns a JSON object with either a tree field containing the parse tree,
+// or an error field containing the error message. It only uses strings, so it can be exported to JavaScript when built
+// for GOOS=js and GOARCH=wasm, where there are no files or readers to pass.
+func ParseToJSON(g Grammar, input string) string {
+	var result parseResultJSON
+
+	if engine, err := NewEngine(g, ParseOptions{}); err != nil {
+		result.Error = err.Error()
+	} else if node, err := engine.Parse(strings.NewReader(input)); err != nil {
+		result.Error = err.Error()
+	} else {
+		result.Tree = &node
+	}
+
+	// A result of strings, ints, and nodes cannot fail to marshal
+	data, _ := json.Marshal(result)
+	return string(data)
+}
@@ -0,0 +1,36 @@
+//go:build !tinygo
+// +build !tinygo
+
+package parser
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNodeJSON(t *testing.T) {
+	data, err := json.Marshal(testTree().children[0])
+	assert.Nil(t, err)
+	assert.Equal(t, `{"rule":"b","text":"x","start":0,"end":1,"children":[{"text":"x","start":0,"end":1}]}`, string(data))
+
+	data, err = json.Marshal(Node{text: "y", start: 1, end: 2, invalid: true})
+	assert.Nil(t, err)
+	assert.Equal(t, `{"text":"y","start":1,"end":2,"error":true}`, string(data))
+}
+
+func TestParseToJSON(t *testing.T) {
+	g := testGrammar(
+		"a = b 'y'",
+		"b = 'x'",
+	)
+
+	assert.Equal(
+		t,
+		`{"tree":{"rule":"a","text":"xy","start":0,"end":2,"children":[{"rule":"b","text":"x","start":0,"end":1,"children":[{"text":"x","start":0,"end":1}]},{"text":"y","start":1,"end":2}]}}`,
+		ParseToJSON(g, "xy"),
+	)
+	assert.Equal(t, `{"error":"`+ErrParseFailed+` at line 1 position 2: found 'x', expected 'y' in rule a"}`, ParseToJSON(g, "xx"))
+	assert.Equal(t, `{"error":"Undefined rule c at line 0 position 0"}`, ParseToJSON(testGrammar("a = c"), "x"))
+}
@@ -0,0 +1,211 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Tune error message constants
+const (
+	ErrTuneUndefinedRule = "The tuned rule is not defined"
+	ErrTuneOrder         = "The order is not of the alternatives of the rule"
+)
+
+// TuneOptions are the options of measuring a grammar with Measure
+type TuneOptions struct {
+	// ParseOptions are the options the corpus is parsed with by the backtracking backend, where Memo is the default
+	// memoization the memoization of rules is recommended against
+	ParseOptions ParseOptions
+	// MemoThreshold is the repeat ratio at which a rule is worth memoizing, as described by SuggestMemo, 0.25 if <= 0
+	MemoThreshold float64
+}
+
+// TuneRecommendation is the tuning recommended by measuring a grammar, which can be written to an options file and
+// applied with Tune
+type TuneRecommendation struct {
+	// Memo are the rules whose memoization should change, to the :MEMO option if true, or :NOMEMO if false
+	Memo map[string]bool `json:"memo,omitempty"`
+	// Order are the rules whose alternatives should be tried in another order, as the index of each alternative in the
+	// rule, most frequently matched first, as described by ReorderAlternatives
+	Order map[string][]int `json:"order,omitempty"`
+	// DFA are the largest rules that match a regular language, as they refer to no rule recursively, so a DFA could match
+	// them without backtracking, such as the tokens of a lexer, in grammar order. They are not changed by Tune.
+	DFA []string `json:"dfa,omitempty"`
+}
+
+// TuneReport is the measurement of parsing a corpus with a grammar
+type TuneReport struct {
+	// Inputs is the number of inputs of the corpus, and Failed are the errors of the inputs that did not parse,
+	// by index in the corpus, which are left out of the profile
+	Inputs int
+	Failed map[int]error
+	// MemoStats are the calls and repeats of each rule that could be memoized
+	MemoStats map[string]MemoStats
+	// Memo are the memoization suggestions of SuggestMemo
+	Memo []MemoSuggestion
+	// Profile is the number of times each alternative of each rule matched in the inputs that parsed
+	Profile Profile
+	// Recommendation is the tuning recommended by the measurements
+	Recommendation TuneRecommendation
+}
+
+// Measure parses each input of a corpus with the grammar, and measures how often each rule is retried at the same
+// position, how often each alternative matches, and which rules match a regular language, to recommend which rules to
+// memoize, which alternatives to try first, and which rules are candidates for a DFA.
+// Every rule without the :NOMEMO option is memoized while measuring, so that it is counted.
+// Returns an error if an engine cannot be constructed for the grammar and parse options.
+func (g Grammar) Measure(corpus []string, options TuneOptions) (TuneReport, error) {
+	var (
+		parseOptions = options.ParseOptions
+		defaultMemo  = parseOptions.Memo
+		counter      = OfMemoCounter(nil)
+		threshold    = options.MemoThreshold
+	)
+	parseOptions.Backend, parseOptions.Memo, parseOptions.MemoStore = BackendBacktrack, true, counter.NewStore
+	if threshold <= 0 {
+		threshold = 0.25
+	}
+
+	engine, err := NewEngine(g, parseOptions)
+	if err != nil {
+		return TuneReport{}, err
+	}
+	coverage := OfCoverage(engine.grammar)
+	engine.options.Coverage = coverage
+
+	result := TuneReport{Inputs: len(corpus), Failed: map[int]error{}}
+	for i, input := range corpus {
+		if _, err := engine.Parse(strings.NewReader(input)); err != nil {
+			result.Failed[i] = err
+		}
+	}
+
+	result.MemoStats = counter.Stats()
+	result.Memo = engine.grammar.SuggestMemo(result.MemoStats, threshold, defaultMemo)
+	result.Profile = coverage.Profile()
+
+	// Only the rules of the grammar are recommended, and not those the parse options add
+	var (
+		defined = rulesByName(g.rules)
+		memo    = map[string]bool{}
+		order   = map[string][]int{}
+	)
+	for _, suggestion := range result.Memo {
+		if _, haveIt := defined[suggestion.Rule]; haveIt {
+			memo[suggestion.Rule] = suggestion.Option == OptionMemo
+		}
+	}
+	for name, alts := range engine.grammar.alternativeOrders(result.Profile) {
+		if rule, haveIt := defined[name]; haveIt && (len(rule.expr.items) == len(alts)) {
+			order[name] = alts
+		}
+	}
+
+	result.Recommendation = TuneRecommendation{DFA: g.regularRules()}
+	if len(memo) > 0 {
+		result.Recommendation.Memo = memo
+	}
+	if len(order) > 0 {
+		result.Recommendation.Order = order
+	}
+
+	return result, nil
+}
+
+// regularRules returns the names of the largest rules that match a regular language, which are those that are not part of
+// a cycle of references and refer only to such rules, that are the first rule or are referred to by a rule that does
+// not match a regular language, in grammar order
+func (g Grammar) regularRules() []string {
+	var (
+		byName     = rulesByName(g.rules)
+		components = ruleComponents(g)
+		sizes      = map[int]int{}
+		regular    = map[string]bool{}
+		visited    = map[string]bool{}
+		isRegular  func(string) bool
+	)
+	for _, c := range components {
+		sizes[c]++
+	}
+
+	isRegular = func(name string) bool {
+		if visited[name] {
+			return regular[name]
+		}
+		visited[name] = true
+
+		rule, defined := byName[name]
+		if !defined || (sizes[components[name]] > 1) {
+			return false
+		}
+
+		for _, alt := range rule.expr.items {
+			for _, item := range alt.list {
+				if item.IsRuleName() && ((item.ruleName == name) || !isRegular(item.ruleName)) {
+					return false
+				}
+			}
+		}
+
+		regular[name] = true
+		return true
+	}
+
+	largest := map[string]bool{}
+	for i, rule := range g.rules {
+		if isRegular(rule.name) {
+			largest[rule.name] = largest[rule.name] || (i == 0)
+			continue
+		}
+
+		for _, alt := range rule.expr.items {
+			for _, item := range alt.list {
+				if item.IsRuleName() && isRegular(item.ruleName) {
+					largest[item.ruleName] = true
+				}
+			}
+		}
+	}
+
+	var result []string
+	for _, rule := range g.rules {
+		if largest[rule.name] {
+			result = append(result, rule.name)
+			largest[rule.name] = false
+		}
+	}
+
+	return result
+}
+
+// Tune returns the grammar with the memoization and order of alternatives of a recommendation, such as one read from
+// an options file.
+// Returns an error if a rule of the recommendation is not defined, or an order is not of each alternative of its rule once.
+func (g Grammar) Tune(r TuneRecommendation) (Grammar, error) {
+	defined := rulesByName(g.rules)
+	for name := range r.Memo {
+		if _, haveIt := defined[name]; !haveIt {
+			return Grammar{}, fmt.Errorf("%s: %s", ErrTuneUndefinedRule, name)
+		}
+	}
+
+	for name, order := range r.Order {
+		rule, haveIt := defined[name]
+		if !haveIt {
+			return Grammar{}, fmt.Errorf("%s: %s", ErrTuneUndefinedRule, name)
+		}
+
+		seen := map[int]bool{}
+		for _, index := range order {
+			if (index < 0) || (index >= len(rule.expr.items)) || seen[index] {
+				return Grammar{}, fmt.Errorf("%s: %s", ErrTuneOrder, name)
+			}
+			seen[index] = true
+		}
+		if len(order) != len(rule.expr.items) {
+			return Grammar{}, fmt.Errorf("%s: %s", ErrTuneOrder, name)
+		}
+	}
+
+	return g.withOrders(r.Order, r.Memo), nil
+}
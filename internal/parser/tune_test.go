@@ -0,0 +1,107 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testTuneGrammar returns a grammar of sums of numbers and names, where a sum retries its term after a failed plus
+func testTuneGrammar() Grammar {
+	return testGrammar(
+		"sum = term '+' sum | term",
+		"term:NOMEMO = number | name",
+		"number = ([123])+",
+		"name = ([abcde])+",
+	)
+}
+
+func TestMeasure(t *testing.T) {
+	g := testTuneGrammar()
+
+	report, err := g.Measure([]string{"a+b+1", "c", "d+e", "1+"}, TuneOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, 4, report.Inputs)
+	assert.Equal(t, []int{3}, func() []int {
+		var failed []int
+		for i := range report.Failed {
+			failed = append(failed, i)
+		}
+		return failed
+	}())
+
+	// term is retried after each failed plus, but has the :NOMEMO option, so it is not counted
+	_, counted := report.MemoStats["term"]
+	assert.False(t, counted)
+	assert.True(t, report.MemoStats["name"].Repeats > 0)
+	assert.Equal(t, map[string]bool{"name": true, "number": true}, report.Recommendation.Memo)
+
+	// name matches more often than number
+	assert.Equal(t, map[string][]int{"term": {1, 0}}, report.Recommendation.Order)
+	assert.Equal(t, []string{"term"}, report.Recommendation.DFA)
+
+	tuned, err := g.Tune(report.Recommendation)
+	assert.Nil(t, err)
+	assert.Equal(t, "sum = term '+' sum | term\nterm:NOMEMO = name | number\nnumber:MEMO = ([123])+\nname:MEMO = ([abcde])+", tuned.String())
+
+	// Memoization is recommended against the default
+	report, err = g.Measure([]string{"a+b"}, TuneOptions{ParseOptions: ParseOptions{Memo: true}, MemoThreshold: 0.1})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]bool{"sum": false}, report.Recommendation.Memo)
+
+	_, err = testGrammar("a = a 'x' | 'x'").Measure(nil, TuneOptions{})
+	assert.NotNil(t, err)
+}
+
+func TestTune(t *testing.T) {
+	g := testTuneGrammar()
+
+	tuned, err := g.Tune(TuneRecommendation{})
+	assert.Nil(t, err)
+	assert.Equal(t, g, tuned)
+
+	tuned, err = g.Tune(TuneRecommendation{Memo: map[string]bool{"term": true, "sum": false}})
+	assert.Nil(t, err)
+	assert.Equal(t, "sum:NOMEMO = term '+' sum | term\nterm:MEMO = number | name", tuned.String()[:len("sum:NOMEMO = term '+' sum | term\nterm:MEMO = number | name")])
+
+	for _, recommendation := range []TuneRecommendation{
+		{Memo: map[string]bool{"other": true}},
+		{Order: map[string][]int{"other": {0}}},
+	} {
+		_, err = g.Tune(recommendation)
+		assert.Equal(t, ErrTuneUndefinedRule+": other", err.Error())
+	}
+
+	for _, order := range [][]int{{0}, {0, 0}, {1, 2}, {0, 1, 2}, {-1, 0}} {
+		_, err = g.Tune(TuneRecommendation{Order: map[string][]int{"term": order}})
+		assert.Equal(t, ErrTuneOrder+": term", err.Error())
+	}
+
+	// The error messages of alternatives move with them
+	g = testGrammar("s = 'a' 'x' | 'b' 'y'")
+	g.rules[0] = g.rules[0].WithDoc("strings\n@error:1 first alt msg\n@error:2 second alt msg")
+	tuned, err = g.Tune(TuneRecommendation{Order: map[string][]int{"s": {1, 0}}})
+	assert.Nil(t, err)
+	assert.Equal(t, "strings\n@error:2 first alt msg\n@error:1 second alt msg", tuned.rules[0].Doc())
+
+	engine, err := NewEngine(tuned, ParseOptions{})
+	assert.Nil(t, err)
+	_, err = engine.ParseString("bz")
+	assert.Equal(t, "second alt msg", err.(ParseError).Message())
+	_, err = engine.ParseString("az")
+	assert.Equal(t, "first alt msg", err.(ParseError).Message())
+
+	// So do their :ERROR options
+	g = testGrammar("s = 'a' 'x' | 'b' 'y'")
+	g.rules[0] = g.rules[0].WithErrorMessage(1, "first alt msg").WithErrorMessage(2, "second alt msg")
+	tuned, err = g.Tune(TuneRecommendation{Order: map[string][]int{"s": {1, 0}}})
+	assert.Nil(t, err)
+	assert.Equal(t, "second alt msg", tuned.rules[0].ErrorMessage(1))
+	assert.Equal(t, "first alt msg", tuned.rules[0].ErrorMessage(2))
+}
+
+func TestRegularRules(t *testing.T) {
+	assert.Equal(t, []string{"a"}, testGrammar("a = b c", "b = 'x'", "c = b").regularRules())
+	assert.Equal(t, []string{"b", "d"}, testGrammar("a = b a | d", "b = c", "c = 'x'", "d = 'y'").regularRules())
+	assert.Nil(t, testGrammar("a = 'x' a | 'y'").regularRules())
+}
@@ -0,0 +1,109 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// Untrusted input error message constants
+const (
+	ErrMaxDepth       = "The rules nested deeper than the maximum depth"
+	ErrMaxSteps       = "The parse took more than the maximum number of steps"
+	ErrMaxRepetitions = "An alternative repeated more than the maximum repetitions"
+	ErrMaxInputSize   = "The input is larger than the maximum input size"
+	ErrNoCallbacks    = "Actions and predicates are disabled"
+)
+
+// The limits the backtracking backend uses when ParseOptions.MaxDepth and MaxRepetitions are 0, which are high enough for
+// any reasonable input, but low enough that a pathological grammar or input returns a LimitError instead of exhausting the
+// stack or memory
+const (
+	DefaultMaxDepth       = 10000
+	DefaultMaxRepetitions = 10000000
+)
+
+// The limits WithUntrustedInput sets.
+// UntrustedMemoSize bounds the entries of the memo table of a parse, and so its memory, whatever the size of the input.
+// A parse that needs more entries evicts some, and matches their rules again if they are needed again, which costs steps
+// instead of memory. As making an entry takes at least one step, a parse only evicts after a quarter of
+// UntrustedMaxSteps, so that parses which stay well within their steps keep every entry.
+const (
+	UntrustedMaxDepth       = 500
+	UntrustedMaxSteps       = 1000000
+	UntrustedMaxRepetitions = 100000
+	UntrustedMaxInputSize   = 1 << 20
+	UntrustedMemoSize       = UntrustedMaxSteps / 4
+)
+
+// WithUntrustedInput returns a copy of the options that are safe for parsing untrusted input with the backtracking backend,
+// so that a service can use one line of configuration:
+// - MaxDepth, MaxSteps, MaxRepetitions, MaxInputSize, and MemoSize are set to the Untrusted constants, unless they are already lower
+// - NoCallbacks is true, so that no user code runs while parsing
+func (o ParseOptions) WithUntrustedInput() ParseOptions {
+	lower(&o.MaxDepth, UntrustedMaxDepth)
+	lower(&o.MaxSteps, UntrustedMaxSteps)
+	lower(&o.MaxRepetitions, UntrustedMaxRepetitions)
+	lower(&o.MaxInputSize, UntrustedMaxInputSize)
+	lower(&o.MemoSize, UntrustedMemoSize)
+	o.NoCallbacks = true
+
+	return o
+}
+
+// withDefault sets a limit that is 0 to a default, keeping a negative limit, which is no maximum
+func withDefault(limit *int, def int) {
+	if *limit == 0 {
+		*limit = def
+	}
+}
+
+// readInput reads all of a source, returning an error if it is larger than max bytes, where max <= 0 is no maximum
+func readInput(source io.Reader, max int) ([]byte, error) {
+	if max <= 0 {
+		return ioutil.ReadAll(source)
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(source, int64(max)+1))
+	if err == nil {
+		err = checkInputSize(len(data), max)
+	}
+
+	return data, err
+}
+
+// checkInputSize returns an error if the size of input in bytes is more than max, when max > 0
+func checkInputSize(size, max int) error {
+	if (max > 0) && (size > max) {
+		return fmt.Errorf("%s: %d", ErrMaxInputSize, max)
+	}
+
+	return nil
+}
+
+// enter counts a rule the backtracker tries at offset, aborting the parse if there are too many, or they nest too deeply.
+// The rule is active until the returned function leaves it.
+func (b *backtracker) enter(name string, offset int) func() {
+	b.step(name, offset)
+
+	b.depth++
+	if max := b.engine.options.MaxDepth; (max > 0) && (b.depth > max) {
+		b.exceeded(ErrMaxDepth, name, max, offset)
+	}
+
+	b.active = append(b.active, activeRule{name: name, offset: offset})
+
+	return func() {
+		b.depth--
+		b.active = b.active[:len(b.active)-1]
+	}
+}
+
+// step counts a step of the backtracker at offset in the named rule, which is trying the rule or finding a way a list of
+// its items or a repetition of its alternative matches, aborting the parse if there are too many
+func (b *backtracker) step(name string, offset int) {
+	b.steps++
+	if max := b.engine.options.MaxSteps; (max > 0) && (b.steps > max) {
+		b.exceeded(ErrMaxSteps, name, max, offset)
+	}
+}
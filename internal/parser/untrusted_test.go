@@ -0,0 +1,124 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithUntrustedInput(t *testing.T) {
+	// Unset and higher limits are lowered, and lower limits are kept
+	assert.Equal(
+		t,
+		ParseOptions{
+			MaxDepth:       10,
+			MaxSteps:       UntrustedMaxSteps,
+			MaxRepetitions: UntrustedMaxRepetitions,
+			MaxInputSize:   UntrustedMaxInputSize,
+			MemoSize:       UntrustedMemoSize,
+			NoCallbacks:    true,
+		},
+		ParseOptions{MaxDepth: 10, MaxSteps: UntrustedMaxSteps + 1}.WithUntrustedInput(),
+	)
+
+	var (
+		g = testGrammar(
+			"list = '(' list ')' | 'x'",
+		)
+		parse = func(options ParseOptions, source string) error {
+			engine, err := NewEngine(g, options)
+			assert.Nil(t, err)
+
+			_, err = engine.Parse(strings.NewReader(source))
+			return err
+		}
+	)
+
+	assert.Nil(t, parse(ParseOptions{}.WithUntrustedInput(), "((x))"))
+
+	// Nesting too deeply
+	err := parse(ParseOptions{MaxDepth: 2}, "(((x)))")
+	assert.Equal(t, LimitError{Err: ErrMaxDepth, Rule: "list", Max: 2, Line: 1, Position: 3}, err)
+
+	// Nesting deeper than the default depth, unless there is no maximum
+	deep := strings.Repeat("(", DefaultMaxDepth) + "x" + strings.Repeat(")", DefaultMaxDepth)
+	err = parse(ParseOptions{}, deep)
+	assert.Equal(t, LimitError{Err: ErrMaxDepth, Rule: "list", Max: DefaultMaxDepth, Line: 1, Position: DefaultMaxDepth + 1}, err)
+	assert.Nil(t, parse(ParseOptions{MaxDepth: -1}, deep))
+
+	// Trying too many rules, where the match of '(' after the second list is the fourth step
+	err = parse(ParseOptions{MaxSteps: 3}, "(((x)))")
+	assert.Equal(t, LimitError{Err: ErrMaxSteps, Rule: "list", Max: 3, Line: 1, Position: 3}, err)
+
+	// Finding too many ways to match, even though memoized rules are only tried once at each offset
+	ambiguous, err := NewEngine(
+		testGrammar(
+			"s = (w)*",
+			"w = l ls",
+			"ls = (l)*",
+			"l = 'a' | 'b'",
+		),
+		ParseOptions{Memo: true, MaxSteps: 1000},
+	)
+	assert.Nil(t, err)
+	_, err = ambiguous.ParseString(strings.Repeat("ab", 20) + "c")
+	assert.Equal(t, ErrMaxSteps, err.(LimitError).Err)
+
+	// Reading too much input
+	assert.Nil(t, parse(ParseOptions{MaxInputSize: 5}, "((x))"))
+	assert.Equal(t, ErrMaxInputSize+": 4", parse(ParseOptions{MaxInputSize: 4}, "((x))").Error())
+
+	engine, err := NewEngine(g, ParseOptions{MaxInputSize: 2})
+	assert.Nil(t, err)
+	_, _, err = engine.ParseOne(strings.NewReader("((x))"))
+	assert.Equal(t, ErrMaxInputSize+": 2", err.Error())
+
+	// Callbacks are rejected
+	_, err = NewEngine(g, ParseOptions{Predicates: map[string]Predicate{"list": nil}}.WithUntrustedInput())
+	assert.Equal(t, ErrNoCallbacks, err.Error())
+
+	_, err = NewEngine(g, ParseOptions{Backend: BackendEarley}.WithUntrustedInput())
+	assert.Equal(t, ErrLimitsBackend, err.Error())
+}
+
+func TestMaxRepetitions(t *testing.T) {
+	var (
+		g = testGrammar(
+			"list = '[' items ']'",
+			"items = (item)*",
+			"item = 'x' | 'yy'",
+		)
+		parse = func(options ParseOptions, source string) error {
+			engine, err := NewEngine(g, options)
+			assert.Nil(t, err)
+
+			_, err = engine.Parse(strings.NewReader(source))
+			return err
+		}
+	)
+
+	// Any alternative can repeat up to the maximum
+	assert.Nil(t, parse(ParseOptions{MaxRepetitions: 3}, "[xyyx]"))
+	err := parse(ParseOptions{MaxRepetitions: 3}, "[xyyxyy]")
+	assert.Equal(t, LimitError{Err: ErrMaxRepetitions, Rule: "items", Max: 3, Line: 1, Position: 6}, err)
+	assert.Equal(t, ErrMaxRepetitions+": items (3) at line 1 position 6", err.Error())
+
+	// The default is high enough for ordinary input, and a negative maximum is no maximum
+	assert.Nil(t, parse(ParseOptions{}, "["+strings.Repeat("x", 1000)+"]"))
+	assert.Nil(t, parse(ParseOptions{MaxRepetitions: -1}, "["+strings.Repeat("x", 1000)+"]"))
+
+	// A lower rule limit wins, and a higher one does not
+	err = parse(ParseOptions{MaxRepetitions: 3, Limits: map[string]RuleLimits{"items": {MaxRepetitions: 2}}}, "[xxx]")
+	assert.Equal(t, LimitError{Err: ErrRuleMaxRepetitions, Rule: "items", Max: 2, Line: 1, Position: 4}, err)
+	err = parse(ParseOptions{MaxRepetitions: 2, Limits: map[string]RuleLimits{"items": {MaxRepetitions: 3}}}, "[xxx]")
+	assert.Equal(t, LimitError{Err: ErrMaxRepetitions, Rule: "items", Max: 2, Line: 1, Position: 4}, err)
+
+	// Only the backtracking backend supports a maximum, and the defaults do not apply to other backends
+	_, err = NewEngine(g, ParseOptions{Backend: BackendEarley, MaxRepetitions: 3})
+	assert.Equal(t, ErrLimitsBackend, err.Error())
+	engine, err := NewEngine(g, ParseOptions{Backend: BackendEarley})
+	assert.Nil(t, err)
+	_, err = engine.ParseString("[xx]")
+	assert.Nil(t, err)
+}
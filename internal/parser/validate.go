@@ -0,0 +1,233 @@
+package parser
+
+import (
+	"fmt"
+)
+
+// Validate runs all semantic checks of the grammar, and returns their diagnostics sorted by SortDiagnostics.
+// The checks are:
+// - DuplicateRules
+// - ReservedNames of DefaultReservedWords
+// - EmptyAlternatives
+// - Repetitions
+// - InvalidOptions
+// - UndefinedRules
+// - UnreachableRules
+// - LeftRecursion
+//
+// A grammar with no diagnostics can be used to parse input.
+func (g Grammar) Validate() []Diagnostic {
+	return g.validate(DefaultReservedWords())
+}
+
+// validate runs all semantic checks of the grammar as described by Validate, with the given reserved words
+func (g Grammar) validate(reserved []string) []Diagnostic {
+	var result []Diagnostic
+
+	for _, check := range []func() []Diagnostic{
+		g.DuplicateRules,
+		func() []Diagnostic { return g.ReservedNames(reserved) },
+		g.EmptyAlternatives,
+		g.Repetitions,
+		g.InvalidOptions,
+		g.UndefinedRules,
+		g.UnreachableRules,
+		g.LeftRecursion,
+	} {
+		result = append(result, check()...)
+	}
+
+	SortDiagnostics(result)
+	return result
+}
+
+// DuplicateRules returns a diagnostic for each rule that has the same name as an earlier rule, at the position of the later rule
+func (g Grammar) DuplicateRules() []Diagnostic {
+	var (
+		defined = map[string]Rule{}
+		result  []Diagnostic
+	)
+
+	for _, rule := range g.rules {
+		if first, isDefined := defined[rule.name]; isDefined {
+			details := fmt.Sprintf("%s, first defined at line %d position %d", rule.name, first.line, first.position)
+			result = append(result, newDiagnostic(DiagDuplicateRule, details, rule.SourceNode))
+			continue
+		}
+
+		defined[rule.name] = rule
+	}
+
+	return result
+}
+
+// EmptyAlternatives returns a diagnostic if the grammar has no rules, for each rule that has no alternatives, which is
+// DiagOptionsOnly if the rule has options, and for each alternative that has no list items.
+// None of them can match input: an empty grammar has no start rule, and an empty rule or alternative would match
+// nothing, which is expressed instead by making the items that refer to it optional.
+func (g Grammar) EmptyAlternatives() []Diagnostic {
+	var result []Diagnostic
+
+	if len(g.rules) == 0 {
+		result = append(result, newDiagnostic(DiagEmptyGrammar, "", g.SourceNode))
+	}
+
+	for _, rule := range g.rules {
+		if len(rule.expr.items) == 0 {
+			code := DiagEmptyRule
+			if len(rule.options) > 0 {
+				code = DiagOptionsOnly
+			}
+			result = append(result, newDiagnostic(code, rule.name, rule.SourceNode))
+			continue
+		}
+
+		for _, alt := range rule.expr.items {
+			if len(alt.list) == 0 {
+				result = append(result, newDiagnostic(DiagEmptyAlt, "in rule "+rule.name, alt.SourceNode))
+			}
+		}
+	}
+
+	return result
+}
+
+// Repetitions returns a diagnostic for each alternative whose repetitions are impossible, such as {0,0} or {3,2}
+func (g Grammar) Repetitions() []Diagnostic {
+	var result []Diagnostic
+
+	for _, rule := range g.rules {
+		for _, alt := range rule.expr.items {
+			if (alt.n < 0) || ((alt.m != -1) && ((alt.m < alt.n) || (alt.m < 1))) {
+				details := fmt.Sprintf("{%d,%d} in rule %s", alt.n, alt.m, rule.name)
+				result = append(result, newDiagnostic(DiagRepetition, details, alt.SourceNode))
+			}
+		}
+	}
+
+	return result
+}
+
+// InvalidOptions returns a diagnostic for each rule that has options other than one of :MEMO or :NOMEMO, :INDEPENDENT,
+// and :KEYWORD, each independent rule that does not end with the same string in every alternative,
+// each keyword rule that is not a token rule of one string,
+// each list item that has a :MEMO, :NOMEMO, :INDEPENDENT, or :KEYWORD option, and each list item that has a :CONSTTIME
+// option and is not a string terminal
+func (g Grammar) InvalidOptions() []Diagnostic {
+	var result []Diagnostic
+
+	for _, rule := range g.rules {
+		var memos, independents, keywords, others int
+		for _, option := range rule.options {
+			switch option {
+			case OptionMemo, OptionNoMemo:
+				memos++
+			case OptionIndependent:
+				independents++
+			case OptionKeyword:
+				keywords++
+			default:
+				others++
+			}
+		}
+
+		if (memos > 1) || (independents > 1) || (keywords > 1) || (others > 0) {
+			result = append(result, newDiagnostic(DiagRuleOption, rule.name, rule.SourceNode))
+		} else if _, ok := rule.separator(); (independents == 1) && !ok {
+			result = append(result, newDiagnostic(DiagIndependent, rule.name, rule.SourceNode))
+		} else if _, ok := rule.literal(); (keywords == 1) && !ok {
+			result = append(result, newDiagnostic(DiagKeyword, rule.name, rule.SourceNode))
+		}
+
+		for _, alt := range rule.expr.items {
+			for _, item := range alt.list {
+				for _, option := range item.options {
+					if (option == OptionMemo) || (option == OptionNoMemo) || (option == OptionIndependent) || (option == OptionKeyword) {
+						result = append(result, newDiagnostic(DiagItemOption, "in rule "+rule.name, item.SourceNode))
+						break
+					}
+				}
+
+				if item.ConstantTime() && !(item.IsTerminal() && item.terminal.IsString()) {
+					result = append(result, newDiagnostic(DiagConstTime, "in rule "+rule.name, item.SourceNode))
+				}
+			}
+		}
+	}
+
+	return result
+}
+
+// UndefinedRules returns a diagnostic for each list item that refers to a rule name that is not defined,
+// at the position of the list item. The message suggests the defined names closest to the name, if any are close.
+func (g Grammar) UndefinedRules() []Diagnostic {
+	var (
+		defined = map[string]bool{}
+		names   []string
+		result  []Diagnostic
+	)
+
+	for _, rule := range g.rules {
+		if !defined[rule.name] {
+			defined[rule.name] = true
+			names = append(names, rule.name)
+		}
+	}
+
+	for _, rule := range g.rules {
+		for _, alt := range rule.expr.items {
+			for _, item := range alt.list {
+				if item.IsRuleName() && !defined[item.ruleName] {
+					details := item.ruleName
+					if suggestion := didYouMean(suggestNames(item.ruleName, names)); suggestion != "" {
+						details += " (" + suggestion + ")"
+					}
+
+					result = append(result, newDiagnostic(DiagUndefinedRule, details, item.SourceNode))
+				}
+			}
+		}
+	}
+
+	return result
+}
+
+// UnreachableRules returns a diagnostic for each rule that cannot be reached from the start rule, at the position of the rule.
+// The start rule is the first rule of the grammar.
+func (g Grammar) UnreachableRules() []Diagnostic {
+	var (
+		byName  = rulesByName(g.rules)
+		reached = map[string]bool{}
+		visit   func(name string)
+		result  []Diagnostic
+	)
+
+	if len(g.rules) == 0 {
+		return nil
+	}
+
+	visit = func(name string) {
+		rule, defined := byName[name]
+		if reached[name] || !defined {
+			return
+		}
+		reached[name] = true
+
+		for _, alt := range rule.expr.items {
+			for _, item := range alt.list {
+				if item.IsRuleName() {
+					visit(item.ruleName)
+				}
+			}
+		}
+	}
+	visit(g.rules[0].name)
+
+	for _, rule := range g.rules {
+		if !reached[rule.name] {
+			result = append(result, newDiagnostic(DiagUnusedRule, rule.name, rule.SourceNode))
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,221 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUndefinedRules(t *testing.T) {
+	g := testGrammar(
+		"a = b 'x' | a",
+		"b = 'y'",
+	)
+	assert.Nil(t, g.UndefinedRules())
+
+	g = testGrammar(
+		"a = b 'x' | c",
+		"b = d 'y' c",
+	)
+	g.rules[0].expr.items[1].list[0].SourceNode = OfSourceNodeAt("c", 1, 13)
+	g.rules[1].expr.items[0].list[0].SourceNode = OfSourceNodeAt("d", 2, 5)
+	g.rules[1].expr.items[0].list[2].SourceNode = OfSourceNodeAt("c", 2, 11)
+
+	diags := g.UndefinedRules()
+	assert.Equal(t, 3, len(diags))
+	assert.Equal(t, DiagUndefinedRule, diags[0].Code())
+	assert.Equal(t, "Undefined rule c at line 1 position 13", diags[0].String())
+	assert.Equal(t, "Undefined rule d at line 2 position 5", diags[1].String())
+	assert.Equal(t, "Undefined rule c at line 2 position 11", diags[2].String())
+
+	// The closest defined names are suggested
+	g = testGrammar(
+		"expr = term | term '+' expr",
+		"term = factr",
+		"factor = [0123456789] | '(' exp ')'",
+	)
+	diags = g.UndefinedRules()
+	assert.Equal(t, 2, len(diags))
+	assert.Equal(t, "Undefined rule factr (did you mean factor?)", diags[0].Message())
+	assert.Equal(t, "Undefined rule exp (did you mean expr?)", diags[1].Message())
+}
+
+func TestUnreachableRules(t *testing.T) {
+	assert.Nil(t, Grammar{}.UnreachableRules())
+
+	g := testGrammar(
+		"a = b 'x' | c",
+		"b = b 'y' | undefined",
+		"c = 'z'",
+	)
+	assert.Nil(t, g.UnreachableRules())
+
+	g = testGrammar(
+		"a = b 'x'",
+		"b = 'y'",
+		"c = d | a",
+		"d = c",
+	)
+	g.rules[2].SourceNode = OfSourceNodeAt(g.rules[2].String(), 3, 1)
+	g.rules[3].SourceNode = OfSourceNodeAt(g.rules[3].String(), 4, 1)
+
+	diags := g.UnreachableRules()
+	assert.Equal(t, 2, len(diags))
+	assert.Equal(t, DiagUnusedRule, diags[0].Code())
+	assert.Equal(t, "Unreachable rule c at line 3 position 1", diags[0].String())
+	assert.Equal(t, "Unreachable rule d at line 4 position 1", diags[1].String())
+}
+
+func TestDuplicateRules(t *testing.T) {
+	g := testGrammar(
+		"a = b",
+		"b = 'x'",
+		"a = 'y'",
+	)
+	g.rules[0].SourceNode = OfSourceNodeAt(g.rules[0].String(), 1, 1)
+	g.rules[2].SourceNode = OfSourceNodeAt(g.rules[2].String(), 3, 1)
+
+	diags := g.DuplicateRules()
+	assert.Equal(t, 1, len(diags))
+	assert.Equal(t, DiagDuplicateRule, diags[0].Code())
+	assert.Equal(t, "Duplicate rule a, first defined at line 1 position 1 at line 3 position 1", diags[0].String())
+}
+
+func TestEmptyAlternatives(t *testing.T) {
+	g := testGrammar("a = 'x'")
+	g.rules = append(
+		g.rules,
+		OfRule("b =", "b", OfExpression("", nil)),
+		newRule("c", nil, newExpression([]ExpressionItem{newExpressionItem(nil, 1, 1), newExpressionItem([]ListItem{newListItemRuleName("a")}, 1, 1)})),
+	)
+
+	diags := g.EmptyAlternatives()
+	assert.Equal(t, 2, len(diags))
+	assert.Equal(t, DiagEmptyRule, diags[0].Code())
+	assert.Equal(t, "A rule must have at least one alternative b", diags[0].Message())
+	assert.Equal(t, DiagEmptyAlt, diags[1].Code())
+	assert.Equal(t, "An alternative must have at least one list item in rule c", diags[1].Message())
+
+	// A rule of only options
+	g.rules[1].options = []Option{OptionMemo}
+	diags = g.EmptyAlternatives()
+	assert.Equal(t, DiagOptionsOnly, diags[0].Code())
+	assert.Equal(t, "A rule with options must have at least one alternative for them to apply to b", diags[0].Message())
+
+	// A grammar of no rules
+	diags = OfGrammar("", nil).EmptyAlternatives()
+	assert.Equal(t, 1, len(diags))
+	assert.Equal(t, DiagEmptyGrammar, diags[0].Code())
+	assert.Equal(t, "A grammar must have at least one rule", diags[0].Message())
+	assert.Equal(t, diags, OfGrammar("", nil).Validate())
+
+	for _, backend := range []Backend{BackendBacktrack, BackendEarley} {
+		_, err := NewEngine(OfGrammar("", nil), ParseOptions{Backend: backend})
+		assert.Equal(t, diags[0], err)
+	}
+
+	_, err := CompileString("", CompileOptions{Dialect: DialectANTLR, Validate: true})
+	assert.Equal(t, "A grammar must have at least one rule at line 1 position 1", err.Error())
+}
+
+func TestRepetitions(t *testing.T) {
+	g := testGrammar("a = ('x'){0,0} | ('x'){2,1} | ('x'){0,-1} | ('x'){-1,1} | ('x'){3,3} | ('x'){2,-2}")
+
+	diags := g.Repetitions()
+	assert.Equal(t, 4, len(diags))
+	for _, diag := range diags {
+		assert.Equal(t, DiagRepetition, diag.Code())
+	}
+	assert.Contains(t, diags[0].Message(), "{0,0} in rule a")
+	assert.Contains(t, diags[1].Message(), "{2,1} in rule a")
+	assert.Contains(t, diags[2].Message(), "{-1,1} in rule a")
+	assert.Contains(t, diags[3].Message(), "{2,-2} in rule a")
+}
+
+func TestInvalidOptions(t *testing.T) {
+	g := testGrammar(
+		"a:MEMO = b",
+		"b:NOMEMO = 'x'",
+		"c:MEMO:NOMEMO = 'x'",
+		"d:AST = 'x'",
+		"e = 'x' 'y'",
+		"IF:KEYWORD:MEMO = 'if'",
+	)
+	g.rules[4].expr.items[0].list[1].options = []Option{OptionEOL, OptionMemo}
+
+	diags := g.InvalidOptions()
+	assert.Equal(t, 3, len(diags))
+	assert.Equal(t, DiagRuleOption, diags[0].Code())
+	assert.Equal(t, "A rule can only have one of the options :MEMO or :NOMEMO, the option :INDEPENDENT, and the option :KEYWORD c", diags[0].Message())
+	assert.Equal(t, DiagRuleOption, diags[1].Code())
+	assert.Equal(t, "A rule can only have one of the options :MEMO or :NOMEMO, the option :INDEPENDENT, and the option :KEYWORD d", diags[1].Message())
+	assert.Equal(t, DiagItemOption, diags[2].Code())
+	assert.Equal(t, "The options :MEMO, :NOMEMO, :INDEPENDENT, and :KEYWORD can only be used on rules in rule e", diags[2].Message())
+
+	// An independent rule can also be memoized, and must end with the same string in every alternative
+	g = testGrammar(
+		"a:INDEPENDENT:MEMO = b ';' | 'x' ';'",
+		"b:INDEPENDENT = 'x' ';' | 'y'",
+		"c:INDEPENDENT = ('x' ';')+",
+		"d:INDEPENDENT:INDEPENDENT = 'x' ';'",
+	)
+	diags = g.InvalidOptions()
+	assert.Equal(t, 3, len(diags))
+	assert.Equal(t, DiagIndependent, diags[0].Code())
+	assert.Equal(t, "Every alternative of an independent rule must match once and end with the same string b", diags[0].Message())
+	assert.Equal(t, DiagIndependent, diags[1].Code())
+	assert.Contains(t, diags[1].Message(), " c")
+	assert.Equal(t, DiagRuleOption, diags[2].Code())
+}
+
+func TestValidate(t *testing.T) {
+	g := testGrammar(
+		"a = b | ('x'){0,0}",
+		"b = b 'y' | c",
+		"a = 'z'",
+		"d = 'w'",
+	)
+
+	var codes []string
+	for _, diag := range g.Validate() {
+		codes = append(codes, diag.Code())
+	}
+	// The test grammar has no positions, so diagnostics are sorted by code
+	assert.Equal(t, []string{DiagDuplicateRule, DiagLeftRecursion, DiagRepetition, DiagUndefinedRule, DiagUnusedRule}, codes)
+
+	assert.Nil(t, testGrammar("a = 'x' b", "b = 'y'").Validate())
+}
+
+func TestSortDiagnostics(t *testing.T) {
+	var (
+		at = func(code string, line, position int) Diagnostic {
+			return newDiagnostic(code, "", OfSourceNodeAt("", line, position))
+		}
+		diags = []Diagnostic{
+			at(DiagUnusedRule, 2, 1),
+			at(DiagUndefinedRule, 1, 5),
+			at(DiagLeftRecursion, 1, 5),
+			newDiagnostic(DiagEmptyAlt, "second", OfSourceNodeAt("", 1, 1)),
+			newDiagnostic(DiagEmptyAlt, "first", OfSourceNodeAt("", 1, 1)),
+			newDiagnostic(DiagUnusedRule, "", OfSourceNodeAt("", 1, 1).inFile("base")),
+			newDiagnostic(DiagEmptyAlt, "", OfSourceNodeAt("", 1, 1).inFile("core")),
+		}
+	)
+
+	SortDiagnostics(diags)
+	assert.Equal(
+		t,
+		[]Diagnostic{
+			newDiagnostic(DiagEmptyAlt, "second", OfSourceNodeAt("", 1, 1)),
+			newDiagnostic(DiagEmptyAlt, "first", OfSourceNodeAt("", 1, 1)),
+			at(DiagLeftRecursion, 1, 5),
+			at(DiagUndefinedRule, 1, 5),
+			at(DiagUnusedRule, 2, 1),
+			newDiagnostic(DiagUnusedRule, "", OfSourceNodeAt("", 1, 1).inFile("base")),
+			newDiagnostic(DiagEmptyAlt, "", OfSourceNodeAt("", 1, 1).inFile("core")),
+		},
+		diags,
+	)
+	assert.Equal(t, "base", diags[5].File())
+	assert.Equal(t, "Unreachable rule in base at line 1 position 1", diags[5].String())
+}
@@ -1 +1,85 @@
 package parser
+
+// Visitor is called for each node of a parse tree by Walk
+type Visitor interface {
+	// Visit is called before the children of a node are visited, and returns false to skip the children
+	Visit(node Node) bool
+	// Leave is called after the children of a node are visited, or skipped
+	Leave(node Node)
+}
+
+// Walk calls the visitor for a node and its descendants, in depth first order
+func Walk(node Node, visitor Visitor) {
+	if visitor.Visit(node) {
+		for _, child := range node.children {
+			Walk(child, visitor)
+		}
+	}
+
+	visitor.Leave(node)
+}
+
+// WalkPreOrder calls visit for a node and its descendants, each node before its children.
+// Children are skipped if visit returns false.
+func WalkPreOrder(node Node, visit func(Node) bool) {
+	Walk(node, VisitorFuncs{OnVisit: visit})
+}
+
+// WalkPostOrder calls leave for a node and its descendants, each node after its children
+func WalkPostOrder(node Node, leave func(Node)) {
+	Walk(node, VisitorFuncs{OnLeave: leave})
+}
+
+// VisitorFuncs is a Visitor made of functions, where a nil function is not called, and a nil OnVisit visits all children
+type VisitorFuncs struct {
+	OnVisit func(Node) bool
+	OnLeave func(Node)
+}
+
+// Visit is the Visitor interface
+func (v VisitorFuncs) Visit(node Node) bool {
+	if v.OnVisit == nil {
+		return true
+	}
+
+	return v.OnVisit(node)
+}
+
+// Leave is the Visitor interface
+func (v VisitorFuncs) Leave(node Node) {
+	if v.OnLeave != nil {
+		v.OnLeave(node)
+	}
+}
+
+// RuleVisitor is a Visitor that dispatches each node to functions for its rule name.
+// Terminals are dispatched to OnTerminal. A missing or nil function is not called, and children are visited if there is
+// no visit function for a node.
+type RuleVisitor struct {
+	OnVisit    map[string]func(Node) bool
+	OnLeave    map[string]func(Node)
+	OnTerminal func(Node)
+}
+
+// Visit is the Visitor interface
+func (v RuleVisitor) Visit(node Node) bool {
+	if node.IsTerminal() {
+		if v.OnTerminal != nil {
+			v.OnTerminal(node)
+		}
+		return true
+	}
+
+	if visit := v.OnVisit[node.rule]; visit != nil {
+		return visit(node)
+	}
+
+	return true
+}
+
+// Leave is the Visitor interface
+func (v RuleVisitor) Leave(node Node) {
+	if leave := v.OnLeave[node.rule]; (leave != nil) && !node.IsTerminal() {
+		leave(node)
+	}
+}
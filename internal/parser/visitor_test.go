@@ -0,0 +1,84 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testTree returns the tree of a(b('x') c(b('y')) 'z')
+func testTree() Node {
+	var (
+		x  = Node{text: "x", start: 0, end: 1}
+		y  = Node{text: "y", start: 1, end: 2}
+		z  = Node{text: "z", start: 2, end: 3}
+		b1 = Node{rule: "b", text: "x", start: 0, end: 1, children: []Node{x}}
+		b2 = Node{rule: "b", text: "y", start: 1, end: 2, children: []Node{y}}
+		c  = Node{rule: "c", text: "y", start: 1, end: 2, children: []Node{b2}}
+	)
+
+	return Node{rule: "a", text: "xyz", start: 0, end: 3, children: []Node{b1, c, z}}
+}
+
+// nodeName is the rule name of a node, or the quoted text of a terminal
+func nodeName(node Node) string {
+	if node.IsTerminal() {
+		return "'" + node.text + "'"
+	}
+
+	return node.rule
+}
+
+func TestWalk(t *testing.T) {
+	var order []string
+	Walk(testTree(), VisitorFuncs{
+		OnVisit: func(node Node) bool {
+			order = append(order, "visit "+nodeName(node))
+			return node.rule != "c"
+		},
+		OnLeave: func(node Node) {
+			order = append(order, "leave "+nodeName(node))
+		},
+	})
+	assert.Equal(
+		t,
+		[]string{
+			"visit a", "visit b", "visit 'x'", "leave 'x'", "leave b", "visit c", "leave c", "visit 'z'", "leave 'z'", "leave a",
+		},
+		order,
+	)
+
+	order = nil
+	WalkPreOrder(testTree(), func(node Node) bool {
+		order = append(order, nodeName(node))
+		return true
+	})
+	assert.Equal(t, []string{"a", "b", "'x'", "c", "b", "'y'", "'z'"}, order)
+
+	order = nil
+	WalkPostOrder(testTree(), func(node Node) {
+		order = append(order, nodeName(node))
+	})
+	assert.Equal(t, []string{"'x'", "b", "'y'", "b", "c", "'z'", "a"}, order)
+}
+
+func TestRuleVisitor(t *testing.T) {
+	var order []string
+	Walk(testTree(), RuleVisitor{
+		OnVisit: map[string]func(Node) bool{
+			"c": func(node Node) bool {
+				order = append(order, "visit c")
+				return true
+			},
+		},
+		OnLeave: map[string]func(Node){
+			"b": func(node Node) {
+				order = append(order, "leave b "+node.text)
+			},
+		},
+		OnTerminal: func(node Node) {
+			order = append(order, "terminal "+node.text)
+		},
+	})
+	assert.Equal(t, []string{"terminal x", "leave b x", "visit c", "terminal y", "leave b y", "terminal z"}, order)
+}
@@ -0,0 +1,560 @@
+package parser
+
+import (
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+// W3C EBNF import error message constants
+const (
+	ErrW3CSyntax = "Invalid W3C EBNF grammar"
+)
+
+// w3cTokenType is the type of a token of a W3C EBNF grammar
+type w3cTokenType uint
+
+const (
+	w3cEOF w3cTokenType = iota
+	w3cName
+	w3cString
+	w3cCharClass
+	w3cPunct
+)
+
+// w3cToken is a token of a W3C EBNF grammar
+type w3cToken struct {
+	tokenType w3cTokenType
+	// name, string, raw text of a character class between the brackets, or punctuation
+	text     string
+	charSet  RuneSet
+	line     int
+	position int
+	// span of source of the token
+	start, end SourcePosition
+}
+
+// w3cExprKind is the kind of a W3C EBNF expression
+type w3cExprKind uint
+
+const (
+	w3cRef w3cExprKind = iota
+	w3cLiteral
+	w3cSet
+	w3cGroup
+	w3cExcept
+)
+
+// w3cExpr is an element of a W3C EBNF sequence: a reference, string, character set, group, or A - B, with a repetition
+type w3cExpr struct {
+	kind     w3cExprKind
+	text     string
+	charSet  RuneSet
+	alts     [][]w3cExpr
+	except   []w3cExpr
+	n, m     int
+	line     int
+	position int
+	// span of source of the expression
+	start, end SourcePosition
+}
+
+// w3cRule is a W3C EBNF production
+type w3cRule struct {
+	name       string
+	alts       [][]w3cExpr
+	line       int
+	position   int
+	start, end SourcePosition
+}
+
+// w3cParser parses W3C EBNF tokens into rules
+type w3cParser struct {
+	tokens []w3cToken
+	index  int
+	rules  map[string]w3cRule
+	// end of the last token consumed
+	end SourcePosition
+}
+
+// ImportW3CEBNF reads a grammar in the EBNF notation of the W3C XML specification, as used by the XML, XPath, and SPARQL
+// specifications, and converts its productions to a Grammar, in the order they are defined.
+//
+// Supported constructs are names, quoted strings, #xN characters, character classes including negated classes and #xN ranges,
+// parentheses, |, the ?, *, and + suffixes, and A - B.
+// Production numbers such as [1], well-formedness and validity constraints such as [ wfc: Name ], and /* comments */ are ignored.
+//
+// A negated class matches the characters of the Basic Multilingual Plane, other than NUL and surrogates, that are not in the class.
+// A - B is converted to a character range if A and B are characters, character classes, or rules that are alternatives of them.
+// Otherwise, such as Char* - (Char* '?>' Char*), the exception cannot be expressed by a Grammar, and only A is converted,
+// so the grammar accepts more input than the specification.
+//
+// Groups and repetitions are converted the same way as ImportANTLR, into new rules named after the enclosing rule,
+// such as Name-part and Name-part-2.
+//
+// Returns an error if the grammar is invalid.
+func ImportW3CEBNF(source io.Reader) (Grammar, error) {
+	return importW3CEBNF(source, PositionOptions{})
+}
+
+// importW3CEBNF is ImportW3CEBNF, where the positions of the grammar follow the options
+func importW3CEBNF(source io.Reader, positions PositionOptions) (grammar Grammar, err error) {
+	// The conversion shares the ANTLR importer's error handling
+	defer func() {
+		if r := recover(); r != nil {
+			if e, isa := r.(antlrError); isa {
+				err = e.err
+				return
+			}
+			panic(r)
+		}
+	}()
+
+	data, err := ioutil.ReadAll(source)
+	if err != nil {
+		return Grammar{}, err
+	}
+
+	var (
+		p     = &w3cParser{tokens: w3cTokens([]rune(string(data)), positions), rules: map[string]w3cRule{}}
+		rules []w3cRule
+		names = map[string]bool{}
+	)
+	for rule, ok := p.parseRule(); ok; rule, ok = p.parseRule() {
+		rules = append(rules, rule)
+		names[rule.name] = true
+		if _, haveIt := p.rules[rule.name]; !haveIt {
+			p.rules[rule.name] = rule
+		}
+	}
+
+	var result []Rule
+	for _, rule := range rules {
+		conv := &antlrConverter{ruleName: rule.name, names: names}
+		items := conv.alternatives(p.elements(rule.alts), rule.line, rule.position)
+
+		converted := newRule(rule.name, nil, conv.expression(items))
+		converted.SourceNode = OfSourceNodeSpan(converted.sourceString, rule.start, rule.end)
+		result = append(append(result, converted), conv.parts...)
+	}
+
+	return newSpannedGrammar(result, p.tokens[len(p.tokens)-1].end), nil
+}
+
+// ==== Lexer
+
+// isW3CNameStart returns true if a rune can begin a name
+func isW3CNameStart(char rune) bool {
+	return ((char >= 'a') && (char <= 'z')) || ((char >= 'A') && (char <= 'Z')) || (char == '_')
+}
+
+// isW3CNameChar returns true if a rune can be part of a name
+func isW3CNameChar(char rune) bool {
+	return isW3CNameStart(char) || ((char >= '0') && (char <= '9'))
+}
+
+// w3cTokens splits a W3C EBNF grammar into tokens, ending with EOF, skipping whitespace, comments, and constraints
+func w3cTokens(input []rune, opts PositionOptions) []w3cToken {
+	var (
+		tokens    []w3cToken
+		positions = sourcePositions(input, opts)
+		i         int
+		advance   = func(count int) {
+			if i += count; i > len(input) {
+				i = len(input)
+			}
+		}
+		// hex reads #xN at the current rune
+		hex = func() rune {
+			start := i
+			advance(2)
+			for (i < len(input)) && strings.ContainsRune("0123456789abcdefABCDEF", input[i]) {
+				advance(1)
+			}
+
+			value, err := strconv.ParseUint(string(input[start+2:i]), 16, 32)
+			if err != nil {
+				antlrFail(ErrW3CSyntax, "invalid character", positions[start].Line, positions[start].Position)
+			}
+
+			return rune(value)
+		}
+	)
+
+	for {
+		token := w3cToken{line: positions[i].Line, position: positions[i].Position, start: positions[i], end: positions[i]}
+
+		switch {
+		case i >= len(input):
+			token.tokenType = w3cEOF
+			return append(tokens, token)
+
+		case (input[i] == ' ') || (input[i] == '\t') || (input[i] == '\r') || (input[i] == '\n'):
+			advance(1)
+			continue
+
+		case strings.HasPrefix(string(input[i:]), "/*"):
+			end := strings.Index(string(input[i+2:]), "*/")
+			if end < 0 {
+				antlrFail(ErrW3CSyntax, "unterminated comment", positions[i].Line, positions[i].Position)
+			}
+			advance(len([]rune(string(input[i+2:])[:end])) + 4)
+			continue
+
+		case isW3CNameStart(input[i]):
+			start := i
+			for (i < len(input)) && isW3CNameChar(input[i]) {
+				advance(1)
+			}
+			if (string(input[start:i]) == "super") && isSuperDot(input, i, isW3CNameStart) {
+				for advance(1); (i < len(input)) && isW3CNameChar(input[i]); {
+					advance(1)
+				}
+			}
+			token.tokenType, token.text = w3cName, string(input[start:i])
+
+		case (input[i] == '"') || (input[i] == '\''):
+			quote := input[i]
+			advance(1)
+			start := i
+			for (i < len(input)) && (input[i] != quote) {
+				advance(1)
+			}
+			if i >= len(input) {
+				antlrFail(ErrW3CSyntax, "unterminated string", token.line, token.position)
+			}
+			token.tokenType, token.text = w3cString, string(input[start:i])
+			advance(1)
+
+			if token.text == "" {
+				antlrFail(ErrW3CSyntax, "empty string", token.line, token.position)
+			}
+
+		case strings.HasPrefix(string(input[i:]), "#x"):
+			start := i
+			token.tokenType, token.charSet = w3cCharClass, OfRuneSetRunes(hex())
+			token.text = string(input[start:i])
+
+		case input[i] == '[':
+			end := strings.IndexRune(string(input[i:]), ']')
+			if end < 0 {
+				antlrFail(ErrW3CSyntax, "unterminated character class", positions[i].Line, positions[i].Position)
+			}
+
+			// Constraints and annotations are skipped
+			content := string(input[i+1:])[:end-1]
+			if lower := strings.ToLower(strings.TrimSpace(content)); strings.HasPrefix(lower, "wfc:") || strings.HasPrefix(lower, "vc:") ||
+				strings.HasPrefix(lower, "ws:") || strings.HasPrefix(lower, "gn:") || strings.HasPrefix(lower, "xgc:") {
+				advance(len([]rune(content)) + 2)
+				continue
+			}
+
+			token.tokenType, token.text, token.charSet = w3cCharClass, content, w3cCharClassSet(input, &i, advance, hex)
+
+		case strings.HasPrefix(string(input[i:]), "::="):
+			token.tokenType, token.text = w3cPunct, "::="
+			advance(3)
+
+		case strings.ContainsRune("()|-?*+", input[i]):
+			token.tokenType, token.text = w3cPunct, string(input[i])
+			advance(1)
+
+		default:
+			antlrFail(ErrW3CSyntax, strconv.Quote(string(input[i])), positions[i].Line, positions[i].Position)
+		}
+
+		token.end = positions[i]
+		tokens = append(tokens, token)
+	}
+}
+
+// w3cCharClassSet reads a character class at input[*i], which is a [ followed by a ], returning its characters
+func w3cCharClassSet(input []rune, i *int, advance func(int), hex func() rune) RuneSet {
+	var (
+		intervals []RuneInterval
+		negated   bool
+		char      = func() rune {
+			if strings.HasPrefix(string(input[*i:]), "#x") {
+				return hex()
+			}
+
+			char := input[*i]
+			advance(1)
+			return char
+		}
+	)
+
+	advance(1)
+	if input[*i] == '^' {
+		negated = true
+		advance(1)
+	}
+
+	for input[*i] != ']' {
+		from := char()
+		to := from
+		if (input[*i] == '-') && (input[*i+1] != ']') {
+			advance(1)
+			to = char()
+		}
+
+		intervals = append(intervals, RuneInterval{from, to})
+	}
+	advance(1)
+
+	// A negated class excludes characters from the Basic Multilingual Plane without NUL and surrogates
+	set := OfRuneSet(intervals...)
+	if negated {
+		set = OfRuneSet(RuneInterval{1, 0xD7FF}, RuneInterval{0xE000, 0xFFFF}).Subtract(set)
+	}
+
+	return set
+}
+
+// ==== Parser
+
+// peek returns the token at an offset from the next token, EOF if there are no more
+func (p *w3cParser) peek(offset int) w3cToken {
+	if p.index+offset >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+
+	return p.tokens[p.index+offset]
+}
+
+// next consumes and returns the next token
+func (p *w3cParser) next() w3cToken {
+	token := p.peek(0)
+	if token.tokenType != w3cEOF {
+		p.index++
+	}
+
+	p.end = token.end
+	return token
+}
+
+// isPunct returns true if a token is the given punctuation
+func (t w3cToken) isPunct(punct string) bool {
+	return (t.tokenType == w3cPunct) && (t.text == punct)
+}
+
+// describe returns a description of a token for error messages
+func (t w3cToken) describe() string {
+	switch t.tokenType {
+	case w3cEOF:
+		return "EOF"
+	case w3cName:
+		return t.text
+	case w3cString:
+		return stringSource(t.text)
+	case w3cCharClass:
+		return "character class"
+	}
+
+	return "'" + t.text + "'"
+}
+
+// atProduction returns true if the next tokens begin a production, which is an optional number such as [1], a name, and ::=
+func (p *w3cParser) atProduction() bool {
+	offset := 0
+	if token := p.peek(0); (token.tokenType == w3cCharClass) && (strings.Trim(token.text, "0123456789abcdefghijklmnopqrstuvwxyz") == "") {
+		offset = 1
+	}
+
+	return (p.peek(offset).tokenType == w3cName) && p.peek(offset+1).isPunct("::=")
+}
+
+// parseRule parses the next production, returning false at EOF
+func (p *w3cParser) parseRule() (w3cRule, bool) {
+	if p.peek(0).tokenType == w3cEOF {
+		return w3cRule{}, false
+	}
+
+	if !p.atProduction() {
+		token := p.peek(0)
+		antlrFail(ErrW3CSyntax, "expected a production, found "+token.describe(), token.line, token.position)
+	}
+	if p.peek(0).tokenType == w3cCharClass {
+		p.next()
+	}
+
+	name := p.next()
+	p.next()
+
+	rule := w3cRule{name: name.text, alts: p.parseAlternatives(), line: name.line, position: name.position, start: name.start}
+	rule.end = p.end
+
+	return rule, true
+}
+
+// parseAlternatives parses sequences separated by |
+func (p *w3cParser) parseAlternatives() [][]w3cExpr {
+	alts := [][]w3cExpr{p.parseSequence()}
+	for p.peek(0).isPunct("|") {
+		p.next()
+		alts = append(alts, p.parseSequence())
+	}
+
+	return alts
+}
+
+// parseSequence parses the elements of a sequence, up to |, ), EOF, or the next production
+func (p *w3cParser) parseSequence() []w3cExpr {
+	var seq []w3cExpr
+
+	for token := p.peek(0); !token.isPunct("|") && !token.isPunct(")") && (token.tokenType != w3cEOF) && !p.atProduction(); token = p.peek(0) {
+		elem := p.parsePostfix()
+		if p.peek(0).isPunct("-") {
+			minus := p.next()
+			elem = w3cExpr{kind: w3cExcept, alts: [][]w3cExpr{{elem}}, except: []w3cExpr{p.parsePostfix()}, n: 1, m: 1, line: minus.line, position: minus.position, start: elem.start}
+			elem.end = p.end
+		}
+
+		seq = append(seq, elem)
+	}
+
+	if len(seq) == 0 {
+		token := p.peek(0)
+		antlrFail(ErrW3CSyntax, "expected an expression, found "+token.describe(), token.line, token.position)
+	}
+
+	return seq
+}
+
+// parsePostfix parses a primary expression and its suffix
+func (p *w3cParser) parsePostfix() w3cExpr {
+	token := p.next()
+	elem := w3cExpr{n: 1, m: 1, line: token.line, position: token.position, start: token.start}
+
+	switch {
+	case token.tokenType == w3cName:
+		elem.kind, elem.text = w3cRef, token.text
+	case token.tokenType == w3cString:
+		elem.kind, elem.text = w3cLiteral, token.text
+	case token.tokenType == w3cCharClass:
+		elem.kind, elem.charSet = w3cSet, token.charSet
+	case token.isPunct("("):
+		elem.kind, elem.alts = w3cGroup, p.parseAlternatives()
+		if next := p.next(); !next.isPunct(")") {
+			antlrFail(ErrW3CSyntax, "expected ')', found "+next.describe(), next.line, next.position)
+		}
+	default:
+		antlrFail(ErrW3CSyntax, "unexpected "+token.describe(), token.line, token.position)
+	}
+
+	switch next := p.peek(0); {
+	case next.isPunct("?"):
+		elem.n, elem.m = 0, 1
+	case next.isPunct("*"):
+		elem.n, elem.m = 0, -1
+	case next.isPunct("+"):
+		elem.n, elem.m = 1, -1
+	default:
+		elem.end = p.end
+		return elem
+	}
+	p.next()
+
+	elem.end = p.end
+	return elem
+}
+
+// ==== Converter
+
+// elements converts W3C alternatives to ANTLR elements, so they can be converted to rules the same way
+func (p *w3cParser) elements(alts [][]w3cExpr) [][]antlrElement {
+	result := make([][]antlrElement, len(alts))
+
+	for i, alt := range alts {
+		for _, expr := range alt {
+			result[i] = append(result[i], p.element(expr))
+		}
+	}
+
+	return result
+}
+
+// element converts a W3C expression to an ANTLR element
+func (p *w3cParser) element(expr w3cExpr) antlrElement {
+	elem := antlrElement{n: expr.n, m: expr.m, start: expr.start, end: expr.end}
+
+	switch expr.kind {
+	case w3cRef:
+		elem.ruleName = expr.text
+	case w3cLiteral:
+		elem.terminal = OfTerminalString(stringSource(expr.text), expr.text)
+	case w3cSet:
+		elem.terminal = OfTerminalRuneSet(rangeSource(expr.charSet), expr.charSet)
+	case w3cGroup:
+		elem.isGroup, elem.group = true, p.elements(expr.alts)
+	default:
+		set, isSet := p.charSet(expr, map[string]bool{})
+		if !isSet {
+			// The exception cannot be expressed, so only the left side is kept
+			return p.element(expr.alts[0][0])
+		}
+		if set.IsEmpty() {
+			antlrFail(ErrW3CSyntax, "exception excludes every character", expr.line, expr.position)
+		}
+		elem.terminal = OfTerminalRuneSet(rangeSource(set), set)
+	}
+
+	return elem
+}
+
+// charSet returns the characters an expression matches and true, if it matches exactly one character of a set.
+// References are followed through rules that are alternatives of single characters, where visiting guards against cycles.
+func (p *w3cParser) charSet(expr w3cExpr, visiting map[string]bool) (RuneSet, bool) {
+	if (expr.n != 1) || (expr.m != 1) {
+		return RuneSet{}, false
+	}
+
+	var alts [][]w3cExpr
+
+	switch expr.kind {
+	case w3cLiteral:
+		if runes := []rune(expr.text); len(runes) == 1 {
+			return OfRuneSetRunes(runes[0]), true
+		}
+		return RuneSet{}, false
+	case w3cSet:
+		return expr.charSet, true
+	case w3cExcept:
+		left, isSet := p.charSet(expr.alts[0][0], visiting)
+		if !isSet {
+			return RuneSet{}, false
+		}
+		right, isSet := p.charSet(expr.except[0], visiting)
+		if !isSet {
+			return RuneSet{}, false
+		}
+
+		return left.Subtract(right), true
+	case w3cRef:
+		rule, haveIt := p.rules[expr.text]
+		if !haveIt || visiting[expr.text] {
+			return RuneSet{}, false
+		}
+		visiting[expr.text] = true
+		defer delete(visiting, expr.text)
+		alts = rule.alts
+	default:
+		alts = expr.alts
+	}
+
+	var set RuneSet
+	for _, alt := range alts {
+		if len(alt) != 1 {
+			return RuneSet{}, false
+		}
+
+		altSet, isSet := p.charSet(alt[0], visiting)
+		if !isSet {
+			return RuneSet{}, false
+		}
+		set = set.Union(altSet)
+	}
+
+	return set, true
+}
@@ -0,0 +1,68 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportW3CEBNF(t *testing.T) {
+	g, err := ImportW3CEBNF(strings.NewReader(`
+/* Lists of names */
+[1]  list     ::= item ( ',' item )* [ wfc: Unique ]
+[2]  item     ::= Name | "#" #x41 | Quoted
+[3]  Name     ::= NameChar+
+[3a] NameChar ::= [a-c] | [#x78-#x7A]
+[4]  Quoted   ::= '"' (NameChar - 'b')? '"'
+[5]  Other    ::= (Name - 'abc') | ( [a-c] - [^b] )
+`))
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		`list = item list-part
+list-part = (',' item)*
+item = Name | '#' [A] | Quoted
+Name = (NameChar)+
+NameChar = [a-c] | [x-z]
+Quoted = '"' Quoted-part '"'
+Quoted-part = ([acx-z])?
+Other = Name | [b]`,
+		g.String(),
+	)
+
+	// Positions of rules are kept for diagnostics
+	assert.Equal(t, 3, g.rules[0].Line())
+	assert.Equal(t, 6, g.rules[0].Position())
+
+	// A negated class excludes characters from the Basic Multilingual Plane
+	g, err = ImportW3CEBNF(strings.NewReader(`Char ::= [^<&#x22]`))
+	assert.Nil(t, err)
+	chars := g.rules[0].expr.items[0].list[0].terminal.RuneSet()
+	assert.True(t, chars.Contains('a'))
+	assert.True(t, chars.Contains(0xFFFD))
+	assert.False(t, chars.Contains('<'))
+	assert.False(t, chars.Contains('"'))
+	assert.False(t, chars.Contains(0xD800))
+	assert.False(t, chars.Contains(0x10000))
+}
+
+func TestImportW3CEBNFErrors(t *testing.T) {
+	for source, msg := range map[string]string{
+		"a ::= 'x":          fmt.Sprintf("%s unterminated string at line 1 position 7", ErrW3CSyntax),
+		"a ::= ''":          fmt.Sprintf("%s empty string at line 1 position 7", ErrW3CSyntax),
+		"a ::= [x":          fmt.Sprintf("%s unterminated character class at line 1 position 7", ErrW3CSyntax),
+		"a ::= 'x' /* x":    fmt.Sprintf("%s unterminated comment at line 1 position 11", ErrW3CSyntax),
+		"a ::= #xZ":         fmt.Sprintf("%s invalid character at line 1 position 7", ErrW3CSyntax),
+		"a ::= 'x' ; ":      fmt.Sprintf("%s \";\" at line 1 position 11", ErrW3CSyntax),
+		"a ::= ( 'x' ":      fmt.Sprintf("%s expected ')', found EOF at line 1 position 13", ErrW3CSyntax),
+		"a ::= ":            fmt.Sprintf("%s expected an expression, found EOF at line 1 position 7", ErrW3CSyntax),
+		"a ::= 'x' | ":      fmt.Sprintf("%s expected an expression, found EOF at line 1 position 13", ErrW3CSyntax),
+		"'x'":               fmt.Sprintf("%s expected a production, found 'x' at line 1 position 1", ErrW3CSyntax),
+		"a ::= [a] - [a-b]": fmt.Sprintf("%s exception excludes every character at line 1 position 11", ErrW3CSyntax),
+	} {
+		_, err := ImportW3CEBNF(strings.NewReader(source))
+		assert.Equal(t, msg, err.Error(), source)
+	}
+}
@@ -0,0 +1,96 @@
+package parser
+
+// XRefSite is where a rule refers to a rule or uses a terminal
+type XRefSite struct {
+	// Rule is the name of the rule the site is in, and Alternative the index of the alternative of the rule
+	Rule        string
+	Alternative int
+	// Line and Position are where the site begins in the grammar source, which are those of the rule when the dialect
+	// does not record where list items begin, or 0 if the grammar was not read from source
+	Line     int
+	Position int
+}
+
+// RuleXRef is the sites that refer to a rule
+type RuleXRef struct {
+	// Name is the name of the rule, and Defined is true if the grammar defines it
+	Name    string
+	Defined bool
+	// Line and Position are where the rule is defined in the grammar source, or 0 if it is not defined or not read from
+	// source
+	Line     int
+	Position int
+	// References are the sites that refer to the rule, in grammar order
+	References []XRefSite
+}
+
+// TerminalXRef is the sites that use a terminal
+type TerminalXRef struct {
+	// Terminal is the terminal in canonical source form, such as 'a' or [a-z]
+	Terminal string
+	// Rules are the names of the rules that use the terminal, in grammar order
+	Rules []string
+	// Sites are the sites that use the terminal, in grammar order
+	Sites []XRefSite
+}
+
+// XRef is a cross-reference index of a grammar, for reviewing a large grammar and finding what a change affects
+type XRef struct {
+	// Rules are the references to each rule defined by the grammar, in grammar order, followed by each rule referred to
+	// that is not defined, in order of first reference
+	Rules []RuleXRef
+	// Terminals are the uses of each different terminal, in order of first use
+	Terminals []TerminalXRef
+}
+
+// XRef returns the cross-reference index of the grammar, mapping every rule to the sites that refer to it, and every
+// terminal to the rules and sites that use it
+func (g Grammar) XRef() XRef {
+	var (
+		result    XRef
+		rules     = map[string]int{}
+		terminals = map[string]int{}
+	)
+	for i, rule := range g.rules {
+		rules[rule.name] = i
+		result.Rules = append(result.Rules, RuleXRef{Name: rule.name, Defined: true, Line: rule.Line(), Position: rule.Position()})
+	}
+
+	for _, rule := range g.rules {
+		for a, alt := range rule.expr.items {
+			for _, item := range alt.list {
+				site := XRefSite{Rule: rule.name, Alternative: a, Line: item.Line(), Position: item.Position()}
+				if site.Line == 0 {
+					site.Line, site.Position = rule.Line(), rule.Position()
+				}
+
+				if item.IsRuleName() {
+					index, haveIt := rules[item.ruleName]
+					if !haveIt {
+						index = len(result.Rules)
+						rules[item.ruleName] = index
+						result.Rules = append(result.Rules, RuleXRef{Name: item.ruleName})
+					}
+					result.Rules[index].References = append(result.Rules[index].References, site)
+					continue
+				}
+
+				format := item.terminal.Format()
+				index, haveIt := terminals[format]
+				if !haveIt {
+					index = len(result.Terminals)
+					terminals[format] = index
+					result.Terminals = append(result.Terminals, TerminalXRef{Terminal: format})
+				}
+
+				uses := &result.Terminals[index]
+				if (len(uses.Rules) == 0) || (uses.Rules[len(uses.Rules)-1] != rule.name) {
+					uses.Rules = append(uses.Rules, rule.name)
+				}
+				uses.Sites = append(uses.Sites, site)
+			}
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,54 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestXRef(t *testing.T) {
+	xref := testGrammar(
+		"sum = term '+' sum | term",
+		"term = number | name | '<' sum '>'",
+		"number = ([123])+",
+		"name = [abc] suffix",
+	).XRef()
+
+	assert.Equal(
+		t,
+		[]RuleXRef{
+			{Name: "sum", Defined: true, References: []XRefSite{{Rule: "sum", Alternative: 0}, {Rule: "term", Alternative: 2}}},
+			{Name: "term", Defined: true, References: []XRefSite{{Rule: "sum", Alternative: 0}, {Rule: "sum", Alternative: 1}}},
+			{Name: "number", Defined: true, References: []XRefSite{{Rule: "term", Alternative: 0}}},
+			{Name: "name", Defined: true, References: []XRefSite{{Rule: "term", Alternative: 1}}},
+			{Name: "suffix", References: []XRefSite{{Rule: "name", Alternative: 0}}},
+		},
+		xref.Rules,
+	)
+
+	var terminals []string
+	for _, uses := range xref.Terminals {
+		terminals = append(terminals, uses.Terminal+" "+strings.Join(uses.Rules, " "))
+	}
+	assert.Equal(t, []string{"'+' sum", "'<' term", "'>' term", "[1-3] number", "[a-c] name"}, terminals)
+
+	// The sites of an ANTLR grammar are where each list item begins
+	g, err := ImportANTLR(strings.NewReader("grammar G;\nlist : item (',' item)* ;\nitem : 'x' | 'y' ',' ;\n"))
+	assert.Nil(t, err)
+
+	xref = g.XRef()
+	for _, uses := range xref.Rules {
+		if uses.Name == "item" {
+			assert.Equal(t, []int{3, 1}, []int{uses.Line, uses.Position})
+			assert.Equal(t, 2, len(uses.References))
+			assert.Equal(t, []int{2, 8}, []int{uses.References[0].Line, uses.References[0].Position})
+		}
+	}
+	for _, uses := range xref.Terminals {
+		if uses.Terminal == "','" {
+			assert.Equal(t, []string{"item"}, uses.Rules[len(uses.Rules)-1:])
+			assert.Equal(t, []int{3, 18}, []int{uses.Sites[len(uses.Sites)-1].Line, uses.Sites[len(uses.Sites)-1].Position})
+		}
+	}
+}
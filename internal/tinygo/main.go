@@ -0,0 +1,47 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Command tinygo parses name=value; settings from stdin, and prints the parse tree as an S-expression.
+// It only uses the packages of goparse that are in the TinyGo subset, as a configuration parser of an embedded device
+// would, so that building it with TinyGo checks the subset compiles.
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bantling/goparse/api"
+)
+
+// grammar is the grammar of the settings, in ISO EBNF
+const grammar = `
+settings = { setting } ;
+setting = name, '=', value, ';' ;
+name = letter, { letter } ;
+value = digit, { digit } ;
+letter = 'a' | 'b' | 'c' | 'd' | 'e' | 'f' | 'g' | 'h' | 'i' | 'j' | 'k' | 'l' | 'm'
+       | 'n' | 'o' | 'p' | 'q' | 'r' | 's' | 't' | 'u' | 'v' | 'w' | 'x' | 'y' | 'z' ;
+digit = '0' | '1' | '2' | '3' | '4' | '5' | '6' | '7' | '8' | '9' ;
+`
+
+func main() {
+	g, err := api.Compile(strings.NewReader(grammar), api.CompileOptions{Dialect: api.DialectISO})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	engine, err := api.NewEngine(g, api.ParseOptions{})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	tree, err := engine.Parse(os.Stdin)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	fmt.Println(tree.SExpr(false))
+}
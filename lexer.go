@@ -1,188 +1,130 @@
 package goparse
 
 import (
-	"fmt"
 	"io"
-	"strings"
 
-	"github.com/bantling/goiter"
+	"github.com/bantling/goparse/internal/parser"
 )
 
-// Lexical token type
-type lexType uint
+// The Lexer is the one that ImportGoparse and Compile read grammars in goparse notation with, so that one lexer defines
+// the notation
+type (
+	// TokenType is the type of a lexical token
+	TokenType = parser.TokenType
+	// Token is a lexical token of a grammar
+	Token = parser.Token
+	// LexError describes a lexical error
+	LexError = parser.LexError
+	// LexOptions are the optional settings of a Lexer
+	LexOptions = parser.LexOptions
+	// Lexer reads the lexical tokens of a grammar
+	Lexer = parser.Lexer
+	// LexMark is a mark of a Lexer, which Reset rewinds to
+	LexMark = parser.LexMark
+	// EOLPolicy is how a Lexer reads EOL sequences
+	EOLPolicy = parser.EOLPolicy
+	// Universe is the runes an inverted range can match
+	Universe = parser.Universe
+	// LexTrace is an event of a lexer trace
+	LexTrace = parser.LexTrace
+	// LexTraceKind is the kind of a LexTrace event
+	LexTraceKind = parser.LexTraceKind
+)
 
+// TokenType constants
 const (
-	lexInvalid lexType = iota
-	lexEOF
-	lexCommentOneLine
-	lexCommentMultiLine
-	lexString
-	lexRange
-	lexN
-	lexM
-	lexZeroOrOne
-	lexZeroOrMore
-	lexOneOrMore
-	lexIdentifier
-	lexJoin
+	TokenInvalid          = parser.TokenInvalid
+	TokenEOF              = parser.TokenEOF
+	TokenCommentOneLine   = parser.TokenCommentOneLine
+	TokenCommentMultiLine = parser.TokenCommentMultiLine
+	TokenString           = parser.TokenString
+	TokenRange            = parser.TokenRange
+	TokenN                = parser.TokenN
+	TokenM                = parser.TokenM
+	TokenZeroOrOne        = parser.TokenZeroOrOne
+	TokenZeroOrMore       = parser.TokenZeroOrMore
+	TokenOneOrMore        = parser.TokenOneOrMore
+	TokenIdentifier       = parser.TokenIdentifier
+	TokenJoin             = parser.TokenJoin
+	TokenError            = parser.TokenError
+	TokenRepetition       = parser.TokenRepetition
+	TokenPunctuation      = parser.TokenPunctuation
+	TokenOption           = parser.TokenOption
 )
 
-// Lexical table actions
+// EOLPolicy constants
 const (
-	lexSkip    uint = 0x01
-	lexAdvance uint = 0x02
-	lexUnread  uint = 0x04
-	lexDone    uint = 0x08
-	lexEOFOK   uint = 0x10
-	lexError   uint = 0x20
+	EOLNormalize  = parser.EOLNormalize
+	EOLPreserve   = parser.EOLPreserve
+	EOLErrorMixed = parser.EOLErrorMixed
 )
 
-// The next table row to jump to and/or which actions to take
-type lexActions struct {
-	actions uint
-	row     uint
-	lexType lexType
-	errCode string
-}
+// Universe constants
+const (
+	UniverseUnicode = parser.UniverseUnicode
+	UniverseASCII   = parser.UniverseASCII
+)
 
-// Lexical errors
+// LexTraceKind constants
 const (
-	lexErrPosition   = " at line %d position %d"
-	lexErrSyntax     = "Syntax error"
-	lexErrSyntaxCode = "-1"
-	lexErrEOF        = "Invalid EOF"
-	lexErrEOFCode    = "-2"
+	LexTraceRune  = parser.LexTraceRune
+	LexTraceEOF   = parser.LexTraceEOF
+	LexTraceToken = parser.LexTraceToken
 )
 
-// LexError describes a lexical error
-type LexError struct {
-	err      string
-	code     string
-	line     int
-	position int
+// NewLexer constructs a Lexer of a reader
+func NewLexer(source io.Reader) *Lexer {
+	return parser.NewLexer(source)
+}
+
+// NewLexerWithOptions constructs a Lexer of a reader with options
+func NewLexerWithOptions(source io.Reader, opts LexOptions) *Lexer {
+	return parser.NewLexerWithOptions(source, opts)
+}
+
+// NewLexerFromString constructs a Lexer of a string, where tokens are slices of it unless an EOL sequence in them is
+// normalized
+func NewLexerFromString(source string) *Lexer {
+	return parser.NewLexerFromString(source)
+}
+
+// NewLexerFromStringWithOptions constructs a Lexer of a string with options
+func NewLexerFromStringWithOptions(source string, opts LexOptions) *Lexer {
+	return parser.NewLexerFromStringWithOptions(source, opts)
+}
+
+// NewLexerFromBytes constructs a Lexer of bytes, which are copied once into a string that tokens are slices of
+func NewLexerFromBytes(source []byte) *Lexer {
+	return parser.NewLexerFromBytes(source)
 }
 
-// Panic with a LexError
-func panicLexError(msg string, code string, line, position int) {
-	panic(
-		LexError{
-			err:      fmt.Sprintf("%s%s", msg, fmt.Sprintf(lexErrPosition, line, position)),
-			code:     code,
-			line:     line,
-			position: position,
-		},
-	)
+// NewLexerFromBytesWithOptions constructs a Lexer of bytes with options
+func NewLexerFromBytesWithOptions(source []byte, opts LexOptions) *Lexer {
+	return parser.NewLexerFromBytesWithOptions(source, opts)
 }
 
-// Error is error interface
-func (l LexError) Error() string {
-	return l.err
+// NewLexerFromRuneReader constructs a Lexer of runes that are already decoded
+func NewLexerFromRuneReader(source io.RuneReader) *Lexer {
+	return parser.NewLexerFromRuneReader(source)
 }
 
-// Lexical token to return to parser
-type lexicalToken struct {
-	lexType  lexType
-	token    string
-	line     int
-	position int
+// NewLexerFromRuneReaderWithOptions constructs a Lexer of runes that are already decoded with options
+func NewLexerFromRuneReaderWithOptions(source io.RuneReader, opts LexOptions) *Lexer {
+	return parser.NewLexerFromRuneReaderWithOptions(source, opts)
 }
 
-// Lexical analyzer
-type lexer struct {
-	iter *goiter.RunePositionIter
+// LexTraceWriter returns a LexOptions.Trace function that writes each event to w on its own line
+func LexTraceWriter(w io.Writer) func(LexTrace) {
+	return parser.LexTraceWriter(w)
 }
 
-// Construct lexer
-func newLexer(source io.Reader) *lexer {
-	return &lexer{
-		iter: goiter.NewRunePositionIter(source),
-	}
+// CheckLexerPositions lexes the source, and returns an error if a token does not begin after the previous token
+func CheckLexerPositions(source string) error {
+	return parser.CheckLexerPositions(source)
 }
 
-// Read next lexical token
-func (l *lexer) next() lexicalToken {
-	var (
-		nextChar rune
-		token    strings.Builder
-		// line and position where token started
-		line     = 1
-		position = 1
-		row      = lexTable[0]
-		// initial actions in case we read EOF on first call to iter.Next
-		theLexActions = lexActions{actions: lexSkip | lexEOFOK, lexType: lexEOF}
-		haveActions   bool
-		eofOK         bool
-		writeChar     bool
-	)
-
-	for {
-		haveActions = false
-		if l.iter.Next() {
-			nextChar = l.iter.Value()
-
-			// get actions for char if they exist
-			theLexActions, haveActions = row[nextChar]
-			if !haveActions {
-				// get default actions, if they exist
-				theLexActions, haveActions = row[-1]
-			}
-			if !haveActions {
-				// panic at current line and position, not where token started
-				panicLexError(lexErrSyntax, lexErrSyntaxCode, l.iter.Line(), l.iter.Position()-1)
-			}
-		} else {
-			if eofOK = (theLexActions.actions & lexEOFOK) > 0; !eofOK {
-				// panic at current line and position, not where token started
-				panicLexError(lexErrEOF, lexErrEOFCode, l.iter.Line(), l.iter.Position()-1)
-			}
-			break
-		}
-
-		writeChar = true
-
-		// A char to be skipped is a delimiter at the beginning or end of a token
-		if (theLexActions.actions & lexSkip) > 0 {
-			writeChar = false
-		}
-
-		// Advance the position, this character is not part of a token
-		if (theLexActions.actions & lexAdvance) > 0 {
-			line = l.iter.Line()
-			position = l.iter.Position()
-		}
-
-		// either the char is unread because it belongs to next token, or we write it as part of this token
-		if (theLexActions.actions & lexUnread) > 0 {
-			l.iter.Unread(nextChar)
-			writeChar = false
-		}
-
-		if writeChar {
-			token.WriteRune(nextChar)
-		}
-
-		if (theLexActions.actions & lexError) > 0 {
-			panicLexError(lexErrors[theLexActions.errCode], theLexActions.errCode, l.iter.Line(), l.iter.Position()-1)
-		}
-
-		if (theLexActions.actions & lexDone) > 0 {
-			break
-		}
-
-		// jump to next row (which could be same row)
-		row = lexTable[theLexActions.row]
-	}
-
-	// cannot not encounter EOF in the middle of a token unless allowed
-	if (theLexActions.lexType == lexEOF) && (!eofOK) {
-		panicLexError(lexErrEOF, lexErrEOFCode, l.iter.Line(), l.iter.Position())
-	}
-
-	// have a valid token
-	return lexicalToken{
-		lexType:  theLexActions.lexType,
-		token:    token.String(),
-		line:     line,
-		position: position,
-	}
+// CheckLexerLossless lexes the source, and returns an error if the tokens and the whitespace between them do not
+// reconstruct the source
+func CheckLexerLossless(source string) error {
+	return parser.CheckLexerLossless(source)
 }